@@ -21,13 +21,36 @@ type Config struct {
 	ConnMaxLifetime int    `envconfig:"DB_CONN_MAX_LIFETIME" default:"10"`
 	IsDebugMode     bool   `envconfig:"DEBUG_MODE" default:"true"`
 
+	// how long the data source of an object is cached before it is resolved again, in seconds
+	DataSourceCacheTTL int64 `envconfig:"DATA_SOURCE_CACHE_TTL" default:"60"`
+
 	RedisHost     string `envconfig:"REDIS_HOST" default:"127.0.0.1"`
 	RedisPort     string `envconfig:"REDIS_PORT" default:"6379"`
 	RedisPassword string `envconfig:"REDIS_PASSWORD" default:""`
 	RedisMaxIdle  int    `envconfig:"REDIS_MAX_IDLE" default:"10"`
 	DefaultTTL    int64  `envconfig:"DEFAULT_TTL" default:"3600"`
 
-	InternalSecretKey string `envconfig:"INTERNAL_SECRET_KEY" default:"INTERNAL_SECRET_KEY"`
+	// guards the admin endpoints, the service does not start without it
+	InternalSecretKey string `envconfig:"INTERNAL_SECRET_KEY" default:""`
+
+	BulkMaxOperations int `envconfig:"BULK_MAX_OPERATIONS" default:"1000"`
+	ImportBatchSize   int `envconfig:"IMPORT_BATCH_SIZE" default:"500"`
+	ImportMaxErrors   int `envconfig:"IMPORT_MAX_ERRORS" default:"1000"`
+	ExportBatchSize   int `envconfig:"EXPORT_BATCH_SIZE" default:"1000"`
+
+	// the export job files are downloaded from any instance, the database driver shares them through the metadata
+	// database, the local driver only when the storage path is a volume shared by every instance
+	ExportStorage     string `envconfig:"EXPORT_STORAGE" default:"database"`
+	ExportStoragePath string `envconfig:"EXPORT_STORAGE_PATH" default:"./storage/exports"`
+	ExportJobWorkers  int    `envconfig:"EXPORT_JOB_WORKERS" default:"2"`
+	ExportJobTTL      int64  `envconfig:"EXPORT_JOB_TTL" default:"86400"`
+
+	// the archives of the offboarded tenants, kept apart from the exports which are purged
+	ArchiveStoragePath string `envconfig:"ARCHIVE_STORAGE_PATH" default:"./storage/archives"`
+
+	// the soft deleted records are purged once older than the retention, a retention of 0 keeps them
+	TrashRetentionDays int   `envconfig:"TRASH_RETENTION_DAYS" default:"30"`
+	TrashPurgeInterval int64 `envconfig:"TRASH_PURGE_INTERVAL" default:"3600"`
 }
 
 func Get() Config {
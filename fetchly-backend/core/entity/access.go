@@ -0,0 +1,92 @@
+package entity
+
+import "errors"
+
+type PermissionAction string
+
+const (
+	PermissionCreate PermissionAction = "create"
+	PermissionRead   PermissionAction = "read"
+	PermissionUpdate PermissionAction = "update"
+	PermissionDelete PermissionAction = "delete"
+	PermissionExport PermissionAction = "export"
+)
+
+var ErrorForbidden = errors.New("forbidden")
+
+type Role struct {
+	Serial      string `json:"serial"`
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	IsAdmin     bool   `json:"is_admin"`
+}
+
+// RoleObjectPermission is the set of actions a role is granted on an object
+type RoleObjectPermission struct {
+	RoleSerial   string `json:"role_serial"`
+	ObjectSerial string `json:"object_serial"`
+	CanCreate    bool   `json:"can_create"`
+	CanRead      bool   `json:"can_read"`
+	CanUpdate    bool   `json:"can_update"`
+	CanDelete    bool   `json:"can_delete"`
+	CanExport    bool   `json:"can_export"`
+}
+
+// RoleFieldPermission narrows the object permission of a role on a single field
+type RoleFieldPermission struct {
+	RoleSerial        string `json:"role_serial"`
+	ObjectFieldSerial string `json:"object_field_serial"`
+	FieldCode         string `json:"field_code"`
+	CanRead           bool   `json:"can_read"`
+	CanWrite          bool   `json:"can_write"`
+}
+
+// ObjectPermission is the effective permission of a user on an object, merged from all of the user's roles
+type ObjectPermission struct {
+	IsRestricted   bool            `json:"is_restricted"`
+	IsAdmin        bool            `json:"is_admin"`
+	CanCreate      bool            `json:"can_create"`
+	CanRead        bool            `json:"can_read"`
+	CanUpdate      bool            `json:"can_update"`
+	CanDelete      bool            `json:"can_delete"`
+	CanExport      bool            `json:"can_export"`
+	HiddenFields   map[string]bool `json:"hidden_fields"`
+	ReadOnlyFields map[string]bool `json:"read_only_fields"`
+}
+
+func (p ObjectPermission) Can(action PermissionAction) bool {
+	switch action {
+	case PermissionCreate:
+		return p.CanCreate
+	case PermissionRead:
+		return p.CanRead
+	case PermissionUpdate:
+		return p.CanUpdate
+	case PermissionDelete:
+		return p.CanDelete
+	case PermissionExport:
+		return p.CanExport
+	}
+
+	return false
+}
+
+// RowPolicy restricts the records of an object a user can read, update and delete. A policy without a
+// role applies to every user, otherwise only to the users holding the role. Filter values may reference
+// the request user, e.g. "{{user.branch_serial}}" or "{{claims.sub}}".
+type RowPolicy struct {
+	Serial       string        `json:"serial"`
+	ObjectSerial string        `json:"object_serial"`
+	RoleSerial   string        `json:"role_serial"`
+	Code         string        `json:"code"`
+	Name         string        `json:"name"`
+	Filters      []FilterGroup `json:"filters"`
+}
+
+const (
+	// RowPolicyUserReference resolves to an attribute of the user record of the request user
+	RowPolicyUserReference = "user"
+	// RowPolicyClaimReference resolves to a claim of the access token of the request user
+	RowPolicyClaimReference = "claims"
+)
@@ -0,0 +1,65 @@
+package entity
+
+type AggregateFunction string
+
+const (
+	AggregateFunctionCount         AggregateFunction = "count"
+	AggregateFunctionCountDistinct AggregateFunction = "count_distinct"
+	AggregateFunctionSum           AggregateFunction = "sum"
+	AggregateFunctionAvg           AggregateFunction = "avg"
+	AggregateFunctionMin           AggregateFunction = "min"
+	AggregateFunctionMax           AggregateFunction = "max"
+)
+
+// DateBucket truncates a date or timestamp group to the start of its day, week (monday) or month
+type DateBucket string
+
+const (
+	DateBucketDay   DateBucket = "day"
+	DateBucketWeek  DateBucket = "week"
+	DateBucketMonth DateBucket = "month"
+)
+
+// AggregateMetric is a computed value of every group. FieldName may be empty for count, which then counts
+// the records. Alias names the metric in the rows and series, it defaults to <function>_<field_name>.
+type AggregateMetric struct {
+	Function  AggregateFunction `json:"function"`
+	FieldName string            `json:"field_name"`
+	Alias     string            `json:"alias"`
+}
+
+// AggregateGroup groups the records by a field, a joined field is written as a__b__c like in the filters.
+// Alias defaults to the field name.
+type AggregateGroup struct {
+	FieldName string     `json:"field_name"`
+	Bucket    DateBucket `json:"bucket"`
+	Alias     string     `json:"alias"`
+}
+
+// AggregateQuery groups the records matching Filters by Groups and computes Metrics for every group. Orders
+// refer to the aliases of the groups and metrics.
+type AggregateQuery struct {
+	Filters     []FilterGroup     `json:"filters"`
+	Groups      []AggregateGroup  `json:"group_by"`
+	Metrics     []AggregateMetric `json:"metrics"`
+	Orders      []Order           `json:"orders"`
+	Limit       int               `json:"limit"`
+	ObjectCode  string            `json:"object_code"`
+	TenantCode  string            `json:"tenant_code"`
+	ProductCode string            `json:"product_code"`
+}
+
+type AggregateSeries struct {
+	Name string `json:"name"`
+	Data []any  `json:"data"`
+}
+
+// AggregateResponse is shaped for the chart config. XAxis holds the values of the first group, every metric
+// is a series, and with a second group every value of that group becomes a series of its own. Rows keeps the
+// flat result keyed by alias.
+type AggregateResponse struct {
+	XAxis  []any             `json:"x_axis"`
+	YAxis  []string          `json:"y_axis"`
+	Series []AggregateSeries `json:"series"`
+	Rows   []map[string]any  `json:"rows"`
+}
@@ -0,0 +1,72 @@
+package entity
+
+import (
+	"context"
+	"time"
+)
+
+type AuditAction string
+
+const (
+	AuditActionCreate  AuditAction = "create"
+	AuditActionUpdate  AuditAction = "update"
+	AuditActionDelete  AuditAction = "delete"
+	AuditActionRestore AuditAction = "restore"
+)
+
+// RequestIDHeader carries the ID of a request, the one given by the caller or a generated one
+const RequestIDHeader = "X-Request-ID"
+
+// AuditLog is a change of a record, Changes lists its changed fields. A created record lists all of its fields
+// with their new value.
+type AuditLog struct {
+	Serial       string        `json:"serial"`
+	TenantCode   string        `json:"tenant_code"`
+	ObjectCode   string        `json:"object_code"`
+	RecordSerial string        `json:"record_serial"`
+	Action       AuditAction   `json:"action"`
+	UserSerial   string        `json:"user_serial"`
+	RequestID    string        `json:"request_id"`
+	Changes      []FieldChange `json:"changes"`
+	CreatedAt    time.Time     `json:"created_at"`
+}
+
+type FieldChange struct {
+	FieldCode string `json:"field_code"`
+	OldValue  any    `json:"old_value"`
+	NewValue  any    `json:"new_value"`
+}
+
+// AuditQuery filters the audit logs of a tenant, the empty filters match every log. The logs are listed from the
+// latest.
+type AuditQuery struct {
+	TenantCode   string      `json:"tenant_code"`
+	ObjectCode   string      `json:"object_code"`
+	RecordSerial string      `json:"record_serial"`
+	UserSerial   string      `json:"user_serial"`
+	RequestID    string      `json:"request_id"`
+	Action       AuditAction `json:"action"`
+	From         *time.Time  `json:"from"`
+	To           *time.Time  `json:"to"`
+	Page         int         `json:"page"`
+	PageSize     int         `json:"page_size"`
+}
+
+type AuditLogResponse struct {
+	Page      int        `json:"page"`
+	PageSize  int        `json:"page_size"`
+	TotalData int        `json:"total_data"`
+	TotalPage int        `json:"total_page"`
+	Items     []AuditLog `json:"items"`
+}
+
+type requestIDContextKey struct{}
+
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, requestID)
+}
+
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDContextKey{}).(string)
+	return requestID
+}
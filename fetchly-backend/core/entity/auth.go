@@ -1,7 +1,9 @@
 package entity
 
 import (
+	"context"
 	"errors"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
@@ -12,8 +14,19 @@ const (
 	Pbkdf2Iterations        = 100_000
 	KeySize                 = 32 // 32 bytes = 256 bits
 	SaltSize                = 16
-	IvSize                  = 12 // for AES-GCM
-	DefaultTokenExpiredHour = 24 // 1 day
+	IvSize                  = 12      // for AES-GCM
+	DefaultTokenExpiredHour = 24      // 1 day
+	RefreshTokenExpiredHour = 24 * 30 // 30 days
+	RefreshTokenSize        = 32
+	DefaultUserSerial       = "system" // the user of mutations made without an authenticated user
+)
+
+const (
+	SessionRevokedReasonLogout         = "logout"
+	SessionRevokedReasonAdmin          = "revoked_by_admin"
+	SessionRevokedReasonTokenReused    = "refresh_token_reused"
+	SessionRevokedReasonAllSessions    = "logout_all_sessions"
+	SessionRevokedReasonTenantArchived = "tenant_archived"
 )
 
 var (
@@ -22,6 +35,11 @@ var (
 	ErrInvalidPassword      = errors.New("invalid password")
 	ErrInvalidTenantSerial  = errors.New("invalid tenant serial")
 	ErrInvalidProductSerial = errors.New("invalid product serial")
+	ErrInvalidRefreshToken  = errors.New("invalid refresh token")
+	ErrRefreshTokenExpired  = errors.New("refresh token is expired")
+	ErrRefreshTokenReused   = errors.New("refresh token is already used, all tokens of the session are revoked")
+	ErrSessionNotFound      = errors.New("session not found")
+	ErrSessionRevoked       = errors.New("session is revoked")
 
 	JWT_SIGNING_METHOD = jwt.SigningMethodHS256
 )
@@ -42,6 +60,14 @@ type LoginRequest struct {
 	Password    string `json:"password"`
 	TenantCode  string `json:"tenant_code"`
 	ProductCode string `json:"product_code"`
+
+	Client SessionClient `json:"-"`
+}
+
+// SessionClient describes the client a session is opened from
+type SessionClient struct {
+	UserAgent string `json:"user_agent"`
+	IPAddress string `json:"ip_address"`
 }
 
 type RefreshTokenRequest struct {
@@ -75,3 +101,58 @@ type GoogleLoginResponse struct {
 type JWTUserData interface {
 	~map[string]any | ~map[string]DataItem
 }
+
+type LogoutRequest struct {
+	IsAllSessions bool `json:"is_all_sessions"`
+}
+
+// AuthSession is a login of a user, it lives as long as its refresh tokens keep being rotated
+type AuthSession struct {
+	Serial        string     `json:"serial"`
+	TenantCode    string     `json:"tenant_code"`
+	UserSerial    string     `json:"user_serial"`
+	UserAgent     string     `json:"user_agent"`
+	IPAddress     string     `json:"ip_address"`
+	CreatedAt     time.Time  `json:"created_at"`
+	LastUsedAt    time.Time  `json:"last_used_at"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+	RevokedReason string     `json:"revoked_reason,omitempty"`
+}
+
+func (s AuthSession) IsActive() bool {
+	return s.RevokedAt == nil && time.Now().Before(s.ExpiresAt)
+}
+
+// RefreshToken is a single use token of a session, only the sha256 hash of the token is persisted
+type RefreshToken struct {
+	Serial        string     `json:"serial"`
+	SessionSerial string     `json:"session_serial"`
+	TokenHash     string     `json:"-"`
+	CreatedAt     time.Time  `json:"created_at"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	UsedAt        *time.Time `json:"used_at,omitempty"`
+	ReplacedBy    string     `json:"replaced_by,omitempty"`
+
+	Session AuthSession `json:"session"`
+}
+
+// AuthUser is the authenticated user of a request, taken from the claims of its access token
+type AuthUser struct {
+	Serial        string         `json:"serial"`
+	TenantCode    string         `json:"tenant_code"`
+	SessionSerial string         `json:"session_serial"`
+	Data          map[string]any `json:"data"`
+	Claims        map[string]any `json:"-"`
+}
+
+type authUserContextKey struct{}
+
+func ContextWithAuthUser(ctx context.Context, user AuthUser) context.Context {
+	return context.WithValue(ctx, authUserContextKey{}, user)
+}
+
+func AuthUserFromContext(ctx context.Context) (AuthUser, bool) {
+	user, ok := ctx.Value(authUserContextKey{}).(AuthUser)
+	return user, ok
+}
@@ -8,6 +8,10 @@ type FilterOperator string
 const (
 	DEFAULT_IDENTIFIER = "serial"
 
+	// DisplayFieldSuffix selects the display value of the record a foreign key references as <field>__name, on
+	// its own it is the display value of the record itself
+	DisplayFieldSuffix = "__name"
+
 	PUBLIC             = "public"
 	PRODUCT_CODE       = "product_code"
 	TENANT_CODE        = "tenant_code"
@@ -17,6 +21,7 @@ const (
 
 	FilterOperatorAnd FilterGroupOperator = "AND"
 	FilterOperatorOr  FilterGroupOperator = "OR"
+	FilterOperatorNot FilterGroupOperator = "NOT"
 
 	FilterOperatorEqual            FilterOperator = "equal"
 	FilterOperatorNotEqual         FilterOperator = "not_equal"
@@ -27,6 +32,34 @@ const (
 	FilterOperatorLessThan         FilterOperator = "less_than"
 	FilterOperatorLessThanEqual    FilterOperator = "less_than_equal"
 	FilterOperatorIN               FilterOperator = "in"
+	FilterOperatorNotIN            FilterOperator = "not_in"
+	FilterOperatorBetween          FilterOperator = "between"
+	FilterOperatorIsNull           FilterOperator = "is_null"
+	FilterOperatorIsNotNull        FilterOperator = "is_not_null"
+	FilterOperatorStartsWith       FilterOperator = "starts_with"
+	FilterOperatorEndsWith         FilterOperator = "ends_with"
+
+	// FilterOperatorContainsCaseSensitive is contains matching the letter case, contains ignores it
+	FilterOperatorContainsCaseSensitive FilterOperator = "contains_case_sensitive"
+
+	// relative date operators, the ranges start at midnight of the server time zone and weeks start on monday.
+	// last_n_days and next_n_days take the number of days as value, the others take no value.
+	FilterOperatorToday       FilterOperator = "today"
+	FilterOperatorBeforeToday FilterOperator = "before_today"
+	FilterOperatorAfterToday  FilterOperator = "after_today"
+	FilterOperatorLastNDays   FilterOperator = "last_n_days"
+	FilterOperatorNextNDays   FilterOperator = "next_n_days"
+	FilterOperatorThisWeek    FilterOperator = "this_week"
+	FilterOperatorThisMonth   FilterOperator = "this_month"
+	FilterOperatorThisYear    FilterOperator = "this_year"
+
+	// json operators of json and jsonb columns. json_contains matches a json document contained in the column,
+	// array_contains a value or list of values contained in an array column, json_has_key a top level key and
+	// json_path_exists a json path such as $.address.city.
+	FilterOperatorJSONContains   FilterOperator = "json_contains"
+	FilterOperatorArrayContains  FilterOperator = "array_contains"
+	FilterOperatorJSONHasKey     FilterOperator = "json_has_key"
+	FilterOperatorJSONPathExists FilterOperator = "json_path_exists"
 
 	FieldColumnName            = "field_name"
 	FieldDataType              = "data_type"
@@ -35,6 +68,7 @@ const (
 	FieldForeignColumnName     = "foreign_field_name"
 	FieldCompleteColumnCode    = "complete_field_code"
 	FieldOriginalFieldCode     = "original_field_code"
+	FieldSelectExpression      = "select_expression"
 	ForeignTable               = "foreign_table"
 	ForeignReferenceColumnName = "foreign_reference_column_name"
 	FieldIsDisplayedInTable    = "is_displayed_in_table"
@@ -53,6 +87,9 @@ var (
 		FilterOperatorLessThan:         "<",
 		FilterOperatorLessThanEqual:    "<=",
 		FilterOperatorIN:               "IN",
+		FilterOperatorNotIN:            "NOT IN",
+		FilterOperatorStartsWith:       "ILIKE",
+		FilterOperatorEndsWith:         "ILIKE",
 	}
 
 	OperatorLIKEList = []FilterOperator{
@@ -61,6 +98,18 @@ var (
 	}
 )
 
+// IsValueless reports whether the operator is applied without a value
+func (o FilterOperator) IsValueless() bool {
+	switch o {
+	case FilterOperatorIsNull, FilterOperatorIsNotNull,
+		FilterOperatorToday, FilterOperatorBeforeToday, FilterOperatorAfterToday,
+		FilterOperatorThisWeek, FilterOperatorThisMonth, FilterOperatorThisYear:
+		return true
+	}
+
+	return false
+}
+
 type Tenants struct {
 	ID           int32          `json:"id"`
 	Serial       string         `json:"serial"`
@@ -102,15 +151,16 @@ type Products struct {
 }
 
 type Objects struct {
-	ID          int        `json:"id"`
-	Serial      string     `json:"serial"`
-	Tenant      Tenants    `json:"tenant"`
-	Module      Modules    `json:"module"`
-	Code        string     `json:"code"`
-	DisplayName string     `json:"display_name"`
-	Description string     `json:"description"`
-	ObjectType  string     `json:"object_type"`
-	DataSource  DataSource `json:"data_source"`
+	ID              int        `json:"id"`
+	Serial          string     `json:"serial"`
+	Tenant          Tenants    `json:"tenant"`
+	Module          Modules    `json:"module"`
+	Code            string     `json:"code"`
+	DisplayName     string     `json:"display_name"`
+	DisplayTemplate string     `json:"display_template"`
+	Description     string     `json:"description"`
+	ObjectType      string     `json:"object_type"`
+	DataSource      DataSource `json:"data_source"`
 }
 
 type ObjectFields struct {
@@ -128,6 +178,7 @@ type ObjectFields struct {
 	TargetObjectField map[string]any `json:"target_object_field"`
 	Relation          string         `json:"relation"`
 	IsSystem          bool           `json:"is_system"`
+	IsSearchable      bool           `json:"is_searchable"`
 	DefaultValue      string         `json:"default_value"`
 }
 
@@ -151,9 +202,17 @@ type FilterItem struct {
 	Value     any            `json:"value"`
 }
 
+// FilterGroup is a node of a filter tree. Its conditions are the items of Filters keyed by field name, the items
+// of Conditions, which may repeat a field, and the child Groups, combined with Operator: AND by default, OR, or
+// NOT which negates the AND of them. The groups of a query are combined with AND.
+//
+//	{"operator": "OR", "conditions": [{"field_name": "status", "operator": "equal", "value": "open"}],
+//	 "groups": [{"operator": "NOT", "filter_item": {"owner": {"operator": "is_null"}}}]}
 type FilterGroup struct {
-	Operator helper.FlexibleOperator[FilterOperator, FilterGroupOperator] `json:"operator"`
-	Filters  map[string]FilterItem                                        `json:"filter_item"`
+	Operator   helper.FlexibleOperator[FilterOperator, FilterGroupOperator] `json:"operator"`
+	Filters    map[string]FilterItem                                        `json:"filter_item"`
+	Conditions []FilterItem                                                 `json:"conditions,omitempty"`
+	Groups     []FilterGroup                                                `json:"groups,omitempty"`
 }
 
 // In your entity package maybe
@@ -178,12 +237,35 @@ type Field struct {
 	RenderConfig       string `json:"render_config"`
 }
 
+// PaginationMode selects how GetObjectData pages through the records. Offset pages by Page, cursor pages
+// from the Cursor returned with the previous page, keyed on the Orders with serial as the tiebreaker.
+type PaginationMode string
+
+const (
+	PaginationModeOffset PaginationMode = "offset"
+	PaginationModeCursor PaginationMode = "cursor"
+)
+
+// CountMode selects how the total of the records is counted. Estimated reads the planner statistics of the
+// table when no filter is applied and falls back to an exact count otherwise.
+type CountMode string
+
+const (
+	CountModeExact     CountMode = "exact"
+	CountModeEstimated CountMode = "estimated"
+	CountModeNone      CountMode = "none"
+)
+
 type CatalogQuery struct {
 	Fields          map[string]Field `json:"fields"`
 	Filters         []FilterGroup    `json:"filters"`
 	Orders          []Order          `json:"orders"`
 	Page            int              `json:"page"`
 	PageSize        int              `json:"page_size"`
+	PaginationMode  PaginationMode   `json:"pagination_mode"`
+	Cursor          string           `json:"cursor"`
+	CountMode       CountMode        `json:"count_mode"`
+	Search          string           `json:"search"`
 	Serial          string           `json:"serial"`
 	ObjectCode      string           `json:"object_code"`
 	ObjectSerial    string           `json:"object_serial"`
@@ -194,6 +276,17 @@ type CatalogQuery struct {
 	RawQuery        string           `json:"raw_query"`
 	ViewContentCode string           `json:"view_content_code"`
 	IsForLayout     bool             `json:"is_for_layout"`
+
+	// Expand loads the to many relations of the records by their field code
+	Expand map[string]ExpandQuery `json:"expand"`
+
+	// IsIncludeDeleted adds the soft deleted records to the live ones, IsOnlyDeleted lists the soft deleted records
+	// alone, the trash of the object
+	IsIncludeDeleted bool `json:"include_deleted"`
+	IsOnlyDeleted    bool `json:"only_deleted"`
+
+	// ExcludedSearchFields are searchable fields left out of Search, the fields the user can not read
+	ExcludedSearchFields []string `json:"-"`
 }
 
 type DataItem struct {
@@ -207,11 +300,14 @@ type DataItem struct {
 }
 
 type CatalogResponse struct {
-	Page      int                   `json:"page"`
-	PageSize  int                   `json:"page_size"`
-	TotalData int                   `json:"total_data"`
-	TotalPage int                   `json:"total_page"`
-	Items     []map[string]DataItem `json:"items"`
+	Page             int                   `json:"page"`
+	PageSize         int                   `json:"page_size"`
+	TotalData        int                   `json:"total_data"`
+	TotalPage        int                   `json:"total_page"`
+	IsTotalEstimated bool                  `json:"is_total_estimated,omitempty"`
+	NextCursor       string                `json:"next_cursor,omitempty"`
+	PrevCursor       string                `json:"prev_cursor,omitempty"`
+	Items            []map[string]DataItem `json:"items"`
 }
 
 type DataMutationRequest struct {
@@ -221,6 +317,10 @@ type DataMutationRequest struct {
 	TenantCode  string     `json:"tenant_code"`
 	ProductCode string     `json:"product_code"`
 	UserSerial  string     `json:"user_serial"`
+	// Filters restrict the record being updated or deleted, they are set from the row policies and never bound from the request
+	Filters []FilterGroup `json:"-"`
+	// IsCascade deletes or restores the child records of the one to many relations along with the record
+	IsCascade bool `json:"is_cascade"`
 }
 
 type ForeignKeyInfo struct {
@@ -232,12 +332,108 @@ type ForeignKeyInfo struct {
 type ExportFormat string
 
 const (
-	ExportFormatXLSX ExportFormat = "xlsx"
-	ExportFormatCSV  ExportFormat = "csv"
+	ExportFormatXLSX   ExportFormat = "xlsx"
+	ExportFormatCSV    ExportFormat = "csv"
+	ExportFormatNDJSON ExportFormat = "ndjson"
 )
 
+var ExportContentTypes = map[ExportFormat]string{
+	ExportFormatXLSX:   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	ExportFormatCSV:    "text/csv",
+	ExportFormatNDJSON: "application/x-ndjson",
+}
+
+type ImportRequest struct {
+	Format      ExportFormat `json:"format"`
+	Mode        BulkMode     `json:"mode"`
+	IsDryRun    bool         `json:"is_dry_run"`
+	ObjectCode  string       `json:"object_code"`
+	TenantCode  string       `json:"tenant_code"`
+	ProductCode string       `json:"product_code"`
+	UserSerial  string       `json:"user_serial"`
+}
+
+type ImportHeaderMapping struct {
+	Header       string `json:"header"`
+	FieldCode    string `json:"field_code"`
+	IsForeignKey bool   `json:"is_foreign_key"`
+	ForeignTable string `json:"foreign_table,omitempty"`
+}
+
+type ImportRowError struct {
+	Row              int                               `json:"row"`
+	Error            string                            `json:"error"`
+	ValidationErrors map[string][]FieldValidationError `json:"validation_errors,omitempty"`
+}
+
+type ImportResponse struct {
+	Mode             BulkMode              `json:"mode"`
+	IsDryRun         bool                  `json:"is_dry_run"`
+	IsCommitted      bool                  `json:"is_committed"`
+	TotalRows        int                   `json:"total_rows"`
+	ValidRows        int                   `json:"valid_rows"`
+	InvalidRows      int                   `json:"invalid_rows"`
+	ImportedRows     int                   `json:"imported_rows"`
+	HeaderMapping    []ImportHeaderMapping `json:"header_mapping"`
+	UnmappedHeaders  []string              `json:"unmapped_headers"`
+	Errors           []ImportRowError      `json:"errors"`
+	IsErrorTruncated bool                  `json:"is_error_truncated"`
+}
+
 type ExportResponse struct {
 	Data        string `json:"data"`         // Base64 encoded data
 	ContentType string `json:"content_type"` // MIME type
 	FileName    string `json:"file_name"`    // Suggested filename
 }
+
+type BulkMode string
+type BulkOperationType string
+type BulkOperationStatus string
+
+const (
+	BulkModeAllOrNothing BulkMode = "all_or_nothing"
+	BulkModeBestEffort   BulkMode = "best_effort"
+
+	BulkOperationCreate BulkOperationType = "create"
+	BulkOperationUpdate BulkOperationType = "update"
+	BulkOperationDelete BulkOperationType = "delete"
+
+	BulkOperationStatusSuccess    BulkOperationStatus = "success"
+	BulkOperationStatusFailed     BulkOperationStatus = "failed"
+	BulkOperationStatusRolledBack BulkOperationStatus = "rolled_back"
+	BulkOperationStatusSkipped    BulkOperationStatus = "skipped"
+)
+
+type BulkOperation struct {
+	Operation BulkOperationType `json:"operation"`
+	Serial    string            `json:"serial"`
+	Items     []DataItem        `json:"items"`
+}
+
+type BulkMutationRequest struct {
+	Mode        BulkMode        `json:"mode"`
+	Operations  []BulkOperation `json:"operations"`
+	ObjectCode  string          `json:"object_code"`
+	TenantCode  string          `json:"tenant_code"`
+	ProductCode string          `json:"product_code"`
+	UserSerial  string          `json:"user_serial"`
+}
+
+type BulkOperationResult struct {
+	Index            int                               `json:"index"`
+	Operation        BulkOperationType                 `json:"operation"`
+	Serial           string                            `json:"serial"`
+	Status           BulkOperationStatus               `json:"status"`
+	Data             map[string]DataItem               `json:"data,omitempty"`
+	Error            string                            `json:"error,omitempty"`
+	ValidationErrors map[string][]FieldValidationError `json:"validation_errors,omitempty"`
+}
+
+type BulkMutationResponse struct {
+	Mode        BulkMode              `json:"mode"`
+	IsCommitted bool                  `json:"is_committed"`
+	Total       int                   `json:"total"`
+	Succeeded   int                   `json:"succeeded"`
+	Failed      int                   `json:"failed"`
+	Results     []BulkOperationResult `json:"results"`
+}
@@ -0,0 +1,53 @@
+package entity
+
+import (
+	"errors"
+	"time"
+)
+
+type ExportJobStatus string
+
+const (
+	ExportJobStatusQueued    ExportJobStatus = "queued"
+	ExportJobStatusRunning   ExportJobStatus = "running"
+	ExportJobStatusCompleted ExportJobStatus = "completed"
+	ExportJobStatusFailed    ExportJobStatus = "failed"
+)
+
+var (
+	ErrorExportJobNotFound = errors.New("export job is not found")
+	ErrorExportJobNotReady = errors.New("export job is not completed yet")
+)
+
+type ExportJobRequest struct {
+	Query             CatalogQuery `json:"query"`
+	Format            ExportFormat `json:"format"`
+	IsIncludeMetadata bool         `json:"is_include_metadata"`
+	UserSerial        string       `json:"user_serial"`
+}
+
+type ExportJob struct {
+	ID                string          `json:"id"`
+	TenantCode        string          `json:"tenant_code"`
+	ProductCode       string          `json:"product_code"`
+	ObjectCode        string          `json:"object_code"`
+	ViewContentCode   string          `json:"view_content_code"`
+	Query             CatalogQuery    `json:"query"`
+	Format            ExportFormat    `json:"format"`
+	IsIncludeMetadata bool            `json:"is_include_metadata"`
+	Status            ExportJobStatus `json:"status"`
+	ProcessedRows     int             `json:"processed_rows"`
+	TotalRows         int             `json:"total_rows"`
+	Progress          float64         `json:"progress"`
+	FileName          string          `json:"file_name"`
+	ContentType       string          `json:"content_type"`
+	FileSize          int64           `json:"file_size"`
+	DownloadURL       string          `json:"download_url,omitempty"`
+	Error             string          `json:"error,omitempty"`
+	Attempts          int             `json:"attempts"`
+	CreatedBy         string          `json:"created_by"`
+	CreatedAt         time.Time       `json:"created_at"`
+	StartedAt         *time.Time      `json:"started_at,omitempty"`
+	CompletedAt       *time.Time      `json:"completed_at,omitempty"`
+	ExpiresAt         time.Time       `json:"expires_at"`
+}
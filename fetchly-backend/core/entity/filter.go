@@ -0,0 +1,110 @@
+package entity
+
+import (
+	"sort"
+	"strings"
+)
+
+// GroupOperator returns how the conditions of the group combine, AND unless the group asks for OR or NOT. A
+// decoded request holds the operator as a FilterOperator, so both sides of the flexible operator are read.
+func (g FilterGroup) GroupOperator() FilterGroupOperator {
+	var operator string
+	if value, ok := g.Operator.AsT2(); ok {
+		operator = string(value)
+	} else if value, ok := g.Operator.AsT1(); ok {
+		operator = string(value)
+	}
+
+	switch {
+	case strings.EqualFold(operator, string(FilterOperatorOr)):
+		return FilterOperatorOr
+	case strings.EqualFold(operator, string(FilterOperatorNot)):
+		return FilterOperatorNot
+	}
+
+	return FilterOperatorAnd
+}
+
+// IsEmpty reports whether the group holds no condition at any depth
+func (g FilterGroup) IsEmpty() bool {
+	if len(g.Filters) > 0 || len(g.Conditions) > 0 {
+		return false
+	}
+
+	for _, child := range g.Groups {
+		if !child.IsEmpty() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Walk calls fn with the field name and the item of every condition of the group and its children, the items of
+// Filters in the order of their keys
+func (g FilterGroup) Walk(fn func(fieldName string, item FilterItem)) {
+	for _, key := range g.filterKeys() {
+		fn(key, g.Filters[key])
+	}
+
+	for _, item := range g.Conditions {
+		fn(item.FieldName, item)
+	}
+
+	for _, child := range g.Groups {
+		child.Walk(fn)
+	}
+}
+
+// MapItems returns a copy of the group and its children with every condition replaced by the item fn returns,
+// the conditions fn does not keep are dropped
+func (g FilterGroup) MapItems(fn func(fieldName string, item FilterItem) (FilterItem, bool, error)) (FilterGroup, error) {
+	mapped := FilterGroup{Operator: g.Operator}
+
+	if g.Filters != nil {
+		mapped.Filters = make(map[string]FilterItem, len(g.Filters))
+	}
+
+	for key, item := range g.Filters {
+		item, isKept, err := fn(key, item)
+		if err != nil {
+			return mapped, err
+		}
+
+		if isKept {
+			mapped.Filters[key] = item
+		}
+	}
+
+	for _, item := range g.Conditions {
+		item, isKept, err := fn(item.FieldName, item)
+		if err != nil {
+			return mapped, err
+		}
+
+		if isKept {
+			mapped.Conditions = append(mapped.Conditions, item)
+		}
+	}
+
+	for _, child := range g.Groups {
+		mappedChild, err := child.MapItems(fn)
+		if err != nil {
+			return mapped, err
+		}
+
+		mapped.Groups = append(mapped.Groups, mappedChild)
+	}
+
+	return mapped, nil
+}
+
+func (g FilterGroup) filterKeys() []string {
+	keys := make([]string, 0, len(g.Filters))
+	for key := range g.Filters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
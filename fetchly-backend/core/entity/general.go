@@ -8,6 +8,7 @@ var (
 	ErrorBadRequest          = errors.New("bad request")
 	ErrorSerialEmpty         = errors.New("serial is empty")
 	ErrorNoUpdateDataFound   = errors.New("no update data found")
+	ErrorBulkRolledBack      = errors.New("bulk operation is rolled back")
 )
 
 const (
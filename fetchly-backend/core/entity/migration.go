@@ -0,0 +1,140 @@
+package entity
+
+import "time"
+
+type MetadataBundleFormat string
+type MigrationAction string
+type MigrationTarget string
+
+const (
+	MetadataBundleFormatJSON MetadataBundleFormat = "json"
+	MetadataBundleFormatYAML MetadataBundleFormat = "yaml"
+
+	MigrationActionCreate MigrationAction = "create"
+	MigrationActionAlter  MigrationAction = "alter"
+	MigrationActionDrop   MigrationAction = "drop"
+
+	MigrationTargetObject      MigrationTarget = "object"
+	MigrationTargetViewLayout  MigrationTarget = "view_layout"
+	MigrationTargetViewSchema  MigrationTarget = "view_schema"
+	MigrationTargetViewContent MigrationTarget = "view_content"
+	MigrationTargetModule      MigrationTarget = "module"
+)
+
+// MetadataBundle is the configuration of a tenant, or of one module of a tenant, at a version: its objects with
+// their fields and the view metadata showing them. Everything in a bundle is identified by code so the bundle
+// exported from one tenant applies to another one.
+type MetadataBundle struct {
+	Version    string         `json:"version"`
+	TenantCode string         `json:"tenant_code"`
+	ModuleCode string         `json:"module_code"`
+	ExportedAt time.Time      `json:"exported_at"`
+	Objects    []ObjectSchema `json:"objects"`
+	ViewMetadata
+}
+
+// ViewMetadata are the view layouts, view schemas and view contents of a tenant, a view content holds its
+// navigation
+type ViewMetadata struct {
+	ViewLayouts  []BundleViewLayout  `json:"view_layouts"`
+	ViewSchemas  []BundleViewSchema  `json:"view_schemas"`
+	ViewContents []BundleViewContent `json:"view_contents"`
+}
+
+// BundleViewLayout is a view layout identified by its code, view layouts are shared by the tenants
+type BundleViewLayout struct {
+	Code         string         `json:"code"`
+	LayoutConfig map[string]any `json:"layout_config"`
+}
+
+// BundleViewSchema is a view schema identified by its object and code
+type BundleViewSchema struct {
+	ObjectCode    string           `json:"object_code"`
+	Code          string           `json:"code"`
+	Name          string           `json:"name"`
+	Query         map[string]any   `json:"query"`
+	DisplayField  map[string]any   `json:"display_field"`
+	StructureType string           `json:"structure_type"`
+	IsFavorite    bool             `json:"is_favorite"`
+	FieldSections map[string]any   `json:"field_sections"`
+	Orders        []map[string]any `json:"orders"`
+}
+
+// BundleViewContent is a view content identified by its product, object, code and layout type, the keys it is
+// looked up by. Its view layout and view schema are referenced by code.
+type BundleViewContent struct {
+	ProductCode       string             `json:"product_code"`
+	ObjectCode        string             `json:"object_code"`
+	Code              string             `json:"code"`
+	LayoutType        string             `json:"layout_type"`
+	Name              string             `json:"name"`
+	ViewLayoutCode    string             `json:"view_layout_code"`
+	ViewSchemaCode    string             `json:"view_schema_code"`
+	IsDefault         bool               `json:"is_default"`
+	IsShownInList     bool               `json:"is_shown_in_list"`
+	ViewContentConfig map[string]any     `json:"view_content_config"`
+	Navigation        []BundleNavigation `json:"navigation"`
+}
+
+// BundleNavigation is a navigation item of a view content identified by its code
+type BundleNavigation struct {
+	Code             string         `json:"code"`
+	Title            string         `json:"title"`
+	Description      string         `json:"description"`
+	URL              string         `json:"url"`
+	NavigationLevel  int32          `json:"navigation_level"`
+	Path             string         `json:"path"`
+	NavigationOrder  int32          `json:"navigation_order"`
+	NavigationConfig map[string]any `json:"navigation_config"`
+}
+
+// MetadataExportRequest exports the configuration of a tenant, ModuleCode narrows it down to the objects of a
+// module. Version defaults to the version of the module.
+type MetadataExportRequest struct {
+	TenantCode string `json:"tenant_code"`
+	ModuleCode string `json:"module_code"`
+	Version    string `json:"version"`
+}
+
+// MigrationRequest migrates a tenant to a bundle. What the tenant holds beyond the bundle is only dropped when
+// IsPruning, a change of data type losing values only made when IsForced.
+type MigrationRequest struct {
+	TenantCode string         `json:"tenant_code"`
+	Bundle     MetadataBundle `json:"bundle"`
+	IsPruning  bool           `json:"is_pruning"`
+	IsForced   bool           `json:"is_forced"`
+	IsDryRun   bool           `json:"is_dry_run"`
+	UserSerial string         `json:"-"`
+}
+
+// MigrationPlan lists the steps migrating a tenant to a bundle in the order they are applied. Statements is the
+// DDL the steps ran, or would run for a dry run. The objects of a data source are not migrated, their DDL could not
+// be rolled back with the metadata, a bundle changing one is refused.
+type MigrationPlan struct {
+	TenantCode     string            `json:"tenant_code"`
+	ModuleCode     string            `json:"module_code"`
+	CurrentVersion string            `json:"current_version"`
+	Version        string            `json:"version"`
+	Steps          []MigrationStep   `json:"steps"`
+	Statements     []SchemaStatement `json:"statements"`
+	IsApplied      bool              `json:"is_applied"`
+}
+
+// MigrationStep creates, alters or drops one item of the tenant, the item it holds is the one of its target
+type MigrationStep struct {
+	Action       MigrationAction     `json:"action"`
+	Target       MigrationTarget     `json:"target"`
+	Code         string              `json:"code"`
+	Object       *ObjectSchema       `json:"object,omitempty"`
+	ObjectChange *ObjectSchemaChange `json:"object_change,omitempty"`
+	ViewLayout   *BundleViewLayout   `json:"view_layout,omitempty"`
+	ViewSchema   *BundleViewSchema   `json:"view_schema,omitempty"`
+	ViewContent  *BundleViewContent  `json:"view_content,omitempty"`
+	Version      string              `json:"version,omitempty"`
+}
+
+// SchemaStatement is a DDL statement run on the table of an object in the tenant schema
+type SchemaStatement struct {
+	ObjectCode string `json:"object_code"`
+	SQL        string `json:"sql"`
+}
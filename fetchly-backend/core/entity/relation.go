@@ -0,0 +1,66 @@
+package entity
+
+// Relation of an object field. A many to one relation is a foreign key column of the object itself, the to many
+// relations are fields without a column that list the related records.
+//
+// A one to many field targets the child object and its foreign key field referencing the object, as the line
+// items of an order. A many to many field targets the junction object and its foreign key field referencing the
+// object, the field reference is the foreign key field of the junction referencing the related object.
+const (
+	RelationManyToOne  = "many_to_one"
+	RelationOneToMany  = "one_to_many"
+	RelationManyToMany = "many_to_many"
+)
+
+const (
+	// MaxRelationDepth is the deepest nesting of an expand or a nested write
+	MaxRelationDepth = 3
+
+	DefaultExpandPageSize = 10
+	MaxExpandPageSize     = 100
+)
+
+// ObjectRelation is a to many relation field of an object resolved into the tables it reads. ParentKey is the
+// column of the object the relation references, TargetKey the column of the target object the junction of a
+// many to many relation references.
+type ObjectRelation struct {
+	FieldCode    string `json:"field_code"`
+	FieldName    string `json:"field_name"`
+	Relation     string `json:"relation"`
+	ParentKey    string `json:"parent_key"`
+	TargetObject string `json:"target_object"`
+	// TargetField is the foreign key field of the child referencing the object for one to many
+	TargetField string `json:"target_field"`
+	TargetKey   string `json:"target_key"`
+
+	JunctionObject      string `json:"junction_object"`
+	JunctionParentField string `json:"junction_parent_field"`
+	JunctionTargetField string `json:"junction_target_field"`
+}
+
+// IsToManyRelation reports whether a field of the relation lists related records instead of holding a column
+func IsToManyRelation(relation string) bool {
+	return relation == RelationOneToMany || relation == RelationManyToMany
+}
+
+func (r ObjectRelation) IsManyToMany() bool {
+	return r.Relation == RelationManyToMany
+}
+
+// ExpandQuery loads a to many relation along every record. The page applies to the related records of every
+// record on its own, Expand loads the relations of the related records in turn.
+type ExpandQuery struct {
+	Fields   map[string]Field       `json:"fields"`
+	Filters  []FilterGroup          `json:"filters"`
+	Orders   []Order                `json:"orders"`
+	Page     int                    `json:"page"`
+	PageSize int                    `json:"page_size"`
+	Expand   map[string]ExpandQuery `json:"expand"`
+}
+
+// RelatedQuery reads the records related to ParentKeys through Relation, Query is the query of the related object
+type RelatedQuery struct {
+	Query      CatalogQuery
+	Relation   ObjectRelation
+	ParentKeys []any
+}
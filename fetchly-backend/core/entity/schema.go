@@ -0,0 +1,162 @@
+package entity
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// primitive data types of DataType.PrimitiveDataType, the column type of a field in every dialect follows from them
+const (
+	PrimitiveDataTypeString   = "string"
+	PrimitiveDataTypeText     = "text"
+	PrimitiveDataTypeInteger  = "integer"
+	PrimitiveDataTypeBigInt   = "bigint"
+	PrimitiveDataTypeDecimal  = "decimal"
+	PrimitiveDataTypeFloat    = "float"
+	PrimitiveDataTypeBoolean  = "boolean"
+	PrimitiveDataTypeDate     = "date"
+	PrimitiveDataTypeDateTime = "datetime"
+	PrimitiveDataTypeTime     = "time"
+	PrimitiveDataTypeJSON     = "json"
+	PrimitiveDataTypeUUID     = "uuid"
+)
+
+// StandardColumns are created with every table of the schema management API and can not be altered or dropped
+var StandardColumns = []string{DEFAULT_IDENTIFIER, "created_at", "updated_at", "deleted_at", "created_by", "updated_by", "deleted_by"}
+
+// widerDataTypes lists the primitive data types every value of a primitive data type converts to without loss
+var widerDataTypes = map[string][]string{
+	PrimitiveDataTypeString:   {PrimitiveDataTypeText},
+	PrimitiveDataTypeInteger:  {PrimitiveDataTypeBigInt, PrimitiveDataTypeDecimal, PrimitiveDataTypeString, PrimitiveDataTypeText},
+	PrimitiveDataTypeBigInt:   {PrimitiveDataTypeDecimal, PrimitiveDataTypeString, PrimitiveDataTypeText},
+	PrimitiveDataTypeDecimal:  {PrimitiveDataTypeString, PrimitiveDataTypeText},
+	PrimitiveDataTypeFloat:    {PrimitiveDataTypeString, PrimitiveDataTypeText},
+	PrimitiveDataTypeBoolean:  {PrimitiveDataTypeString, PrimitiveDataTypeText},
+	PrimitiveDataTypeDate:     {PrimitiveDataTypeDateTime, PrimitiveDataTypeString, PrimitiveDataTypeText},
+	PrimitiveDataTypeDateTime: {PrimitiveDataTypeString, PrimitiveDataTypeText},
+	PrimitiveDataTypeTime:     {PrimitiveDataTypeString, PrimitiveDataTypeText},
+	PrimitiveDataTypeJSON:     {PrimitiveDataTypeText},
+	PrimitiveDataTypeUUID:     {PrimitiveDataTypeString, PrimitiveDataTypeText},
+}
+
+// identifierPattern matches the codes of objects and fields. A double underscore is left out, it chains the
+// fields of related objects.
+var identifierPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,62}$`)
+
+// ObjectSchema is an object with its fields as created by the schema management API. A field is a column of the
+// table of the object unless it is a to many relation.
+type ObjectSchema struct {
+	TenantCode      string        `json:"tenant_code"`
+	ObjectCode      string        `json:"object_code"`
+	DisplayName     string        `json:"display_name"`
+	DisplayTemplate string        `json:"display_template"`
+	Description     string        `json:"description"`
+	ObjectType      string        `json:"object_type"`
+	ModuleCode      string        `json:"module_code"`
+	DataSourceCode  string        `json:"data_source_code"`
+	Fields          []FieldSchema `json:"fields"`
+	UserSerial      string        `json:"-"`
+}
+
+// FieldSchema is a field of an object. DataType is found by its code, a data type with a primitive data type
+// that does not exist yet is created along the field. IsRequired and IsUnique are constraints of the column and
+// rules of the validation engine alike.
+//
+// A many to one field references the key of TargetObjectCode, TargetFieldCode or serial. A one to many field
+// targets the child object and its many to one field referencing the object, a many to many field the junction
+// object and its many to one field referencing the object, FieldReference being the one referencing the other object.
+type FieldSchema struct {
+	FieldCode        string         `json:"field_code"`
+	DisplayName      string         `json:"display_name"`
+	Description      string         `json:"description"`
+	DataType         DataType       `json:"data_type"`
+	IsRequired       bool           `json:"is_required"`
+	IsUnique         bool           `json:"is_unique"`
+	IsDisplayName    bool           `json:"is_display_name"`
+	IsSearchable     bool           `json:"is_searchable"`
+	DefaultValue     string         `json:"default_value"`
+	ValidationRules  map[string]any `json:"validation_rules"`
+	Relation         string         `json:"relation"`
+	TargetObjectCode string         `json:"target_object_code"`
+	TargetFieldCode  string         `json:"target_field_code"`
+	FieldReference   string         `json:"field_reference"`
+}
+
+// ObjectSchemaChange alters an object. The object attributes left nil are kept, AlterFields holds the complete new
+// definition of the fields it changes.
+type ObjectSchemaChange struct {
+	TenantCode      string              `json:"tenant_code"`
+	ObjectCode      string              `json:"object_code"`
+	DisplayName     *string             `json:"display_name"`
+	DisplayTemplate *string             `json:"display_template"`
+	Description     *string             `json:"description"`
+	AddFields       []FieldSchema       `json:"add_fields"`
+	AlterFields     []FieldSchemaChange `json:"alter_fields"`
+	DropFields      []string            `json:"drop_fields"`
+	UserSerial      string              `json:"-"`
+}
+
+// FieldSchemaChange redefines a field. RenameFrom renames the field RenameFrom into Field.FieldCode. A change of
+// data type to a type that can not hold every value of the former one is refused unless IsForced, the values are
+// then cast and a value that can not be cast fails the change.
+type FieldSchemaChange struct {
+	Field      FieldSchema `json:"field"`
+	RenameFrom string      `json:"rename_from"`
+	IsForced   bool        `json:"is_forced"`
+}
+
+// Code is the current code of the changed field
+func (c FieldSchemaChange) Code() string {
+	if c.RenameFrom != "" {
+		return c.RenameFrom
+	}
+
+	return c.Field.FieldCode
+}
+
+// IsColumn reports whether the field is a column of the table of its object
+func (f FieldSchema) IsColumn() bool {
+	return !IsToManyRelation(f.Relation)
+}
+
+// IsStandardColumn reports whether the column is one of the standard columns of every table
+func IsStandardColumn(column string) bool {
+	for _, standardColumn := range StandardColumns {
+		if column == standardColumn {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsPrimitiveDataType reports whether a column can be created for the primitive data type
+func IsPrimitiveDataType(primitiveDataType string) bool {
+	_, ok := widerDataTypes[primitiveDataType]
+	return ok
+}
+
+// IsLosslessTypeChange reports whether every value of the primitive data type from converts to the type to
+func IsLosslessTypeChange(from, to string) bool {
+	if from == to {
+		return true
+	}
+
+	for _, dataType := range widerDataTypes[from] {
+		if dataType == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ValidateIdentifier checks the code of an object or a field, the codes end up in the DDL of the table
+func ValidateIdentifier(kind, code string) error {
+	if !identifierPattern.MatchString(code) || strings.Contains(code, "__") {
+		return fmt.Errorf("%w: %v code %q must start with a lowercase letter, hold lowercase letters, digits and single underscores and be at most 63 characters long", ErrorBadRequest, kind, code)
+	}
+
+	return nil
+}
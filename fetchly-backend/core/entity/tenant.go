@@ -0,0 +1,76 @@
+package entity
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TenantProvisionRequest creates a tenant with its schema. The tenant is seeded from the configuration of
+// TemplateTenantCode, narrowed down to ModuleCode when set, or from Bundle: its objects, view contents and
+// navigation. A tenant is always given a user table.
+type TenantProvisionRequest struct {
+	TenantCode         string          `json:"tenant_code"`
+	Name               string          `json:"name"`
+	TenantConfig       map[string]any  `json:"tenant_config"`
+	TemplateTenantCode string          `json:"template_tenant_code"`
+	ModuleCode         string          `json:"module_code"`
+	Bundle             *MetadataBundle `json:"bundle"`
+	IsDataIncluded     bool            `json:"is_data_included"`
+	IsDryRun           bool            `json:"is_dry_run"`
+	UserSerial         string          `json:"-"`
+}
+
+// TenantCloneRequest creates a tenant as a copy of SourceTenantCode, its rows along when IsDataIncluded. The
+// name and configuration of the source are kept unless given.
+type TenantCloneRequest struct {
+	SourceTenantCode string         `json:"source_tenant_code"`
+	TenantCode       string         `json:"tenant_code"`
+	Name             string         `json:"name"`
+	TenantConfig     map[string]any `json:"tenant_config"`
+	IsDataIncluded   bool           `json:"is_data_included"`
+	IsDryRun         bool           `json:"is_dry_run"`
+}
+
+// TenantProvision is the outcome of provisioning a tenant, Plan lists the steps seeding it and CopiedRows the
+// number of rows copied per object
+type TenantProvision struct {
+	TenantCode         string           `json:"tenant_code"`
+	TemplateTenantCode string           `json:"template_tenant_code"`
+	Plan               MigrationPlan    `json:"plan"`
+	CopiedRows         map[string]int64 `json:"copied_rows"`
+	IsApplied          bool             `json:"is_applied"`
+}
+
+// TenantOffboardRequest archives a tenant and its data, the schema of the tenant is dropped once archived when
+// IsSchemaDropped
+type TenantOffboardRequest struct {
+	TenantCode      string `json:"tenant_code"`
+	IsSchemaDropped bool   `json:"is_schema_dropped"`
+	UserSerial      string `json:"-"`
+}
+
+// TenantArchive is the archive of an offboarded tenant, a zip file with the bundle of the tenant and a file of
+// json lines per object. ExportedRows is the number of rows archived per object.
+type TenantArchive struct {
+	TenantCode      string           `json:"tenant_code"`
+	Key             string           `json:"key"`
+	Size            int64            `json:"size"`
+	ExportedRows    map[string]int64 `json:"exported_rows"`
+	IsSchemaDropped bool             `json:"is_schema_dropped"`
+	ArchivedAt      time.Time        `json:"archived_at"`
+}
+
+// ValidateTenantCode checks the code of a tenant, the code names the schema of the tenant so the public schema and
+// the schemas of postgres are reserved
+func ValidateTenantCode(code string) error {
+	if err := ValidateIdentifier("tenant", code); err != nil {
+		return err
+	}
+
+	if code == PUBLIC || code == "information_schema" || strings.HasPrefix(code, "pg_") {
+		return fmt.Errorf("%w: tenant code %q is a reserved schema name", ErrorBadRequest, code)
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package entity
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateTenantCode(t *testing.T) {
+	tests := []struct {
+		code    string
+		isValid bool
+	}{
+		{code: "acme", isValid: true},
+		{code: "acme_2", isValid: true},
+		{code: "pgadmin", isValid: true},
+		{code: "public"},
+		{code: "information_schema"},
+		{code: "pg_catalog"},
+		{code: "pg_toast"},
+		{code: "Acme"},
+		{code: "acme;drop"},
+		{code: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			err := ValidateTenantCode(tt.code)
+			if isValid := err == nil; isValid != tt.isValid {
+				t.Errorf("ValidateTenantCode(%q) = %v, want valid %v", tt.code, err, tt.isValid)
+			}
+
+			if err != nil && !errors.Is(err, ErrorBadRequest) {
+				t.Errorf("ValidateTenantCode(%q) = %v, want a bad request", tt.code, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package entity
+
+import "time"
+
+// DeletionRequest soft deletes the records of an object or restores them. The records are the ones of Serials, or
+// the child records of a cascade referencing ParentKeys through ForeignKey.
+//
+// DeletedAt is the deletion time of the records, the value deleted_at is set to on a delete. A restore with
+// DeletedAt only restores the records deleted at that time, the ones deleted along with their parent, it takes the
+// value as read from the table of the parent.
+type DeletionRequest struct {
+	TenantCode string
+	ObjectCode string
+	Serials    []string
+	ForeignKey string
+	ParentKeys []any
+	DeletedAt  any
+	IsRestore  bool
+	UserSerial string
+	// Filters are the row policies of the object, a record they hide is left as it is
+	Filters []FilterGroup
+}
+
+// TrashPurge is the purge of the records of a tenant soft deleted before DeletedBefore, PurgedRows is the number of
+// records purged per object
+type TrashPurge struct {
+	TenantCode    string           `json:"tenant_code"`
+	DeletedBefore time.Time        `json:"deleted_before"`
+	PurgedRows    map[string]int64 `json:"purged_rows"`
+}
@@ -0,0 +1,56 @@
+package entity
+
+import (
+	"fmt"
+	"strings"
+)
+
+type ValidationRule string
+
+const (
+	ValidationRuleRequired   ValidationRule = "required"
+	ValidationRuleMin        ValidationRule = "min"
+	ValidationRuleMax        ValidationRule = "max"
+	ValidationRuleMinLength  ValidationRule = "min_length"
+	ValidationRuleMaxLength  ValidationRule = "max_length"
+	ValidationRulePattern    ValidationRule = "pattern"
+	ValidationRuleEnum       ValidationRule = "enum"
+	ValidationRuleUnique     ValidationRule = "unique"
+	ValidationRuleCrossField ValidationRule = "cross_field"
+	ValidationRuleReference  ValidationRule = "reference"
+
+	// ValidationRuleMessages holds custom messages keyed by rule, e.g. {"messages": {"required": "please fill the name"}}
+	ValidationRuleMessages = "messages"
+)
+
+// FieldValidationError describes a single rule violation of a field, shaped so the
+// frontend can render it next to the matching input of the form layout.
+type FieldValidationError struct {
+	FieldCode string         `json:"field_code"`
+	FieldName string         `json:"field_name"`
+	Rule      ValidationRule `json:"rule"`
+	Message   string         `json:"message"`
+	Params    map[string]any `json:"params,omitempty"`
+}
+
+// ValidationErrors is returned by the validation engine when a mutation breaks one or more rules
+type ValidationErrors []FieldValidationError
+
+func (ve ValidationErrors) Error() string {
+	messages := make([]string, 0, len(ve))
+	for _, fieldError := range ve {
+		messages = append(messages, fmt.Sprintf("%v: %v", fieldError.FieldCode, fieldError.Message))
+	}
+
+	return fmt.Sprintf("validation failed: %v", strings.Join(messages, "; "))
+}
+
+// ByField groups the errors by field code
+func (ve ValidationErrors) ByField() map[string][]FieldValidationError {
+	result := make(map[string][]FieldValidationError)
+	for _, fieldError := range ve {
+		result[fieldError.FieldCode] = append(result[fieldError.FieldCode], fieldError)
+	}
+
+	return result
+}
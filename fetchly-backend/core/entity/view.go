@@ -12,6 +12,10 @@ type GetViewContentByKeysRequest struct {
 	ObjectCode      string `json:"object_code"`
 	ViewContentCode string `json:"view_content_code"`
 	LayoutType      string `json:"layout_type"`
+
+	// IsResolveDataSource runs the query data sources of the charts and score cards and returns their values
+	// inline in the layout
+	IsResolveDataSource bool `json:"is_resolve_data_source"`
 }
 
 type ViewLayout struct {
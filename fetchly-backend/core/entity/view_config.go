@@ -56,15 +56,25 @@ type ScoreCardConfig struct {
 
 // ScoreCard represents individual score card configuration
 type ScoreCard struct {
-	Title      string          `json:"title"`
-	Subtitle   string          `json:"subtitle,omitempty"`
-	Value      string          `json:"value"`
-	Unit       string          `json:"unit,omitempty"`
-	Icon       string          `json:"icon,omitempty"`
-	Color      string          `json:"color,omitempty"`
-	Trend      *ScoreCardTrend `json:"trend,omitempty"`
-	DataSource *ViewDataSource `json:"dataSource,omitempty"`
-	Style      map[string]any  `json:"style,omitempty"`
+	Title      string            `json:"title"`
+	Subtitle   string            `json:"subtitle,omitempty"`
+	Value      string            `json:"value"`
+	Unit       string            `json:"unit,omitempty"`
+	Icon       string            `json:"icon,omitempty"`
+	Color      string            `json:"color,omitempty"`
+	Trend      *ScoreCardTrend   `json:"trend,omitempty"`
+	DataSource *ViewDataSource   `json:"dataSource,omitempty"`
+	Compare    *ScoreCardCompare `json:"compare,omitempty"`
+	Style      map[string]any    `json:"style,omitempty"`
+}
+
+// ScoreCardCompare computes the trend of a query score card by running its data source over the last period and
+// the period before it, both windows are taken on DateField
+type ScoreCardCompare struct {
+	DateField      string     `json:"dateField"`
+	Period         DateBucket `json:"period"`
+	IsDecreaseGood bool       `json:"isDecreaseGood,omitempty"`
+	TimeSpan       string     `json:"timeSpan,omitempty"`
 }
 
 // ScoreCardTrend represents trend information for a score card
@@ -116,4 +126,8 @@ const (
 	DataSourceTypeAPI    = "api"
 	DataSourceTypeStatic = "static"
 	DataSourceTypeQuery  = "query"
+
+	// Endpoints of a query data source, run against the catalog or the aggregate engine
+	DataSourceEndpointData      = "data"
+	DataSourceEndpointAggregate = "aggregate"
 )
@@ -0,0 +1,482 @@
+package module
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+	"github.com/fetchlydev/source/fetchly-backend/core/repository"
+	"github.com/fetchlydev/source/fetchly-backend/pkg/helper"
+)
+
+// accessControl resolves the permissions of the request user from the roles granted in the metadata.
+// Objects no role has a permission on are left open, once a role is granted a permission on an
+// object only the granted roles can act on it. Permissions are cached per object for the lifetime
+// of the accessControl, so create one per request.
+type accessControl struct {
+	accessRepo     repository.AccessRepository
+	permissions    map[string]entity.ObjectPermission
+	roles          map[string][]entity.Role
+	userAttributes map[string]map[string]any
+}
+
+func newAccessControl(accessRepo repository.AccessRepository) *accessControl {
+	return &accessControl{
+		accessRepo:     accessRepo,
+		permissions:    make(map[string]entity.ObjectPermission),
+		roles:          make(map[string][]entity.Role),
+		userAttributes: make(map[string]map[string]any),
+	}
+}
+
+// Authorize returns the permission of the request user on the object, or entity.ErrorForbidden when
+// the action is not granted
+func (ac *accessControl) Authorize(ctx context.Context, tenantCode, objectCode string, action entity.PermissionAction) (entity.ObjectPermission, error) {
+	permission, err := ac.GetObjectPermission(ctx, tenantCode, objectCode)
+	if err != nil {
+		return permission, err
+	}
+
+	if !permission.Can(action) {
+		return permission, errActionForbidden(action, objectCode)
+	}
+
+	return permission, nil
+}
+
+func (ac *accessControl) GetObjectPermission(ctx context.Context, tenantCode, objectCode string) (entity.ObjectPermission, error) {
+	cacheKey := tenantCode + "." + objectCode
+	if permission, ok := ac.permissions[cacheKey]; ok {
+		return permission, nil
+	}
+
+	permission, err := ac.loadObjectPermission(ctx, tenantCode, objectCode)
+	if err != nil {
+		return permission, err
+	}
+
+	ac.permissions[cacheKey] = permission
+	return permission, nil
+}
+
+func (ac *accessControl) loadObjectPermission(ctx context.Context, tenantCode, objectCode string) (permission entity.ObjectPermission, err error) {
+	objectPermissions, err := ac.accessRepo.GetObjectPermissions(ctx, tenantCode, objectCode)
+	if err != nil {
+		return permission, err
+	}
+
+	if len(objectPermissions) == 0 {
+		return unrestrictedPermission(), nil
+	}
+
+	permission.IsRestricted = true
+
+	roles, err := ac.getUserRoles(ctx, tenantCode)
+	if err != nil {
+		return permission, err
+	}
+
+	roleSerials := make([]string, 0, len(roles))
+	for _, role := range roles {
+		if role.IsAdmin {
+			permission = unrestrictedPermission()
+			permission.IsRestricted = true
+			permission.IsAdmin = true
+
+			return permission, nil
+		}
+
+		roleSerials = append(roleSerials, role.Serial)
+	}
+
+	// the object permission of every role of the user, a role may act on the object only through its own grant
+	rolePermissions := make(map[string]entity.RoleObjectPermission)
+	for _, objectPermission := range objectPermissions {
+		if !helper.Contains(roleSerials, objectPermission.RoleSerial) {
+			continue
+		}
+
+		rolePermissions[objectPermission.RoleSerial] = objectPermission
+
+		permission.CanCreate = permission.CanCreate || objectPermission.CanCreate
+		permission.CanRead = permission.CanRead || objectPermission.CanRead
+		permission.CanUpdate = permission.CanUpdate || objectPermission.CanUpdate
+		permission.CanDelete = permission.CanDelete || objectPermission.CanDelete
+		permission.CanExport = permission.CanExport || objectPermission.CanExport
+	}
+
+	fieldPermissions, err := ac.accessRepo.GetFieldPermissions(ctx, tenantCode, objectCode, roleSerials)
+	if err != nil {
+		return permission, err
+	}
+
+	// field permission rows only narrow the object permission of their role, a role without a row for
+	// a field keeps the object permission on it. A field is readable or writable when any role allows it.
+	roleFieldPermissions := make(map[string]map[string]entity.RoleFieldPermission)
+	for _, fieldPermission := range fieldPermissions {
+		if _, ok := roleFieldPermissions[fieldPermission.FieldCode]; !ok {
+			roleFieldPermissions[fieldPermission.FieldCode] = make(map[string]entity.RoleFieldPermission)
+		}
+
+		roleFieldPermissions[fieldPermission.FieldCode][fieldPermission.RoleSerial] = fieldPermission
+	}
+
+	permission.HiddenFields = make(map[string]bool)
+	permission.ReadOnlyFields = make(map[string]bool)
+	for fieldCode, fieldRoles := range roleFieldPermissions {
+		isReadable, isWritable := false, false
+		for roleSerial, rolePermission := range rolePermissions {
+			canRead := rolePermission.CanRead
+			canWrite := rolePermission.CanCreate || rolePermission.CanUpdate
+
+			if fieldPermission, ok := fieldRoles[roleSerial]; ok {
+				canRead = canRead && fieldPermission.CanRead
+				canWrite = canWrite && fieldPermission.CanWrite
+			}
+
+			isReadable = isReadable || canRead
+			isWritable = isWritable || canWrite
+		}
+
+		if !isReadable {
+			permission.HiddenFields[fieldCode] = true
+		}
+
+		if !isWritable {
+			permission.ReadOnlyFields[fieldCode] = true
+		}
+	}
+
+	return permission, nil
+}
+
+// RowFilters returns the filter groups of the row policies applying to the request user on the object,
+// with their user and claim references resolved. Every returned group has to match, admins get none.
+func (ac *accessControl) RowFilters(ctx context.Context, tenantCode, objectCode string) (filters []entity.FilterGroup, err error) {
+	policies, err := ac.accessRepo.GetRowPolicies(ctx, tenantCode, objectCode)
+	if err != nil {
+		return filters, err
+	}
+
+	if len(policies) == 0 {
+		return filters, nil
+	}
+
+	roles, err := ac.getUserRoles(ctx, tenantCode)
+	if err != nil {
+		return filters, err
+	}
+
+	roleSerials := make([]string, 0, len(roles))
+	for _, role := range roles {
+		if role.IsAdmin {
+			return filters, nil
+		}
+
+		roleSerials = append(roleSerials, role.Serial)
+	}
+
+	for _, policy := range policies {
+		if policy.RoleSerial != "" && !helper.Contains(roleSerials, policy.RoleSerial) {
+			continue
+		}
+
+		for _, filterGroup := range policy.Filters {
+			resolvedGroup, err := filterGroup.MapItems(func(_ string, filter entity.FilterItem) (entity.FilterItem, bool, error) {
+				value, err := ac.resolvePolicyValue(ctx, tenantCode, filter.Value)
+				filter.Value = value
+
+				return filter, true, err
+			})
+			if err != nil {
+				return filters, err
+			}
+
+			filters = append(filters, resolvedGroup)
+		}
+	}
+
+	return filters, nil
+}
+
+func (ac *accessControl) getUserRoles(ctx context.Context, tenantCode string) ([]entity.Role, error) {
+	if roles, ok := ac.roles[tenantCode]; ok {
+		return roles, nil
+	}
+
+	roles, err := ac.accessRepo.GetUserRoles(ctx, tenantCode, userSerialFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	ac.roles[tenantCode] = roles
+	return roles, nil
+}
+
+// resolvePolicyValue replaces a "{{user.<column>}}" or "{{claims.<claim>}}" reference with the value it
+// points to, lists are resolved element by element. A reference to a missing attribute resolves to nil,
+// which no record matches.
+func (ac *accessControl) resolvePolicyValue(ctx context.Context, tenantCode string, value any) (any, error) {
+	if values, ok := value.([]any); ok {
+		resolved := make([]any, 0, len(values))
+		for _, item := range values {
+			resolvedItem, err := ac.resolvePolicyValue(ctx, tenantCode, item)
+			if err != nil {
+				return nil, err
+			}
+
+			resolved = append(resolved, resolvedItem)
+		}
+
+		return resolved, nil
+	}
+
+	reference, ok := value.(string)
+	if !ok || !strings.HasPrefix(reference, "{{") || !strings.HasSuffix(reference, "}}") {
+		return value, nil
+	}
+
+	source, attribute, _ := strings.Cut(strings.TrimSpace(reference[2:len(reference)-2]), ".")
+
+	user, _ := entity.AuthUserFromContext(ctx)
+	switch source {
+	case entity.RowPolicyClaimReference:
+		return user.Claims[attribute], nil
+	case entity.RowPolicyUserReference:
+		if attribute == entity.DEFAULT_IDENTIFIER {
+			return userSerialFromContext(ctx), nil
+		}
+
+		// attributes are read from the user record instead of the token, so a changed attribute applies right away
+		userAttributes, ok := ac.userAttributes[tenantCode]
+		if !ok {
+			var err error
+			userAttributes, err = ac.accessRepo.GetUserAttributes(ctx, tenantCode, userSerialFromContext(ctx))
+			if err != nil {
+				return nil, err
+			}
+
+			ac.userAttributes[tenantCode] = userAttributes
+		}
+
+		return userAttributes[attribute], nil
+	}
+
+	return nil, fmt.Errorf("unsupported row policy reference %v", reference)
+}
+
+func errActionForbidden(action entity.PermissionAction, objectCode string) error {
+	return fmt.Errorf("%w: %v is not allowed on object %v", entity.ErrorForbidden, action, objectCode)
+}
+
+func unrestrictedPermission() entity.ObjectPermission {
+	return entity.ObjectPermission{
+		CanCreate: true,
+		CanRead:   true,
+		CanUpdate: true,
+		CanDelete: true,
+		CanExport: true,
+	}
+}
+
+// canReadField checks the field itself, a <field>__name display column, a chained <field>__<column> and a
+// field.column reference all follow the field of the object they start from. Field codes never hold a double
+// underscore.
+func canReadField(permission entity.ObjectPermission, fieldCode string) bool {
+	fieldCode, _, _ = strings.Cut(fieldCode, ".")
+	fieldCode, _, _ = strings.Cut(fieldCode, "__")
+
+	return !permission.HiddenFields[fieldCode]
+}
+
+func canWriteField(permission entity.ObjectPermission, fieldCode string) bool {
+	return !permission.HiddenFields[fieldCode] && !permission.ReadOnlyFields[fieldCode]
+}
+
+// stripHiddenFields removes the fields the user can not read from item
+func stripHiddenFields(permission entity.ObjectPermission, item map[string]entity.DataItem) map[string]entity.DataItem {
+	if len(permission.HiddenFields) == 0 {
+		return item
+	}
+
+	for key := range item {
+		if !canReadField(permission, key) {
+			delete(item, key)
+		}
+	}
+
+	return item
+}
+
+// visibleFields drops the column maps of the fields the user can not read, they are never handed to the layout
+func visibleFields(permission entity.ObjectPermission, fields []map[string]any) []map[string]any {
+	if len(permission.HiddenFields) == 0 {
+		return fields
+	}
+
+	visible := make([]map[string]any, 0, len(fields))
+	for _, field := range fields {
+		fieldCode, _ := field[entity.FieldColumnCode].(string)
+		if !canReadField(permission, fieldCode) {
+			continue
+		}
+
+		visible = append(visible, field)
+	}
+
+	return visible
+}
+
+// checkTrashAccess rejects reading the soft deleted records without the permission to delete them, the trash
+// belongs to the users who can restore its records
+func checkTrashAccess(permission entity.ObjectPermission, request entity.CatalogQuery) error {
+	if (request.IsIncludeDeleted || request.IsOnlyDeleted) && !permission.Can(entity.PermissionDelete) {
+		return errActionForbidden(entity.PermissionDelete, request.ObjectCode)
+	}
+
+	return nil
+}
+
+// checkQueryFields rejects selecting, filtering and sorting on hidden fields, as all of them reveal their values
+func checkQueryFields(permission entity.ObjectPermission, request entity.CatalogQuery) error {
+	if len(permission.HiddenFields) == 0 {
+		return nil
+	}
+
+	fieldKeys := make([]string, 0, len(request.Fields))
+	for fieldKey := range request.Fields {
+		fieldKeys = append(fieldKeys, fieldKey)
+	}
+	sort.Strings(fieldKeys)
+
+	for _, fieldKey := range fieldKeys {
+		for _, fieldCode := range []string{fieldKey, request.Fields[fieldKey].FieldCode} {
+			if !canReadField(permission, fieldCode) {
+				return fmt.Errorf("%w: field %v can not be read", entity.ErrorForbidden, fieldCode)
+			}
+		}
+	}
+
+	for _, filterGroup := range request.Filters {
+		var hiddenField string
+		filterGroup.Walk(func(fieldName string, _ entity.FilterItem) {
+			if hiddenField == "" && !canReadField(permission, fieldName) {
+				hiddenField = fieldName
+			}
+		})
+
+		if hiddenField != "" {
+			return fmt.Errorf("%w: field %v can not be filtered", entity.ErrorForbidden, hiddenField)
+		}
+	}
+
+	for _, order := range request.Orders {
+		if !canReadField(permission, order.FieldName) {
+			return fmt.Errorf("%w: field %v can not be sorted", entity.ErrorForbidden, order.FieldName)
+		}
+	}
+
+	return nil
+}
+
+// authorizeChainedFields follows every chained field through the objects it joins. Each hop needs the read of the
+// object it reaches and of the field it goes on with, the last field is read on the object the chain ends in. Row
+// policies are not applied to the joins, so a chain into an object with row policies for the user is rejected.
+func (ac *accessControl) authorizeChainedFields(ctx context.Context, catalogRepo repository.CatalogRepository, tenantCode, objectCode string, permission entity.ObjectPermission, fieldNames []string) error {
+	for _, fieldName := range fieldNames {
+		if !strings.Contains(fieldName, "__") || fieldName == entity.DisplayFieldSuffix {
+			continue
+		}
+
+		chain := strings.Split(fieldName, "__")
+		currentObject, currentPermission := objectCode, permission
+
+		for i, fieldCode := range chain {
+			if i == len(chain)-1 {
+				// the display value of the last object is covered by its read permission
+				if !strings.HasSuffix(fieldName, entity.DisplayFieldSuffix) && !canReadField(currentPermission, fieldCode) {
+					return fmt.Errorf("%w: field %v of object %v can not be read", entity.ErrorForbidden, fieldCode, currentObject)
+				}
+
+				break
+			}
+
+			if !canReadField(currentPermission, fieldCode) {
+				return fmt.Errorf("%w: field %v of object %v can not be read", entity.ErrorForbidden, fieldCode, currentObject)
+			}
+
+			foreignKey, err := catalogRepo.GetForeignKeyInfo(ctx, currentObject, fieldCode, tenantCode)
+			if err != nil {
+				return err
+			}
+
+			if foreignKey.ForeignTable == "" {
+				return fmt.Errorf("%w: field %v is not a relation of object %v", entity.ErrorBadRequest, fieldCode, currentObject)
+			}
+
+			currentPermission, err = ac.Authorize(ctx, tenantCode, foreignKey.ForeignTable, entity.PermissionRead)
+			if err != nil {
+				return err
+			}
+
+			rowFilters, err := ac.RowFilters(ctx, tenantCode, foreignKey.ForeignTable)
+			if err != nil {
+				return err
+			}
+
+			if len(rowFilters) > 0 {
+				return fmt.Errorf("%w: object %v has row policies, field %v can not join it", entity.ErrorForbidden, foreignKey.ForeignTable, fieldName)
+			}
+
+			currentObject = foreignKey.ForeignTable
+		}
+	}
+
+	return nil
+}
+
+// queryFieldNames lists the fields a query selects, filters and sorts on
+func queryFieldNames(request entity.CatalogQuery) []string {
+	fieldNames := make([]string, 0, len(request.Fields)+len(request.Orders))
+	for fieldKey := range request.Fields {
+		fieldNames = append(fieldNames, fieldKey)
+	}
+	sort.Strings(fieldNames)
+
+	for _, filterGroup := range request.Filters {
+		filterGroup.Walk(func(fieldName string, _ entity.FilterItem) {
+			fieldNames = append(fieldNames, fieldName)
+		})
+	}
+
+	for _, order := range request.Orders {
+		fieldNames = append(fieldNames, order.FieldName)
+	}
+
+	return fieldNames
+}
+
+// hiddenFieldCodes lists the fields the user can not read, a search must not match on them
+func hiddenFieldCodes(permission entity.ObjectPermission) []string {
+	fieldCodes := make([]string, 0, len(permission.HiddenFields))
+	for fieldCode, isHidden := range permission.HiddenFields {
+		if isHidden {
+			fieldCodes = append(fieldCodes, fieldCode)
+		}
+	}
+
+	return fieldCodes
+}
+
+// checkWritableFields rejects a mutation touching a field the user can not write
+func checkWritableFields(permission entity.ObjectPermission, items []entity.DataItem) error {
+	for _, item := range items {
+		if !canWriteField(permission, item.FieldCode) {
+			return fmt.Errorf("%w: field %v is read only", entity.ErrorForbidden, item.FieldCode)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,457 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+	"github.com/fetchlydev/source/fetchly-backend/core/repository"
+)
+
+// grantRepo is an access repository holding the grants of a single user on a single object
+type grantRepo struct {
+	roles              []entity.Role
+	objectPermissions  []entity.RoleObjectPermission
+	fieldPermissions   []entity.RoleFieldPermission
+	rowPolicies        []entity.RowPolicy
+	userAttributes     map[string]any
+	userAttributeReads int
+}
+
+func (r *grantRepo) GetUserRoles(ctx context.Context, tenantCode, userSerial string) (resp []entity.Role, err error) {
+	return r.roles, nil
+}
+
+func (r *grantRepo) GetObjectPermissions(ctx context.Context, tenantCode, objectCode string) (resp []entity.RoleObjectPermission, err error) {
+	return r.objectPermissions, nil
+}
+
+func (r *grantRepo) GetFieldPermissions(ctx context.Context, tenantCode, objectCode string, roleSerials []string) (resp []entity.RoleFieldPermission, err error) {
+	return r.fieldPermissions, nil
+}
+
+func (r *grantRepo) GetRowPolicies(ctx context.Context, tenantCode, objectCode string) (resp []entity.RowPolicy, err error) {
+	return r.rowPolicies, nil
+}
+
+func (r *grantRepo) GetUserAttributes(ctx context.Context, tenantCode, userSerial string) (resp map[string]any, err error) {
+	r.userAttributeReads++
+	return r.userAttributes, nil
+}
+
+func TestGetObjectPermission(t *testing.T) {
+	sales := entity.Role{Serial: "sales"}
+	support := entity.Role{Serial: "support"}
+
+	tests := []struct {
+		name             string
+		repo             *grantRepo
+		wantCanRead      bool
+		wantCanUpdate    bool
+		wantHiddenFields []string
+		wantReadOnly     []string
+	}{
+		{
+			name:          "object without grants is open",
+			repo:          &grantRepo{roles: []entity.Role{sales}},
+			wantCanRead:   true,
+			wantCanUpdate: true,
+		},
+		{
+			name: "role without a grant",
+			repo: &grantRepo{
+				roles:             []entity.Role{sales},
+				objectPermissions: []entity.RoleObjectPermission{{RoleSerial: "support", CanRead: true}},
+			},
+		},
+		{
+			name: "admin",
+			repo: &grantRepo{
+				roles:             []entity.Role{{Serial: "admin", IsAdmin: true}},
+				objectPermissions: []entity.RoleObjectPermission{{RoleSerial: "support", CanRead: true}},
+			},
+			wantCanRead:   true,
+			wantCanUpdate: true,
+		},
+		{
+			name: "field hidden and read only",
+			repo: &grantRepo{
+				roles:             []entity.Role{sales},
+				objectPermissions: []entity.RoleObjectPermission{{RoleSerial: "sales", CanRead: true, CanUpdate: true}},
+				fieldPermissions: []entity.RoleFieldPermission{
+					{RoleSerial: "sales", FieldCode: "salary"},
+					{RoleSerial: "sales", FieldCode: "email", CanRead: true},
+				},
+			},
+			wantCanRead:      true,
+			wantCanUpdate:    true,
+			wantHiddenFields: []string{"salary"},
+			wantReadOnly:     []string{"email", "salary"},
+		},
+		{
+			name: "field readable through another role",
+			repo: &grantRepo{
+				roles: []entity.Role{sales, support},
+				objectPermissions: []entity.RoleObjectPermission{
+					{RoleSerial: "sales", CanRead: true},
+					{RoleSerial: "support", CanRead: true},
+				},
+				fieldPermissions: []entity.RoleFieldPermission{{RoleSerial: "sales", FieldCode: "salary"}},
+			},
+			wantCanRead:  true,
+			wantReadOnly: []string{"salary"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			permission, err := newAccessControl(tt.repo).GetObjectPermission(context.Background(), "acme", "employee")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if permission.CanRead != tt.wantCanRead || permission.CanUpdate != tt.wantCanUpdate {
+				t.Errorf("read %v and update %v, want %v and %v", permission.CanRead, permission.CanUpdate, tt.wantCanRead, tt.wantCanUpdate)
+			}
+
+			assertFieldSet(t, "hidden", permission.HiddenFields, tt.wantHiddenFields)
+			assertFieldSet(t, "read only", permission.ReadOnlyFields, tt.wantReadOnly)
+		})
+	}
+}
+
+func assertFieldSet(t *testing.T, name string, got map[string]bool, want []string) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Errorf("%v fields = %v, want %v", name, got, want)
+		return
+	}
+
+	for _, fieldCode := range want {
+		if !got[fieldCode] {
+			t.Errorf("%v fields = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestCanReadField(t *testing.T) {
+	permission := entity.ObjectPermission{HiddenFields: map[string]bool{"salary": true, "manager_id": true}}
+
+	tests := []struct {
+		fieldCode string
+		want      bool
+	}{
+		{fieldCode: "name", want: true},
+		{fieldCode: "salary", want: false},
+		{fieldCode: "manager_id", want: false},
+		{fieldCode: "manager_id__name", want: false},
+		{fieldCode: "manager_id__email", want: false},
+		{fieldCode: "manager_id__department_id__name", want: false},
+		{fieldCode: "manager_id.email", want: false},
+		{fieldCode: "department_id__name", want: true},
+		{fieldCode: "department_id__salary", want: true},
+		{fieldCode: entity.DisplayFieldSuffix, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fieldCode, func(t *testing.T) {
+			if got := canReadField(permission, tt.fieldCode); got != tt.want {
+				t.Errorf("canReadField(%q) = %v, want %v", tt.fieldCode, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckQueryFields(t *testing.T) {
+	permission := entity.ObjectPermission{HiddenFields: map[string]bool{"manager_id": true}}
+
+	tests := []struct {
+		name      string
+		query     entity.CatalogQuery
+		forbidden bool
+	}{
+		{
+			name: "visible fields",
+			query: entity.CatalogQuery{
+				Fields:  map[string]entity.Field{"name": {FieldCode: "name"}},
+				Filters: []entity.FilterGroup{{Filters: map[string]entity.FilterItem{"name": {Operator: entity.FilterOperatorEqual, Value: "a"}}}},
+				Orders:  []entity.Order{{FieldName: "department_id__name"}},
+			},
+		},
+		{
+			name:      "hidden field selected",
+			query:     entity.CatalogQuery{Fields: map[string]entity.Field{"manager_id": {}}},
+			forbidden: true,
+		},
+		{
+			name:      "hidden foreign key selected through a chain",
+			query:     entity.CatalogQuery{Fields: map[string]entity.Field{"boss": {FieldCode: "manager_id__email"}}},
+			forbidden: true,
+		},
+		{
+			name:      "hidden foreign key filtered through a chain",
+			query:     entity.CatalogQuery{Filters: []entity.FilterGroup{{Filters: map[string]entity.FilterItem{"manager_id__email": {Operator: entity.FilterOperatorEqual, Value: "a"}}}}},
+			forbidden: true,
+		},
+		{
+			name: "hidden field in a nested group",
+			query: entity.CatalogQuery{Filters: []entity.FilterGroup{{
+				Groups: []entity.FilterGroup{{Conditions: []entity.FilterItem{{FieldName: "manager_id", Operator: entity.FilterOperatorIsNull}}}},
+			}}},
+			forbidden: true,
+		},
+		{
+			name:      "hidden foreign key sorted through a chain",
+			query:     entity.CatalogQuery{Orders: []entity.Order{{FieldName: "manager_id__name"}}},
+			forbidden: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkQueryFields(permission, tt.query)
+			if got := errors.Is(err, entity.ErrorForbidden); got != tt.forbidden {
+				t.Errorf("checkQueryFields() = %v, want forbidden %v", err, tt.forbidden)
+			}
+		})
+	}
+}
+
+func TestStripHiddenFields(t *testing.T) {
+	permission := entity.ObjectPermission{HiddenFields: map[string]bool{"manager_id": true}}
+	item := map[string]entity.DataItem{
+		"name":              {Value: "a"},
+		"manager_id":        {Value: 1},
+		"manager_id__name":  {Value: "b"},
+		"manager_id__email": {Value: "c"},
+	}
+
+	item = stripHiddenFields(permission, item)
+	if len(item) != 1 {
+		t.Fatalf("got fields %v, want name only", item)
+	}
+
+	if _, ok := item["name"]; !ok {
+		t.Errorf("name was stripped")
+	}
+}
+
+func TestRowFilters(t *testing.T) {
+	ownRecords := entity.RowPolicy{RoleSerial: "sales", Filters: []entity.FilterGroup{{
+		Filters: map[string]entity.FilterItem{"owner": {Operator: entity.FilterOperatorEqual, Value: "{{user.serial}}"}},
+	}}}
+	ownRegion := entity.RowPolicy{Filters: []entity.FilterGroup{{
+		Conditions: []entity.FilterItem{{FieldName: "region", Operator: entity.FilterOperatorIN, Value: []any{"{{user.region}}", "{{claims.region}}", "global"}}},
+		Groups: []entity.FilterGroup{{
+			Conditions: []entity.FilterItem{{FieldName: "branch", Operator: entity.FilterOperatorEqual, Value: "{{user.branch}}"}},
+		}},
+	}}}
+	supportOnly := entity.RowPolicy{RoleSerial: "support", Filters: []entity.FilterGroup{{
+		Filters: map[string]entity.FilterItem{"is_open": {Operator: entity.FilterOperatorEqual, Value: true}},
+	}}}
+
+	tests := []struct {
+		name      string
+		roles     []entity.Role
+		policies  []entity.RowPolicy
+		want      []string
+		wantReads int
+	}{
+		{
+			name:  "no policy",
+			roles: []entity.Role{{Serial: "sales"}},
+		},
+		{
+			name:     "admin",
+			roles:    []entity.Role{{Serial: "admin", IsAdmin: true}},
+			policies: []entity.RowPolicy{ownRecords},
+		},
+		{
+			name:     "policy of another role",
+			roles:    []entity.Role{{Serial: "sales"}},
+			policies: []entity.RowPolicy{supportOnly},
+		},
+		{
+			name:     "policy of the role",
+			roles:    []entity.Role{{Serial: "sales"}},
+			policies: []entity.RowPolicy{ownRecords, supportOnly},
+			want:     []string{"owner equal u1"},
+		},
+		{
+			name:      "policy of every role resolved in nested groups",
+			roles:     []entity.Role{{Serial: "support"}},
+			policies:  []entity.RowPolicy{ownRegion},
+			want:      []string{"region in [north south global]", "branch equal <nil>"},
+			wantReads: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &grantRepo{roles: tt.roles, rowPolicies: tt.policies, userAttributes: map[string]any{"region": "north"}}
+			ctx := entity.ContextWithAuthUser(context.Background(), entity.AuthUser{Serial: "u1", Claims: map[string]any{"region": "south"}})
+
+			filters, err := newAccessControl(repo).RowFilters(ctx, "acme", "deal")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := []string{}
+			for _, filterGroup := range filters {
+				_, _ = filterGroup.MapItems(func(fieldName string, filter entity.FilterItem) (entity.FilterItem, bool, error) {
+					got = append(got, fmt.Sprintf("%v %v %v", fieldName, filter.Operator, filter.Value))
+					return filter, true, nil
+				})
+			}
+
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) || len(got) != len(tt.want) {
+				t.Errorf("RowFilters() = %v, want %v", got, tt.want)
+			}
+
+			if repo.userAttributeReads != tt.wantReads {
+				t.Errorf("user attributes read %d times, want %d", repo.userAttributeReads, tt.wantReads)
+			}
+		})
+	}
+}
+
+func TestResolvePolicyValueOfUnknownReference(t *testing.T) {
+	_, err := newAccessControl(&grantRepo{}).resolvePolicyValue(context.Background(), "acme", "{{session.id}}")
+	if err == nil {
+		t.Error("resolvePolicyValue() of an unknown reference returned no error")
+	}
+}
+
+// objectGrantRepo is an access repository holding the grants of a single user per object
+type objectGrantRepo struct {
+	roles  []entity.Role
+	grants map[string]*grantRepo
+}
+
+func (r *objectGrantRepo) GetUserRoles(ctx context.Context, tenantCode, userSerial string) (resp []entity.Role, err error) {
+	return r.roles, nil
+}
+
+func (r *objectGrantRepo) GetObjectPermissions(ctx context.Context, tenantCode, objectCode string) (resp []entity.RoleObjectPermission, err error) {
+	return r.object(objectCode).objectPermissions, nil
+}
+
+func (r *objectGrantRepo) GetFieldPermissions(ctx context.Context, tenantCode, objectCode string, roleSerials []string) (resp []entity.RoleFieldPermission, err error) {
+	return r.object(objectCode).fieldPermissions, nil
+}
+
+func (r *objectGrantRepo) GetRowPolicies(ctx context.Context, tenantCode, objectCode string) (resp []entity.RowPolicy, err error) {
+	return r.object(objectCode).rowPolicies, nil
+}
+
+func (r *objectGrantRepo) GetUserAttributes(ctx context.Context, tenantCode, userSerial string) (resp map[string]any, err error) {
+	return nil, nil
+}
+
+func (r *objectGrantRepo) object(objectCode string) *grantRepo {
+	if grants, ok := r.grants[objectCode]; ok {
+		return grants
+	}
+
+	return &grantRepo{}
+}
+
+// foreignKeyRepo is a catalog repository knowing the foreign keys of the objects, keyed by <object>.<field>
+type foreignKeyRepo struct {
+	repository.CatalogRepository
+	foreignKeys map[string]string
+}
+
+func (r *foreignKeyRepo) GetForeignKeyInfo(ctx context.Context, tableName, columnName, schemaName string) (resp entity.ForeignKeyInfo, err error) {
+	if foreignTable, ok := r.foreignKeys[tableName+"."+columnName]; ok {
+		return entity.ForeignKeyInfo{ForeignSchema: schemaName, ForeignTable: foreignTable, ForeignColumn: entity.DEFAULT_IDENTIFIER}, nil
+	}
+
+	return resp, nil
+}
+
+func TestAuthorizeChainedFields(t *testing.T) {
+	sales := entity.Role{Serial: "sales"}
+	catalogRepo := &foreignKeyRepo{foreignKeys: map[string]string{
+		"contact.company_id": "company",
+		"contact.manager_id": "user",
+		"contact.deal_id":    "deal",
+		"contact.vault_id":   "vault",
+		"company.owner_id":   "user",
+	}}
+
+	accessRepo := &objectGrantRepo{roles: []entity.Role{sales}, grants: map[string]*grantRepo{
+		"contact": {
+			objectPermissions: []entity.RoleObjectPermission{{RoleSerial: "sales", CanRead: true}},
+			fieldPermissions:  []entity.RoleFieldPermission{{RoleSerial: "sales", FieldCode: "manager_id"}},
+		},
+		"company": {
+			objectPermissions: []entity.RoleObjectPermission{{RoleSerial: "sales", CanRead: true}},
+			fieldPermissions:  []entity.RoleFieldPermission{{RoleSerial: "sales", FieldCode: "revenue"}},
+		},
+		"user": {
+			objectPermissions: []entity.RoleObjectPermission{{RoleSerial: "sales", CanRead: true}},
+			fieldPermissions:  []entity.RoleFieldPermission{{RoleSerial: "sales", FieldCode: "password_cipher"}},
+		},
+		"vault": {
+			objectPermissions: []entity.RoleObjectPermission{{RoleSerial: "admin", CanRead: true}},
+		},
+		"deal": {
+			rowPolicies: []entity.RowPolicy{{RoleSerial: "sales", Filters: []entity.FilterGroup{{Filters: map[string]entity.FilterItem{"owner": {Operator: entity.FilterOperatorEqual, Value: "u1"}}}}}},
+		},
+	}}
+
+	tests := []struct {
+		name      string
+		fieldName string
+		wantErr   error
+	}{
+		{name: "field of the object", fieldName: "name"},
+		{name: "display value of the object", fieldName: entity.DisplayFieldSuffix},
+		{name: "display value of a related object", fieldName: "company_id__name"},
+		{name: "readable field of a related object", fieldName: "company_id__city"},
+		{name: "hidden field of a related object", fieldName: "company_id__revenue", wantErr: entity.ErrorForbidden},
+		{name: "readable field two objects away", fieldName: "company_id__owner_id__email"},
+		{name: "hidden field two objects away", fieldName: "company_id__owner_id__password_cipher", wantErr: entity.ErrorForbidden},
+		{name: "hidden foreign key of the object", fieldName: "manager_id__email", wantErr: entity.ErrorForbidden},
+		{name: "object that can not be read", fieldName: "vault_id__code", wantErr: entity.ErrorForbidden},
+		{name: "display value of an object that can not be read", fieldName: "vault_id__name", wantErr: entity.ErrorForbidden},
+		{name: "object with row policies", fieldName: "deal_id__title", wantErr: entity.ErrorForbidden},
+		{name: "field that is not a relation", fieldName: "name__code", wantErr: entity.ErrorBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accessControl := newAccessControl(accessRepo)
+
+			permission, err := accessControl.Authorize(context.Background(), "acme", "contact", entity.PermissionRead)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = accessControl.authorizeChainedFields(context.Background(), catalogRepo, "acme", "contact", permission, []string{tt.fieldName})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("authorizeChainedFields() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestQueryFieldNames(t *testing.T) {
+	query := entity.CatalogQuery{
+		Fields: map[string]entity.Field{"name": {}, "company_id__city": {FieldCode: "city"}},
+		Filters: []entity.FilterGroup{{
+			Filters: map[string]entity.FilterItem{"deal_id__title": {Operator: entity.FilterOperatorEqual, Value: "a"}},
+			Groups:  []entity.FilterGroup{{Conditions: []entity.FilterItem{{FieldName: "vault_id__code", Operator: entity.FilterOperatorIsNull}}}},
+		}},
+		Orders: []entity.Order{{FieldName: "company_id__owner_id__email"}},
+	}
+
+	want := "[company_id__city name deal_id__title vault_id__code company_id__owner_id__email]"
+	if got := fmt.Sprint(queryFieldNames(query)); got != want {
+		t.Errorf("queryFieldNames() = %v, want %v", got, want)
+	}
+}
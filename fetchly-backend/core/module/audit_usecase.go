@@ -0,0 +1,92 @@
+package module
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fetchlydev/source/fetchly-backend/config"
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+	"github.com/fetchlydev/source/fetchly-backend/core/repository"
+)
+
+// AuditUsecase reads the audit logs the changes of the records leave, the tenant wide logs for the administrators
+// and the history of a record for the users who can read it
+type AuditUsecase interface {
+	GetAuditLogs(ctx context.Context, request entity.AuditQuery) (resp entity.AuditLogResponse, err error)
+	GetRecordHistory(ctx context.Context, request entity.AuditQuery) (resp entity.AuditLogResponse, err error)
+}
+
+type auditUsecase struct {
+	cfg         config.Config
+	catalogRepo repository.CatalogRepository
+	accessRepo  repository.AccessRepository
+}
+
+func NewAuditUsecase(cfg config.Config, catalogRepo repository.CatalogRepository, accessRepo repository.AccessRepository) AuditUsecase {
+	return &auditUsecase{
+		cfg:         cfg,
+		catalogRepo: catalogRepo,
+		accessRepo:  accessRepo,
+	}
+}
+
+func (uc *auditUsecase) GetAuditLogs(ctx context.Context, request entity.AuditQuery) (resp entity.AuditLogResponse, err error) {
+	if request.From != nil && request.To != nil && !request.From.Before(*request.To) {
+		return resp, fmt.Errorf("%w: from has to be before to", entity.ErrorBadRequest)
+	}
+
+	return uc.catalogRepo.GetAuditLogs(ctx, request)
+}
+
+// GetRecordHistory lists the changes of a record the user can read, the soft deleted record only to the users who
+// can restore it. The changes of the fields hidden from the user are left out.
+func (uc *auditUsecase) GetRecordHistory(ctx context.Context, request entity.AuditQuery) (resp entity.AuditLogResponse, err error) {
+	if request.RecordSerial == "" {
+		return resp, entity.ErrorSerialEmpty
+	}
+
+	accessControl := newAccessControl(uc.accessRepo)
+
+	permission, err := accessControl.Authorize(ctx, request.TenantCode, request.ObjectCode, entity.PermissionRead)
+	if err != nil {
+		return resp, err
+	}
+
+	rowFilters, err := accessControl.RowFilters(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return resp, err
+	}
+
+	record, err := uc.catalogRepo.GetObjectDetail(ctx, entity.CatalogQuery{
+		TenantCode:       request.TenantCode,
+		ObjectCode:       request.ObjectCode,
+		Serial:           request.RecordSerial,
+		Filters:          rowFilters,
+		IsIncludeDeleted: permission.Can(entity.PermissionDelete),
+	})
+	if err != nil {
+		return resp, err
+	}
+
+	if len(record) == 0 {
+		return resp, fmt.Errorf("%w: record %v of object %v", entity.ErrorNotFound, request.RecordSerial, request.ObjectCode)
+	}
+
+	resp, err = uc.GetAuditLogs(ctx, request)
+	if err != nil {
+		return resp, err
+	}
+
+	for i, auditLog := range resp.Items {
+		changes := make([]entity.FieldChange, 0, len(auditLog.Changes))
+		for _, change := range auditLog.Changes {
+			if canReadField(permission, change.FieldCode) {
+				changes = append(changes, change)
+			}
+		}
+
+		resp.Items[i].Changes = changes
+	}
+
+	return resp, nil
+}
@@ -8,7 +8,9 @@ import (
 	"crypto/rsa"
 	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
 	"net/http"
@@ -26,9 +28,14 @@ import (
 type AuthUsecase interface {
 	Login(ctx context.Context, request entity.LoginRequest) (resp entity.LoginResponse, err error)
 	RefreshToken(ctx context.Context, tenantCode, refreshToken string) (resp entity.RefreshTokenResponse, err error)
+	Logout(ctx context.Context, tenantCode, accessToken string, request entity.LogoutRequest) error
 	EncryptPassword(ctx context.Context, tenantCode, plainPwd string) (cipherTextB64, saltB64, ivB64 string, err error)
 	GetCurrentUser(ctx context.Context, tenantCode, accessToken string) (resp map[string]any, err error)
-	GoogleLogin(ctx context.Context, tenantCode, productCode, credential string) (resp entity.GoogleLoginResponse, err error)
+	Authenticate(ctx context.Context, tenantCode, accessToken string) (resp entity.AuthUser, err error)
+	GoogleLogin(ctx context.Context, tenantCode, productCode, credential string, client entity.SessionClient) (resp entity.GoogleLoginResponse, err error)
+	GetUserSessions(ctx context.Context, tenantCode, userSerial string) (resp []entity.AuthSession, err error)
+	RevokeUserSession(ctx context.Context, tenantCode, userSerial, sessionSerial string) error
+	RevokeUserSessions(ctx context.Context, tenantCode, userSerial string) error
 }
 
 type authUsecase struct {
@@ -37,6 +44,9 @@ type authUsecase struct {
 	catalogRepo repository.CatalogRepository
 }
 
+// userExcludedFields are never put into a token nor returned to the client
+var userExcludedFields = []string{"password_cipher", "password_salt", "password_iv", "created_at", "updated_at", "deleted_at", "created_by", "updated_by", "deleted_by"}
+
 func NewAuthUsecase(cfg config.Config, authRepo repository.AuthRepository, catalogRepo repository.CatalogRepository) AuthUsecase {
 	return &authUsecase{
 		cfg:         cfg,
@@ -51,34 +61,15 @@ func (uc *authUsecase) Login(ctx context.Context, request entity.LoginRequest) (
 		return resp, err
 	}
 
-	result, err := uc.catalogRepo.GetObjectData(ctx, entity.CatalogQuery{
-		TenantCode: request.TenantCode,
-		ObjectCode: "user",
-		Filters: []entity.FilterGroup{
-			{
-				Operator: entity.NewFilterGroupOperator(entity.FilterOperatorOr),
-				Filters: map[string]entity.FilterItem{
-					"username":     {Operator: entity.FilterOperatorEqual, Value: request.Username, FieldName: "username"},
-					"email":        {Operator: entity.FilterOperatorEqual, Value: request.Username, FieldName: "email"},
-					"phone_number": {Operator: entity.FilterOperatorEqual, Value: request.Username, FieldName: "phone_number"},
-				},
-			},
-		},
-	})
+	user, err := uc.authRepo.GetUserByLogin(ctx, request.TenantCode, request.Username)
 	if err != nil {
 		return resp, err
 	}
 
-	if len(result.Items) == 0 {
-		return resp, entity.ErrUserNotFound
-	}
-
-	user := result.Items[0]
-
 	// Decrypt the password
-	chiperText := user["password_cipher"].Value.(string)
-	salt := user["password_salt"].Value.(string)
-	iv := user["password_iv"].Value.(string)
+	chiperText, _ := user["password_cipher"].Value.(string)
+	salt, _ := user["password_salt"].Value.(string)
+	iv, _ := user["password_iv"].Value.(string)
 	appSecret := GetAppSecret(request.TenantCode)
 
 	plainPwd, err := DecryptPassword(chiperText, salt, iv, appSecret)
@@ -90,38 +81,181 @@ func (uc *authUsecase) Login(ctx context.Context, request entity.LoginRequest) (
 		return resp, entity.ErrInvalidPassword
 	}
 
-	// Generate JWT token
-	token, err := GenerateJWT(user, request.TenantCode)
+	simplifiedUser := simplifyUser(user)
+
+	resp.Token, resp.RefreshToken, err = uc.openSession(ctx, request.TenantCode, simplifiedUser, request.Client)
 	if err != nil {
 		return resp, err
 	}
 
-	resp.Token = token
+	resp.User = simplifiedUser
 
-	exlucdedFields := []string{"password_cipher", "password_salt", "password_iv", "created_at", "updated_at", "deleted_at", "created_by", "updated_by", "deleted_by"}
+	return
+}
 
-	simplifiedUser := make(map[string]entity.DataItem)
-	for k, v := range user {
-		// Skip excluded fields
-		if !stringInSlice(k, exlucdedFields) {
-			simplifiedUser[k] = v
-		}
+// RefreshToken exchanges a refresh token for a new token pair. Every refresh token can be used once,
+// a token coming back after it was rotated means it leaked, so the whole session is revoked.
+func (uc *authUsecase) RefreshToken(ctx context.Context, tenantCode, refreshToken string) (resp entity.RefreshTokenResponse, err error) {
+	current, err := uc.authRepo.GetRefreshTokenByHash(ctx, tenantCode, hashRefreshToken(refreshToken))
+	if err != nil {
+		return resp, err
+	}
+
+	if current.UsedAt != nil {
+		return resp, uc.revokeReusedSession(ctx, tenantCode, current.SessionSerial)
+	}
+
+	if current.Session.RevokedAt != nil {
+		return resp, entity.ErrSessionRevoked
+	}
+
+	if !current.Session.IsActive() || time.Now().After(current.ExpiresAt) {
+		return resp, entity.ErrRefreshTokenExpired
 	}
 
-	// generate refresh token
-	refreshToken, err := GenerateJWT(simplifiedUser, request.TenantCode)
+	user, err := uc.authRepo.GetUserBySerial(ctx, tenantCode, current.Session.UserSerial)
 	if err != nil {
 		return resp, err
 	}
 
-	resp.RefreshToken = refreshToken
-	resp.User = simplifiedUser
+	newRefreshToken, tokenHash, err := generateRefreshToken()
+	if err != nil {
+		return resp, err
+	}
 
-	return
+	err = uc.authRepo.RotateRefreshToken(ctx, current, entity.RefreshToken{
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(entity.RefreshTokenExpiredHour * time.Hour),
+	})
+	if errors.Is(err, entity.ErrRefreshTokenReused) {
+		// another request rotated the same token first
+		return resp, uc.revokeReusedSession(ctx, tenantCode, current.SessionSerial)
+	}
+
+	if err != nil {
+		return resp, err
+	}
+
+	newToken, err := GenerateJWT(simplifyUser(user), tenantCode, current.Session)
+	if err != nil {
+		return resp, err
+	}
+
+	// Return the new tokens
+	return entity.RefreshTokenResponse{
+		Token:        newToken,
+		RefreshToken: newRefreshToken,
+	}, nil
 }
 
-func (uc *authUsecase) RefreshToken(ctx context.Context, tenantCode, refreshToken string) (resp entity.RefreshTokenResponse, err error) {
-	token, err := jwt.Parse(refreshToken, func(token *jwt.Token) (interface{}, error) {
+// Logout revokes the session of the access token, every token issued for it stops working right away
+func (uc *authUsecase) Logout(ctx context.Context, tenantCode, accessToken string, request entity.LogoutRequest) error {
+	claims, err := uc.parseAccessToken(ctx, tenantCode, accessToken)
+	if err != nil {
+		return err
+	}
+
+	if request.IsAllSessions {
+		userSerial, _ := claims["sub"].(string)
+		return uc.authRepo.RevokeUserSessions(ctx, tenantCode, userSerial, entity.SessionRevokedReasonAllSessions)
+	}
+
+	sessionSerial, _ := claims["sid"].(string)
+	return uc.authRepo.RevokeSession(ctx, tenantCode, sessionSerial, entity.SessionRevokedReasonLogout)
+}
+
+// Authenticate verifies the access token against the tenant and returns the user it was issued to
+func (uc *authUsecase) Authenticate(ctx context.Context, tenantCode, accessToken string) (resp entity.AuthUser, err error) {
+	claims, err := uc.parseAccessToken(ctx, tenantCode, accessToken)
+	if err != nil {
+		return resp, err
+	}
+
+	resp.Serial, _ = claims["sub"].(string)
+	resp.TenantCode, _ = claims["tenant_code"].(string)
+	resp.SessionSerial, _ = claims["sid"].(string)
+	resp.Data, _ = claims["user"].(map[string]any)
+	resp.Claims = claims
+
+	if resp.Serial == "" {
+		return resp, fmt.Errorf("token invalid")
+	}
+
+	return resp, nil
+}
+
+func (uc *authUsecase) GetUserSessions(ctx context.Context, tenantCode, userSerial string) (resp []entity.AuthSession, err error) {
+	return uc.authRepo.GetUserSessions(ctx, tenantCode, userSerial)
+}
+
+func (uc *authUsecase) RevokeUserSession(ctx context.Context, tenantCode, userSerial, sessionSerial string) error {
+	session, err := uc.authRepo.GetSessionBySerial(ctx, tenantCode, sessionSerial)
+	if err != nil {
+		return err
+	}
+
+	if session.UserSerial != userSerial {
+		return entity.ErrSessionNotFound
+	}
+
+	return uc.authRepo.RevokeSession(ctx, tenantCode, sessionSerial, entity.SessionRevokedReasonAdmin)
+}
+
+func (uc *authUsecase) RevokeUserSessions(ctx context.Context, tenantCode, userSerial string) error {
+	return uc.authRepo.RevokeUserSessions(ctx, tenantCode, userSerial, entity.SessionRevokedReasonAdmin)
+}
+
+// openSession persists a new session of user and returns its access and refresh token
+func (uc *authUsecase) openSession(ctx context.Context, tenantCode string, user map[string]entity.DataItem, client entity.SessionClient) (token, refreshToken string, err error) {
+	if user["serial"].Value == nil {
+		return "", "", entity.ErrUserNotFound
+	}
+
+	// the users of an archived tenant can not sign in, its rows are being archived or gone
+	if _, err := uc.catalogRepo.GetTenantByCode(ctx, tenantCode); err != nil {
+		return "", "", err
+	}
+
+	userSerial := fmt.Sprintf("%v", user["serial"].Value)
+
+	refreshToken, tokenHash, err := generateRefreshToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	session, err := uc.authRepo.CreateSession(ctx, entity.AuthSession{
+		TenantCode: tenantCode,
+		UserSerial: userSerial,
+		UserAgent:  client.UserAgent,
+		IPAddress:  client.IPAddress,
+	}, entity.RefreshToken{
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(entity.RefreshTokenExpiredHour * time.Hour),
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	token, err = GenerateJWT(user, tenantCode, session)
+	if err != nil {
+		return "", "", err
+	}
+
+	return token, refreshToken, nil
+}
+
+func (uc *authUsecase) revokeReusedSession(ctx context.Context, tenantCode, sessionSerial string) error {
+	err := uc.authRepo.RevokeSession(ctx, tenantCode, sessionSerial, entity.SessionRevokedReasonTokenReused)
+	if err != nil && !errors.Is(err, entity.ErrSessionNotFound) {
+		return err
+	}
+
+	return entity.ErrRefreshTokenReused
+}
+
+// parseAccessToken verifies the access token and checks its session is still active
+func (uc *authUsecase) parseAccessToken(ctx context.Context, tenantCode, accessToken string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(accessToken, func(token *jwt.Token) (interface{}, error) {
 		if method, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("signing method invalid")
 		} else if method != entity.JWT_SIGNING_METHOD {
@@ -131,37 +265,58 @@ func (uc *authUsecase) RefreshToken(ctx context.Context, tenantCode, refreshToke
 		return []byte(GetJWTSecret(tenantCode)), nil
 	})
 	if err != nil {
-		return resp, err
+		return nil, err
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok || !token.Valid {
-		return resp, fmt.Errorf("token invalid")
+		return nil, fmt.Errorf("token invalid")
 	}
 
-	// Ensure claims contain the user data and check if the token is valid
-	user, ok := claims["user"].(map[string]any)
-	if !ok {
-		return resp, fmt.Errorf("invalid user claims")
+	sessionSerial, _ := claims["sid"].(string)
+	if sessionSerial == "" {
+		return nil, entity.ErrSessionNotFound
 	}
 
-	// Generate a new token and refresh token
-	newToken, err := GenerateJWT(user, tenantCode)
+	session, err := uc.authRepo.GetSessionBySerial(ctx, tenantCode, sessionSerial)
 	if err != nil {
-		return resp, err
+		return nil, err
+	}
+
+	if !session.IsActive() {
+		return nil, entity.ErrSessionRevoked
+	}
+
+	return claims, nil
+}
+
+// simplifyUser drops the credential and audit fields of a user
+func simplifyUser(user map[string]entity.DataItem) map[string]entity.DataItem {
+	simplifiedUser := make(map[string]entity.DataItem)
+	for k, v := range user {
+		// Skip excluded fields
+		if !stringInSlice(k, userExcludedFields) {
+			simplifiedUser[k] = v
+		}
 	}
 
-	// Generate a new refresh token (can reuse the same logic)
-	newRefreshToken, err := GenerateJWT(user, tenantCode)
+	return simplifiedUser
+}
+
+// generateRefreshToken returns a random opaque refresh token and the hash it is stored by
+func generateRefreshToken() (token, tokenHash string, err error) {
+	tokenBytes, err := GenerateRandomBytes(entity.RefreshTokenSize)
 	if err != nil {
-		return resp, err
+		return "", "", err
 	}
 
-	// Return the new tokens
-	return entity.RefreshTokenResponse{
-		Token:        newToken,
-		RefreshToken: newRefreshToken,
-	}, nil
+	token = base64.RawURLEncoding.EncodeToString(tokenBytes)
+	return token, hashRefreshToken(token), nil
+}
+
+func hashRefreshToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
 }
 
 func (uc *authUsecase) EncryptPassword(ctx context.Context, tenantCode, plainPwd string) (cipherTextB64, saltB64, ivB64 string, err error) {
@@ -178,10 +333,12 @@ func GetJWTSecret(tenantCode string) string {
 	return fmt.Sprintf(entity.JWTSecret, tenantCode)
 }
 
-func GenerateJWT[T entity.JWTUserData](user T, tenantCode string) (string, error) {
+func GenerateJWT[T entity.JWTUserData](user T, tenantCode string, session entity.AuthSession) (string, error) {
 	claims := jwt.MapClaims{
 		"user":        user,
 		"tenant_code": tenantCode,
+		"sub":         session.UserSerial,
+		"sid":         session.Serial,
 		"exp":         time.Now().Add(entity.DefaultTokenExpiredHour * time.Hour).Unix(),
 		"iat":         time.Now().Unix(),
 	}
@@ -297,24 +454,11 @@ func stringInSlice(str string, slice []string) bool {
 }
 
 func (uc *authUsecase) GetCurrentUser(ctx context.Context, tenantCode, accessToken string) (resp map[string]any, err error) {
-	token, err := jwt.Parse(accessToken, func(token *jwt.Token) (interface{}, error) {
-		if method, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("signing method invalid")
-		} else if method != entity.JWT_SIGNING_METHOD {
-			return nil, fmt.Errorf("signing method invalid")
-		}
-
-		return []byte(GetJWTSecret(tenantCode)), nil
-	})
+	claims, err := uc.parseAccessToken(ctx, tenantCode, accessToken)
 	if err != nil {
 		return nil, err
 	}
 
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok || !token.Valid {
-		return nil, fmt.Errorf("token invalid")
-	}
-
 	user, ok := claims["user"]
 	if !ok {
 		return nil, fmt.Errorf("user field not found in claims")
@@ -328,7 +472,7 @@ func (uc *authUsecase) GetCurrentUser(ctx context.Context, tenantCode, accessTok
 	return userMap, nil
 }
 
-func (uc *authUsecase) GoogleLogin(ctx context.Context, tenantCode, productCode, credential string) (resp entity.GoogleLoginResponse, err error) {
+func (uc *authUsecase) GoogleLogin(ctx context.Context, tenantCode, productCode, credential string, client entity.SessionClient) (resp entity.GoogleLoginResponse, err error) {
 	// First, get the token header without verification to extract the key ID
 	segments := strings.Split(credential, ".")
 	if len(segments) != 3 {
@@ -430,24 +574,8 @@ func (uc *authUsecase) GoogleLogin(ctx context.Context, tenantCode, productCode,
 	sub, _ := claims["sub"].(string)
 
 	// Check if user exists
-	result, err := uc.catalogRepo.GetObjectData(ctx, entity.CatalogQuery{
-		TenantCode: tenantCode,
-		ObjectCode: "user",
-		Filters: []entity.FilterGroup{
-			{
-				Operator: entity.NewFilterGroupOperator(entity.FilterOperatorOr),
-				Filters: map[string]entity.FilterItem{
-					"email": {Operator: entity.FilterOperatorEqual, Value: email, FieldName: "email"},
-				},
-			},
-		},
-	})
-	if err != nil {
-		return resp, err
-	}
-
-	var user map[string]entity.DataItem
-	if len(result.Items) == 0 {
+	user, err := uc.authRepo.GetUserByLogin(ctx, tenantCode, email)
+	if errors.Is(err, entity.ErrUserNotFound) {
 		// Create new user
 		items := []entity.DataItem{
 			{FieldCode: "email", Value: email},
@@ -468,54 +596,21 @@ func (uc *authUsecase) GoogleLogin(ctx context.Context, tenantCode, productCode,
 		}
 
 		// Fetch the newly created user
-		result, err = uc.catalogRepo.GetObjectData(ctx, entity.CatalogQuery{
-			TenantCode: tenantCode,
-			ObjectCode: "user",
-			Filters: []entity.FilterGroup{
-				{
-					Operator: entity.NewFilterGroupOperator(entity.FilterOperatorOr),
-					Filters: map[string]entity.FilterItem{
-						"email": {Operator: entity.FilterOperatorEqual, Value: email, FieldName: "email"},
-					},
-				},
-			},
-		})
+		user, err = uc.authRepo.GetUserByLogin(ctx, tenantCode, email)
 		if err != nil {
 			return resp, fmt.Errorf("failed to fetch created user: %v", err)
 		}
-
-		if len(result.Items) == 0 {
-			return resp, fmt.Errorf("user was created but could not be fetched")
-		}
-
-		user = result.Items[0]
-	} else {
-		user = result.Items[0]
-	}
-
-	// Generate JWT token
-	jwtToken, err := GenerateJWT(user, tenantCode)
-	if err != nil {
+	} else if err != nil {
 		return resp, err
 	}
 
-	resp.Token = jwtToken
-
-	// Generate refresh token
-	excludedFields := []string{"password_cipher", "password_salt", "password_iv", "created_at", "updated_at", "deleted_at", "created_by", "updated_by", "deleted_by"}
-	simplifiedUser := make(map[string]entity.DataItem)
-	for k, v := range user {
-		if !stringInSlice(k, excludedFields) {
-			simplifiedUser[k] = v
-		}
-	}
+	simplifiedUser := simplifyUser(user)
 
-	refreshToken, err := GenerateJWT(simplifiedUser, tenantCode)
+	resp.Token, resp.RefreshToken, err = uc.openSession(ctx, tenantCode, simplifiedUser, client)
 	if err != nil {
 		return resp, err
 	}
 
-	resp.RefreshToken = refreshToken
 	resp.User = simplifiedUser
 
 	return resp, nil
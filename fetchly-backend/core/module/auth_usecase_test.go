@@ -0,0 +1,255 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+	"github.com/fetchlydev/source/fetchly-backend/core/repository"
+)
+
+// sessionRepo is an auth repository keeping the sessions and refresh tokens in memory, every user exists
+type sessionRepo struct {
+	repository.AuthRepository
+	sessions map[string]entity.AuthSession
+	tokens   map[string]entity.RefreshToken
+}
+
+func newSessionRepo() *sessionRepo {
+	return &sessionRepo{sessions: map[string]entity.AuthSession{}, tokens: map[string]entity.RefreshToken{}}
+}
+
+func (r *sessionRepo) GetUserBySerial(ctx context.Context, tenantCode, userSerial string) (resp map[string]entity.DataItem, err error) {
+	return map[string]entity.DataItem{"serial": {FieldCode: "serial", Value: userSerial}}, nil
+}
+
+func (r *sessionRepo) CreateSession(ctx context.Context, session entity.AuthSession, refreshToken entity.RefreshToken) (resp entity.AuthSession, err error) {
+	session.Serial = fmt.Sprintf("session%d", len(r.sessions)+1)
+	session.ExpiresAt = refreshToken.ExpiresAt
+	r.sessions[session.Serial] = session
+
+	refreshToken.Serial = fmt.Sprintf("token%d", len(r.tokens)+1)
+	refreshToken.SessionSerial = session.Serial
+	r.tokens[refreshToken.TokenHash] = refreshToken
+
+	return session, nil
+}
+
+func (r *sessionRepo) GetSessionBySerial(ctx context.Context, tenantCode, sessionSerial string) (resp entity.AuthSession, err error) {
+	session, ok := r.sessions[sessionSerial]
+	if !ok || session.TenantCode != tenantCode {
+		return resp, entity.ErrSessionNotFound
+	}
+
+	return session, nil
+}
+
+func (r *sessionRepo) GetRefreshTokenByHash(ctx context.Context, tenantCode, tokenHash string) (resp entity.RefreshToken, err error) {
+	token, ok := r.tokens[tokenHash]
+	if !ok {
+		return resp, entity.ErrInvalidRefreshToken
+	}
+
+	token.Session, err = r.GetSessionBySerial(ctx, tenantCode, token.SessionSerial)
+	if err != nil {
+		return resp, entity.ErrInvalidRefreshToken
+	}
+
+	return token, nil
+}
+
+func (r *sessionRepo) RotateRefreshToken(ctx context.Context, current, next entity.RefreshToken) error {
+	stored := r.tokens[current.TokenHash]
+	if stored.UsedAt != nil {
+		return entity.ErrRefreshTokenReused
+	}
+
+	now := time.Now()
+	stored.UsedAt = &now
+	r.tokens[current.TokenHash] = stored
+
+	next.Serial = fmt.Sprintf("token%d", len(r.tokens)+1)
+	next.SessionSerial = current.SessionSerial
+	r.tokens[next.TokenHash] = next
+
+	return nil
+}
+
+func (r *sessionRepo) RevokeSession(ctx context.Context, tenantCode, sessionSerial, reason string) error {
+	session, err := r.GetSessionBySerial(ctx, tenantCode, sessionSerial)
+	if err != nil || session.RevokedAt != nil {
+		return entity.ErrSessionNotFound
+	}
+
+	now := time.Now()
+	session.RevokedAt = &now
+	session.RevokedReason = reason
+	r.sessions[sessionSerial] = session
+
+	return nil
+}
+
+func (r *sessionRepo) RevokeUserSessions(ctx context.Context, tenantCode, userSerial, reason string) error {
+	for serial, session := range r.sessions {
+		if session.TenantCode == tenantCode && session.UserSerial == userSerial && session.RevokedAt == nil {
+			r.RevokeSession(ctx, tenantCode, serial, reason)
+		}
+	}
+
+	return nil
+}
+
+func (r *sessionRepo) RevokeTenantSessions(ctx context.Context, tenantCode, reason string) error {
+	for serial, session := range r.sessions {
+		if session.TenantCode == tenantCode && session.RevokedAt == nil {
+			r.RevokeSession(ctx, tenantCode, serial, reason)
+		}
+	}
+
+	return nil
+}
+
+func openTestSession(t *testing.T, uc *authUsecase, userSerial string) (token, refreshToken string) {
+	t.Helper()
+
+	user := map[string]entity.DataItem{"serial": {FieldCode: "serial", Value: userSerial}}
+	token, refreshToken, err := uc.openSession(context.Background(), "acme", user, entity.SessionClient{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return token, refreshToken
+}
+
+func TestRefreshToken(t *testing.T) {
+	repo := newSessionRepo()
+	uc := &authUsecase{authRepo: repo, catalogRepo: &offboardedTenantRepo{}}
+	ctx := context.Background()
+
+	_, first := openTestSession(t, uc, "ann")
+
+	rotated, err := uc.RefreshToken(ctx, "acme", first)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rotated.RefreshToken == first || rotated.Token == "" {
+		t.Fatalf("RefreshToken() = %+v, want a new token pair", rotated)
+	}
+
+	user, err := uc.Authenticate(ctx, "acme", rotated.Token)
+	if err != nil || user.Serial != "ann" || user.SessionSerial != "session1" {
+		t.Fatalf("Authenticate() = %+v, %v, want ann of session1", user, err)
+	}
+
+	// the rotated token keeps working until the first one comes back
+	latest, err := uc.RefreshToken(ctx, "acme", rotated.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		refreshToken string
+		wantErr      error
+	}{
+		{name: "unknown token", refreshToken: "unknown", wantErr: entity.ErrInvalidRefreshToken},
+		{name: "reused token revokes the session", refreshToken: first, wantErr: entity.ErrRefreshTokenReused},
+		{name: "latest token of the revoked session", refreshToken: latest.RefreshToken, wantErr: entity.ErrSessionRevoked},
+		{name: "reused token of the revoked session", refreshToken: rotated.RefreshToken, wantErr: entity.ErrRefreshTokenReused},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := uc.RefreshToken(ctx, "acme", tt.refreshToken); !errors.Is(err, tt.wantErr) {
+				t.Errorf("RefreshToken() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if session := repo.sessions["session1"]; session.RevokedReason != entity.SessionRevokedReasonTokenReused {
+		t.Errorf("session revoked for %q, want %q", session.RevokedReason, entity.SessionRevokedReasonTokenReused)
+	}
+
+	// the access tokens of the session stop working with it
+	if _, err := uc.Authenticate(ctx, "acme", latest.Token); !errors.Is(err, entity.ErrSessionRevoked) {
+		t.Errorf("Authenticate() error = %v, want %v", err, entity.ErrSessionRevoked)
+	}
+}
+
+func TestRefreshTokenOfAnotherTenant(t *testing.T) {
+	uc := &authUsecase{authRepo: newSessionRepo(), catalogRepo: &offboardedTenantRepo{}}
+
+	_, refreshToken := openTestSession(t, uc, "ann")
+
+	if _, err := uc.RefreshToken(context.Background(), "globex", refreshToken); !errors.Is(err, entity.ErrInvalidRefreshToken) {
+		t.Errorf("RefreshToken() error = %v, want %v", err, entity.ErrInvalidRefreshToken)
+	}
+}
+
+func TestLogout(t *testing.T) {
+	tests := []struct {
+		name          string
+		isAllSessions bool
+		wantRevoked   []string
+		wantReason    string
+	}{
+		{name: "current session", wantRevoked: []string{"session1"}, wantReason: entity.SessionRevokedReasonLogout},
+		{name: "all sessions", isAllSessions: true, wantRevoked: []string{"session1", "session2"}, wantReason: entity.SessionRevokedReasonAllSessions},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newSessionRepo()
+			uc := &authUsecase{authRepo: repo, catalogRepo: &offboardedTenantRepo{}}
+			ctx := context.Background()
+
+			token, refreshToken := openTestSession(t, uc, "ann")
+			otherToken, _ := openTestSession(t, uc, "ann")
+			bobToken, _ := openTestSession(t, uc, "bob")
+
+			if err := uc.Logout(ctx, "acme", token, entity.LogoutRequest{IsAllSessions: tt.isAllSessions}); err != nil {
+				t.Fatal(err)
+			}
+
+			revoked := []string{}
+			for _, serial := range []string{"session1", "session2", "session3"} {
+				if session := repo.sessions[serial]; session.RevokedAt != nil {
+					revoked = append(revoked, serial)
+
+					if session.RevokedReason != tt.wantReason {
+						t.Errorf("%v revoked for %q, want %q", serial, session.RevokedReason, tt.wantReason)
+					}
+				}
+			}
+
+			if fmt.Sprint(revoked) != fmt.Sprint(tt.wantRevoked) {
+				t.Errorf("revoked %v, want %v", revoked, tt.wantRevoked)
+			}
+
+			if _, err := uc.Authenticate(ctx, "acme", token); !errors.Is(err, entity.ErrSessionRevoked) {
+				t.Errorf("Authenticate() error = %v, want %v", err, entity.ErrSessionRevoked)
+			}
+
+			if _, err := uc.RefreshToken(ctx, "acme", refreshToken); !errors.Is(err, entity.ErrSessionRevoked) {
+				t.Errorf("RefreshToken() error = %v, want %v", err, entity.ErrSessionRevoked)
+			}
+
+			if _, err := uc.Authenticate(ctx, "acme", otherToken); (err != nil) != tt.isAllSessions {
+				t.Errorf("Authenticate() of the other session error = %v", err)
+			}
+
+			if _, err := uc.Authenticate(ctx, "acme", bobToken); err != nil {
+				t.Errorf("Authenticate() of another user error = %v", err)
+			}
+
+			// logging out twice fails as the session is gone
+			if err := uc.Logout(ctx, "acme", token, entity.LogoutRequest{}); !errors.Is(err, entity.ErrSessionRevoked) {
+				t.Errorf("Logout() again error = %v, want %v", err, entity.ErrSessionRevoked)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package module
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+)
+
+// AggregateObjectData groups the records of the object the user can read and shapes the result into the
+// series of a chart. The row policies of the user apply like on GetObjectData.
+func (uc *catalogUsecase) AggregateObjectData(ctx context.Context, request entity.AggregateQuery) (resp entity.AggregateResponse, err error) {
+	accessControl := newAccessControl(uc.accessRepo)
+
+	permission, err := accessControl.Authorize(ctx, request.TenantCode, request.ObjectCode, entity.PermissionRead)
+	if err != nil {
+		return resp, err
+	}
+
+	request, err = normalizeAggregateQuery(request)
+	if err != nil {
+		return resp, err
+	}
+
+	if err := checkQueryFields(permission, entity.CatalogQuery{Filters: request.Filters}); err != nil {
+		return resp, err
+	}
+
+	// grouping or aggregating a hidden field reveals its values as much as reading it
+	for _, group := range request.Groups {
+		if !canReadField(permission, group.FieldName) {
+			return resp, fmt.Errorf("%w: field %v can not be grouped", entity.ErrorForbidden, group.FieldName)
+		}
+	}
+
+	for _, metric := range request.Metrics {
+		if metric.FieldName != "" && !canReadField(permission, metric.FieldName) {
+			return resp, fmt.Errorf("%w: field %v can not be aggregated", entity.ErrorForbidden, metric.FieldName)
+		}
+	}
+
+	fieldNames := queryFieldNames(entity.CatalogQuery{Filters: request.Filters})
+	for _, group := range request.Groups {
+		fieldNames = append(fieldNames, group.FieldName)
+	}
+
+	for _, metric := range request.Metrics {
+		fieldNames = append(fieldNames, metric.FieldName)
+	}
+
+	if err := accessControl.authorizeChainedFields(ctx, uc.catalogRepo, request.TenantCode, request.ObjectCode, permission, fieldNames); err != nil {
+		return resp, err
+	}
+
+	rowFilters, err := accessControl.RowFilters(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return resp, err
+	}
+
+	request.Filters = append(request.Filters, rowFilters...)
+
+	rows, err := uc.catalogRepo.AggregateObjectData(ctx, request)
+	if err != nil {
+		return resp, err
+	}
+
+	return toAggregateResponse(request, rows), nil
+}
+
+// normalizeAggregateQuery validates the groups and metrics and fills in their default aliases
+func normalizeAggregateQuery(request entity.AggregateQuery) (entity.AggregateQuery, error) {
+	if len(request.Metrics) == 0 {
+		return request, fmt.Errorf("%w: at least one metric is required", entity.ErrorBadRequest)
+	}
+
+	aliases := make(map[string]bool)
+	addAlias := func(alias string) error {
+		if aliases[alias] {
+			return fmt.Errorf("%w: alias %v is used more than once", entity.ErrorBadRequest, alias)
+		}
+
+		aliases[alias] = true
+		return nil
+	}
+
+	groups := make([]entity.AggregateGroup, 0, len(request.Groups))
+	for _, group := range request.Groups {
+		if group.FieldName == "" {
+			return request, fmt.Errorf("%w: group field is required", entity.ErrorBadRequest)
+		}
+
+		switch group.Bucket {
+		case "", entity.DateBucketDay, entity.DateBucketWeek, entity.DateBucketMonth:
+		default:
+			return request, fmt.Errorf("%w: unsupported date bucket %v", entity.ErrorBadRequest, group.Bucket)
+		}
+
+		if group.Alias == "" {
+			group.Alias = group.FieldName
+		}
+
+		if err := addAlias(group.Alias); err != nil {
+			return request, err
+		}
+
+		groups = append(groups, group)
+	}
+
+	metrics := make([]entity.AggregateMetric, 0, len(request.Metrics))
+	for _, metric := range request.Metrics {
+		switch metric.Function {
+		case entity.AggregateFunctionCount:
+		case entity.AggregateFunctionCountDistinct, entity.AggregateFunctionSum, entity.AggregateFunctionAvg, entity.AggregateFunctionMin, entity.AggregateFunctionMax:
+			if metric.FieldName == "" {
+				return request, fmt.Errorf("%w: %v needs a field", entity.ErrorBadRequest, metric.Function)
+			}
+		default:
+			return request, fmt.Errorf("%w: unsupported aggregate function %v", entity.ErrorBadRequest, metric.Function)
+		}
+
+		if metric.Alias == "" {
+			metric.Alias = string(metric.Function)
+			if metric.FieldName != "" {
+				metric.Alias = fmt.Sprintf("%v_%v", metric.Function, metric.FieldName)
+			}
+		}
+
+		if err := addAlias(metric.Alias); err != nil {
+			return request, err
+		}
+
+		metrics = append(metrics, metric)
+	}
+
+	request.Groups = groups
+	request.Metrics = metrics
+
+	return request, nil
+}
+
+// toAggregateResponse pivots the rows into chart series. The first group is the x axis, the values of the
+// other groups split every metric into a series per value. A group without a row for an x axis value is nil there.
+func toAggregateResponse(request entity.AggregateQuery, rows []map[string]any) (resp entity.AggregateResponse) {
+	resp.Rows = rows
+	if resp.Rows == nil {
+		resp.Rows = []map[string]any{}
+	}
+
+	for _, metric := range request.Metrics {
+		resp.YAxis = append(resp.YAxis, metric.Alias)
+	}
+
+	xIndexes := make(map[string]int)
+	for _, row := range rows {
+		var xValue any
+		if len(request.Groups) > 0 {
+			xValue = row[request.Groups[0].Alias]
+		}
+
+		xKey := fmt.Sprintf("%v", xValue)
+		if _, ok := xIndexes[xKey]; !ok {
+			xIndexes[xKey] = len(resp.XAxis)
+			resp.XAxis = append(resp.XAxis, xValue)
+		}
+	}
+
+	seriesIndexes := make(map[string]int)
+	for _, row := range rows {
+		var xValue any
+		if len(request.Groups) > 0 {
+			xValue = row[request.Groups[0].Alias]
+		}
+
+		splitValues := make([]string, 0, len(request.Groups))
+		for _, group := range request.Groups[min(len(request.Groups), 1):] {
+			splitValues = append(splitValues, fmt.Sprintf("%v", row[group.Alias]))
+		}
+
+		for _, metric := range request.Metrics {
+			name := metric.Alias
+			if len(splitValues) > 0 {
+				name = strings.Join(splitValues, " / ")
+				if len(request.Metrics) > 1 {
+					name = fmt.Sprintf("%v - %v", name, metric.Alias)
+				}
+			}
+
+			index, ok := seriesIndexes[name]
+			if !ok {
+				index = len(resp.Series)
+				seriesIndexes[name] = index
+				resp.Series = append(resp.Series, entity.AggregateSeries{Name: name, Data: make([]any, len(resp.XAxis))})
+			}
+
+			resp.Series[index].Data[xIndexes[fmt.Sprintf("%v", xValue)]] = row[metric.Alias]
+		}
+	}
+
+	return resp
+}
@@ -0,0 +1,169 @@
+package module
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+)
+
+func TestNormalizeAggregateQuery(t *testing.T) {
+	tests := []struct {
+		name        string
+		query       entity.AggregateQuery
+		wantAliases []string
+		wantErr     bool
+	}{
+		{
+			name: "default aliases",
+			query: entity.AggregateQuery{
+				Groups: []entity.AggregateGroup{{FieldName: "stage"}, {FieldName: "closed_at", Bucket: entity.DateBucketWeek, Alias: "week"}},
+				Metrics: []entity.AggregateMetric{
+					{Function: entity.AggregateFunctionCount},
+					{Function: entity.AggregateFunctionSum, FieldName: "amount"},
+					{Function: entity.AggregateFunctionMax, FieldName: "amount", Alias: "largest"},
+				},
+			},
+			wantAliases: []string{"stage", "week", "count", "sum_amount", "largest"},
+		},
+		{
+			name:    "no metric",
+			query:   entity.AggregateQuery{Groups: []entity.AggregateGroup{{FieldName: "stage"}}},
+			wantErr: true,
+		},
+		{
+			name: "group without a field",
+			query: entity.AggregateQuery{
+				Groups:  []entity.AggregateGroup{{Alias: "stage"}},
+				Metrics: []entity.AggregateMetric{{Function: entity.AggregateFunctionCount}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unsupported bucket",
+			query: entity.AggregateQuery{
+				Groups:  []entity.AggregateGroup{{FieldName: "closed_at", Bucket: "quarter"}},
+				Metrics: []entity.AggregateMetric{{Function: entity.AggregateFunctionCount}},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "average without a field",
+			query:   entity.AggregateQuery{Metrics: []entity.AggregateMetric{{Function: entity.AggregateFunctionAvg}}},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported function",
+			query:   entity.AggregateQuery{Metrics: []entity.AggregateMetric{{Function: "median", FieldName: "amount"}}},
+			wantErr: true,
+		},
+		{
+			name: "alias used twice",
+			query: entity.AggregateQuery{
+				Groups:  []entity.AggregateGroup{{FieldName: "count"}},
+				Metrics: []entity.AggregateMetric{{Function: entity.AggregateFunctionCount}},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := normalizeAggregateQuery(tt.query)
+			if tt.wantErr {
+				if !errors.Is(err, entity.ErrorBadRequest) {
+					t.Errorf("normalizeAggregateQuery() error = %v, want a bad request", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			aliases := []string{}
+			for _, group := range query.Groups {
+				aliases = append(aliases, group.Alias)
+			}
+			for _, metric := range query.Metrics {
+				aliases = append(aliases, metric.Alias)
+			}
+
+			if fmt.Sprint(aliases) != fmt.Sprint(tt.wantAliases) {
+				t.Errorf("aliases = %v, want %v", aliases, tt.wantAliases)
+			}
+		})
+	}
+}
+
+func TestToAggregateResponse(t *testing.T) {
+	count := entity.AggregateMetric{Function: entity.AggregateFunctionCount, Alias: "deals"}
+	total := entity.AggregateMetric{Function: entity.AggregateFunctionSum, FieldName: "amount", Alias: "total"}
+	month := entity.AggregateGroup{FieldName: "closed_at", Alias: "month"}
+	stage := entity.AggregateGroup{FieldName: "stage", Alias: "stage"}
+
+	tests := []struct {
+		name       string
+		query      entity.AggregateQuery
+		rows       []map[string]any
+		wantXAxis  string
+		wantSeries []string
+	}{
+		{
+			name:       "no group",
+			query:      entity.AggregateQuery{Metrics: []entity.AggregateMetric{count}},
+			rows:       []map[string]any{{"deals": 4}},
+			wantXAxis:  "[<nil>]",
+			wantSeries: []string{"deals [4]"},
+		},
+		{
+			name:  "a series per metric",
+			query: entity.AggregateQuery{Groups: []entity.AggregateGroup{month}, Metrics: []entity.AggregateMetric{count, total}},
+			rows: []map[string]any{
+				{"month": "2024-05", "deals": 2, "total": 150},
+				{"month": "2024-06", "deals": 1, "total": 20},
+			},
+			wantXAxis:  "[2024-05 2024-06]",
+			wantSeries: []string{"deals [2 1]", "total [150 20]"},
+		},
+		{
+			name:  "a series per value of the second group",
+			query: entity.AggregateQuery{Groups: []entity.AggregateGroup{month, stage}, Metrics: []entity.AggregateMetric{count}},
+			rows: []map[string]any{
+				{"month": "2024-05", "stage": "won", "deals": 2},
+				{"month": "2024-06", "stage": "lost", "deals": 1},
+				{"month": "2024-06", "stage": "won", "deals": 1},
+			},
+			wantXAxis:  "[2024-05 2024-06]",
+			wantSeries: []string{"won [2 1]", "lost [<nil> 1]"},
+		},
+		{
+			name:  "a series per value and metric",
+			query: entity.AggregateQuery{Groups: []entity.AggregateGroup{month, stage}, Metrics: []entity.AggregateMetric{count, total}},
+			rows: []map[string]any{
+				{"month": "2024-05", "stage": "won", "deals": 2, "total": 150},
+			},
+			wantXAxis:  "[2024-05]",
+			wantSeries: []string{"won - deals [2]", "won - total [150]"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := toAggregateResponse(tt.query, tt.rows)
+
+			if got := fmt.Sprint(resp.XAxis); got != tt.wantXAxis {
+				t.Errorf("x axis = %v, want %v", got, tt.wantXAxis)
+			}
+
+			series := []string{}
+			for _, s := range resp.Series {
+				series = append(series, fmt.Sprintf("%v %v", s.Name, s.Data))
+			}
+
+			if fmt.Sprint(series) != fmt.Sprint(tt.wantSeries) {
+				t.Errorf("series = %v, want %v", series, tt.wantSeries)
+			}
+		})
+	}
+}
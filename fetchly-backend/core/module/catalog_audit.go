@@ -0,0 +1,75 @@
+package module
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+)
+
+// newAuditLog records a change of a record made by the user of the request, under the ID of the HTTP request
+func newAuditLog(ctx context.Context, tenantCode, objectCode, userSerial string, recordSerial any, action entity.AuditAction, changes []entity.FieldChange) entity.AuditLog {
+	return entity.AuditLog{
+		TenantCode:   tenantCode,
+		ObjectCode:   objectCode,
+		RecordSerial: fmt.Sprintf("%v", recordSerial),
+		Action:       action,
+		UserSerial:   userSerial,
+		RequestID:    entity.RequestIDFromContext(ctx),
+		Changes:      changes,
+	}
+}
+
+// createdFieldChanges lists the fields of a created record with their value, the standard columns left out
+func createdFieldChanges(record map[string]entity.DataItem) []entity.FieldChange {
+	return updatedFieldChanges(nil, record)
+}
+
+// updatedFieldChanges lists the fields whose value differs between the record before and after a change, the
+// standard columns left out as every change sets them
+func updatedFieldChanges(existing, updated map[string]entity.DataItem) []entity.FieldChange {
+	fieldCodes := make([]string, 0, len(updated))
+	for fieldCode := range updated {
+		if !entity.IsStandardColumn(fieldCode) {
+			fieldCodes = append(fieldCodes, fieldCode)
+		}
+	}
+	sort.Strings(fieldCodes)
+
+	changes := []entity.FieldChange{}
+	for _, fieldCode := range fieldCodes {
+		var oldValue any
+		if item, ok := existing[fieldCode]; ok {
+			oldValue = item.Value
+		}
+
+		newValue := updated[fieldCode].Value
+		if existing != nil && reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+
+		changes = append(changes, entity.FieldChange{FieldCode: fieldCode, OldValue: oldValue, NewValue: newValue})
+	}
+
+	return changes
+}
+
+// deletionAuditLogs records the soft deletion or the restore of rows, as read before the change
+func deletionAuditLogs(ctx context.Context, deletion entity.DeletionRequest, rows []map[string]any) []entity.AuditLog {
+	action := entity.AuditActionDelete
+	var deletedAt any = deletion.DeletedAt
+	if deletion.IsRestore {
+		action = entity.AuditActionRestore
+		deletedAt = nil
+	}
+
+	logs := make([]entity.AuditLog, 0, len(rows))
+	for _, row := range rows {
+		changes := []entity.FieldChange{{FieldCode: "deleted_at", OldValue: row["deleted_at"], NewValue: deletedAt}}
+		logs = append(logs, newAuditLog(ctx, deletion.TenantCode, deletion.ObjectCode, deletion.UserSerial, row[entity.DEFAULT_IDENTIFIER], action, changes))
+	}
+
+	return logs
+}
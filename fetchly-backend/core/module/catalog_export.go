@@ -0,0 +1,283 @@
+package module
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+	"github.com/fetchlydev/source/fetchly-backend/pkg/helper"
+)
+
+var metadataFieldNames = []string{"created at", "created by", "updated at", "updated by", "deleted at", "deleted by"}
+
+// exportRowWriter writes the header and rows of an export in one of the supported formats
+type exportRowWriter interface {
+	WriteHeader(keys, headers []string) error
+	WriteRow(keys []string, values []string, items map[string]entity.DataItem) error
+	Flush() error
+	Close() error
+}
+
+// StreamExportObjectData pages through the whole filtered result set with a server side cursor and
+// writes every batch straight into w, so the export never holds more than one batch in memory.
+// onProgress, when set, receives the number of rows written after every batch.
+func (uc *catalogUsecase) StreamExportObjectData(ctx context.Context, request entity.CatalogQuery, format entity.ExportFormat, isIncludeMetadata bool, w io.Writer, onProgress func(processedRows int)) (err error) {
+	var writer exportRowWriter
+	switch format {
+	case entity.ExportFormatCSV:
+		writer = &csvExportWriter{writer: csv.NewWriter(w)}
+	case entity.ExportFormatXLSX:
+		writer = &xlsxExportWriter{writer: helper.NewXLSXStreamWriter(w, "Sheet One")}
+	case entity.ExportFormatNDJSON:
+		writer = &ndjsonExportWriter{encoder: json.NewEncoder(w)}
+	default:
+		return fmt.Errorf("unsupported export format: %s", format)
+	}
+
+	accessControl := newAccessControl(uc.accessRepo)
+
+	permission, err := accessControl.Authorize(ctx, request.TenantCode, request.ObjectCode, entity.PermissionExport)
+	if err != nil {
+		return err
+	}
+
+	if err := checkQueryFields(permission, request); err != nil {
+		return err
+	}
+
+	if err := accessControl.authorizeChainedFields(ctx, uc.catalogRepo, request.TenantCode, request.ObjectCode, permission, queryFieldNames(request)); err != nil {
+		return err
+	}
+
+	request, err = uc.composeViewQuery(ctx, request)
+	if err != nil {
+		return err
+	}
+
+	rowFilters, err := accessControl.RowFilters(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return err
+	}
+
+	request.Filters = append(request.Filters, rowFilters...)
+	request.ExcludedSearchFields = hiddenFieldCodes(permission)
+
+	request, objectFields, err := uc.getObjectFieldMap(ctx, request)
+	if err != nil {
+		return err
+	}
+
+	// the columns are those of the object the user can read, an export without rows still has its header
+	columns, _, _, _, err := uc.catalogRepo.GetColumnList(ctx, request)
+	if err != nil {
+		return err
+	}
+
+	keys, headers := exportColumns(request, stripHiddenFields(permission, decorateItems(request, objectFields, columnItem(columns))), isIncludeMetadata)
+	if err := writer.WriteHeader(keys, headers); err != nil {
+		return err
+	}
+
+	processedRows := 0
+	err = uc.catalogRepo.StreamObjectData(ctx, request, uc.cfg.ExportBatchSize, func(items []map[string]entity.DataItem) error {
+		for _, item := range items {
+			item = stripHiddenFields(permission, decorateItems(request, objectFields, item))
+
+			values := make([]string, len(keys))
+			for i, key := range keys {
+				values[i] = formatExportValue(item[key].DisplayValue)
+			}
+
+			if err := writer.WriteRow(keys, values, item); err != nil {
+				return err
+			}
+		}
+
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+
+		if flusher, ok := w.(interface{ Flush() }); ok {
+			flusher.Flush()
+		}
+
+		processedRows += len(items)
+		if onProgress != nil {
+			onProgress(processedRows)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return writer.Close()
+}
+
+// ExportFileName returns the suggested file name of an export
+func ExportFileName(objectCode string, format entity.ExportFormat) string {
+	return fmt.Sprintf("%s_%s.%s", objectCode, time.Now().Format("20060102_150405"), format)
+}
+
+// exportColumns returns the item keys to export and their headers. The <field>__name helper columns
+// are left out since the foreign key column already carries the display value.
+func exportColumns(request entity.CatalogQuery, item map[string]entity.DataItem, isIncludeMetadata bool) (keys, headers []string) {
+	for key, dataItem := range item {
+		if baseKey, ok := strings.CutSuffix(key, entity.DisplayFieldSuffix); ok {
+			if baseItem, ok := item[baseKey]; ok && baseItem.AdditionalData["foreign_table_name"] != nil {
+				continue
+			}
+		}
+
+		if !isIncludeMetadata && isMetadataField(dataItem.FieldName) {
+			continue
+		}
+
+		keys = append(keys, key)
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool {
+		orderI, orderJ := request.Fields[keys[i]].FieldOrder, request.Fields[keys[j]].FieldOrder
+		if orderI != orderJ && orderI != 0 && orderJ != 0 {
+			return orderI < orderJ
+		}
+
+		return keys[i] < keys[j]
+	})
+
+	for _, key := range keys {
+		headers = append(headers, item[key].FieldName)
+	}
+
+	return keys, headers
+}
+
+// columnItem returns a row without values of the columns of GetColumnList, keyed the way the rows are
+func columnItem(columns []map[string]any) map[string]entity.DataItem {
+	item := make(map[string]entity.DataItem, len(columns))
+	for _, column := range columns {
+		fieldCode, _ := column[entity.FieldColumnCode].(string)
+
+		key := fieldCode
+		if originalFieldCode, ok := column[entity.FieldOriginalFieldCode].(string); ok {
+			key = originalFieldCode
+		}
+
+		additionalData := map[string]any{}
+		for _, name := range []string{entity.FieldForeignTableName, entity.FieldForeignColumnName} {
+			if column[name] != nil {
+				additionalData[name] = column[name]
+			}
+		}
+
+		item[key] = entity.DataItem{FieldCode: fieldCode, FieldName: fieldCode, AdditionalData: additionalData}
+	}
+
+	return item
+}
+
+// isMetadataField checks both space and underscore formats of the audit columns
+func isMetadataField(fieldName string) bool {
+	fieldName = strings.ToLower(strings.ReplaceAll(fieldName, "_", " "))
+	return helper.Contains(metadataFieldNames, fieldName)
+}
+
+func formatExportValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case map[string]any, []any, []map[string]any:
+		jsonBytes, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(jsonBytes)
+	}
+
+	return fmt.Sprintf("%v", value)
+}
+
+type csvExportWriter struct {
+	writer *csv.Writer
+}
+
+func (cw *csvExportWriter) WriteHeader(_, headers []string) error {
+	return cw.writer.Write(headers)
+}
+
+func (cw *csvExportWriter) WriteRow(_ []string, values []string, _ map[string]entity.DataItem) error {
+	return cw.writer.Write(values)
+}
+
+func (cw *csvExportWriter) Flush() error {
+	cw.writer.Flush()
+	return cw.writer.Error()
+}
+
+func (cw *csvExportWriter) Close() error {
+	return cw.Flush()
+}
+
+type xlsxExportWriter struct {
+	writer *helper.XLSXStreamWriter
+}
+
+func (xw *xlsxExportWriter) WriteHeader(_, headers []string) error {
+	return xw.writer.WriteHeader(headers)
+}
+
+func (xw *xlsxExportWriter) WriteRow(_ []string, values []string, _ map[string]entity.DataItem) error {
+	return xw.writer.WriteRow(values)
+}
+
+func (xw *xlsxExportWriter) Flush() error {
+	return xw.writer.Flush()
+}
+
+func (xw *xlsxExportWriter) Close() error {
+	return xw.writer.Close()
+}
+
+// ndjsonExportWriter writes one json object per line keyed by field code, keeping the value types
+type ndjsonExportWriter struct {
+	encoder *json.Encoder
+}
+
+func (nw *ndjsonExportWriter) WriteHeader(_, _ []string) error {
+	return nil
+}
+
+func (nw *ndjsonExportWriter) WriteRow(keys []string, _ []string, items map[string]entity.DataItem) error {
+	record := make(map[string]any, len(keys))
+	for _, key := range keys {
+		value := items[key].DisplayValue
+		if valueBytes, ok := value.([]byte); ok {
+			value = string(valueBytes)
+		}
+
+		record[key] = value
+	}
+
+	return nw.encoder.Encode(record)
+}
+
+func (nw *ndjsonExportWriter) Flush() error {
+	return nil
+}
+
+func (nw *ndjsonExportWriter) Close() error {
+	return nil
+}
@@ -0,0 +1,73 @@
+package module
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+)
+
+func TestExportHeader(t *testing.T) {
+	columns := []map[string]any{
+		{entity.FieldColumnCode: "serial"},
+		{entity.FieldColumnCode: "name"},
+		{entity.FieldColumnCode: "salary"},
+		{entity.FieldColumnCode: "company_id", entity.FieldForeignTableName: "company", entity.FieldForeignColumnName: "serial"},
+		{entity.FieldColumnCode: "company_id__name"},
+		{entity.FieldColumnCode: "created_at"},
+	}
+	objectFields := map[string]any{"name": entity.ObjectFields{FieldCode: "name", DisplayName: "Full Name"}}
+
+	tests := []struct {
+		name              string
+		request           entity.CatalogQuery
+		permission        entity.ObjectPermission
+		isIncludeMetadata bool
+		wantKeys          []string
+		wantHeaders       []string
+	}{
+		{
+			name:        "visible fields",
+			wantKeys:    []string{"company_id", "name", "salary", "serial"},
+			wantHeaders: []string{"Company Id", "Full Name", "Salary", "Serial"},
+		},
+		{
+			name:              "metadata included",
+			isIncludeMetadata: true,
+			wantKeys:          []string{"company_id", "created_at", "name", "salary", "serial"},
+			wantHeaders:       []string{"Company Id", "Created At", "Full Name", "Salary", "Serial"},
+		},
+		{
+			name:        "hidden field",
+			permission:  entity.ObjectPermission{HiddenFields: map[string]bool{"salary": true}},
+			wantKeys:    []string{"company_id", "name", "serial"},
+			wantHeaders: []string{"Company Id", "Full Name", "Serial"},
+		},
+		{
+			name: "view order and names",
+			request: entity.CatalogQuery{Fields: map[string]entity.Field{
+				"name":       {FieldName: "Contact", FieldOrder: 1},
+				"serial":     {FieldName: "Key", FieldOrder: 2},
+				"company_id": {FieldName: "Company", FieldOrder: 3},
+				"salary":     {FieldName: "Salary", FieldOrder: 4},
+			}},
+			wantKeys:    []string{"name", "serial", "company_id", "salary"},
+			wantHeaders: []string{"Contact", "Key", "Company", "Salary"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := stripHiddenFields(tt.permission, decorateItems(tt.request, objectFields, columnItem(columns)))
+			keys, headers := exportColumns(tt.request, item, tt.isIncludeMetadata)
+
+			if fmt.Sprint(keys) != fmt.Sprint(tt.wantKeys) {
+				t.Errorf("keys = %v, want %v", keys, tt.wantKeys)
+			}
+
+			if fmt.Sprint(headers) != fmt.Sprint(tt.wantHeaders) {
+				t.Errorf("headers = %v, want %v", headers, tt.wantHeaders)
+			}
+		})
+	}
+}
@@ -0,0 +1,443 @@
+package module
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+	"github.com/fetchlydev/source/fetchly-backend/core/repository"
+	"github.com/fetchlydev/source/fetchly-backend/pkg/helper"
+)
+
+var (
+	errImportDryRun = errors.New("import dry run is rolled back")
+
+	// importIgnoredColumns are managed by the system and never read from an import file
+	importIgnoredColumns = []string{"id", entity.DEFAULT_IDENTIFIER, "created_at", "created_by", "updated_at", "updated_by", "deleted_at", "deleted_by"}
+)
+
+// importRowReader reads an import file row by row, Line reports the row number for error reports
+type importRowReader interface {
+	Read() ([]string, error)
+	Line() int
+}
+
+type csvRowReader struct {
+	reader *csv.Reader
+	line   int
+}
+
+func (r *csvRowReader) Read() ([]string, error) {
+	record, err := r.reader.Read()
+	if err == nil && len(record) > 0 {
+		r.line, _ = r.reader.FieldPos(0)
+	}
+
+	return record, err
+}
+
+func (r *csvRowReader) Line() int {
+	return r.line
+}
+
+// importColumn is a file column mapped to a field of the object
+type importColumn struct {
+	index         int
+	header        string
+	fieldCode     string
+	isForeignKey  bool
+	foreignTable  string
+	foreignColumn string
+}
+
+type importRow struct {
+	line  int
+	cells []string
+}
+
+// ImportObjectData streams a CSV or XLSX file into the object. Headers are matched against the field
+// codes and display names, foreign keys are resolved by their display value, and every row goes through
+// the validation engine. The whole import runs in one transaction with a savepoint per row, a dry run
+// always rolls back so it only produces the report.
+func (uc *catalogUsecase) ImportObjectData(ctx context.Context, request entity.ImportRequest, file io.ReaderAt, size int64) (resp entity.ImportResponse, err error) {
+	request.UserSerial = userSerialFromContext(ctx)
+
+	if request.Mode == "" {
+		request.Mode = entity.BulkModeAllOrNothing
+	}
+
+	if request.Mode != entity.BulkModeAllOrNothing && request.Mode != entity.BulkModeBestEffort {
+		return resp, fmt.Errorf("%w: unsupported import mode %v", entity.ErrorBadRequest, request.Mode)
+	}
+
+	permission, err := newAccessControl(uc.accessRepo).Authorize(ctx, request.TenantCode, request.ObjectCode, entity.PermissionCreate)
+	if err != nil {
+		return resp, err
+	}
+
+	var reader importRowReader
+	switch request.Format {
+	case entity.ExportFormatCSV:
+		csvReader := csv.NewReader(io.NewSectionReader(file, 0, size))
+		csvReader.FieldsPerRecord = -1
+		csvReader.TrimLeadingSpace = true
+		reader = &csvRowReader{reader: csvReader}
+	case entity.ExportFormatXLSX:
+		xlsxReader, err := helper.NewXLSXRowReader(file, size)
+		if err != nil {
+			return resp, fmt.Errorf("%w: %v", entity.ErrorBadRequest, err)
+		}
+		defer xlsxReader.Close()
+
+		reader = xlsxReader
+	default:
+		return resp, fmt.Errorf("%w: unsupported import format %v", entity.ErrorBadRequest, request.Format)
+	}
+
+	headers, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return resp, fmt.Errorf("%w: import file is empty", entity.ErrorBadRequest)
+	}
+	if err != nil {
+		return resp, fmt.Errorf("%w: %v", entity.ErrorBadRequest, err)
+	}
+
+	resp = entity.ImportResponse{
+		Mode:            request.Mode,
+		IsDryRun:        request.IsDryRun,
+		HeaderMapping:   []entity.ImportHeaderMapping{},
+		UnmappedHeaders: []string{},
+		Errors:          []entity.ImportRowError{},
+	}
+
+	validator := newValidationEngine(uc.catalogRepo)
+	columns, err := uc.mapImportHeaders(ctx, validator, request, headers, &resp)
+	if err != nil {
+		return resp, err
+	}
+
+	for _, column := range columns {
+		if !canWriteField(permission, column.fieldCode) {
+			return resp, fmt.Errorf("%w: field %v of header %v is read only", entity.ErrorForbidden, column.fieldCode, column.header)
+		}
+	}
+
+	batchSize := uc.cfg.ImportBatchSize
+	if batchSize < 1 {
+		batchSize = 500
+	}
+
+	err = uc.catalogRepo.WithTransaction(ctx, request.TenantCode, request.ObjectCode, func(txRepo repository.CatalogRepository) error {
+		txValidator := newValidationEngine(txRepo)
+		batch := make([]importRow, 0, batchSize)
+
+		for {
+			cells, err := reader.Read()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return fmt.Errorf("%w: row %v: %v", entity.ErrorBadRequest, reader.Line()+1, err)
+			}
+
+			if isBlankRow(cells) {
+				continue
+			}
+
+			batch = append(batch, importRow{line: reader.Line(), cells: cells})
+			if len(batch) < batchSize {
+				continue
+			}
+
+			if err := uc.importBatch(ctx, txRepo, txValidator, permission, request, columns, batch, &resp); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+
+		if err := uc.importBatch(ctx, txRepo, txValidator, permission, request, columns, batch, &resp); err != nil {
+			return err
+		}
+
+		if request.IsDryRun {
+			return errImportDryRun
+		}
+
+		if request.Mode == entity.BulkModeAllOrNothing && resp.InvalidRows > 0 {
+			return entity.ErrorBulkRolledBack
+		}
+
+		return nil
+	})
+
+	if errors.Is(err, errImportDryRun) {
+		return resp, nil
+	}
+
+	if err != nil {
+		return resp, err
+	}
+
+	resp.IsCommitted = true
+	resp.ImportedRows = resp.ValidRows
+	return resp, nil
+}
+
+// mapImportHeaders matches every header with a column of the object, by field code or display name.
+// Foreign keys are resolved by display value when the header is <field>__name or the field display name.
+func (uc *catalogUsecase) mapImportHeaders(ctx context.Context, validator *validationEngine, request entity.ImportRequest, headers []string, resp *entity.ImportResponse) ([]importColumn, error) {
+	columnList, _, _, _, err := uc.catalogRepo.GetColumnList(ctx, entity.CatalogQuery{
+		TenantCode:  request.TenantCode,
+		ProductCode: request.ProductCode,
+		ObjectCode:  request.ObjectCode,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	objectFields, err := validator.getObjectFields(ctx, request.ObjectCode, request.TenantCode)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make(map[string]importColumn)
+	for _, column := range columnList {
+		fieldCode, _ := column[entity.FieldColumnCode].(string)
+		if fieldCode == "" || strings.Contains(fieldCode, "__") || helper.Contains(importIgnoredColumns, fieldCode) {
+			continue
+		}
+
+		candidate := importColumn{fieldCode: fieldCode}
+		candidates[strings.ToLower(fieldCode)] = candidate
+
+		foreignTable, _ := column[entity.FieldForeignTableName].(string)
+		foreignColumn, _ := column[entity.FieldForeignColumnName].(string)
+		if foreignTable == "" || foreignColumn == "" {
+			if field, ok := objectFields[fieldCode]; ok && field.DisplayName != "" {
+				candidates[strings.ToLower(field.DisplayName)] = candidate
+			}
+			continue
+		}
+
+		// foreign keys are written as the referenced key, but read by display value
+		displayCandidate := importColumn{
+			fieldCode:     fieldCode,
+			isForeignKey:  true,
+			foreignTable:  foreignTable,
+			foreignColumn: foreignColumn,
+		}
+		candidates[strings.ToLower(fieldCode+entity.DisplayFieldSuffix)] = displayCandidate
+
+		if field, ok := objectFields[fieldCode]; ok && field.DisplayName != "" {
+			candidates[strings.ToLower(field.DisplayName)] = displayCandidate
+		}
+	}
+
+	columns := []importColumn{}
+	mappedFields := make(map[string]bool)
+	for i, header := range headers {
+		// excel saved csv files start with a byte order mark
+		if i == 0 {
+			header = strings.TrimPrefix(header, "\ufeff")
+		}
+
+		candidate, ok := candidates[strings.ToLower(strings.TrimSpace(header))]
+		if !ok || mappedFields[candidate.fieldCode] {
+			resp.UnmappedHeaders = append(resp.UnmappedHeaders, header)
+			continue
+		}
+
+		candidate.index = i
+		candidate.header = header
+		mappedFields[candidate.fieldCode] = true
+		columns = append(columns, candidate)
+
+		resp.HeaderMapping = append(resp.HeaderMapping, entity.ImportHeaderMapping{
+			Header:       header,
+			FieldCode:    candidate.fieldCode,
+			IsForeignKey: candidate.isForeignKey,
+			ForeignTable: candidate.foreignTable,
+		})
+	}
+
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("%w: no header matches a field of object %v", entity.ErrorBadRequest, request.ObjectCode)
+	}
+
+	return columns, nil
+}
+
+// importBatch resolves the foreign keys of a batch of rows at once, then creates every row in its own savepoint
+func (uc *catalogUsecase) importBatch(ctx context.Context, txRepo repository.CatalogRepository, validator *validationEngine, permission entity.ObjectPermission, request entity.ImportRequest, columns []importColumn, rows []importRow, resp *entity.ImportResponse) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	foreignKeys := make(map[string]map[string]any)
+	for _, column := range columns {
+		if !column.isForeignKey {
+			continue
+		}
+
+		displayValues := []any{}
+		seen := make(map[string]bool)
+		for _, row := range rows {
+			value := cellValue(row.cells, column.index)
+			if value == "" || seen[value] {
+				continue
+			}
+
+			seen[value] = true
+			displayValues = append(displayValues, value)
+		}
+
+		resolved, err := uc.resolveForeignKeys(ctx, txRepo, request, column, displayValues)
+		if err != nil {
+			return err
+		}
+
+		foreignKeys[column.fieldCode] = resolved
+	}
+
+	for _, row := range rows {
+		resp.TotalRows++
+
+		items := []entity.DataItem{}
+		referenceErrors := entity.ValidationErrors{}
+		for _, column := range columns {
+			value := cellValue(row.cells, column.index)
+			if value == "" {
+				continue
+			}
+
+			item := entity.DataItem{FieldCode: column.fieldCode, Value: value}
+			if column.isForeignKey {
+				key, ok := foreignKeys[column.fieldCode][value]
+				if !ok || key == nil {
+					referenceErrors = append(referenceErrors, entity.FieldValidationError{
+						FieldCode: column.fieldCode,
+						FieldName: column.header,
+						Rule:      entity.ValidationRuleReference,
+						Message:   fmt.Sprintf("%v %v is not found or is ambiguous in %v", column.header, value, column.foreignTable),
+					})
+					continue
+				}
+
+				item.Value = key
+			}
+
+			items = append(items, item)
+		}
+
+		var rowErr error = referenceErrors
+		if len(referenceErrors) == 0 {
+			mutation := entity.DataMutationRequest{
+				Items:       items,
+				ObjectCode:  request.ObjectCode,
+				TenantCode:  request.TenantCode,
+				ProductCode: request.ProductCode,
+				UserSerial:  request.UserSerial,
+			}
+
+			rowErr = txRepo.WithTransaction(ctx, request.TenantCode, request.ObjectCode, func(savepointRepo repository.CatalogRepository) error {
+				_, err := uc.createObjectData(ctx, savepointRepo, validator, permission, mutation)
+				return err
+			})
+		}
+
+		if rowErr == nil {
+			resp.ValidRows++
+			continue
+		}
+
+		resp.InvalidRows++
+		if uc.cfg.ImportMaxErrors > 0 && len(resp.Errors) >= uc.cfg.ImportMaxErrors {
+			resp.IsErrorTruncated = true
+			continue
+		}
+
+		rowError := entity.ImportRowError{
+			Row:   row.line,
+			Error: rowErr.Error(),
+		}
+
+		var validationErrors entity.ValidationErrors
+		if errors.As(rowErr, &validationErrors) {
+			rowError.ValidationErrors = validationErrors.ByField()
+		}
+
+		resp.Errors = append(resp.Errors, rowError)
+	}
+
+	return nil
+}
+
+// resolveForeignKeys maps display values of the foreign table to its referenced key,
+// a display value shared by more than one record maps to nil.
+func (uc *catalogUsecase) resolveForeignKeys(ctx context.Context, txRepo repository.CatalogRepository, request entity.ImportRequest, column importColumn, displayValues []any) (map[string]any, error) {
+	resolved := make(map[string]any)
+	if len(displayValues) == 0 {
+		return resolved, nil
+	}
+
+	result, err := txRepo.GetObjectData(ctx, entity.CatalogQuery{
+		TenantCode:  request.TenantCode,
+		ProductCode: request.ProductCode,
+		ObjectCode:  column.foreignTable,
+		PageSize:    len(displayValues) * 2,
+		Fields: map[string]entity.Field{
+			entity.DisplayFieldSuffix: {FieldCode: entity.DisplayFieldSuffix},
+			column.foreignColumn:      {FieldCode: column.foreignColumn},
+		},
+		Filters: []entity.FilterGroup{
+			{
+				Operator: entity.NewFilterGroupOperator(entity.FilterOperatorAnd),
+				Filters: map[string]entity.FilterItem{
+					entity.DisplayFieldSuffix: {FieldName: entity.DisplayFieldSuffix, Operator: entity.FilterOperatorIN, Value: displayValues},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return resolved, err
+	}
+
+	for _, item := range result.Items {
+		displayValue := fmt.Sprintf("%v", item[entity.DisplayFieldSuffix].Value)
+		key := item[column.foreignColumn].Value
+		if keyBytes, ok := key.([]byte); ok {
+			key = string(keyBytes)
+		}
+
+		if _, ok := resolved[displayValue]; ok {
+			resolved[displayValue] = nil
+			continue
+		}
+
+		resolved[displayValue] = key
+	}
+
+	return resolved, nil
+}
+
+func cellValue(cells []string, index int) string {
+	if index >= len(cells) {
+		return ""
+	}
+
+	return strings.TrimSpace(cells[index])
+}
+
+func isBlankRow(cells []string) bool {
+	for _, cell := range cells {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,176 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+	"github.com/fetchlydev/source/fetchly-backend/core/repository"
+)
+
+// importRepo is a savepointRepo of an object with a name and a foreign key to the users,
+// where user bob is not unique
+type importRepo struct {
+	*savepointRepo
+}
+
+func (r *importRepo) WithTransaction(ctx context.Context, tenantCode, objectCode string, fn func(txRepo repository.CatalogRepository) error) error {
+	return r.savepointRepo.WithTransaction(ctx, tenantCode, objectCode, func(repository.CatalogRepository) error {
+		return fn(r)
+	})
+}
+
+func (r *importRepo) GetColumnList(ctx context.Context, request entity.CatalogQuery) (columns []map[string]interface{}, columnStrings string, joinQueryMap map[string]string, joinQueryOrder []string, err error) {
+	columns = []map[string]interface{}{
+		{entity.FieldColumnCode: "id"},
+		{entity.FieldColumnCode: "name"},
+		{entity.FieldColumnCode: "owner_id", entity.FieldForeignTableName: "users", entity.FieldForeignColumnName: "serial"},
+		{entity.FieldColumnCode: "owner_id__name"},
+	}
+
+	return columns, columnStrings, joinQueryMap, joinQueryOrder, nil
+}
+
+func (r *importRepo) GetObjectData(ctx context.Context, request entity.CatalogQuery) (resp entity.CatalogResponse, err error) {
+	for _, user := range [][2]string{{"alice", "u1"}, {"bob", "u2"}, {"bob", "u3"}} {
+		resp.Items = append(resp.Items, map[string]entity.DataItem{
+			entity.DisplayFieldSuffix: {Value: user[0]},
+			"serial":                  {Value: []byte(user[1])},
+		})
+	}
+
+	return resp, nil
+}
+
+func TestImportObjectData(t *testing.T) {
+	rows := "\ufeffName,Owner_ID__name,id,notes\n" +
+		"a,alice,9,x\n" +
+		"b,nobody,,\n" +
+		"rejected,alice,,\n" +
+		",,,\n" +
+		"c,bob,,\n" +
+		"d,,,\n"
+
+	tests := []struct {
+		name          string
+		request       entity.ImportRequest
+		file          string
+		wantErr       error
+		wantCommitted bool
+		wantValid     int
+		wantErrorRows []int
+		wantRecords   []string
+	}{
+		{
+			name:          "best effort",
+			request:       entity.ImportRequest{Format: entity.ExportFormatCSV, Mode: entity.BulkModeBestEffort},
+			file:          rows,
+			wantCommitted: true,
+			wantValid:     2,
+			wantErrorRows: []int{3, 4, 6},
+			wantRecords:   []string{"a", "d"},
+		},
+		{
+			name:          "all or nothing",
+			request:       entity.ImportRequest{Format: entity.ExportFormatCSV},
+			file:          rows,
+			wantErr:       entity.ErrorBulkRolledBack,
+			wantValid:     2,
+			wantErrorRows: []int{3, 4, 6},
+		},
+		{
+			name:          "dry run",
+			request:       entity.ImportRequest{Format: entity.ExportFormatCSV, Mode: entity.BulkModeBestEffort, IsDryRun: true},
+			file:          rows,
+			wantValid:     2,
+			wantErrorRows: []int{3, 4, 6},
+		},
+		{
+			name:    "empty file",
+			request: entity.ImportRequest{Format: entity.ExportFormatCSV},
+			wantErr: entity.ErrorBadRequest,
+		},
+		{
+			name:    "no header matches",
+			request: entity.ImportRequest{Format: entity.ExportFormatCSV},
+			file:    "id,created_at\n1,2024-01-01\n",
+			wantErr: entity.ErrorBadRequest,
+		},
+		{
+			name:    "unsupported format",
+			request: entity.ImportRequest{Format: "pdf"},
+			file:    rows,
+			wantErr: entity.ErrorBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &importRepo{savepointRepo: &savepointRepo{}}
+			uc := &catalogUsecase{catalogRepo: repo, accessRepo: &grantRepo{}}
+
+			tt.request.TenantCode = "acme"
+			tt.request.ObjectCode = "deal"
+			resp, err := uc.ImportObjectData(context.Background(), tt.request, strings.NewReader(tt.file), int64(len(tt.file)))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ImportObjectData() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if resp.IsCommitted != tt.wantCommitted || resp.ValidRows != tt.wantValid || resp.InvalidRows != len(tt.wantErrorRows) {
+				t.Errorf("committed %v, valid %d, invalid %d, want %v, %d, %d", resp.IsCommitted, resp.ValidRows, resp.InvalidRows, tt.wantCommitted, tt.wantValid, len(tt.wantErrorRows))
+			}
+
+			errorRows := []int{}
+			for _, rowError := range resp.Errors {
+				errorRows = append(errorRows, rowError.Row)
+			}
+
+			if fmt.Sprint(errorRows) != fmt.Sprint(tt.wantErrorRows) {
+				t.Errorf("errors on rows %v, want %v", errorRows, tt.wantErrorRows)
+			}
+
+			if fmt.Sprint(repo.records) != fmt.Sprint(tt.wantRecords) || len(repo.records) != len(tt.wantRecords) {
+				t.Errorf("persisted records %v, want %v", repo.records, tt.wantRecords)
+			}
+		})
+	}
+}
+
+func TestMapImportHeaders(t *testing.T) {
+	repo := &importRepo{savepointRepo: &savepointRepo{}}
+	uc := &catalogUsecase{catalogRepo: repo}
+
+	resp := entity.ImportResponse{}
+	columns, err := uc.mapImportHeaders(context.Background(), newValidationEngine(repo), entity.ImportRequest{TenantCode: "acme", ObjectCode: "deal"},
+		[]string{"\ufeffName", " OWNER_ID__NAME ", "owner_id", "name", "id", "notes"}, &resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		index        int
+		fieldCode    string
+		isForeignKey bool
+	}{
+		{index: 0, fieldCode: "name"},
+		{index: 1, fieldCode: "owner_id", isForeignKey: true},
+	}
+
+	if len(columns) != len(tests) {
+		t.Fatalf("got columns %+v, want %d", columns, len(tests))
+	}
+
+	for i, tt := range tests {
+		column := columns[i]
+		if column.index != tt.index || column.fieldCode != tt.fieldCode || column.isForeignKey != tt.isForeignKey {
+			t.Errorf("column %d = %+v, want %+v", i, column, tt)
+		}
+	}
+
+	if want := []string{"owner_id", "name", "id", "notes"}; fmt.Sprint(resp.UnmappedHeaders) != fmt.Sprint(want) {
+		t.Errorf("unmapped headers %v, want %v", resp.UnmappedHeaders, want)
+	}
+}
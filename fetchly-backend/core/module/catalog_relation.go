@@ -0,0 +1,299 @@
+package module
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+	"github.com/fetchlydev/source/fetchly-backend/core/repository"
+)
+
+// nestedRecords are the records of a to many relation written along the record they belong to
+type nestedRecords struct {
+	relation entity.ObjectRelation
+	records  []any
+}
+
+// expandRelations loads the relations of request.Expand along items, with a single query per relation and level
+// whatever the number of items. The related records are checked against the permissions and row policies of
+// their own object. permission is the one of the object of the items.
+func (uc *catalogUsecase) expandRelations(ctx context.Context, permission entity.ObjectPermission, request entity.CatalogQuery, items []map[string]entity.DataItem, depth int) error {
+	if len(request.Expand) == 0 || len(items) == 0 {
+		return nil
+	}
+
+	if depth > entity.MaxRelationDepth {
+		return fmt.Errorf("%w: relations can be expanded %v levels deep at most", entity.ErrorBadRequest, entity.MaxRelationDepth)
+	}
+
+	relations, err := uc.catalogRepo.GetObjectRelations(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return err
+	}
+
+	fieldCodes := make([]string, 0, len(request.Expand))
+	for fieldCode := range request.Expand {
+		fieldCodes = append(fieldCodes, fieldCode)
+	}
+	sort.Strings(fieldCodes)
+
+	for _, fieldCode := range fieldCodes {
+		relation, ok := relations[fieldCode]
+		if !ok {
+			return fmt.Errorf("%w: field %v is not a to many relation of object %v", entity.ErrorBadRequest, fieldCode, request.ObjectCode)
+		}
+
+		if !canReadField(permission, fieldCode) {
+			return fmt.Errorf("%w: field %v can not be expanded", entity.ErrorForbidden, fieldCode)
+		}
+
+		relatedPermission, relatedQuery, err := uc.relatedQuery(ctx, request, relation, request.Expand[fieldCode])
+		if err != nil {
+			return err
+		}
+
+		parentKeys := []any{}
+		isParentKey := make(map[string]bool)
+		for _, item := range items {
+			parentKey, ok := item[relation.ParentKey]
+			if !ok || parentKey.Value == nil || isParentKey[fmt.Sprintf("%v", parentKey.Value)] {
+				continue
+			}
+
+			isParentKey[fmt.Sprintf("%v", parentKey.Value)] = true
+			parentKeys = append(parentKeys, parentKey.Value)
+		}
+
+		pages, err := uc.catalogRepo.GetRelatedObjectData(ctx, entity.RelatedQuery{
+			Query:      relatedQuery,
+			Relation:   relation,
+			ParentKeys: parentKeys,
+		})
+		if err != nil {
+			return err
+		}
+
+		relatedQuery, objectFields, err := uc.getObjectFieldMap(ctx, relatedQuery)
+		if err != nil {
+			return err
+		}
+
+		// items without a parent key or related records get an empty page
+		relatedItems := []map[string]entity.DataItem{}
+		for _, item := range items {
+			page := entity.CatalogResponse{
+				Page:     relatedQuery.Page,
+				PageSize: relatedQuery.PageSize,
+				Items:    []map[string]entity.DataItem{},
+			}
+
+			if parentKey, ok := item[relation.ParentKey]; ok && parentKey.Value != nil {
+				if relatedPage, ok := pages[fmt.Sprintf("%v", parentKey.Value)]; ok {
+					page = relatedPage
+				}
+			}
+
+			for i, relatedItem := range page.Items {
+				page.Items[i] = stripHiddenFields(relatedPermission, decorateItems(relatedQuery, objectFields, relatedItem))
+			}
+
+			relatedItems = append(relatedItems, page.Items...)
+			item[fieldCode] = entity.DataItem{
+				FieldCode: fieldCode,
+				FieldName: relation.FieldName,
+				DataType:  relation.Relation,
+				Value:     page,
+			}
+		}
+
+		if err := uc.expandRelations(ctx, relatedPermission, relatedQuery, relatedItems, depth+1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// relatedQuery authorizes the read of the related object of a relation and returns its query, restricted by its row
+// policies. A many to many relation also needs the read of its junction object.
+func (uc *catalogUsecase) relatedQuery(ctx context.Context, request entity.CatalogQuery, relation entity.ObjectRelation, expand entity.ExpandQuery) (entity.ObjectPermission, entity.CatalogQuery, error) {
+	accessControl := newAccessControl(uc.accessRepo)
+
+	permission, err := accessControl.Authorize(ctx, request.TenantCode, relation.TargetObject, entity.PermissionRead)
+	if err != nil {
+		return permission, entity.CatalogQuery{}, err
+	}
+
+	if relation.IsManyToMany() {
+		if _, err := accessControl.Authorize(ctx, request.TenantCode, relation.JunctionObject, entity.PermissionRead); err != nil {
+			return permission, entity.CatalogQuery{}, err
+		}
+	}
+
+	query := entity.CatalogQuery{
+		TenantCode:  request.TenantCode,
+		ProductCode: request.ProductCode,
+		ObjectCode:  relation.TargetObject,
+		Fields:      expand.Fields,
+		Filters:     expand.Filters,
+		Orders:      expand.Orders,
+		Page:        expand.Page,
+		PageSize:    expand.PageSize,
+		Expand:      expand.Expand,
+	}
+
+	if err := checkQueryFields(permission, query); err != nil {
+		return permission, query, err
+	}
+
+	if err := accessControl.authorizeChainedFields(ctx, uc.catalogRepo, query.TenantCode, query.ObjectCode, permission, queryFieldNames(query)); err != nil {
+		return permission, query, err
+	}
+
+	if query.Page < 1 {
+		query.Page = 1
+	}
+
+	if query.PageSize < 1 {
+		query.PageSize = entity.DefaultExpandPageSize
+	}
+
+	if query.PageSize > entity.MaxExpandPageSize {
+		query.PageSize = entity.MaxExpandPageSize
+	}
+
+	rowFilters, err := accessControl.RowFilters(ctx, request.TenantCode, relation.TargetObject)
+	if err != nil {
+		return permission, query, err
+	}
+
+	query.Filters = append(query.Filters, rowFilters...)
+
+	return permission, query, nil
+}
+
+// hasNestedRecords reports whether a mutation may carry records of to many relations, which are list values
+func hasNestedRecords(items []entity.DataItem) bool {
+	for _, item := range items {
+		if _, ok := item.Value.([]any); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// splitNestedRecords takes the records of the to many relations out of the items of the request. The relations of
+// the object are only read when an item holds a list.
+func (uc *catalogUsecase) splitNestedRecords(ctx context.Context, catalogRepo repository.CatalogRepository, request entity.DataMutationRequest) (entity.DataMutationRequest, []nestedRecords, error) {
+	if !hasNestedRecords(request.Items) {
+		return request, nil, nil
+	}
+
+	relations, err := catalogRepo.GetObjectRelations(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil || len(relations) == 0 {
+		return request, nil, err
+	}
+
+	var nested []nestedRecords
+	items := make([]entity.DataItem, 0, len(request.Items))
+	for _, item := range request.Items {
+		relation, ok := relations[item.FieldCode]
+		if !ok {
+			items = append(items, item)
+			continue
+		}
+
+		records, ok := item.Value.([]any)
+		if !ok && item.Value != nil {
+			return request, nil, fmt.Errorf("%w: field %v takes a list of records", entity.ErrorBadRequest, item.FieldCode)
+		}
+
+		nested = append(nested, nestedRecords{relation: relation, records: records})
+	}
+
+	request.Items = items
+	return request, nested, nil
+}
+
+// createNestedRecords creates the records of the to many relations of a created record. The records of a one to many
+// relation are created as children of the record, a many to many relation takes the keys of existing records
+// and creates their junction records.
+func (uc *catalogUsecase) createNestedRecords(ctx context.Context, catalogRepo repository.CatalogRepository, validator *validationEngine, request entity.DataMutationRequest, resp map[string]entity.DataItem, nested []nestedRecords, depth int) error {
+	if depth > entity.MaxRelationDepth {
+		return fmt.Errorf("%w: records can be nested %v levels deep at most", entity.ErrorBadRequest, entity.MaxRelationDepth)
+	}
+
+	accessControl := newAccessControl(uc.accessRepo)
+
+	for _, relationRecords := range nested {
+		relation := relationRecords.relation
+		parentKey, ok := resp[relation.ParentKey]
+		if !ok || parentKey.Value == nil {
+			return fmt.Errorf("%w: the record of object %v has no %v to relate %v to", entity.ErrorBadRequest, request.ObjectCode, relation.ParentKey, relation.FieldCode)
+		}
+
+		childObject := relation.TargetObject
+		if relation.IsManyToMany() {
+			childObject = relation.JunctionObject
+		}
+
+		permission, err := accessControl.GetObjectPermission(ctx, request.TenantCode, childObject)
+		if err != nil {
+			return err
+		}
+
+		children := make([]map[string]entity.DataItem, 0, len(relationRecords.records))
+		for i, record := range relationRecords.records {
+			childRequest := entity.DataMutationRequest{
+				ObjectCode:  childObject,
+				TenantCode:  request.TenantCode,
+				ProductCode: request.ProductCode,
+				UserSerial:  request.UserSerial,
+			}
+
+			if relation.IsManyToMany() {
+				childRequest.Items = []entity.DataItem{
+					{FieldCode: relation.JunctionParentField, Value: parentKey.Value},
+					{FieldCode: relation.JunctionTargetField, Value: record},
+				}
+			} else {
+				recordMap, ok := record.(map[string]any)
+				if !ok {
+					return fmt.Errorf("%w: %v[%d] is not a record", entity.ErrorBadRequest, relation.FieldCode, i)
+				}
+
+				fieldCodes := make([]string, 0, len(recordMap))
+				for fieldCode := range recordMap {
+					if fieldCode != relation.TargetField {
+						fieldCodes = append(fieldCodes, fieldCode)
+					}
+				}
+				sort.Strings(fieldCodes)
+
+				for _, fieldCode := range fieldCodes {
+					childRequest.Items = append(childRequest.Items, entity.DataItem{FieldCode: fieldCode, Value: recordMap[fieldCode]})
+				}
+
+				childRequest.Items = append(childRequest.Items, entity.DataItem{FieldCode: relation.TargetField, Value: parentKey.Value})
+			}
+
+			child, err := uc.createObjectTree(ctx, catalogRepo, validator, permission, childRequest, depth)
+			if err != nil {
+				return fmt.Errorf("%v[%d]: %w", relation.FieldCode, i, err)
+			}
+
+			children = append(children, child)
+		}
+
+		resp[relation.FieldCode] = entity.DataItem{
+			FieldCode: relation.FieldCode,
+			FieldName: relation.FieldName,
+			DataType:  relation.Relation,
+			Value:     children,
+		}
+	}
+
+	return nil
+}
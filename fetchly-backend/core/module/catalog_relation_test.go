@@ -0,0 +1,162 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+	"github.com/fetchlydev/source/fetchly-backend/core/repository"
+)
+
+// nestedRepo is a catalog repository of objects without metadata where a contact has deals and tags, it records
+// the items of the created records
+type nestedRepo struct {
+	repository.CatalogRepository
+	created []string
+}
+
+func (r *nestedRepo) GetObjectRelations(ctx context.Context, tenantCode, objectCode string) (resp map[string]entity.ObjectRelation, err error) {
+	if objectCode != "contact" {
+		return resp, nil
+	}
+
+	return map[string]entity.ObjectRelation{
+		"deals": {FieldCode: "deals", Relation: entity.RelationOneToMany, ParentKey: "serial", TargetObject: "deal", TargetField: "contact_serial"},
+		"tags": {
+			FieldCode: "tags", Relation: entity.RelationManyToMany, ParentKey: "serial", TargetObject: "tag", TargetKey: "serial",
+			JunctionObject: "contact_tag", JunctionParentField: "contact_serial", JunctionTargetField: "tag_serial",
+		},
+	}, nil
+}
+
+func (r *nestedRepo) GetObjectByCode(ctx context.Context, objectCode, tenantCode string) (resp entity.Objects, err error) {
+	return resp, fmt.Errorf("%w: object %v", entity.ErrorNotFound, objectCode)
+}
+
+func (r *nestedRepo) CreateObjectData(ctx context.Context, request entity.DataMutationRequest) (resp map[string]entity.DataItem, err error) {
+	serial := fmt.Sprintf("%v%d", request.ObjectCode, len(r.created)+1)
+
+	values := []string{}
+	for _, item := range request.Items {
+		values = append(values, fmt.Sprintf("%v=%v", item.FieldCode, item.Value))
+	}
+	r.created = append(r.created, fmt.Sprintf("%v %v", serial, values))
+
+	return map[string]entity.DataItem{entity.DEFAULT_IDENTIFIER: {FieldCode: entity.DEFAULT_IDENTIFIER, Value: serial}}, nil
+}
+
+func (r *nestedRepo) CreateAuditLogs(ctx context.Context, auditLogs []entity.AuditLog) error {
+	return nil
+}
+
+func TestCreateNestedRecords(t *testing.T) {
+	tests := []struct {
+		name    string
+		items   []entity.DataItem
+		want    []string
+		wantErr error
+	}{
+		{
+			name:  "no nested record",
+			items: []entity.DataItem{{FieldCode: "name", Value: "ann"}, {FieldCode: "labels", Value: []any{"x"}}},
+			want:  []string{"contact1 [name=ann labels=[x]]"},
+		},
+		{
+			name: "one to many children take the key of the record",
+			items: []entity.DataItem{
+				{FieldCode: "name", Value: "ann"},
+				{FieldCode: "deals", Value: []any{
+					map[string]any{"title": "a", "amount": 1, "contact_serial": "other"},
+					map[string]any{"title": "b"},
+				}},
+			},
+			want: []string{
+				"contact1 [name=ann]",
+				"deal2 [amount=1 title=a contact_serial=contact1]",
+				"deal3 [title=b contact_serial=contact1]",
+			},
+		},
+		{
+			name:  "many to many creates the junction records",
+			items: []entity.DataItem{{FieldCode: "name", Value: "ann"}, {FieldCode: "tags", Value: []any{"t1", "t2"}}},
+			want: []string{
+				"contact1 [name=ann]",
+				"contact_tag2 [contact_serial=contact1 tag_serial=t1]",
+				"contact_tag3 [contact_serial=contact1 tag_serial=t2]",
+			},
+		},
+		{
+			name:  "empty relation",
+			items: []entity.DataItem{{FieldCode: "name", Value: "ann"}, {FieldCode: "deals", Value: []any{}}},
+			want:  []string{"contact1 [name=ann]"},
+		},
+		{
+			name:    "relation that is not a list",
+			items:   []entity.DataItem{{FieldCode: "deals", Value: "a"}, {FieldCode: "tags", Value: []any{"t1"}}},
+			wantErr: entity.ErrorBadRequest,
+		},
+		{
+			name:    "one to many child that is not a record",
+			items:   []entity.DataItem{{FieldCode: "deals", Value: []any{"a"}}},
+			want:    []string{"contact1 []"},
+			wantErr: entity.ErrorBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &nestedRepo{}
+			uc := &catalogUsecase{catalogRepo: repo, accessRepo: &grantRepo{}}
+
+			permission, err := newAccessControl(uc.accessRepo).GetObjectPermission(context.Background(), "acme", "contact")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = uc.createObjectData(context.Background(), repo, newValidationEngine(repo), permission, entity.DataMutationRequest{
+				TenantCode: "acme",
+				ObjectCode: "contact",
+				Items:      tt.items,
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("createObjectData() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if fmt.Sprint(repo.created) != fmt.Sprint(tt.want) || len(repo.created) != len(tt.want) {
+				t.Errorf("created %v, want %v", repo.created, tt.want)
+			}
+		})
+	}
+}
+
+func TestRelatedQueryPage(t *testing.T) {
+	relation := entity.ObjectRelation{FieldCode: "deals", Relation: entity.RelationOneToMany, TargetObject: "deal", TargetField: "contact_serial"}
+
+	tests := []struct {
+		name         string
+		expand       entity.ExpandQuery
+		wantPage     int
+		wantPageSize int
+	}{
+		{name: "default", wantPage: 1, wantPageSize: entity.DefaultExpandPageSize},
+		{name: "given", expand: entity.ExpandQuery{Page: 3, PageSize: 5}, wantPage: 3, wantPageSize: 5},
+		{name: "too large", expand: entity.ExpandQuery{PageSize: entity.MaxExpandPageSize + 1}, wantPage: 1, wantPageSize: entity.MaxExpandPageSize},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := &catalogUsecase{accessRepo: &grantRepo{}}
+
+			_, query, err := uc.relatedQuery(context.Background(), entity.CatalogQuery{TenantCode: "acme", ObjectCode: "contact"}, relation, tt.expand)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if query.ObjectCode != "deal" || query.Page != tt.wantPage || query.PageSize != tt.wantPageSize {
+				t.Errorf("query of %v, page %d of %d, want deal, page %d of %d", query.ObjectCode, query.Page, query.PageSize, tt.wantPage, tt.wantPageSize)
+			}
+		})
+	}
+}
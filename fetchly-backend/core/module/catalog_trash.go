@@ -0,0 +1,135 @@
+package module
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+	"github.com/fetchlydev/source/fetchly-backend/core/repository"
+)
+
+// setObjectDataDeleted soft deletes a record or restores it, along with the child records of its one to many
+// relations when request.IsCascade. The records are deleted or restored together or not at all.
+func (uc *catalogUsecase) setObjectDataDeleted(ctx context.Context, accessControl *accessControl, request entity.DataMutationRequest, isRestore bool) error {
+	return uc.catalogRepo.WithTransaction(ctx, request.TenantCode, request.ObjectCode, func(txRepo repository.CatalogRepository) error {
+		return uc.setRecordDeleted(ctx, txRepo, accessControl, request, isRestore)
+	})
+}
+
+// setRecordDeleted is setObjectDataDeleted using the given repository, which may be bound to a transaction
+func (uc *catalogUsecase) setRecordDeleted(ctx context.Context, catalogRepo repository.CatalogRepository, accessControl *accessControl, request entity.DataMutationRequest, isRestore bool) error {
+	deletion := entity.DeletionRequest{
+		TenantCode: request.TenantCode,
+		ObjectCode: request.ObjectCode,
+		Serials:    []string{request.Serial},
+		IsRestore:  isRestore,
+		UserSerial: request.UserSerial,
+		Filters:    request.Filters,
+	}
+
+	if !isRestore {
+		deletion.DeletedAt = time.Now()
+	}
+
+	rows, err := catalogRepo.SetObjectDataDeleted(ctx, deletion)
+	if err != nil {
+		return err
+	}
+
+	if len(rows) == 0 {
+		return entity.ErrorNoUpdateDataFound
+	}
+
+	if err := catalogRepo.CreateAuditLogs(ctx, deletionAuditLogs(ctx, deletion, rows)); err != nil {
+		return err
+	}
+
+	if !request.IsCascade {
+		return nil
+	}
+
+	// the children deleted along with the record share its deletion time, only those are restored with it
+	if isRestore {
+		deletion.DeletedAt = rows[0]["deleted_at"]
+	}
+
+	return uc.cascadeDeletion(ctx, catalogRepo, accessControl, deletion, rows, 1)
+}
+
+// cascadeDeletion soft deletes or restores the child records of the one to many relations of rows, level by level.
+// The child records are checked against the permissions and row policies of their own object, the ones a row
+// policy hides are left as they are.
+func (uc *catalogUsecase) cascadeDeletion(ctx context.Context, catalogRepo repository.CatalogRepository, accessControl *accessControl, parent entity.DeletionRequest, rows []map[string]any, depth int) error {
+	if depth > entity.MaxRelationDepth {
+		return fmt.Errorf("%w: records can be deleted %v levels deep at most", entity.ErrorBadRequest, entity.MaxRelationDepth)
+	}
+
+	relations, err := catalogRepo.GetObjectRelations(ctx, parent.TenantCode, parent.ObjectCode)
+	if err != nil {
+		return err
+	}
+
+	fieldCodes := make([]string, 0, len(relations))
+	for fieldCode, relation := range relations {
+		if relation.Relation == entity.RelationOneToMany {
+			fieldCodes = append(fieldCodes, fieldCode)
+		}
+	}
+	sort.Strings(fieldCodes)
+
+	for _, fieldCode := range fieldCodes {
+		relation := relations[fieldCode]
+
+		parentKeys := []any{}
+		for _, row := range rows {
+			if parentKey := row[relation.ParentKey]; parentKey != nil {
+				parentKeys = append(parentKeys, parentKey)
+			}
+		}
+
+		if len(parentKeys) == 0 {
+			continue
+		}
+
+		if _, err := accessControl.Authorize(ctx, parent.TenantCode, relation.TargetObject, entity.PermissionDelete); err != nil {
+			return err
+		}
+
+		filters, err := accessControl.RowFilters(ctx, parent.TenantCode, relation.TargetObject)
+		if err != nil {
+			return err
+		}
+
+		children := entity.DeletionRequest{
+			TenantCode: parent.TenantCode,
+			ObjectCode: relation.TargetObject,
+			ForeignKey: relation.TargetField,
+			ParentKeys: parentKeys,
+			DeletedAt:  parent.DeletedAt,
+			IsRestore:  parent.IsRestore,
+			UserSerial: parent.UserSerial,
+			Filters:    filters,
+		}
+
+		childRows, err := catalogRepo.SetObjectDataDeleted(ctx, children)
+		if err != nil {
+			return fmt.Errorf("%v: %w", relation.FieldCode, err)
+		}
+
+		if len(childRows) == 0 {
+			continue
+		}
+
+		if err := catalogRepo.CreateAuditLogs(ctx, deletionAuditLogs(ctx, children, childRows)); err != nil {
+			return err
+		}
+
+		if err := uc.cascadeDeletion(ctx, catalogRepo, accessControl, children, childRows, depth+1); err != nil {
+			return fmt.Errorf("%v: %w", relation.FieldCode, err)
+		}
+	}
+
+	return nil
+}
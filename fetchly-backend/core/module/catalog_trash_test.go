@@ -0,0 +1,216 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+	"github.com/fetchlydev/source/fetchly-backend/core/repository"
+)
+
+// trashRecord is a record of trashRepo, parent is the deal it references
+type trashRecord struct {
+	parent    string
+	deletedAt any
+}
+
+// trashRepo is a catalog repository of deals and their lines whose changes are discarded when the transaction
+// returns an error. The records of changedObject are changed by another request before they are updated.
+type trashRepo struct {
+	repository.CatalogRepository
+	records       map[string]map[string]trashRecord
+	changedObject string
+	auditLogs     []entity.AuditLog
+}
+
+func newTrashRepo() *trashRepo {
+	return &trashRepo{records: map[string]map[string]trashRecord{
+		"deal": {"d1": {}, "d2": {}},
+		"line": {"l1": {parent: "d1"}, "l2": {parent: "d1"}, "l3": {parent: "d2"}},
+	}}
+}
+
+func (r *trashRepo) WithTransaction(ctx context.Context, tenantCode, objectCode string, fn func(txRepo repository.CatalogRepository) error) error {
+	records := map[string]map[string]trashRecord{}
+	for objectCode, objectRecords := range r.records {
+		records[objectCode] = maps.Clone(objectRecords)
+	}
+	auditLogs := len(r.auditLogs)
+
+	if err := fn(r); err != nil {
+		r.records = records
+		r.auditLogs = r.auditLogs[:auditLogs]
+		return err
+	}
+
+	return nil
+}
+
+func (r *trashRepo) GetObjectRelations(ctx context.Context, tenantCode, objectCode string) (resp map[string]entity.ObjectRelation, err error) {
+	if objectCode != "deal" {
+		return resp, nil
+	}
+
+	return map[string]entity.ObjectRelation{
+		"lines": {FieldCode: "lines", Relation: entity.RelationOneToMany, ParentKey: entity.DEFAULT_IDENTIFIER, TargetObject: "line", TargetField: "deal_serial"},
+	}, nil
+}
+
+func (r *trashRepo) SetObjectDataDeleted(ctx context.Context, request entity.DeletionRequest) (resp []map[string]any, err error) {
+	serials := slices.Sorted(maps.Keys(r.records[request.ObjectCode]))
+	for _, serial := range serials {
+		record := r.records[request.ObjectCode][serial]
+
+		isRequested := slices.Contains(request.Serials, serial)
+		if request.ForeignKey != "" {
+			isRequested = slices.Contains(request.ParentKeys, any(record.parent))
+		}
+
+		// only the records still in the state the request expects are changed
+		isInState := record.deletedAt == nil
+		if request.IsRestore {
+			isInState = record.deletedAt != nil && (request.DeletedAt == nil || record.deletedAt == request.DeletedAt)
+		}
+
+		if !isRequested || !isInState {
+			continue
+		}
+
+		if request.ObjectCode == r.changedObject {
+			return resp, fmt.Errorf("%w: records of object %v were changed meanwhile", entity.ErrorNoUpdateDataFound, request.ObjectCode)
+		}
+
+		resp = append(resp, map[string]any{entity.DEFAULT_IDENTIFIER: serial, "deleted_at": record.deletedAt})
+
+		record.deletedAt = request.DeletedAt
+		if request.IsRestore {
+			record.deletedAt = nil
+		}
+		r.records[request.ObjectCode][serial] = record
+	}
+
+	return resp, nil
+}
+
+func (r *trashRepo) CreateAuditLogs(ctx context.Context, logs []entity.AuditLog) error {
+	r.auditLogs = append(r.auditLogs, logs...)
+	return nil
+}
+
+// deletedRecords lists the soft deleted records of trashRepo as object/serial
+func (r *trashRepo) deletedRecords() []string {
+	deleted := []string{}
+	for objectCode, records := range r.records {
+		for serial, record := range records {
+			if record.deletedAt != nil {
+				deleted = append(deleted, objectCode+"/"+serial)
+			}
+		}
+	}
+	slices.Sort(deleted)
+
+	return deleted
+}
+
+func TestSetObjectDataDeleted(t *testing.T) {
+	tests := []struct {
+		name          string
+		prepare       func(repo *trashRepo)
+		request       entity.DataMutationRequest
+		isRestore     bool
+		changedObject string
+		wantErr       error
+		wantDeleted   []string
+		wantAuditLogs int
+	}{
+		{
+			name:          "delete a record",
+			request:       entity.DataMutationRequest{Serial: "d1"},
+			wantDeleted:   []string{"deal/d1"},
+			wantAuditLogs: 1,
+		},
+		{
+			name:          "delete the children along with a record",
+			request:       entity.DataMutationRequest{Serial: "d1", IsCascade: true},
+			wantDeleted:   []string{"deal/d1", "line/l1", "line/l2"},
+			wantAuditLogs: 3,
+		},
+		{
+			name:        "deleted record",
+			prepare:     func(repo *trashRepo) { repo.records["deal"]["d1"] = trashRecord{deletedAt: time.Unix(1, 0)} },
+			request:     entity.DataMutationRequest{Serial: "d1"},
+			wantErr:     entity.ErrorNoUpdateDataFound,
+			wantDeleted: []string{"deal/d1"},
+		},
+		{
+			name: "restore only the children deleted along with a record",
+			prepare: func(repo *trashRepo) {
+				repo.records["deal"]["d1"] = trashRecord{deletedAt: time.Unix(2, 0)}
+				repo.records["line"]["l1"] = trashRecord{parent: "d1", deletedAt: time.Unix(1, 0)}
+				repo.records["line"]["l2"] = trashRecord{parent: "d1", deletedAt: time.Unix(2, 0)}
+			},
+			request:       entity.DataMutationRequest{Serial: "d1", IsCascade: true},
+			isRestore:     true,
+			wantDeleted:   []string{"line/l1"},
+			wantAuditLogs: 2,
+		},
+		{
+			name:          "children changed meanwhile roll back the record",
+			request:       entity.DataMutationRequest{Serial: "d1", IsCascade: true},
+			changedObject: "line",
+			wantErr:       entity.ErrorNoUpdateDataFound,
+			wantDeleted:   []string{},
+		},
+		{
+			name: "children changed meanwhile roll back the restore",
+			prepare: func(repo *trashRepo) {
+				repo.records["deal"]["d2"] = trashRecord{deletedAt: time.Unix(1, 0)}
+				repo.records["line"]["l3"] = trashRecord{parent: "d2", deletedAt: time.Unix(1, 0)}
+			},
+			request:       entity.DataMutationRequest{Serial: "d2", IsCascade: true},
+			isRestore:     true,
+			changedObject: "line",
+			wantErr:       entity.ErrorNoUpdateDataFound,
+			wantDeleted:   []string{"deal/d2", "line/l3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newTrashRepo()
+			if tt.prepare != nil {
+				tt.prepare(repo)
+			}
+			repo.changedObject = tt.changedObject
+
+			uc := &catalogUsecase{catalogRepo: repo, accessRepo: &grantRepo{}}
+
+			tt.request.TenantCode = "acme"
+			tt.request.ObjectCode = "deal"
+
+			var err error
+			if tt.isRestore {
+				err = uc.RestoreObjectData(context.Background(), tt.request)
+			} else {
+				err = uc.DeleteObjectData(context.Background(), tt.request)
+			}
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+
+			if got := repo.deletedRecords(); fmt.Sprint(got) != fmt.Sprint(tt.wantDeleted) {
+				t.Errorf("deleted records %v, want %v", got, tt.wantDeleted)
+			}
+
+			if len(repo.auditLogs) != tt.wantAuditLogs {
+				t.Errorf("audit logs %d, want %d", len(repo.auditLogs), tt.wantAuditLogs)
+			}
+		})
+	}
+}
@@ -5,7 +5,9 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/csv"
+	"errors"
 	"fmt"
+	"io"
 	"strings"
 	"time"
 
@@ -24,25 +26,33 @@ type CatalogUsecase interface {
 	GetObjectData(ctx context.Context, request entity.CatalogQuery) (resp entity.CatalogResponse, err error)
 	GetObjectDetail(ctx context.Context, request entity.CatalogQuery, serial string) (resp map[string]entity.DataItem, err error)
 	GetDataByRawQuery(ctx context.Context, request entity.CatalogQuery) (resp entity.CatalogResponse, err error)
+	AggregateObjectData(ctx context.Context, request entity.AggregateQuery) (resp entity.AggregateResponse, err error)
 	CreateObjectData(ctx context.Context, request entity.DataMutationRequest) (resp map[string]entity.DataItem, err error)
 	UpdateObjectData(ctx context.Context, request entity.DataMutationRequest) (resp map[string]entity.DataItem, err error)
 	DeleteObjectData(ctx context.Context, request entity.DataMutationRequest) (err error)
+	RestoreObjectData(ctx context.Context, request entity.DataMutationRequest) (err error)
 	GetObjectFieldsByObjectCode(ctx context.Context, request entity.CatalogQuery) (resp map[string]any, err error)
 	GetContentLayoutByKeys(ctx context.Context, request entity.GetViewContentByKeysRequest, catalogQuery entity.CatalogQuery) (resp entity.ViewContentResponse, err error)
 	ExportObjectData(ctx context.Context, request entity.CatalogQuery, format entity.ExportFormat, isIncludeMetadata bool) (resp entity.ExportResponse, err error)
+	StreamExportObjectData(ctx context.Context, request entity.CatalogQuery, format entity.ExportFormat, isIncludeMetadata bool, w io.Writer, onProgress func(processedRows int)) (err error)
+	BulkMutateObjectData(ctx context.Context, request entity.BulkMutationRequest) (resp entity.BulkMutationResponse, err error)
+	ImportObjectData(ctx context.Context, request entity.ImportRequest, file io.ReaderAt, size int64) (resp entity.ImportResponse, err error)
+	GetObjectPermission(ctx context.Context, tenantCode, objectCode string) (resp entity.ObjectPermission, err error)
 }
 
 type catalogUsecase struct {
 	cfg         config.Config
 	catalogRepo repository.CatalogRepository
 	viewRepo    repository.ViewRepository
+	accessRepo  repository.AccessRepository
 }
 
-func NewCatalogUsecase(cfg config.Config, catalogRepo repository.CatalogRepository, viewRepo repository.ViewRepository) CatalogUsecase {
+func NewCatalogUsecase(cfg config.Config, catalogRepo repository.CatalogRepository, viewRepo repository.ViewRepository, accessRepo repository.AccessRepository) CatalogUsecase {
 	return &catalogUsecase{
 		cfg:         cfg,
 		catalogRepo: catalogRepo,
 		viewRepo:    viewRepo,
+		accessRepo:  accessRepo,
 	}
 }
 
@@ -94,15 +104,7 @@ func (uc *catalogUsecase) GetTenantProductByCode(ctx context.Context, code, tena
 		resp = result.Items[0]
 	}
 
-	for columnName, item := range resp {
-		if item.AdditionalData["foreign_table_name"] != nil && item.AdditionalData["foreign_field_name"] != nil {
-			// handle display value logic here
-			// room for improvement: replace hardcoded __name with display value from catalog
-			item.DisplayValue = resp[fmt.Sprintf("%v__name", columnName)].Value
-
-			resp[columnName] = item
-		}
-	}
+	setForeignDisplayValues(resp)
 
 	return resp, nil
 }
@@ -146,6 +148,90 @@ func (uc *catalogUsecase) GetObjectFieldsByObjectCode(ctx context.Context, reque
 }
 
 func (uc *catalogUsecase) GetObjectData(ctx context.Context, request entity.CatalogQuery) (resp entity.CatalogResponse, err error) {
+	accessControl := newAccessControl(uc.accessRepo)
+
+	permission, err := accessControl.Authorize(ctx, request.TenantCode, request.ObjectCode, entity.PermissionRead)
+	if err != nil {
+		return resp, err
+	}
+
+	if err := checkQueryFields(permission, request); err != nil {
+		return resp, err
+	}
+
+	if err := accessControl.authorizeChainedFields(ctx, uc.catalogRepo, request.TenantCode, request.ObjectCode, permission, queryFieldNames(request)); err != nil {
+		return resp, err
+	}
+
+	if err := checkTrashAccess(permission, request); err != nil {
+		return resp, err
+	}
+
+	if err := checkPagination(request); err != nil {
+		return resp, err
+	}
+
+	request, err = uc.composeViewQuery(ctx, request)
+	if err != nil {
+		return resp, err
+	}
+
+	// row policies are merged after the view query so neither the view nor the request can drop them,
+	// the count query shares the filters
+	rowFilters, err := accessControl.RowFilters(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return resp, err
+	}
+
+	request.Filters = append(request.Filters, rowFilters...)
+	request.ExcludedSearchFields = hiddenFieldCodes(permission)
+
+	results, err := uc.catalogRepo.GetObjectData(ctx, request)
+	if err != nil {
+		return resp, err
+	}
+
+	request, objectFields, err := uc.getObjectFieldMap(ctx, request)
+	if err != nil {
+		return resp, err
+	}
+
+	// iterate object fields and map to response, the display values of the foreign keys come with the items
+	for i, items := range results.Items {
+		results.Items[i] = stripHiddenFields(permission, decorateItems(request, objectFields, items))
+	}
+
+	if err := uc.expandRelations(ctx, permission, request, results.Items, 1); err != nil {
+		return resp, err
+	}
+
+	return results, err
+}
+
+// checkPagination rejects an unknown pagination or count mode, an empty mode keeps the offset pagination
+// with an exact count
+func checkPagination(request entity.CatalogQuery) error {
+	switch request.PaginationMode {
+	case "", entity.PaginationModeOffset, entity.PaginationModeCursor:
+	default:
+		return fmt.Errorf("%w: unsupported pagination mode %v", entity.ErrorBadRequest, request.PaginationMode)
+	}
+
+	switch request.CountMode {
+	case "", entity.CountModeExact, entity.CountModeEstimated, entity.CountModeNone:
+	default:
+		return fmt.Errorf("%w: unsupported count mode %v", entity.ErrorBadRequest, request.CountMode)
+	}
+
+	if request.Cursor != "" && request.PaginationMode != entity.PaginationModeCursor {
+		return fmt.Errorf("%w: cursor is only accepted with the cursor pagination mode", entity.ErrorBadRequest)
+	}
+
+	return nil
+}
+
+// composeViewQuery merges the fields, filters and orders of the view schema into the request
+func (uc *catalogUsecase) composeViewQuery(ctx context.Context, request entity.CatalogQuery) (entity.CatalogQuery, error) {
 	// inject view schema to get field config and query, and combine it to request fields and filters
 	viewContent, err := uc.GetContentLayoutByKeys(ctx, entity.GetViewContentByKeysRequest{
 		TenantCode:      request.TenantCode,
@@ -155,7 +241,7 @@ func (uc *catalogUsecase) GetObjectData(ctx context.Context, request entity.Cata
 		LayoutType:      "record",
 	}, request)
 	if err != nil {
-		return resp, err
+		return request, err
 	}
 
 	combinedQuery := entity.CatalogQuery{
@@ -175,61 +261,22 @@ func (uc *catalogUsecase) GetObjectData(ctx context.Context, request entity.Cata
 	for _, filter := range viewSchemaQueryFilters {
 		filterGroup := entity.FilterGroup{}
 		if filterMap, ok := filter.(map[string]any); ok {
-			if operator, ok := filterMap["operator"].(string); ok {
-				filterGroup.Operator = entity.NewFilterGroupOperator(entity.FilterGroupOperator(operator))
-			}
-
-			if filterItem, ok := filterMap["filter_item"].(map[string]any); ok {
-				filterGroup.Filters = make(map[string]entity.FilterItem)
-				for key, item := range filterItem {
-					if itemMap, ok := item.(map[string]any); ok {
-						filterItem := entity.FilterItem{}
-						if fieldCode, ok := itemMap["field_code"].(string); ok {
-							filterItem.FieldName = fieldCode
-						}
-						if operator, ok := itemMap["operator"].(string); ok {
-							filterItem.Operator = entity.FilterOperator(operator)
-						}
-						if value, ok := itemMap["value"]; ok {
-							filterItem.Value = value
-						}
-
-						if filterItem.Value != "" {
-							filterGroup.Filters[key] = filterItem
-						}
-					}
-				}
-			}
+			filterGroup = parseViewFilterGroup(filterMap)
 		}
 
 		combinedQuery.Filters = append(combinedQuery.Filters, filterGroup)
 	}
 
-	// combine combonedQuery with request
-	if len(request.Filters) > 0 {
-		for _, filter := range request.Filters {
-			filterGroup := entity.FilterGroup{}
-
-			if !filter.Operator.IsEmpty() {
-				filterGroup.Operator = filter.Operator
-			}
-
-			if len(filter.Filters) > 0 {
-				filterGroup.Filters = make(map[string]entity.FilterItem)
-
-				for key, item := range filter.Filters {
-					if item.Operator != "" {
-						filterGroup.Filters[key] = entity.FilterItem{
-							FieldName: item.FieldName,
-							Operator:  item.Operator,
-							Value:     item.Value,
-						}
-					}
-				}
-			}
-
-			combinedQuery.Filters = append(combinedQuery.Filters, filterGroup)
+	// combine combonedQuery with request, the conditions without an operator are dropped
+	for _, filter := range request.Filters {
+		filterGroup, err := filter.MapItems(func(_ string, item entity.FilterItem) (entity.FilterItem, bool, error) {
+			return item, item.Operator != "", nil
+		})
+		if err != nil {
+			return request, err
 		}
+
+		combinedQuery.Filters = append(combinedQuery.Filters, filterGroup)
 	}
 
 	request.Filters = combinedQuery.Filters
@@ -291,13 +338,77 @@ func (uc *catalogUsecase) GetObjectData(ctx context.Context, request entity.Cata
 
 	request.Orders = combinedQuery.Orders
 
-	results, err := uc.catalogRepo.GetObjectData(ctx, request)
-	if err != nil {
-		return resp, err
+	return request, nil
+}
+
+// parseViewFilterGroup reads a filter group stored in the query of a view schema, with its nested conditions and
+// groups. The conditions without a value are dropped unless their operator takes none.
+func parseViewFilterGroup(filterMap map[string]any) entity.FilterGroup {
+	filterGroup := entity.FilterGroup{}
+
+	if operator, ok := filterMap["operator"].(string); ok {
+		filterGroup.Operator = entity.NewFilterGroupOperator(entity.FilterGroupOperator(operator))
+	}
+
+	if filterItem, ok := filterMap["filter_item"].(map[string]any); ok {
+		filterGroup.Filters = make(map[string]entity.FilterItem)
+		for key, item := range filterItem {
+			if itemMap, ok := item.(map[string]any); ok {
+				if filterItem, ok := parseViewFilterItem(itemMap); ok {
+					filterGroup.Filters[key] = filterItem
+				}
+			}
+		}
+	}
+
+	if conditions, ok := filterMap["conditions"].([]any); ok {
+		for _, condition := range conditions {
+			if itemMap, ok := condition.(map[string]any); ok {
+				if filterItem, ok := parseViewFilterItem(itemMap); ok {
+					filterGroup.Conditions = append(filterGroup.Conditions, filterItem)
+				}
+			}
+		}
+	}
+
+	if groups, ok := filterMap["groups"].([]any); ok {
+		for _, group := range groups {
+			if groupMap, ok := group.(map[string]any); ok {
+				filterGroup.Groups = append(filterGroup.Groups, parseViewFilterGroup(groupMap))
+			}
+		}
 	}
 
-	objects, _ := uc.catalogRepo.GetObjectByCode(ctx, request.ObjectCode, request.TenantCode)
+	return filterGroup
+}
+
+// parseViewFilterItem reads a stored condition, the field is field_code in the stored filters and field_name in
+// the filters of a request
+func parseViewFilterItem(itemMap map[string]any) (entity.FilterItem, bool) {
+	filterItem := entity.FilterItem{}
+	if fieldCode, ok := itemMap["field_code"].(string); ok {
+		filterItem.FieldName = fieldCode
+	} else if fieldName, ok := itemMap["field_name"].(string); ok {
+		filterItem.FieldName = fieldName
+	}
+	if operator, ok := itemMap["operator"].(string); ok {
+		filterItem.Operator = entity.FilterOperator(operator)
+	}
+	if value, ok := itemMap["value"]; ok {
+		filterItem.Value = value
+	}
+
+	return filterItem, filterItem.Value != "" || filterItem.Operator.IsValueless()
+}
+
+// getObjectFieldMap loads the object fields metadata used to decorate the items, the request is
+// returned with the object and tenant serial filled in.
+func (uc *catalogUsecase) getObjectFieldMap(ctx context.Context, request entity.CatalogQuery) (entity.CatalogQuery, map[string]any, error) {
 	objectFields := map[string]any{}
+	objects, err := uc.catalogRepo.GetObjectByCode(ctx, request.ObjectCode, request.TenantCode)
+	if err != nil && !errors.Is(err, entity.ErrorNotFound) {
+		return request, objectFields, err
+	}
 
 	if objects.Serial != "" {
 		request.ObjectSerial = objects.Serial
@@ -306,133 +417,143 @@ func (uc *catalogUsecase) GetObjectData(ctx context.Context, request entity.Cata
 		// handle custom object fields based on object field table
 		objectFields, err = uc.GetObjectFieldsByObjectCode(ctx, request)
 		if err != nil {
-			return resp, err
+			return request, objectFields, err
 		}
 	}
 
-	foreignTables := make(map[string][]any)
+	return request, objectFields, nil
+}
 
-	// iterate object fields and map to response
-	for i, items := range results.Items {
-		for j, item := range items {
-			// put field code to complete field code, and assign new value to field code with column name only after splitted
-			// example: item.FieldCode = "object.field_code" => item.CompleteFieldCode = "object.field_code" and item.FieldCode = "field_code"
-			item.CompleteFieldCode = item.FieldCode
+// decorateItems fills field names, data types and display values of a row using the object fields metadata
+func decorateItems(request entity.CatalogQuery, objectFields map[string]any, items map[string]entity.DataItem) map[string]entity.DataItem {
+	for j, item := range items {
+		// put field code to complete field code, and assign new value to field code with column name only after splitted
+		// example: item.FieldCode = "object.field_code" => item.CompleteFieldCode = "object.field_code" and item.FieldCode = "field_code"
+		item.CompleteFieldCode = item.FieldCode
 
-			fieldCode := ""
+		fieldCode := ""
 
-			// split item.FieldCode by dot
-			if len(item.FieldCode) > 0 {
-				split := strings.Split(item.FieldCode, ".")
+		// split item.FieldCode by dot
+		if len(item.FieldCode) > 0 {
+			split := strings.Split(item.FieldCode, ".")
 
-				// find the last element
-				fieldCode = split[len(split)-1]
-			}
+			// find the last element
+			fieldCode = split[len(split)-1]
+		}
+
+		if fieldCode == "" {
+			continue
+		}
+
+		item.FieldCode = fieldCode
+		// set field name to field code, but remove underscore and make it camel case
+		// example: item.FieldCode = "object_field_code" => item.FieldName = "ObjectFieldCode"
+		item.FieldName = strings.ReplaceAll(item.FieldCode, "_", " ")
+		item.FieldName = cases.Title(language.English).String(item.FieldName)
 
-			if fieldCode == "" {
+		if field, ok := objectFields[fieldCode]; ok {
+			data, ok := field.(entity.ObjectFields)
+			if !ok {
 				continue
 			}
 
-			item.FieldCode = fieldCode
-			// set field name to field code, but remove underscore and make it camel case
-			// example: item.FieldCode = "object_field_code" => item.FieldName = "ObjectFieldCode"
-			item.FieldName = strings.ReplaceAll(item.FieldCode, "_", " ")
-			item.FieldName = cases.Title(language.English).String(item.FieldName)
+			// set custom field name
+			item.FieldName = data.DisplayName
 
-			if field, ok := objectFields[fieldCode]; ok {
-				data, ok := field.(entity.ObjectFields)
-				if !ok {
-					continue
-				}
-
-				// set custom field name
-				item.FieldName = data.DisplayName
+			// set custom data type
+			item.DataType = data.DataType.Name
+		}
 
-				// set custom data type
-				item.DataType = data.DataType.Name
-			}
+		if requestDisplayName, ok := request.Fields[item.CompleteFieldCode]; ok {
+			// set custom field name
+			item.FieldName = requestDisplayName.FieldName
+		}
 
-			if requestDisplayName, ok := request.Fields[item.CompleteFieldCode]; ok {
-				// set custom field name
-				item.FieldName = requestDisplayName.FieldName
-			}
+		// set item.DataType to CamelCase
+		item.DataType = cases.Title(language.English).String(item.DataType)
 
-			// set item.DataType to CamelCase
-			item.DataType = cases.Title(language.English).String(item.DataType)
+		// check if additionaldata.foreign_table_name and additionaldata.foreign_field_name exist
+		if isForeignKeyItem(item) {
+			item.DisplayValue = items[j+entity.DisplayFieldSuffix].Value
+		}
 
-			// check if additionaldata.foreign_table_name and additionaldata.foreign_field_name exist
-			if item.AdditionalData["foreign_table_name"] != nil && item.AdditionalData["foreign_field_name"] != nil {
-				if _, ok := foreignTables[item.AdditionalData["foreign_table_name"].(string)]; !ok {
-					foreignTables[item.AdditionalData["foreign_table_name"].(string)] = []any{}
-				}
+		items[j] = item
+	}
 
-				foreignTables[item.AdditionalData["foreign_table_name"].(string)] = append(foreignTables[item.AdditionalData["foreign_table_name"].(string)], item.Value)
+	return items
+}
 
-				displayValue := items[fmt.Sprintf("%v__name", j)].Value
-				item.DisplayValue = displayValue
-			}
+// isForeignKeyItem reports whether item is a foreign key, its display value is read from <field>__name
+func isForeignKeyItem(item entity.DataItem) bool {
+	return item.AdditionalData["foreign_table_name"] != nil && item.AdditionalData["foreign_field_name"] != nil
+}
 
-			results.Items[i][j] = item
+// setForeignDisplayValues sets the display value of the foreign keys of item to the display value of the
+// records they reference
+func setForeignDisplayValues(item map[string]entity.DataItem) {
+	for columnName, dataItem := range item {
+		if isForeignKeyItem(dataItem) {
+			dataItem.DisplayValue = item[columnName+entity.DisplayFieldSuffix].Value
+			item[columnName] = dataItem
 		}
 	}
+}
 
-	foreignRequest := entity.CatalogQuery{
-		TenantCode:  request.TenantCode,
-		ProductCode: request.ProductCode,
-	}
-
-	foreignTableResults := make(map[string]map[string]string)
+func (uc *catalogUsecase) GetObjectDetail(ctx context.Context, request entity.CatalogQuery, serial string) (resp map[string]entity.DataItem, err error) {
+	accessControl := newAccessControl(uc.accessRepo)
 
-	for foreignTable, params := range foreignTables {
-		foreignRequest.ObjectCode = foreignTable
-		foreignRequest.Filters = append(foreignRequest.Filters, entity.FilterGroup{
-			Operator: entity.NewFilterGroupOperator(entity.FilterOperatorAnd),
-			Filters: map[string]entity.FilterItem{
-				"serial": {Operator: entity.FilterOperatorIN, Value: params},
-			},
-		})
+	permission, err := accessControl.Authorize(ctx, request.TenantCode, request.ObjectCode, entity.PermissionRead)
+	if err != nil {
+		return resp, err
+	}
 
-		foreignResults, err := uc.catalogRepo.GetObjectData(ctx, foreignRequest)
-		if err != nil {
-			return resp, err
-		}
+	if err := checkQueryFields(permission, request); err != nil {
+		return resp, err
+	}
 
-		itemMap := make(map[string]string)
-		for _, item := range foreignResults.Items {
-			itemMap[item["serial"].Value.(string)] = item["name"].Value.(string)
-		}
+	if err := accessControl.authorizeChainedFields(ctx, uc.catalogRepo, request.TenantCode, request.ObjectCode, permission, queryFieldNames(request)); err != nil {
+		return resp, err
+	}
 
-		foreignTableResults[foreignTable] = itemMap
+	if err := checkTrashAccess(permission, request); err != nil {
+		return resp, err
 	}
 
-	return results, err
-}
+	rowFilters, err := accessControl.RowFilters(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return resp, err
+	}
 
-func (uc *catalogUsecase) GetObjectDetail(ctx context.Context, request entity.CatalogQuery, serial string) (resp map[string]entity.DataItem, err error) {
 	request.Serial = serial
+	request.Filters = append(request.Filters, rowFilters...)
 
 	resp, err = uc.catalogRepo.GetObjectDetail(ctx, request)
 	if err != nil {
 		return resp, err
 	}
 
-	for columnName, item := range resp {
-		if item.AdditionalData["foreign_table_name"] != nil && item.AdditionalData["foreign_field_name"] != nil {
-			// handle display value logic here
-			// room for improvement: replace hardcoded __name with display value from catalog
-			item.DisplayValue = resp[fmt.Sprintf("%v__name", columnName)].Value
+	setForeignDisplayValues(resp)
+	resp = stripHiddenFields(permission, resp)
 
-			resp[columnName] = item
+	if len(resp) > 0 {
+		if err := uc.expandRelations(ctx, permission, request, []map[string]entity.DataItem{resp}, 1); err != nil {
+			return resp, err
 		}
 	}
 
-	return
+	return resp, nil
 }
 
+// GetDataByRawQuery runs a query of the caller on the database of the object. A raw query can read any column, table
+// or schema whatever the permissions and row policies, it is only routed to the administrators.
 func (uc *catalogUsecase) GetDataByRawQuery(ctx context.Context, request entity.CatalogQuery) (resp entity.CatalogResponse, err error) {
 	return uc.catalogRepo.GetDataByRawQuery(ctx, request)
 }
 
+func (uc *catalogUsecase) GetObjectPermission(ctx context.Context, tenantCode, objectCode string) (resp entity.ObjectPermission, err error) {
+	return newAccessControl(uc.accessRepo).GetObjectPermission(ctx, tenantCode, objectCode)
+}
+
 func (uc *catalogUsecase) GetContentLayoutByKeys(ctx context.Context, request entity.GetViewContentByKeysRequest, catalogQuery entity.CatalogQuery) (resp entity.ViewContentResponse, err error) {
 	viewContentRecord, err := uc.viewRepo.GetViewContentByKeys(ctx, request)
 	if err != nil {
@@ -586,6 +707,13 @@ func (uc *catalogUsecase) GetContentLayoutByKeys(ctx context.Context, request en
 		return resp, err
 	}
 
+	permission, err := uc.GetObjectPermission(ctx, catalogQuery.TenantCode, catalogQuery.ObjectCode)
+	if err != nil {
+		return resp, err
+	}
+
+	originalFields = visibleFields(permission, originalFields)
+
 	// handle custom object fields based on object field table
 	objectFields := map[string]any{}
 	if catalogQuery.ObjectSerial != "" {
@@ -628,18 +756,341 @@ func (uc *catalogUsecase) GetContentLayoutByKeys(ctx context.Context, request en
 }
 
 func (uc *catalogUsecase) CreateObjectData(ctx context.Context, request entity.DataMutationRequest) (resp map[string]entity.DataItem, err error) {
-	return uc.catalogRepo.CreateObjectData(ctx, request)
+	request.UserSerial = userSerialFromContext(ctx)
+
+	permission, err := newAccessControl(uc.accessRepo).GetObjectPermission(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return resp, err
+	}
+
+	// the nested records and the audit logs are written with the record or not at all
+	err = uc.catalogRepo.WithTransaction(ctx, request.TenantCode, request.ObjectCode, func(txRepo repository.CatalogRepository) error {
+		resp, err = uc.createObjectData(ctx, txRepo, newValidationEngine(txRepo), permission, request)
+		return err
+	})
+
+	return resp, err
 }
 
 func (uc *catalogUsecase) UpdateObjectData(ctx context.Context, request entity.DataMutationRequest) (resp map[string]entity.DataItem, err error) {
-	return uc.catalogRepo.UpdateObjectData(ctx, request)
+	request.UserSerial = userSerialFromContext(ctx)
+
+	accessControl := newAccessControl(uc.accessRepo)
+
+	permission, err := accessControl.GetObjectPermission(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return resp, err
+	}
+
+	request.Filters, err = accessControl.RowFilters(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return resp, err
+	}
+
+	err = uc.catalogRepo.WithTransaction(ctx, request.TenantCode, request.ObjectCode, func(txRepo repository.CatalogRepository) error {
+		resp, err = uc.updateObjectData(ctx, txRepo, newValidationEngine(txRepo), permission, request)
+		return err
+	})
+
+	return resp, err
 }
 
 func (uc *catalogUsecase) DeleteObjectData(ctx context.Context, request entity.DataMutationRequest) (err error) {
-	return uc.catalogRepo.DeleteObjectData(ctx, request)
+	request.UserSerial = userSerialFromContext(ctx)
+
+	accessControl := newAccessControl(uc.accessRepo)
+
+	if _, err := accessControl.Authorize(ctx, request.TenantCode, request.ObjectCode, entity.PermissionDelete); err != nil {
+		return err
+	}
+
+	request.Filters, err = accessControl.RowFilters(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return err
+	}
+
+	return uc.setObjectDataDeleted(ctx, accessControl, request, false)
+}
+
+// RestoreObjectData restores a soft deleted record, the users who can delete a record can restore it
+func (uc *catalogUsecase) RestoreObjectData(ctx context.Context, request entity.DataMutationRequest) (err error) {
+	request.UserSerial = userSerialFromContext(ctx)
+
+	accessControl := newAccessControl(uc.accessRepo)
+
+	if _, err := accessControl.Authorize(ctx, request.TenantCode, request.ObjectCode, entity.PermissionDelete); err != nil {
+		return err
+	}
+
+	request.Filters, err = accessControl.RowFilters(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return err
+	}
+
+	return uc.setObjectDataDeleted(ctx, accessControl, request, true)
+}
+
+// userSerialFromContext returns the serial of the authenticated user, mutations made without one are
+// recorded as entity.DefaultUserSerial
+func userSerialFromContext(ctx context.Context) string {
+	if user, ok := entity.AuthUserFromContext(ctx); ok && user.Serial != "" {
+		return user.Serial
+	}
+
+	return entity.DefaultUserSerial
+}
+
+// createObjectData authorizes, validates and creates a record using the given repository, which may be bound to a transaction
+func (uc *catalogUsecase) createObjectData(ctx context.Context, catalogRepo repository.CatalogRepository, validator *validationEngine, permission entity.ObjectPermission, request entity.DataMutationRequest) (resp map[string]entity.DataItem, err error) {
+	return uc.createObjectTree(ctx, catalogRepo, validator, permission, request, 0)
+}
+
+// createObjectTree is createObjectData for a record nested depth levels deep, its own nested records are created
+// after it so they can reference it
+func (uc *catalogUsecase) createObjectTree(ctx context.Context, catalogRepo repository.CatalogRepository, validator *validationEngine, permission entity.ObjectPermission, request entity.DataMutationRequest, depth int) (resp map[string]entity.DataItem, err error) {
+	if !permission.Can(entity.PermissionCreate) {
+		return resp, errActionForbidden(entity.PermissionCreate, request.ObjectCode)
+	}
+
+	if err := checkWritableFields(permission, request.Items); err != nil {
+		return resp, err
+	}
+
+	request, nested, err := uc.splitNestedRecords(ctx, catalogRepo, request)
+	if err != nil {
+		return resp, err
+	}
+
+	request, err = validator.ValidateCreate(ctx, request)
+	if err != nil {
+		return resp, err
+	}
+
+	resp, err = catalogRepo.CreateObjectData(ctx, request)
+	if err != nil {
+		return resp, err
+	}
+
+	auditLog := newAuditLog(ctx, request.TenantCode, request.ObjectCode, request.UserSerial, resp[entity.DEFAULT_IDENTIFIER].Value, entity.AuditActionCreate, createdFieldChanges(resp))
+	if err := catalogRepo.CreateAuditLogs(ctx, []entity.AuditLog{auditLog}); err != nil {
+		return resp, err
+	}
+
+	if len(nested) > 0 {
+		if err := uc.createNestedRecords(ctx, catalogRepo, validator, request, resp, nested, depth+1); err != nil {
+			return resp, err
+		}
+	}
+
+	return stripHiddenFields(permission, resp), nil
+}
+
+// updateObjectData authorizes, validates and updates a record using the given repository, which may be bound to a transaction
+func (uc *catalogUsecase) updateObjectData(ctx context.Context, catalogRepo repository.CatalogRepository, validator *validationEngine, permission entity.ObjectPermission, request entity.DataMutationRequest) (resp map[string]entity.DataItem, err error) {
+	if !permission.Can(entity.PermissionUpdate) {
+		return resp, errActionForbidden(entity.PermissionUpdate, request.ObjectCode)
+	}
+
+	if err := checkWritableFields(permission, request.Items); err != nil {
+		return resp, err
+	}
+
+	existingData, err := catalogRepo.GetObjectDetail(ctx, entity.CatalogQuery{
+		TenantCode:  request.TenantCode,
+		ProductCode: request.ProductCode,
+		ObjectCode:  request.ObjectCode,
+		Serial:      request.Serial,
+		Filters:     request.Filters,
+	})
+	if err != nil {
+		return resp, err
+	}
+
+	if len(existingData) == 0 {
+		return resp, entity.ErrorNotFound
+	}
+
+	request, err = validator.ValidateUpdate(ctx, request, existingData)
+	if err != nil {
+		return resp, err
+	}
+
+	resp, err = catalogRepo.UpdateObjectData(ctx, request)
+	if err != nil {
+		return resp, err
+	}
+
+	auditLog := newAuditLog(ctx, request.TenantCode, request.ObjectCode, request.UserSerial, request.Serial, entity.AuditActionUpdate, updatedFieldChanges(existingData, resp))
+	if err := catalogRepo.CreateAuditLogs(ctx, []entity.AuditLog{auditLog}); err != nil {
+		return resp, err
+	}
+
+	return stripHiddenFields(permission, resp), nil
+}
+
+// BulkMutateObjectData runs a batch of create, update and delete operations in one transaction.
+// In all_or_nothing mode the first failure rolls back the whole batch, in best_effort mode every
+// operation runs in its own savepoint so a failure only discards that operation.
+func (uc *catalogUsecase) BulkMutateObjectData(ctx context.Context, request entity.BulkMutationRequest) (resp entity.BulkMutationResponse, err error) {
+	request.UserSerial = userSerialFromContext(ctx)
+
+	if request.Mode == "" {
+		request.Mode = entity.BulkModeAllOrNothing
+	}
+
+	if request.Mode != entity.BulkModeAllOrNothing && request.Mode != entity.BulkModeBestEffort {
+		return resp, fmt.Errorf("%w: unsupported bulk mode %v", entity.ErrorBadRequest, request.Mode)
+	}
+
+	if len(request.Operations) == 0 {
+		return resp, fmt.Errorf("%w: no bulk operation found", entity.ErrorBadRequest)
+	}
+
+	if uc.cfg.BulkMaxOperations > 0 && len(request.Operations) > uc.cfg.BulkMaxOperations {
+		return resp, fmt.Errorf("%w: maximum %v operations are allowed in one request", entity.ErrorBadRequest, uc.cfg.BulkMaxOperations)
+	}
+
+	resp = entity.BulkMutationResponse{
+		Mode:    request.Mode,
+		Total:   len(request.Operations),
+		Results: make([]entity.BulkOperationResult, 0, len(request.Operations)),
+	}
+
+	accessControl := newAccessControl(uc.accessRepo)
+
+	permission, err := accessControl.GetObjectPermission(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return resp, err
+	}
+
+	rowFilters, err := accessControl.RowFilters(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return resp, err
+	}
+
+	err = uc.catalogRepo.WithTransaction(ctx, request.TenantCode, request.ObjectCode, func(txRepo repository.CatalogRepository) error {
+		validator := newValidationEngine(txRepo)
+
+		for i, operation := range request.Operations {
+			result := entity.BulkOperationResult{
+				Index:     i,
+				Operation: operation.Operation,
+				Serial:    operation.Serial,
+			}
+
+			mutation := entity.DataMutationRequest{
+				Serial:      operation.Serial,
+				Items:       operation.Items,
+				ObjectCode:  request.ObjectCode,
+				TenantCode:  request.TenantCode,
+				ProductCode: request.ProductCode,
+				UserSerial:  request.UserSerial,
+				Filters:     rowFilters,
+			}
+
+			var data map[string]entity.DataItem
+			var operationErr error
+			if request.Mode == entity.BulkModeBestEffort {
+				operationErr = txRepo.WithTransaction(ctx, request.TenantCode, request.ObjectCode, func(savepointRepo repository.CatalogRepository) error {
+					data, operationErr = uc.runBulkOperation(ctx, savepointRepo, validator, permission, operation.Operation, mutation)
+					return operationErr
+				})
+			} else {
+				data, operationErr = uc.runBulkOperation(ctx, txRepo, validator, permission, operation.Operation, mutation)
+			}
+
+			if operationErr != nil {
+				result.Status = entity.BulkOperationStatusFailed
+				result.Error = operationErr.Error()
+
+				var validationErrors entity.ValidationErrors
+				if errors.As(operationErr, &validationErrors) {
+					result.ValidationErrors = validationErrors.ByField()
+				}
+
+				resp.Failed++
+				resp.Results = append(resp.Results, result)
+
+				if request.Mode == entity.BulkModeAllOrNothing {
+					return entity.ErrorBulkRolledBack
+				}
+
+				continue
+			}
+
+			result.Status = entity.BulkOperationStatusSuccess
+			result.Data = data
+			if serial, ok := data[entity.DEFAULT_IDENTIFIER]; ok && result.Serial == "" {
+				result.Serial = fmt.Sprintf("%v", serial.Value)
+			}
+
+			resp.Succeeded++
+			resp.Results = append(resp.Results, result)
+		}
+
+		return nil
+	})
+
+	if errors.Is(err, entity.ErrorBulkRolledBack) {
+		// nothing is persisted, report the executed operations as rolled back and the rest as skipped
+		for i := range resp.Results {
+			if resp.Results[i].Status == entity.BulkOperationStatusSuccess {
+				resp.Results[i].Status = entity.BulkOperationStatusRolledBack
+				resp.Results[i].Data = nil
+			}
+		}
+
+		for i := len(resp.Results); i < len(request.Operations); i++ {
+			resp.Results = append(resp.Results, entity.BulkOperationResult{
+				Index:     i,
+				Operation: request.Operations[i].Operation,
+				Serial:    request.Operations[i].Serial,
+				Status:    entity.BulkOperationStatusSkipped,
+			})
+		}
+
+		resp.Succeeded = 0
+		return resp, err
+	}
+
+	if err != nil {
+		return resp, err
+	}
+
+	resp.IsCommitted = true
+	return resp, nil
+}
+
+func (uc *catalogUsecase) runBulkOperation(ctx context.Context, catalogRepo repository.CatalogRepository, validator *validationEngine, permission entity.ObjectPermission, operation entity.BulkOperationType, request entity.DataMutationRequest) (resp map[string]entity.DataItem, err error) {
+	switch operation {
+	case entity.BulkOperationCreate:
+		return uc.createObjectData(ctx, catalogRepo, validator, permission, request)
+	case entity.BulkOperationUpdate:
+		if request.Serial == "" {
+			return resp, entity.ErrorSerialEmpty
+		}
+
+		return uc.updateObjectData(ctx, catalogRepo, validator, permission, request)
+	case entity.BulkOperationDelete:
+		if request.Serial == "" {
+			return resp, entity.ErrorSerialEmpty
+		}
+
+		if !permission.Can(entity.PermissionDelete) {
+			return resp, errActionForbidden(entity.PermissionDelete, request.ObjectCode)
+		}
+
+		return resp, uc.setRecordDeleted(ctx, catalogRepo, newAccessControl(uc.accessRepo), request, false)
+	}
+
+	return resp, fmt.Errorf("unsupported bulk operation %v", operation)
 }
 
 func (uc *catalogUsecase) ExportObjectData(ctx context.Context, request entity.CatalogQuery, format entity.ExportFormat, isIncludeMetadata bool) (resp entity.ExportResponse, err error) {
+	if _, err := newAccessControl(uc.accessRepo).Authorize(ctx, request.TenantCode, request.ObjectCode, entity.PermissionExport); err != nil {
+		return resp, err
+	}
+
 	// Get data using existing GetObjectData function
 	data, err := uc.GetObjectData(ctx, request)
 	if err != nil {
@@ -654,19 +1105,8 @@ func (uc *catalogUsecase) ExportObjectData(ctx context.Context, request entity.C
 	if len(data.Items) > 0 {
 		for _, item := range data.Items[0] {
 			// Skip metadata fields if is_include_metadata is false
-			if !isIncludeMetadata {
-				fieldName := item.FieldName
-				// Convert field name to lowercase for comparison
-				fieldNameLower := strings.ToLower(fieldName)
-				// Check both space and underscore formats
-				if strings.EqualFold(fieldNameLower, "created at") || strings.EqualFold(fieldNameLower, "created_at") ||
-					strings.EqualFold(fieldNameLower, "created by") || strings.EqualFold(fieldNameLower, "created_by") ||
-					strings.EqualFold(fieldNameLower, "updated at") || strings.EqualFold(fieldNameLower, "updated_at") ||
-					strings.EqualFold(fieldNameLower, "updated by") || strings.EqualFold(fieldNameLower, "updated_by") ||
-					strings.EqualFold(fieldNameLower, "deleted at") || strings.EqualFold(fieldNameLower, "deleted_at") ||
-					strings.EqualFold(fieldNameLower, "deleted by") || strings.EqualFold(fieldNameLower, "deleted_by") {
-					continue
-				}
+			if !isIncludeMetadata && isMetadataField(item.FieldName) {
+				continue
 			}
 			headers = append(headers, item.FieldName)
 		}
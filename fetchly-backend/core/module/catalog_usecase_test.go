@@ -0,0 +1,148 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+	"github.com/fetchlydev/source/fetchly-backend/core/repository"
+)
+
+var errCreateRejected = errors.New("create rejected")
+
+// savepointRepo is a catalog repository of an object without metadata whose created records are discarded
+// when the transaction or savepoint they were created in returns an error
+type savepointRepo struct {
+	repository.CatalogRepository
+	records []string
+}
+
+func (r *savepointRepo) WithTransaction(ctx context.Context, tenantCode, objectCode string, fn func(txRepo repository.CatalogRepository) error) error {
+	savepoint := len(r.records)
+	if err := fn(r); err != nil {
+		r.records = r.records[:savepoint]
+		return err
+	}
+
+	return nil
+}
+
+func (r *savepointRepo) GetObjectByCode(ctx context.Context, objectCode, tenantCode string) (resp entity.Objects, err error) {
+	return resp, fmt.Errorf("%w: object %v", entity.ErrorNotFound, objectCode)
+}
+
+func (r *savepointRepo) CreateObjectData(ctx context.Context, request entity.DataMutationRequest) (resp map[string]entity.DataItem, err error) {
+	var name string
+	for _, item := range request.Items {
+		if item.FieldCode == "name" {
+			name, _ = item.Value.(string)
+		}
+	}
+
+	if name == "rejected" {
+		return resp, errCreateRejected
+	}
+
+	r.records = append(r.records, name)
+	return map[string]entity.DataItem{entity.DEFAULT_IDENTIFIER: {FieldCode: entity.DEFAULT_IDENTIFIER, Value: len(r.records)}}, nil
+}
+
+func (r *savepointRepo) CreateAuditLogs(ctx context.Context, auditLogs []entity.AuditLog) error {
+	return nil
+}
+
+func TestBulkMutateObjectData(t *testing.T) {
+	create := func(name string) entity.BulkOperation {
+		return entity.BulkOperation{Operation: entity.BulkOperationCreate, Items: []entity.DataItem{{FieldCode: "name", Value: name}}}
+	}
+	updateWithoutSerial := entity.BulkOperation{Operation: entity.BulkOperationUpdate}
+
+	tests := []struct {
+		name          string
+		mode          entity.BulkMode
+		operations    []entity.BulkOperation
+		wantErr       error
+		wantCommitted bool
+		wantStatuses  []entity.BulkOperationStatus
+		wantRecords   []string
+		wantSucceeded int
+		wantFailed    int
+	}{
+		{
+			name:          "all or nothing commit",
+			operations:    []entity.BulkOperation{create("a"), create("b")},
+			wantCommitted: true,
+			wantStatuses:  []entity.BulkOperationStatus{entity.BulkOperationStatusSuccess, entity.BulkOperationStatusSuccess},
+			wantRecords:   []string{"a", "b"},
+			wantSucceeded: 2,
+		},
+		{
+			name:         "all or nothing rollback",
+			mode:         entity.BulkModeAllOrNothing,
+			operations:   []entity.BulkOperation{create("a"), create("rejected"), create("b")},
+			wantErr:      entity.ErrorBulkRolledBack,
+			wantStatuses: []entity.BulkOperationStatus{entity.BulkOperationStatusRolledBack, entity.BulkOperationStatusFailed, entity.BulkOperationStatusSkipped},
+			wantFailed:   1,
+		},
+		{
+			name:          "best effort savepoints",
+			mode:          entity.BulkModeBestEffort,
+			operations:    []entity.BulkOperation{create("a"), create("rejected"), updateWithoutSerial, create("b")},
+			wantCommitted: true,
+			wantStatuses: []entity.BulkOperationStatus{
+				entity.BulkOperationStatusSuccess, entity.BulkOperationStatusFailed, entity.BulkOperationStatusFailed, entity.BulkOperationStatusSuccess,
+			},
+			wantRecords:   []string{"a", "b"},
+			wantSucceeded: 2,
+			wantFailed:    2,
+		},
+		{
+			name:       "unsupported mode",
+			mode:       "eventually",
+			operations: []entity.BulkOperation{create("a")},
+			wantErr:    entity.ErrorBadRequest,
+		},
+		{
+			name:    "no operation",
+			mode:    entity.BulkModeBestEffort,
+			wantErr: entity.ErrorBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &savepointRepo{}
+			uc := &catalogUsecase{catalogRepo: repo, accessRepo: &grantRepo{}}
+
+			resp, err := uc.BulkMutateObjectData(context.Background(), entity.BulkMutationRequest{
+				Mode:       tt.mode,
+				Operations: tt.operations,
+				TenantCode: "acme",
+				ObjectCode: "contact",
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("BulkMutateObjectData() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if resp.IsCommitted != tt.wantCommitted || resp.Succeeded != tt.wantSucceeded || resp.Failed != tt.wantFailed {
+				t.Errorf("committed %v, succeeded %d, failed %d, want %v, %d, %d", resp.IsCommitted, resp.Succeeded, resp.Failed, tt.wantCommitted, tt.wantSucceeded, tt.wantFailed)
+			}
+
+			if len(resp.Results) != len(tt.wantStatuses) {
+				t.Fatalf("got results %+v, want statuses %v", resp.Results, tt.wantStatuses)
+			}
+
+			for i, result := range resp.Results {
+				if result.Index != i || result.Status != tt.wantStatuses[i] {
+					t.Errorf("result %d is %v at index %d, want %v", i, result.Status, result.Index, tt.wantStatuses[i])
+				}
+			}
+
+			if fmt.Sprint(repo.records) != fmt.Sprint(tt.wantRecords) || len(repo.records) != len(tt.wantRecords) {
+				t.Errorf("persisted records %v, want %v", repo.records, tt.wantRecords)
+			}
+		})
+	}
+}
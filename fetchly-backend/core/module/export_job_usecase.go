@@ -0,0 +1,412 @@
+package module
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/fetchlydev/source/fetchly-backend/config"
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+	"github.com/fetchlydev/source/fetchly-backend/pkg/conn"
+	"github.com/fetchlydev/source/fetchly-backend/pkg/helper"
+	"github.com/fetchlydev/source/fetchly-backend/pkg/storage"
+)
+
+const (
+	exportJobKeyPrefix     = "export_job"
+	exportJobQueueKey      = "export_job:queue"
+	exportJobProcessingKey = "export_job:processing"
+	exportJobMaintainKey   = "export_job:maintain"
+
+	// a running job holds its lock and refreshes it while the file is generated,
+	// a job in the processing list without lock belongs to a worker that died
+	exportJobLockTTL          int64 = 60
+	exportJobHeartbeat              = 20 * time.Second
+	exportJobPopTimeout       int64 = 5
+	exportJobMaintainInterval       = time.Minute
+	exportJobMaxAttempts            = 3
+)
+
+type ExportJobUsecase interface {
+	SubmitExportJob(ctx context.Context, request entity.ExportJobRequest) (resp entity.ExportJob, err error)
+	GetExportJob(ctx context.Context, tenantCode, jobID string) (resp entity.ExportJob, err error)
+	OpenExportJobFile(ctx context.Context, tenantCode, jobID string) (resp entity.ExportJob, file io.ReadCloser, err error)
+	StartWorkers(ctx context.Context)
+}
+
+type exportJobUsecase struct {
+	cfg       config.Config
+	cache     conn.CacheService
+	storage   storage.Storage
+	catalogUc CatalogUsecase
+}
+
+func NewExportJobUsecase(cfg config.Config, cache conn.CacheService, storage storage.Storage, catalogUc CatalogUsecase) ExportJobUsecase {
+	return &exportJobUsecase{
+		cfg:       cfg,
+		cache:     cache,
+		storage:   storage,
+		catalogUc: catalogUc,
+	}
+}
+
+// SubmitExportJob stores a queued export job and pushes it to the shared redis queue
+func (uc *exportJobUsecase) SubmitExportJob(ctx context.Context, request entity.ExportJobRequest) (resp entity.ExportJob, err error) {
+	request.UserSerial = userSerialFromContext(ctx)
+
+	if request.Format == "" {
+		request.Format = entity.ExportFormatXLSX
+	}
+
+	contentType, ok := entity.ExportContentTypes[request.Format]
+	if !ok {
+		return resp, fmt.Errorf("%w: unsupported export format %v", entity.ErrorBadRequest, request.Format)
+	}
+
+	// checked up front so a forbidden export fails right away instead of in the worker
+	permission, err := uc.catalogUc.GetObjectPermission(ctx, request.Query.TenantCode, request.Query.ObjectCode)
+	if err != nil {
+		return resp, err
+	}
+
+	if !permission.Can(entity.PermissionExport) {
+		return resp, errActionForbidden(entity.PermissionExport, request.Query.ObjectCode)
+	}
+
+	jobID, err := helper.GenerateUUUID()
+	if err != nil {
+		return resp, err
+	}
+
+	now := time.Now()
+	resp = entity.ExportJob{
+		ID:                jobID,
+		TenantCode:        request.Query.TenantCode,
+		ProductCode:       request.Query.ProductCode,
+		ObjectCode:        request.Query.ObjectCode,
+		ViewContentCode:   request.Query.ViewContentCode,
+		Query:             request.Query,
+		Format:            request.Format,
+		IsIncludeMetadata: request.IsIncludeMetadata,
+		Status:            entity.ExportJobStatusQueued,
+		FileName:          ExportFileName(request.Query.ObjectCode, request.Format),
+		ContentType:       contentType,
+		CreatedBy:         request.UserSerial,
+		CreatedAt:         now,
+		ExpiresAt:         now.Add(time.Duration(uc.cfg.ExportJobTTL) * time.Second),
+	}
+
+	if err := uc.saveJob(resp); err != nil {
+		return resp, err
+	}
+
+	if err := uc.cache.Push(exportJobQueueKey, []byte(exportJobQueueItem(resp.TenantCode, resp.ID))); err != nil {
+		return resp, err
+	}
+
+	return resp, nil
+}
+
+// GetExportJob returns the job only to the user who submitted it, other users of the tenant get
+// entity.ErrorExportJobNotFound so the ids of their jobs are not disclosed
+func (uc *exportJobUsecase) GetExportJob(ctx context.Context, tenantCode, jobID string) (resp entity.ExportJob, err error) {
+	resp, err = uc.loadJob(tenantCode, jobID)
+	if err != nil {
+		return resp, err
+	}
+
+	if resp.CreatedBy != userSerialFromContext(ctx) {
+		return entity.ExportJob{}, entity.ErrorExportJobNotFound
+	}
+
+	return resp, nil
+}
+
+// loadJob returns the stored job of the tenant whoever submitted it
+func (uc *exportJobUsecase) loadJob(tenantCode, jobID string) (resp entity.ExportJob, err error) {
+	key := exportJobKey(tenantCode, jobID)
+
+	exists, err := uc.cache.Exists(key)
+	if err != nil {
+		return resp, err
+	}
+
+	if !exists {
+		return resp, entity.ErrorExportJobNotFound
+	}
+
+	data, err := uc.cache.Get(key)
+	if err != nil {
+		return resp, err
+	}
+
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return resp, err
+	}
+
+	return resp, nil
+}
+
+func (uc *exportJobUsecase) OpenExportJobFile(ctx context.Context, tenantCode, jobID string) (resp entity.ExportJob, file io.ReadCloser, err error) {
+	resp, err = uc.GetExportJob(ctx, tenantCode, jobID)
+	if err != nil {
+		return resp, nil, err
+	}
+
+	if resp.Status != entity.ExportJobStatusCompleted {
+		return resp, nil, entity.ErrorExportJobNotReady
+	}
+
+	file, err = uc.storage.Open(exportJobFileKey(resp))
+	if err != nil {
+		return resp, nil, err
+	}
+
+	return resp, file, nil
+}
+
+// StartWorkers runs the worker pool and the maintenance loop until ctx is done. Every instance runs
+// its own pool, the redis queue makes sure a job is only picked up once.
+func (uc *exportJobUsecase) StartWorkers(ctx context.Context) {
+	workers := uc.cfg.ExportJobWorkers
+	if workers < 1 {
+		return
+	}
+
+	for i := 0; i < workers; i++ {
+		go uc.runWorker(ctx)
+	}
+
+	go uc.runMaintenance(ctx)
+}
+
+func (uc *exportJobUsecase) runWorker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		item, err := uc.cache.PopPush(exportJobQueueKey, exportJobProcessingKey, exportJobPopTimeout)
+		if err != nil {
+			log.Printf("error at export job worker. Detail: %v", err)
+			time.Sleep(time.Second)
+			continue
+		}
+
+		if item == nil {
+			continue
+		}
+
+		uc.processJob(ctx, string(item))
+	}
+}
+
+func (uc *exportJobUsecase) processJob(ctx context.Context, item string) {
+	tenantCode, jobID, ok := parseExportJobQueueItem(item)
+	if !ok {
+		uc.cache.ListRemove(exportJobProcessingKey, []byte(item))
+		return
+	}
+
+	// another worker is already running this job
+	lockKey := exportJobLockKey(tenantCode, jobID)
+	isLocked, err := uc.cache.SetNX(lockKey, []byte(item), exportJobLockTTL)
+	if err != nil || !isLocked {
+		return
+	}
+	defer uc.cache.Delete(lockKey)
+
+	job, err := uc.loadJob(tenantCode, jobID)
+	if err != nil || job.Status == entity.ExportJobStatusCompleted || job.Status == entity.ExportJobStatusFailed {
+		// expired or already finished
+		uc.cache.ListRemove(exportJobProcessingKey, []byte(item))
+		return
+	}
+
+	heartbeatCtx, stopHeartbeat := context.WithCancel(ctx)
+	defer stopHeartbeat()
+	go uc.heartbeat(heartbeatCtx, lockKey)
+
+	startedAt := time.Now()
+	job.Status = entity.ExportJobStatusRunning
+	job.StartedAt = &startedAt
+	job.Attempts++
+	job.ProcessedRows = 0
+	job.Error = ""
+	uc.saveJob(job)
+
+	err = uc.generateFile(ctx, &job)
+	if err != nil {
+		log.Printf("error at export job %v. Detail: %v", job.ID, err)
+		uc.storage.Delete(exportJobFileKey(job))
+
+		job.Error = err.Error()
+		job.Status = entity.ExportJobStatusFailed
+		if job.Attempts < exportJobMaxAttempts {
+			job.Status = entity.ExportJobStatusQueued
+		}
+	} else {
+		completedAt := time.Now()
+		job.Status = entity.ExportJobStatusCompleted
+		job.CompletedAt = &completedAt
+		job.Progress = 100
+		job.ExpiresAt = completedAt.Add(time.Duration(uc.cfg.ExportJobTTL) * time.Second)
+	}
+
+	if err := uc.saveJob(job); err != nil {
+		log.Printf("error at saving export job %v. Detail: %v", job.ID, err)
+	}
+
+	uc.cache.ListRemove(exportJobProcessingKey, []byte(item))
+	if job.Status == entity.ExportJobStatusQueued {
+		uc.cache.Push(exportJobQueueKey, []byte(item))
+	}
+}
+
+func (uc *exportJobUsecase) generateFile(ctx context.Context, job *entity.ExportJob) error {
+	// the export runs with the permissions of the user who submitted it
+	ctx = entity.ContextWithAuthUser(ctx, entity.AuthUser{Serial: job.CreatedBy, TenantCode: job.TenantCode})
+
+	// total rows are only used for the progress, a failing count does not stop the export
+	query := job.Query
+	query.Page = 1
+	query.PageSize = 1
+	if result, err := uc.catalogUc.GetObjectData(ctx, query); err == nil {
+		job.TotalRows = result.TotalData
+		uc.saveJob(*job)
+	}
+
+	file, err := uc.storage.Create(exportJobFileKey(*job))
+	if err != nil {
+		return err
+	}
+
+	err = uc.catalogUc.StreamExportObjectData(ctx, job.Query, job.Format, job.IsIncludeMetadata, file, func(processedRows int) {
+		job.ProcessedRows = processedRows
+		if job.TotalRows > 0 {
+			job.Progress = float64(processedRows) * 100 / float64(job.TotalRows)
+		}
+
+		uc.saveJob(*job)
+	})
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	job.FileSize, err = uc.storage.Size(exportJobFileKey(*job))
+	return err
+}
+
+func (uc *exportJobUsecase) heartbeat(ctx context.Context, lockKey string) {
+	ticker := time.NewTicker(exportJobHeartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := uc.cache.Expire(lockKey, exportJobLockTTL); err != nil {
+				log.Printf("error at export job heartbeat. Detail: %v", err)
+			}
+		}
+	}
+}
+
+// runMaintenance requeues jobs left behind by stopped instances and removes expired files,
+// only one instance does it per interval.
+func (uc *exportJobUsecase) runMaintenance(ctx context.Context) {
+	ticker := time.NewTicker(exportJobMaintainInterval)
+	defer ticker.Stop()
+
+	for {
+		isLocked, err := uc.cache.SetNX(exportJobMaintainKey, []byte("1"), int64(exportJobMaintainInterval.Seconds())-1)
+		if err == nil && isLocked {
+			uc.requeueOrphanJobs()
+
+			if err := uc.storage.Purge(time.Now().Add(-time.Duration(uc.cfg.ExportJobTTL) * time.Second)); err != nil {
+				log.Printf("error at purging export files. Detail: %v", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (uc *exportJobUsecase) requeueOrphanJobs() {
+	items, err := uc.cache.ListRange(exportJobProcessingKey)
+	if err != nil {
+		log.Printf("error at listing export jobs. Detail: %v", err)
+		return
+	}
+
+	for _, item := range items {
+		tenantCode, jobID, ok := parseExportJobQueueItem(string(item))
+		if !ok {
+			uc.cache.ListRemove(exportJobProcessingKey, item)
+			continue
+		}
+
+		isRunning, err := uc.cache.Exists(exportJobLockKey(tenantCode, jobID))
+		if err != nil || isRunning {
+			continue
+		}
+
+		log.Printf("requeue export job %v", jobID)
+		uc.cache.ListRemove(exportJobProcessingKey, item)
+		uc.cache.Push(exportJobQueueKey, item)
+	}
+}
+
+// saveJob stores the job until it expires
+func (uc *exportJobUsecase) saveJob(job entity.ExportJob) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	ttl := int64(time.Until(job.ExpiresAt).Seconds())
+	if ttl < 1 {
+		ttl = 1
+	}
+
+	return uc.cache.Set(exportJobKey(job.TenantCode, job.ID), data, ttl)
+}
+
+func exportJobKey(tenantCode, jobID string) string {
+	return fmt.Sprintf("%s:%s:%s", exportJobKeyPrefix, tenantCode, jobID)
+}
+
+func exportJobLockKey(tenantCode, jobID string) string {
+	return fmt.Sprintf("%s:lock:%s:%s", exportJobKeyPrefix, tenantCode, jobID)
+}
+
+func exportJobFileKey(job entity.ExportJob) string {
+	return fmt.Sprintf("%s/%s.%s", job.TenantCode, job.ID, job.Format)
+}
+
+func exportJobQueueItem(tenantCode, jobID string) string {
+	return tenantCode + ":" + jobID
+}
+
+func parseExportJobQueueItem(item string) (tenantCode, jobID string, ok bool) {
+	index := strings.LastIndex(item, ":")
+	if index < 1 || index == len(item)-1 {
+		return "", "", false
+	}
+
+	return item[:index], item[index+1:], true
+}
@@ -0,0 +1,341 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/fetchlydev/source/fetchly-backend/config"
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+	"github.com/fetchlydev/source/fetchly-backend/pkg/storage"
+)
+
+// memoryCache is a cache service kept in memory, PopPush does not block on an empty list
+type memoryCache struct {
+	values map[string][]byte
+	lists  map[string][][]byte
+}
+
+func newMemoryCache() *memoryCache {
+	return &memoryCache{values: map[string][]byte{}, lists: map[string][][]byte{}}
+}
+
+func (c *memoryCache) Ping() error { return nil }
+
+func (c *memoryCache) Get(key string) ([]byte, error) {
+	value, ok := c.values[key]
+	if !ok {
+		return nil, fmt.Errorf("key %v is not found", key)
+	}
+
+	return value, nil
+}
+
+func (c *memoryCache) Set(key string, value []byte, ttl int64) error {
+	c.values[key] = value
+	return nil
+}
+
+func (c *memoryCache) Exists(key string) (bool, error) {
+	_, ok := c.values[key]
+	return ok, nil
+}
+
+func (c *memoryCache) Delete(key string) error {
+	delete(c.values, key)
+	return nil
+}
+
+func (c *memoryCache) SetNX(key string, value []byte, ttl int64) (bool, error) {
+	if _, ok := c.values[key]; ok {
+		return false, nil
+	}
+
+	c.values[key] = value
+	return true, nil
+}
+
+func (c *memoryCache) Expire(key string, ttl int64) error { return nil }
+
+// Push adds to the head like LPUSH, PopPush takes from the tail like RPOPLPUSH
+func (c *memoryCache) Push(key string, value []byte) error {
+	c.lists[key] = append([][]byte{value}, c.lists[key]...)
+	return nil
+}
+
+func (c *memoryCache) PopPush(source, destination string, timeout int64) ([]byte, error) {
+	list := c.lists[source]
+	if len(list) == 0 {
+		return nil, nil
+	}
+
+	item := list[len(list)-1]
+	c.lists[source] = list[:len(list)-1]
+	c.Push(destination, item)
+
+	return item, nil
+}
+
+func (c *memoryCache) ListRange(key string) ([][]byte, error) {
+	return c.lists[key], nil
+}
+
+func (c *memoryCache) ListRemove(key string, value []byte) error {
+	list := [][]byte{}
+	for _, item := range c.lists[key] {
+		if string(item) != string(value) {
+			list = append(list, item)
+		}
+	}
+	c.lists[key] = list
+
+	return nil
+}
+
+// exportCatalog is a catalog usecase where everyone may export, the export writes the serial of the
+// user it runs as unless failures is still above zero
+type exportCatalog struct {
+	CatalogUsecase
+	permission entity.ObjectPermission
+	failures   int
+}
+
+func (uc *exportCatalog) GetObjectPermission(ctx context.Context, tenantCode, objectCode string) (resp entity.ObjectPermission, err error) {
+	return uc.permission, nil
+}
+
+func (uc *exportCatalog) GetObjectData(ctx context.Context, request entity.CatalogQuery) (resp entity.CatalogResponse, err error) {
+	return entity.CatalogResponse{TotalData: 2}, nil
+}
+
+func (uc *exportCatalog) StreamExportObjectData(ctx context.Context, request entity.CatalogQuery, format entity.ExportFormat, isIncludeMetadata bool, w io.Writer, onProgress func(processedRows int)) (err error) {
+	if uc.failures > 0 {
+		uc.failures--
+		return errors.New("data source is down")
+	}
+
+	_, err = io.WriteString(w, userSerialFromContext(ctx))
+	onProgress(2)
+
+	return err
+}
+
+func newExportJobTest(t *testing.T, catalogUc CatalogUsecase) (*exportJobUsecase, *memoryCache) {
+	fileStorage, err := storage.New(storage.DriverLocal, t.TempDir(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cache := newMemoryCache()
+	return &exportJobUsecase{cfg: config.Config{ExportJobTTL: 60}, cache: cache, storage: fileStorage, catalogUc: catalogUc}, cache
+}
+
+func userContext(serial string) context.Context {
+	return entity.ContextWithAuthUser(context.Background(), entity.AuthUser{Serial: serial, TenantCode: "acme"})
+}
+
+func TestSubmitExportJob(t *testing.T) {
+	tests := []struct {
+		name       string
+		permission entity.ObjectPermission
+		format     entity.ExportFormat
+		wantErr    error
+		wantQueue  int
+	}{
+		{name: "queued", permission: entity.ObjectPermission{CanExport: true}, format: entity.ExportFormatCSV, wantQueue: 1},
+		{name: "default format", permission: entity.ObjectPermission{CanExport: true}, wantQueue: 1},
+		{name: "unknown format", permission: entity.ObjectPermission{CanExport: true}, format: "pdf", wantErr: entity.ErrorBadRequest},
+		{name: "not allowed to export", permission: entity.ObjectPermission{CanRead: true}, format: entity.ExportFormatCSV, wantErr: entity.ErrorForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc, cache := newExportJobTest(t, &exportCatalog{permission: tt.permission})
+
+			job, err := uc.SubmitExportJob(userContext("ann"), entity.ExportJobRequest{
+				Query:  entity.CatalogQuery{TenantCode: "acme", ObjectCode: "contact"},
+				Format: tt.format,
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SubmitExportJob() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if len(cache.lists[exportJobQueueKey]) != tt.wantQueue {
+				t.Fatalf("queue %q, want %d items", cache.lists[exportJobQueueKey], tt.wantQueue)
+			}
+
+			if tt.wantErr == nil && (job.Status != entity.ExportJobStatusQueued || job.CreatedBy != "ann" || job.Format == "") {
+				t.Errorf("job %+v, want queued csv or xlsx job of ann", job)
+			}
+		})
+	}
+}
+
+func TestProcessExportJob(t *testing.T) {
+	tests := []struct {
+		name         string
+		failures     int
+		isLocked     bool
+		runs         int
+		wantStatus   entity.ExportJobStatus
+		wantAttempts int
+		wantQueue    int
+	}{
+		{name: "completed", runs: 1, wantStatus: entity.ExportJobStatusCompleted, wantAttempts: 1},
+		{name: "failure is retried", failures: 1, runs: 1, wantStatus: entity.ExportJobStatusQueued, wantAttempts: 1, wantQueue: 1},
+		{name: "retried until completed", failures: 2, runs: 3, wantStatus: entity.ExportJobStatusCompleted, wantAttempts: 3},
+		{name: "failed after the last attempt", failures: exportJobMaxAttempts, runs: exportJobMaxAttempts, wantStatus: entity.ExportJobStatusFailed, wantAttempts: exportJobMaxAttempts},
+		{name: "locked by another worker", isLocked: true, runs: 1, wantStatus: entity.ExportJobStatusQueued},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc, cache := newExportJobTest(t, &exportCatalog{permission: entity.ObjectPermission{CanExport: true}, failures: tt.failures})
+
+			ctx := userContext("ann")
+			job, err := uc.SubmitExportJob(ctx, entity.ExportJobRequest{
+				Query:  entity.CatalogQuery{TenantCode: "acme", ObjectCode: "contact"},
+				Format: entity.ExportFormatCSV,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.isLocked {
+				cache.SetNX(exportJobLockKey(job.TenantCode, job.ID), []byte("other"), exportJobLockTTL)
+			}
+
+			for i := 0; i < tt.runs; i++ {
+				item, _ := cache.PopPush(exportJobQueueKey, exportJobProcessingKey, exportJobPopTimeout)
+				if item == nil {
+					t.Fatalf("run %d found an empty queue", i+1)
+				}
+
+				uc.processJob(context.Background(), string(item))
+			}
+
+			job, err = uc.GetExportJob(ctx, job.TenantCode, job.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if job.Status != tt.wantStatus || job.Attempts != tt.wantAttempts {
+				t.Errorf("job is %v after %d attempts, want %v after %d", job.Status, job.Attempts, tt.wantStatus, tt.wantAttempts)
+			}
+
+			if len(cache.lists[exportJobQueueKey]) != tt.wantQueue {
+				t.Errorf("queue %q, want %d items", cache.lists[exportJobQueueKey], tt.wantQueue)
+			}
+
+			// a locked job stays in the processing list until the worker holding the lock finishes
+			wantProcessing := 0
+			if tt.isLocked {
+				wantProcessing = 1
+			}
+			if len(cache.lists[exportJobProcessingKey]) != wantProcessing {
+				t.Errorf("processing %q, want %d items", cache.lists[exportJobProcessingKey], wantProcessing)
+			}
+		})
+	}
+}
+
+func TestRequeueOrphanExportJobs(t *testing.T) {
+	uc, cache := newExportJobTest(t, &exportCatalog{})
+
+	cache.Push(exportJobProcessingKey, []byte(exportJobQueueItem("acme", "running")))
+	cache.Push(exportJobProcessingKey, []byte(exportJobQueueItem("acme", "orphan")))
+	cache.Push(exportJobProcessingKey, []byte("invalid"))
+	cache.SetNX(exportJobLockKey("acme", "running"), []byte("1"), exportJobLockTTL)
+
+	uc.requeueOrphanJobs()
+
+	if got := fmt.Sprintf("%s", cache.lists[exportJobQueueKey]); got != "[acme:orphan]" {
+		t.Errorf("queue %v, want [acme:orphan]", got)
+	}
+
+	if got := fmt.Sprintf("%s", cache.lists[exportJobProcessingKey]); got != "[acme:running]" {
+		t.Errorf("processing %v, want [acme:running]", got)
+	}
+}
+
+func TestOpenExportJobFile(t *testing.T) {
+	tests := []struct {
+		name        string
+		user        string
+		isProcessed bool
+		wantErr     error
+		wantFile    string
+	}{
+		{name: "completed", user: "ann", isProcessed: true, wantFile: "ann"},
+		{name: "not completed yet", user: "ann", wantErr: entity.ErrorExportJobNotReady},
+		{name: "job of another user", user: "bob", isProcessed: true, wantErr: entity.ErrorExportJobNotFound},
+		{name: "job of another user not completed yet", user: "bob", wantErr: entity.ErrorExportJobNotFound},
+		{name: "no user", isProcessed: true, wantErr: entity.ErrorExportJobNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uc, cache := newExportJobTest(t, &exportCatalog{permission: entity.ObjectPermission{CanExport: true}})
+
+			job, err := uc.SubmitExportJob(userContext("ann"), entity.ExportJobRequest{
+				Query:  entity.CatalogQuery{TenantCode: "acme", ObjectCode: "contact"},
+				Format: entity.ExportFormatCSV,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.isProcessed {
+				item, _ := cache.PopPush(exportJobQueueKey, exportJobProcessingKey, exportJobPopTimeout)
+				uc.processJob(context.Background(), string(item))
+			}
+
+			ctx := context.Background()
+			if tt.user != "" {
+				ctx = userContext(tt.user)
+			}
+
+			if _, err := uc.GetExportJob(ctx, job.TenantCode, job.ID); tt.user != job.CreatedBy && !errors.Is(err, entity.ErrorExportJobNotFound) {
+				t.Errorf("GetExportJob() error = %v, want %v", err, entity.ErrorExportJobNotFound)
+			}
+
+			_, file, err := uc.OpenExportJobFile(ctx, job.TenantCode, job.ID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("OpenExportJobFile() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+			defer file.Close()
+
+			// the export ran as the user who submitted it
+			data, err := io.ReadAll(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(data) != tt.wantFile {
+				t.Errorf("file %q, want %q", data, tt.wantFile)
+			}
+		})
+	}
+}
+
+func TestExportJobOfAnotherTenant(t *testing.T) {
+	uc, _ := newExportJobTest(t, &exportCatalog{permission: entity.ObjectPermission{CanExport: true}})
+
+	job, err := uc.SubmitExportJob(userContext("ann"), entity.ExportJobRequest{
+		Query:  entity.CatalogQuery{TenantCode: "acme", ObjectCode: "contact"},
+		Format: entity.ExportFormatCSV,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := uc.GetExportJob(userContext("ann"), "globex", job.ID); !errors.Is(err, entity.ErrorExportJobNotFound) {
+		t.Errorf("GetExportJob() error = %v, want %v", err, entity.ErrorExportJobNotFound)
+	}
+}
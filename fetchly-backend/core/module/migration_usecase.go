@@ -0,0 +1,597 @@
+package module
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fetchlydev/source/fetchly-backend/config"
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+	"github.com/fetchlydev/source/fetchly-backend/core/repository"
+)
+
+var errMigrationDryRun = errors.New("migration dry run is rolled back")
+
+// MigrationUsecase exports the configuration of a tenant as a versioned bundle and migrates a tenant to a bundle,
+// so a configuration is promoted from one environment to the next
+type MigrationUsecase interface {
+	ExportMetadataBundle(ctx context.Context, request entity.MetadataExportRequest) (resp entity.MetadataBundle, err error)
+	PlanMigration(ctx context.Context, request entity.MigrationRequest) (resp entity.MigrationPlan, err error)
+	ApplyMigration(ctx context.Context, request entity.MigrationRequest) (resp entity.MigrationPlan, err error)
+}
+
+type migrationUsecase struct {
+	cfg         config.Config
+	catalogRepo repository.CatalogRepository
+}
+
+func NewMigrationUsecase(cfg config.Config, catalogRepo repository.CatalogRepository) MigrationUsecase {
+	return &migrationUsecase{
+		cfg:         cfg,
+		catalogRepo: catalogRepo,
+	}
+}
+
+func (uc *migrationUsecase) ExportMetadataBundle(ctx context.Context, request entity.MetadataExportRequest) (resp entity.MetadataBundle, err error) {
+	resp = entity.MetadataBundle{
+		Version:    request.Version,
+		TenantCode: request.TenantCode,
+		ModuleCode: request.ModuleCode,
+		ExportedAt: time.Now(),
+		Objects:    []entity.ObjectSchema{},
+	}
+
+	// the view metadata of a module is the one of its objects
+	var viewObjectCodes []string
+	if request.ModuleCode != "" {
+		module, err := uc.catalogRepo.GetModuleByCode(ctx, request.ModuleCode)
+		if err != nil {
+			return resp, err
+		}
+
+		if resp.Version == "" {
+			resp.Version = module.Version
+		}
+
+		viewObjectCodes = []string{}
+	}
+
+	if resp.Version == "" {
+		resp.Version = resp.ExportedAt.UTC().Format("20060102150405")
+	}
+
+	objectCodes, err := uc.catalogRepo.GetObjectCodes(ctx, request.TenantCode, request.ModuleCode)
+	if err != nil {
+		return resp, err
+	}
+
+	for _, objectCode := range objectCodes {
+		object, err := uc.catalogRepo.GetObjectSchema(ctx, request.TenantCode, objectCode)
+		if err != nil {
+			return resp, err
+		}
+
+		resp.Objects = append(resp.Objects, bundleObject(object))
+
+		if viewObjectCodes != nil {
+			viewObjectCodes = append(viewObjectCodes, objectCode)
+		}
+	}
+
+	resp.ViewMetadata, err = uc.catalogRepo.GetViewMetadata(ctx, request.TenantCode, viewObjectCodes)
+	if err != nil {
+		return resp, err
+	}
+
+	return resp, nil
+}
+
+func (uc *migrationUsecase) PlanMigration(ctx context.Context, request entity.MigrationRequest) (resp entity.MigrationPlan, err error) {
+	live, err := uc.ExportMetadataBundle(ctx, entity.MetadataExportRequest{
+		TenantCode: request.TenantCode,
+		ModuleCode: request.Bundle.ModuleCode,
+	})
+	if err != nil {
+		return resp, err
+	}
+
+	return diffMetadataBundle(live, request)
+}
+
+func (uc *migrationUsecase) ApplyMigration(ctx context.Context, request entity.MigrationRequest) (resp entity.MigrationPlan, err error) {
+	request.UserSerial = userSerialFromContext(ctx)
+
+	resp, err = uc.PlanMigration(ctx, request)
+	if err != nil {
+		return resp, err
+	}
+
+	// the steps run in one transaction, a dry run rolls it back once the DDL of every step is known
+	err = uc.catalogRepo.WithMetadataTransaction(ctx, func(txRepo repository.CatalogRepository) error {
+		for _, step := range resp.Steps {
+			if err := applyMigrationStep(ctx, txRepo, request, step); err != nil {
+				return fmt.Errorf("failed to %v %v %v: %w", step.Action, step.Target, step.Code, err)
+			}
+		}
+
+		resp.Statements = txRepo.SchemaStatements()
+
+		if request.IsDryRun {
+			return errMigrationDryRun
+		}
+
+		return nil
+	})
+
+	if errors.Is(err, errMigrationDryRun) {
+		return resp, nil
+	}
+
+	if err != nil {
+		return resp, err
+	}
+
+	resp.IsApplied = true
+	return resp, nil
+}
+
+// applyMigrationStep applies a step of a plan through the repository of the migration transaction
+func applyMigrationStep(ctx context.Context, txRepo repository.CatalogRepository, request entity.MigrationRequest, step entity.MigrationStep) error {
+	switch step.Target {
+	case entity.MigrationTargetObject:
+		switch {
+		case step.Action == entity.MigrationActionCreate && step.Object != nil:
+			object := *step.Object
+			object.UserSerial = request.UserSerial
+			return txRepo.CreateObjectSchema(ctx, object)
+
+		case step.Action == entity.MigrationActionAlter && step.ObjectChange != nil:
+			change := *step.ObjectChange
+			change.UserSerial = request.UserSerial
+			return txRepo.AlterObjectSchema(ctx, change)
+
+		case step.Action == entity.MigrationActionDrop:
+			return txRepo.DropObjectSchema(ctx, entity.ObjectSchemaChange{
+				TenantCode: request.TenantCode,
+				ObjectCode: step.Code,
+				UserSerial: request.UserSerial,
+			})
+		}
+
+	case entity.MigrationTargetModule:
+		return txRepo.SetModuleVersion(ctx, step.Code, step.Version, request.UserSerial)
+
+	default:
+		return txRepo.ApplyViewMetadataStep(ctx, request.TenantCode, step, request.UserSerial)
+	}
+
+	return fmt.Errorf("%w: step %v of %v %v is not supported", entity.ErrorBadRequest, step.Action, step.Target, step.Code)
+}
+
+// diffMetadataBundle plans the migration of the live configuration of a tenant to a bundle. The steps drop the
+// relations and then the columns left out of the bundle, create the objects, change their columns and then their
+// relations, since a relation needs the columns it goes through. The view metadata follows the objects it shows,
+// what is dropped of it goes before the objects. Nothing is dropped unless pruning.
+func diffMetadataBundle(live entity.MetadataBundle, request entity.MigrationRequest) (plan entity.MigrationPlan, err error) {
+	bundle := request.Bundle
+
+	plan = entity.MigrationPlan{
+		TenantCode: request.TenantCode,
+		ModuleCode: bundle.ModuleCode,
+		Version:    bundle.Version,
+		Steps:      []entity.MigrationStep{},
+	}
+
+	if bundle.ModuleCode != "" {
+		plan.CurrentVersion = live.Version
+	}
+
+	liveObjects := make(map[string]entity.ObjectSchema, len(live.Objects))
+	for _, object := range live.Objects {
+		liveObjects[object.ObjectCode] = object
+	}
+
+	bundleObjects := make(map[string]bool, len(bundle.Objects))
+	for _, object := range bundle.Objects {
+		if err := entity.ValidateIdentifier("object", object.ObjectCode); err != nil {
+			return plan, err
+		}
+
+		if bundleObjects[object.ObjectCode] {
+			return plan, fmt.Errorf("%w: object %v is in the bundle more than once", entity.ErrorBadRequest, object.ObjectCode)
+		}
+
+		bundleObjects[object.ObjectCode] = true
+	}
+
+	var dropRelations, dropColumns, creates, columns, relations, dropObjects []entity.MigrationStep
+	for _, object := range bundle.Objects {
+		object.TenantCode = request.TenantCode
+		if object.DisplayName == "" {
+			object.DisplayName = object.ObjectCode
+		}
+
+		fields := []entity.FieldSchema{}
+		fieldCodes := map[string]bool{}
+		for _, field := range object.Fields {
+			if entity.IsStandardColumn(field.FieldCode) {
+				continue
+			}
+
+			if err := checkFieldSchema(field, fieldCodes); err != nil {
+				return plan, fmt.Errorf("object %v: %w", object.ObjectCode, err)
+			}
+
+			fields = append(fields, field)
+		}
+
+		columnChange := entity.ObjectSchemaChange{TenantCode: request.TenantCode, ObjectCode: object.ObjectCode}
+		relationChange := entity.ObjectSchemaChange{TenantCode: request.TenantCode, ObjectCode: object.ObjectCode}
+
+		current, ok := liveObjects[object.ObjectCode]
+		if !ok {
+			// a column referencing an object created by the migration is added once every object is created
+			created := object
+			created.Fields = []entity.FieldSchema{}
+			for _, field := range fields {
+				_, isTargetLive := liveObjects[field.TargetObjectCode]
+
+				switch {
+				case !field.IsColumn():
+					relationChange.AddFields = append(relationChange.AddFields, field)
+
+				case field.Relation != "" && !isTargetLive:
+					columnChange.AddFields = append(columnChange.AddFields, field)
+
+				default:
+					created.Fields = append(created.Fields, field)
+				}
+			}
+
+			// the display template may show a column added later on
+			if len(columnChange.AddFields) > 0 && created.DisplayTemplate != "" {
+				displayTemplate := created.DisplayTemplate
+				columnChange.DisplayTemplate = &displayTemplate
+				created.DisplayTemplate = ""
+			}
+
+			creates = append(creates, entity.MigrationStep{
+				Action: entity.MigrationActionCreate,
+				Target: entity.MigrationTargetObject,
+				Code:   object.ObjectCode,
+				Object: &created,
+			})
+		} else {
+			if current.ObjectType != object.ObjectType || current.ModuleCode != object.ModuleCode || current.DataSourceCode != object.DataSourceCode {
+				return plan, fmt.Errorf("%w: the object type, module and data source of object %v are not changed by a migration", entity.ErrorBadRequest, object.ObjectCode)
+			}
+
+			if current.DisplayName != object.DisplayName {
+				columnChange.DisplayName = &object.DisplayName
+			}
+
+			if current.DisplayTemplate != object.DisplayTemplate {
+				columnChange.DisplayTemplate = &object.DisplayTemplate
+			}
+
+			if current.Description != object.Description {
+				columnChange.Description = &object.Description
+			}
+
+			currentFields := make(map[string]entity.FieldSchema, len(current.Fields))
+			for _, field := range current.Fields {
+				currentFields[field.FieldCode] = field
+			}
+
+			for _, field := range fields {
+				from, ok := currentFields[field.FieldCode]
+
+				switch {
+				case !ok && field.IsColumn():
+					columnChange.AddFields = append(columnChange.AddFields, field)
+
+				case !ok:
+					relationChange.AddFields = append(relationChange.AddFields, field)
+
+				case isSameJSON(normalizedFieldSchema(from), normalizedFieldSchema(field)):
+
+				case field.IsColumn():
+					columnChange.AlterFields = append(columnChange.AlterFields, entity.FieldSchemaChange{Field: field, IsForced: request.IsForced})
+
+				default:
+					relationChange.AlterFields = append(relationChange.AlterFields, entity.FieldSchemaChange{Field: field, IsForced: request.IsForced})
+				}
+			}
+
+			if request.IsPruning {
+				dropRelation := entity.ObjectSchemaChange{TenantCode: request.TenantCode, ObjectCode: object.ObjectCode}
+				dropColumn := entity.ObjectSchemaChange{TenantCode: request.TenantCode, ObjectCode: object.ObjectCode}
+				for _, field := range current.Fields {
+					if fieldCodes[field.FieldCode] || entity.IsStandardColumn(field.FieldCode) {
+						continue
+					}
+
+					if field.IsColumn() {
+						dropColumn.DropFields = append(dropColumn.DropFields, field.FieldCode)
+					} else {
+						dropRelation.DropFields = append(dropRelation.DropFields, field.FieldCode)
+					}
+				}
+
+				dropRelations = appendObjectChange(dropRelations, dropRelation)
+				dropColumns = appendObjectChange(dropColumns, dropColumn)
+			}
+		}
+
+		columns = appendObjectChange(columns, columnChange)
+		relations = appendObjectChange(relations, relationChange)
+	}
+
+	if request.IsPruning {
+		for _, object := range live.Objects {
+			if !bundleObjects[object.ObjectCode] {
+				dropObjects = append(dropObjects, entity.MigrationStep{
+					Action: entity.MigrationActionDrop,
+					Target: entity.MigrationTargetObject,
+					Code:   object.ObjectCode,
+				})
+			}
+		}
+	}
+
+	// the DDL of a data source can not join the transaction of the metadata, a failed commit would leave its tables
+	// changed, so the objects of a data source are left to the schema endpoints
+	dataSourceCodes := make(map[string]string, len(live.Objects)+len(bundle.Objects))
+	for _, object := range append(append([]entity.ObjectSchema{}, live.Objects...), bundle.Objects...) {
+		if object.DataSourceCode != "" {
+			dataSourceCodes[object.ObjectCode] = object.DataSourceCode
+		}
+	}
+
+	for _, steps := range [][]entity.MigrationStep{dropRelations, dropColumns, creates, columns, relations, dropObjects} {
+		for _, step := range steps {
+			if dataSourceCode, ok := dataSourceCodes[step.Code]; ok {
+				return plan, fmt.Errorf("%w: object %v is in data source %v, a migration only changes the objects of the tenant schema", entity.ErrorBadRequest, step.Code, dataSourceCode)
+			}
+		}
+	}
+
+	viewSteps, viewDrops := diffViewMetadata(live.ViewMetadata, bundle.ViewMetadata, request.IsPruning)
+
+	for _, steps := range [][]entity.MigrationStep{dropRelations, dropColumns, creates, columns, relations, viewSteps, viewDrops, dropObjects} {
+		plan.Steps = append(plan.Steps, steps...)
+	}
+
+	if bundle.ModuleCode != "" && bundle.Version != "" && bundle.Version != plan.CurrentVersion {
+		plan.Steps = append(plan.Steps, entity.MigrationStep{
+			Action:  entity.MigrationActionAlter,
+			Target:  entity.MigrationTargetModule,
+			Code:    bundle.ModuleCode,
+			Version: bundle.Version,
+		})
+	}
+
+	return plan, nil
+}
+
+// diffViewMetadata plans the view layouts, view schemas and view contents to create or alter, and apart from them
+// the view contents and view schemas to drop. View layouts are shared by the tenants and never dropped. The
+// navigation of a view content keeps the items left out of the bundle unless pruning.
+func diffViewMetadata(live, bundle entity.ViewMetadata, isPruning bool) (steps, drops []entity.MigrationStep) {
+	liveLayouts := make(map[string]entity.BundleViewLayout, len(live.ViewLayouts))
+	for _, layout := range live.ViewLayouts {
+		liveLayouts[layout.Code] = layout
+	}
+
+	for _, layout := range bundle.ViewLayouts {
+		current, ok := liveLayouts[layout.Code]
+		if ok && isSameJSON(current, layout) {
+			continue
+		}
+
+		steps = append(steps, entity.MigrationStep{
+			Action:     migrationAction(ok),
+			Target:     entity.MigrationTargetViewLayout,
+			Code:       layout.Code,
+			ViewLayout: &layout,
+		})
+	}
+
+	liveSchemas := make(map[string]entity.BundleViewSchema, len(live.ViewSchemas))
+	for _, schema := range live.ViewSchemas {
+		liveSchemas[viewSchemaKey(schema)] = schema
+	}
+
+	bundleSchemas := make(map[string]bool, len(bundle.ViewSchemas))
+	for _, schema := range bundle.ViewSchemas {
+		bundleSchemas[viewSchemaKey(schema)] = true
+
+		current, ok := liveSchemas[viewSchemaKey(schema)]
+		if ok && isSameJSON(current, schema) {
+			continue
+		}
+
+		steps = append(steps, entity.MigrationStep{
+			Action:     migrationAction(ok),
+			Target:     entity.MigrationTargetViewSchema,
+			Code:       viewSchemaKey(schema),
+			ViewSchema: &schema,
+		})
+	}
+
+	liveContents := make(map[string]entity.BundleViewContent, len(live.ViewContents))
+	for _, content := range live.ViewContents {
+		liveContents[viewContentKey(content)] = content
+	}
+
+	bundleContents := make(map[string]bool, len(bundle.ViewContents))
+	for _, content := range bundle.ViewContents {
+		bundleContents[viewContentKey(content)] = true
+
+		current, ok := liveContents[viewContentKey(content)]
+		if ok && !isPruning {
+			navigationCodes := make(map[string]bool, len(content.Navigation))
+			for _, item := range content.Navigation {
+				navigationCodes[item.Code] = true
+			}
+
+			navigation := append([]entity.BundleNavigation{}, content.Navigation...)
+			for _, item := range current.Navigation {
+				if !navigationCodes[item.Code] {
+					navigation = append(navigation, item)
+				}
+			}
+
+			content.Navigation = navigation
+		}
+
+		if ok && isSameViewContent(current, content) {
+			continue
+		}
+
+		steps = append(steps, entity.MigrationStep{
+			Action:      migrationAction(ok),
+			Target:      entity.MigrationTargetViewContent,
+			Code:        viewContentKey(content),
+			ViewContent: &content,
+		})
+	}
+
+	if !isPruning {
+		return steps, drops
+	}
+
+	for _, content := range live.ViewContents {
+		if !bundleContents[viewContentKey(content)] {
+			drops = append(drops, entity.MigrationStep{
+				Action:      entity.MigrationActionDrop,
+				Target:      entity.MigrationTargetViewContent,
+				Code:        viewContentKey(content),
+				ViewContent: &content,
+			})
+		}
+	}
+
+	for _, schema := range live.ViewSchemas {
+		if !bundleSchemas[viewSchemaKey(schema)] {
+			drops = append(drops, entity.MigrationStep{
+				Action:     entity.MigrationActionDrop,
+				Target:     entity.MigrationTargetViewSchema,
+				Code:       viewSchemaKey(schema),
+				ViewSchema: &schema,
+			})
+		}
+	}
+
+	return steps, drops
+}
+
+// bundleObject is an object as it is exported, the standard columns are left to the table and the data types go
+// by code since their serials differ between environments
+func bundleObject(object entity.ObjectSchema) entity.ObjectSchema {
+	object.TenantCode = ""
+
+	fields := make([]entity.FieldSchema, 0, len(object.Fields))
+	for _, field := range object.Fields {
+		if entity.IsStandardColumn(field.FieldCode) {
+			continue
+		}
+
+		field.DataType.ID = 0
+		field.DataType.Serial = ""
+		fields = append(fields, field)
+	}
+
+	object.Fields = fields
+	return object
+}
+
+// normalizedFieldSchema is a field with the defaults of the schema management API filled in, for a comparison
+func normalizedFieldSchema(field entity.FieldSchema) entity.FieldSchema {
+	if field.DisplayName == "" {
+		field.DisplayName = field.FieldCode
+	}
+
+	if field.Relation == entity.RelationManyToOne && field.TargetFieldCode == "" {
+		field.TargetFieldCode = entity.DEFAULT_IDENTIFIER
+	}
+
+	if len(field.ValidationRules) == 0 {
+		field.ValidationRules = nil
+	}
+
+	field.DataType = entity.DataType{Code: field.DataType.Code}
+	return field
+}
+
+// isSameViewContent compares two view contents, their navigation by code
+func isSameViewContent(a, b entity.BundleViewContent) bool {
+	if len(a.Navigation) != len(b.Navigation) {
+		return false
+	}
+
+	navigation := make(map[string]entity.BundleNavigation, len(a.Navigation))
+	for _, item := range a.Navigation {
+		navigation[item.Code] = item
+	}
+
+	for _, item := range b.Navigation {
+		current, ok := navigation[item.Code]
+		if !ok || !isSameJSON(current, item) {
+			return false
+		}
+	}
+
+	a.Navigation, b.Navigation = nil, nil
+	return isSameJSON(a, b)
+}
+
+// isSameJSON compares two values by their JSON encoding, which leaves the representation of the numbers and the
+// order of the keys of the maps out
+func isSameJSON(a, b any) bool {
+	left, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+
+	right, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+
+	return bytes.Equal(left, right)
+}
+
+func appendObjectChange(steps []entity.MigrationStep, change entity.ObjectSchemaChange) []entity.MigrationStep {
+	if change.DisplayName == nil && change.DisplayTemplate == nil && change.Description == nil &&
+		len(change.AddFields) == 0 && len(change.AlterFields) == 0 && len(change.DropFields) == 0 {
+		return steps
+	}
+
+	return append(steps, entity.MigrationStep{
+		Action:       entity.MigrationActionAlter,
+		Target:       entity.MigrationTargetObject,
+		Code:         change.ObjectCode,
+		ObjectChange: &change,
+	})
+}
+
+func migrationAction(exists bool) entity.MigrationAction {
+	if exists {
+		return entity.MigrationActionAlter
+	}
+
+	return entity.MigrationActionCreate
+}
+
+func viewSchemaKey(schema entity.BundleViewSchema) string {
+	return schema.ObjectCode + "." + schema.Code
+}
+
+func viewContentKey(content entity.BundleViewContent) string {
+	return strings.Join([]string{content.ProductCode, content.ObjectCode, content.Code, content.LayoutType}, "/")
+}
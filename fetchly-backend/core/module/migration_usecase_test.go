@@ -0,0 +1,271 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+	"github.com/fetchlydev/source/fetchly-backend/core/repository"
+)
+
+func TestDiffMetadataBundle(t *testing.T) {
+	stringType := entity.DataType{Code: entity.PrimitiveDataTypeString, PrimitiveDataType: entity.PrimitiveDataTypeString}
+	name := entity.FieldSchema{FieldCode: "name", DataType: stringType}
+	email := entity.FieldSchema{FieldCode: "email", DataType: stringType}
+	customerID := entity.FieldSchema{FieldCode: "customer_id", DataType: stringType, Relation: entity.RelationManyToOne, TargetObjectCode: "customer"}
+
+	live := entity.MetadataBundle{Objects: []entity.ObjectSchema{
+		{ObjectCode: "contact", DisplayName: "contact", Fields: []entity.FieldSchema{name}},
+		{ObjectCode: "ledger", DisplayName: "ledger", DataSourceCode: "warehouse", Fields: []entity.FieldSchema{name}},
+	}}
+
+	tests := []struct {
+		name      string
+		objects   []entity.ObjectSchema
+		isPruning bool
+		want      []string
+		wantErr   error
+	}{
+		{
+			name: "unchanged",
+			objects: []entity.ObjectSchema{
+				{ObjectCode: "contact", Fields: []entity.FieldSchema{name}},
+				{ObjectCode: "ledger", DataSourceCode: "warehouse", Fields: []entity.FieldSchema{name}},
+			},
+			want: []string{},
+		},
+		{
+			name:    "field added",
+			objects: []entity.ObjectSchema{{ObjectCode: "contact", Fields: []entity.FieldSchema{name, email}}},
+			want:    []string{"alter object contact"},
+		},
+		{
+			name: "objects created before the columns referencing them",
+			objects: []entity.ObjectSchema{
+				{ObjectCode: "invoice", Fields: []entity.FieldSchema{name, customerID}},
+				{ObjectCode: "customer", Fields: []entity.FieldSchema{name}},
+			},
+			want: []string{"create object invoice", "create object customer", "alter object invoice"},
+		},
+		{
+			name:    "left out objects kept",
+			objects: []entity.ObjectSchema{},
+			want:    []string{},
+		},
+		{
+			name:      "left out objects pruned",
+			objects:   []entity.ObjectSchema{{ObjectCode: "ledger", DataSourceCode: "warehouse", Fields: []entity.FieldSchema{name}}},
+			isPruning: true,
+			want:      []string{"drop object contact"},
+		},
+		{
+			name:      "data source object pruned",
+			objects:   []entity.ObjectSchema{{ObjectCode: "contact", Fields: []entity.FieldSchema{name}}},
+			isPruning: true,
+			wantErr:   entity.ErrorBadRequest,
+		},
+		{
+			name:    "data source object changed",
+			objects: []entity.ObjectSchema{{ObjectCode: "ledger", DataSourceCode: "warehouse", Fields: []entity.FieldSchema{name, email}}},
+			wantErr: entity.ErrorBadRequest,
+		},
+		{
+			name:    "data source object created",
+			objects: []entity.ObjectSchema{{ObjectCode: "archive", DataSourceCode: "warehouse", Fields: []entity.FieldSchema{name}}},
+			wantErr: entity.ErrorBadRequest,
+		},
+		{
+			name:    "data source moved",
+			objects: []entity.ObjectSchema{{ObjectCode: "contact", DataSourceCode: "warehouse", Fields: []entity.FieldSchema{name}}},
+			wantErr: entity.ErrorBadRequest,
+		},
+		{
+			name:    "object twice",
+			objects: []entity.ObjectSchema{{ObjectCode: "contact"}, {ObjectCode: "contact"}},
+			wantErr: entity.ErrorBadRequest,
+		},
+		{
+			name:    "invalid object code",
+			objects: []entity.ObjectSchema{{ObjectCode: "contact__name"}},
+			wantErr: entity.ErrorBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := diffMetadataBundle(live, entity.MigrationRequest{
+				TenantCode: "acme",
+				Bundle:     entity.MetadataBundle{Objects: tt.objects},
+				IsPruning:  tt.isPruning,
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("diffMetadataBundle() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			got := []string{}
+			for _, step := range plan.Steps {
+				got = append(got, fmt.Sprintf("%v %v %v", step.Action, step.Target, step.Code))
+			}
+
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("diffMetadataBundle() steps = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// migrationRepo is a catalog repository of a tenant with object contact, it records the schema changes of the
+// migration transaction and keeps them once it commits
+type migrationRepo struct {
+	repository.CatalogRepository
+	failOn     string
+	statements []entity.SchemaStatement
+	committed  []entity.SchemaStatement
+}
+
+func (r *migrationRepo) GetObjectCodes(ctx context.Context, tenantCode, moduleCode string) (resp []string, err error) {
+	return []string{"contact"}, nil
+}
+
+func (r *migrationRepo) GetObjectSchema(ctx context.Context, tenantCode, objectCode string) (resp entity.ObjectSchema, err error) {
+	return entity.ObjectSchema{ObjectCode: objectCode, DisplayName: objectCode, Fields: []entity.FieldSchema{{FieldCode: "name"}}}, nil
+}
+
+func (r *migrationRepo) GetViewMetadata(ctx context.Context, tenantCode string, objectCodes []string) (resp entity.ViewMetadata, err error) {
+	return resp, nil
+}
+
+func (r *migrationRepo) WithMetadataTransaction(ctx context.Context, fn func(txRepo repository.CatalogRepository) error) error {
+	r.statements = nil
+	if err := fn(r); err != nil {
+		return err
+	}
+
+	r.committed = r.statements
+	return nil
+}
+
+func (r *migrationRepo) SchemaStatements() []entity.SchemaStatement {
+	return r.statements
+}
+
+func (r *migrationRepo) record(objectCode, sql string) error {
+	if objectCode == r.failOn {
+		return errors.New("table exists")
+	}
+
+	r.statements = append(r.statements, entity.SchemaStatement{ObjectCode: objectCode, SQL: sql})
+	return nil
+}
+
+func (r *migrationRepo) CreateObjectSchema(ctx context.Context, request entity.ObjectSchema) error {
+	return r.record(request.ObjectCode, "CREATE TABLE")
+}
+
+func (r *migrationRepo) AlterObjectSchema(ctx context.Context, request entity.ObjectSchemaChange) error {
+	return r.record(request.ObjectCode, "ALTER TABLE")
+}
+
+func (r *migrationRepo) DropObjectSchema(ctx context.Context, request entity.ObjectSchemaChange) error {
+	return r.record(request.ObjectCode, "DROP TABLE")
+}
+
+func TestApplyMigration(t *testing.T) {
+	name := entity.FieldSchema{FieldCode: "name"}
+	email := entity.FieldSchema{FieldCode: "email", DataType: entity.DataType{Code: "string", PrimitiveDataType: entity.PrimitiveDataTypeString}}
+	objects := []entity.ObjectSchema{
+		{ObjectCode: "contact", Fields: []entity.FieldSchema{name, email}},
+		{ObjectCode: "deal", Fields: []entity.FieldSchema{name}},
+	}
+
+	tests := []struct {
+		name          string
+		request       entity.MigrationRequest
+		failOn        string
+		wantErr       bool
+		wantApplied   bool
+		wantPlanned   []string
+		wantCommitted []string
+	}{
+		{
+			name:          "applied",
+			request:       entity.MigrationRequest{Bundle: entity.MetadataBundle{Objects: objects}},
+			wantApplied:   true,
+			wantPlanned:   []string{"deal CREATE TABLE", "contact ALTER TABLE"},
+			wantCommitted: []string{"deal CREATE TABLE", "contact ALTER TABLE"},
+		},
+		{
+			name:        "dry run reports the statements and rolls back",
+			request:     entity.MigrationRequest{Bundle: entity.MetadataBundle{Objects: objects}, IsDryRun: true},
+			wantPlanned: []string{"deal CREATE TABLE", "contact ALTER TABLE"},
+		},
+		{
+			name:    "failed step rolls back",
+			request: entity.MigrationRequest{Bundle: entity.MetadataBundle{Objects: objects}},
+			failOn:  "deal",
+			wantErr: true,
+		},
+		{
+			name:          "pruned",
+			request:       entity.MigrationRequest{Bundle: entity.MetadataBundle{Objects: objects[1:]}, IsPruning: true},
+			wantApplied:   true,
+			wantPlanned:   []string{"deal CREATE TABLE", "contact DROP TABLE"},
+			wantCommitted: []string{"deal CREATE TABLE", "contact DROP TABLE"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &migrationRepo{failOn: tt.failOn}
+			uc := &migrationUsecase{catalogRepo: repo}
+
+			tt.request.TenantCode = "acme"
+			plan, err := uc.ApplyMigration(context.Background(), tt.request)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ApplyMigration() error = %v, want error %v", err, tt.wantErr)
+			}
+
+			if plan.IsApplied != tt.wantApplied {
+				t.Errorf("applied %v, want %v", plan.IsApplied, tt.wantApplied)
+			}
+
+			assertSchemaStatements(t, "planned", plan.Statements, tt.wantPlanned)
+			assertSchemaStatements(t, "committed", repo.committed, tt.wantCommitted)
+		})
+	}
+}
+
+func assertSchemaStatements(t *testing.T, name string, statements []entity.SchemaStatement, want []string) {
+	t.Helper()
+
+	got := []string{}
+	for _, statement := range statements {
+		got = append(got, statement.ObjectCode+" "+statement.SQL)
+	}
+
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("%v statements = %v, want %v", name, got, want)
+	}
+}
+
+func TestApplyMigrationStepNotSupported(t *testing.T) {
+	tests := []entity.MigrationStep{
+		{Action: entity.MigrationActionCreate, Target: entity.MigrationTargetObject, Code: "deal"},
+		{Action: entity.MigrationActionAlter, Target: entity.MigrationTargetObject, Code: "deal"},
+	}
+
+	for _, step := range tests {
+		t.Run(string(step.Action), func(t *testing.T) {
+			err := applyMigrationStep(context.Background(), &migrationRepo{}, entity.MigrationRequest{TenantCode: "acme"}, step)
+			if !errors.Is(err, entity.ErrorBadRequest) {
+				t.Errorf("applyMigrationStep() error = %v, want %v", err, entity.ErrorBadRequest)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package module
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fetchlydev/source/fetchly-backend/config"
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+	"github.com/fetchlydev/source/fetchly-backend/core/repository"
+)
+
+// SchemaUsecase creates, alters and drops the tables of the objects of a tenant along with their metadata
+type SchemaUsecase interface {
+	GetObjectSchema(ctx context.Context, tenantCode, objectCode string) (resp entity.ObjectSchema, err error)
+	CreateObjectSchema(ctx context.Context, request entity.ObjectSchema) (resp entity.ObjectSchema, err error)
+	AlterObjectSchema(ctx context.Context, request entity.ObjectSchemaChange) (resp entity.ObjectSchema, err error)
+	DropObjectSchema(ctx context.Context, request entity.ObjectSchemaChange) (err error)
+}
+
+type schemaUsecase struct {
+	cfg         config.Config
+	catalogRepo repository.CatalogRepository
+}
+
+func NewSchemaUsecase(cfg config.Config, catalogRepo repository.CatalogRepository) SchemaUsecase {
+	return &schemaUsecase{
+		cfg:         cfg,
+		catalogRepo: catalogRepo,
+	}
+}
+
+func (uc *schemaUsecase) GetObjectSchema(ctx context.Context, tenantCode, objectCode string) (resp entity.ObjectSchema, err error) {
+	return uc.catalogRepo.GetObjectSchema(ctx, tenantCode, objectCode)
+}
+
+func (uc *schemaUsecase) CreateObjectSchema(ctx context.Context, request entity.ObjectSchema) (resp entity.ObjectSchema, err error) {
+	request.UserSerial = userSerialFromContext(ctx)
+
+	if err := entity.ValidateIdentifier("object", request.ObjectCode); err != nil {
+		return resp, err
+	}
+
+	if request.DisplayName == "" {
+		request.DisplayName = request.ObjectCode
+	}
+
+	fieldCodes := make(map[string]bool, len(request.Fields))
+	for _, field := range request.Fields {
+		if err := checkFieldSchema(field, fieldCodes); err != nil {
+			return resp, err
+		}
+	}
+
+	if err := uc.catalogRepo.CreateObjectSchema(ctx, request); err != nil {
+		return resp, err
+	}
+
+	return uc.catalogRepo.GetObjectSchema(ctx, request.TenantCode, request.ObjectCode)
+}
+
+func (uc *schemaUsecase) AlterObjectSchema(ctx context.Context, request entity.ObjectSchemaChange) (resp entity.ObjectSchema, err error) {
+	request.UserSerial = userSerialFromContext(ctx)
+
+	// a field is changed once per request, under its code before and after the change
+	fieldCodes := make(map[string]bool)
+	for _, fieldCode := range request.DropFields {
+		if entity.IsStandardColumn(fieldCode) {
+			return resp, fmt.Errorf("%w: standard column %v can not be dropped", entity.ErrorBadRequest, fieldCode)
+		}
+
+		if fieldCodes[fieldCode] {
+			return resp, fmt.Errorf("%w: field %v is changed more than once", entity.ErrorBadRequest, fieldCode)
+		}
+
+		fieldCodes[fieldCode] = true
+	}
+
+	for _, change := range request.AlterFields {
+		if change.RenameFrom != "" && change.RenameFrom != change.Field.FieldCode {
+			if entity.IsStandardColumn(change.RenameFrom) {
+				return resp, fmt.Errorf("%w: standard column %v can not be renamed", entity.ErrorBadRequest, change.RenameFrom)
+			}
+
+			if fieldCodes[change.RenameFrom] {
+				return resp, fmt.Errorf("%w: field %v is changed more than once", entity.ErrorBadRequest, change.RenameFrom)
+			}
+
+			fieldCodes[change.RenameFrom] = true
+		}
+
+		if err := checkFieldSchema(change.Field, fieldCodes); err != nil {
+			return resp, err
+		}
+	}
+
+	for _, field := range request.AddFields {
+		if err := checkFieldSchema(field, fieldCodes); err != nil {
+			return resp, err
+		}
+	}
+
+	if err := uc.catalogRepo.AlterObjectSchema(ctx, request); err != nil {
+		return resp, err
+	}
+
+	return uc.catalogRepo.GetObjectSchema(ctx, request.TenantCode, request.ObjectCode)
+}
+
+func (uc *schemaUsecase) DropObjectSchema(ctx context.Context, request entity.ObjectSchemaChange) (err error) {
+	request.UserSerial = userSerialFromContext(ctx)
+
+	return uc.catalogRepo.DropObjectSchema(ctx, request)
+}
+
+// checkFieldSchema checks the code and the relation of a field, fieldCodes are the codes already taken by the request
+func checkFieldSchema(field entity.FieldSchema, fieldCodes map[string]bool) error {
+	if err := entity.ValidateIdentifier("field", field.FieldCode); err != nil {
+		return err
+	}
+
+	if entity.IsStandardColumn(field.FieldCode) {
+		return fmt.Errorf("%w: field %v is a standard column", entity.ErrorBadRequest, field.FieldCode)
+	}
+
+	if fieldCodes[field.FieldCode] {
+		return fmt.Errorf("%w: field %v is changed more than once", entity.ErrorBadRequest, field.FieldCode)
+	}
+
+	fieldCodes[field.FieldCode] = true
+
+	switch field.Relation {
+	case "":
+		return nil
+
+	case entity.RelationManyToOne:
+		if field.TargetObjectCode == "" {
+			return fmt.Errorf("%w: relation %v needs a target object", entity.ErrorBadRequest, field.FieldCode)
+		}
+
+	case entity.RelationOneToMany, entity.RelationManyToMany:
+		if field.TargetObjectCode == "" || field.TargetFieldCode == "" {
+			return fmt.Errorf("%w: relation %v needs a target object and field", entity.ErrorBadRequest, field.FieldCode)
+		}
+
+		if field.Relation == entity.RelationManyToMany && field.FieldReference == "" {
+			return fmt.Errorf("%w: relation %v needs the field reference of its junction object", entity.ErrorBadRequest, field.FieldCode)
+		}
+
+	default:
+		return fmt.Errorf("%w: relation %v of field %v is not supported", entity.ErrorBadRequest, field.Relation, field.FieldCode)
+	}
+
+	return nil
+}
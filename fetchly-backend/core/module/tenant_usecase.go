@@ -0,0 +1,498 @@
+package module
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fetchlydev/source/fetchly-backend/config"
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+	"github.com/fetchlydev/source/fetchly-backend/core/repository"
+	"github.com/fetchlydev/source/fetchly-backend/pkg/storage"
+)
+
+// userObjectCode is the table of every tenant schema holding its users
+const userObjectCode = "user"
+
+var errProvisionDryRun = errors.New("tenant provisioning dry run is rolled back")
+
+// TenantUsecase onboards a tenant, seeded from a template tenant or a bundle or cloned from another tenant, and
+// offboards a tenant once its data is archived
+type TenantUsecase interface {
+	ProvisionTenant(ctx context.Context, request entity.TenantProvisionRequest) (resp entity.TenantProvision, err error)
+	CloneTenant(ctx context.Context, request entity.TenantCloneRequest) (resp entity.TenantProvision, err error)
+	OffboardTenant(ctx context.Context, request entity.TenantOffboardRequest) (resp entity.TenantArchive, err error)
+}
+
+type tenantUsecase struct {
+	cfg         config.Config
+	catalogRepo repository.CatalogRepository
+	authRepo    repository.AuthRepository
+	migrationUc MigrationUsecase
+	archive     storage.Storage
+}
+
+func NewTenantUsecase(cfg config.Config, catalogRepo repository.CatalogRepository, authRepo repository.AuthRepository, migrationUc MigrationUsecase, archive storage.Storage) TenantUsecase {
+	return &tenantUsecase{
+		cfg:         cfg,
+		catalogRepo: catalogRepo,
+		authRepo:    authRepo,
+		migrationUc: migrationUc,
+		archive:     archive,
+	}
+}
+
+// ProvisionTenant creates the tenant and its schema and applies the bundle seeding it in one transaction, a dry
+// run rolls it back once the DDL is known. The rows of the template are copied after the objects are created.
+func (uc *tenantUsecase) ProvisionTenant(ctx context.Context, request entity.TenantProvisionRequest) (resp entity.TenantProvision, err error) {
+	request.UserSerial = userSerialFromContext(ctx)
+
+	if err := entity.ValidateTenantCode(request.TenantCode); err != nil {
+		return resp, err
+	}
+
+	if request.TemplateTenantCode != "" && request.Bundle != nil {
+		return resp, fmt.Errorf("%w: a tenant is seeded from a template tenant or from a bundle, not both", entity.ErrorBadRequest)
+	}
+
+	if request.TemplateTenantCode == "" && (request.ModuleCode != "" || request.IsDataIncluded) {
+		return resp, fmt.Errorf("%w: a module or the data of a template needs a template tenant", entity.ErrorBadRequest)
+	}
+
+	bundle := entity.MetadataBundle{}
+	if request.Bundle != nil {
+		bundle = *request.Bundle
+	}
+
+	if request.TemplateTenantCode != "" {
+		if _, err := uc.catalogRepo.GetTenantByCode(ctx, request.TemplateTenantCode); err != nil {
+			return resp, err
+		}
+
+		bundle, err = uc.migrationUc.ExportMetadataBundle(ctx, entity.MetadataExportRequest{
+			TenantCode: request.TemplateTenantCode,
+			ModuleCode: request.ModuleCode,
+		})
+		if err != nil {
+			return resp, err
+		}
+	}
+
+	// the tables of a data source are shared by the tenants, a tenant only gets the tables of its schema
+	hasUserObject := false
+	for _, object := range bundle.Objects {
+		if object.DataSourceCode != "" {
+			return resp, fmt.Errorf("%w: object %v is in data source %v, only the objects of the tenant schema are provisioned", entity.ErrorBadRequest, object.ObjectCode, object.DataSourceCode)
+		}
+
+		hasUserObject = hasUserObject || object.ObjectCode == userObjectCode
+	}
+
+	copiedObjects := bundle.Objects
+	if !hasUserObject {
+		bundle.Objects = append(append([]entity.ObjectSchema{}, bundle.Objects...), defaultUserObject())
+	}
+
+	if request.Name == "" {
+		request.Name = request.TenantCode
+	}
+
+	resp = entity.TenantProvision{
+		TenantCode:         request.TenantCode,
+		TemplateTenantCode: request.TemplateTenantCode,
+		CopiedRows:         map[string]int64{},
+	}
+
+	// nothing of a new tenant is live, the version of the bundle is taken as the live one so the version of a module
+	// shared by the tenants is left as it is
+	migration := entity.MigrationRequest{
+		TenantCode: request.TenantCode,
+		Bundle:     bundle,
+		UserSerial: request.UserSerial,
+	}
+
+	resp.Plan, err = diffMetadataBundle(entity.MetadataBundle{Version: bundle.Version}, migration)
+	if err != nil {
+		return resp, err
+	}
+
+	err = uc.catalogRepo.WithMetadataTransaction(ctx, func(txRepo repository.CatalogRepository) error {
+		tenant := entity.Tenants{
+			Code:         request.TenantCode,
+			Name:         request.Name,
+			TenantConfig: request.TenantConfig,
+		}
+
+		if err := txRepo.CreateTenant(ctx, tenant, request.UserSerial); err != nil {
+			return err
+		}
+
+		for _, step := range resp.Plan.Steps {
+			if err := applyMigrationStep(ctx, txRepo, migration, step); err != nil {
+				return fmt.Errorf("failed to %v %v %v: %w", step.Action, step.Target, step.Code, err)
+			}
+		}
+
+		if request.IsDataIncluded {
+			for _, objectCode := range copyOrder(copiedObjects) {
+				copied, err := txRepo.CopyObjectData(ctx, request.TemplateTenantCode, request.TenantCode, objectCode)
+				if err != nil {
+					return err
+				}
+
+				resp.CopiedRows[objectCode] = copied
+			}
+		}
+
+		resp.Plan.Statements = txRepo.SchemaStatements()
+
+		if request.IsDryRun {
+			return errProvisionDryRun
+		}
+
+		return nil
+	})
+
+	if errors.Is(err, errProvisionDryRun) {
+		return resp, nil
+	}
+
+	if err != nil {
+		return resp, err
+	}
+
+	resp.IsApplied = true
+	resp.Plan.IsApplied = true
+	return resp, nil
+}
+
+// CloneTenant provisions a tenant with the source tenant as its template
+func (uc *tenantUsecase) CloneTenant(ctx context.Context, request entity.TenantCloneRequest) (resp entity.TenantProvision, err error) {
+	source, err := uc.catalogRepo.GetTenantByCode(ctx, request.SourceTenantCode)
+	if err != nil {
+		return resp, err
+	}
+
+	if request.Name == "" {
+		request.Name = source.Name
+	}
+
+	if request.TenantConfig == nil {
+		request.TenantConfig = source.TenantConfig
+	}
+
+	return uc.ProvisionTenant(ctx, entity.TenantProvisionRequest{
+		TenantCode:         request.TenantCode,
+		Name:               request.Name,
+		TenantConfig:       request.TenantConfig,
+		TemplateTenantCode: source.Code,
+		IsDataIncluded:     request.IsDataIncluded,
+		IsDryRun:           request.IsDryRun,
+	})
+}
+
+// OffboardTenant archives a tenant, so that nothing is written to it and none of its users sign in, and then writes
+// its bundle and rows into an archive. The schema is dropped once the archive is read back complete, a tenant whose
+// archive fails is restored. A tenant with objects in a data source is refused, the rows of a data source are not dropped with
+// the schema of the tenant and would be left behind.
+func (uc *tenantUsecase) OffboardTenant(ctx context.Context, request entity.TenantOffboardRequest) (resp entity.TenantArchive, err error) {
+	request.UserSerial = userSerialFromContext(ctx)
+
+	if err := entity.ValidateTenantCode(request.TenantCode); err != nil {
+		return resp, err
+	}
+
+	if _, err := uc.catalogRepo.GetTenantByCode(ctx, request.TenantCode); err != nil {
+		return resp, err
+	}
+
+	bundle, err := uc.migrationUc.ExportMetadataBundle(ctx, entity.MetadataExportRequest{TenantCode: request.TenantCode})
+	if err != nil {
+		return resp, err
+	}
+
+	dataSourceObjects := []string{}
+	for _, object := range bundle.Objects {
+		if object.DataSourceCode != "" {
+			dataSourceObjects = append(dataSourceObjects, object.ObjectCode)
+		}
+	}
+
+	if len(dataSourceObjects) > 0 {
+		return resp, fmt.Errorf("%w: objects %v of tenant %v are in a data source, their rows have to be moved or deleted before the tenant is offboarded", entity.ErrorBadRequest, strings.Join(dataSourceObjects, ", "), request.TenantCode)
+	}
+
+	// the tenant is archived before its rows are read, nothing is written to it while the archive is made
+	if err := uc.catalogRepo.ArchiveTenant(ctx, request.TenantCode, request.UserSerial); err != nil {
+		return resp, err
+	}
+
+	if err := uc.authRepo.RevokeTenantSessions(ctx, request.TenantCode, entity.SessionRevokedReasonTenantArchived); err != nil {
+		return resp, uc.restoreTenant(ctx, request.TenantCode, fmt.Errorf("failed to revoke the sessions of tenant %v: %w", request.TenantCode, err))
+	}
+
+	resp = entity.TenantArchive{
+		TenantCode:   request.TenantCode,
+		Key:          fmt.Sprintf("%v/%v.zip", request.TenantCode, bundle.ExportedAt.UTC().Format("20060102150405")),
+		ExportedRows: map[string]int64{},
+	}
+
+	if err := uc.writeArchive(ctx, resp.Key, bundle, resp.ExportedRows); err != nil {
+		_ = uc.archive.Delete(resp.Key)
+		return resp, uc.restoreTenant(ctx, request.TenantCode, fmt.Errorf("failed to archive tenant %v: %w", request.TenantCode, err))
+	}
+
+	if err := uc.verifyArchive(resp.Key, bundle, resp.ExportedRows); err != nil {
+		_ = uc.archive.Delete(resp.Key)
+		return resp, uc.restoreTenant(ctx, request.TenantCode, fmt.Errorf("failed to verify the archive of tenant %v: %w", request.TenantCode, err))
+	}
+
+	resp.Size, err = uc.archive.Size(resp.Key)
+	if err != nil {
+		return resp, err
+	}
+
+	resp.ArchivedAt = time.Now()
+
+	// the rows are only dropped once the archive holds every one of them
+	if request.IsSchemaDropped {
+		if err := uc.catalogRepo.DropTenantSchema(ctx, request.TenantCode); err != nil {
+			return resp, fmt.Errorf("tenant %v is archived but its schema is not dropped: %w", request.TenantCode, err)
+		}
+
+		resp.IsSchemaDropped = true
+	}
+
+	return resp, nil
+}
+
+// restoreTenant brings back a tenant whose offboarding failed, returning the failure
+func (uc *tenantUsecase) restoreTenant(ctx context.Context, tenantCode string, err error) error {
+	if restoreErr := uc.catalogRepo.RestoreTenant(ctx, tenantCode); restoreErr != nil {
+		return errors.Join(err, restoreErr)
+	}
+
+	return err
+}
+
+// verifyArchive reads the archive back, its files have to pass their checksums, the bundle has to be the one of
+// the tenant and every object has to have the rows that were exported
+func (uc *tenantUsecase) verifyArchive(key string, bundle entity.MetadataBundle, exportedRows map[string]int64) (err error) {
+	file, err := uc.archive.Open(key)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// a zip is read from its end, the archive is copied aside unless the storage can read at an offset
+	readerAt, isReaderAt := file.(io.ReaderAt)
+	size, err := uc.archive.Size(key)
+	if err != nil {
+		return err
+	}
+
+	if !isReaderAt {
+		copied, err := os.CreateTemp("", "tenant-archive-*.zip")
+		if err != nil {
+			return err
+		}
+
+		defer func() {
+			copied.Close()
+			os.Remove(copied.Name())
+		}()
+
+		if size, err = io.Copy(copied, file); err != nil {
+			return err
+		}
+
+		readerAt = copied
+	}
+
+	archive, err := zip.NewReader(readerAt, size)
+	if err != nil {
+		return err
+	}
+
+	rows := map[string]int64{}
+	var archivedBundle entity.MetadataBundle
+	for _, archiveFile := range archive.File {
+		content, err := archiveFile.Open()
+		if err != nil {
+			return err
+		}
+
+		// reading a file to its end checks its checksum
+		if archiveFile.Name == "bundle.json" {
+			err = json.NewDecoder(content).Decode(&archivedBundle)
+			if err == nil {
+				_, err = io.Copy(io.Discard, content)
+			}
+		} else {
+			rows[strings.TrimSuffix(strings.TrimPrefix(archiveFile.Name, "data/"), ".ndjson")], err = countLines(content)
+		}
+
+		content.Close()
+		if err != nil {
+			return fmt.Errorf("file %v: %w", archiveFile.Name, err)
+		}
+	}
+
+	if archivedBundle.TenantCode != bundle.TenantCode || len(archivedBundle.Objects) != len(bundle.Objects) {
+		return fmt.Errorf("the bundle of tenant %v is missing", bundle.TenantCode)
+	}
+
+	for _, object := range bundle.Objects {
+		archivedRows, ok := rows[object.ObjectCode]
+		if !ok || archivedRows != exportedRows[object.ObjectCode] {
+			return fmt.Errorf("object %v has %d rows in the archive, %d were exported", object.ObjectCode, archivedRows, exportedRows[object.ObjectCode])
+		}
+	}
+
+	return nil
+}
+
+func countLines(reader io.Reader) (lines int64, err error) {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		lines++
+	}
+
+	return lines, scanner.Err()
+}
+
+// writeArchive writes the bundle of a tenant as bundle.json and the rows of every object as json lines under
+// data/, counting the rows of every object in exportedRows
+func (uc *tenantUsecase) writeArchive(ctx context.Context, key string, bundle entity.MetadataBundle, exportedRows map[string]int64) (err error) {
+	file, err := uc.archive.Create(key)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	archive := zip.NewWriter(file)
+
+	bundleFile, err := archive.Create("bundle.json")
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(bundleFile)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(bundle); err != nil {
+		return err
+	}
+
+	for _, object := range bundle.Objects {
+		dataFile, err := archive.Create("data/" + object.ObjectCode + ".ndjson")
+		if err != nil {
+			return err
+		}
+
+		encoder := json.NewEncoder(dataFile)
+		exportedRows[object.ObjectCode] = 0
+
+		err = uc.catalogRepo.StreamObjectRows(ctx, bundle.TenantCode, object.ObjectCode, uc.cfg.ExportBatchSize, func(rows []map[string]any) error {
+			for _, row := range rows {
+				if err := encoder.Encode(row); err != nil {
+					return err
+				}
+			}
+
+			exportedRows[object.ObjectCode] += int64(len(rows))
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to archive object %v: %w", object.ObjectCode, err)
+		}
+	}
+
+	return archive.Close()
+}
+
+// defaultUserObject is the user table given to a tenant whose template has none, with the columns a user logs in
+// with and the ones holding the encrypted password
+func defaultUserObject() entity.ObjectSchema {
+	stringType := entity.DataType{Code: entity.PrimitiveDataTypeString, PrimitiveDataType: entity.PrimitiveDataTypeString}
+	textType := entity.DataType{Code: entity.PrimitiveDataTypeText, PrimitiveDataType: entity.PrimitiveDataTypeText}
+
+	return entity.ObjectSchema{
+		ObjectCode:      userObjectCode,
+		DisplayName:     "User",
+		DisplayTemplate: "{name}",
+		Fields: []entity.FieldSchema{
+			{FieldCode: "name", DisplayName: "Name", DataType: stringType, IsRequired: true, IsDisplayName: true, IsSearchable: true},
+			{FieldCode: "username", DisplayName: "Username", DataType: stringType, IsUnique: true, IsSearchable: true},
+			{FieldCode: "email", DisplayName: "Email", DataType: stringType, IsUnique: true, IsSearchable: true},
+			{FieldCode: "phone_number", DisplayName: "Phone Number", DataType: stringType, IsUnique: true},
+			{FieldCode: "password_cipher", DisplayName: "Password Cipher", DataType: textType},
+			{FieldCode: "password_salt", DisplayName: "Password Salt", DataType: textType},
+			{FieldCode: "password_iv", DisplayName: "Password IV", DataType: textType},
+		},
+	}
+}
+
+// copyOrder orders the objects whose rows are copied so an object follows the objects its many to one fields
+// reference, the foreign keys of a copied row find their rows. Objects referencing each other keep their order.
+func copyOrder(objects []entity.ObjectSchema) []string {
+	targets := make(map[string][]string, len(objects))
+	for _, object := range objects {
+		targets[object.ObjectCode] = []string{}
+	}
+
+	for _, object := range objects {
+		for _, field := range object.Fields {
+			if _, ok := targets[field.TargetObjectCode]; ok && field.Relation == entity.RelationManyToOne && field.TargetObjectCode != object.ObjectCode {
+				targets[object.ObjectCode] = append(targets[object.ObjectCode], field.TargetObjectCode)
+			}
+		}
+	}
+
+	ordered := make([]string, 0, len(objects))
+	isOrdered := make(map[string]bool, len(objects))
+	for len(ordered) < len(objects) {
+		isProgressing := false
+		for _, object := range objects {
+			if isOrdered[object.ObjectCode] {
+				continue
+			}
+
+			isReady := true
+			for _, target := range targets[object.ObjectCode] {
+				isReady = isReady && isOrdered[target]
+			}
+
+			if isReady {
+				ordered = append(ordered, object.ObjectCode)
+				isOrdered[object.ObjectCode] = true
+				isProgressing = true
+			}
+		}
+
+		if isProgressing {
+			continue
+		}
+
+		for _, object := range objects {
+			if !isOrdered[object.ObjectCode] {
+				ordered = append(ordered, object.ObjectCode)
+				isOrdered[object.ObjectCode] = true
+			}
+		}
+	}
+
+	return ordered
+}
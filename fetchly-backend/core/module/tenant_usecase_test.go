@@ -0,0 +1,208 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+	"github.com/fetchlydev/source/fetchly-backend/core/repository"
+	"github.com/fetchlydev/source/fetchly-backend/pkg/storage"
+)
+
+// offboardedTenantRepo is a catalog repository of a tenant being offboarded with the rows of its objects, recording
+// whether the tenant is archived and its schema dropped and whether its rows were read while it was archived
+type offboardedTenantRepo struct {
+	repository.CatalogRepository
+	rows              map[string][]map[string]any
+	failedObject      string
+	isArchived        bool
+	isSchemaDropped   bool
+	isReadWhileActive bool
+}
+
+func (r *offboardedTenantRepo) GetTenantByCode(ctx context.Context, tenantCode string) (resp entity.Tenants, err error) {
+	if r.isArchived {
+		return resp, fmt.Errorf("%w: tenant %v is not found", entity.ErrorNotFound, tenantCode)
+	}
+
+	return entity.Tenants{Code: tenantCode}, nil
+}
+
+func (r *offboardedTenantRepo) ArchiveTenant(ctx context.Context, tenantCode, userSerial string) (err error) {
+	r.isArchived = true
+	return nil
+}
+
+func (r *offboardedTenantRepo) RestoreTenant(ctx context.Context, tenantCode string) (err error) {
+	r.isArchived = false
+	return nil
+}
+
+func (r *offboardedTenantRepo) DropTenantSchema(ctx context.Context, tenantCode string) (err error) {
+	if !r.isArchived {
+		return fmt.Errorf("%w: tenant %v is not archived", entity.ErrorBadRequest, tenantCode)
+	}
+
+	r.isSchemaDropped = true
+	return nil
+}
+
+func (r *offboardedTenantRepo) StreamObjectRows(ctx context.Context, tenantCode, objectCode string, batchSize int, fn func(rows []map[string]any) error) (err error) {
+	if !r.isArchived {
+		r.isReadWhileActive = true
+	}
+
+	if objectCode == r.failedObject {
+		return errors.New("connection reset")
+	}
+
+	return fn(r.rows[objectCode])
+}
+
+// bundleExporter is a migration usecase exporting a fixed bundle
+type bundleExporter struct {
+	MigrationUsecase
+	bundle entity.MetadataBundle
+}
+
+func (uc *bundleExporter) ExportMetadataBundle(ctx context.Context, request entity.MetadataExportRequest) (resp entity.MetadataBundle, err error) {
+	return uc.bundle, nil
+}
+
+func TestOffboardTenantRefusals(t *testing.T) {
+	tests := []struct {
+		name       string
+		tenantCode string
+		objects    []entity.ObjectSchema
+	}{
+		{name: "public schema", tenantCode: entity.PUBLIC},
+		{name: "postgres schema", tenantCode: "pg_catalog"},
+		{name: "information schema", tenantCode: "information_schema"},
+		{
+			name:       "objects in a data source",
+			tenantCode: "acme",
+			objects:    []entity.ObjectSchema{{ObjectCode: "contact"}, {ObjectCode: "invoice", DataSourceCode: "warehouse"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &offboardedTenantRepo{}
+			uc := &tenantUsecase{
+				catalogRepo: repo,
+				migrationUc: &bundleExporter{bundle: entity.MetadataBundle{TenantCode: tt.tenantCode, Objects: tt.objects}},
+			}
+
+			_, err := uc.OffboardTenant(context.Background(), entity.TenantOffboardRequest{TenantCode: tt.tenantCode, IsSchemaDropped: true})
+			if !errors.Is(err, entity.ErrorBadRequest) {
+				t.Errorf("OffboardTenant() = %v, want a bad request", err)
+			}
+
+			if repo.isArchived {
+				t.Errorf("tenant %v is archived", tt.tenantCode)
+			}
+		})
+	}
+}
+
+// truncatedStorage is a storage whose files do not read back the way they were written
+type truncatedStorage struct {
+	storage.Storage
+}
+
+func (s *truncatedStorage) Open(key string) (io.ReadCloser, error) {
+	file, err := s.Storage.Open(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(file, 64), file}, nil
+}
+
+func TestOffboardTenant(t *testing.T) {
+	tests := []struct {
+		name            string
+		isSchemaDropped bool
+		failedObject    string
+		isTruncated     bool
+		wantErr         bool
+		wantArchived    bool
+		wantDropped     bool
+	}{
+		{name: "archive and drop the schema", isSchemaDropped: true, wantArchived: true, wantDropped: true},
+		{name: "archive and keep the schema", wantArchived: true},
+		{name: "failed archive restores the tenant", isSchemaDropped: true, failedObject: "deal", wantErr: true},
+		{name: "archive that does not read back restores the tenant", isSchemaDropped: true, isTruncated: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &offboardedTenantRepo{
+				failedObject: tt.failedObject,
+				rows: map[string][]map[string]any{
+					"contact": {{"serial": "1", "name": "ann"}, {"serial": "2", "name": "bob"}, {"serial": "3", "name": "cid"}},
+				},
+			}
+
+			archive, err := storage.NewLocalStorage(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.isTruncated {
+				archive = &truncatedStorage{Storage: archive}
+			}
+
+			sessions := newSessionRepo()
+			authUc := &authUsecase{authRepo: sessions, catalogRepo: repo}
+			token, _ := openTestSession(t, authUc, "ann")
+
+			uc := &tenantUsecase{
+				catalogRepo: repo,
+				authRepo:    sessions,
+				archive:     archive,
+				migrationUc: &bundleExporter{bundle: entity.MetadataBundle{TenantCode: "acme", Objects: []entity.ObjectSchema{{ObjectCode: "contact"}, {ObjectCode: "deal"}}}},
+			}
+
+			resp, err := uc.OffboardTenant(context.Background(), entity.TenantOffboardRequest{TenantCode: "acme", IsSchemaDropped: tt.isSchemaDropped})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("OffboardTenant() error = %v, want error %v", err, tt.wantErr)
+			}
+
+			if repo.isReadWhileActive {
+				t.Error("rows were read before the tenant was archived")
+			}
+
+			if repo.isArchived != tt.wantArchived || repo.isSchemaDropped != tt.wantDropped {
+				t.Errorf("archived %v and schema dropped %v, want %v and %v", repo.isArchived, repo.isSchemaDropped, tt.wantArchived, tt.wantDropped)
+			}
+
+			// the sessions of the tenant end with its archiving
+			if _, err := authUc.Authenticate(context.Background(), "acme", token); !errors.Is(err, entity.ErrSessionRevoked) {
+				t.Errorf("Authenticate() error = %v, want %v", err, entity.ErrSessionRevoked)
+			}
+
+			_, _, err = authUc.openSession(context.Background(), "acme", map[string]entity.DataItem{"serial": {Value: "ann"}}, entity.SessionClient{})
+			if errors.Is(err, entity.ErrorNotFound) != tt.wantArchived {
+				t.Errorf("openSession() error = %v, want refused %v", err, tt.wantArchived)
+			}
+
+			if tt.wantErr {
+				if _, err := archive.Open(resp.Key); err == nil {
+					t.Errorf("archive %v of a failed offboarding is kept", resp.Key)
+				}
+				return
+			}
+
+			if resp.ExportedRows["contact"] != 3 || resp.ExportedRows["deal"] != 0 || resp.Size == 0 || resp.IsSchemaDropped != tt.wantDropped {
+				t.Errorf("OffboardTenant() = %+v", resp)
+			}
+		})
+	}
+}
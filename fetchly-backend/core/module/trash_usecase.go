@@ -0,0 +1,106 @@
+package module
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/fetchlydev/source/fetchly-backend/config"
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+	"github.com/fetchlydev/source/fetchly-backend/core/repository"
+	"github.com/fetchlydev/source/fetchly-backend/pkg/conn"
+)
+
+const trashPurgeKey = "trash:purge"
+
+type TrashUsecase interface {
+	PurgeTrash(ctx context.Context, tenantCode string) (resp entity.TrashPurge, err error)
+	StartPurge(ctx context.Context)
+}
+
+type trashUsecase struct {
+	cfg         config.Config
+	cache       conn.CacheService
+	catalogRepo repository.CatalogRepository
+}
+
+func NewTrashUsecase(cfg config.Config, cache conn.CacheService, catalogRepo repository.CatalogRepository) TrashUsecase {
+	return &trashUsecase{
+		cfg:         cfg,
+		cache:       cache,
+		catalogRepo: catalogRepo,
+	}
+}
+
+// PurgeTrash deletes for good the records of a tenant soft deleted longer than the retention ago. The objects are
+// purged in the reverse order of their creation, the children before the objects they reference.
+func (uc *trashUsecase) PurgeTrash(ctx context.Context, tenantCode string) (resp entity.TrashPurge, err error) {
+	resp = entity.TrashPurge{
+		TenantCode:    tenantCode,
+		DeletedBefore: time.Now().AddDate(0, 0, -uc.cfg.TrashRetentionDays),
+		PurgedRows:    map[string]int64{},
+	}
+
+	objectCodes, err := uc.catalogRepo.GetObjectCodes(ctx, tenantCode, "")
+	if err != nil {
+		return resp, err
+	}
+
+	for i := len(objectCodes) - 1; i >= 0; i-- {
+		purged, err := uc.catalogRepo.PurgeObjectData(ctx, tenantCode, objectCodes[i], resp.DeletedBefore)
+		if err != nil {
+			// a record still referenced by a live one is left for a later purge
+			log.Printf("error at purging object %v of tenant %v. Detail: %v", objectCodes[i], tenantCode, err)
+			continue
+		}
+
+		if purged > 0 {
+			resp.PurgedRows[objectCodes[i]] = purged
+		}
+	}
+
+	return resp, nil
+}
+
+// StartPurge purges the trash of every tenant per interval until ctx is done, only one instance does it per
+// interval. A retention of 0 keeps the soft deleted records.
+func (uc *trashUsecase) StartPurge(ctx context.Context) {
+	if uc.cfg.TrashRetentionDays < 1 || uc.cfg.TrashPurgeInterval < 1 {
+		return
+	}
+
+	go uc.runPurge(ctx)
+}
+
+func (uc *trashUsecase) runPurge(ctx context.Context) {
+	interval := time.Duration(uc.cfg.TrashPurgeInterval) * time.Second
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		isLocked, err := uc.cache.SetNX(trashPurgeKey, []byte("1"), uc.cfg.TrashPurgeInterval-1)
+		if err == nil && isLocked {
+			uc.purgeTenants(ctx)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (uc *trashUsecase) purgeTenants(ctx context.Context) {
+	tenantCodes, err := uc.catalogRepo.GetTenantCodes(ctx)
+	if err != nil {
+		log.Printf("error at listing tenants to purge. Detail: %v", err)
+		return
+	}
+
+	for _, tenantCode := range tenantCodes {
+		if _, err := uc.PurgeTrash(ctx, tenantCode); err != nil {
+			log.Printf("error at purging the trash of tenant %v. Detail: %v", tenantCode, err)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/fetchlydev/source/fetchly-backend/config"
+	"github.com/fetchlydev/source/fetchly-backend/core/repository"
+)
+
+// purgeRepo is a catalog repository whose objects hold purged soft deleted records, the records of
+// referencedObject are still referenced by live ones
+type purgeRepo struct {
+	repository.CatalogRepository
+	objectCodes      []string
+	purged           map[string]int64
+	referencedObject string
+	purgeOrder       []string
+	deletedBefore    time.Time
+}
+
+func (r *purgeRepo) GetObjectCodes(ctx context.Context, tenantCode, moduleCode string) (resp []string, err error) {
+	return r.objectCodes, nil
+}
+
+func (r *purgeRepo) PurgeObjectData(ctx context.Context, tenantCode, objectCode string, deletedBefore time.Time) (purged int64, err error) {
+	r.purgeOrder = append(r.purgeOrder, objectCode)
+	r.deletedBefore = deletedBefore
+
+	if objectCode == r.referencedObject {
+		return 0, errors.New("violates foreign key constraint")
+	}
+
+	return r.purged[objectCode], nil
+}
+
+func TestPurgeTrash(t *testing.T) {
+	repo := &purgeRepo{
+		objectCodes:      []string{"account", "deal", "line"},
+		purged:           map[string]int64{"deal": 2, "line": 5},
+		referencedObject: "account",
+	}
+
+	uc := &trashUsecase{cfg: config.Config{TrashRetentionDays: 30}, catalogRepo: repo}
+
+	resp, err := uc.PurgeTrash(context.Background(), "acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the children are purged before the objects they reference
+	if fmt.Sprint(repo.purgeOrder) != "[line deal account]" {
+		t.Errorf("purge order %v, want [line deal account]", repo.purgeOrder)
+	}
+
+	if fmt.Sprint(resp.PurgedRows) != "map[deal:2 line:5]" {
+		t.Errorf("PurgedRows = %v, want map[deal:2 line:5]", resp.PurgedRows)
+	}
+
+	if !repo.deletedBefore.Equal(resp.DeletedBefore) {
+		t.Errorf("purged records deleted before %v, want %v", repo.deletedBefore, resp.DeletedBefore)
+	}
+
+	if retention := time.Since(resp.DeletedBefore); retention < 30*24*time.Hour || retention > 31*24*time.Hour {
+		t.Errorf("DeletedBefore = %v, want 30 days ago", resp.DeletedBefore)
+	}
+}
@@ -0,0 +1,553 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+	"github.com/fetchlydev/source/fetchly-backend/core/repository"
+)
+
+// validationEngine enforces the validation rules, default values and field options stored in
+// object_fields and data_types before any mutation reaches the repository.
+// The engine caches the object metadata, so it is meant to live for a single request or batch.
+type validationEngine struct {
+	catalogRepo  repository.CatalogRepository
+	objectFields map[string]map[string]entity.ObjectFields
+}
+
+func newValidationEngine(catalogRepo repository.CatalogRepository) *validationEngine {
+	return &validationEngine{
+		catalogRepo:  catalogRepo,
+		objectFields: make(map[string]map[string]entity.ObjectFields),
+	}
+}
+
+// ValidateCreate applies default values and validates a new record
+func (ve *validationEngine) ValidateCreate(ctx context.Context, request entity.DataMutationRequest) (entity.DataMutationRequest, error) {
+	return ve.validate(ctx, request, nil)
+}
+
+// ValidateUpdate validates the changed fields of an existing record, existingData is used for
+// required and cross-field rules on fields that are not part of the request.
+func (ve *validationEngine) ValidateUpdate(ctx context.Context, request entity.DataMutationRequest, existingData map[string]entity.DataItem) (entity.DataMutationRequest, error) {
+	if existingData == nil {
+		existingData = map[string]entity.DataItem{}
+	}
+
+	return ve.validate(ctx, request, existingData)
+}
+
+func (ve *validationEngine) validate(ctx context.Context, request entity.DataMutationRequest, existingData map[string]entity.DataItem) (entity.DataMutationRequest, error) {
+	isCreate := existingData == nil
+
+	objectFields, err := ve.getObjectFields(ctx, request.ObjectCode, request.TenantCode)
+	if err != nil {
+		return request, err
+	}
+
+	// objects without metadata have nothing to enforce
+	if len(objectFields) == 0 {
+		return request, nil
+	}
+
+	providedItems := make(map[string]entity.DataItem)
+	for _, item := range request.Items {
+		providedItems[item.FieldCode] = item
+	}
+
+	// apply default value on create for missing fields
+	if isCreate {
+		for fieldCode, field := range objectFields {
+			if _, ok := providedItems[fieldCode]; ok || field.DefaultValue == "" || field.IsSystem {
+				continue
+			}
+
+			item := entity.DataItem{FieldCode: fieldCode, Value: field.DefaultValue}
+			request.Items = append(request.Items, item)
+			providedItems[fieldCode] = item
+		}
+	}
+
+	// values of the record after the mutation, used for required and cross-field rules
+	recordValues := make(map[string]any)
+	for key, item := range existingData {
+		recordValues[key] = item.Value
+	}
+	for key, item := range providedItems {
+		recordValues[key] = item.Value
+	}
+
+	validationErrors := entity.ValidationErrors{}
+
+	for fieldCode, field := range objectFields {
+		// the records of a to many relation are validated against their own object
+		if field.IsSystem || entity.IsToManyRelation(field.Relation) {
+			continue
+		}
+
+		item, isProvided := providedItems[fieldCode]
+
+		// on update only the fields sent in the request are validated
+		if !isCreate && !isProvided {
+			continue
+		}
+
+		rules := mergeValidationRules(field.DataType.ValidationRules, field.ValidationRules)
+		fieldErrors, err := ve.validateField(ctx, request, field, rules, item.Value, recordValues)
+		if err != nil {
+			return request, err
+		}
+
+		validationErrors = append(validationErrors, fieldErrors...)
+	}
+
+	if len(validationErrors) > 0 {
+		return request, validationErrors
+	}
+
+	return request, nil
+}
+
+func (ve *validationEngine) validateField(ctx context.Context, request entity.DataMutationRequest, field entity.ObjectFields, rules map[string]any, value any, recordValues map[string]any) (entity.ValidationErrors, error) {
+	fieldErrors := entity.ValidationErrors{}
+	addError := func(rule entity.ValidationRule, defaultMessage string, params map[string]any) {
+		fieldErrors = append(fieldErrors, entity.FieldValidationError{
+			FieldCode: field.FieldCode,
+			FieldName: field.DisplayName,
+			Rule:      rule,
+			Message:   ruleMessage(rules, rule, defaultMessage),
+			Params:    params,
+		})
+	}
+
+	if isEmptyValue(value) {
+		if isRuleEnabled(rules[string(entity.ValidationRuleRequired)]) {
+			addError(entity.ValidationRuleRequired, fmt.Sprintf("%v is required", fieldLabel(field)), nil)
+		}
+
+		// the remaining rules only apply to filled values
+		return fieldErrors, nil
+	}
+
+	// min and max compare numbers, or dates when the rule is a date string
+	for _, rule := range []entity.ValidationRule{entity.ValidationRuleMin, entity.ValidationRuleMax} {
+		limit, ok := rules[string(rule)]
+		if !ok {
+			continue
+		}
+
+		comparison, comparable := compareValues(value, limit)
+		if !comparable {
+			addError(rule, fmt.Sprintf("%v must be comparable with %v", fieldLabel(field), limit), map[string]any{string(rule): limit})
+			continue
+		}
+
+		if rule == entity.ValidationRuleMin && comparison < 0 {
+			addError(rule, fmt.Sprintf("%v must be at least %v", fieldLabel(field), limit), map[string]any{string(rule): limit})
+		}
+
+		if rule == entity.ValidationRuleMax && comparison > 0 {
+			addError(rule, fmt.Sprintf("%v must be at most %v", fieldLabel(field), limit), map[string]any{string(rule): limit})
+		}
+	}
+
+	// length rules count characters of the string representation
+	length := utf8.RuneCountInString(fmt.Sprintf("%v", value))
+	if minLength, ok := toFloat(rules[string(entity.ValidationRuleMinLength)]); ok && float64(length) < minLength {
+		addError(entity.ValidationRuleMinLength, fmt.Sprintf("%v must be at least %v characters", fieldLabel(field), minLength), map[string]any{"min_length": minLength})
+	}
+
+	if maxLength, ok := toFloat(rules[string(entity.ValidationRuleMaxLength)]); ok && float64(length) > maxLength {
+		addError(entity.ValidationRuleMaxLength, fmt.Sprintf("%v must be at most %v characters", fieldLabel(field), maxLength), map[string]any{"max_length": maxLength})
+	}
+
+	pattern, _ := rules[string(entity.ValidationRulePattern)].(string)
+	if pattern == "" {
+		pattern, _ = rules["regex"].(string)
+	}
+
+	if pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fieldErrors, fmt.Errorf("invalid pattern rule on field %v: %w", field.FieldCode, err)
+		}
+
+		if !re.MatchString(fmt.Sprintf("%v", value)) {
+			addError(entity.ValidationRulePattern, fmt.Sprintf("%v has an invalid format", fieldLabel(field)), map[string]any{"pattern": pattern})
+		}
+	}
+
+	// enum values come from the rule itself or from the data type field options
+	if options := enumOptions(rules, field.DataType.FieldOptions); len(options) > 0 {
+		if !containsValue(options, value) {
+			addError(entity.ValidationRuleEnum, fmt.Sprintf("%v must be one of the available options", fieldLabel(field)), map[string]any{"options": options})
+		}
+	}
+
+	if isRuleEnabled(rules[string(entity.ValidationRuleUnique)]) {
+		isUnique, err := ve.isUnique(ctx, request, field.FieldCode, value)
+		if err != nil {
+			return fieldErrors, err
+		}
+
+		if !isUnique {
+			addError(entity.ValidationRuleUnique, fmt.Sprintf("%v is already used", fieldLabel(field)), nil)
+		}
+	}
+
+	for _, crossRule := range crossFieldRules(rules[string(entity.ValidationRuleCrossField)]) {
+		otherField, _ := crossRule["field"].(string)
+		operator, _ := crossRule["operator"].(string)
+		if otherField == "" || operator == "" {
+			continue
+		}
+
+		otherValue := recordValues[otherField]
+		if isEmptyValue(otherValue) {
+			continue
+		}
+
+		comparison, comparable := compareValues(value, otherValue)
+		if !comparable || !matchComparison(entity.FilterOperator(operator), comparison) {
+			message := fmt.Sprintf("%v must be %v %v", fieldLabel(field), strings.ReplaceAll(operator, "_", " "), otherField)
+			if customMessage, ok := crossRule["message"].(string); ok && customMessage != "" {
+				message = customMessage
+			}
+
+			fieldErrors = append(fieldErrors, entity.FieldValidationError{
+				FieldCode: field.FieldCode,
+				FieldName: field.DisplayName,
+				Rule:      entity.ValidationRuleCrossField,
+				Message:   message,
+				Params:    map[string]any{"field": otherField, "operator": operator},
+			})
+		}
+	}
+
+	return fieldErrors, nil
+}
+
+// isUnique checks that no other non deleted record holds the same value
+func (ve *validationEngine) isUnique(ctx context.Context, request entity.DataMutationRequest, fieldCode string, value any) (bool, error) {
+	filters := map[string]entity.FilterItem{
+		fieldCode: {FieldName: fieldCode, Operator: entity.FilterOperatorEqual, Value: value},
+	}
+
+	if request.Serial != "" {
+		filters[entity.DEFAULT_IDENTIFIER] = entity.FilterItem{FieldName: entity.DEFAULT_IDENTIFIER, Operator: entity.FilterOperatorNotEqual, Value: request.Serial}
+	}
+
+	result, err := ve.catalogRepo.GetObjectData(ctx, entity.CatalogQuery{
+		TenantCode:  request.TenantCode,
+		ProductCode: request.ProductCode,
+		ObjectCode:  request.ObjectCode,
+		PageSize:    1,
+		Fields: map[string]entity.Field{
+			entity.DEFAULT_IDENTIFIER: {FieldCode: entity.DEFAULT_IDENTIFIER},
+		},
+		Filters: []entity.FilterGroup{
+			{
+				Operator: entity.NewFilterGroupOperator(entity.FilterOperatorAnd),
+				Filters:  filters,
+			},
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return result.TotalData == 0, nil
+}
+
+// getObjectFields loads the object fields metadata together with their data types
+func (ve *validationEngine) getObjectFields(ctx context.Context, objectCode, tenantCode string) (map[string]entity.ObjectFields, error) {
+	cacheKey := tenantCode + "." + objectCode
+	if result, ok := ve.objectFields[cacheKey]; ok {
+		return result, nil
+	}
+
+	result, err := ve.loadObjectFields(ctx, objectCode, tenantCode)
+	if err != nil {
+		return result, err
+	}
+
+	ve.objectFields[cacheKey] = result
+	return result, nil
+}
+
+func (ve *validationEngine) loadObjectFields(ctx context.Context, objectCode, tenantCode string) (map[string]entity.ObjectFields, error) {
+	result := make(map[string]entity.ObjectFields)
+
+	object, err := ve.catalogRepo.GetObjectByCode(ctx, objectCode, tenantCode)
+	if errors.Is(err, entity.ErrorNotFound) {
+		// object without metadata
+		return result, nil
+	}
+
+	if err != nil {
+		return result, err
+	}
+
+	fields, err := ve.catalogRepo.GetObjectFieldsByObjectCode(ctx, entity.CatalogQuery{
+		ObjectCode:   objectCode,
+		ObjectSerial: object.Serial,
+		TenantCode:   tenantCode,
+	})
+	if err != nil {
+		return result, err
+	}
+
+	dataTypeSerials := []string{}
+	for _, item := range fields {
+		if field, ok := item.(entity.ObjectFields); ok && field.DataType.Serial != "" {
+			dataTypeSerials = append(dataTypeSerials, field.DataType.Serial)
+		}
+	}
+
+	dataTypes, err := ve.catalogRepo.GetDataTypeBySerials(ctx, dataTypeSerials)
+	if err != nil {
+		return result, err
+	}
+
+	dataTypeMap := make(map[string]entity.DataType)
+	for _, dataType := range dataTypes {
+		dataTypeMap[dataType.Serial] = dataType
+	}
+
+	for fieldCode, item := range fields {
+		field, ok := item.(entity.ObjectFields)
+		if !ok {
+			continue
+		}
+
+		if dataType, ok := dataTypeMap[field.DataType.Serial]; ok {
+			field.DataType = dataType
+		}
+
+		result[fieldCode] = field
+	}
+
+	return result, nil
+}
+
+// mergeValidationRules overlays the field level rules on top of the data type rules
+func mergeValidationRules(dataTypeRules, fieldRules map[string]any) map[string]any {
+	rules := make(map[string]any)
+	for key, value := range dataTypeRules {
+		rules[key] = value
+	}
+	for key, value := range fieldRules {
+		rules[key] = value
+	}
+
+	return rules
+}
+
+func ruleMessage(rules map[string]any, rule entity.ValidationRule, defaultMessage string) string {
+	if messages, ok := rules[entity.ValidationRuleMessages].(map[string]any); ok {
+		if message, ok := messages[string(rule)].(string); ok && message != "" {
+			return message
+		}
+	}
+
+	return defaultMessage
+}
+
+func fieldLabel(field entity.ObjectFields) string {
+	if field.DisplayName != "" {
+		return field.DisplayName
+	}
+
+	return field.FieldCode
+}
+
+func isRuleEnabled(rule any) bool {
+	switch v := rule.(type) {
+	case bool:
+		return v
+	case string:
+		enabled, _ := strconv.ParseBool(v)
+		return enabled
+	}
+
+	return false
+}
+
+func isEmptyValue(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(v) == ""
+	case []any:
+		return len(v) == 0
+	case map[string]any:
+		return len(v) == 0
+	}
+
+	return false
+}
+
+// enumOptions reads allowed values from the enum rule, or from field options shaped as
+// {"options": ["a", "b"]} or {"options": [{"value": "a", "label": "A"}]}
+func enumOptions(rules map[string]any, fieldOptions map[string]any) []any {
+	rawOptions, ok := rules[string(entity.ValidationRuleEnum)].([]any)
+	if !ok {
+		rawOptions, ok = fieldOptions["options"].([]any)
+		if !ok {
+			return nil
+		}
+	}
+
+	options := make([]any, 0, len(rawOptions))
+	for _, option := range rawOptions {
+		if optionMap, ok := option.(map[string]any); ok {
+			options = append(options, optionMap["value"])
+			continue
+		}
+
+		options = append(options, option)
+	}
+
+	return options
+}
+
+func containsValue(options []any, value any) bool {
+	values := []any{value}
+	if list, ok := value.([]any); ok {
+		values = list
+	}
+
+	for _, v := range values {
+		found := false
+		for _, option := range options {
+			if fmt.Sprintf("%v", option) == fmt.Sprintf("%v", v) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+func crossFieldRules(rule any) []map[string]any {
+	var result []map[string]any
+
+	switch v := rule.(type) {
+	case map[string]any:
+		result = append(result, v)
+	case []any:
+		for _, item := range v {
+			if itemMap, ok := item.(map[string]any); ok {
+				result = append(result, itemMap)
+			}
+		}
+	}
+
+	return result
+}
+
+func matchComparison(operator entity.FilterOperator, comparison int) bool {
+	switch operator {
+	case entity.FilterOperatorEqual:
+		return comparison == 0
+	case entity.FilterOperatorNotEqual:
+		return comparison != 0
+	case entity.FilterOperatorGreaterThan:
+		return comparison > 0
+	case entity.FilterOperatorGreaterThanEqual:
+		return comparison >= 0
+	case entity.FilterOperatorLessThan:
+		return comparison < 0
+	case entity.FilterOperatorLessThanEqual:
+		return comparison <= 0
+	}
+
+	return false
+}
+
+// compareValues compares two values as numbers, then as dates, then as strings
+func compareValues(a, b any) (int, bool) {
+	if aNumber, ok := toFloat(a); ok {
+		if bNumber, ok := toFloat(b); ok {
+			return compareOrdered(aNumber, bNumber), true
+		}
+	}
+
+	if aTime, ok := toTime(a); ok {
+		if bTime, ok := toTime(b); ok {
+			return aTime.Compare(bTime), true
+		}
+
+		return 0, false
+	}
+
+	if aString, ok := a.(string); ok {
+		if bString, ok := b.(string); ok {
+			return strings.Compare(aString, bString), true
+		}
+	}
+
+	return 0, false
+}
+
+func compareOrdered(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
+
+func toFloat(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case string:
+		number, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return number, err == nil
+	case []byte:
+		number, err := strconv.ParseFloat(string(v), 64)
+		return number, err == nil
+	}
+
+	return 0, false
+}
+
+func toTime(value any) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", entity.DefaultDateFormat} {
+			if parsed, err := time.Parse(layout, v); err == nil {
+				return parsed, true
+			}
+		}
+	}
+
+	return time.Time{}, false
+}
@@ -0,0 +1,73 @@
+package module
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+	"github.com/fetchlydev/source/fetchly-backend/core/repository"
+)
+
+// objectMetadataRepo is a catalog repository serving the metadata of a single object
+type objectMetadataRepo struct {
+	repository.CatalogRepository
+	object    entity.Objects
+	objectErr error
+	fields    map[string]any
+}
+
+func (r *objectMetadataRepo) GetObjectByCode(ctx context.Context, objectCode, tenantCode string) (resp entity.Objects, err error) {
+	return r.object, r.objectErr
+}
+
+func (r *objectMetadataRepo) GetObjectFieldsByObjectCode(ctx context.Context, request entity.CatalogQuery) (resp map[string]any, err error) {
+	return r.fields, nil
+}
+
+func (r *objectMetadataRepo) GetDataTypeBySerials(ctx context.Context, serials []string) (resp []entity.DataType, err error) {
+	return resp, nil
+}
+
+func TestLoadObjectFields(t *testing.T) {
+	errConnection := errors.New("connection refused")
+
+	tests := []struct {
+		name       string
+		repo       *objectMetadataRepo
+		wantErr    error
+		wantFields int
+	}{
+		{
+			name: "object with metadata",
+			repo: &objectMetadataRepo{
+				object: entity.Objects{Serial: "1"},
+				fields: map[string]any{"name": entity.ObjectFields{FieldCode: "name"}},
+			},
+			wantFields: 1,
+		},
+		{
+			name: "object without metadata",
+			repo: &objectMetadataRepo{objectErr: fmt.Errorf("%w: object contact", entity.ErrorNotFound)},
+		},
+		{
+			name:    "metadata not readable",
+			repo:    &objectMetadataRepo{objectErr: errConnection},
+			wantErr: errConnection,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields, err := newValidationEngine(tt.repo).loadObjectFields(context.Background(), "contact", "acme")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("loadObjectFields() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if len(fields) != tt.wantFields {
+				t.Errorf("loadObjectFields() = %v, want %d fields", fields, tt.wantFields)
+			}
+		})
+	}
+}
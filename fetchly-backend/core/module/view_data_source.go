@@ -0,0 +1,375 @@
+package module
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+)
+
+// scoreCardResult is the resolved value and trend of a score card, cached as a whole so the rolling comparison
+// windows do not change the cache key
+type scoreCardResult struct {
+	Value string                 `json:"value"`
+	Trend *entity.ScoreCardTrend `json:"trend,omitempty"`
+}
+
+// resolveViewDataSources runs the query data sources of the charts and score cards of the layout and puts their
+// values inline. A data source that fails carries its error in dataError, the rest of the layout is still returned.
+func (uc *viewUsecase) resolveViewDataSources(ctx context.Context, layout map[string]any, request entity.GetViewContentByKeysRequest) {
+	if layout == nil {
+		return
+	}
+
+	props, _ := layout[entity.PROPS].(map[string]any)
+
+	switch layout["type"] {
+	case entity.TypeChart:
+		dataSource, ok := toViewDataSource(props["dataSource"])
+		if !ok {
+			break
+		}
+
+		data, err := cachedDataSource(uc, ctx, request, dataSource.RefreshInterval, dataSource, func() (any, error) {
+			return uc.runDataSource(ctx, dataSource, request, nil)
+		})
+		if err != nil {
+			log.Printf("failed to resolve chart data source of %v: %v", request.ViewContentCode, err)
+			props["dataError"] = err.Error()
+			break
+		}
+
+		props["data"] = data
+
+	case entity.TypeScoreCard:
+		config, _ := props["config"].(map[string]any)
+		cards, _ := config["cards"].([]any)
+
+		for _, card := range cards {
+			cardMap, ok := card.(map[string]any)
+			if !ok {
+				continue
+			}
+
+			if err := uc.resolveScoreCard(ctx, cardMap, request); err != nil {
+				log.Printf("failed to resolve score card data source of %v: %v", request.ViewContentCode, err)
+				cardMap["dataError"] = err.Error()
+			}
+		}
+	}
+
+	if children, ok := layout["children"].([]any); ok {
+		for _, child := range children {
+			if childMap, ok := child.(map[string]any); ok {
+				uc.resolveViewDataSources(ctx, childMap, request)
+			}
+		}
+	}
+}
+
+// resolveScoreCard sets the value of a query score card, and its trend when the card compares periods. With a
+// comparison the value is the one of the last period.
+func (uc *viewUsecase) resolveScoreCard(ctx context.Context, cardMap map[string]any, request entity.GetViewContentByKeysRequest) error {
+	dataSource, ok := toViewDataSource(cardMap["dataSource"])
+	if !ok {
+		return nil
+	}
+
+	card := entity.ScoreCard{}
+	if err := remarshal(cardMap, &card); err != nil {
+		return err
+	}
+
+	cacheSource := map[string]any{"dataSource": dataSource, "compare": card.Compare}
+	result, err := cachedDataSource(uc, ctx, request, dataSource.RefreshInterval, cacheSource, func() (scoreCardResult, error) {
+		if card.Compare == nil {
+			value, err := uc.runScoreCardValue(ctx, dataSource, request, nil)
+			if err != nil {
+				return scoreCardResult{}, err
+			}
+
+			return scoreCardResult{Value: formatScoreCardValue(value)}, nil
+		}
+
+		return uc.compareScoreCard(ctx, dataSource, *card.Compare, request)
+	})
+	if err != nil {
+		return err
+	}
+
+	cardMap["value"] = result.Value
+	if result.Trend != nil {
+		cardMap["trend"] = result.Trend
+	}
+
+	return nil
+}
+
+// compareScoreCard runs the data source over the last period and the period before it, both rolling back from now
+func (uc *viewUsecase) compareScoreCard(ctx context.Context, dataSource entity.ViewDataSource, compare entity.ScoreCardCompare, request entity.GetViewContentByKeysRequest) (result scoreCardResult, err error) {
+	if compare.DateField == "" {
+		return result, fmt.Errorf("%w: score card comparison needs a date field", entity.ErrorBadRequest)
+	}
+
+	periodStart := func(end time.Time) (time.Time, error) {
+		switch compare.Period {
+		case entity.DateBucketDay:
+			return end.AddDate(0, 0, -1), nil
+		case entity.DateBucketWeek:
+			return end.AddDate(0, 0, -7), nil
+		case entity.DateBucketMonth:
+			return end.AddDate(0, -1, 0), nil
+		}
+
+		return end, fmt.Errorf("%w: unsupported comparison period %v", entity.ErrorBadRequest, compare.Period)
+	}
+
+	now := time.Now()
+	currentStart, err := periodStart(now)
+	if err != nil {
+		return result, err
+	}
+
+	previousStart, err := periodStart(currentStart)
+	if err != nil {
+		return result, err
+	}
+
+	current, err := uc.runScoreCardValue(ctx, dataSource, request, periodFilters(compare.DateField, currentStart, now))
+	if err != nil {
+		return result, err
+	}
+
+	previous, err := uc.runScoreCardValue(ctx, dataSource, request, periodFilters(compare.DateField, previousStart, currentStart))
+	if err != nil {
+		return result, err
+	}
+
+	result.Value = formatScoreCardValue(current)
+
+	currentNumber, isCurrentNumber := toFloat(current)
+	previousNumber, isPreviousNumber := toFloat(previous)
+	if !isCurrentNumber || !isPreviousNumber {
+		return result, nil
+	}
+
+	result.Trend = scoreCardTrend(currentNumber, previousNumber, compare)
+	return result, nil
+}
+
+// scoreCardTrend is the change of current against previous in percent, a change from zero counts as 100 percent
+func scoreCardTrend(current, previous float64, compare entity.ScoreCardCompare) *entity.ScoreCardTrend {
+	trend := &entity.ScoreCardTrend{
+		Type:     entity.ScoreCardTrendNeutral,
+		TimeSpan: compare.TimeSpan,
+	}
+
+	if trend.TimeSpan == "" {
+		trend.TimeSpan = fmt.Sprintf("vs previous %v", compare.Period)
+	}
+
+	switch {
+	case current == previous:
+		return trend
+	case previous == 0:
+		trend.Value = 100
+	default:
+		trend.Value = math.Round(math.Abs(current-previous)/math.Abs(previous)*1000) / 10
+	}
+
+	trend.Type = entity.ScoreCardTrendIncrease
+	if current < previous {
+		trend.Type = entity.ScoreCardTrendDecrease
+	}
+
+	trend.IsGood = (trend.Type == entity.ScoreCardTrendDecrease) == compare.IsDecreaseGood
+	return trend
+}
+
+// periodFilters matches the records whose date field falls in [start, end)
+func periodFilters(dateField string, start, end time.Time) []entity.FilterGroup {
+	return []entity.FilterGroup{
+		{
+			Operator: entity.NewFilterGroupOperator(entity.FilterOperatorAnd),
+			Filters: map[string]entity.FilterItem{
+				dateField: {FieldName: dateField, Operator: entity.FilterOperatorGreaterThanEqual, Value: start},
+			},
+		},
+		{
+			Operator: entity.NewFilterGroupOperator(entity.FilterOperatorAnd),
+			Filters: map[string]entity.FilterItem{
+				dateField: {FieldName: dateField, Operator: entity.FilterOperatorLessThan, Value: end},
+			},
+		},
+	}
+}
+
+// runScoreCardValue runs the data source and returns the single value of a score card, the first metric of the
+// first row of an aggregate or the total of a data query
+func (uc *viewUsecase) runScoreCardValue(ctx context.Context, dataSource entity.ViewDataSource, request entity.GetViewContentByKeysRequest, filters []entity.FilterGroup) (any, error) {
+	data, err := uc.runDataSource(ctx, dataSource, request, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp := data.(type) {
+	case entity.AggregateResponse:
+		if len(resp.Rows) == 0 || len(resp.YAxis) == 0 {
+			return 0, nil
+		}
+
+		return resp.Rows[0][resp.YAxis[0]], nil
+	case entity.CatalogResponse:
+		return resp.TotalData, nil
+	}
+
+	return nil, nil
+}
+
+// runDataSource runs a query data source against the aggregate engine or the catalog. The body is the aggregate
+// or catalog query, the tenant is always the one of the view and the product, object and view content of a catalog
+// query default to it. Without an endpoint a body with metrics is an aggregate.
+func (uc *viewUsecase) runDataSource(ctx context.Context, dataSource entity.ViewDataSource, request entity.GetViewContentByKeysRequest, filters []entity.FilterGroup) (any, error) {
+	endpoint := strings.ToLower(dataSource.Endpoint)
+	if endpoint == "" {
+		endpoint = entity.DataSourceEndpointData
+		if _, ok := dataSource.Body["metrics"]; ok {
+			endpoint = entity.DataSourceEndpointAggregate
+		}
+	}
+
+	switch endpoint {
+	case entity.DataSourceEndpointAggregate:
+		query := entity.AggregateQuery{}
+		if err := remarshal(dataSource.Body, &query); err != nil {
+			return nil, fmt.Errorf("%w: invalid aggregate data source: %v", entity.ErrorBadRequest, err)
+		}
+
+		query.TenantCode = request.TenantCode
+		if query.ProductCode == "" {
+			query.ProductCode = request.ProductCode
+		}
+
+		if query.ObjectCode == "" {
+			query.ObjectCode = request.ObjectCode
+		}
+
+		query.Filters = append(query.Filters, filters...)
+
+		return uc.catalogUc.AggregateObjectData(ctx, query)
+
+	case entity.DataSourceEndpointData:
+		query := entity.CatalogQuery{}
+		if err := remarshal(dataSource.Body, &query); err != nil {
+			return nil, fmt.Errorf("%w: invalid data source: %v", entity.ErrorBadRequest, err)
+		}
+
+		query.TenantCode = request.TenantCode
+		if query.ProductCode == "" {
+			query.ProductCode = request.ProductCode
+		}
+
+		if query.ObjectCode == "" {
+			query.ObjectCode = request.ObjectCode
+		}
+
+		// the records of the object of the view are read through the view, so its filters and fields apply to the
+		// data source as they do to the table, another object goes through its default view
+		if query.ViewContentCode == "" && query.ObjectCode == request.ObjectCode {
+			query.ViewContentCode = request.ViewContentCode
+		}
+
+		query.Filters = append(query.Filters, filters...)
+
+		return uc.catalogUc.GetObjectData(ctx, query)
+	}
+
+	return nil, fmt.Errorf("%w: unsupported data source endpoint %v", entity.ErrorBadRequest, dataSource.Endpoint)
+}
+
+// cachedDataSource returns the cached result of a data source for refreshInterval seconds. The key holds the user,
+// as the row policies of the user shape the result. A failing cache only costs the query, it is never an error.
+func cachedDataSource[T any](uc *viewUsecase, ctx context.Context, request entity.GetViewContentByKeysRequest, refreshInterval int, source any, resolve func() (T, error)) (T, error) {
+	if refreshInterval <= 0 || uc.cache == nil {
+		return resolve()
+	}
+
+	sourceJSON, err := json.Marshal(source)
+	if err != nil {
+		return resolve()
+	}
+
+	key := fmt.Sprintf("view_data_source:%v:%v:%x", request.TenantCode, userSerialFromContext(ctx), sha256.Sum256(sourceJSON))
+
+	var result T
+	if cached, err := uc.cache.Get(key); err == nil {
+		if err := json.Unmarshal(cached, &result); err == nil {
+			return result, nil
+		}
+	}
+
+	result, err = resolve()
+	if err != nil {
+		return result, err
+	}
+
+	// the cached value is read back as json, so the first response has the same shape as the cached ones
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return result, nil
+	}
+
+	if err := uc.cache.Set(key, resultJSON, int64(refreshInterval)); err != nil {
+		log.Printf("failed to cache data source of %v: %v", request.ViewContentCode, err)
+	}
+
+	var decoded T
+	if err := json.Unmarshal(resultJSON, &decoded); err != nil {
+		return result, nil
+	}
+
+	return decoded, nil
+}
+
+// toViewDataSource reads the data source of a layout component, only query data sources are resolved
+func toViewDataSource(value any) (dataSource entity.ViewDataSource, ok bool) {
+	dataSourceMap, ok := value.(map[string]any)
+	if !ok || dataSourceMap["type"] != entity.DataSourceTypeQuery {
+		return dataSource, false
+	}
+
+	if err := remarshal(dataSourceMap, &dataSource); err != nil {
+		return dataSource, false
+	}
+
+	return dataSource, true
+}
+
+// remarshal decodes a generic layout value into target through json, the way a request body would be bound
+func remarshal(value any, target any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, target)
+}
+
+func formatScoreCardValue(value any) string {
+	if value == nil {
+		return ""
+	}
+
+	if number, ok := toFloat(value); ok {
+		return strconv.FormatFloat(number, 'f', -1, 64)
+	}
+
+	return fmt.Sprintf("%v", value)
+}
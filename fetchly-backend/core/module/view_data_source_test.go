@@ -0,0 +1,187 @@
+package module
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+	"github.com/fetchlydev/source/fetchly-backend/core/repository"
+)
+
+// viewSchemaRepo is a view repository where every view content of an object has the view schema
+// <object>.<view content>
+type viewSchemaRepo struct {
+	repository.ViewRepository
+}
+
+func (r *viewSchemaRepo) GetViewContentByKeys(ctx context.Context, request entity.GetViewContentByKeysRequest) (resp map[string]entity.DataItem, err error) {
+	viewContentCode := request.ViewContentCode
+	if viewContentCode == "" {
+		viewContentCode = "default"
+	}
+
+	return map[string]entity.DataItem{
+		entity.VIEW_SCHEMA_SERIAL: {Value: request.ObjectCode + "." + viewContentCode},
+	}, nil
+}
+
+// viewCatalogRepo is a catalog repository with the view schemas of the deals, the won deals view shows the won
+// deals by name only. It records the queries of the object data.
+type viewCatalogRepo struct {
+	repository.CatalogRepository
+	queries []entity.CatalogQuery
+}
+
+func (r *viewCatalogRepo) GetObjectDetail(ctx context.Context, request entity.CatalogQuery) (resp map[string]entity.DataItem, err error) {
+	if request.ObjectCode != "view_schema" {
+		return map[string]entity.DataItem{}, nil
+	}
+
+	viewSchema := map[string]entity.DataItem{"serial": {Value: request.Serial}}
+	if request.Serial == "deal.won_deals" {
+		viewSchema["query"] = entity.DataItem{Value: map[string]any{"filters": []any{
+			map[string]any{"filter_item": map[string]any{"status": map[string]any{"field_code": "status", "operator": "equal", "value": "won"}}},
+		}}}
+		viewSchema["display_field"] = entity.DataItem{Value: map[string]any{"name": map[string]any{"field_code": "name"}}}
+	}
+
+	return viewSchema, nil
+}
+
+func (r *viewCatalogRepo) GetColumnList(ctx context.Context, request entity.CatalogQuery) (columns []map[string]interface{}, columnStrings string, joinQueryMap map[string]string, joinQueryOrder []string, err error) {
+	return []map[string]any{
+		{entity.FieldColumnCode: "name", entity.FieldColumnName: "name", entity.FieldDataType: "text"},
+		{entity.FieldColumnCode: "status", entity.FieldColumnName: "status", entity.FieldDataType: "text"},
+	}, "", nil, nil, nil
+}
+
+func (r *viewCatalogRepo) GetObjectByCode(ctx context.Context, objectCode, tenantCode string) (resp entity.Objects, err error) {
+	return resp, fmt.Errorf("%w: object %v", entity.ErrorNotFound, objectCode)
+}
+
+func (r *viewCatalogRepo) GetObjectData(ctx context.Context, request entity.CatalogQuery) (resp entity.CatalogResponse, err error) {
+	r.queries = append(r.queries, request)
+	return resp, nil
+}
+
+func TestRunDataSourceThroughView(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        map[string]any
+		wantObject  string
+		wantFilters []string
+		wantFields  []string
+	}{
+		{
+			name:        "object of the view",
+			body:        map[string]any{},
+			wantObject:  "deal",
+			wantFilters: []string{"status equal won"},
+			wantFields:  []string{"name"},
+		},
+		{
+			name:        "filters of the data source add to those of the view",
+			body:        map[string]any{"filters": []any{map[string]any{"filter_item": map[string]any{"amount": map[string]any{"operator": "greater_than", "value": 10}}}}},
+			wantObject:  "deal",
+			wantFilters: []string{"status equal won", "amount greater_than 10"},
+			wantFields:  []string{"name"},
+		},
+		{
+			name:       "another view of the object",
+			body:       map[string]any{"view_content_code": "all_deals"},
+			wantObject: "deal",
+		},
+		{
+			name:       "another object goes through its default view",
+			body:       map[string]any{"object_code": "contact"},
+			wantObject: "contact",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			catalogRepo := &viewCatalogRepo{}
+			catalogUc := &catalogUsecase{catalogRepo: catalogRepo, viewRepo: &viewSchemaRepo{}, accessRepo: &grantRepo{}}
+			uc := &viewUsecase{catalogUc: catalogUc}
+
+			_, err := uc.runDataSource(context.Background(), entity.ViewDataSource{Type: entity.DataSourceTypeQuery, Body: tt.body}, entity.GetViewContentByKeysRequest{
+				TenantCode:      "acme",
+				ObjectCode:      "deal",
+				ViewContentCode: "won_deals",
+			}, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(catalogRepo.queries) != 1 {
+				t.Fatalf("ran %d queries, want 1", len(catalogRepo.queries))
+			}
+
+			query := catalogRepo.queries[0]
+			if query.ObjectCode != tt.wantObject || query.TenantCode != "acme" {
+				t.Errorf("query of %v.%v, want acme.%v", query.TenantCode, query.ObjectCode, tt.wantObject)
+			}
+
+			filters := []string{}
+			for _, filterGroup := range query.Filters {
+				filterGroup.Walk(func(fieldName string, item entity.FilterItem) {
+					filters = append(filters, fmt.Sprintf("%v %v %v", fieldName, item.Operator, item.Value))
+				})
+			}
+
+			if fmt.Sprint(filters) != fmt.Sprint(tt.wantFilters) && len(filters)+len(tt.wantFilters) > 0 {
+				t.Errorf("filters %v, want %v", filters, tt.wantFilters)
+			}
+
+			fields := []string{}
+			for fieldKey := range query.Fields {
+				fields = append(fields, fieldKey)
+			}
+			sort.Strings(fields)
+
+			if fmt.Sprint(fields) != fmt.Sprint(tt.wantFields) && len(fields)+len(tt.wantFields) > 0 {
+				t.Errorf("fields %v, want %v", fields, tt.wantFields)
+			}
+		})
+	}
+}
+
+// aggregateCatalog is a catalog usecase recording the aggregate queries
+type aggregateCatalog struct {
+	CatalogUsecase
+	queries []entity.AggregateQuery
+}
+
+func (uc *aggregateCatalog) AggregateObjectData(ctx context.Context, request entity.AggregateQuery) (resp entity.AggregateResponse, err error) {
+	uc.queries = append(uc.queries, request)
+	return entity.AggregateResponse{YAxis: []string{"count"}, Rows: []map[string]any{{"count": 3}}}, nil
+}
+
+func TestResolveViewDataSources(t *testing.T) {
+	catalogUc := &aggregateCatalog{}
+	uc := &viewUsecase{catalogUc: catalogUc}
+
+	chart := map[string]any{"type": entity.TypeChart, entity.PROPS: map[string]any{
+		"dataSource": map[string]any{"type": entity.DataSourceTypeQuery, "body": map[string]any{"metrics": []any{map[string]any{"function": "count"}}}},
+	}}
+	broken := map[string]any{"type": entity.TypeChart, entity.PROPS: map[string]any{
+		"dataSource": map[string]any{"type": entity.DataSourceTypeQuery, "endpoint": "raw"},
+	}}
+	layout := map[string]any{"type": "container", "children": []any{chart, broken}}
+
+	uc.resolveViewDataSources(context.Background(), layout, entity.GetViewContentByKeysRequest{TenantCode: "acme", ObjectCode: "deal"})
+
+	if len(catalogUc.queries) != 1 || catalogUc.queries[0].TenantCode != "acme" || catalogUc.queries[0].ObjectCode != "deal" {
+		t.Fatalf("aggregate queries %+v, want one of acme.deal", catalogUc.queries)
+	}
+
+	if _, ok := chart[entity.PROPS].(map[string]any)["data"].(entity.AggregateResponse); !ok {
+		t.Errorf("chart data %v, want the aggregate", chart[entity.PROPS])
+	}
+
+	if _, ok := broken[entity.PROPS].(map[string]any)["dataError"]; !ok {
+		t.Errorf("chart of an unsupported endpoint has no data error: %v", broken[entity.PROPS])
+	}
+}
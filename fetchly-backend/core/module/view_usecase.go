@@ -11,6 +11,7 @@ import (
 	"github.com/fetchlydev/source/fetchly-backend/config"
 	"github.com/fetchlydev/source/fetchly-backend/core/entity"
 	"github.com/fetchlydev/source/fetchly-backend/core/repository"
+	"github.com/fetchlydev/source/fetchly-backend/pkg/conn"
 	"github.com/fetchlydev/source/fetchly-backend/pkg/helper"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
@@ -23,14 +24,16 @@ type ViewUsecase interface {
 
 type viewUsecase struct {
 	cfg         config.Config
+	cache       conn.CacheService
 	catalogRepo repository.CatalogRepository
 	viewRepo    repository.ViewRepository
 	catalogUc   CatalogUsecase
 }
 
-func NewViewUsecase(cfg config.Config, catalogRepo repository.CatalogRepository, viewRepo repository.ViewRepository, catalogUc CatalogUsecase) ViewUsecase {
+func NewViewUsecase(cfg config.Config, cache conn.CacheService, catalogRepo repository.CatalogRepository, viewRepo repository.ViewRepository, catalogUc CatalogUsecase) ViewUsecase {
 	return &viewUsecase{
 		cfg:         cfg,
+		cache:       cache,
 		catalogRepo: catalogRepo,
 		viewRepo:    viewRepo,
 		catalogUc:   catalogUc,
@@ -321,6 +324,13 @@ func (uc *viewUsecase) GetContentLayoutByKeys(ctx context.Context, request entit
 			return resp, err
 		}
 
+		permission, err := uc.catalogUc.GetObjectPermission(ctx, catalogQuery.TenantCode, catalogQuery.ObjectCode)
+		if err != nil {
+			return resp, err
+		}
+
+		originalFields = visibleFields(permission, originalFields)
+
 		// handle custom object fields based on object field table
 		objectFields := map[string]any{}
 		if catalogQuery.ObjectSerial != "" {
@@ -374,6 +384,10 @@ func (uc *viewUsecase) GetContentLayoutByKeys(ctx context.Context, request entit
 		return resp, err
 	}
 
+	if request.IsResolveDataSource {
+		uc.resolveViewDataSources(ctx, resp.Layout, request)
+	}
+
 	return resp, nil
 }
 
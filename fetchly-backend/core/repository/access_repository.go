@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+)
+
+type AccessRepository interface {
+	GetUserRoles(ctx context.Context, tenantCode, userSerial string) (resp []entity.Role, err error)
+	GetObjectPermissions(ctx context.Context, tenantCode, objectCode string) (resp []entity.RoleObjectPermission, err error)
+	GetFieldPermissions(ctx context.Context, tenantCode, objectCode string, roleSerials []string) (resp []entity.RoleFieldPermission, err error)
+	GetRowPolicies(ctx context.Context, tenantCode, objectCode string) (resp []entity.RowPolicy, err error)
+	GetUserAttributes(ctx context.Context, tenantCode, userSerial string) (resp map[string]any, err error)
+}
@@ -7,9 +7,15 @@ import (
 )
 
 type AuthRepository interface {
-	Login(ctx context.Context, request entity.LoginRequest) (resp entity.LoginResponse, err error)
-	Logout(ctx context.Context) error
-	RefreshToken(ctx context.Context, refreshToken string) (string, error)
-	GetCurrentUser(ctx context.Context) (string, error)
-	GetUserBySerial(ctx context.Context, userID string) (string, error)
+	GetUserByLogin(ctx context.Context, tenantCode, login string) (resp map[string]entity.DataItem, err error)
+	GetUserBySerial(ctx context.Context, tenantCode, userSerial string) (resp map[string]entity.DataItem, err error)
+
+	CreateSession(ctx context.Context, session entity.AuthSession, refreshToken entity.RefreshToken) (resp entity.AuthSession, err error)
+	GetSessionBySerial(ctx context.Context, tenantCode, sessionSerial string) (resp entity.AuthSession, err error)
+	GetUserSessions(ctx context.Context, tenantCode, userSerial string) (resp []entity.AuthSession, err error)
+	GetRefreshTokenByHash(ctx context.Context, tenantCode, tokenHash string) (resp entity.RefreshToken, err error)
+	RotateRefreshToken(ctx context.Context, current, next entity.RefreshToken) error
+	RevokeSession(ctx context.Context, tenantCode, sessionSerial, reason string) error
+	RevokeUserSessions(ctx context.Context, tenantCode, userSerial, reason string) error
+	RevokeTenantSessions(ctx context.Context, tenantCode, reason string) error
 }
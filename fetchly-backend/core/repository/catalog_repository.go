@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/fetchlydev/source/fetchly-backend/core/entity"
 )
@@ -9,13 +10,42 @@ import (
 type CatalogRepository interface {
 	GetColumnList(ctx context.Context, request entity.CatalogQuery) (columns []map[string]interface{}, columnStrings string, joinQueryMap map[string]string, joinQueryOrder []string, err error)
 	GetObjectData(ctx context.Context, request entity.CatalogQuery) (resp entity.CatalogResponse, err error)
+	StreamObjectData(ctx context.Context, request entity.CatalogQuery, batchSize int, fn func(items []map[string]entity.DataItem) error) (err error)
 	GetObjectDetail(ctx context.Context, request entity.CatalogQuery) (resp map[string]entity.DataItem, err error)
 	GetDataByRawQuery(ctx context.Context, request entity.CatalogQuery) (resp entity.CatalogResponse, err error)
+	AggregateObjectData(ctx context.Context, request entity.AggregateQuery) (resp []map[string]any, err error)
 	CreateObjectData(ctx context.Context, request entity.DataMutationRequest) (resp map[string]entity.DataItem, err error)
 	UpdateObjectData(ctx context.Context, request entity.DataMutationRequest) (resp map[string]entity.DataItem, err error)
 	DeleteObjectData(ctx context.Context, request entity.DataMutationRequest) (err error)
+	SetObjectDataDeleted(ctx context.Context, request entity.DeletionRequest) (resp []map[string]any, err error)
+	PurgeObjectData(ctx context.Context, tenantCode, objectCode string, deletedBefore time.Time) (purged int64, err error)
+	CreateAuditLogs(ctx context.Context, logs []entity.AuditLog) (err error)
+	GetAuditLogs(ctx context.Context, request entity.AuditQuery) (resp entity.AuditLogResponse, err error)
 	GetObjectFieldsByObjectCode(ctx context.Context, request entity.CatalogQuery) (resp map[string]any, err error)
 	GetObjectByCode(ctx context.Context, objectCode, tenantCode string) (resp entity.Objects, err error)
+	GetForeignKeyInfo(ctx context.Context, tableName, columnName, schemaName string) (resp entity.ForeignKeyInfo, err error)
+	GetObjectRelations(ctx context.Context, tenantCode, objectCode string) (resp map[string]entity.ObjectRelation, err error)
+	GetRelatedObjectData(ctx context.Context, request entity.RelatedQuery) (resp map[string]entity.CatalogResponse, err error)
+	GetObjectSchema(ctx context.Context, tenantCode, objectCode string) (resp entity.ObjectSchema, err error)
+	CreateObjectSchema(ctx context.Context, request entity.ObjectSchema) (err error)
+	AlterObjectSchema(ctx context.Context, request entity.ObjectSchemaChange) (err error)
+	DropObjectSchema(ctx context.Context, request entity.ObjectSchemaChange) (err error)
+	GetObjectCodes(ctx context.Context, tenantCode, moduleCode string) (resp []string, err error)
+	GetModuleByCode(ctx context.Context, moduleCode string) (resp entity.Modules, err error)
+	SetModuleVersion(ctx context.Context, moduleCode, version, userSerial string) (err error)
+	GetViewMetadata(ctx context.Context, tenantCode string, objectCodes []string) (resp entity.ViewMetadata, err error)
+	ApplyViewMetadataStep(ctx context.Context, tenantCode string, step entity.MigrationStep, userSerial string) (err error)
+	GetTenantByCode(ctx context.Context, tenantCode string) (resp entity.Tenants, err error)
+	GetTenantCodes(ctx context.Context) (resp []string, err error)
+	CreateTenant(ctx context.Context, request entity.Tenants, userSerial string) (err error)
+	CopyObjectData(ctx context.Context, sourceTenantCode, tenantCode, objectCode string) (copied int64, err error)
+	StreamObjectRows(ctx context.Context, tenantCode, objectCode string, batchSize int, fn func(rows []map[string]any) error) (err error)
+	ArchiveTenant(ctx context.Context, tenantCode, userSerial string) (err error)
+	RestoreTenant(ctx context.Context, tenantCode string) (err error)
+	DropTenantSchema(ctx context.Context, tenantCode string) (err error)
 	GetDataTypeBySerial(ctx context.Context, serial string) (resp entity.DataType, err error)
 	GetDataTypeBySerials(ctx context.Context, serials []string) (resp []entity.DataType, err error)
+	WithTransaction(ctx context.Context, tenantCode, objectCode string, fn func(txRepo CatalogRepository) error) error
+	WithMetadataTransaction(ctx context.Context, fn func(txRepo CatalogRepository) error) error
+	SchemaStatements() []entity.SchemaStatement
 }
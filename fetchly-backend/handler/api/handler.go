@@ -1,15 +1,23 @@
 package api
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/fetchlydev/source/fetchly-backend/config"
 	"github.com/fetchlydev/source/fetchly-backend/core/entity"
 	"github.com/fetchlydev/source/fetchly-backend/core/module"
 	"github.com/fetchlydev/source/fetchly-backend/pkg/helper"
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
 )
 
 type HTTPHandler interface {
@@ -22,27 +30,64 @@ type HTTPHandler interface {
 	CreateObjectData(c *gin.Context)
 	UpdateObjectData(c *gin.Context)
 	DeleteObjectData(c *gin.Context)
+	RestoreObjectData(c *gin.Context)
+	GetRecordHistory(c *gin.Context)
+	BulkMutateObjectData(c *gin.Context)
+	AggregateObjectData(c *gin.Context)
+	ImportObjectData(c *gin.Context)
 	Login(c *gin.Context)
 	RefreshToken(c *gin.Context)
 	EncryptPassword(c *gin.Context)
 	GetCurrentUser(c *gin.Context)
+	Logout(c *gin.Context)
+	GetUserSessions(c *gin.Context)
+	RevokeUserSession(c *gin.Context)
+	RevokeUserSessions(c *gin.Context)
+	GetObjectSchema(c *gin.Context)
+	CreateObjectSchema(c *gin.Context)
+	AlterObjectSchema(c *gin.Context)
+	DropObjectSchema(c *gin.Context)
+	ExportMetadataBundle(c *gin.Context)
+	PlanMigration(c *gin.Context)
+	ApplyMigration(c *gin.Context)
+	ProvisionTenant(c *gin.Context)
+	CloneTenant(c *gin.Context)
+	OffboardTenant(c *gin.Context)
+	PurgeTrash(c *gin.Context)
+	GetAuditLogs(c *gin.Context)
 	ExportObjectData(c *gin.Context)
+	StreamExportObjectData(c *gin.Context)
+	SubmitExportJob(c *gin.Context)
+	GetExportJob(c *gin.Context)
+	DownloadExportJob(c *gin.Context)
 	GoogleLogin(c *gin.Context)
 }
 
 type httpHandler struct {
-	cfg       config.Config
-	catalogUc module.CatalogUsecase
-	viewUc    module.ViewUsecase
-	authUc    module.AuthUsecase
+	cfg         config.Config
+	catalogUc   module.CatalogUsecase
+	viewUc      module.ViewUsecase
+	authUc      module.AuthUsecase
+	exportJobUc module.ExportJobUsecase
+	schemaUc    module.SchemaUsecase
+	migrationUc module.MigrationUsecase
+	tenantUc    module.TenantUsecase
+	trashUc     module.TrashUsecase
+	auditUc     module.AuditUsecase
 }
 
-func NewHTTPHandler(cfg config.Config, catalogUc module.CatalogUsecase, viewUc module.ViewUsecase, authUc module.AuthUsecase) HTTPHandler {
+func NewHTTPHandler(cfg config.Config, catalogUc module.CatalogUsecase, viewUc module.ViewUsecase, authUc module.AuthUsecase, exportJobUc module.ExportJobUsecase, schemaUc module.SchemaUsecase, migrationUc module.MigrationUsecase, tenantUc module.TenantUsecase, trashUc module.TrashUsecase, auditUc module.AuditUsecase) HTTPHandler {
 	return &httpHandler{
-		cfg:       cfg,
-		catalogUc: catalogUc,
-		viewUc:    viewUc,
-		authUc:    authUc,
+		cfg:         cfg,
+		catalogUc:   catalogUc,
+		viewUc:      viewUc,
+		authUc:      authUc,
+		exportJobUc: exportJobUc,
+		schemaUc:    schemaUc,
+		migrationUc: migrationUc,
+		tenantUc:    tenantUc,
+		trashUc:     trashUc,
+		auditUc:     auditUc,
 	}
 }
 
@@ -124,11 +169,21 @@ func (h *httpHandler) GetObjectData(c *gin.Context) {
 		return
 	}
 
+	bindQueryPath(c, &request)
+
 	response, err := h.catalogUc.GetObjectData(c, request)
 	if err != nil {
 		statusCode = http.StatusInternalServerError
 		statusMessage = err.Error()
 
+		if errors.Is(err, entity.ErrorBadRequest) {
+			statusCode = http.StatusBadRequest
+		}
+
+		if errors.Is(err, entity.ErrorForbidden) {
+			statusCode = http.StatusForbidden
+		}
+
 		log.Println(statusMessage)
 		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
 		return
@@ -142,9 +197,6 @@ func (h *httpHandler) GetObjectDetail(c *gin.Context) {
 	var statusMessage string = entity.DefaultSuccessMessage
 
 	serial := c.Param("serial")
-	objectCode := c.Param("object_code")
-	tenantCode := c.Param("tenant_code")
-	productCode := c.Param("product_code")
 
 	if serial == "" {
 		statusCode = http.StatusBadRequest
@@ -165,25 +217,22 @@ func (h *httpHandler) GetObjectDetail(c *gin.Context) {
 		return
 	}
 
+	bindQueryPath(c, &request)
 	request.Serial = serial
 
-	if tenantCode != "" {
-		request.TenantCode = tenantCode
-	}
-
-	if productCode != "" {
-		request.ProductCode = productCode
-	}
-
-	if objectCode != "" {
-		request.ObjectCode = objectCode
-	}
-
 	response, err := h.catalogUc.GetObjectDetail(c, request, serial)
 	if err != nil {
 		statusCode = http.StatusInternalServerError
 		statusMessage = err.Error()
 
+		if errors.Is(err, entity.ErrorBadRequest) {
+			statusCode = http.StatusBadRequest
+		}
+
+		if errors.Is(err, entity.ErrorForbidden) {
+			statusCode = http.StatusForbidden
+		}
+
 		log.Println(statusMessage)
 		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
 		return
@@ -206,11 +255,17 @@ func (h *httpHandler) GetDataByRawQuery(c *gin.Context) {
 		return
 	}
 
+	bindQueryPath(c, &request)
+
 	response, err := h.catalogUc.GetDataByRawQuery(c, request)
 	if err != nil {
 		statusCode = http.StatusInternalServerError
 		statusMessage = err.Error()
 
+		if errors.Is(err, entity.ErrorForbidden) {
+			statusCode = http.StatusForbidden
+		}
+
 		log.Println(statusMessage)
 		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
 		return
@@ -230,6 +285,7 @@ func (h *httpHandler) GetContentLayoutByKeys(c *gin.Context) {
 	request.ObjectCode = c.Param("object_code")
 	request.ViewContentCode = c.Param("view_content_code")
 	request.LayoutType = c.Param("layout_type")
+	request.IsResolveDataSource = c.Query("resolve_data_source") == "true"
 
 	catalogQuery := entity.CatalogQuery{
 		TenantCode:      request.TenantCode,
@@ -243,6 +299,10 @@ func (h *httpHandler) GetContentLayoutByKeys(c *gin.Context) {
 		statusCode = http.StatusInternalServerError
 		statusMessage = err.Error()
 
+		if errors.Is(err, entity.ErrorForbidden) {
+			statusCode = http.StatusForbidden
+		}
+
 		log.Println(statusMessage)
 		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
 		return
@@ -254,7 +314,6 @@ func (h *httpHandler) GetContentLayoutByKeys(c *gin.Context) {
 func (h *httpHandler) CreateObjectData(c *gin.Context) {
 	var statusCode int32 = entity.DefaultSucessCode
 	var statusMessage string = entity.DefaultSuccessMessage
-	var defaultUserSerial string = "system"
 
 	request := entity.DataMutationRequest{}
 	if err := c.ShouldBindJSON(&request); err != nil {
@@ -266,13 +325,40 @@ func (h *httpHandler) CreateObjectData(c *gin.Context) {
 		return
 	}
 
-	request.UserSerial = defaultUserSerial
+	if c.Param("tenant_code") != "" {
+		request.TenantCode = c.Param("tenant_code")
+	}
+
+	if c.Param("product_code") != "" {
+		request.ProductCode = c.Param("product_code")
+	}
+
+	if c.Param("object_code") != "" {
+		request.ObjectCode = c.Param("object_code")
+	}
 
 	response, err := h.catalogUc.CreateObjectData(c, request)
 	if err != nil {
 		statusCode = http.StatusInternalServerError
 		statusMessage = err.Error()
 
+		if errors.Is(err, entity.ErrorBadRequest) {
+			statusCode = http.StatusBadRequest
+		}
+
+		if errors.Is(err, entity.ErrorForbidden) {
+			statusCode = http.StatusForbidden
+		}
+
+		var validationErrors entity.ValidationErrors
+		if errors.As(err, &validationErrors) {
+			statusCode = http.StatusUnprocessableEntity
+
+			log.Println(statusMessage)
+			helper.ResponseOutput(c, int32(statusCode), statusMessage, validationErrors.ByField())
+			return
+		}
+
 		log.Println(statusMessage)
 		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
 		return
@@ -284,7 +370,6 @@ func (h *httpHandler) CreateObjectData(c *gin.Context) {
 func (h *httpHandler) UpdateObjectData(c *gin.Context) {
 	var statusCode int32 = entity.DefaultSucessCode
 	var statusMessage string = entity.DefaultSuccessMessage
-	var defaultUserSerial string = "system"
 
 	request := entity.DataMutationRequest{}
 	if err := c.ShouldBindJSON(&request); err != nil {
@@ -300,18 +385,45 @@ func (h *httpHandler) UpdateObjectData(c *gin.Context) {
 		request.Serial = c.Param("serial")
 	}
 
-	request.UserSerial = defaultUserSerial
+	if c.Param("tenant_code") != "" {
+		request.TenantCode = c.Param("tenant_code")
+	}
+
+	if c.Param("product_code") != "" {
+		request.ProductCode = c.Param("product_code")
+	}
+
+	if c.Param("object_code") != "" {
+		request.ObjectCode = c.Param("object_code")
+	}
 
 	response, err := h.catalogUc.UpdateObjectData(c, request)
 	if err != nil {
 		statusCode = http.StatusInternalServerError
 		statusMessage = err.Error()
 
+		if errors.Is(err, entity.ErrorForbidden) {
+			statusCode = http.StatusForbidden
+		}
+
 		if errors.Is(err, entity.ErrorNoUpdateDataFound) {
 			statusCode = http.StatusNotFound
 			statusMessage = entity.ErrorNoUpdateDataFound.Error()
 		}
 
+		if errors.Is(err, entity.ErrorNotFound) {
+			statusCode = http.StatusNotFound
+		}
+
+		var validationErrors entity.ValidationErrors
+		if errors.As(err, &validationErrors) {
+			statusCode = http.StatusUnprocessableEntity
+
+			log.Println(statusMessage)
+			helper.ResponseOutput(c, int32(statusCode), statusMessage, validationErrors.ByField())
+			return
+		}
+
 		log.Println(statusMessage)
 		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
 		return
@@ -323,7 +435,6 @@ func (h *httpHandler) UpdateObjectData(c *gin.Context) {
 func (h *httpHandler) DeleteObjectData(c *gin.Context) {
 	var statusCode int32 = entity.DefaultSucessCode
 	var statusMessage string = entity.DefaultSuccessMessage
-	var defaultUserSerial string = "system"
 
 	request := entity.DataMutationRequest{}
 
@@ -343,13 +454,21 @@ func (h *httpHandler) DeleteObjectData(c *gin.Context) {
 		request.ObjectCode = c.Param("object_code")
 	}
 
-	request.UserSerial = defaultUserSerial
+	request.IsCascade = c.Query("cascade") == "true"
 
 	err := h.catalogUc.DeleteObjectData(c, request)
 	if err != nil {
 		statusCode = http.StatusInternalServerError
 		statusMessage = err.Error()
 
+		if errors.Is(err, entity.ErrorBadRequest) {
+			statusCode = http.StatusBadRequest
+		}
+
+		if errors.Is(err, entity.ErrorForbidden) {
+			statusCode = http.StatusForbidden
+		}
+
 		if errors.Is(err, entity.ErrorNoUpdateDataFound) {
 			statusCode = http.StatusNotFound
 			statusMessage = entity.ErrorNoUpdateDataFound.Error()
@@ -363,87 +482,99 @@ func (h *httpHandler) DeleteObjectData(c *gin.Context) {
 	helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
 }
 
-func (h *httpHandler) Login(c *gin.Context) {
+func (h *httpHandler) RestoreObjectData(c *gin.Context) {
 	var statusCode int32 = entity.DefaultSucessCode
 	var statusMessage string = entity.DefaultSuccessMessage
 
-	request := entity.LoginRequest{}
-	if err := c.ShouldBindJSON(&request); err != nil {
-		statusCode = http.StatusBadRequest
-		statusMessage = err.Error()
+	request := entity.DataMutationRequest{}
 
-		log.Println(statusMessage)
-		helper.ResponseOutput(c, statusCode, statusMessage, nil)
-		return
+	if c.Param("serial") != "" {
+		request.Serial = c.Param("serial")
 	}
 
-	if c.Param(entity.TENANT_CODE) != "" {
-		request.TenantCode = c.Param(entity.TENANT_CODE)
+	if c.Param("tenant_code") != "" {
+		request.TenantCode = c.Param("tenant_code")
 	}
 
-	if c.Param(entity.PRODUCT_CODE) != "" {
-		request.ProductCode = c.Param(entity.PRODUCT_CODE)
+	if c.Param("product_code") != "" {
+		request.ProductCode = c.Param("product_code")
 	}
 
-	response, err := h.authUc.Login(c, request)
+	if c.Param("object_code") != "" {
+		request.ObjectCode = c.Param("object_code")
+	}
+
+	request.IsCascade = c.Query("cascade") == "true"
+
+	err := h.catalogUc.RestoreObjectData(c, request)
 	if err != nil {
 		statusCode = http.StatusInternalServerError
 		statusMessage = err.Error()
 
+		if errors.Is(err, entity.ErrorBadRequest) {
+			statusCode = http.StatusBadRequest
+		}
+
+		if errors.Is(err, entity.ErrorForbidden) {
+			statusCode = http.StatusForbidden
+		}
+
+		if errors.Is(err, entity.ErrorNoUpdateDataFound) {
+			statusCode = http.StatusNotFound
+			statusMessage = entity.ErrorNoUpdateDataFound.Error()
+		}
+
 		log.Println(statusMessage)
 		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
 		return
 	}
 
-	helper.ResponseOutput(c, int32(statusCode), statusMessage, response)
+	helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
 }
 
-func (h *httpHandler) RefreshToken(c *gin.Context) {
+func (h *httpHandler) GetRecordHistory(c *gin.Context) {
 	var statusCode int32 = entity.DefaultSucessCode
 	var statusMessage string = entity.DefaultSuccessMessage
 
-	request := entity.RefreshTokenRequest{}
-	accessToken := c.Request.Header["Authorization"]
-
-	if len(accessToken) < 1 {
-		statusCode = http.StatusBadRequest
-		statusMessage = "empty authorization token"
-
-		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
-		return
-	}
-
-	tenantCode := c.Param(entity.TENANT_CODE)
-	if tenantCode == "" {
+	request, err := bindAuditQuery(c)
+	if err != nil {
 		statusCode = http.StatusBadRequest
-		statusMessage = entity.ErrorSerialEmpty.Error()
+		statusMessage = err.Error()
 
-		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
 		return
 	}
 
-	request.RefreshToken = accessToken[0]
-	request.TenantCode = tenantCode
+	request.ObjectCode = c.Param("object_code")
+	request.RecordSerial = c.Param("serial")
 
-	response, err := h.authUc.RefreshToken(c, request.TenantCode, request.RefreshToken)
+	response, err := h.auditUc.GetRecordHistory(c, request)
 	if err != nil {
-		statusCode = http.StatusInternalServerError
+		statusCode = schemaErrorStatusCode(err)
 		statusMessage = err.Error()
 
+		if errors.Is(err, entity.ErrorSerialEmpty) {
+			statusCode = http.StatusBadRequest
+		}
+
+		if errors.Is(err, entity.ErrorForbidden) {
+			statusCode = http.StatusForbidden
+		}
+
 		log.Println(statusMessage)
-		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
 		return
 	}
 
-	helper.ResponseOutput(c, int32(statusCode), statusMessage, response)
+	helper.ResponseOutput(c, statusCode, statusMessage, response)
 }
 
-func (h *httpHandler) EncryptPassword(c *gin.Context) {
+func (h *httpHandler) BulkMutateObjectData(c *gin.Context) {
 	var statusCode int32 = entity.DefaultSucessCode
 	var statusMessage string = entity.DefaultSuccessMessage
 
-	request := entity.EncryptPasswordRequest{}
-
+	request := entity.BulkMutationRequest{}
 	if err := c.ShouldBindJSON(&request); err != nil {
 		statusCode = http.StatusBadRequest
 		statusMessage = err.Error()
@@ -453,115 +584,101 @@ func (h *httpHandler) EncryptPassword(c *gin.Context) {
 		return
 	}
 
-	if c.Param(entity.TENANT_CODE) != "" {
-		request.TenantCode = c.Param(entity.TENANT_CODE)
+	if c.Param("tenant_code") != "" {
+		request.TenantCode = c.Param("tenant_code")
 	}
 
-	cipherTextB64, saltB64, ivB64, err := h.authUc.EncryptPassword(c, request.TenantCode, request.PlainPassword)
+	if c.Param("product_code") != "" {
+		request.ProductCode = c.Param("product_code")
+	}
+
+	if c.Param("object_code") != "" {
+		request.ObjectCode = c.Param("object_code")
+	}
+
+	response, err := h.catalogUc.BulkMutateObjectData(c, request)
 	if err != nil {
 		statusCode = http.StatusInternalServerError
 		statusMessage = err.Error()
 
+		if errors.Is(err, entity.ErrorForbidden) {
+			statusCode = http.StatusForbidden
+		}
+
+		if errors.Is(err, entity.ErrorBadRequest) {
+			statusCode = http.StatusBadRequest
+		}
+
+		// the batch is rolled back, return the per item results so the failing rows can be fixed
+		if errors.Is(err, entity.ErrorBulkRolledBack) {
+			statusCode = http.StatusUnprocessableEntity
+
+			log.Println(statusMessage)
+			helper.ResponseOutput(c, int32(statusCode), statusMessage, response)
+			return
+		}
+
 		log.Println(statusMessage)
 		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
 		return
 	}
 
-	response := entity.EncryptPasswordResponse{
-		CipherText: cipherTextB64,
-		Salt:       saltB64,
-		Iv:         ivB64,
-	}
-
 	helper.ResponseOutput(c, int32(statusCode), statusMessage, response)
 }
 
-func (h *httpHandler) GetCurrentUser(c *gin.Context) {
-	var statusCode int = http.StatusOK
-	var statusMessage string = ""
-	accessToken := c.Request.Header["Authorization"]
+func (h *httpHandler) AggregateObjectData(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
 
-	if len(accessToken) < 1 {
+	request := entity.AggregateQuery{}
+	if err := c.ShouldBindJSON(&request); err != nil {
 		statusCode = http.StatusBadRequest
-		statusMessage = "empty authorization token"
+		statusMessage = err.Error()
 
-		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
 		return
 	}
 
-	tenantCode := c.Param(entity.TENANT_CODE)
-	if tenantCode == "" {
-		statusCode = http.StatusBadRequest
-		statusMessage = entity.ErrorSerialEmpty.Error()
+	if c.Param("tenant_code") != "" {
+		request.TenantCode = c.Param("tenant_code")
+	}
 
-		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
-		return
+	if c.Param("product_code") != "" {
+		request.ProductCode = c.Param("product_code")
 	}
 
-	resp, err := h.authUc.GetCurrentUser(c, tenantCode, accessToken[0])
+	if c.Param("object_code") != "" {
+		request.ObjectCode = c.Param("object_code")
+	}
+
+	response, err := h.catalogUc.AggregateObjectData(c, request)
 	if err != nil {
 		statusCode = http.StatusInternalServerError
 		statusMessage = err.Error()
 
-		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
-		return
-	}
-
-	helper.ResponseOutput(c, int32(statusCode), statusMessage, resp)
-}
-
-func (h *httpHandler) ExportObjectData(c *gin.Context) {
-	var request entity.CatalogQuery
-	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"code":    http.StatusBadRequest,
-			"message": err.Error(),
-		})
-		return
-	}
-
-	// Get format from query parameter, default to XLSX
-	format := entity.ExportFormatXLSX
-	if formatStr := c.Query("format"); formatStr != "" {
-		format = entity.ExportFormat(formatStr)
-		if format != entity.ExportFormatXLSX && format != entity.ExportFormatCSV {
-			c.JSON(http.StatusBadRequest, gin.H{
-				"code":    http.StatusBadRequest,
-				"message": "unsupported export format",
-			})
-			return
+		if errors.Is(err, entity.ErrorForbidden) {
+			statusCode = http.StatusForbidden
 		}
-	}
 
-	// Get is_include_metadata parameter, default to false
-	isIncludeMetadata := false
-	if includeMetadata := c.Query("is_include_metadata"); includeMetadata != "" {
-		isIncludeMetadata = includeMetadata == "true"
-	}
+		if errors.Is(err, entity.ErrorBadRequest) {
+			statusCode = http.StatusBadRequest
+		}
 
-	// Export data
-	response, err := h.catalogUc.ExportObjectData(c.Request.Context(), request, format, isIncludeMetadata)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"code":    http.StatusInternalServerError,
-			"message": err.Error(),
-		})
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"code":    http.StatusOK,
-		"message": "success",
-		"data":    response,
-	})
+	helper.ResponseOutput(c, int32(statusCode), statusMessage, response)
 }
 
-func (h *httpHandler) GoogleLogin(c *gin.Context) {
+func (h *httpHandler) ImportObjectData(c *gin.Context) {
 	var statusCode int32 = entity.DefaultSucessCode
 	var statusMessage string = entity.DefaultSuccessMessage
 
-	request := entity.GoogleLoginRequest{}
-	if err := c.ShouldBindJSON(&request); err != nil {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
 		statusCode = http.StatusBadRequest
 		statusMessage = err.Error()
 
@@ -570,27 +687,1065 @@ func (h *httpHandler) GoogleLogin(c *gin.Context) {
 		return
 	}
 
-	tenantCode := c.Param(entity.TENANT_CODE)
-	if tenantCode == "" {
-		statusCode = http.StatusBadRequest
-		statusMessage = entity.ErrorSerialEmpty.Error()
-
-		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
-		return
+	// format comes from the query parameter, or from the file extension
+	format := entity.ExportFormat(strings.ToLower(c.Query("format")))
+	if format == "" {
+		format = entity.ExportFormat(strings.ToLower(strings.TrimPrefix(filepath.Ext(fileHeader.Filename), ".")))
 	}
 
-	productCode := c.Param(entity.PRODUCT_CODE)
-	if productCode == "" {
+	if format != entity.ExportFormatXLSX && format != entity.ExportFormatCSV {
 		statusCode = http.StatusBadRequest
-		statusMessage = entity.ErrorSerialEmpty.Error()
+		statusMessage = "unsupported import format"
 
-		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
 		return
 	}
 
-	response, err := h.authUc.GoogleLogin(c, tenantCode, productCode, request.Credential)
+	file, err := fileHeader.Open()
 	if err != nil {
-		statusCode = http.StatusInternalServerError
+		statusCode = http.StatusBadRequest
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+	defer file.Close()
+
+	request := entity.ImportRequest{
+		Format:      format,
+		Mode:        entity.BulkMode(c.Query("mode")),
+		IsDryRun:    c.Query("dry_run") == "true",
+		TenantCode:  c.Param("tenant_code"),
+		ProductCode: c.Param("product_code"),
+		ObjectCode:  c.Param("object_code"),
+	}
+
+	response, err := h.catalogUc.ImportObjectData(c, request, file, fileHeader.Size)
+	if err != nil {
+		statusCode = http.StatusInternalServerError
+		statusMessage = err.Error()
+
+		if errors.Is(err, entity.ErrorForbidden) {
+			statusCode = http.StatusForbidden
+		}
+
+		if errors.Is(err, entity.ErrorBadRequest) {
+			statusCode = http.StatusBadRequest
+		}
+
+		// nothing is imported, return the report so the file can be fixed
+		if errors.Is(err, entity.ErrorBulkRolledBack) {
+			statusCode = http.StatusUnprocessableEntity
+
+			log.Println(statusMessage)
+			helper.ResponseOutput(c, int32(statusCode), statusMessage, response)
+			return
+		}
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, int32(statusCode), statusMessage, response)
+}
+
+func (h *httpHandler) Login(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	request := entity.LoginRequest{}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		statusCode = http.StatusBadRequest
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	if c.Param(entity.TENANT_CODE) != "" {
+		request.TenantCode = c.Param(entity.TENANT_CODE)
+	}
+
+	if c.Param(entity.PRODUCT_CODE) != "" {
+		request.ProductCode = c.Param(entity.PRODUCT_CODE)
+	}
+
+	request.Client = sessionClient(c)
+
+	response, err := h.authUc.Login(c, request)
+	if err != nil {
+		statusCode = authErrorStatusCode(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, int32(statusCode), statusMessage, response)
+}
+
+func (h *httpHandler) RefreshToken(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	request := entity.RefreshTokenRequest{}
+	accessToken := c.Request.Header["Authorization"]
+
+	if len(accessToken) < 1 {
+		statusCode = http.StatusBadRequest
+		statusMessage = "empty authorization token"
+
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+
+	tenantCode := c.Param(entity.TENANT_CODE)
+	if tenantCode == "" {
+		statusCode = http.StatusBadRequest
+		statusMessage = entity.ErrorSerialEmpty.Error()
+
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+
+	request.RefreshToken = accessToken[0]
+	request.TenantCode = tenantCode
+
+	response, err := h.authUc.RefreshToken(c, request.TenantCode, request.RefreshToken)
+	if err != nil {
+		statusCode = authErrorStatusCode(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, int32(statusCode), statusMessage, response)
+}
+
+func (h *httpHandler) EncryptPassword(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	request := entity.EncryptPasswordRequest{}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		statusCode = http.StatusBadRequest
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	if c.Param(entity.TENANT_CODE) != "" {
+		request.TenantCode = c.Param(entity.TENANT_CODE)
+	}
+
+	cipherTextB64, saltB64, ivB64, err := h.authUc.EncryptPassword(c, request.TenantCode, request.PlainPassword)
+	if err != nil {
+		statusCode = http.StatusInternalServerError
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+
+	response := entity.EncryptPasswordResponse{
+		CipherText: cipherTextB64,
+		Salt:       saltB64,
+		Iv:         ivB64,
+	}
+
+	helper.ResponseOutput(c, int32(statusCode), statusMessage, response)
+}
+
+func (h *httpHandler) GetCurrentUser(c *gin.Context) {
+	var statusCode int = http.StatusOK
+	var statusMessage string = ""
+	accessToken := c.Request.Header["Authorization"]
+
+	if len(accessToken) < 1 {
+		statusCode = http.StatusBadRequest
+		statusMessage = "empty authorization token"
+
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+
+	tenantCode := c.Param(entity.TENANT_CODE)
+	if tenantCode == "" {
+		statusCode = http.StatusBadRequest
+		statusMessage = entity.ErrorSerialEmpty.Error()
+
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+
+	resp, err := h.authUc.GetCurrentUser(c, tenantCode, accessToken[0])
+	if err != nil {
+		statusCode = int(authErrorStatusCode(err))
+		statusMessage = err.Error()
+
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, int32(statusCode), statusMessage, resp)
+}
+
+func (h *httpHandler) Logout(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	accessToken := c.GetHeader("Authorization")
+	if accessToken == "" {
+		statusCode = http.StatusBadRequest
+		statusMessage = "empty authorization token"
+
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	tenantCode := c.Param(entity.TENANT_CODE)
+	if tenantCode == "" {
+		statusCode = http.StatusBadRequest
+		statusMessage = entity.ErrorSerialEmpty.Error()
+
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	// the body is optional, an empty one logs out the current session only
+	request := entity.LogoutRequest{}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			statusCode = http.StatusBadRequest
+			statusMessage = err.Error()
+
+			log.Println(statusMessage)
+			helper.ResponseOutput(c, statusCode, statusMessage, nil)
+			return
+		}
+	}
+
+	if err := h.authUc.Logout(c, tenantCode, accessToken, request); err != nil {
+		statusCode = authErrorStatusCode(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, statusCode, statusMessage, nil)
+}
+
+func (h *httpHandler) GetUserSessions(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	tenantCode := c.Param(entity.TENANT_CODE)
+	userSerial := c.Param("user_serial")
+	if tenantCode == "" || userSerial == "" {
+		statusCode = http.StatusBadRequest
+		statusMessage = entity.ErrorSerialEmpty.Error()
+
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	response, err := h.authUc.GetUserSessions(c, tenantCode, userSerial)
+	if err != nil {
+		statusCode = http.StatusInternalServerError
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, statusCode, statusMessage, response)
+}
+
+func (h *httpHandler) RevokeUserSession(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	tenantCode := c.Param(entity.TENANT_CODE)
+	userSerial := c.Param("user_serial")
+	sessionSerial := c.Param("session_serial")
+	if tenantCode == "" || userSerial == "" || sessionSerial == "" {
+		statusCode = http.StatusBadRequest
+		statusMessage = entity.ErrorSerialEmpty.Error()
+
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	if err := h.authUc.RevokeUserSession(c, tenantCode, userSerial, sessionSerial); err != nil {
+		statusCode = http.StatusInternalServerError
+		if errors.Is(err, entity.ErrSessionNotFound) {
+			statusCode = http.StatusNotFound
+		}
+
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, statusCode, statusMessage, nil)
+}
+
+func (h *httpHandler) RevokeUserSessions(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	tenantCode := c.Param(entity.TENANT_CODE)
+	userSerial := c.Param("user_serial")
+	if tenantCode == "" || userSerial == "" {
+		statusCode = http.StatusBadRequest
+		statusMessage = entity.ErrorSerialEmpty.Error()
+
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	if err := h.authUc.RevokeUserSessions(c, tenantCode, userSerial); err != nil {
+		statusCode = http.StatusInternalServerError
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, statusCode, statusMessage, nil)
+}
+
+func (h *httpHandler) GetObjectSchema(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	tenantCode := c.Param(entity.TENANT_CODE)
+	objectCode := c.Param(entity.OBJECT_CODE)
+	if tenantCode == "" || objectCode == "" {
+		statusCode = http.StatusBadRequest
+		statusMessage = entity.ErrorSerialEmpty.Error()
+
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	response, err := h.schemaUc.GetObjectSchema(c, tenantCode, objectCode)
+	if err != nil {
+		statusCode = schemaErrorStatusCode(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, statusCode, statusMessage, response)
+}
+
+func (h *httpHandler) CreateObjectSchema(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	request := entity.ObjectSchema{}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		statusCode = http.StatusBadRequest
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	request.TenantCode = c.Param(entity.TENANT_CODE)
+
+	response, err := h.schemaUc.CreateObjectSchema(c, request)
+	if err != nil {
+		statusCode = schemaErrorStatusCode(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, statusCode, statusMessage, response)
+}
+
+func (h *httpHandler) AlterObjectSchema(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	request := entity.ObjectSchemaChange{}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		statusCode = http.StatusBadRequest
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	request.TenantCode = c.Param(entity.TENANT_CODE)
+	request.ObjectCode = c.Param(entity.OBJECT_CODE)
+
+	response, err := h.schemaUc.AlterObjectSchema(c, request)
+	if err != nil {
+		statusCode = schemaErrorStatusCode(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, statusCode, statusMessage, response)
+}
+
+func (h *httpHandler) DropObjectSchema(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	request := entity.ObjectSchemaChange{
+		TenantCode: c.Param(entity.TENANT_CODE),
+		ObjectCode: c.Param(entity.OBJECT_CODE),
+	}
+
+	if err := h.schemaUc.DropObjectSchema(c, request); err != nil {
+		statusCode = schemaErrorStatusCode(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, statusCode, statusMessage, nil)
+}
+
+func (h *httpHandler) ExportMetadataBundle(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	request := entity.MetadataExportRequest{
+		TenantCode: c.Param(entity.TENANT_CODE),
+		ModuleCode: c.Query("module_code"),
+		Version:    c.Query("version"),
+	}
+
+	format := entity.MetadataBundleFormat(strings.ToLower(c.Query("format")))
+	if format != "" && format != entity.MetadataBundleFormatJSON && format != entity.MetadataBundleFormatYAML {
+		statusCode = http.StatusBadRequest
+		statusMessage = "unsupported bundle format"
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	response, err := h.migrationUc.ExportMetadataBundle(c, request)
+	if err != nil {
+		statusCode = schemaErrorStatusCode(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	// without a format the bundle is returned like any other response, with one it is downloaded as a file
+	if format == "" {
+		helper.ResponseOutput(c, statusCode, statusMessage, response)
+		return
+	}
+
+	contentType := "application/json"
+	data, err := json.MarshalIndent(response, "", "  ")
+	if format == entity.MetadataBundleFormatYAML {
+		contentType = "application/yaml"
+		data, err = helper.MarshalYAML(response)
+	}
+
+	if err != nil {
+		statusCode = http.StatusInternalServerError
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	fileName := strings.Join([]string{response.TenantCode, response.ModuleCode, response.Version}, "-")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", strings.ReplaceAll(fileName, "--", "-")+"."+string(format)))
+	c.Data(http.StatusOK, contentType, data)
+}
+
+func (h *httpHandler) PlanMigration(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	request, err := bindMigrationRequest(c)
+	if err != nil {
+		statusCode = http.StatusBadRequest
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	response, err := h.migrationUc.PlanMigration(c, request)
+	if err != nil {
+		statusCode = schemaErrorStatusCode(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, statusCode, statusMessage, response)
+}
+
+func (h *httpHandler) ApplyMigration(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	request, err := bindMigrationRequest(c)
+	if err != nil {
+		statusCode = http.StatusBadRequest
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	response, err := h.migrationUc.ApplyMigration(c, request)
+	if err != nil {
+		statusCode = schemaErrorStatusCode(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, statusCode, statusMessage, response)
+}
+
+func (h *httpHandler) ProvisionTenant(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	request := entity.TenantProvisionRequest{}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		statusCode = http.StatusBadRequest
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	request.TenantCode = c.Param(entity.TENANT_CODE)
+	request.IsDryRun = request.IsDryRun || c.Query("dry_run") == "true"
+
+	response, err := h.tenantUc.ProvisionTenant(c, request)
+	if err != nil {
+		statusCode = schemaErrorStatusCode(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, statusCode, statusMessage, response)
+}
+
+func (h *httpHandler) CloneTenant(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	request := entity.TenantCloneRequest{}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		statusCode = http.StatusBadRequest
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	request.TenantCode = c.Param(entity.TENANT_CODE)
+	request.IsDryRun = request.IsDryRun || c.Query("dry_run") == "true"
+
+	response, err := h.tenantUc.CloneTenant(c, request)
+	if err != nil {
+		statusCode = schemaErrorStatusCode(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, statusCode, statusMessage, response)
+}
+
+func (h *httpHandler) OffboardTenant(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	request := entity.TenantOffboardRequest{
+		TenantCode:      c.Param(entity.TENANT_CODE),
+		IsSchemaDropped: c.Query("drop_schema") == "true",
+	}
+
+	response, err := h.tenantUc.OffboardTenant(c, request)
+	if err != nil {
+		statusCode = schemaErrorStatusCode(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, statusCode, statusMessage, response)
+}
+
+func (h *httpHandler) PurgeTrash(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	response, err := h.trashUc.PurgeTrash(c, c.Param(entity.TENANT_CODE))
+	if err != nil {
+		statusCode = schemaErrorStatusCode(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, statusCode, statusMessage, response)
+}
+
+func (h *httpHandler) GetAuditLogs(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	request, err := bindAuditQuery(c)
+	if err != nil {
+		statusCode = http.StatusBadRequest
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	response, err := h.auditUc.GetAuditLogs(c, request)
+	if err != nil {
+		statusCode = schemaErrorStatusCode(err)
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, statusCode, statusMessage, response)
+}
+
+// bindQueryPath sets the tenant, product, object and view content of a query from the path whatever the body says,
+// the user is only authenticated against the tenant of the path
+func bindQueryPath(c *gin.Context, request *entity.CatalogQuery) {
+	request.TenantCode = c.Param(entity.TENANT_CODE)
+	request.ProductCode = c.Param(entity.PRODUCT_CODE)
+	request.ObjectCode = c.Param(entity.OBJECT_CODE)
+	request.ViewContentCode = c.Param("view_content_code")
+}
+
+// bindAuditQuery reads the filters of the audit logs from the query parameters, from and to as RFC 3339 times
+func bindAuditQuery(c *gin.Context) (entity.AuditQuery, error) {
+	request := entity.AuditQuery{
+		TenantCode:   c.Param(entity.TENANT_CODE),
+		ObjectCode:   c.Query("object_code"),
+		RecordSerial: c.Query("record_serial"),
+		UserSerial:   c.Query("user_serial"),
+		RequestID:    c.Query("request_id"),
+		Action:       entity.AuditAction(c.Query("action")),
+	}
+
+	for name, page := range map[string]*int{"page": &request.Page, "page_size": &request.PageSize} {
+		if c.Query(name) == "" {
+			continue
+		}
+
+		value, err := strconv.Atoi(c.Query(name))
+		if err != nil {
+			return request, fmt.Errorf("%v is not a number", name)
+		}
+
+		*page = value
+	}
+
+	for name, at := range map[string]**time.Time{"from": &request.From, "to": &request.To} {
+		if c.Query(name) == "" {
+			continue
+		}
+
+		value, err := time.Parse(time.RFC3339, c.Query(name))
+		if err != nil {
+			return request, fmt.Errorf("%v is not an RFC 3339 time", name)
+		}
+
+		*at = &value
+	}
+
+	return request, nil
+}
+
+// bindMigrationRequest reads the bundle of a migration from the body, YAML when the format query parameter or the
+// content type says so and JSON otherwise, and the options from the query parameters
+func bindMigrationRequest(c *gin.Context) (entity.MigrationRequest, error) {
+	request := entity.MigrationRequest{
+		TenantCode: c.Param(entity.TENANT_CODE),
+		IsPruning:  c.Query("prune") == "true",
+		IsForced:   c.Query("force") == "true",
+		IsDryRun:   c.Query("dry_run") == "true",
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return request, err
+	}
+
+	format := entity.MetadataBundleFormat(strings.ToLower(c.Query("format")))
+	if format == "" && strings.Contains(c.ContentType(), "yaml") {
+		format = entity.MetadataBundleFormatYAML
+	}
+
+	if format == entity.MetadataBundleFormatYAML {
+		err = helper.UnmarshalYAML(body, &request.Bundle)
+	} else {
+		err = json.Unmarshal(body, &request.Bundle)
+	}
+
+	return request, err
+}
+
+// schemaErrorStatusCode maps the errors of a schema change, a refused change is a bad request
+func schemaErrorStatusCode(err error) int32 {
+	if errors.Is(err, entity.ErrorBadRequest) {
+		return http.StatusBadRequest
+	}
+
+	if errors.Is(err, entity.ErrorNotFound) {
+		return http.StatusNotFound
+	}
+
+	return http.StatusInternalServerError
+}
+
+// sessionClient describes the client of the request for the session it opens
+func sessionClient(c *gin.Context) entity.SessionClient {
+	return entity.SessionClient{
+		UserAgent: c.Request.UserAgent(),
+		IPAddress: c.ClientIP(),
+	}
+}
+
+// authErrorStatusCode maps the credential and session errors to 401, anything else is a server error
+func authErrorStatusCode(err error) int32 {
+	for _, authErr := range []error{
+		entity.ErrUserNotFound,
+		entity.ErrInvalidUsername,
+		entity.ErrInvalidPassword,
+		entity.ErrInvalidRefreshToken,
+		entity.ErrRefreshTokenExpired,
+		entity.ErrRefreshTokenReused,
+		entity.ErrSessionNotFound,
+		entity.ErrSessionRevoked,
+		jwt.ErrTokenMalformed,
+		jwt.ErrTokenExpired,
+		jwt.ErrTokenSignatureInvalid,
+	} {
+		if errors.Is(err, authErr) {
+			return http.StatusUnauthorized
+		}
+	}
+
+	return http.StatusInternalServerError
+}
+
+func (h *httpHandler) ExportObjectData(c *gin.Context) {
+	var request entity.CatalogQuery
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"code":    http.StatusBadRequest,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	bindQueryPath(c, &request)
+
+	// Get format from query parameter, default to XLSX
+	format := entity.ExportFormatXLSX
+	if formatStr := c.Query("format"); formatStr != "" {
+		format = entity.ExportFormat(formatStr)
+		if format != entity.ExportFormatXLSX && format != entity.ExportFormatCSV {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"code":    http.StatusBadRequest,
+				"message": "unsupported export format",
+			})
+			return
+		}
+	}
+
+	// Get is_include_metadata parameter, default to false
+	isIncludeMetadata := false
+	if includeMetadata := c.Query("is_include_metadata"); includeMetadata != "" {
+		isIncludeMetadata = includeMetadata == "true"
+	}
+
+	// Export data
+	response, err := h.catalogUc.ExportObjectData(c.Request.Context(), request, format, isIncludeMetadata)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, entity.ErrorForbidden) {
+			statusCode = http.StatusForbidden
+		}
+
+		c.JSON(statusCode, gin.H{
+			"code":    statusCode,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":    http.StatusOK,
+		"message": "success",
+		"data":    response,
+	})
+}
+
+func (h *httpHandler) StreamExportObjectData(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	request := entity.CatalogQuery{}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		statusCode = http.StatusBadRequest
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	bindQueryPath(c, &request)
+
+	format := entity.ExportFormatCSV
+	if formatStr := c.Query("format"); formatStr != "" {
+		format = entity.ExportFormat(formatStr)
+	}
+
+	contentType, ok := entity.ExportContentTypes[format]
+	if !ok {
+		statusCode = http.StatusBadRequest
+		statusMessage = "unsupported export format"
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	isIncludeMetadata := c.Query("is_include_metadata") == "true"
+
+	// headers are only sent with the first byte, so errors raised before that can still be reported as json
+	writer := &streamResponseWriter{
+		context:     c,
+		contentType: contentType,
+		fileName:    module.ExportFileName(request.ObjectCode, format),
+	}
+
+	err := h.catalogUc.StreamExportObjectData(c.Request.Context(), request, format, isIncludeMetadata, writer, nil)
+	if err != nil {
+		statusCode = http.StatusInternalServerError
+		statusMessage = err.Error()
+
+		if errors.Is(err, entity.ErrorForbidden) {
+			statusCode = http.StatusForbidden
+		}
+
+		log.Println(statusMessage)
+		if writer.isStarted {
+			// the response is already partially written, abort the connection so the client sees a broken download
+			c.Abort()
+			return
+		}
+
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+
+	writer.start()
+}
+
+func (h *httpHandler) SubmitExportJob(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	request := entity.ExportJobRequest{}
+	if err := c.ShouldBindJSON(&request.Query); err != nil {
+		statusCode = http.StatusBadRequest
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	bindQueryPath(c, &request.Query)
+	request.Format = entity.ExportFormat(c.Query("format"))
+	request.IsIncludeMetadata = c.Query("is_include_metadata") == "true"
+
+	response, err := h.exportJobUc.SubmitExportJob(c, request)
+	if err != nil {
+		statusCode = http.StatusInternalServerError
+		statusMessage = err.Error()
+
+		if errors.Is(err, entity.ErrorForbidden) {
+			statusCode = http.StatusForbidden
+		}
+
+		if errors.Is(err, entity.ErrorBadRequest) {
+			statusCode = http.StatusBadRequest
+		}
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, int32(statusCode), statusMessage, withDownloadURL(c, response))
+}
+
+func (h *httpHandler) GetExportJob(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	response, err := h.exportJobUc.GetExportJob(c, c.Param("tenant_code"), c.Param("job_id"))
+	if err != nil {
+		statusCode = http.StatusInternalServerError
+		statusMessage = err.Error()
+
+		if errors.Is(err, entity.ErrorExportJobNotFound) {
+			statusCode = http.StatusNotFound
+		}
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+
+	helper.ResponseOutput(c, int32(statusCode), statusMessage, withDownloadURL(c, response))
+}
+
+func (h *httpHandler) DownloadExportJob(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	job, file, err := h.exportJobUc.OpenExportJobFile(c, c.Param("tenant_code"), c.Param("job_id"))
+	if err != nil {
+		statusCode = http.StatusInternalServerError
+		statusMessage = err.Error()
+
+		if errors.Is(err, entity.ErrorExportJobNotFound) {
+			statusCode = http.StatusNotFound
+		}
+
+		if errors.Is(err, entity.ErrorExportJobNotReady) {
+			statusCode = http.StatusConflict
+		}
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+	defer file.Close()
+
+	c.DataFromReader(http.StatusOK, job.FileSize, job.ContentType, file, map[string]string{
+		"Content-Disposition": fmt.Sprintf("attachment; filename=%q", job.FileName),
+	})
+}
+
+// withDownloadURL sets the download link of a completed job, relative to the tenant product path
+func withDownloadURL(c *gin.Context, job entity.ExportJob) entity.ExportJob {
+	if job.Status == entity.ExportJobStatusCompleted {
+		job.DownloadURL = fmt.Sprintf("/t/%s/p/%s/export-jobs/%s/download", job.TenantCode, c.Param("product_code"), job.ID)
+	}
+
+	return job
+}
+
+func (h *httpHandler) GoogleLogin(c *gin.Context) {
+	var statusCode int32 = entity.DefaultSucessCode
+	var statusMessage string = entity.DefaultSuccessMessage
+
+	request := entity.GoogleLoginRequest{}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		statusCode = http.StatusBadRequest
+		statusMessage = err.Error()
+
+		log.Println(statusMessage)
+		helper.ResponseOutput(c, statusCode, statusMessage, nil)
+		return
+	}
+
+	tenantCode := c.Param(entity.TENANT_CODE)
+	if tenantCode == "" {
+		statusCode = http.StatusBadRequest
+		statusMessage = entity.ErrorSerialEmpty.Error()
+
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+
+	productCode := c.Param(entity.PRODUCT_CODE)
+	if productCode == "" {
+		statusCode = http.StatusBadRequest
+		statusMessage = entity.ErrorSerialEmpty.Error()
+
+		helper.ResponseOutput(c, int32(statusCode), statusMessage, nil)
+		return
+	}
+
+	response, err := h.authUc.GoogleLogin(c, tenantCode, productCode, request.Credential, sessionClient(c))
+	if err != nil {
+		statusCode = authErrorStatusCode(err)
 		statusMessage = err.Error()
 
 		log.Println(statusMessage)
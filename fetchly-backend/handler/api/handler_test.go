@@ -0,0 +1,87 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+	"github.com/fetchlydev/source/fetchly-backend/core/module"
+	"github.com/gin-gonic/gin"
+)
+
+// queryRecorder is a catalog usecase recording the queries it is given
+type queryRecorder struct {
+	module.CatalogUsecase
+	queries []entity.CatalogQuery
+}
+
+func (r *queryRecorder) GetObjectData(ctx context.Context, request entity.CatalogQuery) (resp entity.CatalogResponse, err error) {
+	r.queries = append(r.queries, request)
+	return resp, nil
+}
+
+func (r *queryRecorder) GetObjectDetail(ctx context.Context, request entity.CatalogQuery, serial string) (resp map[string]entity.DataItem, err error) {
+	r.queries = append(r.queries, request)
+	return resp, nil
+}
+
+func (r *queryRecorder) ExportObjectData(ctx context.Context, request entity.CatalogQuery, format entity.ExportFormat, isIncludeMetadata bool) (resp entity.ExportResponse, err error) {
+	r.queries = append(r.queries, request)
+	return resp, nil
+}
+
+func TestReadHandlersTakeCodesFromPath(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	body := `{"tenant_code":"other","product_code":"other","object_code":"other","view_content_code":"other"}`
+	tests := []struct {
+		name    string
+		route   string
+		path    string
+		handler func(h *httpHandler) gin.HandlerFunc
+	}{
+		{
+			name:    "data",
+			route:   "/t/:tenant_code/p/:product_code/o/:object_code/view/:view_content_code/data",
+			path:    "/t/acme/p/crm/o/contact/view/list/data",
+			handler: func(h *httpHandler) gin.HandlerFunc { return h.GetObjectData },
+		},
+		{
+			name:    "detail",
+			route:   "/t/:tenant_code/p/:product_code/o/:object_code/view/:view_content_code/data/detail/:serial",
+			path:    "/t/acme/p/crm/o/contact/view/list/data/detail/1",
+			handler: func(h *httpHandler) gin.HandlerFunc { return h.GetObjectDetail },
+		},
+		{
+			name:    "export",
+			route:   "/t/:tenant_code/p/:product_code/o/:object_code/view/:view_content_code/export",
+			path:    "/t/acme/p/crm/o/contact/view/list/export",
+			handler: func(h *httpHandler) gin.HandlerFunc { return h.ExportObjectData },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &queryRecorder{}
+			h := &httpHandler{catalogUc: recorder}
+
+			router := gin.New()
+			router.POST(tt.route, tt.handler(h))
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(body)))
+
+			if len(recorder.queries) != 1 {
+				t.Fatalf("got %d queries, status %d", len(recorder.queries), w.Code)
+			}
+
+			query := recorder.queries[0]
+			if query.TenantCode != "acme" || query.ProductCode != "crm" || query.ObjectCode != "contact" || query.ViewContentCode != "list" {
+				t.Errorf("got tenant %q, product %q, object %q, view content %q", query.TenantCode, query.ProductCode, query.ObjectCode, query.ViewContentCode)
+			}
+		})
+	}
+}
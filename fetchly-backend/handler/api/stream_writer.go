@@ -0,0 +1,41 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// streamResponseWriter writes a file download into the response, the status and headers are
+// written lazily on the first write.
+type streamResponseWriter struct {
+	context     *gin.Context
+	contentType string
+	fileName    string
+	isStarted   bool
+}
+
+func (w *streamResponseWriter) start() {
+	if w.isStarted {
+		return
+	}
+
+	w.isStarted = true
+	w.context.Header("Content-Type", w.contentType)
+	w.context.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", w.fileName))
+	w.context.Header("Cache-Control", "no-store")
+	w.context.Status(http.StatusOK)
+	w.context.Writer.WriteHeaderNow()
+}
+
+func (w *streamResponseWriter) Write(p []byte) (int, error) {
+	w.start()
+	return w.context.Writer.Write(p)
+}
+
+func (w *streamResponseWriter) Flush() {
+	if w.isStarted {
+		w.context.Writer.Flush()
+	}
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+	"github.com/fetchlydev/source/fetchly-backend/core/module"
+	"github.com/fetchlydev/source/fetchly-backend/pkg/helper"
+	"github.com/gin-gonic/gin"
+)
+
+// AuthMiddleware authenticates the access token of the request against the tenant of the path
+// and puts the user into the request context
+func AuthMiddleware(authUc module.AuthUsecase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		accessToken := strings.TrimSpace(strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer "))
+		if accessToken == "" {
+			helper.ResponseOutput(c, http.StatusUnauthorized, "empty authorization token", nil)
+			c.Abort()
+			return
+		}
+
+		tenantCode := c.Param(entity.TENANT_CODE)
+		if tenantCode == "" {
+			helper.ResponseOutput(c, http.StatusBadRequest, entity.ErrorSerialEmpty.Error(), nil)
+			c.Abort()
+			return
+		}
+
+		user, err := authUc.Authenticate(c.Request.Context(), tenantCode, accessToken)
+		if err != nil {
+			log.Println(err.Error())
+			helper.ResponseOutput(c, http.StatusUnauthorized, err.Error(), nil)
+			c.Abort()
+			return
+		}
+
+		if user.TenantCode != tenantCode {
+			helper.ResponseOutput(c, http.StatusForbidden, "token is not issued for this tenant", nil)
+			c.Abort()
+			return
+		}
+
+		c.Request = c.Request.WithContext(entity.ContextWithAuthUser(c.Request.Context(), user))
+
+		c.Next()
+	}
+}
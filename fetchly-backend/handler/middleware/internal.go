@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+
+	"github.com/fetchlydev/source/fetchly-backend/config"
+	"github.com/fetchlydev/source/fetchly-backend/pkg/helper"
+	"github.com/gin-gonic/gin"
+)
+
+const InternalSecretKeyHeader = "X-Internal-Secret-Key"
+
+// placeholderInternalSecretKey is the value the internal secret key defaulted to, it is known to everyone
+const placeholderInternalSecretKey = "INTERNAL_SECRET_KEY"
+
+var ErrInternalSecretKeyNotSet = errors.New("INTERNAL_SECRET_KEY has to be set to a secret of its own to serve the admin endpoints")
+
+// ValidateInternalSecretKey reports whether the internal secret key of the config can guard the administrative
+// endpoints, an empty key or the former default can not
+func ValidateInternalSecretKey(cfg config.Config) error {
+	if cfg.InternalSecretKey == "" || cfg.InternalSecretKey == placeholderInternalSecretKey {
+		return ErrInternalSecretKeyNotSet
+	}
+
+	return nil
+}
+
+// InternalSecretKeyMiddleware only lets through the requests carrying the internal secret key,
+// it guards the administrative endpoints. Without a valid key in the config every request is refused.
+func InternalSecretKeyMiddleware(cfg config.Config) gin.HandlerFunc {
+	isKeyValid := ValidateInternalSecretKey(cfg) == nil
+
+	return func(c *gin.Context) {
+		secretKey := c.GetHeader(InternalSecretKeyHeader)
+		if !isKeyValid || secretKey == "" || subtle.ConstantTimeCompare([]byte(secretKey), []byte(cfg.InternalSecretKey)) != 1 {
+			helper.ResponseOutput(c, http.StatusUnauthorized, "invalid internal secret key", nil)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fetchlydev/source/fetchly-backend/config"
+	"github.com/gin-gonic/gin"
+)
+
+func TestValidateInternalSecretKey(t *testing.T) {
+	tests := []struct {
+		name      string
+		secretKey string
+		wantErr   error
+	}{
+		{name: "secret of its own", secretKey: "s3cr3t-of-the-deployment"},
+		{name: "empty", secretKey: "", wantErr: ErrInternalSecretKeyNotSet},
+		{name: "former default", secretKey: "INTERNAL_SECRET_KEY", wantErr: ErrInternalSecretKeyNotSet},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateInternalSecretKey(config.Config{InternalSecretKey: tt.secretKey}); !errors.Is(err, tt.wantErr) {
+				t.Errorf("ValidateInternalSecretKey() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestInternalSecretKeyMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		configKey  string
+		header     string
+		wantStatus int
+	}{
+		{name: "matching key", configKey: "s3cr3t", header: "s3cr3t", wantStatus: http.StatusOK},
+		{name: "wrong key", configKey: "s3cr3t", header: "s3cr3", wantStatus: http.StatusUnauthorized},
+		{name: "no key", configKey: "s3cr3t", wantStatus: http.StatusUnauthorized},
+		{name: "empty key in the config", configKey: "", wantStatus: http.StatusUnauthorized},
+		{name: "former default in the config", configKey: "INTERNAL_SECRET_KEY", header: "INTERNAL_SECRET_KEY", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/admin", InternalSecretKeyMiddleware(config.Config{InternalSecretKey: tt.configKey}), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			request := httptest.NewRequest(http.MethodGet, "/admin", nil)
+			if tt.header != "" {
+				request.Header.Set(InternalSecretKeyHeader, tt.header)
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, request)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+	"github.com/fetchlydev/source/fetchly-backend/pkg/helper"
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDMiddleware puts the ID of the request into the request context and the response, the one given by the
+// caller or a generated one. The audit logs of the changes made by the request carry it.
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(entity.RequestIDHeader)
+		if requestID == "" || len(requestID) > 100 {
+			requestID, _ = helper.GenerateUUUID()
+		}
+
+		c.Header(entity.RequestIDHeader, requestID)
+		c.Request = c.Request.WithContext(entity.ContextWithRequestID(c.Request.Context(), requestID))
+
+		c.Next()
+	}
+}
@@ -2,12 +2,16 @@
 package middleware
 
 import (
+	"context"
+	"log"
 	"strings"
 
 	"github.com/fetchlydev/source/fetchly-backend/config"
 	"github.com/fetchlydev/source/fetchly-backend/core/module"
 	"github.com/fetchlydev/source/fetchly-backend/handler/api"
 	"github.com/fetchlydev/source/fetchly-backend/pkg/conn"
+	"github.com/fetchlydev/source/fetchly-backend/pkg/storage"
+	accessrepository "github.com/fetchlydev/source/fetchly-backend/repository/access_repository"
 	authrepository "github.com/fetchlydev/source/fetchly-backend/repository/auth_repository"
 	catalogrepository "github.com/fetchlydev/source/fetchly-backend/repository/catalog_repository"
 	viewrepository "github.com/fetchlydev/source/fetchly-backend/repository/view_repository"
@@ -21,46 +25,131 @@ func InitRouter(cfg config.Config, db *gorm.DB) (*gin.Engine, conn.CacheService)
 		gin.SetMode(gin.ReleaseMode)
 	}
 
+	// the admin endpoints are guarded by the internal secret key, they are not served behind a guessable one
+	if err := ValidateInternalSecretKey(cfg); err != nil {
+		log.Fatal(err)
+	}
+
 	router := gin.New()
 	router.Use(CORSMiddleware())
+	router.Use(RequestIDMiddleware())
+
+	// let the usecases read the authenticated user from the gin context
+	router.ContextWithFallback = true
 
 	coreRedis, _ := conn.InitRedis(cfg)
 
+	// the tables of objects with their own data source are queried through a pool opened on first use
+	dbRegistry := conn.NewDBRegistry(&cfg)
+
 	// repository
-	catalogRepo := catalogrepository.New(cfg, db)
+	catalogRepo := catalogrepository.New(cfg, db, dbRegistry)
 	viewRepo := viewrepository.New(db, cfg)
 	authRepo := authrepository.New(cfg, db)
+	accessRepo := accessrepository.New(cfg, db)
 
 	// usecase
-	catalogUc := module.NewCatalogUsecase(cfg, catalogRepo, viewRepo)
-	viewUc := module.NewViewUsecase(cfg, catalogRepo, viewRepo, catalogUc)
+	catalogUc := module.NewCatalogUsecase(cfg, catalogRepo, viewRepo, accessRepo)
+	viewUc := module.NewViewUsecase(cfg, coreRedis, catalogRepo, viewRepo, catalogUc)
 	authUc := module.NewAuthUsecase(cfg, authRepo, catalogRepo)
+	schemaUc := module.NewSchemaUsecase(cfg, catalogRepo)
+	migrationUc := module.NewMigrationUsecase(cfg, catalogRepo)
+
+	exportStorage, err := storage.New(cfg.ExportStorage, cfg.ExportStoragePath, db)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	exportJobUc := module.NewExportJobUsecase(cfg, coreRedis, exportStorage, catalogUc)
+	exportJobUc.StartWorkers(context.Background())
+
+	archiveStorage, err := storage.NewLocalStorage(cfg.ArchiveStoragePath)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	tenantUc := module.NewTenantUsecase(cfg, catalogRepo, authRepo, migrationUc, archiveStorage)
+
+	trashUc := module.NewTrashUsecase(cfg, coreRedis, catalogRepo)
+	trashUc.StartPurge(context.Background())
+
+	auditUc := module.NewAuditUsecase(cfg, catalogRepo, accessRepo)
 
 	// handler
-	httpHandler := api.NewHTTPHandler(cfg, catalogUc, viewUc, authUc)
+	httpHandler := api.NewHTTPHandler(cfg, catalogUc, viewUc, authUc, exportJobUc, schemaUc, migrationUc, tenantUc, trashUc, auditUc)
 
 	t := router.Group("t/:tenant_code")
 	{
 		t.POST("", httpHandler.GetTenantByCode)
 
+		admin := t.Group("admin", InternalSecretKeyMiddleware(cfg))
+		{
+			sessions := admin.Group("users/:user_serial/sessions")
+			{
+				sessions.GET("", httpHandler.GetUserSessions)
+				sessions.DELETE("", httpHandler.RevokeUserSessions)
+				sessions.DELETE("/:session_serial", httpHandler.RevokeUserSession)
+			}
+
+			objects := admin.Group("objects")
+			{
+				objects.POST("", httpHandler.CreateObjectSchema)
+				objects.GET("/:object_code", httpHandler.GetObjectSchema)
+				objects.PATCH("/:object_code", httpHandler.AlterObjectSchema)
+				objects.DELETE("/:object_code", httpHandler.DropObjectSchema)
+			}
+
+			migrations := admin.Group("migrations")
+			{
+				migrations.GET("/bundle", httpHandler.ExportMetadataBundle)
+				migrations.POST("/plan", httpHandler.PlanMigration)
+				migrations.POST("/apply", httpHandler.ApplyMigration)
+			}
+
+			// the tenant of the path is the one created, cloned into or offboarded
+			tenant := admin.Group("tenant")
+			{
+				tenant.POST("/provision", httpHandler.ProvisionTenant)
+				tenant.POST("/clone", httpHandler.CloneTenant)
+				tenant.POST("/offboard", httpHandler.OffboardTenant)
+			}
+
+			// a raw query escapes the permissions and row policies, the object only selects the database it runs on
+			admin.POST("/o/:object_code/data/raw", httpHandler.GetDataByRawQuery)
+			admin.POST("/trash/purge", httpHandler.PurgeTrash)
+			admin.GET("/audit", httpHandler.GetAuditLogs)
+		}
+
 		p := t.Group("p/:product_code")
 		{
 			p.POST("", httpHandler.GetTenantProductByCode)
 
-			o := p.Group("o/:object_code")
+			o := p.Group("o/:object_code", AuthMiddleware(authUc))
 			{
 				v := o.Group("view/:view_content_code")
 				{
 					v.POST("/data", httpHandler.GetObjectData)
-					v.POST("/data/raw", httpHandler.GetDataByRawQuery)
 					v.POST("/data/detail/:serial", httpHandler.GetObjectDetail)
 					v.POST("/export", httpHandler.ExportObjectData)
+					v.POST("/export/stream", httpHandler.StreamExportObjectData)
+					v.POST("/export/jobs", httpHandler.SubmitExportJob)
 					v.POST("/:layout_type", httpHandler.GetContentLayoutByKeys)
 				}
 
 				o.PUT("/data", httpHandler.CreateObjectData)
 				o.PATCH("/data/:serial", httpHandler.UpdateObjectData)
 				o.DELETE("/data/:serial", httpHandler.DeleteObjectData)
+				o.POST("/data/:serial/restore", httpHandler.RestoreObjectData)
+				o.GET("/data/:serial/history", httpHandler.GetRecordHistory)
+				o.POST("/data/bulk", httpHandler.BulkMutateObjectData)
+				o.POST("/data/aggregate", httpHandler.AggregateObjectData)
+				o.POST("/data/import", httpHandler.ImportObjectData)
+			}
+
+			exportJobs := p.Group("export-jobs", AuthMiddleware(authUc))
+			{
+				exportJobs.GET("/:job_id", httpHandler.GetExportJob)
+				exportJobs.GET("/:job_id/download", httpHandler.DownloadExportJob)
 			}
 
 			auth := p.Group("auth")
@@ -69,6 +158,7 @@ func InitRouter(cfg config.Config, db *gorm.DB) (*gin.Engine, conn.CacheService)
 				auth.POST("/refresh-token", httpHandler.RefreshToken)
 				auth.POST("/encrypt-password", httpHandler.EncryptPassword)
 				auth.POST("/current-user", httpHandler.GetCurrentUser)
+				auth.POST("/logout", httpHandler.Logout)
 				auth.POST("/google-login", httpHandler.GoogleLogin)
 			}
 		}
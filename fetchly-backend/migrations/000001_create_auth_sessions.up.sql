@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS auth_sessions (
+    id              BIGSERIAL PRIMARY KEY,
+    serial          VARCHAR(36)  NOT NULL UNIQUE,
+    tenant_code     VARCHAR(100) NOT NULL,
+    user_serial     VARCHAR(36)  NOT NULL,
+    user_agent      TEXT         NOT NULL DEFAULT '',
+    ip_address      VARCHAR(45)  NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    last_used_at    TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    expires_at      TIMESTAMPTZ  NOT NULL,
+    revoked_at      TIMESTAMPTZ,
+    revoked_reason  VARCHAR(50)
+);
+
+CREATE INDEX IF NOT EXISTS auth_sessions_tenant_user_idx ON auth_sessions (tenant_code, user_serial) WHERE revoked_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS auth_refresh_tokens (
+    id              BIGSERIAL PRIMARY KEY,
+    serial          VARCHAR(36)  NOT NULL UNIQUE,
+    session_serial  VARCHAR(36)  NOT NULL REFERENCES auth_sessions (serial) ON DELETE CASCADE,
+    token_hash      CHAR(64)     NOT NULL UNIQUE,
+    created_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    expires_at      TIMESTAMPTZ  NOT NULL,
+    used_at         TIMESTAMPTZ,
+    replaced_by     VARCHAR(36)
+);
+
+CREATE INDEX IF NOT EXISTS auth_refresh_tokens_session_idx ON auth_refresh_tokens (session_serial);
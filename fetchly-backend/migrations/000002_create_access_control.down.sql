@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS role_field_permissions;
+DROP TABLE IF EXISTS role_object_permissions;
+DROP TABLE IF EXISTS user_roles;
+DROP TABLE IF EXISTS roles;
@@ -0,0 +1,70 @@
+CREATE TABLE IF NOT EXISTS roles (
+    id              BIGSERIAL PRIMARY KEY,
+    serial          VARCHAR(36)  NOT NULL UNIQUE,
+    created_by      VARCHAR(36)  NOT NULL DEFAULT 'system',
+    created_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_by      VARCHAR(36)  NOT NULL DEFAULT 'system',
+    updated_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    deleted_by      VARCHAR(36),
+    deleted_at      TIMESTAMPTZ,
+    tenant_serial   VARCHAR(36)  NOT NULL,
+    code            VARCHAR(100) NOT NULL,
+    name            VARCHAR(255) NOT NULL,
+    description     TEXT         NOT NULL DEFAULT '',
+    is_admin        BOOLEAN      NOT NULL DEFAULT FALSE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS roles_tenant_code_idx ON roles (tenant_serial, code) WHERE deleted_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS user_roles (
+    id              BIGSERIAL PRIMARY KEY,
+    serial          VARCHAR(36)  NOT NULL UNIQUE,
+    created_by      VARCHAR(36)  NOT NULL DEFAULT 'system',
+    created_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_by      VARCHAR(36)  NOT NULL DEFAULT 'system',
+    updated_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    deleted_by      VARCHAR(36),
+    deleted_at      TIMESTAMPTZ,
+    role_serial     VARCHAR(36)  NOT NULL REFERENCES roles (serial),
+    user_serial     VARCHAR(36)  NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS user_roles_user_role_idx ON user_roles (user_serial, role_serial) WHERE deleted_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS role_object_permissions (
+    id              BIGSERIAL PRIMARY KEY,
+    serial          VARCHAR(36)  NOT NULL UNIQUE,
+    created_by      VARCHAR(36)  NOT NULL DEFAULT 'system',
+    created_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_by      VARCHAR(36)  NOT NULL DEFAULT 'system',
+    updated_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    deleted_by      VARCHAR(36),
+    deleted_at      TIMESTAMPTZ,
+    role_serial     VARCHAR(36)  NOT NULL REFERENCES roles (serial),
+    object_serial   VARCHAR(36)  NOT NULL,
+    can_create      BOOLEAN      NOT NULL DEFAULT FALSE,
+    can_read        BOOLEAN      NOT NULL DEFAULT FALSE,
+    can_update      BOOLEAN      NOT NULL DEFAULT FALSE,
+    can_delete      BOOLEAN      NOT NULL DEFAULT FALSE,
+    can_export      BOOLEAN      NOT NULL DEFAULT FALSE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS role_object_permissions_role_object_idx ON role_object_permissions (role_serial, object_serial) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS role_object_permissions_object_idx ON role_object_permissions (object_serial);
+
+CREATE TABLE IF NOT EXISTS role_field_permissions (
+    id                   BIGSERIAL PRIMARY KEY,
+    serial               VARCHAR(36)  NOT NULL UNIQUE,
+    created_by           VARCHAR(36)  NOT NULL DEFAULT 'system',
+    created_at           TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_by           VARCHAR(36)  NOT NULL DEFAULT 'system',
+    updated_at           TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    deleted_by           VARCHAR(36),
+    deleted_at           TIMESTAMPTZ,
+    role_serial          VARCHAR(36)  NOT NULL REFERENCES roles (serial),
+    object_field_serial  VARCHAR(36)  NOT NULL,
+    can_read             BOOLEAN      NOT NULL DEFAULT TRUE,
+    can_write            BOOLEAN      NOT NULL DEFAULT TRUE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS role_field_permissions_role_field_idx ON role_field_permissions (role_serial, object_field_serial) WHERE deleted_at IS NULL;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS row_policies;
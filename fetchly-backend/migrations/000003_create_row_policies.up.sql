@@ -0,0 +1,21 @@
+-- filters holds a list of filter groups, {"operator": "AND" | "OR", "filter_item": {"<field>": {"operator": "equal", "value": ...}}},
+-- every group and every policy applying to the user has to match. role_serial NULL applies the policy to every user.
+CREATE TABLE IF NOT EXISTS row_policies (
+    id              BIGSERIAL PRIMARY KEY,
+    serial          VARCHAR(36)  NOT NULL UNIQUE,
+    created_by      VARCHAR(36)  NOT NULL DEFAULT 'system',
+    created_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_by      VARCHAR(36)  NOT NULL DEFAULT 'system',
+    updated_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    deleted_by      VARCHAR(36),
+    deleted_at      TIMESTAMPTZ,
+    object_serial   VARCHAR(36)  NOT NULL,
+    role_serial     VARCHAR(36)  REFERENCES roles (serial),
+    code            VARCHAR(100) NOT NULL,
+    name            VARCHAR(255) NOT NULL,
+    description     TEXT         NOT NULL DEFAULT '',
+    filters         JSONB        NOT NULL DEFAULT '[]',
+    is_active       BOOLEAN      NOT NULL DEFAULT TRUE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS row_policies_object_code_idx ON row_policies (object_serial, code) WHERE deleted_at IS NULL;
@@ -0,0 +1 @@
+ALTER TABLE object_fields DROP COLUMN IF EXISTS is_searchable;
@@ -0,0 +1,3 @@
+-- is_searchable puts the text field in the full text search of its object, the platform keeps a GIN index over
+-- the searchable fields of every postgres object table
+ALTER TABLE object_fields ADD COLUMN IF NOT EXISTS is_searchable BOOLEAN NOT NULL DEFAULT FALSE;
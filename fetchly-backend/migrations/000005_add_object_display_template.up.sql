@@ -0,0 +1,3 @@
+-- display_template composes the display value of the records of an object from their fields, as "{code} - {name}".
+-- Without a template the fields marked is_display_name are the display value, then the name column.
+ALTER TABLE objects ADD COLUMN IF NOT EXISTS display_template TEXT;
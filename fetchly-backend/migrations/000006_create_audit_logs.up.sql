@@ -0,0 +1,28 @@
+-- audit_logs records every change of the records of the objects, changes holds the changed fields as
+-- [{"field_code": ..., "old_value": ..., "new_value": ...}]. The table is append only, its rows are never updated
+-- or deleted.
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id              BIGSERIAL PRIMARY KEY,
+    serial          VARCHAR(36)  NOT NULL UNIQUE,
+    tenant_code     VARCHAR(100) NOT NULL,
+    object_code     VARCHAR(100) NOT NULL,
+    record_serial   VARCHAR(100) NOT NULL,
+    action          VARCHAR(20)  NOT NULL,
+    user_serial     VARCHAR(36)  NOT NULL,
+    request_id      VARCHAR(100) NOT NULL DEFAULT '',
+    changes         JSONB        NOT NULL DEFAULT '[]',
+    created_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS audit_logs_record_idx ON audit_logs (tenant_code, object_code, record_serial, created_at);
+CREATE INDEX IF NOT EXISTS audit_logs_tenant_idx ON audit_logs (tenant_code, created_at);
+
+CREATE OR REPLACE FUNCTION audit_logs_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_logs is append only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS audit_logs_append_only ON audit_logs;
+CREATE TRIGGER audit_logs_append_only BEFORE UPDATE OR DELETE ON audit_logs
+    FOR EACH ROW EXECUTE FUNCTION audit_logs_append_only();
@@ -0,0 +1,11 @@
+-- stored_file_chunks keeps the generated files, such as the export job files, in the database shared by every
+-- instance. A file is split in parts numbered from 0, so it is never held in memory at once.
+CREATE TABLE IF NOT EXISTS stored_file_chunks (
+    file_key        VARCHAR(255) NOT NULL,
+    part            INT          NOT NULL,
+    data            BYTEA        NOT NULL,
+    created_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (file_key, part)
+);
+
+CREATE INDEX IF NOT EXISTS stored_file_chunks_created_idx ON stored_file_chunks (created_at);
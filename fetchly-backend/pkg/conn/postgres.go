@@ -22,7 +22,7 @@ func InitDB(cfg *config.Config) *gorm.DB {
 		},
 	})
 	if err != nil {
-		log.Fatalf(err.Error())
+		log.Fatal(err)
 		panic(err)
 	} else {
 		log.Printf("Successfully connected to database server")
@@ -30,7 +30,7 @@ func InitDB(cfg *config.Config) *gorm.DB {
 
 	rdb, err := db.DB()
 	if err != nil {
-		log.Fatalf(err.Error())
+		log.Fatal(err)
 		panic(err)
 	}
 
@@ -44,7 +44,7 @@ func InitDB(cfg *config.Config) *gorm.DB {
 func DbClose(db *gorm.DB) {
 	rdb, err := db.DB()
 	if err != nil {
-		log.Fatalf(err.Error())
+		log.Fatal(err)
 		panic(err)
 	}
 
@@ -18,6 +18,12 @@ type CacheService interface {
 	Set(key string, value []byte, ttl int64) error
 	Exists(key string) (bool, error)
 	Delete(key string) error
+	SetNX(key string, value []byte, ttl int64) (bool, error)
+	Expire(key string, ttl int64) error
+	Push(key string, value []byte) error
+	PopPush(source, destination string, timeout int64) ([]byte, error)
+	ListRange(key string) ([][]byte, error)
+	ListRemove(key string, value []byte) error
 }
 
 var cache CacheService
@@ -130,6 +136,85 @@ func (cache *Cache) Delete(key string) error {
 	return err
 }
 
+// SetNX set key, value only when the key does not exist yet, used as a distributed lock
+func (cache *Cache) SetNX(key string, value []byte, ttl int64) (bool, error) {
+	conn := cache.Pool.Get()
+	defer conn.Close()
+
+	reply, err := redis.String(conn.Do("SET", key, value, "NX", "EX", ttl))
+	if err == redis.ErrNil {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error setting key %s if not exists: %v", key, err)
+	}
+	return reply == "OK", nil
+}
+
+// Expire refresh ttl of key
+func (cache *Cache) Expire(key string, ttl int64) error {
+	conn := cache.Pool.Get()
+	defer conn.Close()
+
+	_, err := conn.Do("EXPIRE", key, ttl)
+	if err != nil {
+		return fmt.Errorf("error setting expire key %s to %d: %v", key, ttl, err)
+	}
+	return nil
+}
+
+// Push push value to the head of list
+func (cache *Cache) Push(key string, value []byte) error {
+	conn := cache.Pool.Get()
+	defer conn.Close()
+
+	_, err := conn.Do("LPUSH", key, value)
+	if err != nil {
+		return fmt.Errorf("error pushing to list %s: %v", key, err)
+	}
+	return nil
+}
+
+// PopPush atomically move the tail of source list to the head of destination list, waiting up to
+// timeout seconds for an item. It returns nil when no item is available.
+func (cache *Cache) PopPush(source, destination string, timeout int64) ([]byte, error) {
+	conn := cache.Pool.Get()
+	defer conn.Close()
+
+	data, err := redis.Bytes(conn.Do("BRPOPLPUSH", source, destination, timeout))
+	if err == redis.ErrNil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error moving item from list %s to %s: %v", source, destination, err)
+	}
+	return data, nil
+}
+
+// ListRange get all items of list
+func (cache *Cache) ListRange(key string) ([][]byte, error) {
+	conn := cache.Pool.Get()
+	defer conn.Close()
+
+	data, err := redis.ByteSlices(conn.Do("LRANGE", key, 0, -1))
+	if err != nil {
+		return data, fmt.Errorf("error getting list %s: %v", key, err)
+	}
+	return data, nil
+}
+
+// ListRemove remove all occurrences of value from list
+func (cache *Cache) ListRemove(key string, value []byte) error {
+	conn := cache.Pool.Get()
+	defer conn.Close()
+
+	_, err := conn.Do("LREM", key, 0, value)
+	if err != nil {
+		return fmt.Errorf("error removing item from list %s: %v", key, err)
+	}
+	return nil
+}
+
 func InitRedis(cfg config.Config) (CacheService, *redis.Pool) {
 	// Initialize redis core
 	redisAddress := cfg.RedisHost + ":" + cfg.RedisPort
@@ -0,0 +1,352 @@
+package conn
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fetchlydev/source/fetchly-backend/config"
+	"github.com/glebarez/sqlite"
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// DataSourceConfig holds the connection settings of a data source. Configs selects the database with dialect
+// (postgres, mysql or sqlite, defaulting to postgres) and may override the pool settings with max_idle_conns,
+// max_open_conns and conn_max_lifetime (minutes), every other key is passed on as a connection parameter,
+// e.g. sslmode. The DBName of a sqlite data source is the path of its database file.
+type DataSourceConfig struct {
+	Serial   string
+	Host     string
+	Port     string
+	Username string
+	Password string
+	DBName   string
+	Configs  map[string]any
+}
+
+const (
+	DialectPostgres = "postgres"
+	DialectMySQL    = "mysql"
+	DialectSQLite   = "sqlite"
+)
+
+var poolConfigKeys = []string{"dialect", "max_idle_conns", "max_open_conns", "conn_max_lifetime"}
+
+// Dialect returns the database of the data source
+func (c DataSourceConfig) Dialect() string {
+	if dialect, ok := c.Configs["dialect"].(string); ok && dialect != "" {
+		return strings.ToLower(dialect)
+	}
+
+	return DialectPostgres
+}
+
+// Dialector returns the gorm dialector connecting to the data source
+func (c DataSourceConfig) Dialector() (gorm.Dialector, error) {
+	switch c.Dialect() {
+	case DialectPostgres:
+		return postgres.Open(c.DSN()), nil
+	case DialectMySQL:
+		return mysql.Open(c.DSN()), nil
+	case DialectSQLite:
+		return sqlite.Open(c.DSN()), nil
+	}
+
+	return nil, fmt.Errorf("unsupported dialect %v of data source %v", c.Dialect(), c.Serial)
+}
+
+// DSN builds the connection string of the data source in the format of its dialect
+func (c DataSourceConfig) DSN() string {
+	switch c.Dialect() {
+	case DialectMySQL:
+		return c.mysqlDSN()
+	case DialectSQLite:
+		return c.sqliteDSN()
+	}
+
+	return c.postgresDSN()
+}
+
+func (c DataSourceConfig) mysqlDSN() string {
+	mysqlConfig := mysqldriver.NewConfig()
+	mysqlConfig.User = c.Username
+	mysqlConfig.Passwd = c.Password
+	mysqlConfig.Net = "tcp"
+	mysqlConfig.Addr = c.Host + ":" + c.Port
+	mysqlConfig.DBName = c.DBName
+	mysqlConfig.ParseTime = true
+	mysqlConfig.Params = c.connectionParams()
+
+	return mysqlConfig.FormatDSN()
+}
+
+func (c DataSourceConfig) sqliteDSN() string {
+	params := url.Values{}
+	params.Add("_pragma", "foreign_keys(1)")
+	for key, value := range c.connectionParams() {
+		params.Add(key, value)
+	}
+
+	return c.DBName + "?" + params.Encode()
+}
+
+// connectionParams returns the configs passed on to the driver
+func (c DataSourceConfig) connectionParams() map[string]string {
+	params := make(map[string]string)
+	for key, value := range c.Configs {
+		if isPoolConfigKey(key) {
+			continue
+		}
+
+		params[key] = fmt.Sprintf("%v", value)
+	}
+
+	return params
+}
+
+func (c DataSourceConfig) postgresDSN() string {
+	params := map[string]string{
+		"host":     c.Host,
+		"port":     c.Port,
+		"user":     c.Username,
+		"password": c.Password,
+		"dbname":   c.DBName,
+		"sslmode":  "disable",
+		"TimeZone": "Asia/Jakarta",
+	}
+
+	for key, value := range c.connectionParams() {
+		params[key] = value
+	}
+
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, fmt.Sprintf("%v=%v", key, quoteDSNValue(params[key])))
+	}
+
+	return strings.Join(pairs, " ")
+}
+
+func isPoolConfigKey(key string) bool {
+	for _, poolKey := range poolConfigKeys {
+		if key == poolKey {
+			return true
+		}
+	}
+
+	return false
+}
+
+// quoteDSNValue quotes a connection parameter, so passwords with spaces or quotes survive the key=value format
+func quoteDSNValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, ` '\`) {
+		return value
+	}
+
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+
+	return "'" + value + "'"
+}
+
+func (c DataSourceConfig) intConfig(key string, fallback int) int {
+	switch value := c.Configs[key].(type) {
+	case float64:
+		return int(value)
+	case int:
+		return value
+	case int64:
+		return int(value)
+	}
+
+	return fallback
+}
+
+// retiredPoolIdleTime is how long a replaced pool has to stay without a connection in use before it is closed, a
+// request between two queries holds no connection so a pool idle for a moment may still be in use
+const (
+	retiredPoolIdleTime      = time.Minute
+	retiredPoolCheckInterval = 5 * time.Second
+)
+
+type registeredDB struct {
+	dsn string
+	db  *gorm.DB
+}
+
+// openingDB is a pool being opened, the requests of its data source wait for done instead of opening their own
+type openingDB struct {
+	dsn  string
+	db   *gorm.DB
+	err  error
+	done chan struct{}
+}
+
+// retiredDB is a pool replaced after its connection settings changed, it keeps serving the requests that got it
+// until it is idle
+type retiredDB struct {
+	db         *gorm.DB
+	lastUsedAt time.Time
+}
+
+// DBRegistry keeps one connection pool per data source, a pool is opened on the first query of the data source
+// and shared by every request afterwards
+type DBRegistry struct {
+	cfg     *config.Config
+	mu      sync.Mutex
+	pools   map[string]registeredDB
+	opening map[string]*openingDB
+	retired []*retiredDB
+
+	isClosingRetired bool
+	idleTime         time.Duration
+	checkInterval    time.Duration
+	open             func(dataSource DataSourceConfig) (*gorm.DB, error)
+}
+
+func NewDBRegistry(cfg *config.Config) *DBRegistry {
+	r := &DBRegistry{
+		cfg:           cfg,
+		pools:         make(map[string]registeredDB),
+		opening:       make(map[string]*openingDB),
+		idleTime:      retiredPoolIdleTime,
+		checkInterval: retiredPoolCheckInterval,
+	}
+	r.open = r.openPool
+
+	return r
+}
+
+// Get returns the pool of the data source. The pool is opened without holding the registry, so a slow or
+// unreachable data source only makes its own requests wait. A data source whose connection settings changed
+// since its pool was opened gets a new pool, the old one is closed once it is idle.
+func (r *DBRegistry) Get(dataSource DataSourceConfig) (*gorm.DB, error) {
+	dsn := dataSource.Dialect() + ":" + dataSource.DSN()
+
+	for {
+		r.mu.Lock()
+		if pool, ok := r.pools[dataSource.Serial]; ok && pool.dsn == dsn {
+			r.mu.Unlock()
+			return pool.db, nil
+		}
+
+		if opening, ok := r.opening[dataSource.Serial]; ok {
+			r.mu.Unlock()
+			<-opening.done
+
+			if opening.dsn == dsn {
+				return opening.db, opening.err
+			}
+
+			// the settings changed while the other pool was opening
+			continue
+		}
+
+		opening := &openingDB{dsn: dsn, done: make(chan struct{})}
+		r.opening[dataSource.Serial] = opening
+		r.mu.Unlock()
+
+		opening.db, opening.err = r.open(dataSource)
+
+		r.mu.Lock()
+		delete(r.opening, dataSource.Serial)
+		if opening.err == nil {
+			if pool, ok := r.pools[dataSource.Serial]; ok {
+				r.retire(pool.db)
+			}
+
+			r.pools[dataSource.Serial] = registeredDB{dsn: dsn, db: opening.db}
+		}
+		r.mu.Unlock()
+
+		close(opening.done)
+		return opening.db, opening.err
+	}
+}
+
+func (r *DBRegistry) openPool(dataSource DataSourceConfig) (*gorm.DB, error) {
+	dialector, err := dataSource.Dialector()
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{
+		NamingStrategy: schema.NamingStrategy{
+			SingularTable: true,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to data source %v: %w", dataSource.Serial, err)
+	}
+
+	rdb, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	rdb.SetMaxIdleConns(dataSource.intConfig("max_idle_conns", r.cfg.MaxIdleConns))
+	rdb.SetMaxOpenConns(dataSource.intConfig("max_open_conns", r.cfg.MaxOpenConns))
+	rdb.SetConnMaxLifetime(time.Duration(int(time.Minute) * dataSource.intConfig("conn_max_lifetime", r.cfg.ConnMaxLifetime)))
+
+	log.Printf("Successfully connected to data source %v", dataSource.Serial)
+
+	return db, nil
+}
+
+// retire schedules the pool to be closed once it is idle, the caller holds the registry
+func (r *DBRegistry) retire(db *gorm.DB) {
+	r.retired = append(r.retired, &retiredDB{db: db, lastUsedAt: time.Now()})
+
+	if !r.isClosingRetired {
+		r.isClosingRetired = true
+		go r.closeRetired()
+	}
+}
+
+// closeRetired closes every retired pool without a connection in use for idleTime, it stops once all of them are
+// closed
+func (r *DBRegistry) closeRetired() {
+	ticker := time.NewTicker(r.checkInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		r.mu.Lock()
+
+		retired := r.retired[:0]
+		for _, pool := range r.retired {
+			if rdb, err := pool.db.DB(); err == nil && rdb.Stats().InUse > 0 {
+				pool.lastUsedAt = time.Now()
+			}
+
+			if time.Since(pool.lastUsedAt) < r.idleTime {
+				retired = append(retired, pool)
+				continue
+			}
+
+			DbClose(pool.db)
+		}
+		r.retired = retired
+
+		if len(r.retired) == 0 {
+			r.isClosingRetired = false
+			r.mu.Unlock()
+			return
+		}
+
+		r.mu.Unlock()
+	}
+}
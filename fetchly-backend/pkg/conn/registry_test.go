@@ -0,0 +1,161 @@
+package conn
+
+import (
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fetchlydev/source/fetchly-backend/config"
+	"gorm.io/gorm"
+)
+
+func sqliteDataSource(t *testing.T, serial, dbName string) DataSourceConfig {
+	return DataSourceConfig{
+		Serial:  serial,
+		DBName:  filepath.Join(t.TempDir(), dbName),
+		Configs: map[string]any{"dialect": DialectSQLite},
+	}
+}
+
+func TestDBRegistryGet(t *testing.T) {
+	registry := NewDBRegistry(&config.Config{})
+
+	var opened atomic.Int32
+	open := registry.open
+	registry.open = func(dataSource DataSourceConfig) (*gorm.DB, error) {
+		opened.Add(1)
+		return open(dataSource)
+	}
+
+	dataSource := sqliteDataSource(t, "remote", "remote.db")
+
+	pools := make([]*gorm.DB, 10)
+	var wg sync.WaitGroup
+	for i := range pools {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			db, err := registry.Get(dataSource)
+			if err != nil {
+				t.Error(err)
+			}
+			pools[i] = db
+		}(i)
+	}
+	wg.Wait()
+
+	for _, db := range pools {
+		if db != pools[0] {
+			t.Fatal("Get() returned different pools for the same data source")
+		}
+	}
+
+	if opened.Load() != 1 {
+		t.Errorf("opened %d pools, want 1", opened.Load())
+	}
+
+	if err := pools[0].Exec("SELECT 1").Error; err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDBRegistryGetWhileOpening(t *testing.T) {
+	registry := NewDBRegistry(&config.Config{})
+
+	slow := sqliteDataSource(t, "slow", "slow.db")
+	fast := sqliteDataSource(t, "fast", "fast.db")
+
+	release := make(chan struct{})
+	open := registry.open
+	registry.open = func(dataSource DataSourceConfig) (*gorm.DB, error) {
+		if dataSource.Serial == slow.Serial {
+			<-release
+		}
+
+		return open(dataSource)
+	}
+
+	slowDone := make(chan error)
+	go func() {
+		_, err := registry.Get(slow)
+		slowDone <- err
+	}()
+
+	// another data source is served while the slow one is still connecting
+	fastDone := make(chan error)
+	go func() {
+		_, err := registry.Get(fast)
+		fastDone <- err
+	}()
+
+	select {
+	case err := <-fastDone:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Get() of another data source waited for the slow one")
+	}
+
+	close(release)
+	if err := <-slowDone; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDBRegistryRetiresChangedPool(t *testing.T) {
+	registry := NewDBRegistry(&config.Config{})
+	registry.idleTime = 50 * time.Millisecond
+	registry.checkInterval = 10 * time.Millisecond
+
+	dataSource := sqliteDataSource(t, "remote", "remote.db")
+
+	oldDB, err := registry.Get(dataSource)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a request still running on the old pool
+	tx := oldDB.Begin()
+	if err := tx.Exec("CREATE TABLE contact (serial TEXT)").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	dataSource.DBName = filepath.Join(t.TempDir(), "moved.db")
+	newDB, err := registry.Get(dataSource)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if newDB == oldDB {
+		t.Fatal("Get() returned the old pool after the settings changed")
+	}
+
+	time.Sleep(5 * registry.idleTime)
+
+	if err := tx.Exec("INSERT INTO contact VALUES ('a')").Commit().Error; err != nil {
+		t.Fatalf("request on the old pool failed: %v", err)
+	}
+
+	// the old pool is closed once it stayed idle
+	deadline := time.Now().Add(5 * time.Second)
+	for isPoolOpen(oldDB) {
+		if time.Now().After(deadline) {
+			t.Fatal("old pool was not closed after it became idle")
+		}
+
+		time.Sleep(registry.checkInterval)
+	}
+
+	if !isPoolOpen(newDB) {
+		t.Error("new pool was closed")
+	}
+}
+
+func isPoolOpen(db *gorm.DB) bool {
+	rdb, err := db.DB()
+	return err == nil && rdb.Ping() == nil
+}
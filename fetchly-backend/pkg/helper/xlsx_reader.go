@@ -0,0 +1,283 @@
+package helper
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// XLSXRowReader reads the rows of the first sheet of a workbook one by one, decoding the sheet
+// xml as a stream so large files are never fully loaded into memory. Only the shared string
+// table is kept in memory.
+type XLSXRowReader struct {
+	sheet         io.ReadCloser
+	decoder       *xml.Decoder
+	sharedStrings []string
+	rowNumber     int
+}
+
+func NewXLSXRowReader(r io.ReaderAt, size int64) (*XLSXRowReader, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("invalid xlsx file: %w", err)
+	}
+
+	files := make(map[string]*zip.File)
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+
+	sharedStrings, err := readSharedStrings(files["xl/sharedStrings.xml"])
+	if err != nil {
+		return nil, err
+	}
+
+	sheetFile := files[firstSheetPath(files)]
+	if sheetFile == nil {
+		return nil, errors.New("invalid xlsx file: sheet is not found")
+	}
+
+	sheet, err := sheetFile.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	return &XLSXRowReader{
+		sheet:         sheet,
+		decoder:       xml.NewDecoder(sheet),
+		sharedStrings: sharedStrings,
+	}, nil
+}
+
+// Read returns the cells of the next row, or io.EOF when the sheet has no more rows
+func (x *XLSXRowReader) Read() ([]string, error) {
+	for {
+		token, err := x.decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "row" {
+			continue
+		}
+
+		x.rowNumber++
+		if rowNumber, err := strconv.Atoi(xmlAttr(start, "r")); err == nil {
+			x.rowNumber = rowNumber
+		}
+
+		return x.readRow()
+	}
+}
+
+// Line returns the 1 based row number of the last row returned by Read
+func (x *XLSXRowReader) Line() int {
+	return x.rowNumber
+}
+
+func (x *XLSXRowReader) Close() error {
+	return x.sheet.Close()
+}
+
+func (x *XLSXRowReader) readRow() ([]string, error) {
+	var cells []string
+
+	for {
+		token, err := x.decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			if element.Name.Local != "c" {
+				continue
+			}
+
+			var cell xlsxStreamCell
+			if err := x.decoder.DecodeElement(&cell, &element); err != nil {
+				return nil, err
+			}
+
+			columnIndex := len(cells)
+			if cell.Reference != "" {
+				columnIndex = columnIndexFromReference(cell.Reference)
+			}
+
+			for len(cells) <= columnIndex {
+				cells = append(cells, "")
+			}
+
+			cells[columnIndex] = x.cellValue(cell)
+		case xml.EndElement:
+			if element.Name.Local == "row" {
+				return cells, nil
+			}
+		}
+	}
+}
+
+func (x *XLSXRowReader) cellValue(cell xlsxStreamCell) string {
+	switch cell.Type {
+	case "s":
+		index, err := strconv.Atoi(strings.TrimSpace(cell.Value))
+		if err != nil || index < 0 || index >= len(x.sharedStrings) {
+			return ""
+		}
+		return x.sharedStrings[index]
+	case "inlineStr":
+		return cell.InlineString.text()
+	case "b":
+		if cell.Value == "1" {
+			return "true"
+		}
+		return "false"
+	}
+
+	return cell.Value
+}
+
+type xlsxStreamCell struct {
+	Reference    string           `xml:"r,attr"`
+	Type         string           `xml:"t,attr"`
+	Value        string           `xml:"v"`
+	InlineString xlsxStreamString `xml:"is"`
+}
+
+type xlsxStreamString struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (s xlsxStreamString) text() string {
+	if len(s.Runs) == 0 {
+		return s.Text
+	}
+
+	var builder strings.Builder
+	builder.WriteString(s.Text)
+	for _, run := range s.Runs {
+		builder.WriteString(run.Text)
+	}
+
+	return builder.String()
+}
+
+func readSharedStrings(file *zip.File) ([]string, error) {
+	if file == nil {
+		return nil, nil
+	}
+
+	reader, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	var sharedStrings []string
+	decoder := xml.NewDecoder(reader)
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return sharedStrings, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "si" {
+			continue
+		}
+
+		var item xlsxStreamString
+		if err := decoder.DecodeElement(&item, &start); err != nil {
+			return nil, err
+		}
+
+		sharedStrings = append(sharedStrings, item.text())
+	}
+}
+
+// firstSheetPath resolves the first sheet of the workbook through its relationship file
+func firstSheetPath(files map[string]*zip.File) string {
+	defaultPath := "xl/worksheets/sheet1.xml"
+
+	var workbook struct {
+		Sheets []struct {
+			RelationID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := decodeZipXML(files["xl/workbook.xml"], &workbook); err != nil || len(workbook.Sheets) == 0 {
+		return defaultPath
+	}
+
+	var relationships struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := decodeZipXML(files["xl/_rels/workbook.xml.rels"], &relationships); err != nil {
+		return defaultPath
+	}
+
+	for _, relationship := range relationships.Relationships {
+		if relationship.ID != workbook.Sheets[0].RelationID {
+			continue
+		}
+
+		if strings.HasPrefix(relationship.Target, "/") {
+			return strings.TrimPrefix(relationship.Target, "/")
+		}
+
+		return path.Join("xl", relationship.Target)
+	}
+
+	return defaultPath
+}
+
+func decodeZipXML(file *zip.File, target any) error {
+	if file == nil {
+		return errors.New("file is not found")
+	}
+
+	reader, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	return xml.NewDecoder(reader).Decode(target)
+}
+
+// columnIndexFromReference converts a cell reference such as "AB12" into a zero based column index
+func columnIndexFromReference(reference string) int {
+	index := 0
+	for _, char := range strings.ToUpper(reference) {
+		if char < 'A' || char > 'Z' {
+			break
+		}
+		index = index*26 + int(char-'A'+1)
+	}
+
+	return index - 1
+}
+
+func xmlAttr(element xml.StartElement, name string) string {
+	for _, attr := range element.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,168 @@
+package helper
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/360EntSecGroup-Skylar/excelize"
+)
+
+// xlsxMaxRows is the row limit of a single worksheet, a new sheet is started once it is reached
+const xlsxMaxRows = 1048576
+
+// XLSXStreamWriter writes a workbook row by row straight into w, cells are written as inline strings
+// so no shared string table has to be kept in memory. The workbook parts are written on Close.
+type XLSXStreamWriter struct {
+	archive    *zip.Writer
+	sheet      *bufio.Writer
+	sheetName  string
+	sheetCount int
+	rowCount   int
+	header     []string
+}
+
+func NewXLSXStreamWriter(w io.Writer, sheetName string) *XLSXStreamWriter {
+	return &XLSXStreamWriter{
+		archive:   zip.NewWriter(w),
+		sheetName: sheetName,
+	}
+}
+
+// WriteHeader writes the header row, it is repeated on top of every additional sheet
+func (x *XLSXStreamWriter) WriteHeader(header []string) error {
+	x.header = header
+	return x.WriteRow(header)
+}
+
+func (x *XLSXStreamWriter) WriteRow(cells []string) error {
+	if x.sheet == nil || x.rowCount >= xlsxMaxRows {
+		if err := x.nextSheet(); err != nil {
+			return err
+		}
+	}
+
+	x.rowCount++
+	if _, err := fmt.Fprintf(x.sheet, `<row r="%d">`, x.rowCount); err != nil {
+		return err
+	}
+
+	for i, cell := range cells {
+		if _, err := fmt.Fprintf(x.sheet, `<c r="%s%d" t="inlineStr"><is><t xml:space="preserve">`, excelize.ToAlphaString(i), x.rowCount); err != nil {
+			return err
+		}
+
+		if err := xml.EscapeText(x.sheet, []byte(cell)); err != nil {
+			return err
+		}
+
+		if _, err := x.sheet.WriteString(`</t></is></c>`); err != nil {
+			return err
+		}
+	}
+
+	_, err := x.sheet.WriteString(`</row>`)
+	return err
+}
+
+// Flush pushes the buffered rows to the underlying writer
+func (x *XLSXStreamWriter) Flush() error {
+	if x.sheet != nil {
+		if err := x.sheet.Flush(); err != nil {
+			return err
+		}
+	}
+
+	return x.archive.Flush()
+}
+
+func (x *XLSXStreamWriter) Close() error {
+	if x.sheet == nil {
+		if err := x.nextSheet(); err != nil {
+			return err
+		}
+	}
+
+	if err := x.closeSheet(); err != nil {
+		return err
+	}
+
+	var sheets, relationships, overrides strings.Builder
+	for i := 1; i <= x.sheetCount; i++ {
+		name := x.sheetName
+		if i > 1 {
+			name = fmt.Sprintf("%s %d", x.sheetName, i)
+		}
+
+		var escapedName strings.Builder
+		if err := xml.EscapeText(&escapedName, []byte(name)); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(&sheets, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, escapedName.String(), i, i)
+		fmt.Fprintf(&relationships, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i, i)
+		fmt.Fprintf(&overrides, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i)
+	}
+
+	parts := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` + overrides.String() + `</Types>`},
+		{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`},
+		{"xl/workbook.xml", xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>` + sheets.String() + `</sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` + relationships.String() + `</Relationships>`},
+	}
+
+	for _, part := range parts {
+		writer, err := x.archive.Create(part.name)
+		if err != nil {
+			return err
+		}
+
+		if _, err := io.WriteString(writer, part.content); err != nil {
+			return err
+		}
+	}
+
+	return x.archive.Close()
+}
+
+func (x *XLSXStreamWriter) nextSheet() error {
+	if x.sheet != nil {
+		if err := x.closeSheet(); err != nil {
+			return err
+		}
+	}
+
+	x.sheetCount++
+	x.rowCount = 0
+
+	writer, err := x.archive.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", x.sheetCount))
+	if err != nil {
+		return err
+	}
+
+	x.sheet = bufio.NewWriter(writer)
+	if _, err := x.sheet.WriteString(xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`); err != nil {
+		return err
+	}
+
+	// repeat the header on every additional sheet
+	if x.sheetCount > 1 && len(x.header) > 0 {
+		return x.WriteRow(x.header)
+	}
+
+	return nil
+}
+
+func (x *XLSXStreamWriter) closeSheet() error {
+	if _, err := x.sheet.WriteString(`</sheetData></worksheet>`); err != nil {
+		return err
+	}
+
+	return x.sheet.Flush()
+}
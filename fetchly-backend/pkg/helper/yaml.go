@@ -0,0 +1,37 @@
+package helper
+
+import (
+	"encoding/json"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MarshalYAML encodes v as YAML under the keys of its JSON encoding, so the types only need their JSON tags
+func MarshalYAML(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var document any
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+
+	return yaml.Marshal(document)
+}
+
+// UnmarshalYAML decodes a YAML document into v through the keys of its JSON encoding
+func UnmarshalYAML(data []byte, v any) error {
+	var document any
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(document)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,194 @@
+package storage
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// databaseChunkSize is the size of the parts a file is stored in, a file is never held in memory at once
+const databaseChunkSize = 1 << 20
+
+// storedFileChunks is a part of a stored file, the parts of a file are numbered from 0
+type storedFileChunks struct {
+	FileKey   string    `gorm:"column:file_key;primaryKey"`
+	Part      int       `gorm:"column:part;primaryKey"`
+	Data      []byte    `gorm:"column:data"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+}
+
+func (storedFileChunks) TableName() string {
+	return "stored_file_chunks"
+}
+
+type databaseStorage struct {
+	db *gorm.DB
+}
+
+// NewDatabaseStorage stores files in the stored_file_chunks table, so a file written by one instance
+// can be read by every other instance
+func NewDatabaseStorage(db *gorm.DB) Storage {
+	return &databaseStorage{
+		db: db,
+	}
+}
+
+// Create replaces the file of key, its parts are written as they fill up and the last one on close
+func (s *databaseStorage) Create(key string) (io.WriteCloser, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.Delete(key); err != nil {
+		return nil, err
+	}
+
+	return &chunkWriter{
+		db:        s.db,
+		key:       key,
+		createdAt: time.Now(),
+	}, nil
+}
+
+func (s *databaseStorage) Open(key string) (io.ReadCloser, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var parts int64
+	if err := s.db.Model(&storedFileChunks{}).Where("file_key = ?", key).Count(&parts).Error; err != nil {
+		return nil, err
+	}
+
+	if parts == 0 {
+		return nil, os.ErrNotExist
+	}
+
+	return &chunkReader{
+		db:    s.db,
+		key:   key,
+		parts: int(parts),
+	}, nil
+}
+
+func (s *databaseStorage) Size(key string) (int64, error) {
+	key, err := cleanKey(key)
+	if err != nil {
+		return 0, err
+	}
+
+	var result struct {
+		Parts int64
+		Size  int64
+	}
+
+	err = s.db.Model(&storedFileChunks{}).
+		Select("COUNT(*) AS parts, COALESCE(SUM(LENGTH(data)), 0) AS size").
+		Where("file_key = ?", key).
+		Scan(&result).Error
+	if err != nil {
+		return 0, err
+	}
+
+	if result.Parts == 0 {
+		return 0, os.ErrNotExist
+	}
+
+	return result.Size, nil
+}
+
+func (s *databaseStorage) Delete(key string) error {
+	key, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Where("file_key = ?", key).Delete(&storedFileChunks{}).Error
+}
+
+// Purge removes every file created before olderThan
+func (s *databaseStorage) Purge(olderThan time.Time) error {
+	return s.db.Where("created_at < ?", olderThan).Delete(&storedFileChunks{}).Error
+}
+
+type chunkWriter struct {
+	db        *gorm.DB
+	key       string
+	createdAt time.Time
+	buffer    bytes.Buffer
+	part      int
+}
+
+func (w *chunkWriter) Write(p []byte) (int, error) {
+	n, _ := w.buffer.Write(p)
+
+	for w.buffer.Len() >= databaseChunkSize {
+		if err := w.flush(w.buffer.Next(databaseChunkSize)); err != nil {
+			return n, err
+		}
+	}
+
+	return n, nil
+}
+
+// Close writes the rest of the buffer, an empty file is stored as a single empty part
+func (w *chunkWriter) Close() error {
+	if w.buffer.Len() == 0 && w.part > 0 {
+		return nil
+	}
+
+	return w.flush(w.buffer.Next(w.buffer.Len()))
+}
+
+func (w *chunkWriter) flush(data []byte) error {
+	chunk := storedFileChunks{
+		FileKey:   w.key,
+		Part:      w.part,
+		Data:      append([]byte{}, data...),
+		CreatedAt: w.createdAt,
+	}
+
+	if err := w.db.Create(&chunk).Error; err != nil {
+		return err
+	}
+
+	w.part++
+	return nil
+}
+
+// chunkReader reads the parts of a file one at a time
+type chunkReader struct {
+	db     *gorm.DB
+	key    string
+	parts  int
+	part   int
+	buffer bytes.Reader
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for r.buffer.Len() == 0 {
+		if r.part >= r.parts {
+			return 0, io.EOF
+		}
+
+		chunk := storedFileChunks{}
+		err := r.db.Where("file_key = ? AND part = ?", r.key, r.part).Take(&chunk).Error
+		if err != nil {
+			return 0, err
+		}
+
+		r.buffer.Reset(chunk.Data)
+		r.part++
+	}
+
+	return r.buffer.Read(p)
+}
+
+func (r *chunkReader) Close() error {
+	return nil
+}
@@ -0,0 +1,147 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// DriverLocal keeps the files on the disk of the instance, only shared when the path is a shared volume
+	DriverLocal = "local"
+	// DriverDatabase keeps the files in the database shared by every instance
+	DriverDatabase = "database"
+)
+
+var ErrInvalidKey = errors.New("invalid storage key")
+
+// Storage keeps generated files such as exports. Keys are slash separated relative paths.
+type Storage interface {
+	Create(key string) (io.WriteCloser, error)
+	Open(key string) (io.ReadCloser, error)
+	Size(key string) (int64, error)
+	Delete(key string) error
+	Purge(olderThan time.Time) error
+}
+
+// New returns the storage of the driver, the local driver keeps its files under basePath
+func New(driver, basePath string, db *gorm.DB) (Storage, error) {
+	switch driver {
+	case DriverLocal:
+		return NewLocalStorage(basePath)
+	case DriverDatabase:
+		return NewDatabaseStorage(db), nil
+	}
+
+	return nil, fmt.Errorf("unsupported storage driver %v", driver)
+}
+
+type localStorage struct {
+	basePath string
+}
+
+// NewLocalStorage stores files on the local disk under basePath
+func NewLocalStorage(basePath string) (Storage, error) {
+	if err := os.MkdirAll(basePath, 0o755); err != nil {
+		return nil, err
+	}
+
+	return &localStorage{
+		basePath: basePath,
+	}, nil
+}
+
+func (s *localStorage) Create(key string) (io.WriteCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+
+	return os.Create(path)
+}
+
+func (s *localStorage) Open(key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.Open(path)
+}
+
+func (s *localStorage) Size(key string) (int64, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return 0, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+
+	return info.Size(), nil
+}
+
+func (s *localStorage) Delete(key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// Purge removes every file last modified before olderThan
+func (s *localStorage) Purge(olderThan time.Time) error {
+	return filepath.WalkDir(s.basePath, func(path string, entry os.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		if info.ModTime().Before(olderThan) {
+			return os.Remove(path)
+		}
+
+		return nil
+	})
+}
+
+// path resolves the key inside basePath
+func (s *localStorage) path(key string) (string, error) {
+	cleanKey, err := cleanKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(s.basePath, filepath.FromSlash(cleanKey)), nil
+}
+
+// cleanKey normalizes a key to a slash separated relative path, rejecting keys escaping the storage
+func cleanKey(key string) (string, error) {
+	cleanKey := filepath.Clean(filepath.FromSlash(key))
+	if key == "" || filepath.IsAbs(cleanKey) || cleanKey == ".." || strings.HasPrefix(cleanKey, ".."+string(filepath.Separator)) {
+		return "", ErrInvalidKey
+	}
+
+	return filepath.ToSlash(cleanKey), nil
+}
@@ -0,0 +1,167 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func newTestStorages(t *testing.T) map[string]Storage {
+	t.Helper()
+
+	dir := t.TempDir()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(dir, "storage.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Exec(`CREATE TABLE stored_file_chunks (
+		file_key TEXT NOT NULL, part INTEGER NOT NULL, data BLOB NOT NULL, created_at DATETIME NOT NULL,
+		PRIMARY KEY (file_key, part)
+	)`).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	local, err := New(DriverLocal, filepath.Join(dir, "files"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	database, err := New(DriverDatabase, "", db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return map[string]Storage{DriverLocal: local, DriverDatabase: database}
+}
+
+func writeFile(t *testing.T, s Storage, key string, data []byte) {
+	t.Helper()
+
+	file, err := s.Create(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// written in uneven pieces so the parts of the database storage do not line up with the writes
+	for len(data) > 0 {
+		n := min(len(data), 300_000)
+		if _, err := file.Write(data[:n]); err != nil {
+			t.Fatal(err)
+		}
+		data = data[n:]
+	}
+
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStorage(t *testing.T) {
+	large := bytes.Repeat([]byte("0123456789"), databaseChunkSize/4)
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "empty file", data: []byte{}},
+		{name: "small file", data: []byte("a,b\n1,2\n")},
+		{name: "file of several parts", data: large},
+	}
+
+	for driver, s := range newTestStorages(t) {
+		for _, tt := range tests {
+			t.Run(driver+" "+tt.name, func(t *testing.T) {
+				key := "acme/" + driver + ".csv"
+				writeFile(t, s, key, []byte("replaced"))
+				writeFile(t, s, key, tt.data)
+
+				size, err := s.Size(key)
+				if err != nil || size != int64(len(tt.data)) {
+					t.Errorf("Size() = %d, %v, want %d", size, err, len(tt.data))
+				}
+
+				file, err := s.Open(key)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer file.Close()
+
+				got, err := io.ReadAll(file)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if !bytes.Equal(got, tt.data) {
+					t.Errorf("read %d bytes, want the %d bytes written", len(got), len(tt.data))
+				}
+
+				if err := s.Delete(key); err != nil {
+					t.Fatal(err)
+				}
+
+				if _, err := s.Open(key); !errors.Is(err, os.ErrNotExist) {
+					t.Errorf("Open() of a deleted file error = %v, want %v", err, os.ErrNotExist)
+				}
+			})
+		}
+	}
+}
+
+func TestStoragePurge(t *testing.T) {
+	for driver, s := range newTestStorages(t) {
+		t.Run(driver, func(t *testing.T) {
+			writeFile(t, s, "acme/old.csv", []byte("old"))
+			purgedAt := time.Now().Add(time.Second)
+
+			if err := s.Purge(purgedAt); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := s.Size("acme/old.csv"); !errors.Is(err, os.ErrNotExist) {
+				t.Errorf("Size() of a purged file error = %v, want %v", err, os.ErrNotExist)
+			}
+
+			writeFile(t, s, "acme/new.csv", []byte("new"))
+			if err := s.Purge(time.Now().Add(-time.Hour)); err != nil {
+				t.Fatal(err)
+			}
+
+			if size, err := s.Size("acme/new.csv"); err != nil || size != 3 {
+				t.Errorf("Size() of a kept file = %d, %v, want 3", size, err)
+			}
+		})
+	}
+}
+
+func TestInvalidKey(t *testing.T) {
+	keys := []string{"", "../outside.csv", "/etc/passwd", "acme/../../outside.csv"}
+
+	for driver, s := range newTestStorages(t) {
+		for _, key := range keys {
+			t.Run(driver+" "+key, func(t *testing.T) {
+				if _, err := s.Create(key); !errors.Is(err, ErrInvalidKey) {
+					t.Errorf("Create(%q) error = %v, want %v", key, err, ErrInvalidKey)
+				}
+
+				if _, err := s.Open(key); !errors.Is(err, ErrInvalidKey) {
+					t.Errorf("Open(%q) error = %v, want %v", key, err, ErrInvalidKey)
+				}
+			})
+		}
+	}
+}
+
+func TestNewOfUnknownDriver(t *testing.T) {
+	if _, err := New("s3", "", nil); err == nil {
+		t.Error("New() of an unknown driver returned no error")
+	}
+}
@@ -0,0 +1,171 @@
+package accessrepository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+type Roles struct {
+	ID           int            `gorm:"column:id" json:"id"`
+	Serial       string         `gorm:"column:serial" json:"serial"`
+	CreatedBy    string         `gorm:"column:created_by" json:"created_by"`
+	CreatedAt    time.Time      `gorm:"column:created_at" json:"created_at"`
+	UpdatedBy    string         `gorm:"column:updated_by" json:"updated_by"`
+	UpdatedAt    time.Time      `gorm:"column:updated_at" json:"updated_at"`
+	DeletedBy    sql.NullString `gorm:"column:deleted_by" json:"deleted_by"`
+	DeletedAt    gorm.DeletedAt `gorm:"column:deleted_at" json:"deleted_at"`
+	TenantSerial string         `gorm:"column:tenant_serial" json:"tenant_serial"`
+	Code         string         `gorm:"column:code" json:"code"`
+	Name         string         `gorm:"column:name" json:"name"`
+	Description  string         `gorm:"column:description" json:"description"`
+	IsAdmin      bool           `gorm:"column:is_admin" json:"is_admin"`
+}
+
+func (r *Roles) TableName() string {
+	return "roles"
+}
+
+func (r *Roles) ToEntity() entity.Role {
+	return entity.Role{
+		Serial:      r.Serial,
+		Code:        r.Code,
+		Name:        r.Name,
+		Description: r.Description,
+		IsAdmin:     r.IsAdmin,
+	}
+}
+
+type RoleObjectPermissions struct {
+	ID           int            `gorm:"column:id" json:"id"`
+	Serial       string         `gorm:"column:serial" json:"serial"`
+	CreatedBy    string         `gorm:"column:created_by" json:"created_by"`
+	CreatedAt    time.Time      `gorm:"column:created_at" json:"created_at"`
+	UpdatedBy    string         `gorm:"column:updated_by" json:"updated_by"`
+	UpdatedAt    time.Time      `gorm:"column:updated_at" json:"updated_at"`
+	DeletedBy    sql.NullString `gorm:"column:deleted_by" json:"deleted_by"`
+	DeletedAt    gorm.DeletedAt `gorm:"column:deleted_at" json:"deleted_at"`
+	RoleSerial   string         `gorm:"column:role_serial" json:"role_serial"`
+	ObjectSerial string         `gorm:"column:object_serial" json:"object_serial"`
+	CanCreate    bool           `gorm:"column:can_create" json:"can_create"`
+	CanRead      bool           `gorm:"column:can_read" json:"can_read"`
+	CanUpdate    bool           `gorm:"column:can_update" json:"can_update"`
+	CanDelete    bool           `gorm:"column:can_delete" json:"can_delete"`
+	CanExport    bool           `gorm:"column:can_export" json:"can_export"`
+}
+
+func (p *RoleObjectPermissions) TableName() string {
+	return "role_object_permissions"
+}
+
+func (p *RoleObjectPermissions) ToEntity() entity.RoleObjectPermission {
+	return entity.RoleObjectPermission{
+		RoleSerial:   p.RoleSerial,
+		ObjectSerial: p.ObjectSerial,
+		CanCreate:    p.CanCreate,
+		CanRead:      p.CanRead,
+		CanUpdate:    p.CanUpdate,
+		CanDelete:    p.CanDelete,
+		CanExport:    p.CanExport,
+	}
+}
+
+type RoleFieldPermissions struct {
+	ID                int            `gorm:"column:id" json:"id"`
+	Serial            string         `gorm:"column:serial" json:"serial"`
+	CreatedBy         string         `gorm:"column:created_by" json:"created_by"`
+	CreatedAt         time.Time      `gorm:"column:created_at" json:"created_at"`
+	UpdatedBy         string         `gorm:"column:updated_by" json:"updated_by"`
+	UpdatedAt         time.Time      `gorm:"column:updated_at" json:"updated_at"`
+	DeletedBy         sql.NullString `gorm:"column:deleted_by" json:"deleted_by"`
+	DeletedAt         gorm.DeletedAt `gorm:"column:deleted_at" json:"deleted_at"`
+	RoleSerial        string         `gorm:"column:role_serial" json:"role_serial"`
+	ObjectFieldSerial string         `gorm:"column:object_field_serial" json:"object_field_serial"`
+	CanRead           bool           `gorm:"column:can_read" json:"can_read"`
+	CanWrite          bool           `gorm:"column:can_write" json:"can_write"`
+	FieldCode         string         `gorm:"column:field_code;->" json:"field_code"`
+}
+
+func (p *RoleFieldPermissions) TableName() string {
+	return "role_field_permissions"
+}
+
+func (p *RoleFieldPermissions) ToEntity() entity.RoleFieldPermission {
+	return entity.RoleFieldPermission{
+		RoleSerial:        p.RoleSerial,
+		ObjectFieldSerial: p.ObjectFieldSerial,
+		FieldCode:         p.FieldCode,
+		CanRead:           p.CanRead,
+		CanWrite:          p.CanWrite,
+	}
+}
+
+type RowPolicies struct {
+	ID           int            `gorm:"column:id" json:"id"`
+	Serial       string         `gorm:"column:serial" json:"serial"`
+	CreatedBy    string         `gorm:"column:created_by" json:"created_by"`
+	CreatedAt    time.Time      `gorm:"column:created_at" json:"created_at"`
+	UpdatedBy    string         `gorm:"column:updated_by" json:"updated_by"`
+	UpdatedAt    time.Time      `gorm:"column:updated_at" json:"updated_at"`
+	DeletedBy    sql.NullString `gorm:"column:deleted_by" json:"deleted_by"`
+	DeletedAt    gorm.DeletedAt `gorm:"column:deleted_at" json:"deleted_at"`
+	ObjectSerial string         `gorm:"column:object_serial" json:"object_serial"`
+	RoleSerial   sql.NullString `gorm:"column:role_serial" json:"role_serial"`
+	Code         string         `gorm:"column:code" json:"code"`
+	Name         string         `gorm:"column:name" json:"name"`
+	Description  string         `gorm:"column:description" json:"description"`
+	Filters      datatypes.JSON `gorm:"column:filters" json:"filters"`
+	IsActive     bool           `gorm:"column:is_active" json:"is_active"`
+}
+
+// rowPolicyFilterGroup is the stored form of a filter group, its operator is always a group operator
+type rowPolicyFilterGroup struct {
+	Operator   entity.FilterGroupOperator   `json:"operator"`
+	Filters    map[string]entity.FilterItem `json:"filter_item"`
+	Conditions []entity.FilterItem          `json:"conditions"`
+	Groups     []rowPolicyFilterGroup       `json:"groups"`
+}
+
+func (g rowPolicyFilterGroup) toEntity() entity.FilterGroup {
+	filterGroup := entity.FilterGroup{
+		Operator:   entity.NewFilterGroupOperator(g.Operator),
+		Filters:    g.Filters,
+		Conditions: g.Conditions,
+	}
+
+	for _, child := range g.Groups {
+		filterGroup.Groups = append(filterGroup.Groups, child.toEntity())
+	}
+
+	return filterGroup
+}
+
+func (p *RowPolicies) TableName() string {
+	return "row_policies"
+}
+
+func (p *RowPolicies) ToEntity() (entity.RowPolicy, error) {
+	filterGroups := []rowPolicyFilterGroup{}
+	if err := json.Unmarshal(p.Filters, &filterGroups); err != nil {
+		return entity.RowPolicy{}, fmt.Errorf("row policy %v has invalid filters: %w", p.Code, err)
+	}
+
+	filters := make([]entity.FilterGroup, 0, len(filterGroups))
+	for _, filterGroup := range filterGroups {
+		filters = append(filters, filterGroup.toEntity())
+	}
+
+	return entity.RowPolicy{
+		Serial:       p.Serial,
+		ObjectSerial: p.ObjectSerial,
+		RoleSerial:   p.RoleSerial.String,
+		Code:         p.Code,
+		Name:         p.Name,
+		Filters:      filters,
+	}, nil
+}
@@ -0,0 +1,160 @@
+package accessrepository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fetchlydev/source/fetchly-backend/config"
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+	repository_intf "github.com/fetchlydev/source/fetchly-backend/core/repository"
+	querybuilder "github.com/fetchlydev/source/fetchly-backend/repository/query_builder"
+	"gorm.io/gorm"
+)
+
+// userObjectCode is the table of every tenant schema holding its users
+const userObjectCode = "user"
+
+type repository struct {
+	cfg config.Config
+	db  *gorm.DB
+}
+
+func New(cfg config.Config, db *gorm.DB) repository_intf.AccessRepository {
+	return &repository{
+		cfg: cfg,
+		db:  db,
+	}
+}
+
+func (r *repository) GetUserRoles(ctx context.Context, tenantCode, userSerial string) (resp []entity.Role, err error) {
+	db := r.db.WithContext(ctx).Model(&Roles{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	results := []Roles{}
+	err = db.Joins("JOIN user_roles ON user_roles.role_serial = roles.serial AND user_roles.deleted_at IS NULL").
+		Joins("JOIN tenants ON tenants.serial = roles.tenant_serial").
+		Where("tenants.code = ?", tenantCode).
+		Where("user_roles.user_serial = ?", userSerial).
+		Find(&results).Error
+	if err != nil {
+		return resp, err
+	}
+
+	for _, result := range results {
+		resp = append(resp, result.ToEntity())
+	}
+
+	return resp, nil
+}
+
+// GetObjectPermissions returns the permissions of every role on the object
+func (r *repository) GetObjectPermissions(ctx context.Context, tenantCode, objectCode string) (resp []entity.RoleObjectPermission, err error) {
+	db := r.db.WithContext(ctx).Model(&RoleObjectPermissions{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	results := []RoleObjectPermissions{}
+	err = db.Joins("JOIN objects ON objects.serial = role_object_permissions.object_serial").
+		Joins("JOIN tenants ON tenants.serial = objects.tenant_serial").
+		Where("tenants.code = ?", tenantCode).
+		Where("objects.code = ?", objectCode).
+		Find(&results).Error
+	if err != nil {
+		return resp, err
+	}
+
+	for _, result := range results {
+		resp = append(resp, result.ToEntity())
+	}
+
+	return resp, nil
+}
+
+// GetFieldPermissions returns the field permissions of the given roles on the fields of the object
+func (r *repository) GetFieldPermissions(ctx context.Context, tenantCode, objectCode string, roleSerials []string) (resp []entity.RoleFieldPermission, err error) {
+	if len(roleSerials) == 0 {
+		return resp, nil
+	}
+
+	db := r.db.WithContext(ctx).Model(&RoleFieldPermissions{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	results := []RoleFieldPermissions{}
+	err = db.Select("role_field_permissions.*, object_fields.field_code").
+		Joins("JOIN object_fields ON object_fields.serial = role_field_permissions.object_field_serial").
+		Joins("JOIN objects ON objects.serial = object_fields.object_serial").
+		Joins("JOIN tenants ON tenants.serial = objects.tenant_serial").
+		Where("tenants.code = ?", tenantCode).
+		Where("objects.code = ?", objectCode).
+		Where("role_field_permissions.role_serial IN ?", roleSerials).
+		Find(&results).Error
+	if err != nil {
+		return resp, err
+	}
+
+	for _, result := range results {
+		resp = append(resp, result.ToEntity())
+	}
+
+	return resp, nil
+}
+
+// GetRowPolicies returns the active row policies of the object
+func (r *repository) GetRowPolicies(ctx context.Context, tenantCode, objectCode string) (resp []entity.RowPolicy, err error) {
+	db := r.db.WithContext(ctx).Model(&RowPolicies{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	results := []RowPolicies{}
+	err = db.Joins("JOIN objects ON objects.serial = row_policies.object_serial").
+		Joins("JOIN tenants ON tenants.serial = objects.tenant_serial").
+		Where("tenants.code = ?", tenantCode).
+		Where("objects.code = ?", objectCode).
+		Where("row_policies.is_active = ?", true).
+		Find(&results).Error
+	if err != nil {
+		return resp, err
+	}
+
+	for _, result := range results {
+		policy, err := result.ToEntity()
+		if err != nil {
+			return resp, err
+		}
+
+		resp = append(resp, policy)
+	}
+
+	return resp, nil
+}
+
+// GetUserAttributes returns the columns of the user record, or an empty map when the user does not exist
+func (r *repository) GetUserAttributes(ctx context.Context, tenantCode, userSerial string) (resp map[string]any, err error) {
+	db := r.db.WithContext(ctx)
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	results := []map[string]any{}
+	query := fmt.Sprintf(`SELECT * FROM %v WHERE "serial" = ? LIMIT 1`, querybuilder.Table(tenantCode, userObjectCode))
+	if err := db.Raw(query, userSerial).Scan(&results).Error; err != nil {
+		return resp, err
+	}
+
+	if len(results) == 0 {
+		return map[string]any{}, nil
+	}
+
+	return results[0], nil
+}
@@ -1 +1,78 @@
 package authrepository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+)
+
+type AuthSessions struct {
+	ID            int            `gorm:"column:id;primaryKey" json:"id"`
+	Serial        string         `gorm:"column:serial" json:"serial"`
+	TenantCode    string         `gorm:"column:tenant_code" json:"tenant_code"`
+	UserSerial    string         `gorm:"column:user_serial" json:"user_serial"`
+	UserAgent     string         `gorm:"column:user_agent" json:"user_agent"`
+	IPAddress     string         `gorm:"column:ip_address" json:"ip_address"`
+	CreatedAt     time.Time      `gorm:"column:created_at" json:"created_at"`
+	LastUsedAt    time.Time      `gorm:"column:last_used_at" json:"last_used_at"`
+	ExpiresAt     time.Time      `gorm:"column:expires_at" json:"expires_at"`
+	RevokedAt     sql.NullTime   `gorm:"column:revoked_at" json:"revoked_at"`
+	RevokedReason sql.NullString `gorm:"column:revoked_reason" json:"revoked_reason"`
+}
+
+func (s *AuthSessions) TableName() string {
+	return "auth_sessions"
+}
+
+func (s *AuthSessions) ToEntity() entity.AuthSession {
+	session := entity.AuthSession{
+		Serial:        s.Serial,
+		TenantCode:    s.TenantCode,
+		UserSerial:    s.UserSerial,
+		UserAgent:     s.UserAgent,
+		IPAddress:     s.IPAddress,
+		CreatedAt:     s.CreatedAt,
+		LastUsedAt:    s.LastUsedAt,
+		ExpiresAt:     s.ExpiresAt,
+		RevokedReason: s.RevokedReason.String,
+	}
+
+	if s.RevokedAt.Valid {
+		session.RevokedAt = &s.RevokedAt.Time
+	}
+
+	return session
+}
+
+type AuthRefreshTokens struct {
+	ID            int            `gorm:"column:id;primaryKey" json:"id"`
+	Serial        string         `gorm:"column:serial" json:"serial"`
+	SessionSerial string         `gorm:"column:session_serial" json:"session_serial"`
+	TokenHash     string         `gorm:"column:token_hash" json:"token_hash"`
+	CreatedAt     time.Time      `gorm:"column:created_at" json:"created_at"`
+	ExpiresAt     time.Time      `gorm:"column:expires_at" json:"expires_at"`
+	UsedAt        sql.NullTime   `gorm:"column:used_at" json:"used_at"`
+	ReplacedBy    sql.NullString `gorm:"column:replaced_by" json:"replaced_by"`
+}
+
+func (t *AuthRefreshTokens) TableName() string {
+	return "auth_refresh_tokens"
+}
+
+func (t *AuthRefreshTokens) ToEntity() entity.RefreshToken {
+	refreshToken := entity.RefreshToken{
+		Serial:        t.Serial,
+		SessionSerial: t.SessionSerial,
+		TokenHash:     t.TokenHash,
+		CreatedAt:     t.CreatedAt,
+		ExpiresAt:     t.ExpiresAt,
+		ReplacedBy:    t.ReplacedBy.String,
+	}
+
+	if t.UsedAt.Valid {
+		refreshToken.UsedAt = &t.UsedAt.Time
+	}
+
+	return refreshToken
+}
@@ -2,13 +2,26 @@ package authrepository
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/fetchlydev/source/fetchly-backend/config"
 	"github.com/fetchlydev/source/fetchly-backend/core/entity"
 	repository_intf "github.com/fetchlydev/source/fetchly-backend/core/repository"
+	"github.com/fetchlydev/source/fetchly-backend/pkg/helper"
+	querybuilder "github.com/fetchlydev/source/fetchly-backend/repository/query_builder"
+	"github.com/fetchlydev/source/fetchly-backend/repository/util"
 	"gorm.io/gorm"
 )
 
+// userObjectCode is the table of every tenant schema holding its users
+const userObjectCode = "user"
+
+// userLoginColumns are the columns a user can log in with, the ones missing from a tenant's user table are skipped
+var userLoginColumns = []string{"username", "email", "phone_number"}
+
 type repository struct {
 	cfg config.Config
 	db  *gorm.DB
@@ -21,28 +34,345 @@ func New(cfg config.Config, db *gorm.DB) repository_intf.AuthRepository {
 	}
 }
 
-func (r *repository) Login(ctx context.Context, request entity.LoginRequest) (resp entity.LoginResponse, err error) {
-	// Implement login logic here
-	return
+func (r *repository) GetUserByLogin(ctx context.Context, tenantCode, login string) (resp map[string]entity.DataItem, err error) {
+	tableColumns, err := r.getUserColumns(ctx, tenantCode)
+	if err != nil {
+		return resp, err
+	}
+
+	var conditions []string
+	var args []any
+	for _, column := range userLoginColumns {
+		if !tableColumns.Has(column) {
+			continue
+		}
+
+		conditions = append(conditions, fmt.Sprintf("%v = ?", querybuilder.QuoteIdentifier(column)))
+		args = append(args, login)
+	}
+
+	if len(conditions) == 0 {
+		return resp, fmt.Errorf("user table of tenant %v has no login column", tenantCode)
+	}
+
+	return r.getUser(ctx, tenantCode, tableColumns, querybuilder.NewExpr("("+strings.Join(conditions, " OR ")+")", args...))
+}
+
+func (r *repository) GetUserBySerial(ctx context.Context, tenantCode, userSerial string) (resp map[string]entity.DataItem, err error) {
+	tableColumns, err := r.getUserColumns(ctx, tenantCode)
+	if err != nil {
+		return resp, err
+	}
+
+	return r.getUser(ctx, tenantCode, tableColumns, querybuilder.NewExpr(`"serial" = ?`, userSerial))
+}
+
+func (r *repository) CreateSession(ctx context.Context, session entity.AuthSession, refreshToken entity.RefreshToken) (resp entity.AuthSession, err error) {
+	now := time.Now()
+
+	sessionModel := AuthSessions{
+		Serial:     session.Serial,
+		TenantCode: session.TenantCode,
+		UserSerial: session.UserSerial,
+		UserAgent:  session.UserAgent,
+		IPAddress:  session.IPAddress,
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiresAt:  refreshToken.ExpiresAt,
+	}
+
+	if sessionModel.Serial == "" {
+		if sessionModel.Serial, err = helper.GenerateUUUID(); err != nil {
+			return resp, err
+		}
+	}
+
+	tokenModel := AuthRefreshTokens{
+		Serial:        refreshToken.Serial,
+		SessionSerial: sessionModel.Serial,
+		TokenHash:     refreshToken.TokenHash,
+		CreatedAt:     now,
+		ExpiresAt:     refreshToken.ExpiresAt,
+	}
+
+	if tokenModel.Serial == "" {
+		if tokenModel.Serial, err = helper.GenerateUUUID(); err != nil {
+			return resp, err
+		}
+	}
+
+	err = r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if r.cfg.IsDebugMode {
+			tx = tx.Debug()
+		}
+
+		if err := tx.Create(&sessionModel).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&tokenModel).Error
+	})
+	if err != nil {
+		return resp, err
+	}
+
+	return sessionModel.ToEntity(), nil
+}
+
+func (r *repository) GetSessionBySerial(ctx context.Context, tenantCode, sessionSerial string) (resp entity.AuthSession, err error) {
+	db := r.db.WithContext(ctx).Model(&AuthSessions{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	result := AuthSessions{}
+	err = db.Where("tenant_code = ? AND serial = ?", tenantCode, sessionSerial).First(&result).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return resp, entity.ErrSessionNotFound
+	}
+
+	if err != nil {
+		return resp, err
+	}
+
+	return result.ToEntity(), nil
+}
+
+// GetUserSessions returns the active sessions of a user, most recently used first
+func (r *repository) GetUserSessions(ctx context.Context, tenantCode, userSerial string) (resp []entity.AuthSession, err error) {
+	db := r.db.WithContext(ctx).Model(&AuthSessions{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	results := []AuthSessions{}
+	err = db.Where("tenant_code = ? AND user_serial = ?", tenantCode, userSerial).
+		Where("revoked_at IS NULL AND expires_at > NOW()").
+		Order("last_used_at DESC").
+		Find(&results).Error
+	if err != nil {
+		return resp, err
+	}
+
+	resp = make([]entity.AuthSession, 0, len(results))
+	for _, result := range results {
+		resp = append(resp, result.ToEntity())
+	}
+
+	return resp, nil
 }
 
-func (r *repository) Logout(ctx context.Context) error {
-	// Implement logout logic here
+// GetRefreshTokenByHash returns the refresh token with its session, used and expired tokens are returned
+// as well so the caller can tell a reused token apart from an unknown one
+func (r *repository) GetRefreshTokenByHash(ctx context.Context, tenantCode, tokenHash string) (resp entity.RefreshToken, err error) {
+	db := r.db.WithContext(ctx).Model(&AuthRefreshTokens{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	result := AuthRefreshTokens{}
+	err = db.Where("token_hash = ?", tokenHash).First(&result).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return resp, entity.ErrInvalidRefreshToken
+	}
+
+	if err != nil {
+		return resp, err
+	}
+
+	session, err := r.GetSessionBySerial(ctx, tenantCode, result.SessionSerial)
+	if errors.Is(err, entity.ErrSessionNotFound) {
+		// the token belongs to another tenant
+		return resp, entity.ErrInvalidRefreshToken
+	}
+
+	if err != nil {
+		return resp, err
+	}
+
+	resp = result.ToEntity()
+	resp.Session = session
+
+	return resp, nil
+}
+
+// RotateRefreshToken marks current as used and stores next in its place. Only one caller can use a token,
+// the others get entity.ErrRefreshTokenReused.
+func (r *repository) RotateRefreshToken(ctx context.Context, current, next entity.RefreshToken) (err error) {
+	now := time.Now()
+
+	tokenModel := AuthRefreshTokens{
+		Serial:        next.Serial,
+		SessionSerial: current.SessionSerial,
+		TokenHash:     next.TokenHash,
+		CreatedAt:     now,
+		ExpiresAt:     next.ExpiresAt,
+	}
+
+	if tokenModel.Serial == "" {
+		if tokenModel.Serial, err = helper.GenerateUUUID(); err != nil {
+			return err
+		}
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if r.cfg.IsDebugMode {
+			tx = tx.Debug()
+		}
+
+		result := tx.Model(&AuthRefreshTokens{}).
+			Where("serial = ? AND used_at IS NULL", current.Serial).
+			Updates(map[string]any{"used_at": now, "replaced_by": tokenModel.Serial})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return entity.ErrRefreshTokenReused
+		}
+
+		if err := tx.Create(&tokenModel).Error; err != nil {
+			return err
+		}
+
+		result = tx.Model(&AuthSessions{}).
+			Where("serial = ? AND revoked_at IS NULL", current.SessionSerial).
+			Updates(map[string]any{"last_used_at": now, "expires_at": tokenModel.ExpiresAt})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return entity.ErrSessionRevoked
+		}
+
+		return nil
+	})
+}
+
+func (r *repository) RevokeSession(ctx context.Context, tenantCode, sessionSerial, reason string) error {
+	db := r.db.WithContext(ctx).Model(&AuthSessions{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	result := db.Where("tenant_code = ? AND serial = ? AND revoked_at IS NULL", tenantCode, sessionSerial).
+		Updates(map[string]any{"revoked_at": time.Now(), "revoked_reason": reason})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return entity.ErrSessionNotFound
+	}
+
 	return nil
 }
 
-func (r *repository) RefreshToken(ctx context.Context, refreshToken string) (string, error) {
-	// Implement refresh token logic here
-	return "", nil
+func (r *repository) RevokeUserSessions(ctx context.Context, tenantCode, userSerial, reason string) error {
+	db := r.db.WithContext(ctx).Model(&AuthSessions{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	return db.Where("tenant_code = ? AND user_serial = ? AND revoked_at IS NULL", tenantCode, userSerial).
+		Updates(map[string]any{"revoked_at": time.Now(), "revoked_reason": reason}).Error
 }
 
-func (r *repository) GetCurrentUser(ctx context.Context) (string, error) {
-	// Implement get current user logic here
-	return "", nil
+// RevokeTenantSessions revokes the sessions of every user of a tenant
+func (r *repository) RevokeTenantSessions(ctx context.Context, tenantCode, reason string) error {
+	db := r.db.WithContext(ctx).Model(&AuthSessions{})
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	return db.Where("tenant_code = ? AND revoked_at IS NULL", tenantCode).
+		Updates(map[string]any{"revoked_at": time.Now(), "revoked_reason": reason}).Error
+}
+
+// local function
+
+// getUserColumns returns the allow-list of the columns of the tenant's user table
+func (r *repository) getUserColumns(ctx context.Context, tenantCode string) (querybuilder.Columns, error) {
+	query := `
+	SELECT
+		col.column_name AS field_code,
+		col.udt_name AS data_type
+	FROM
+		information_schema.columns AS col
+	WHERE
+		col.table_schema = ?
+		AND col.table_name = ?
+	`
+
+	results := []map[string]any{}
+	if err := r.db.WithContext(ctx).Raw(query, tenantCode, userObjectCode).Scan(&results).Error; err != nil {
+		return nil, err
+	}
+
+	if len(results) == 0 {
+		return nil, fmt.Errorf("object %v is not found in tenant %v", userObjectCode, tenantCode)
+	}
+
+	return querybuilder.NewColumns(results), nil
 }
 
-func (r *repository) GetUserBySerial(ctx context.Context, userID string) (string, error) {
-	// Implement get user by serial logic here
+// getUser returns the first not deleted user matching condition, or entity.ErrUserNotFound
+func (r *repository) getUser(ctx context.Context, tenantCode string, tableColumns querybuilder.Columns, condition querybuilder.Expr) (resp map[string]entity.DataItem, err error) {
+	query := querybuilder.New(fmt.Sprintf("SELECT * FROM %v", querybuilder.Table(tenantCode, userObjectCode)))
+	query.WriteExpr(" WHERE ", condition)
+
+	if tableColumns.Has("deleted_at") {
+		query.Write(" AND deleted_at IS NULL")
+	}
+
+	query.Write(" LIMIT 1")
+
+	db := r.db.WithContext(ctx)
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	rows, err := db.Raw(query.SQL(), query.Args()...).Rows()
+	if err != nil {
+		return resp, err
+	}
+	defer rows.Close()
+
+	columnNames, err := rows.Columns()
+	if err != nil {
+		return resp, err
+	}
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return resp, err
+	}
+
+	var columnsList []map[string]any
+	for i, colName := range columnNames {
+		columnsList = append(columnsList, map[string]any{
+			entity.FieldDataType:           columnTypes[i].DatabaseTypeName(),
+			entity.FieldColumnCode:         colName,
+			entity.FieldColumnName:         helper.CapitalizeWords(helper.ReplaceUnderscoreWithSpace(colName)),
+			entity.FieldCompleteColumnCode: colName,
+		})
+	}
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return resp, err
+		}
+
+		return resp, entity.ErrUserNotFound
+	}
 
-	return "", nil
+	return util.HandleSingleRow(columnsList, rows, entity.CatalogQuery{TenantCode: tenantCode, ObjectCode: userObjectCode})
 }
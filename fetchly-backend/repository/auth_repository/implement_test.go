@@ -0,0 +1,192 @@
+package authrepository
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fetchlydev/source/fetchly-backend/config"
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// newTestRepository returns a repository on a sqlite database with the session tables
+func newTestRepository(t *testing.T) *repository {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "auth.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.AutoMigrate(&AuthSessions{}, &AuthRefreshTokens{}); err != nil {
+		t.Fatal(err)
+	}
+
+	return &repository{cfg: config.Config{}, db: db}
+}
+
+func createTestSession(t *testing.T, r *repository, tenantCode, userSerial, tokenHash string) entity.AuthSession {
+	t.Helper()
+
+	session, err := r.CreateSession(context.Background(), entity.AuthSession{TenantCode: tenantCode, UserSerial: userSerial}, entity.RefreshToken{
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return session
+}
+
+func TestRotateRefreshToken(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	session := createTestSession(t, r, "acme", "ann", "first")
+
+	current, err := r.GetRefreshTokenByHash(ctx, "acme", "first")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if current.UsedAt != nil || current.Session.Serial != session.Serial {
+		t.Fatalf("GetRefreshTokenByHash() = %+v, want unused token of %v", current, session.Serial)
+	}
+
+	next := entity.RefreshToken{TokenHash: "second", ExpiresAt: time.Now().Add(2 * time.Hour)}
+	if err := r.RotateRefreshToken(ctx, current, next); err != nil {
+		t.Fatal(err)
+	}
+
+	// the token handed out twice is only rotated by the first caller
+	if err := r.RotateRefreshToken(ctx, current, entity.RefreshToken{TokenHash: "third", ExpiresAt: time.Now().Add(time.Hour)}); !errors.Is(err, entity.ErrRefreshTokenReused) {
+		t.Fatalf("RotateRefreshToken() again error = %v, want %v", err, entity.ErrRefreshTokenReused)
+	}
+
+	tests := []struct {
+		name       string
+		tenantCode string
+		tokenHash  string
+		wantUsed   bool
+		wantErr    error
+	}{
+		{name: "rotated token", tenantCode: "acme", tokenHash: "first", wantUsed: true},
+		{name: "new token", tenantCode: "acme", tokenHash: "second"},
+		{name: "token of the losing rotation", tenantCode: "acme", tokenHash: "third", wantErr: entity.ErrInvalidRefreshToken},
+		{name: "token of another tenant", tenantCode: "globex", tokenHash: "second", wantErr: entity.ErrInvalidRefreshToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := r.GetRefreshTokenByHash(ctx, tt.tenantCode, tt.tokenHash)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetRefreshTokenByHash() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if err == nil && (token.UsedAt != nil) != tt.wantUsed {
+				t.Errorf("token used at %v, want used %v", token.UsedAt, tt.wantUsed)
+			}
+		})
+	}
+
+	// rotating extends the session to the new token
+	got, err := r.GetSessionBySerial(ctx, "acme", session.Serial)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !got.ExpiresAt.After(session.ExpiresAt) {
+		t.Errorf("session expires at %v, want after %v", got.ExpiresAt, session.ExpiresAt)
+	}
+}
+
+func TestRotateRefreshTokenOfRevokedSession(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	session := createTestSession(t, r, "acme", "ann", "first")
+
+	current, err := r.GetRefreshTokenByHash(ctx, "acme", "first")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.RevokeSession(ctx, "acme", session.Serial, entity.SessionRevokedReasonTokenReused); err != nil {
+		t.Fatal(err)
+	}
+
+	err = r.RotateRefreshToken(ctx, current, entity.RefreshToken{TokenHash: "second", ExpiresAt: time.Now().Add(time.Hour)})
+	if !errors.Is(err, entity.ErrSessionRevoked) {
+		t.Fatalf("RotateRefreshToken() error = %v, want %v", err, entity.ErrSessionRevoked)
+	}
+
+	// the rotation is rolled back as a whole
+	if _, err := r.GetRefreshTokenByHash(ctx, "acme", "second"); !errors.Is(err, entity.ErrInvalidRefreshToken) {
+		t.Errorf("GetRefreshTokenByHash() error = %v, want %v", err, entity.ErrInvalidRefreshToken)
+	}
+
+	if token, _ := r.GetRefreshTokenByHash(ctx, "acme", "first"); token.UsedAt != nil {
+		t.Errorf("token used at %v, want unused", token.UsedAt)
+	}
+}
+
+func TestRevokeSession(t *testing.T) {
+	tests := []struct {
+		name        string
+		tenantCode  string
+		isAll       bool
+		wantRevoked []bool
+		wantErr     error
+	}{
+		{name: "one session", tenantCode: "acme", wantRevoked: []bool{true, false, false}},
+		{name: "all sessions of the user", tenantCode: "acme", isAll: true, wantRevoked: []bool{true, true, false}},
+		{name: "session of another tenant", tenantCode: "globex", wantRevoked: []bool{false, false, false}, wantErr: entity.ErrSessionNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRepository(t)
+			ctx := context.Background()
+
+			sessions := []entity.AuthSession{
+				createTestSession(t, r, "acme", "ann", "ann1"),
+				createTestSession(t, r, "acme", "ann", "ann2"),
+				createTestSession(t, r, "acme", "bob", "bob1"),
+			}
+
+			var err error
+			if tt.isAll {
+				err = r.RevokeUserSessions(ctx, tt.tenantCode, "ann", entity.SessionRevokedReasonAllSessions)
+			} else {
+				err = r.RevokeSession(ctx, tt.tenantCode, sessions[0].Serial, entity.SessionRevokedReasonLogout)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("revoke error = %v, want %v", err, tt.wantErr)
+			}
+
+			for i, session := range sessions {
+				got, err := r.GetSessionBySerial(ctx, "acme", session.Serial)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if got.IsActive() == tt.wantRevoked[i] {
+					t.Errorf("session %d active %v, want revoked %v", i, got.IsActive(), tt.wantRevoked[i])
+				}
+			}
+
+			// a revoked session can not be revoked again
+			if tt.wantErr == nil {
+				err := r.RevokeSession(ctx, "acme", sessions[0].Serial, entity.SessionRevokedReasonLogout)
+				if !errors.Is(err, entity.ErrSessionNotFound) {
+					t.Errorf("RevokeSession() again error = %v, want %v", err, entity.ErrSessionNotFound)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package catalogrepository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+	querybuilder "github.com/fetchlydev/source/fetchly-backend/repository/query_builder"
+)
+
+// AggregateObjectData groups the records of the object and computes the metrics of every group. The rows are
+// keyed by the aliases of the groups and metrics, groups and metrics must carry an alias.
+func (r *repository) AggregateObjectData(ctx context.Context, request entity.AggregateQuery) (resp []map[string]any, err error) {
+	db, dialect, err := r.dataDB(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return resp, err
+	}
+
+	tableColumns, err := r.getTableColumns(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return resp, err
+	}
+
+	tableName := dialect.TableName(request.TenantCode, request.ObjectCode)
+	catalogQuery := entity.CatalogQuery{
+		TenantCode:  request.TenantCode,
+		ProductCode: request.ProductCode,
+		ObjectCode:  request.ObjectCode,
+		Filters:     request.Filters,
+	}
+
+	var selectClauses, groupClauses, joins []string
+	aliases := make(map[string]bool)
+	metricAliases := make(map[string]bool)
+
+	for _, group := range request.Groups {
+		expression, groupJoins, err := r.aggregateColumn(ctx, dialect, tableName, catalogQuery, tableColumns, group.FieldName)
+		if err != nil {
+			return resp, err
+		}
+
+		if group.Bucket != "" {
+			bucketExpression, ok := dialect.DateBucket(expression, group.Bucket)
+			if !ok {
+				return resp, fmt.Errorf("%w: unsupported date bucket %v", entity.ErrorBadRequest, group.Bucket)
+			}
+
+			expression = bucketExpression
+		}
+
+		joins = append(joins, groupJoins...)
+		groupClauses = append(groupClauses, expression)
+		selectClauses = append(selectClauses, fmt.Sprintf("%v AS %v", expression, dialect.QuoteIdentifier(group.Alias)))
+		aliases[group.Alias] = true
+	}
+
+	for _, metric := range request.Metrics {
+		var expression string
+		if metric.FieldName == "" {
+			if metric.Function != entity.AggregateFunctionCount {
+				return resp, fmt.Errorf("%w: %v needs a field", entity.ErrorBadRequest, metric.Function)
+			}
+
+			expression = "COUNT(*)"
+		} else {
+			column, metricJoins, err := r.aggregateColumn(ctx, dialect, tableName, catalogQuery, tableColumns, metric.FieldName)
+			if err != nil {
+				return resp, err
+			}
+
+			joins = append(joins, metricJoins...)
+
+			switch metric.Function {
+			case entity.AggregateFunctionCount:
+				expression = fmt.Sprintf("COUNT(%v)", column)
+			case entity.AggregateFunctionCountDistinct:
+				expression = fmt.Sprintf("COUNT(DISTINCT %v)", column)
+			case entity.AggregateFunctionSum, entity.AggregateFunctionAvg, entity.AggregateFunctionMin, entity.AggregateFunctionMax:
+				expression = fmt.Sprintf("%v(%v)", strings.ToUpper(string(metric.Function)), column)
+			default:
+				return resp, fmt.Errorf("%w: unsupported aggregate function %v", entity.ErrorBadRequest, metric.Function)
+			}
+		}
+
+		selectClauses = append(selectClauses, fmt.Sprintf("%v AS %v", expression, dialect.QuoteIdentifier(metric.Alias)))
+		aliases[metric.Alias] = true
+		metricAliases[metric.Alias] = true
+	}
+
+	query, err := r.buildSelectQuery(ctx, dialect, strings.Join(selectClauses, ", "), tableName, catalogQuery, nil, nil, tableColumns, joins...)
+	if err != nil {
+		return resp, err
+	}
+
+	if len(groupClauses) > 0 {
+		query.Write(" GROUP BY " + strings.Join(groupClauses, ", "))
+	}
+
+	// the groups are ordered by default, so the x axis of a chart follows the values of its group
+	var orderClauses []string
+	for _, order := range request.Orders {
+		if !aliases[order.FieldName] {
+			return resp, fmt.Errorf("%w: order %v is not a group or metric", entity.ErrorBadRequest, order.FieldName)
+		}
+
+		orderClauses = append(orderClauses, fmt.Sprintf("%v %v", dialect.QuoteIdentifier(order.FieldName), querybuilder.Direction(order.Direction)))
+	}
+
+	if len(orderClauses) == 0 {
+		orderClauses = groupClauses
+	}
+
+	if len(orderClauses) > 0 {
+		query.Write(" ORDER BY " + strings.Join(orderClauses, ", "))
+	}
+
+	if request.Limit > 0 {
+		dialect.Paginate(query, request.Limit, 0)
+	}
+
+	rows, err := db.Raw(query.SQL(), query.Args()...).Rows()
+	if err != nil {
+		return resp, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return resp, err
+	}
+
+	for rows.Next() {
+		values := make([]any, len(columns))
+		valuePointers := make([]any, len(columns))
+		for i := range values {
+			valuePointers[i] = &values[i]
+		}
+
+		if err := rows.Scan(valuePointers...); err != nil {
+			return resp, err
+		}
+
+		row := make(map[string]any, len(columns))
+		for i, column := range columns {
+			value := values[i]
+			if bytesValue, ok := value.([]byte); ok {
+				value = string(bytesValue)
+			}
+
+			// sums and averages of decimals are read as text by some drivers
+			if stringValue, ok := value.(string); ok && metricAliases[column] {
+				if number, err := strconv.ParseFloat(stringValue, 64); err == nil {
+					value = number
+				}
+			}
+
+			row[column] = value
+		}
+
+		resp = append(resp, row)
+	}
+
+	return resp, rows.Err()
+}
+
+// aggregateColumn returns the quoted reference of a grouped or aggregated field and the joins a joined field needs
+func (r *repository) aggregateColumn(ctx context.Context, dialect querybuilder.Dialect, tableName string, request entity.CatalogQuery, tableColumns querybuilder.Columns, fieldName string) (string, []string, error) {
+	if !strings.Contains(fieldName, "__") {
+		reference, err := tableColumns.ReferenceIn(dialect, tableName, fieldName)
+		return reference, nil, err
+	}
+
+	reference, joinQueryMap, joinQueryOrder, err := r.chainedColumn(ctx, dialect, tableName, request, fieldName)
+	if err != nil {
+		return "", nil, err
+	}
+
+	joins := make([]string, 0, len(joinQueryOrder))
+	for _, joinKey := range joinQueryOrder {
+		joins = append(joins, joinQueryMap[joinKey])
+	}
+
+	return reference, joins, nil
+}
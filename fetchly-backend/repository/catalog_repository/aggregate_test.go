@@ -0,0 +1,116 @@
+package catalogrepository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+)
+
+func TestAggregateObjectData(t *testing.T) {
+	r := newTestRepository(t)
+
+	db, _, err := r.dataDB(context.Background(), "acme", "remote")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Exec(`CREATE TABLE remote (serial TEXT, stage TEXT, owner TEXT, amount NUMERIC, closed_at DATE, deleted_at DATETIME)`).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Exec(`INSERT INTO remote VALUES
+		('1', 'won', 'ann', 100, '2024-05-02', NULL),
+		('2', 'won', 'bob', 50, '2024-05-20', NULL),
+		('3', 'lost', 'ann', 30, '2024-06-01', NULL),
+		('4', 'won', 'ann', 20, '2024-06-03', NULL),
+		('5', 'won', 'ann', 1000, '2024-06-03', '2024-06-04')`).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	count := entity.AggregateMetric{Function: entity.AggregateFunctionCount, Alias: "deals"}
+	sum := entity.AggregateMetric{Function: entity.AggregateFunctionSum, FieldName: "amount", Alias: "total"}
+
+	tests := []struct {
+		name    string
+		query   entity.AggregateQuery
+		want    []string
+		wantErr error
+	}{
+		{
+			name:  "count of every record left out the deleted",
+			query: entity.AggregateQuery{Metrics: []entity.AggregateMetric{count}},
+			want:  []string{"map[deals:4]"},
+		},
+		{
+			name: "groups ordered by default",
+			query: entity.AggregateQuery{
+				Groups:  []entity.AggregateGroup{{FieldName: "stage", Alias: "stage"}},
+				Metrics: []entity.AggregateMetric{count, sum},
+			},
+			want: []string{"map[deals:1 stage:lost total:30]", "map[deals:3 stage:won total:170]"},
+		},
+		{
+			name: "filtered and ordered by a metric",
+			query: entity.AggregateQuery{
+				Filters: []entity.FilterGroup{{Filters: map[string]entity.FilterItem{"stage": {Operator: entity.FilterOperatorEqual, Value: "won"}}}},
+				Groups:  []entity.AggregateGroup{{FieldName: "owner", Alias: "owner"}},
+				Metrics: []entity.AggregateMetric{sum},
+				Orders:  []entity.Order{{FieldName: "total", Direction: "desc"}},
+				Limit:   1,
+			},
+			want: []string{"map[owner:ann total:120]"},
+		},
+		{
+			name: "month bucket",
+			query: entity.AggregateQuery{
+				Groups:  []entity.AggregateGroup{{FieldName: "closed_at", Bucket: entity.DateBucketMonth, Alias: "month"}},
+				Metrics: []entity.AggregateMetric{{Function: entity.AggregateFunctionCountDistinct, FieldName: "owner", Alias: "owners"}},
+			},
+			want: []string{"map[month:2024-05-01 owners:2]", "map[month:2024-06-01 owners:1]"},
+		},
+		{
+			name: "order of an unknown alias",
+			query: entity.AggregateQuery{
+				Metrics: []entity.AggregateMetric{count},
+				Orders:  []entity.Order{{FieldName: "amount"}},
+			},
+			wantErr: entity.ErrorBadRequest,
+		},
+		{
+			name:    "sum without a field",
+			query:   entity.AggregateQuery{Metrics: []entity.AggregateMetric{{Function: entity.AggregateFunctionSum, Alias: "total"}}},
+			wantErr: entity.ErrorBadRequest,
+		},
+		{
+			name:    "unsupported function",
+			query:   entity.AggregateQuery{Metrics: []entity.AggregateMetric{{Function: "median", FieldName: "amount", Alias: "median"}}},
+			wantErr: entity.ErrorBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.query.TenantCode = "acme"
+			tt.query.ObjectCode = "remote"
+
+			rows, err := r.AggregateObjectData(context.Background(), tt.query)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("AggregateObjectData() error = %v, want %v", err, tt.wantErr)
+			}
+
+			got := []string{}
+			for _, row := range rows {
+				got = append(got, fmt.Sprint(row))
+			}
+
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) || len(got) != len(tt.want) {
+				t.Errorf("AggregateObjectData() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package catalogrepository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+	"github.com/fetchlydev/source/fetchly-backend/pkg/helper"
+	"gorm.io/gorm"
+)
+
+// CreateAuditLogs appends audit logs. On a repository bound to a transaction of the metadata database the logs are
+// written in it, committed or rolled back along with the changes they record. In a transaction of a data source
+// they are held back until the transaction commits, a rolled back change leaves no log.
+func (r *repository) CreateAuditLogs(ctx context.Context, logs []entity.AuditLog) (err error) {
+	if len(logs) == 0 {
+		return nil
+	}
+
+	// a held back log keeps the time of its change
+	for i := range logs {
+		if logs[i].CreatedAt.IsZero() {
+			logs[i].CreatedAt = time.Now()
+		}
+	}
+
+	if r.tx == nil {
+		return r.writeAuditLogs(ctx, r.db, logs)
+	}
+
+	if r.tx.dataSourceSerial != "" {
+		*r.tx.auditLogs = append(*r.tx.auditLogs, logs...)
+		return nil
+	}
+
+	return r.writeAuditLogs(ctx, r.tx.db, logs)
+}
+
+func (r *repository) writeAuditLogs(ctx context.Context, db *gorm.DB, logs []entity.AuditLog) (err error) {
+	if len(logs) == 0 {
+		return nil
+	}
+
+	rows := make([]AuditLogs, 0, len(logs))
+	for _, auditLog := range logs {
+		serial, err := helper.GenerateUUUID()
+		if err != nil {
+			return err
+		}
+
+		changes := auditLog.Changes
+		if changes == nil {
+			changes = []entity.FieldChange{}
+		}
+
+		rows = append(rows, AuditLogs{
+			Serial:       serial,
+			TenantCode:   auditLog.TenantCode,
+			ObjectCode:   auditLog.ObjectCode,
+			RecordSerial: auditLog.RecordSerial,
+			Action:       string(auditLog.Action),
+			UserSerial:   auditLog.UserSerial,
+			RequestID:    auditLog.RequestID,
+			Changes:      changes,
+			CreatedAt:    auditLog.CreatedAt,
+		})
+	}
+
+	if err := db.WithContext(ctx).Omit("id").Create(&rows).Error; err != nil {
+		return fmt.Errorf("failed to record the audit logs: %w", err)
+	}
+
+	return nil
+}
+
+// GetAuditLogs lists the audit logs of a tenant matching the query, the latest first
+func (r *repository) GetAuditLogs(ctx context.Context, request entity.AuditQuery) (resp entity.AuditLogResponse, err error) {
+	db := r.db.WithContext(ctx).Model(&AuditLogs{}).Where("tenant_code = ?", request.TenantCode)
+
+	if r.cfg.IsDebugMode {
+		db = db.Debug()
+	}
+
+	if request.ObjectCode != "" {
+		db = db.Where("object_code = ?", request.ObjectCode)
+	}
+
+	if request.RecordSerial != "" {
+		db = db.Where("record_serial = ?", request.RecordSerial)
+	}
+
+	if request.UserSerial != "" {
+		db = db.Where("user_serial = ?", request.UserSerial)
+	}
+
+	if request.RequestID != "" {
+		db = db.Where("request_id = ?", request.RequestID)
+	}
+
+	if request.Action != "" {
+		db = db.Where("action = ?", string(request.Action))
+	}
+
+	if request.From != nil {
+		db = db.Where("created_at >= ?", *request.From)
+	}
+
+	if request.To != nil {
+		db = db.Where("created_at < ?", *request.To)
+	}
+
+	if request.PageSize < 1 {
+		request.PageSize = 10
+	}
+
+	if request.Page < 1 {
+		request.Page = 1
+	}
+
+	var totalData int64
+	if err := db.Session(&gorm.Session{}).Count(&totalData).Error; err != nil {
+		return resp, fmt.Errorf("failed to count the audit logs: %w", err)
+	}
+
+	rows := []AuditLogs{}
+	err = db.Session(&gorm.Session{}).Order("created_at DESC").Order("id DESC").
+		Offset((request.Page - 1) * request.PageSize).
+		Limit(request.PageSize).
+		Find(&rows).Error
+	if err != nil {
+		return resp, fmt.Errorf("failed to get the audit logs: %w", err)
+	}
+
+	resp = entity.AuditLogResponse{
+		Page:      request.Page,
+		PageSize:  request.PageSize,
+		TotalData: int(totalData),
+		TotalPage: int(helper.GenerateTotalPage(totalData, int64(request.PageSize))),
+		Items:     make([]entity.AuditLog, 0, len(rows)),
+	}
+
+	for _, row := range rows {
+		resp.Items = append(resp.Items, row.ToEntity())
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,174 @@
+package catalogrepository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+	repository_intf "github.com/fetchlydev/source/fetchly-backend/core/repository"
+)
+
+// newAuditTestRepository returns the test repository with an audit log table in its metadata database and
+// object "local" of tenant "acme" in the metadata database
+func newAuditTestRepository(t *testing.T) *repository {
+	t.Helper()
+
+	r := newTestRepository(t)
+
+	err := r.db.Exec(`CREATE TABLE audit_logs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT, serial TEXT, tenant_code TEXT, object_code TEXT, record_serial TEXT,
+		action TEXT, user_serial TEXT, request_id TEXT, changes TEXT, created_at DATETIME
+	)`).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.dataSources.cache["acme.local"] = resolvedDataSource{expiresAt: time.Now().Add(time.Hour)}
+
+	return r
+}
+
+func countAuditLogs(t *testing.T, r *repository) (recordSerials []string) {
+	t.Helper()
+
+	if err := r.db.Model(&AuditLogs{}).Order("id").Pluck("record_serial", &recordSerials).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	return recordSerials
+}
+
+func TestAuditLogsFollowTheTransaction(t *testing.T) {
+	errRollback := errors.New("rollback")
+
+	auditLog := func(recordSerial string) []entity.AuditLog {
+		return []entity.AuditLog{{TenantCode: "acme", RecordSerial: recordSerial, Action: entity.AuditActionUpdate}}
+	}
+
+	tests := []struct {
+		name   string
+		object string
+		fn     func(txRepo repository_intf.CatalogRepository) error
+		want   []string
+	}{
+		{
+			name:   "data source commit",
+			object: "remote",
+			fn: func(txRepo repository_intf.CatalogRepository) error {
+				return txRepo.CreateAuditLogs(context.Background(), auditLog("1"))
+			},
+			want: []string{"1"},
+		},
+		{
+			name:   "data source rollback",
+			object: "remote",
+			fn: func(txRepo repository_intf.CatalogRepository) error {
+				if err := txRepo.CreateAuditLogs(context.Background(), auditLog("1")); err != nil {
+					return err
+				}
+
+				return errRollback
+			},
+		},
+		{
+			name:   "data source savepoint rollback",
+			object: "remote",
+			fn: func(txRepo repository_intf.CatalogRepository) error {
+				if err := txRepo.CreateAuditLogs(context.Background(), auditLog("1")); err != nil {
+					return err
+				}
+
+				savepointErr := txRepo.WithTransaction(context.Background(), "acme", "remote", func(savepointRepo repository_intf.CatalogRepository) error {
+					if err := savepointRepo.CreateAuditLogs(context.Background(), auditLog("2")); err != nil {
+						return err
+					}
+
+					return errRollback
+				})
+				if !errors.Is(savepointErr, errRollback) {
+					return savepointErr
+				}
+
+				return txRepo.CreateAuditLogs(context.Background(), auditLog("3"))
+			},
+			want: []string{"1", "3"},
+		},
+		{
+			name:   "metadata rollback",
+			object: "local",
+			fn: func(txRepo repository_intf.CatalogRepository) error {
+				if err := txRepo.CreateAuditLogs(context.Background(), auditLog("1")); err != nil {
+					return err
+				}
+
+				return errRollback
+			},
+		},
+		{
+			name:   "metadata commit",
+			object: "local",
+			fn: func(txRepo repository_intf.CatalogRepository) error {
+				return txRepo.CreateAuditLogs(context.Background(), auditLog("1"))
+			},
+			want: []string{"1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newAuditTestRepository(t)
+
+			err := r.WithTransaction(context.Background(), "acme", tt.object, tt.fn)
+			if err != nil && !errors.Is(err, errRollback) {
+				t.Fatal(err)
+			}
+
+			got := countAuditLogs(t, r)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got audit logs of %v, want %v", got, tt.want)
+			}
+
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("got audit logs of %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestNestedTransactionOfAnotherDataSource(t *testing.T) {
+	tests := []struct {
+		name    string
+		outer   string
+		inner   string
+		wantErr error
+	}{
+		{name: "same data source", outer: "remote", inner: "remote"},
+		{name: "metadata database in a data source transaction", outer: "remote", inner: "local", wantErr: entity.ErrorBadRequest},
+		{name: "data source in a metadata transaction", outer: "local", inner: "remote", wantErr: entity.ErrorBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newAuditTestRepository(t)
+
+			isCalled := false
+			err := r.WithTransaction(context.Background(), "acme", tt.outer, func(txRepo repository_intf.CatalogRepository) error {
+				return txRepo.WithTransaction(context.Background(), "acme", tt.inner, func(repository_intf.CatalogRepository) error {
+					isCalled = true
+					return nil
+				})
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("WithTransaction() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if isCalled != (tt.wantErr == nil) {
+				t.Errorf("nested transaction ran %v, want %v", isCalled, tt.wantErr == nil)
+			}
+		})
+	}
+}
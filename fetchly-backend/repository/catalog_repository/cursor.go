@@ -0,0 +1,326 @@
+package catalogrepository
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+	"github.com/fetchlydev/source/fetchly-backend/pkg/helper"
+	querybuilder "github.com/fetchlydev/source/fetchly-backend/repository/query_builder"
+	"github.com/fetchlydev/source/fetchly-backend/repository/util"
+	"gorm.io/gorm"
+)
+
+// cursorColumnPrefix names the order values selected next to the fields of a cursor page, they are removed
+// from the items before the page is returned
+const cursorColumnPrefix = "__cursor_"
+
+// cursorTimeLayout keeps the fractional seconds and the offset of a timestamp, so the cursor compares exactly
+const cursorTimeLayout = "2006-01-02 15:04:05.999999999-07:00"
+
+// catalogCursor is the position of a record in the order of a query. It carries the orders it was issued for,
+// a cursor is only valid for a query with the same orders.
+type catalogCursor struct {
+	Orders     []string `json:"o"`
+	Values     []any    `json:"v"`
+	IsBackward bool     `json:"b,omitempty"`
+}
+
+// getObjectDataByCursor reads the page after the cursor of the request, or before it for a backward cursor. The
+// page is keyed on the orders of the request with serial as the tiebreaker, so deep pages cost the same as the
+// first one and stay stable while records are added.
+func (r *repository) getObjectDataByCursor(
+	ctx context.Context,
+	db *gorm.DB,
+	dialect querybuilder.Dialect,
+	resp entity.CatalogResponse,
+	columnsString, tableName string,
+	request entity.CatalogQuery,
+	joinQueryMap map[string]string,
+	joinQueryOrder []string,
+	columnsList []map[string]any,
+	tableColumns querybuilder.Columns,
+) (entity.CatalogResponse, error) {
+	if !tableColumns.Has(entity.DEFAULT_IDENTIFIER) {
+		return resp, fmt.Errorf("%w: cursor pagination needs the serial column of table %v", entity.ErrorBadRequest, request.ObjectCode)
+	}
+
+	orderColumns, orderJoinMap, orderJoinOrder, err := buildOrderColumns(dialect, request, columnsList, tableColumns)
+	if err != nil {
+		return resp, err
+	}
+
+	if !isOrderedBySerial(request.Orders) {
+		serialReference, err := tableColumns.ReferenceIn(dialect, tableName, entity.DEFAULT_IDENTIFIER)
+		if err != nil {
+			return resp, err
+		}
+
+		orderColumns = append(orderColumns, orderColumn{
+			reference: serialReference,
+			dataType:  tableColumns.DataType(entity.DEFAULT_IDENTIFIER),
+			direction: "ASC",
+		})
+	}
+
+	orderKeys := make([]string, 0, len(request.Orders))
+	for _, order := range request.Orders {
+		orderKeys = append(orderKeys, fmt.Sprintf("%v %v", order.FieldName, querybuilder.Direction(order.Direction)))
+	}
+
+	cursor := catalogCursor{}
+	if request.Cursor != "" {
+		cursor, err = decodeCursor(request.Cursor, orderKeys, len(orderColumns))
+		if err != nil {
+			return resp, err
+		}
+	}
+
+	// a backward page reads the records before the cursor in the reverse order and flips them afterwards
+	queryColumns := orderColumns
+	if cursor.IsBackward {
+		queryColumns = make([]orderColumn, 0, len(orderColumns))
+		for _, column := range orderColumns {
+			column.direction = reverseDirection(column.direction)
+			queryColumns = append(queryColumns, column)
+		}
+	}
+
+	// the order values are selected along the fields, the next cursors are built from them
+	selectClause := columnsString
+	pageColumns := make([]map[string]any, 0, len(columnsList)+len(orderColumns))
+	pageColumns = append(pageColumns, columnsList...)
+	for i, column := range orderColumns {
+		alias := fmt.Sprintf("%v%d", cursorColumnPrefix, i)
+		selectClause = fmt.Sprintf("%v, %v AS %v", selectClause, column.reference, dialect.QuoteIdentifier(alias))
+		pageColumns = append(pageColumns, map[string]any{
+			entity.FieldColumnCode: alias,
+			entity.FieldDataType:   column.dataType,
+		})
+	}
+
+	orderJoins := make([]string, 0, len(orderJoinOrder))
+	for _, joinKey := range orderJoinOrder {
+		orderJoins = append(orderJoins, orderJoinMap[joinKey])
+	}
+
+	query, err := r.buildSelectQuery(ctx, dialect, selectClause, tableName, request, joinQueryMap, joinQueryOrder, tableColumns, orderJoins...)
+	if err != nil {
+		return resp, err
+	}
+
+	if request.Cursor != "" {
+		query.WriteExpr(" AND ", keysetCondition(dialect, queryColumns, cursor.Values))
+	}
+
+	orderClauses := make([]string, 0, len(queryColumns))
+	for _, column := range queryColumns {
+		orderClauses = append(orderClauses, column.clause())
+	}
+
+	query.Write(" ORDER BY " + strings.Join(orderClauses, ", "))
+
+	// one record more than the page tells whether another page follows
+	dialect.Paginate(query, request.PageSize+1, 0)
+
+	rows, err := db.Raw(query.SQL(), query.Args()...).Rows()
+	if err != nil {
+		return resp, err
+	}
+	defer rows.Close()
+
+	var cursorValues [][]any
+	for rows.Next() {
+		item, err := util.HandleSingleRow(pageColumns, rows, request)
+		if err != nil {
+			return resp, err
+		}
+
+		values := make([]any, len(orderColumns))
+		for i := range orderColumns {
+			alias := fmt.Sprintf("%v%d", cursorColumnPrefix, i)
+			values[i] = item[alias].Value
+			delete(item, alias)
+		}
+
+		resp.Items = append(resp.Items, item)
+		cursorValues = append(cursorValues, values)
+	}
+
+	if err := rows.Err(); err != nil {
+		return resp, err
+	}
+
+	hasMore := len(resp.Items) > request.PageSize
+	if hasMore {
+		resp.Items = resp.Items[:request.PageSize]
+		cursorValues = cursorValues[:request.PageSize]
+	}
+
+	if cursor.IsBackward {
+		for i, j := 0, len(resp.Items)-1; i < j; i, j = i+1, j-1 {
+			resp.Items[i], resp.Items[j] = resp.Items[j], resp.Items[i]
+			cursorValues[i], cursorValues[j] = cursorValues[j], cursorValues[i]
+		}
+	}
+
+	if len(resp.Items) > 0 {
+		// the first page has nothing before it, a backward page has when it was cut short
+		if (cursor.IsBackward && hasMore) || (!cursor.IsBackward && request.Cursor != "") {
+			resp.PrevCursor, err = encodeCursor(catalogCursor{Orders: orderKeys, Values: cursorValues[0], IsBackward: true})
+			if err != nil {
+				return resp, err
+			}
+		}
+
+		if cursor.IsBackward || hasMore {
+			resp.NextCursor, err = encodeCursor(catalogCursor{Orders: orderKeys, Values: cursorValues[len(cursorValues)-1]})
+			if err != nil {
+				return resp, err
+			}
+		}
+	}
+
+	resp.PageSize = request.PageSize
+	resp.TotalPage = int(helper.GenerateTotalPage(int64(resp.TotalData), int64(request.PageSize)))
+
+	return resp, nil
+}
+
+// keysetCondition matches the records after values in the order of columns, expanded as
+// (a > ?) OR (a = ? AND b > ?) OR ... so every column keeps its own direction
+func keysetCondition(dialect querybuilder.Dialect, columns []orderColumn, values []any) querybuilder.Expr {
+	var alternatives []querybuilder.Expr
+	var equalities []querybuilder.Expr
+
+	for i, column := range columns {
+		value := querybuilder.BindValue(column.dataType, values[i])
+
+		if after := column.after(dialect, value); !after.IsEmpty() {
+			alternative := make([]querybuilder.Expr, 0, len(equalities)+1)
+			alternative = append(alternative, equalities...)
+			alternative = append(alternative, after)
+
+			alternatives = append(alternatives, querybuilder.Join(alternative, " AND "))
+		}
+
+		equalities = append(equalities, column.equal(value))
+	}
+
+	if len(alternatives) == 0 {
+		return querybuilder.NewExpr("FALSE")
+	}
+
+	return querybuilder.Join(alternatives, " OR ")
+}
+
+// after matches the values sorting after value, NULL sorts where the dialect puts it. An empty expression
+// means nothing sorts after value on this column.
+func (o orderColumn) after(dialect querybuilder.Dialect, value any) querybuilder.Expr {
+	isAscending := o.direction == "ASC"
+	isNullAfter := isAscending == dialect.NullsLast()
+
+	if value == nil {
+		if isNullAfter {
+			return querybuilder.Expr{}
+		}
+
+		return querybuilder.NewExpr(fmt.Sprintf("%s IS NOT NULL", o.reference))
+	}
+
+	operator := ">"
+	if !isAscending {
+		operator = "<"
+	}
+
+	if isNullAfter {
+		return querybuilder.NewExpr(fmt.Sprintf("(%s %s ? OR %s IS NULL)", o.reference, operator, o.reference), value)
+	}
+
+	return querybuilder.NewExpr(fmt.Sprintf("%s %s ?", o.reference, operator), value)
+}
+
+func (o orderColumn) equal(value any) querybuilder.Expr {
+	if value == nil {
+		return querybuilder.NewExpr(fmt.Sprintf("%s IS NULL", o.reference))
+	}
+
+	return querybuilder.NewExpr(fmt.Sprintf("%s = ?", o.reference), value)
+}
+
+func reverseDirection(direction string) string {
+	if direction == "DESC" {
+		return "ASC"
+	}
+
+	return "DESC"
+}
+
+func isOrderedBySerial(orders []entity.Order) bool {
+	for _, order := range orders {
+		if order.FieldName == entity.DEFAULT_IDENTIFIER {
+			return true
+		}
+	}
+
+	return false
+}
+
+func encodeCursor(cursor catalogCursor) (string, error) {
+	for i, value := range cursor.Values {
+		if timeValue, ok := value.(time.Time); ok {
+			cursor.Values[i] = timeValue.Format(cursorTimeLayout)
+		}
+	}
+
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor reads a cursor issued for the orders, numbers are kept exact instead of going through float64
+func decodeCursor(value string, orders []string, valueCount int) (cursor catalogCursor, err error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, fmt.Errorf("%w: invalid cursor", entity.ErrorBadRequest)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&cursor); err != nil {
+		return cursor, fmt.Errorf("%w: invalid cursor", entity.ErrorBadRequest)
+	}
+
+	if len(cursor.Values) != valueCount || len(cursor.Orders) != len(orders) {
+		return cursor, fmt.Errorf("%w: cursor does not match the orders of the query", entity.ErrorBadRequest)
+	}
+
+	for i, order := range orders {
+		if cursor.Orders[i] != order {
+			return cursor, fmt.Errorf("%w: cursor does not match the orders of the query", entity.ErrorBadRequest)
+		}
+	}
+
+	for i, value := range cursor.Values {
+		number, ok := value.(json.Number)
+		if !ok {
+			continue
+		}
+
+		if intValue, err := number.Int64(); err == nil {
+			cursor.Values[i] = intValue
+		} else if floatValue, err := number.Float64(); err == nil {
+			cursor.Values[i] = floatValue
+		}
+	}
+
+	return cursor, nil
+}
@@ -0,0 +1,165 @@
+package catalogrepository
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+	querybuilder "github.com/fetchlydev/source/fetchly-backend/repository/query_builder"
+)
+
+func TestKeysetCondition(t *testing.T) {
+	name := func(direction string) orderColumn {
+		return orderColumn{reference: `"name"`, dataType: "varchar", direction: direction}
+	}
+	serial := orderColumn{reference: `"serial"`, dataType: "uuid", direction: "ASC"}
+
+	tests := []struct {
+		name     string
+		dialect  querybuilder.Dialect
+		columns  []orderColumn
+		values   []any
+		wantSQL  string
+		wantArgs []any
+	}{
+		{
+			name:     "ascending with nulls last",
+			dialect:  querybuilder.Postgres,
+			columns:  []orderColumn{name("ASC"), serial},
+			values:   []any{"ann", "s1"},
+			wantSQL:  `((("name" > ? OR "name" IS NULL)) OR ("name" = ? AND ("serial" > ? OR "serial" IS NULL)))`,
+			wantArgs: []any{"ann", "ann", "s1"},
+		},
+		{
+			name:     "null ascending with nulls last is followed by the nulls only",
+			dialect:  querybuilder.Postgres,
+			columns:  []orderColumn{name("ASC"), serial},
+			values:   []any{nil, "s1"},
+			wantSQL:  `(("name" IS NULL AND ("serial" > ? OR "serial" IS NULL)))`,
+			wantArgs: []any{"s1"},
+		},
+		{
+			name:     "null descending with nulls last is followed by the values",
+			dialect:  querybuilder.Postgres,
+			columns:  []orderColumn{name("DESC"), serial},
+			values:   []any{nil, "s1"},
+			wantSQL:  `(("name" IS NOT NULL) OR ("name" IS NULL AND ("serial" > ? OR "serial" IS NULL)))`,
+			wantArgs: []any{"s1"},
+		},
+		{
+			name:     "ascending with nulls first",
+			dialect:  querybuilder.MySQL,
+			columns:  []orderColumn{name("ASC"), serial},
+			values:   []any{"ann", "s1"},
+			wantSQL:  `(("name" > ?) OR ("name" = ? AND "serial" > ?))`,
+			wantArgs: []any{"ann", "ann", "s1"},
+		},
+		{
+			name:     "descending with nulls first",
+			dialect:  querybuilder.SQLite,
+			columns:  []orderColumn{name("DESC"), serial},
+			values:   []any{"ann", "s1"},
+			wantSQL:  `((("name" < ? OR "name" IS NULL)) OR ("name" = ? AND "serial" > ?))`,
+			wantArgs: []any{"ann", "ann", "s1"},
+		},
+		{
+			name:     "null ascending with nulls first is followed by the values",
+			dialect:  querybuilder.SQLite,
+			columns:  []orderColumn{name("ASC"), serial},
+			values:   []any{nil, "s1"},
+			wantSQL:  `(("name" IS NOT NULL) OR ("name" IS NULL AND "serial" > ?))`,
+			wantArgs: []any{"s1"},
+		},
+		{
+			name:    "nothing after the last null",
+			dialect: querybuilder.Postgres,
+			columns: []orderColumn{name("ASC")},
+			values:  []any{nil},
+			wantSQL: "FALSE",
+		},
+		{
+			name:     "descending with nulls last binds whole numbers of an integer column",
+			dialect:  querybuilder.Postgres,
+			columns:  []orderColumn{{reference: `"age"`, dataType: "int4", direction: "DESC"}},
+			values:   []any{float64(30)},
+			wantSQL:  `(("age" < ?))`,
+			wantArgs: []any{int64(30)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr := keysetCondition(tt.dialect, tt.columns, tt.values)
+			assertExpr(t, expr, tt.wantSQL, tt.wantArgs)
+		})
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	createdAt := time.Date(2024, 5, 6, 7, 8, 9, 123456789, time.FixedZone("", 7*3600))
+	orders := []string{"created_at DESC", "amount ASC", "name ASC", "serial ASC"}
+
+	value, err := encodeCursor(catalogCursor{Orders: orders, Values: []any{createdAt, int64(9007199254740993), 1.5, nil}, IsBackward: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cursor, err := decodeCursor(value, orders, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []any{createdAt.Format(cursorTimeLayout), int64(9007199254740993), 1.5, nil}
+	for i := range want {
+		if cursor.Values[i] != want[i] {
+			t.Errorf("value %d = %#v, want %#v", i, cursor.Values[i], want[i])
+		}
+	}
+
+	if !cursor.IsBackward {
+		t.Error("cursor lost its direction")
+	}
+}
+
+func TestDecodeCursorRejects(t *testing.T) {
+	orders := []string{"name ASC", "serial ASC"}
+	issued, err := encodeCursor(catalogCursor{Orders: orders, Values: []any{"ann", "s1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		value      string
+		orders     []string
+		valueCount int
+	}{
+		{name: "not base64", value: "%%%", orders: orders, valueCount: 2},
+		{name: "not json", value: "bm90IGpzb24", orders: orders, valueCount: 2},
+		{name: "other orders", value: issued, orders: []string{"name DESC", "serial ASC"}, valueCount: 2},
+		{name: "fewer orders", value: issued, orders: orders[:1], valueCount: 2},
+		{name: "other value count", value: issued, orders: orders, valueCount: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeCursor(tt.value, tt.orders, tt.valueCount)
+			if !errors.Is(err, entity.ErrorBadRequest) {
+				t.Errorf("decodeCursor() = %v, want a bad request", err)
+			}
+		})
+	}
+}
+
+func TestReverseDirection(t *testing.T) {
+	for direction, want := range map[string]string{"ASC": "DESC", "DESC": "ASC"} {
+		if got := reverseDirection(direction); got != want {
+			t.Errorf("reverseDirection(%v) = %v, want %v", direction, got, want)
+		}
+	}
+
+	if !isOrderedBySerial([]entity.Order{{FieldName: "name"}, {FieldName: entity.DEFAULT_IDENTIFIER}}) {
+		t.Error("isOrderedBySerial() missed the serial order")
+	}
+}
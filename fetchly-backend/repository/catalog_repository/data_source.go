@@ -0,0 +1,138 @@
+package catalogrepository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+	"github.com/fetchlydev/source/fetchly-backend/pkg/conn"
+	querybuilder "github.com/fetchlydev/source/fetchly-backend/repository/query_builder"
+	"gorm.io/gorm"
+)
+
+// dataSourceResolver finds the data source holding the table of an object. Objects without a data source
+// keep their table in the metadata database.
+type dataSourceResolver struct {
+	registry *conn.DBRegistry
+	ttl      time.Duration
+	mu       sync.RWMutex
+	cache    map[string]resolvedDataSource
+}
+
+type resolvedDataSource struct {
+	dataSource *conn.DataSourceConfig
+	expiresAt  time.Time
+}
+
+// dataSourceTx is the transaction a repository is bound to, it only covers the objects of its data source
+type dataSourceTx struct {
+	dataSourceSerial string
+	db               *gorm.DB
+	// auditLogs are the audit logs of the changes made on a data source, shared with the nested transactions and
+	// written once the outermost transaction commits
+	auditLogs *[]entity.AuditLog
+}
+
+func newDataSourceResolver(registry *conn.DBRegistry, ttl time.Duration) *dataSourceResolver {
+	return &dataSourceResolver{
+		registry: registry,
+		ttl:      ttl,
+		cache:    make(map[string]resolvedDataSource),
+	}
+}
+
+// resolve returns the data source of the object, nil when the object is stored in the metadata database
+func (d *dataSourceResolver) resolve(ctx context.Context, metadataDB *gorm.DB, tenantCode, objectCode string) (*conn.DataSourceConfig, error) {
+	if d.registry == nil || tenantCode == entity.PUBLIC {
+		return nil, nil
+	}
+
+	cacheKey := tenantCode + "." + objectCode
+
+	d.mu.RLock()
+	cached, ok := d.cache[cacheKey]
+	d.mu.RUnlock()
+
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.dataSource, nil
+	}
+
+	result := DataSource{}
+	err := metadataDB.WithContext(ctx).Model(&DataSource{}).
+		Select("data_sources.*").
+		Joins("JOIN objects ON objects.data_source_serial = data_sources.serial AND objects.deleted_at IS NULL").
+		Joins("JOIN tenants ON tenants.serial = objects.tenant_serial").
+		Where("tenants.code = ?", tenantCode).
+		Where("objects.code = ?", objectCode).
+		First(&result).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to resolve data source of object %v: %w", objectCode, err)
+	}
+
+	var dataSource *conn.DataSourceConfig
+	if err == nil {
+		config := result.ToConfig()
+		dataSource = &config
+	}
+
+	d.mu.Lock()
+	d.cache[cacheKey] = resolvedDataSource{dataSource: dataSource, expiresAt: time.Now().Add(d.ttl)}
+	d.mu.Unlock()
+
+	return dataSource, nil
+}
+
+// dataDB returns the database holding the table of the object and the SQL dialect it speaks, the metadata
+// database is postgres. On a repository bound to a transaction the transaction is used for the objects of its
+// data source.
+func (r *repository) dataDB(ctx context.Context, tenantCode, objectCode string) (*gorm.DB, querybuilder.Dialect, error) {
+	dataSource, err := r.dataSources.resolve(ctx, r.db, tenantCode, objectCode)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if dataSource == nil {
+		if r.tx != nil && r.tx.dataSourceSerial == "" {
+			return r.tx.db, querybuilder.Postgres, nil
+		}
+
+		return r.db, querybuilder.Postgres, nil
+	}
+
+	if r.tx != nil && r.tx.dataSourceSerial == dataSource.Serial {
+		dialect, err := querybuilder.NewDialect(dataSource.Dialect())
+		return r.tx.db, dialect, err
+	}
+
+	return r.dataSourceDB(dataSource)
+}
+
+// dataSourceDB returns the pool of a data source and the SQL dialect it speaks, the metadata database for a nil
+// data source
+func (r *repository) dataSourceDB(dataSource *conn.DataSourceConfig) (*gorm.DB, querybuilder.Dialect, error) {
+	if dataSource == nil {
+		return r.db, querybuilder.Postgres, nil
+	}
+
+	dialect, err := querybuilder.NewDialect(dataSource.Dialect())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	db, err := r.dataSources.registry.Get(*dataSource)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return db, dialect, nil
+}
+
+// forget drops the cached data source of an object, after its object is created or dropped
+func (d *dataSourceResolver) forget(tenantCode, objectCode string) {
+	d.mu.Lock()
+	delete(d.cache, tenantCode+"."+objectCode)
+	d.mu.Unlock()
+}
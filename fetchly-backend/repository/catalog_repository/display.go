@@ -0,0 +1,152 @@
+package catalogrepository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+	querybuilder "github.com/fetchlydev/source/fetchly-backend/repository/query_builder"
+)
+
+// displayFallbackField is the display value of an object with neither a display template nor display fields
+const displayFallbackField = "name"
+
+// displayTemplates keeps the display template of the objects, read from their metadata
+type displayTemplates struct {
+	ttl       time.Duration
+	mu        sync.RWMutex
+	templates map[string]displayTemplate
+}
+
+type displayTemplate struct {
+	template  querybuilder.DisplayTemplate
+	expiresAt time.Time
+}
+
+func newDisplayTemplates(ttl time.Duration) *displayTemplates {
+	return &displayTemplates{
+		ttl:       ttl,
+		templates: make(map[string]displayTemplate),
+	}
+}
+
+// objectDisplayTemplate returns the display template of the object. The display template of the object comes first,
+// then the fields marked is_display_name separated by a space, then the name column. An object with none of them
+// has an empty template.
+func (r *repository) objectDisplayTemplate(ctx context.Context, tenantCode, objectCode string) (querybuilder.DisplayTemplate, error) {
+	cacheKey := tenantCode + "." + objectCode
+
+	r.displayTemplates.mu.RLock()
+	cached, ok := r.displayTemplates.templates[cacheKey]
+	r.displayTemplates.mu.RUnlock()
+
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.template, nil
+	}
+
+	tableColumns, err := r.getTableColumns(ctx, tenantCode, objectCode)
+	if err != nil {
+		return querybuilder.DisplayTemplate{}, err
+	}
+
+	object := Objects{}
+	err = r.db.WithContext(ctx).
+		Joins("JOIN tenants ON tenants.serial = objects.tenant_serial").
+		Where("tenants.code = ?", tenantCode).
+		Where("objects.code = ?", objectCode).
+		Limit(1).
+		Find(&object).Error
+	if err != nil {
+		return querybuilder.DisplayTemplate{}, fmt.Errorf("failed to get display template of object %v: %w", objectCode, err)
+	}
+
+	var template querybuilder.DisplayTemplate
+	switch {
+	case strings.TrimSpace(object.DisplayTemplate.String) != "":
+		template, err = querybuilder.ParseDisplayTemplate(object.DisplayTemplate.String)
+		if err != nil {
+			return template, fmt.Errorf("%w: object %v: %v", entity.ErrorBadRequest, objectCode, err)
+		}
+
+	case object.Serial != "":
+		var fieldCodes []string
+		err = r.db.WithContext(ctx).Model(&ObjectFields{}).
+			Where("object_serial = ?", object.Serial).
+			Where("is_display_name").
+			Order("id").
+			Pluck("field_code", &fieldCodes).Error
+		if err != nil {
+			return template, fmt.Errorf("failed to get display fields of object %v: %w", objectCode, err)
+		}
+
+		if len(fieldCodes) > 0 {
+			template, err = querybuilder.ParseDisplayTemplate("{" + strings.Join(fieldCodes, "} {") + "}")
+			if err != nil {
+				return template, err
+			}
+		}
+	}
+
+	if template.IsEmpty() && tableColumns.Has(displayFallbackField) {
+		template = querybuilder.NewDisplayField(displayFallbackField)
+	}
+
+	for _, fieldCode := range template.Fields() {
+		if !tableColumns.Has(fieldCode) {
+			return template, fmt.Errorf("%w: display field %v is not found in table %v", entity.ErrorBadRequest, fieldCode, objectCode)
+		}
+	}
+
+	r.displayTemplates.mu.Lock()
+	r.displayTemplates.templates[cacheKey] = displayTemplate{template: template, expiresAt: time.Now().Add(r.displayTemplates.ttl)}
+	r.displayTemplates.mu.Unlock()
+
+	return template, nil
+}
+
+// displayReference returns the display value of the records of the object in table, an already quoted reference.
+// An object without a display template shows keyColumn, the key its records are referenced by.
+func (r *repository) displayReference(ctx context.Context, dialect querybuilder.Dialect, tenantCode, objectCode, table, keyColumn string) (string, error) {
+	template, err := r.objectDisplayTemplate(ctx, tenantCode, objectCode)
+	if err != nil {
+		return "", err
+	}
+
+	if template.IsEmpty() {
+		template = querybuilder.NewDisplayField(keyColumn)
+	}
+
+	return template.Expression(dialect, table), nil
+}
+
+// chainedColumn returns the reference of a chained field such as user_serial__user_type_serial__code with the joins
+// it needs, the last join is aliased as the field name itself. A chain ending in __name is the display value of the
+// last related object, __name on its own the display value of the object.
+func (r *repository) chainedColumn(ctx context.Context, dialect querybuilder.Dialect, tableName string, request entity.CatalogQuery, fieldName string) (string, map[string]string, []string, error) {
+	if fieldName == entity.DisplayFieldSuffix {
+		reference, err := r.displayReference(ctx, dialect, request.TenantCode, request.ObjectCode, querybuilder.QuoteReferenceIn(dialect, tableName), entity.DEFAULT_IDENTIFIER)
+		return reference, map[string]string{}, nil, err
+	}
+
+	joinQueryMap, joinQueryOrder, target, err := r.handleChainingJoin(ctx, dialect, fieldName, tableName, request)
+	if err != nil {
+		return "", joinQueryMap, joinQueryOrder, err
+	}
+
+	if _, ok := joinQueryMap[fieldName]; !ok {
+		return "", joinQueryMap, joinQueryOrder, fmt.Errorf("field %v is not a relation of table %v", fieldName, request.ObjectCode)
+	}
+
+	if strings.HasSuffix(fieldName, entity.DisplayFieldSuffix) {
+		reference, err := r.displayReference(ctx, dialect, request.TenantCode, target.ForeignTable, dialect.QuoteIdentifier(fieldName), target.ForeignColumn)
+		return reference, joinQueryMap, joinQueryOrder, err
+	}
+
+	foreignFieldSet := strings.Split(fieldName, "__")
+	reference := fmt.Sprintf("%v.%v", dialect.QuoteIdentifier(fieldName), dialect.QuoteIdentifier(foreignFieldSet[len(foreignFieldSet)-1]))
+
+	return reference, joinQueryMap, joinQueryOrder, nil
+}
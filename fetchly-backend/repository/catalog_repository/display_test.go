@@ -0,0 +1,106 @@
+package catalogrepository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+	querybuilder "github.com/fetchlydev/source/fetchly-backend/repository/query_builder"
+)
+
+func TestDisplayReference(t *testing.T) {
+	tests := []struct {
+		name          string
+		columns       string
+		template      string
+		displayFields []string
+		want          string
+		wantErr       error
+	}{
+		{
+			name:          "display template of the object",
+			columns:       "serial TEXT, code TEXT, name TEXT",
+			template:      "{code} - {name}",
+			displayFields: []string{"name"},
+			want:          `COALESCE(CAST("remote"."code" AS TEXT), '') || COALESCE(CAST(' - ' AS TEXT), '') || COALESCE(CAST("remote"."name" AS TEXT), '')`,
+		},
+		{
+			name:          "display fields separated by a space",
+			columns:       "serial TEXT, first_name TEXT, last_name TEXT, name TEXT",
+			displayFields: []string{"first_name", "last_name"},
+			want:          `COALESCE(CAST("remote"."first_name" AS TEXT), '') || COALESCE(CAST(' ' AS TEXT), '') || COALESCE(CAST("remote"."last_name" AS TEXT), '')`,
+		},
+		{
+			name:    "name column",
+			columns: "serial TEXT, name TEXT",
+			want:    `"remote"."name"`,
+		},
+		{
+			name:    "key column",
+			columns: "serial TEXT, code TEXT",
+			want:    `"remote"."serial"`,
+		},
+		{
+			name:     "display field not in the table",
+			columns:  "serial TEXT, name TEXT",
+			template: "{code}",
+			wantErr:  entity.ErrorBadRequest,
+		},
+		{
+			name:     "invalid display template",
+			columns:  "serial TEXT, name TEXT",
+			template: "{name",
+			wantErr:  entity.ErrorBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRepository(t)
+			r.displayTemplates = newDisplayTemplates(time.Hour)
+
+			if err := r.db.AutoMigrate(&Objects{}, &ObjectFields{}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := r.db.Exec("CREATE TABLE tenants (serial TEXT, code TEXT)").Error; err != nil {
+				t.Fatal(err)
+			}
+
+			db, _, err := r.dataDB(context.Background(), "acme", "remote")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := db.Exec("CREATE TABLE remote (" + tt.columns + ")").Error; err != nil {
+				t.Fatal(err)
+			}
+
+			object := Objects{Serial: "o1", Code: "remote", TenantSerial: "t1", DisplayTemplate: sql.NullString{String: tt.template, Valid: tt.template != ""}}
+			if err := r.db.Exec("INSERT INTO tenants (serial, code) VALUES ('t1', 'acme')").Error; err != nil {
+				t.Fatal(err)
+			}
+			if err := r.db.Create(&object).Error; err != nil {
+				t.Fatal(err)
+			}
+
+			for _, fieldCode := range tt.displayFields {
+				if err := r.db.Create(&ObjectFields{ObjectSerial: "o1", FieldCode: fieldCode, IsDisplayName: true}).Error; err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := r.displayReference(context.Background(), querybuilder.SQLite, "acme", "remote", `"remote"`, entity.DEFAULT_IDENTIFIER)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("displayReference() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("displayReference() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
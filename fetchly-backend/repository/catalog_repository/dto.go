@@ -7,6 +7,7 @@ import (
 	"database/sql"
 
 	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+	"github.com/fetchlydev/source/fetchly-backend/pkg/conn"
 	"gorm.io/gorm"
 )
 
@@ -21,7 +22,7 @@ type Tenants struct {
 	DeletedAt    gorm.DeletedAt `gorm:"column:deleted_at" json:"deleted_at"`
 	Code         string         `gorm:"column:code" json:"code"`
 	Name         string         `gorm:"column:name" json:"name"`
-	TenantConfig map[string]any `gorm:"column:tenant_config" json:"tenant_config"`
+	TenantConfig map[string]any `gorm:"column:tenant_config;serializer:json" json:"tenant_config"`
 }
 
 type DataSource struct {
@@ -42,10 +43,31 @@ type DataSource struct {
 	Password     string         `gorm:"column:password" json:"password"`
 	DBName       string         `gorm:"column:db_name" json:"db_name"`
 	DatabaseName string         `gorm:"column:database_name" json:"database_name"`
-	Configs      map[string]any `gorm:"column:configs" json:"configs"`
+	Configs      map[string]any `gorm:"column:configs;serializer:json" json:"configs"`
 	TenantSerial string         `gorm:"column:tenant_serial" json:"tenant_serial"`
 }
 
+func (ds *DataSource) TableName() string {
+	return "data_sources"
+}
+
+func (ds *DataSource) ToConfig() conn.DataSourceConfig {
+	dbName := ds.DBName
+	if dbName == "" {
+		dbName = ds.DatabaseName
+	}
+
+	return conn.DataSourceConfig{
+		Serial:   ds.Serial,
+		Host:     ds.Host,
+		Port:     ds.Port,
+		Username: ds.Username,
+		Password: ds.Password,
+		DBName:   dbName,
+		Configs:  ds.Configs,
+	}
+}
+
 type Modules struct {
 	ID                 int            `gorm:"column:id" json:"id"`
 	Serial             string         `gorm:"column:serial" json:"serial"`
@@ -86,6 +108,7 @@ type Objects struct {
 	DeletedAt        gorm.DeletedAt `gorm:"column:deleted_at" json:"deleted_at"`
 	Code             string         `gorm:"column:code" json:"code"`
 	DisplayName      string         `gorm:"column:display_name" json:"display_name"`
+	DisplayTemplate  sql.NullString `gorm:"column:display_template" json:"display_template"`
 	Description      string         `gorm:"column:description" json:"description"`
 	TenantSerial     string         `gorm:"column:tenant_serial" json:"tenant_serial"`
 	ModuleSerial     string         `gorm:"column:module_serial" json:"module_serial"`
@@ -95,15 +118,16 @@ type Objects struct {
 
 func (o *Objects) ToEntity() entity.Objects {
 	return entity.Objects{
-		ID:          o.ID,
-		Serial:      o.Serial,
-		Tenant:      entity.Tenants{Serial: o.TenantSerial},
-		Module:      entity.Modules{Serial: o.ModuleSerial},
-		Code:        o.Code,
-		DisplayName: o.DisplayName,
-		Description: o.Description,
-		ObjectType:  o.ObjectType,
-		DataSource:  entity.DataSource{Serial: o.DataSourceSerial},
+		ID:              o.ID,
+		Serial:          o.Serial,
+		Tenant:          entity.Tenants{Serial: o.TenantSerial},
+		Module:          entity.Modules{Serial: o.ModuleSerial},
+		Code:            o.Code,
+		DisplayName:     o.DisplayName,
+		DisplayTemplate: o.DisplayTemplate.String,
+		Description:     o.Description,
+		ObjectType:      o.ObjectType,
+		DataSource:      entity.DataSource{Serial: o.DataSourceSerial},
 	}
 }
 
@@ -128,6 +152,8 @@ type ObjectFields struct {
 	TargetObjectFieldSerial string         `gorm:"column:target_object_field_serial" json:"target_object_field_serial"`
 	Relation                string         `gorm:"column:relation" json:"relation"`
 	IsSystem                bool           `gorm:"column:is_system" json:"is_system"`
+	IsSearchable            bool           `gorm:"column:is_searchable" json:"is_searchable"`
+	DefaultValue            sql.NullString `gorm:"column:default_value" json:"default_value"`
 }
 
 func (of *ObjectFields) TableName() string {
@@ -156,6 +182,8 @@ func (of *ObjectFields) ToEntity() entity.ObjectFields {
 		TargetObjectField: map[string]interface{}{"serial": of.TargetObjectFieldSerial},
 		Relation:          of.Relation,
 		IsSystem:          of.IsSystem,
+		IsSearchable:      of.IsSearchable,
+		DefaultValue:      of.DefaultValue.String,
 	}
 }
 
@@ -217,3 +245,107 @@ func (fki *ForeignKeyInfo) ToEntity() entity.ForeignKeyInfo {
 		ForeignColumn: fki.ForeignColumn,
 	}
 }
+
+type ViewLayout struct {
+	ID           int            `gorm:"column:id" json:"id"`
+	Serial       string         `gorm:"column:serial" json:"serial"`
+	CreatedBy    string         `gorm:"column:created_by" json:"created_by"`
+	CreatedAt    time.Time      `gorm:"column:created_at" json:"created_at"`
+	UpdatedBy    string         `gorm:"column:updated_by" json:"updated_by"`
+	UpdatedAt    time.Time      `gorm:"column:updated_at" json:"updated_at"`
+	DeletedBy    sql.NullString `gorm:"column:deleted_by" json:"deleted_by"`
+	DeletedAt    gorm.DeletedAt `gorm:"column:deleted_at" json:"deleted_at"`
+	Code         string         `gorm:"column:code" json:"code"`
+	LayoutConfig map[string]any `gorm:"column:layout_config;serializer:json" json:"layout_config"`
+}
+
+type ViewSchema struct {
+	ID            int              `gorm:"column:id" json:"id"`
+	Serial        string           `gorm:"column:serial" json:"serial"`
+	CreatedBy     string           `gorm:"column:created_by" json:"created_by"`
+	CreatedAt     time.Time        `gorm:"column:created_at" json:"created_at"`
+	UpdatedBy     string           `gorm:"column:updated_by" json:"updated_by"`
+	UpdatedAt     time.Time        `gorm:"column:updated_at" json:"updated_at"`
+	DeletedBy     sql.NullString   `gorm:"column:deleted_by" json:"deleted_by"`
+	DeletedAt     gorm.DeletedAt   `gorm:"column:deleted_at" json:"deleted_at"`
+	Code          string           `gorm:"column:code" json:"code"`
+	Name          string           `gorm:"column:name" json:"name"`
+	Query         map[string]any   `gorm:"column:query;serializer:json" json:"query"`
+	DisplayField  map[string]any   `gorm:"column:display_field;serializer:json" json:"display_field"`
+	StructureType string           `gorm:"column:structure_type" json:"structure_type"`
+	ActionSerial  sql.NullString   `gorm:"column:action_serial" json:"action_serial"`
+	IsFavorite    bool             `gorm:"column:is_favorite" json:"is_favorite"`
+	ObjectSerial  string           `gorm:"column:object_serial" json:"object_serial"`
+	FieldSections map[string]any   `gorm:"column:field_sections;serializer:json" json:"field_sections"`
+	Orders        []map[string]any `gorm:"column:orders;serializer:json" json:"orders"`
+}
+
+type ViewContent struct {
+	ID                int            `gorm:"column:id" json:"id"`
+	Serial            string         `gorm:"column:serial" json:"serial"`
+	CreatedBy         string         `gorm:"column:created_by" json:"created_by"`
+	CreatedAt         time.Time      `gorm:"column:created_at" json:"created_at"`
+	UpdatedBy         string         `gorm:"column:updated_by" json:"updated_by"`
+	UpdatedAt         time.Time      `gorm:"column:updated_at" json:"updated_at"`
+	DeletedBy         sql.NullString `gorm:"column:deleted_by" json:"deleted_by"`
+	DeletedAt         gorm.DeletedAt `gorm:"column:deleted_at" json:"deleted_at"`
+	Code              string         `gorm:"column:code" json:"code"`
+	Name              string         `gorm:"column:name" json:"name"`
+	TenantSerial      string         `gorm:"column:tenant_serial" json:"tenant_serial"`
+	ProductSerial     sql.NullString `gorm:"column:product_serial" json:"product_serial"`
+	ObjectSerial      sql.NullString `gorm:"column:object_serial" json:"object_serial"`
+	OwnerSerial       sql.NullString `gorm:"column:owner_serial" json:"owner_serial"`
+	ViewLayoutSerial  sql.NullString `gorm:"column:view_layout_serial" json:"view_layout_serial"`
+	ViewSchemaSerial  sql.NullString `gorm:"column:view_schema_serial" json:"view_schema_serial"`
+	LayoutType        string         `gorm:"column:layout_type" json:"layout_type"`
+	IsDefault         bool           `gorm:"column:is_default" json:"is_default"`
+	IsShownInList     bool           `gorm:"column:is_shown_in_list" json:"is_shown_in_list"`
+	ViewContentConfig map[string]any `gorm:"column:view_content_config;serializer:json" json:"view_content_config"`
+}
+
+type Navigation struct {
+	ID                int            `gorm:"column:id" json:"id"`
+	Serial            string         `gorm:"column:serial" json:"serial"`
+	CreatedBy         string         `gorm:"column:created_by" json:"created_by"`
+	CreatedAt         time.Time      `gorm:"column:created_at" json:"created_at"`
+	UpdatedBy         string         `gorm:"column:updated_by" json:"updated_by"`
+	UpdatedAt         time.Time      `gorm:"column:updated_at" json:"updated_at"`
+	DeletedBy         sql.NullString `gorm:"column:deleted_by" json:"deleted_by"`
+	DeletedAt         gorm.DeletedAt `gorm:"column:deleted_at" json:"deleted_at"`
+	ViewContentSerial string         `gorm:"column:view_content_serial" json:"view_content_serial"`
+	Code              string         `gorm:"column:code" json:"code"`
+	Title             string         `gorm:"column:title" json:"title"`
+	Description       string         `gorm:"column:description" json:"description"`
+	URL               string         `gorm:"column:url" json:"url"`
+	NavigationLevel   int32          `gorm:"column:navigation_level" json:"navigation_level"`
+	Path              string         `gorm:"column:path" json:"path"`
+	NavigationOrder   int32          `gorm:"column:navigation_order" json:"navigation_order"`
+	NavigationConfig  map[string]any `gorm:"column:navigation_config;serializer:json" json:"navigation_config"`
+}
+
+type AuditLogs struct {
+	ID           int                  `gorm:"column:id" json:"id"`
+	Serial       string               `gorm:"column:serial" json:"serial"`
+	TenantCode   string               `gorm:"column:tenant_code" json:"tenant_code"`
+	ObjectCode   string               `gorm:"column:object_code" json:"object_code"`
+	RecordSerial string               `gorm:"column:record_serial" json:"record_serial"`
+	Action       string               `gorm:"column:action" json:"action"`
+	UserSerial   string               `gorm:"column:user_serial" json:"user_serial"`
+	RequestID    string               `gorm:"column:request_id" json:"request_id"`
+	Changes      []entity.FieldChange `gorm:"column:changes;serializer:json" json:"changes"`
+	CreatedAt    time.Time            `gorm:"column:created_at" json:"created_at"`
+}
+
+func (a *AuditLogs) ToEntity() entity.AuditLog {
+	return entity.AuditLog{
+		Serial:       a.Serial,
+		TenantCode:   a.TenantCode,
+		ObjectCode:   a.ObjectCode,
+		RecordSerial: a.RecordSerial,
+		Action:       entity.AuditAction(a.Action),
+		UserSerial:   a.UserSerial,
+		RequestID:    a.RequestID,
+		Changes:      a.Changes,
+		CreatedAt:    a.CreatedAt,
+	}
+}
@@ -2,29 +2,44 @@ package catalogrepository
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"log"
 	"reflect"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/fetchlydev/source/fetchly-backend/config"
 	"github.com/fetchlydev/source/fetchly-backend/core/entity"
 	repository_intf "github.com/fetchlydev/source/fetchly-backend/core/repository"
+	"github.com/fetchlydev/source/fetchly-backend/pkg/conn"
 	"github.com/fetchlydev/source/fetchly-backend/pkg/helper"
+	querybuilder "github.com/fetchlydev/source/fetchly-backend/repository/query_builder"
 	"github.com/fetchlydev/source/fetchly-backend/repository/util"
 	"gorm.io/gorm"
 )
 
 type repository struct {
-	cfg config.Config
-	db  *gorm.DB
+	cfg              config.Config
+	db               *gorm.DB
+	dataSources      *dataSourceResolver
+	searchIndexes    *searchIndexes
+	displayTemplates *displayTemplates
+	tx               *dataSourceTx
+	statements       *schemaStatements
 }
 
-func New(cfg config.Config, db *gorm.DB) repository_intf.CatalogRepository {
+// New creates the catalog repository, db holds the metadata and the tables of the objects without a data source.
+// The tables of the other objects are queried through the pool registry of their data source.
+func New(cfg config.Config, db *gorm.DB, registry *conn.DBRegistry) repository_intf.CatalogRepository {
 	return &repository{
-		cfg: cfg,
-		db:  db,
+		cfg:              cfg,
+		db:               db,
+		dataSources:      newDataSourceResolver(registry, time.Duration(cfg.DataSourceCacheTTL)*time.Second),
+		searchIndexes:    newSearchIndexes(time.Duration(cfg.DataSourceCacheTTL) * time.Second),
+		displayTemplates: newDisplayTemplates(time.Duration(cfg.DataSourceCacheTTL) * time.Second),
 	}
 }
 
@@ -32,52 +47,43 @@ func (r *repository) GetColumnList(ctx context.Context, request entity.CatalogQu
 	joinQueryMapAll := make(map[string]string)
 	joinQueryOrderAll := make([]string, 0)
 
-	// get list of column from request.ObjectCode
-	listColumnQuery := fmt.Sprintf(`
-	SELECT
-    col.column_name as field_code, 
-		col.udt_name as data_type,
-    ccu.table_name AS foreign_table_name,
-    ccu.column_name AS foreign_field_name
-	FROM
-			information_schema.columns AS col
-	LEFT JOIN information_schema.key_column_usage AS kcu ON col.table_name = kcu.table_name
-	AND col.column_name = kcu.column_name
-	LEFT JOIN information_schema.constraint_column_usage AS ccu ON kcu.constraint_name = ccu.constraint_name
-	WHERE
-		col.table_schema = '%v' 
-	AND col.table_name = '%v'
-	`, request.TenantCode, request.ObjectCode)
-
-	db := r.db
+	db, dialect, err := r.dataDB(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return columns, columnStrings, joinQueryMap, joinQueryOrder, err
+	}
 
 	if r.cfg.IsDebugMode {
 		db.Debug()
 	}
 
-	rows, err := db.Raw(listColumnQuery).Rows()
+	// get list of column from request.ObjectCode
+	listColumnQuery := dialect.ColumnListQuery(request.TenantCode, request.ObjectCode)
+	rows, err := db.Raw(listColumnQuery.SQL, listColumnQuery.Args...).Rows()
 	if err != nil {
 		return columns, columnStrings, joinQueryMap, joinQueryOrder, err
 	}
 	defer rows.Close()
 
+	tableName := dialect.TableName(request.TenantCode, request.ObjectCode)
+
 	// iterate over the result to get value of column_name and data_type
 	for rows.Next() {
 		column := make(map[string]any)
 
-		var columnCode, dataType, foreignTableName, foreignColumnName interface{}
+		// scanned as strings, as some drivers return the information schema columns as bytes
+		var columnCode, dataType, foreignTableName, foreignColumnName sql.NullString
 		if err := rows.Scan(&columnCode, &dataType, &foreignTableName, &foreignColumnName); err != nil {
 			return columns, columnStrings, joinQueryMap, joinQueryOrder, err
 		}
 
-		column[entity.FieldDataType] = dataType.(string)
-		column[entity.FieldColumnCode] = columnCode.(string)
-		column[entity.FieldColumnName] = columnCode.(string)
-		column[entity.FieldCompleteColumnCode] = fmt.Sprintf("%v.%v.%v", request.TenantCode, request.ObjectCode, columnCode.(string))
+		column[entity.FieldDataType] = dataType.String
+		column[entity.FieldColumnCode] = columnCode.String
+		column[entity.FieldColumnName] = columnCode.String
+		column[entity.FieldCompleteColumnCode] = fmt.Sprintf("%v.%v", tableName, columnCode.String)
 
-		if foreignTableName != nil && foreignTableName.(string) != request.ObjectCode && foreignColumnName != nil && foreignColumnName.(string) != "id" {
-			column[entity.FieldForeignTableName] = foreignTableName.(string)
-			column[entity.FieldForeignColumnName] = foreignColumnName.(string)
+		if foreignTableName.Valid && foreignTableName.String != request.ObjectCode && foreignColumnName.Valid && foreignColumnName.String != "id" {
+			column[entity.FieldForeignTableName] = foreignTableName.String
+			column[entity.FieldForeignColumnName] = foreignColumnName.String
 		}
 
 		columns = append(columns, column)
@@ -88,10 +94,9 @@ func (r *repository) GetColumnList(ctx context.Context, request entity.CatalogQu
 		_, foreignFieldNameOK := column["foreign_field_name"]
 
 		if foreignTableNameOK && foreignFieldNameOK {
-			// append foreign name display field into select
-			// room for improvement: replace hardcoded __name with display value from catalog
+			// append foreign name display field into select, the display value of the referenced object
 			newForeignColumn := make(map[string]any)
-			foreignColumName := fmt.Sprintf("%v__name", column["field_code"])
+			foreignColumName := fmt.Sprintf("%v%v", column["field_code"], entity.DisplayFieldSuffix)
 
 			newForeignColumn[entity.FieldDataType] = "string"
 			newForeignColumn[entity.FieldColumnCode] = foreignColumName
@@ -128,7 +133,9 @@ func (r *repository) GetColumnList(ctx context.Context, request entity.CatalogQu
 				}
 			} else {
 				// handle fieldName that has double underscore this indicates that it is a relationship field
-				r.handleJoinColumn(ctx, request, fieldNameKey, &joinQueryMapAll, &joinQueryOrderAll, &filteredColumns)
+				if err := r.handleJoinColumn(ctx, dialect, request, fieldNameKey, &joinQueryMapAll, &joinQueryOrderAll, &filteredColumns); err != nil {
+					return columns, columnStrings, joinQueryMap, joinQueryOrder, err
+				}
 				isFound = true
 			}
 
@@ -155,14 +162,16 @@ func (r *repository) GetColumnList(ctx context.Context, request entity.CatalogQu
 		for _, col := range columns {
 			completeFieldCode := col[entity.FieldCompleteColumnCode].(string)
 			if strings.Contains(completeFieldCode, "__") {
-				r.handleJoinColumn(ctx, request, completeFieldCode, &joinQueryMapAll, &joinQueryOrderAll, &columns)
+				if err := r.handleJoinColumn(ctx, dialect, request, completeFieldCode, &joinQueryMapAll, &joinQueryOrderAll, &columns); err != nil {
+					return columns, columnStrings, joinQueryMap, joinQueryOrder, err
+				}
 			}
 		}
 	}
 
 	// convert columns to string
 	for i, col := range columns {
-		completeFieldCode := col[entity.FieldCompleteColumnCode].(string)
+		completeFieldCode := columnExpression(dialect, col)
 
 		// convert into columnStrings
 		if i == 0 {
@@ -175,28 +184,43 @@ func (r *repository) GetColumnList(ctx context.Context, request entity.CatalogQu
 	return columns, columnStrings, joinQueryMapAll, joinQueryOrderAll, err
 }
 
+// columnExpression returns the select expression of a column of GetColumnList
+func columnExpression(dialect querybuilder.Dialect, column map[string]any) string {
+	if selectExpression, ok := column[entity.FieldSelectExpression].(string); ok {
+		return selectExpression
+	}
+
+	return querybuilder.QuoteReferenceIn(dialect, column[entity.FieldCompleteColumnCode].(string))
+}
+
 func (r *repository) handleJoinColumn(
 	ctx context.Context,
+	dialect querybuilder.Dialect,
 	request entity.CatalogQuery,
 	fieldNameKey string,
 	joinQueryMapAll *map[string]string,
 	joinQueryOrderAll *[]string,
 	filteredColumns *[]map[string]any,
-) {
+) error {
 	foreignFieldSet := strings.Split(fieldNameKey, "__")
-	_, joinQueryMap := r.HandleChainingJoinQuery(ctx, "", fieldNameKey, fmt.Sprintf("%v.%v", request.TenantCode, request.ObjectCode), request, entity.FilterItem{})
+	reference, joinQueryMap, joinQueryOrder, err := r.chainedColumn(ctx, dialect, dialect.TableName(request.TenantCode, request.ObjectCode), request, fieldNameKey)
+	if err != nil {
+		return err
+	}
 
 	// append joinQueryMap to joinQueryMapAll
-	for k, v := range joinQueryMap {
-		(*joinQueryMapAll)[k] = v
-		*joinQueryOrderAll = append(*joinQueryOrderAll, k)
+	for _, k := range joinQueryOrder {
+		if _, ok := (*joinQueryMapAll)[k]; !ok {
+			*joinQueryOrderAll = append(*joinQueryOrderAll, k)
+		}
+		(*joinQueryMapAll)[k] = joinQueryMap[k]
 	}
 
 	// split fieldName by double underscore
 	foreignColumnName := fmt.Sprintf("%v.%v.%v", request.TenantCode, request.ObjectCode, foreignFieldSet[0])
 	referenceColumnName := foreignFieldSet[1]
 
-	if _, ok := joinQueryMap[fieldNameKey]; ok {
+	if _, ok := joinQueryMap[fieldNameKey]; ok || fieldNameKey == entity.DisplayFieldSuffix {
 		fieldNameKeyList := strings.Split(fieldNameKey, "__")
 		destinationColumn := fieldNameKeyList[len(fieldNameKeyList)-1]
 
@@ -210,6 +234,7 @@ func (r *repository) handleJoinColumn(
 		filteredColumn := map[string]any{
 			entity.FieldOriginalFieldCode:  fieldNameKey,
 			entity.FieldCompleteColumnCode: fieldCode,
+			entity.FieldSelectExpression:   reference,
 			entity.FieldColumnCode:         fieldCode,
 			entity.FieldColumnName:         fieldName,
 			entity.FieldForeignColumnName:  foreignColumnName,
@@ -224,11 +249,18 @@ func (r *repository) handleJoinColumn(
 
 		*filteredColumns = append(*filteredColumns, filteredColumn)
 	}
+
+	return nil
 }
 
 func (r *repository) GetObjectData(ctx context.Context, request entity.CatalogQuery) (resp entity.CatalogResponse, err error) {
+	db, dialect, err := r.dataDB(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return resp, err
+	}
+
 	// get list of column from request.ObjectCode
-	completeTableName := request.TenantCode + "." + request.ObjectCode
+	completeTableName := dialect.TableName(request.TenantCode, request.ObjectCode)
 
 	// Get list of columns
 	columnsList, columnsString, joinQueryMap, joinQueryOrder, err := r.GetColumnList(ctx, request)
@@ -236,28 +268,50 @@ func (r *repository) GetObjectData(ctx context.Context, request entity.CatalogQu
 		return resp, err
 	}
 
-	// Get total data count
-	countQuery := r.getTotalCountQuery(ctx, completeTableName, request, joinQueryMap, joinQueryOrder, columnsList)
-	resultCount, err := r.db.Raw(countQuery).Rows()
+	// Get the full column allow-list of the table, used to validate filters and orders
+	tableColumns, err := r.getTableColumns(ctx, request.TenantCode, request.ObjectCode)
 	if err != nil {
 		return resp, err
 	}
 
-	for resultCount.Next() {
-		resultCount.Scan(&resp.TotalData)
+	// Get total data count
+	if request.CountMode != entity.CountModeNone {
+		resp.TotalData, resp.IsTotalEstimated, err = r.countObjectData(ctx, db, dialect, completeTableName, request, joinQueryMap, joinQueryOrder, tableColumns)
+		if err != nil {
+			return resp, err
+		}
 	}
 
 	if request.PageSize < 1 {
 		request.PageSize = 10
 	}
 
+	// a cursor page is filtered by the search but keeps the order of its cursor
+	if request.PaginationMode == entity.PaginationModeCursor {
+		return r.getObjectDataByCursor(ctx, db, dialect, resp, columnsString, completeTableName, request, joinQueryMap, joinQueryOrder, columnsList, tableColumns)
+	}
+
 	if request.Page < 1 {
 		request.Page = 1
 	}
 
+	search, err := r.resolveSearch(ctx, dialect, completeTableName, request, tableColumns)
+	if err != nil {
+		return resp, err
+	}
+
+	if search != nil && search.isFullText {
+		r.ensureSearchIndex(db, dialect, request.TenantCode, request.ObjectCode, search)
+		return r.getObjectDataBySearch(ctx, db, dialect, resp, columnsString, completeTableName, request, joinQueryMap, joinQueryOrder, columnsList, tableColumns, search)
+	}
+
 	// Get data with pagination
-	dataQuery := r.getDataWithPagination(ctx, columnsString, completeTableName, request, joinQueryMap, joinQueryOrder, columnsList)
-	rows, err := r.db.Raw(dataQuery).Rows()
+	dataQuery, err := r.getDataWithPagination(ctx, dialect, columnsString, completeTableName, request, joinQueryMap, joinQueryOrder, columnsList, tableColumns)
+	if err != nil {
+		return resp, err
+	}
+
+	rows, err := db.Raw(dataQuery.SQL(), dataQuery.Args()...).Rows()
 	if err != nil {
 		return resp, err
 	}
@@ -280,9 +334,61 @@ func (r *repository) GetObjectData(ctx context.Context, request entity.CatalogQu
 	return resp, nil
 }
 
+// countObjectData counts the records matching the request. An estimated count is read from the table statistics
+// when nothing filters the records, the statistics include the soft deleted records.
+func (r *repository) countObjectData(ctx context.Context, db *gorm.DB, dialect querybuilder.Dialect, tableName string, request entity.CatalogQuery, joinQueryMap map[string]string, joinQueryOrder []string, tableColumns querybuilder.Columns) (total int, isEstimated bool, err error) {
+	// the estimate counts the soft deleted records along, it is too far off for the trash alone
+	if request.CountMode == entity.CountModeEstimated && !hasFilters(request.Filters) && !request.IsOnlyDeleted {
+		if estimateQuery, ok := dialect.EstimatedCountQuery(request.TenantCode, request.ObjectCode); ok {
+			var estimate sql.NullInt64
+			err := db.Raw(estimateQuery.SQL, estimateQuery.Args...).Row().Scan(&estimate)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				return total, isEstimated, err
+			}
+
+			// a table that has never been analyzed has no estimate
+			if err == nil && estimate.Valid && estimate.Int64 >= 0 {
+				return int(estimate.Int64), true, nil
+			}
+		}
+	}
+
+	countQuery, err := r.getTotalCountQuery(ctx, dialect, tableName, request, joinQueryMap, joinQueryOrder, tableColumns)
+	if err != nil {
+		return total, isEstimated, err
+	}
+
+	resultCount, err := db.Raw(countQuery.SQL(), countQuery.Args()...).Rows()
+	if err != nil {
+		return total, isEstimated, err
+	}
+	defer resultCount.Close()
+
+	for resultCount.Next() {
+		resultCount.Scan(&total)
+	}
+
+	return total, isEstimated, nil
+}
+
+func hasFilters(filterGroups []entity.FilterGroup) bool {
+	for _, filterGroup := range filterGroups {
+		if !filterGroup.IsEmpty() {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (r *repository) GetObjectDetail(ctx context.Context, request entity.CatalogQuery) (resp map[string]entity.DataItem, err error) {
+	db, dialect, err := r.dataDB(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return resp, err
+	}
+
 	// get list of column from request.ObjectCode
-	completeTableName := request.TenantCode + "." + request.ObjectCode
+	completeTableName := dialect.TableName(request.TenantCode, request.ObjectCode)
 
 	// Get list of columns
 	columnsList, columnsString, joinQueryMap, joinQueryOrder, err := r.GetColumnList(ctx, request)
@@ -290,12 +396,22 @@ func (r *repository) GetObjectDetail(ctx context.Context, request entity.Catalog
 		return resp, err
 	}
 
+	tableColumns, err := r.getTableColumns(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return resp, err
+	}
+
 	// get single data using serial in request
-	dataQuery := r.getSingleData(ctx, columnsList, columnsString, completeTableName, request, joinQueryMap, joinQueryOrder)
-	rows, err := r.db.Raw(dataQuery).Rows()
+	dataQuery, err := r.getSingleData(ctx, dialect, columnsString, completeTableName, request, joinQueryMap, joinQueryOrder, tableColumns)
+	if err != nil {
+		return resp, err
+	}
+
+	rows, err := db.Raw(dataQuery.SQL(), dataQuery.Args()...).Rows()
 	if err != nil {
 		return resp, err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
 		item, err := util.HandleSingleRow(columnsList, rows, request)
@@ -309,6 +425,127 @@ func (r *repository) GetObjectDetail(ctx context.Context, request entity.Catalog
 	return resp, nil
 }
 
+// StreamObjectData reads the whole filtered result set through a server side cursor, passing
+// every fetched batch to fn. Page and PageSize of the request are ignored. Dialects without cursors
+// stream the rows of a single query instead.
+func (r *repository) StreamObjectData(ctx context.Context, request entity.CatalogQuery, batchSize int, fn func(items []map[string]entity.DataItem) error) (err error) {
+	db, dialect, err := r.dataDB(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return err
+	}
+
+	completeTableName := dialect.TableName(request.TenantCode, request.ObjectCode)
+
+	columnsList, columnsString, joinQueryMap, joinQueryOrder, err := r.GetColumnList(ctx, request)
+	if err != nil {
+		return err
+	}
+
+	tableColumns, err := r.getTableColumns(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return err
+	}
+
+	dataQuery, err := r.getOrderedDataQuery(ctx, dialect, columnsString, completeTableName, request, joinQueryMap, joinQueryOrder, columnsList, tableColumns)
+	if err != nil {
+		return err
+	}
+
+	if batchSize < 1 {
+		batchSize = 1000
+	}
+
+	if !dialect.SupportsCursor() {
+		return r.streamRows(db.WithContext(ctx), dataQuery, batchSize, columnsList, request, fn)
+	}
+
+	// a cursor only lives inside a transaction
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DECLARE export_cursor NO SCROLL CURSOR FOR "+dataQuery.SQL(), dataQuery.Args()...).Error; err != nil {
+			return err
+		}
+
+		fetchQuery := fmt.Sprintf("FETCH FORWARD %d FROM export_cursor", batchSize)
+		for {
+			items, err := r.fetchCursorBatch(tx, fetchQuery, columnsList, request)
+			if err != nil {
+				return err
+			}
+
+			if len(items) == 0 {
+				break
+			}
+
+			if err := fn(items); err != nil {
+				return err
+			}
+
+			if len(items) < batchSize {
+				break
+			}
+		}
+
+		return tx.Exec("CLOSE export_cursor").Error
+	})
+}
+
+// streamRows reads the rows of query one by one, passing every batchSize rows to fn
+func (r *repository) streamRows(db *gorm.DB, query *querybuilder.Query, batchSize int, columnsList []map[string]any, request entity.CatalogQuery, fn func(items []map[string]entity.DataItem) error) error {
+	rows, err := db.Raw(query.SQL(), query.Args()...).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	items := make([]map[string]entity.DataItem, 0, batchSize)
+	for rows.Next() {
+		item, err := util.HandleSingleRow(columnsList, rows, request)
+		if err != nil {
+			return err
+		}
+
+		items = append(items, item)
+		if len(items) < batchSize {
+			continue
+		}
+
+		if err := fn(items); err != nil {
+			return err
+		}
+
+		items = make([]map[string]entity.DataItem, 0, batchSize)
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if len(items) == 0 {
+		return nil
+	}
+
+	return fn(items)
+}
+
+func (r *repository) fetchCursorBatch(tx *gorm.DB, fetchQuery string, columnsList []map[string]any, request entity.CatalogQuery) (items []map[string]entity.DataItem, err error) {
+	rows, err := tx.Raw(fetchQuery).Rows()
+	if err != nil {
+		return items, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item, err := util.HandleSingleRow(columnsList, rows, request)
+		if err != nil {
+			return items, err
+		}
+
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
 func (r *repository) GetDataByRawQuery(ctx context.Context, request entity.CatalogQuery) (resp entity.CatalogResponse, err error) {
 	// run raw query from request.RawQuery
 	rawQuery := request.RawQuery
@@ -316,7 +553,12 @@ func (r *repository) GetDataByRawQuery(ctx context.Context, request entity.Catal
 	// get total data based on rawQuery
 	rawCountQuery := fmt.Sprintf("SELECT SUM(1) as total from (%s) as subquery", rawQuery)
 
-	countRows, err := r.db.Raw(rawCountQuery).Rows()
+	db, dialect, err := r.dataDB(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return resp, err
+	}
+
+	countRows, err := db.Raw(rawCountQuery).Rows()
 	if err != nil {
 		return resp, err
 	}
@@ -333,9 +575,10 @@ func (r *repository) GetDataByRawQuery(ctx context.Context, request entity.Catal
 	}
 
 	// add page and page size based on request.Page and request.PageSize
-	rawQuery = fmt.Sprintf("%s LIMIT %d OFFSET %d", rawQuery, request.PageSize, (request.Page-1)*request.PageSize)
+	dataQuery := querybuilder.New(rawQuery)
+	dialect.Paginate(dataQuery, request.PageSize, (request.Page-1)*request.PageSize)
 
-	rows, err := r.db.Raw(rawQuery).Rows()
+	rows, err := db.Raw(dataQuery.SQL(), dataQuery.Args()...).Rows()
 	if err != nil {
 		return resp, err
 	}
@@ -386,57 +629,105 @@ func (r *repository) GetDataByRawQuery(ctx context.Context, request entity.Catal
 
 func (r *repository) CreateObjectData(ctx context.Context, request entity.DataMutationRequest) (resp map[string]entity.DataItem, err error) {
 	// INSERT INTO table_name (column1, column2, column3, ...)
-	// VALUES (value1, value2, value3, ...);
+	// VALUES (?, ?, ?, ...);
+
+	db, dialect, err := r.dataDB(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return resp, err
+	}
 
 	// get list of column from request.ObjectCode
-	completeTableName := request.TenantCode + "." + request.ObjectCode
+	tableColumns, err := r.getTableColumns(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return resp, err
+	}
+
+	// without RETURNING the serial is generated here, so the created record can be read back
+	var serial string
+	if !dialect.SupportsReturning() && tableColumns.Has(entity.DEFAULT_IDENTIFIER) {
+		for _, item := range request.Items {
+			if item.FieldCode == entity.DEFAULT_IDENTIFIER && item.Value != nil {
+				serial = fmt.Sprintf("%v", item.Value)
+			}
+		}
+
+		if serial == "" {
+			serial, err = helper.GenerateUUUID()
+			if err != nil {
+				return resp, err
+			}
+
+			request.Items = append(request.Items, entity.DataItem{FieldCode: entity.DEFAULT_IDENTIFIER, Value: serial})
+		}
+	}
 
 	// loop through data items and get the values
-	var columnCodeString string
-	var valueString string
+	columnCodes := make([]string, 0, len(request.Items))
+	values := make([]any, 0, len(request.Items))
+	itemCodes := make(map[string]bool, len(request.Items))
 	for _, item := range request.Items {
-		columnCodeString = columnCodeString + ", " + item.FieldCode
+		if !tableColumns.Has(item.FieldCode) {
+			return resp, fmt.Errorf("field %v is not found in table %v", item.FieldCode, request.ObjectCode)
+		}
 
-		if item.Value == nil {
-			valueString = valueString + ", NULL"
-		} else {
-			switch item.DataType {
-			case "text":
-				valueString = valueString + fmt.Sprintf(", '%v'", item.Value)
-			case "integer":
-				valueString = valueString + fmt.Sprintf(", %v", item.Value)
-			case "boolean":
-				valueString = valueString + fmt.Sprintf(", %v", item.Value)
-			default:
-				valueString = valueString + fmt.Sprintf(", '%v'", item.Value)
-			}
+		columnCodes = append(columnCodes, dialect.QuoteIdentifier(item.FieldCode))
+		values = append(values, querybuilder.BindValue(tableColumns.DataType(item.FieldCode), item.Value))
+		itemCodes[item.FieldCode] = true
+	}
+
+	// stamp the audit columns with the mutating user unless they are set explicitly
+	for _, auditColumn := range []string{"created_by", "updated_by"} {
+		if request.UserSerial == "" || itemCodes[auditColumn] || !tableColumns.Has(auditColumn) {
+			continue
 		}
+
+		columnCodes = append(columnCodes, dialect.QuoteIdentifier(auditColumn))
+		values = append(values, querybuilder.BindValue(tableColumns.DataType(auditColumn), request.UserSerial))
 	}
 
-	if len(valueString) == 0 {
+	if len(values) == 0 {
 		return resp, errors.New("no data item found")
 	}
 
-	columnCodeString = columnCodeString[2:]
-	valueString = valueString[2:]
-
 	// insert into query string
-	insertQuery := fmt.Sprintf("INSERT INTO %v (%v) VALUES (%v)", completeTableName, columnCodeString, valueString)
+	insertQuery := fmt.Sprintf("INSERT INTO %v (%v) VALUES (%v)", querybuilder.TableIn(dialect, request.TenantCode, request.ObjectCode), strings.Join(columnCodes, ", "), querybuilder.Placeholders(len(values)))
 	log.Printf("insertQuery: %v", insertQuery)
 
-	// execute insert query
-	if err := r.db.Exec(insertQuery).Error; err != nil {
-		return resp, err
+	// tables without serial column can not be read back
+	if !tableColumns.Has(entity.DEFAULT_IDENTIFIER) || !dialect.SupportsReturning() {
+		if err := db.Exec(insertQuery, values...).Error; err != nil {
+			return resp, err
+		}
+
+		if serial == "" {
+			return resp, nil
+		}
+	} else {
+		// execute insert query and return the created data
+		insertQuery = fmt.Sprintf("%v RETURNING %v", insertQuery, dialect.QuoteIdentifier(entity.DEFAULT_IDENTIFIER))
+		if err := db.Raw(insertQuery, values...).Scan(&serial).Error; err != nil {
+			return resp, err
+		}
 	}
 
-	return resp, nil
+	return r.GetObjectDetail(ctx, entity.CatalogQuery{
+		ObjectCode:  request.ObjectCode,
+		TenantCode:  request.TenantCode,
+		ProductCode: request.ProductCode,
+		Serial:      serial,
+	})
 }
 
 func (r *repository) UpdateObjectData(ctx context.Context, request entity.DataMutationRequest) (resp map[string]entity.DataItem, err error) {
 	// UPDATE table_name
-	// SET column1 = value1, column2 = value2, ...
+	// SET column1 = ?, column2 = ?, ...
 	// WHERE condition;
 
+	db, dialect, err := r.dataDB(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return resp, err
+	}
+
 	// get list of column from request.ObjectCode
 	columnList, _, _, _, err := r.GetColumnList(ctx, entity.CatalogQuery{
 		ObjectCode:  request.ObjectCode,
@@ -465,11 +756,17 @@ func (r *repository) UpdateObjectData(ctx context.Context, request entity.DataMu
 		TenantCode:  request.TenantCode,
 		ProductCode: request.ProductCode,
 		Serial:      request.Serial,
+		Filters:     request.Filters,
 	})
 	if err != nil {
 		return resp, err
 	}
 
+	// the record is missing or hidden by the row filters
+	if len(existingData) == 0 {
+		return resp, entity.ErrorNotFound
+	}
+
 	// compare mutationDataMap and existingDataMap using each column code respectively
 	for key, existingItem := range existingData {
 		if existingItem.Value == mutationDataMap[key].Value {
@@ -482,30 +779,18 @@ func (r *repository) UpdateObjectData(ctx context.Context, request entity.DataMu
 		return resp, entity.ErrorNoUpdateDataFound
 	}
 
-	// compose update query
-	var updateQuery string
+	// compose update query, only columns that exist in the table are accepted
+	var setClauses []string
+	var values []any
 	for key, item := range mutationDataMap {
 		if column, ok := columnListMap[key]; ok {
-			if item.Value == nil {
-				updateQuery = updateQuery + fmt.Sprintf("%v = NULL, ", column[entity.FieldColumnName])
-			} else {
-				switch strings.ToLower(column[entity.FieldDataType].(string)) {
-				case "text", "varchar", "char", "json", "jsonb":
-					updateQuery = updateQuery + fmt.Sprintf("%v = '%v', ", column[entity.FieldColumnName], item.Value)
-				case "integer", "int", "bigint", "smallint":
-					updateQuery = updateQuery + fmt.Sprintf("%v = %v, ", column[entity.FieldColumnName], item.Value)
-				case "boolean", "bool":
-					updateQuery = updateQuery + fmt.Sprintf("%v = %v, ", column[entity.FieldColumnName], item.Value)
-				default:
-					updateQuery = updateQuery + fmt.Sprintf("%v = '%v', ", column[entity.FieldColumnName], item.Value)
-				}
-			}
+			setClauses = append(setClauses, fmt.Sprintf("%v = ?", dialect.QuoteIdentifier(column[entity.FieldColumnCode].(string))))
+			values = append(values, querybuilder.BindValue(column[entity.FieldDataType].(string), item.Value))
 		}
 	}
 
-	// remove last comma and space
-	if len(updateQuery) > 0 {
-		updateQuery = updateQuery[:len(updateQuery)-2]
+	if len(setClauses) == 0 {
+		return resp, entity.ErrorNoUpdateDataFound
 	}
 
 	// compose where clause
@@ -517,19 +802,27 @@ func (r *repository) UpdateObjectData(ctx context.Context, request entity.DataMu
 	// check if table has updated_at column
 	// if yes, then add updated_at = now() to update query
 	if _, ok := columnListMap["updated_at"]; ok {
-		updateQuery = updateQuery + fmt.Sprintf(", %v = now()", columnListMap["updated_at"][entity.FieldColumnCode])
+		setClauses = append(setClauses, fmt.Sprintf("%v = %v", dialect.QuoteIdentifier("updated_at"), dialect.Now()))
 	}
 
 	if _, ok := columnListMap["updated_by"]; ok {
-		updateQuery = fmt.Sprintf("%v, %v = '%v'", updateQuery, columnListMap["updated_by"][entity.FieldColumnCode], request.UserSerial)
+		setClauses = append(setClauses, fmt.Sprintf("%v = ?", dialect.QuoteIdentifier("updated_by")))
+		values = append(values, request.UserSerial)
 	}
 
 	// compose update query
-	completeTableName := request.TenantCode + "." + request.ObjectCode
-	updateQuery = fmt.Sprintf("UPDATE %v SET %v WHERE %v.%v = '%v'", completeTableName, updateQuery, completeTableName, identifierColumn, request.Serial)
+	completeTableName := querybuilder.TableIn(dialect, request.TenantCode, request.ObjectCode)
+	updateQuery := querybuilder.New(fmt.Sprintf("UPDATE %v SET %v WHERE %v.%v = ? AND %v.deleted_at IS NULL", completeTableName, strings.Join(setClauses, ", "), completeTableName, dialect.QuoteIdentifier(identifierColumn), completeTableName), append(values, request.Serial)...)
+
+	rowFilterExpr, err := r.buildRowFilterCondition(ctx, dialect, request)
+	if err != nil {
+		return resp, err
+	}
+
+	updateQuery.WriteExpr(" AND ", rowFilterExpr)
 
 	// execute update query
-	if err := r.db.Exec(updateQuery).Error; err != nil {
+	if err := db.Exec(updateQuery.SQL(), updateQuery.Args()...).Error; err != nil {
 		return resp, err
 	}
 
@@ -548,6 +841,17 @@ func (r *repository) UpdateObjectData(ctx context.Context, request entity.DataMu
 }
 
 func (r *repository) DeleteObjectData(ctx context.Context, request entity.DataMutationRequest) (err error) {
+	db, dialect, err := r.dataDB(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return err
+	}
+
+	// make sure the object exists before composing the query
+	tableColumns, err := r.getTableColumns(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return err
+	}
+
 	// compose where clause
 	identifierColumn := entity.DEFAULT_IDENTIFIER
 	if !helper.IsUUID(request.Serial) {
@@ -555,17 +859,91 @@ func (r *repository) DeleteObjectData(ctx context.Context, request entity.DataMu
 	}
 
 	// compose delete query
-	completeTableName := request.TenantCode + "." + request.ObjectCode
-	updateQuery := fmt.Sprintf("UPDATE %v SET deleted_at = NOW() WHERE %v.%v = '%v'", completeTableName, completeTableName, identifierColumn, request.Serial)
+	completeTableName := querybuilder.TableIn(dialect, request.TenantCode, request.ObjectCode)
+	setClauses := []string{fmt.Sprintf("deleted_at = %v", dialect.Now())}
+	var values []any
+	if tableColumns.Has("deleted_by") && request.UserSerial != "" {
+		setClauses = append(setClauses, fmt.Sprintf("%v = ?", dialect.QuoteIdentifier("deleted_by")))
+		values = append(values, querybuilder.BindValue(tableColumns.DataType("deleted_by"), request.UserSerial))
+	}
 
-	// execute update query
-	if err := r.db.Exec(updateQuery).Error; err != nil {
+	updateQuery := querybuilder.New(fmt.Sprintf("UPDATE %v SET %v WHERE %v.%v = ? AND %v.deleted_at IS NULL", completeTableName, strings.Join(setClauses, ", "), completeTableName, dialect.QuoteIdentifier(identifierColumn), completeTableName), append(values, request.Serial)...)
+
+	rowFilterExpr, err := r.buildRowFilterCondition(ctx, dialect, request)
+	if err != nil {
 		return err
 	}
 
+	updateQuery.WriteExpr(" AND ", rowFilterExpr)
+
+	// execute update query
+	result := db.Exec(updateQuery.SQL(), updateQuery.Args()...)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return entity.ErrorNoUpdateDataFound
+	}
+
 	return nil
 }
 
+// WithTransaction runs fn with a repository bound to a transaction on the data source of the object, the
+// transaction is committed when fn returns nil. Calling it again on the transactional repository opens a savepoint,
+// for an object of another data source it fails. Objects of other data sources are queried outside of the
+// transaction.
+func (r *repository) WithTransaction(ctx context.Context, tenantCode, objectCode string, fn func(txRepo repository_intf.CatalogRepository) error) error {
+	dataSource, err := r.dataSources.resolve(ctx, r.db, tenantCode, objectCode)
+	if err != nil {
+		return err
+	}
+
+	dataSourceSerial := ""
+	if dataSource != nil {
+		dataSourceSerial = dataSource.Serial
+	}
+
+	// a nested transaction on another data source would commit on its own, whatever becomes of the outer one
+	if r.tx != nil && r.tx.dataSourceSerial != dataSourceSerial {
+		return fmt.Errorf("%w: object %v is not in the data source of the transaction", entity.ErrorBadRequest, objectCode)
+	}
+
+	db, _, err := r.dataDB(ctx, tenantCode, objectCode)
+	if err != nil {
+		return err
+	}
+
+	// the audit logs held back for the data sources are dropped along with the savepoint that recorded them
+	auditLogs := &[]entity.AuditLog{}
+	if r.tx != nil {
+		auditLogs = r.tx.auditLogs
+	}
+	pendingLogs := len(*auditLogs)
+
+	err = db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&repository{
+			cfg:              r.cfg,
+			db:               r.db,
+			dataSources:      r.dataSources,
+			searchIndexes:    r.searchIndexes,
+			displayTemplates: r.displayTemplates,
+			tx:               &dataSourceTx{dataSourceSerial: dataSourceSerial, db: tx, auditLogs: auditLogs},
+		})
+	})
+	if err != nil {
+		*auditLogs = (*auditLogs)[:pendingLogs]
+		return err
+	}
+
+	if r.tx != nil {
+		return nil
+	}
+
+	// the changes are committed, a failure to record them is reported but can not undo them
+	return r.writeAuditLogs(ctx, r.db, *auditLogs)
+}
+
 func isOperatorInLIKEList(operator entity.FilterOperator) bool {
 	for _, validOperator := range entity.OperatorLIKEList {
 		if operator == validOperator {
@@ -583,7 +961,12 @@ func (r *repository) GetObjectByCode(ctx context.Context, objectCode, tenantCode
 	db.Where("tenants.code = ?", tenantCode)
 
 	result := Objects{}
-	if err := db.First(&result).Error; err != nil {
+	err = db.First(&result).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return resp, fmt.Errorf("%w: object %v is not found in tenant %v", entity.ErrorNotFound, objectCode, tenantCode)
+	}
+
+	if err != nil {
 		return resp, err
 	}
 
@@ -649,29 +1032,15 @@ func (r *repository) GetDataTypeBySerials(ctx context.Context, serials []string)
 }
 
 func (r *repository) GetForeignKeyInfo(ctx context.Context, tableName, columnName, schemaName string) (resp entity.ForeignKeyInfo, err error) {
-	query := `
-	SELECT
-		ccu.table_schema AS foreign_schema,
-		ccu.table_name   AS foreign_table,
-		ccu.column_name  AS foreign_column
-	FROM
-		information_schema.table_constraints AS tc
-		JOIN information_schema.key_column_usage AS kcu
-		  ON tc.constraint_name = kcu.constraint_name
-		 AND tc.constraint_schema = kcu.constraint_schema
-		JOIN information_schema.constraint_column_usage AS ccu
-		  ON ccu.constraint_name = tc.constraint_name
-		 AND ccu.constraint_schema = tc.constraint_schema
-	WHERE
-		tc.constraint_type = 'FOREIGN KEY'
-		AND kcu.column_name = ?
-		AND tc.table_name = ?
-		AND tc.table_schema = ?
-	LIMIT 1;
-	`
+	db, dialect, err := r.dataDB(ctx, schemaName, tableName)
+	if err != nil {
+		return resp, err
+	}
+
+	query := dialect.ForeignKeyQuery(schemaName, tableName, columnName)
 
 	result := ForeignKeyInfo{}
-	if err = r.db.Raw(query, columnName, tableName, schemaName).Scan(&result).Error; err != nil {
+	if err = db.Raw(query.SQL, query.Args...).Scan(&result).Error; err != nil {
 		return resp, err
 	}
 
@@ -680,106 +1049,296 @@ func (r *repository) GetForeignKeyInfo(ctx context.Context, tableName, columnNam
 
 // local function
 
-// Helper function to build dynamic filters based on CatalogQuery
-func (r *repository) buildFilters(_ context.Context, request entity.CatalogQuery) string {
-	var filterClauses []string
+// getTableColumns returns the allow-list of every column of tenant.object, straight from the introspection of its dialect
+func (r *repository) getTableColumns(ctx context.Context, tenantCode, objectCode string) (querybuilder.Columns, error) {
+	db, dialect, err := r.dataDB(ctx, tenantCode, objectCode)
+	if err != nil {
+		return nil, err
+	}
 
-	for _, filterGroup := range request.Filters {
-		var groupClauses []string
+	query := dialect.TableColumnsQuery(tenantCode, objectCode)
 
-		for fieldName, filter := range filterGroup.Filters {
-			completeTableName := fmt.Sprintf("%v.%v", request.TenantCode, request.ObjectCode)
-			operator := entity.OperatorQueryMap[filter.Operator]
-			value := filter.Value
+	results := []map[string]any{}
+	if err := db.Raw(query.SQL, query.Args...).Scan(&results).Error; err != nil {
+		return nil, err
+	}
 
-			// handler value of operator is part of entity.OperatorLIKEList, then we should add %
-			if isOperatorInLIKEList(filter.Operator) {
-				value = fmt.Sprintf("%%%v%%", value)
+	if len(results) == 0 {
+		return nil, fmt.Errorf("object %v is not found in tenant %v", objectCode, tenantCode)
+	}
+
+	return querybuilder.NewColumns(results), nil
+}
+
+// Helper function to build dynamic filters based on CatalogQuery, along with the joins of the relationship fields
+// the filters reference
+func (r *repository) buildFilters(ctx context.Context, dialect querybuilder.Dialect, request entity.CatalogQuery, tableColumns querybuilder.Columns) (querybuilder.Expr, []string, error) {
+	var filterClauses []querybuilder.Expr
+	var filterJoins []string
+	completeTableName := dialect.TableName(request.TenantCode, request.ObjectCode)
+
+	// handle fieldName that has double underscore this indicates that it is a relationship field, at any depth of
+	// the filter tree
+	chainedColumns := make(map[string]string)
+	for _, filterGroup := range request.Filters {
+		var walkErr error
+		filterGroup.Walk(func(fieldName string, _ entity.FilterItem) {
+			if _, ok := chainedColumns[fieldName]; ok || walkErr != nil || !strings.Contains(fieldName, "__") {
+				return
 			}
 
-			// Create filter conditions based on the field, operator, and value
-			var formattedValue string
-
-			switch v := value.(type) {
-			case nil:
-				formattedValue = "NULL"
-			case string:
-				formattedValue = fmt.Sprintf("'%s'", v)
-			case bool:
-				formattedValue = fmt.Sprintf("%t", v)
-			case int, int8, int16, int32, int64:
-				formattedValue = fmt.Sprintf("%d", v)
-			case float32, float64:
-				formattedValue = fmt.Sprintf("%f", v)
-			default:
-				val := reflect.ValueOf(value)
-				if val.Kind() == reflect.Slice {
-					// It's a slice/array
-					formattedValue = "("
-
-					for i := range val.Len() {
-						elem := val.Index(i).Interface()
-						if elem == nil {
-							formattedValue += "NULL"
-						} else {
-							formattedValue += fmt.Sprintf("'%v'", elem)
-						}
-						if i < val.Len()-1 {
-							formattedValue += ", "
-						}
-					}
+			reference, joinQueryMap, joinQueryOrder, err := r.chainedColumn(ctx, dialect, completeTableName, request, fieldName)
+			if err != nil {
+				walkErr = err
+				return
+			}
 
-					formattedValue += ")"
-				} else {
-					// Fallback
-					formattedValue = fmt.Sprintf("'%v'", v)
-				}
+			chainedColumns[fieldName] = reference
+			for _, joinKey := range joinQueryOrder {
+				filterJoins = append(filterJoins, joinQueryMap[joinKey])
 			}
+		})
 
-			// lets create logic to handle case sensitive field and value
+		if walkErr != nil {
+			return querybuilder.Expr{}, filterJoins, walkErr
+		}
+	}
 
-			if strings.Contains(fieldName, "__") {
-				// handle fieldName that has double underscore this indicates that it is a relationship field
-				foreignFieldSet := strings.Split(fieldName, "__")
+	for _, filterGroup := range request.Filters {
+		groupClause, err := buildFilterGroup(dialect, completeTableName, filterGroup, tableColumns, chainedColumns)
+		if err != nil {
+			return querybuilder.Expr{}, filterJoins, err
+		}
 
-				if len(foreignFieldSet) < 2 {
-					continue
-				}
+		filterClauses = append(filterClauses, groupClause)
+	}
 
-				foreignFieldName := fmt.Sprintf("%v.%v", fieldName, foreignFieldSet[1])
-				groupClauses = append(groupClauses, fmt.Sprintf("%s %s %s", foreignFieldName, operator, formattedValue))
-			} else {
-				groupClauses = append(groupClauses, fmt.Sprintf("%s %s %s", fmt.Sprintf("%v.%v", completeTableName, fieldName), operator, formattedValue))
+	return querybuilder.Join(filterClauses, " AND "), filterJoins, nil
+}
+
+// buildFilterGroup builds the condition of a filter group and its children, an empty group matches everything.
+// chainedColumns holds the references of the relationship fields.
+func buildFilterGroup(dialect querybuilder.Dialect, tableName string, filterGroup entity.FilterGroup, tableColumns querybuilder.Columns, chainedColumns map[string]string) (querybuilder.Expr, error) {
+	var groupClauses []querybuilder.Expr
+
+	addCondition := func(fieldName string, filter entity.FilterItem) error {
+		var column string
+
+		if reference, ok := chainedColumns[fieldName]; ok {
+			column = reference
+		} else {
+			reference, err := tableColumns.ReferenceIn(dialect, tableName, fieldName)
+			if err != nil {
+				return err
 			}
 
+			column = reference
 		}
-		// Combine the group clauses with the group operator (AND/OR)
-		if len(groupClauses) > 0 {
-			operatorValue, ok := filterGroup.Operator.AsT2()
 
-			// Fallback to "AND" if operator is not set or empty
-			if !ok || operatorValue == "" {
-				operatorValue = "AND"
-			}
+		clause, err := buildFilterCondition(dialect, column, filter)
+		if err != nil {
+			return err
+		}
+
+		groupClauses = append(groupClauses, clause)
+		return nil
+	}
 
-			clause := fmt.Sprintf("(%s)", strings.Join(groupClauses, fmt.Sprintf(" %s ", operatorValue)))
-			filterClauses = append(filterClauses, clause)
+	for fieldName, filter := range filterGroup.Filters {
+		if err := addCondition(fieldName, filter); err != nil {
+			return querybuilder.Expr{}, err
 		}
 	}
 
-	filterQuery := ""
-	if len(filterClauses) > 0 {
-		filterQuery = strings.Join(filterClauses, " AND ")
+	for _, filter := range filterGroup.Conditions {
+		if err := addCondition(filter.FieldName, filter); err != nil {
+			return querybuilder.Expr{}, err
+		}
 	}
 
-	return filterQuery
+	for _, childGroup := range filterGroup.Groups {
+		childClause, err := buildFilterGroup(dialect, tableName, childGroup, tableColumns, chainedColumns)
+		if err != nil {
+			return querybuilder.Expr{}, err
+		}
+
+		groupClauses = append(groupClauses, childClause)
+	}
+
+	// Combine the group clauses with the group operator (AND/OR), NOT negates the AND of them
+	switch filterGroup.GroupOperator() {
+	case entity.FilterOperatorOr:
+		return querybuilder.Join(groupClauses, " OR "), nil
+	case entity.FilterOperatorNot:
+		groupClause := querybuilder.Join(groupClauses, " AND ")
+		if groupClause.IsEmpty() {
+			return groupClause, nil
+		}
+
+		return querybuilder.NewExpr("NOT "+groupClause.SQL, groupClause.Args...), nil
+	}
+
+	return querybuilder.Join(groupClauses, " AND "), nil
+}
+
+func buildFilterCondition(dialect querybuilder.Dialect, column string, filter entity.FilterItem) (querybuilder.Expr, error) {
+	switch filter.Operator {
+	case entity.FilterOperatorIsNull:
+		return querybuilder.NewExpr(fmt.Sprintf("%s IS NULL", column)), nil
+	case entity.FilterOperatorIsNotNull:
+		return querybuilder.NewExpr(fmt.Sprintf("%s IS NOT NULL", column)), nil
+	case entity.FilterOperatorBetween:
+		values := toValueList(filter.Value)
+		if len(values) != 2 || values[0] == nil || values[1] == nil {
+			return querybuilder.Expr{}, fmt.Errorf("%w: between needs a lower and an upper value", entity.ErrorBadRequest)
+		}
+
+		return querybuilder.NewExpr(fmt.Sprintf("%s BETWEEN ? AND ?", column), values...), nil
+	case entity.FilterOperatorToday, entity.FilterOperatorBeforeToday, entity.FilterOperatorAfterToday,
+		entity.FilterOperatorLastNDays, entity.FilterOperatorNextNDays,
+		entity.FilterOperatorThisWeek, entity.FilterOperatorThisMonth, entity.FilterOperatorThisYear:
+		return buildDateRangeCondition(column, filter, time.Now())
+	}
+
+	if condition, ok := dialect.OperatorCondition(column, filter.Operator, filter.Value); ok {
+		return condition, nil
+	}
+
+	operator, ok := dialect.Operator(filter.Operator)
+	if !ok {
+		return querybuilder.Expr{}, fmt.Errorf("unsupported filter operator %v", filter.Operator)
+	}
+
+	value := filter.Value
+
+	// the value of a LIKE operator matches literally, its wildcards are escaped before the pattern is built
+	isLike := isOperatorInLIKEList(filter.Operator) || filter.Operator == entity.FilterOperatorStartsWith || filter.Operator == entity.FilterOperatorEndsWith
+	if isLike {
+		value = querybuilder.EscapeLike(fmt.Sprintf("%v", value))
+	}
+
+	// handler value of operator is part of entity.OperatorLIKEList, then we should add %
+	if isOperatorInLIKEList(filter.Operator) {
+		value = fmt.Sprintf("%%%v%%", value)
+	}
+
+	switch filter.Operator {
+	case entity.FilterOperatorStartsWith:
+		value = fmt.Sprintf("%v%%", value)
+	case entity.FilterOperatorEndsWith:
+		value = fmt.Sprintf("%%%v", value)
+	}
+
+	if isLike {
+		return querybuilder.NewExpr(fmt.Sprintf("%s %s ? %s", column, operator, dialect.LikeEscape()), value), nil
+	}
+
+	if filter.Operator == entity.FilterOperatorIN || filter.Operator == entity.FilterOperatorNotIN {
+		values := toValueList(value)
+		if len(values) == 0 && filter.Operator == entity.FilterOperatorNotIN {
+			return querybuilder.NewExpr("TRUE"), nil
+		}
+
+		if len(values) == 0 {
+			return querybuilder.NewExpr(fmt.Sprintf("%s %s (NULL)", column, operator)), nil
+		}
+
+		return querybuilder.NewExpr(fmt.Sprintf("%s %s (%s)", column, operator, querybuilder.Placeholders(len(values))), values...), nil
+	}
+
+	return querybuilder.NewExpr(fmt.Sprintf("%s %s ?", column, operator), querybuilder.BindValue("", value)), nil
+}
+
+// buildDateRangeCondition matches the dates of a relative date operator, the range is bound as timestamps taken
+// from now so it reads the same on every database
+func buildDateRangeCondition(column string, filter entity.FilterItem, now time.Time) (querybuilder.Expr, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	tomorrow := today.AddDate(0, 0, 1)
+
+	var start, end time.Time
+	switch filter.Operator {
+	case entity.FilterOperatorToday:
+		start, end = today, tomorrow
+	case entity.FilterOperatorBeforeToday:
+		return querybuilder.NewExpr(fmt.Sprintf("%s < ?", column), today), nil
+	case entity.FilterOperatorAfterToday:
+		return querybuilder.NewExpr(fmt.Sprintf("%s >= ?", column), tomorrow), nil
+	case entity.FilterOperatorLastNDays, entity.FilterOperatorNextNDays:
+		days, err := strconv.Atoi(strings.TrimSpace(fmt.Sprintf("%v", filter.Value)))
+		if err != nil || days < 0 {
+			return querybuilder.Expr{}, fmt.Errorf("%w: %v needs a number of days", entity.ErrorBadRequest, filter.Operator)
+		}
+
+		// the last days include today, the next days start tomorrow
+		start, end = today.AddDate(0, 0, -days), tomorrow
+		if filter.Operator == entity.FilterOperatorNextNDays {
+			start, end = tomorrow, tomorrow.AddDate(0, 0, days)
+		}
+	case entity.FilterOperatorThisWeek:
+		start = today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+		end = start.AddDate(0, 0, 7)
+	case entity.FilterOperatorThisMonth:
+		start = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		end = start.AddDate(0, 1, 0)
+	case entity.FilterOperatorThisYear:
+		start = time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, now.Location())
+		end = start.AddDate(1, 0, 0)
+	}
+
+	return querybuilder.NewExpr(fmt.Sprintf("(%s >= ? AND %s < ?)", column, column), start, end), nil
+}
+
+// toValueList flattens a slice or array filter value into bindable arguments
+func toValueList(value any) []any {
+	if value == nil {
+		return nil
+	}
+
+	val := reflect.ValueOf(value)
+	if val.Kind() != reflect.Slice && val.Kind() != reflect.Array {
+		return []any{querybuilder.BindValue("", value)}
+	}
+
+	values := make([]any, 0, val.Len())
+	for i := range val.Len() {
+		values = append(values, querybuilder.BindValue("", val.Index(i).Interface()))
+	}
+
+	return values
 }
 
 // Helper function to build dynamic order by clauses
-func buildOrderBy(request entity.CatalogQuery, columnsList []map[string]any) (string, map[string]string, []string) {
-	var orderClauses []string
+func buildOrderBy(dialect querybuilder.Dialect, request entity.CatalogQuery, columnsList []map[string]any, tableColumns querybuilder.Columns) (string, map[string]string, []string, error) {
+	orderColumns, joinQueryMap, joinQueryOrder, err := buildOrderColumns(dialect, request, columnsList, tableColumns)
+	if err != nil {
+		return "", joinQueryMap, joinQueryOrder, err
+	}
+
+	orderClauses := make([]string, 0, len(orderColumns))
+	for _, orderColumn := range orderColumns {
+		orderClauses = append(orderClauses, orderColumn.clause())
+	}
+
+	return strings.Join(orderClauses, ", "), joinQueryMap, joinQueryOrder, nil
+}
+
+// orderColumn is a quoted column reference of an order clause
+type orderColumn struct {
+	reference string
+	dataType  string
+	direction string
+}
+
+func (o orderColumn) clause() string {
+	return fmt.Sprintf("%s %s", o.reference, o.direction)
+}
+
+// buildOrderColumns resolves the orders of the request into column references and the joins they need
+func buildOrderColumns(dialect querybuilder.Dialect, request entity.CatalogQuery, columnsList []map[string]any, tableColumns querybuilder.Columns) ([]orderColumn, map[string]string, []string, error) {
+	var orderColumns []orderColumn
 	joinQueryMap := make(map[string]string)
 	joinQueryOrder := make([]string, 0)
+	mainTableName := dialect.TableName(request.TenantCode, request.ObjectCode)
 
 	for _, order := range request.Orders {
 		fieldName := order.FieldName
@@ -797,266 +1356,226 @@ func buildOrderBy(request entity.CatalogQuery, columnsList []map[string]any) (st
 				}
 			}
 
-			if foreignTableName != "" {
-				// Create a unique alias for this join
-				joinAlias := fmt.Sprintf("order_%s_%s", parts[0], parts[1])
-				foreignTableFullName := fmt.Sprintf("%v.%v", request.TenantCode, foreignTableName)
-				mainTableName := fmt.Sprintf("%v.%v", request.TenantCode, request.ObjectCode)
-
-				// Create join clause
-				joinClause := fmt.Sprintf("LEFT JOIN %v as %v ON %v.%v = %v.%v",
-					foreignTableFullName,
-					joinAlias,
-					joinAlias,
-					"serial",
-					mainTableName,
-					parts[0])
-
-				// Add to join maps if not exists
-				if _, exists := joinQueryMap[joinAlias]; !exists {
-					joinQueryMap[joinAlias] = joinClause
-					joinQueryOrder = append(joinQueryOrder, joinAlias)
-				}
+			if foreignTableName == "" {
+				return orderColumns, joinQueryMap, joinQueryOrder, fmt.Errorf("field %v is not found in table %v", fieldName, request.ObjectCode)
+			}
 
-				fieldName = fmt.Sprintf("%v.%v", joinAlias, parts[1])
-			} else {
-				fieldName = fmt.Sprintf("%v.%v.%v", request.TenantCode, request.ObjectCode, fieldName)
+			// Create a unique alias for this join
+			joinAlias := fmt.Sprintf("order_%s_%s", parts[0], parts[1])
+
+			// Create join clause
+			joinClause := fmt.Sprintf("LEFT JOIN %v as %v ON %v.%v = %v.%v",
+				querybuilder.TableIn(dialect, request.TenantCode, foreignTableName),
+				dialect.QuoteIdentifier(joinAlias),
+				dialect.QuoteIdentifier(joinAlias),
+				dialect.QuoteIdentifier("serial"),
+				querybuilder.QuoteReferenceIn(dialect, mainTableName),
+				dialect.QuoteIdentifier(parts[0]))
+
+			// Add to join maps if not exists
+			if _, exists := joinQueryMap[joinAlias]; !exists {
+				joinQueryMap[joinAlias] = joinClause
+				joinQueryOrder = append(joinQueryOrder, joinAlias)
 			}
+
+			orderColumns = append(orderColumns, orderColumn{
+				reference: fmt.Sprintf("%v.%v", dialect.QuoteIdentifier(joinAlias), dialect.QuoteIdentifier(parts[1])),
+				direction: querybuilder.Direction(order.Direction),
+			})
 		} else {
-			fieldName = fmt.Sprintf("%v.%v.%v", request.TenantCode, request.ObjectCode, fieldName)
+			reference, err := tableColumns.ReferenceIn(dialect, mainTableName, fieldName)
+			if err != nil {
+				return orderColumns, joinQueryMap, joinQueryOrder, err
+			}
+
+			orderColumns = append(orderColumns, orderColumn{
+				reference: reference,
+				dataType:  tableColumns.DataType(fieldName),
+				direction: querybuilder.Direction(order.Direction),
+			})
 		}
-		orderClauses = append(orderClauses, fmt.Sprintf("%s %s", fieldName, order.Direction))
 	}
-	return strings.Join(orderClauses, ", "), joinQueryMap, joinQueryOrder
+
+	return orderColumns, joinQueryMap, joinQueryOrder, nil
 }
 
-func (r *repository) getSingleData(ctx context.Context, columnList []map[string]interface{}, columnsString, tableName string, request entity.CatalogQuery, joinQueryMap map[string]string, joinQueryOrder []string) string {
-	// Start building the base query
-	query := fmt.Sprintf(`
-		SELECT %v
-		FROM %v`, columnsString, tableName)
+// buildSelectQuery composes "SELECT ... FROM ... JOIN ... WHERE ..." shared by the detail, list and count queries
+func (r *repository) buildSelectQuery(ctx context.Context, dialect querybuilder.Dialect, selectClause, tableName string, request entity.CatalogQuery, joinQueryMap map[string]string, joinQueryOrder []string, tableColumns querybuilder.Columns, extraJoins ...string) (*querybuilder.Query, error) {
+	return r.buildSelectExprQuery(ctx, dialect, querybuilder.NewExpr(selectClause), tableName, request, joinQueryMap, joinQueryOrder, tableColumns, extraJoins...)
+}
 
-	// handle join table if any
-	for _, joinKey := range joinQueryOrder {
-		if !strings.Contains(query, joinQueryMap[joinKey]) {
-			query = fmt.Sprintf("%s %s", query, joinQueryMap[joinKey])
+// buildSelectExprQuery is buildSelectQuery with a select clause binding arguments of its own
+func (r *repository) buildSelectExprQuery(ctx context.Context, dialect querybuilder.Dialect, selectClause querybuilder.Expr, tableName string, request entity.CatalogQuery, joinQueryMap map[string]string, joinQueryOrder []string, tableColumns querybuilder.Columns, extraJoins ...string) (*querybuilder.Query, error) {
+	// Collect all join clauses
+	var allJoins []string
+	addJoin := func(joinClause string) {
+		if !helper.Contains(allJoins, joinClause) {
+			allJoins = append(allJoins, joinClause)
 		}
 	}
 
-	// checking if filters contains join table condition
-	for _, filterGroup := range request.Filters {
-		for fieldName, filter := range filterGroup.Filters {
-			if strings.Contains(fieldName, "__") {
-				queryResult, _ := r.HandleChainingJoinQuery(ctx, query, fieldName, tableName, request, filter)
-				query = queryResult
-			}
-		}
+	// Add existing joins
+	for _, joinKey := range joinQueryOrder {
+		addJoin(joinQueryMap[joinKey])
 	}
 
-	// check if table has deleted_at column
-	hasDeletedAt := false
-	for _, column := range columnList {
-		if column[entity.FieldColumnCode] == "deleted_at" {
-			hasDeletedAt = true
-			break
-		}
+	// Apply dynamic filters if they exist, with the joins of the relationship fields they reference
+	filterExpr, filterJoins, err := r.buildFilters(ctx, dialect, request, tableColumns)
+	if err != nil {
+		return nil, err
 	}
 
-	if hasDeletedAt {
-		query = query + fmt.Sprintf(" WHERE %v.deleted_at IS NULL", tableName)
-	} else {
-		query = query + " WHERE TRUE"
+	for _, joinClause := range filterJoins {
+		addJoin(joinClause)
 	}
 
-	// Apply dynamic filters if they exist
-	if len(request.Filters) > 0 {
-		filterString := r.buildFilters(ctx, request)
-
-		if len(filterString) > 0 {
-			query = query + " AND " + filterString
-		}
+	for _, joinClause := range extraJoins {
+		addJoin(joinClause)
 	}
 
-	// // handle join table if any
-	// for _, column := range columnList {
-	// 	if column[entity.FieldForeignTableName] != nil && column[entity.FieldForeignColumnName] != nil {
-	// 		foreignTableName := column[entity.FieldForeignTableName].(string)
-	// 		foreignFieldName := column[entity.FieldForeignColumnName].(string)
-
-	// 		joinClause := fmt.Sprintf("LEFT JOIN %v ON %v = %v.%v", foreignTableName, column[entity.FieldForeignColumnName], foreignTableName, foreignFieldName)
-
-	// 		if !strings.Contains(query, joinClause) {
-	// 			query = fmt.Sprintf("%s %s", query, joinClause)
-	// 		}
-	// 	}
-	// }
+	query := querybuilder.New(fmt.Sprintf("SELECT %v FROM %v", selectClause.SQL, querybuilder.QuoteReferenceIn(dialect, tableName)), selectClause.Args...)
+	for _, joinClause := range allJoins {
+		query.Write(" " + joinClause)
+	}
 
-	// // check if table has deleted_at column
-	// hasDeletedAt := false
-	// for _, column := range columnList {
-	// 	if column[entity.FieldColumnCode] == "deleted_at" {
-	// 		hasDeletedAt = true
-	// 		break
-	// 	}
-	// }
+	// the soft deleted records are left out unless the trash is asked for, a table without deleted_at has none
+	switch {
+	case request.IsOnlyDeleted && !tableColumns.Has("deleted_at"):
+		query.Write(" WHERE FALSE")
+	case request.IsOnlyDeleted:
+		query.Write(fmt.Sprintf(" WHERE %v.deleted_at IS NOT NULL", querybuilder.QuoteReferenceIn(dialect, tableName)))
+	case request.IsIncludeDeleted || !tableColumns.Has("deleted_at"):
+		query.Write(" WHERE TRUE")
+	default:
+		query.Write(fmt.Sprintf(" WHERE %v.deleted_at IS NULL", querybuilder.QuoteReferenceIn(dialect, tableName)))
+	}
 
-	// if hasDeletedAt {
-	// 	query = query + fmt.Sprintf(" WHERE %v.deleted_at IS NULL", tableName)
-	// } else {
-	// 	query = query + " WHERE TRUE"
-	// }
+	query.WriteExpr(" AND ", filterExpr)
 
-	// apply serial to get single data
-	identifierColumn := entity.DEFAULT_IDENTIFIER
-	if !helper.IsUUID(request.Serial) {
-		identifierColumn = "code"
+	search, err := r.resolveSearch(ctx, dialect, tableName, request, tableColumns)
+	if err != nil {
+		return nil, err
 	}
 
-	query = query + fmt.Sprintf(" AND %v.%v = '%v'", tableName, identifierColumn, request.Serial)
+	if search != nil {
+		query.WriteExpr(" AND ", search.condition(dialect))
+	}
 
-	return query
+	return query, nil
 }
 
-// Main function to get data with pagination, filters, and orders
-func (r *repository) getDataWithPagination(ctx context.Context, columnsString, tableName string, request entity.CatalogQuery, joinQueryMap map[string]string, joinQueryOrder []string, columnList []map[string]any) string {
-	// Start building the base query
-	query := fmt.Sprintf(`SELECT %v FROM %v`, columnsString, tableName)
-
-	// Collect all join clauses
-	var allJoins []string
-
-	// Add existing joins
-	for _, joinKey := range joinQueryOrder {
-		if !strings.Contains(query, joinQueryMap[joinKey]) {
-			allJoins = append(allJoins, joinQueryMap[joinKey])
-		}
+// buildRowFilterCondition restricts an update or delete to the records matching the row filters of the
+// request. The filters may reference related objects, so they are applied through a subquery carrying their joins.
+func (r *repository) buildRowFilterCondition(ctx context.Context, dialect querybuilder.Dialect, request entity.DataMutationRequest) (querybuilder.Expr, error) {
+	if len(request.Filters) == 0 {
+		return querybuilder.Expr{}, nil
 	}
 
-	// checking if filters contains join table condition
-	for _, filterGroup := range request.Filters {
-		for fieldName, filter := range filterGroup.Filters {
-			if strings.Contains(fieldName, "__") {
-				queryResult, joinMap := r.HandleChainingJoinQuery(ctx, query, fieldName, tableName, request, filter)
-				query = queryResult
-				// Add any new joins from filter
-				for _, joinClause := range joinMap {
-					if !strings.Contains(query, joinClause) {
-						allJoins = append(allJoins, joinClause)
-					}
-				}
-			}
-		}
+	tableColumns, err := r.getTableColumns(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return querybuilder.Expr{}, err
 	}
 
-	// check if table has deleted_at column
-	hasDeletedAt := false
-	for _, column := range columnList {
-		if column[entity.FieldColumnCode] == "deleted_at" {
-			hasDeletedAt = true
-			break
-		}
+	tableName := dialect.TableName(request.TenantCode, request.ObjectCode)
+	identifierColumn := fmt.Sprintf("%v.%v", querybuilder.QuoteReferenceIn(dialect, tableName), dialect.QuoteIdentifier(entity.DEFAULT_IDENTIFIER))
+
+	// the mutation decides on the soft deleted records, a restore finds them through the filters
+	subQuery, err := r.buildSelectQuery(ctx, dialect, identifierColumn, tableName, entity.CatalogQuery{
+		TenantCode:       request.TenantCode,
+		ProductCode:      request.ProductCode,
+		ObjectCode:       request.ObjectCode,
+		Filters:          request.Filters,
+		IsIncludeDeleted: true,
+	}, nil, nil, tableColumns)
+	if err != nil {
+		return querybuilder.Expr{}, err
 	}
 
-	// Build WHERE clause
-	whereClause := "WHERE TRUE"
-	if hasDeletedAt {
-		whereClause = fmt.Sprintf("WHERE %v.deleted_at IS NULL", tableName)
+	return querybuilder.NewExpr(fmt.Sprintf("%v IN (%v)", identifierColumn, subQuery.SQL()), subQuery.Args()...), nil
+}
+
+func (r *repository) getSingleData(ctx context.Context, dialect querybuilder.Dialect, columnsString, tableName string, request entity.CatalogQuery, joinQueryMap map[string]string, joinQueryOrder []string, tableColumns querybuilder.Columns) (*querybuilder.Query, error) {
+	// Start building the base query
+	query, err := r.buildSelectQuery(ctx, dialect, columnsString, tableName, request, joinQueryMap, joinQueryOrder, tableColumns)
+	if err != nil {
+		return nil, err
 	}
 
-	// Apply dynamic filters if they exist
-	if len(request.Filters) > 0 {
-		filterString := r.buildFilters(ctx, request)
-		if len(filterString) > 0 {
-			whereClause = whereClause + " AND " + filterString
-		}
+	// apply serial to get single data
+	identifierColumn := entity.DEFAULT_IDENTIFIER
+	if !helper.IsUUID(request.Serial) {
+		identifierColumn = "code"
 	}
 
-	// Apply dynamic order by if they exist
-	if len(request.Orders) > 0 {
-		orderString, orderJoinMap, orderJoinOrder := buildOrderBy(request, columnList)
+	query.Write(fmt.Sprintf(" AND %v.%v = ?", querybuilder.QuoteReferenceIn(dialect, tableName), dialect.QuoteIdentifier(identifierColumn)), request.Serial)
 
-		// Add any new joins from order by
-		for _, joinKey := range orderJoinOrder {
-			if !strings.Contains(query, orderJoinMap[joinKey]) {
-				allJoins = append(allJoins, orderJoinMap[joinKey])
-			}
-		}
+	return query, nil
+}
 
-		// Combine all parts of the query
-		query = fmt.Sprintf("%s %s %s ORDER BY %s",
-			query,
-			strings.Join(allJoins, " "),
-			whereClause,
-			orderString)
-	} else {
-		// If no order by, just combine the parts without ORDER BY
-		query = fmt.Sprintf("%s %s %s",
-			query,
-			strings.Join(allJoins, " "),
-			whereClause)
+// Main function to get data with pagination, filters, and orders
+func (r *repository) getDataWithPagination(ctx context.Context, dialect querybuilder.Dialect, columnsString, tableName string, request entity.CatalogQuery, joinQueryMap map[string]string, joinQueryOrder []string, columnList []map[string]any, tableColumns querybuilder.Columns) (*querybuilder.Query, error) {
+	query, err := r.getOrderedDataQuery(ctx, dialect, columnsString, tableName, request, joinQueryMap, joinQueryOrder, columnList, tableColumns)
+	if err != nil {
+		return nil, err
 	}
 
 	// Apply pagination (LIMIT and OFFSET)
-	query = fmt.Sprintf("%s LIMIT %d OFFSET %d", query, request.PageSize, (request.Page-1)*request.PageSize)
-	log.Print(query)
+	dialect.Paginate(query, request.PageSize, (request.Page-1)*request.PageSize)
+	log.Print(query.SQL())
 
-	return query
+	return query, nil
 }
 
-func (r *repository) getTotalCountQuery(ctx context.Context, tableName string, request entity.CatalogQuery, joinQueryMap map[string]string, joinQueryOrder []string, columnList []map[string]any) string {
-	query := fmt.Sprintf(`SELECT COUNT(*) FROM %v`, tableName)
-
-	// integrate join query if any
-	for _, joinKey := range joinQueryOrder {
-		if !strings.Contains(query, joinQueryMap[joinKey]) {
-			query = fmt.Sprintf("%s %s", query, joinQueryMap[joinKey])
+// getOrderedDataQuery builds the filtered and ordered select without pagination
+func (r *repository) getOrderedDataQuery(ctx context.Context, dialect querybuilder.Dialect, columnsString, tableName string, request entity.CatalogQuery, joinQueryMap map[string]string, joinQueryOrder []string, columnList []map[string]any, tableColumns querybuilder.Columns) (*querybuilder.Query, error) {
+	// Apply dynamic order by if they exist
+	orderString := ""
+	orderJoins := []string{}
+	if len(request.Orders) > 0 {
+		orderBy, orderJoinMap, orderJoinOrder, err := buildOrderBy(dialect, request, columnList, tableColumns)
+		if err != nil {
+			return nil, err
 		}
-	}
-
-	// checking if filters contains join table condition
-	for _, filterGroup := range request.Filters {
-		for fieldName, filter := range filterGroup.Filters {
-			if strings.Contains(fieldName, "__") {
-				queryResult, _ := r.HandleChainingJoinQuery(ctx, query, fieldName, tableName, request, filter)
-				query = queryResult
 
-				// fmt.Print("joinQueryMap: ", joinQueryMap)
-			}
+		orderString = orderBy
+		for _, joinKey := range orderJoinOrder {
+			orderJoins = append(orderJoins, orderJoinMap[joinKey])
 		}
 	}
 
-	// check if table has deleted_at column
-	hasDeletedAt := false
-	for _, column := range columnList {
-		if column[entity.FieldColumnCode] == "deleted_at" {
-			hasDeletedAt = true
-			break
-		}
+	// Start building the base query
+	query, err := r.buildSelectQuery(ctx, dialect, columnsString, tableName, request, joinQueryMap, joinQueryOrder, tableColumns, orderJoins...)
+	if err != nil {
+		return nil, err
 	}
 
-	if hasDeletedAt {
-		query = query + fmt.Sprintf(" WHERE %v.deleted_at IS NULL", tableName)
-	} else {
-		query = query + " WHERE TRUE"
+	if orderString != "" {
+		query.Write(" ORDER BY " + orderString)
 	}
 
-	// Apply dynamic filters if they exist
-	if len(request.Filters) > 0 {
-		filterString := r.buildFilters(ctx, request)
+	return query, nil
+}
 
-		if len(filterString) > 0 {
-			query = query + " AND " + filterString
-		}
+func (r *repository) getTotalCountQuery(ctx context.Context, dialect querybuilder.Dialect, tableName string, request entity.CatalogQuery, joinQueryMap map[string]string, joinQueryOrder []string, tableColumns querybuilder.Columns) (*querybuilder.Query, error) {
+	query, err := r.buildSelectQuery(ctx, dialect, "COUNT(*)", tableName, request, joinQueryMap, joinQueryOrder, tableColumns)
+	if err != nil {
+		return nil, err
 	}
 
-	log.Print(query)
-	return query
+	log.Print(query.SQL())
+	return query, nil
+}
+
+func (r *repository) HandleChainingJoinQuery(ctx context.Context, dialect querybuilder.Dialect, fieldName, tableName string, request entity.CatalogQuery) (joinQueryMap map[string]string, joinQueryOrder []string, err error) {
+	joinQueryMap, joinQueryOrder, _, err = r.handleChainingJoin(ctx, dialect, fieldName, tableName, request)
+	return joinQueryMap, joinQueryOrder, err
 }
 
-func (r *repository) HandleChainingJoinQuery(ctx context.Context, query, fieldName, tableName string, request entity.CatalogQuery, filter entity.FilterItem) (updatedQuery string, joinQueryMap map[string]string) {
+// handleChainingJoin is HandleChainingJoinQuery also returning the foreign key of the last join, the object the
+// chain ends in
+func (r *repository) handleChainingJoin(ctx context.Context, dialect querybuilder.Dialect, fieldName, tableName string, request entity.CatalogQuery) (joinQueryMap map[string]string, joinQueryOrder []string, target entity.ForeignKeyInfo, err error) {
 	// case example: user_serial__user_type_serial__name
 	joinQueryMap = make(map[string]string)
-	joinQuery := query
 
 	foreignFieldSet := strings.Split(fieldName, "__")
 	cleanTableName := strings.Split(tableName, ".")
@@ -1070,8 +1589,16 @@ func (r *repository) HandleChainingJoinQuery(ctx context.Context, query, fieldNa
 
 	for i, foreignField := range foreignFieldSet {
 		if i < len(foreignFieldSet)-1 {
-			foreignKeyInfo, _ := r.GetForeignKeyInfo(ctx, currentTableName, foreignField, request.TenantCode)
-			foreignTableName := fmt.Sprintf("%v.%v", request.TenantCode, foreignKeyInfo.ForeignTable)
+			foreignKeyInfo, err := r.GetForeignKeyInfo(ctx, currentTableName, foreignField, request.TenantCode)
+			if err != nil {
+				return joinQueryMap, joinQueryOrder, target, err
+			}
+
+			if foreignKeyInfo.ForeignTable == "" {
+				return joinQueryMap, joinQueryOrder, target, fmt.Errorf("field %v is not a relation of table %v", foreignField, currentTableName)
+			}
+
+			foreignTableName := querybuilder.TableIn(dialect, request.TenantCode, foreignKeyInfo.ForeignTable)
 
 			// check if i is the last element
 			joinAlias := fieldName
@@ -1083,77 +1610,26 @@ func (r *repository) HandleChainingJoinQuery(ctx context.Context, query, fieldNa
 			// clean join alias if it contains . convert into _
 			joinAlias = strings.ReplaceAll(joinAlias, ".", "_")
 
-			joinAliasField := joinAlias
-			joinTableName := tableName
+			joinTableName := querybuilder.QuoteReferenceIn(dialect, tableName)
 			if nextJoinAlias != "" {
-				joinTableName = nextJoinAlias
+				joinTableName = dialect.QuoteIdentifier(nextJoinAlias)
 			}
 
-			foreignFieldName := fmt.Sprintf("%v.%v", joinAliasField, foreignKeyInfo.ForeignColumn)
-			sourceFieldName := fmt.Sprintf("%v.%v", joinTableName, foreignField)
+			foreignFieldName := fmt.Sprintf("%v.%v", dialect.QuoteIdentifier(joinAlias), dialect.QuoteIdentifier(foreignKeyInfo.ForeignColumn))
+			sourceFieldName := fmt.Sprintf("%v.%v", joinTableName, dialect.QuoteIdentifier(foreignField))
 
-			joinClause := fmt.Sprintf("LEFT JOIN %v as %v ON %v = %v", foreignTableName, joinAlias, foreignFieldName, sourceFieldName)
-			if !strings.Contains(joinQuery, joinClause) {
-				joinQuery = fmt.Sprintf("%s %s", joinQuery, joinClause)
-			}
+			joinClause := fmt.Sprintf("LEFT JOIN %v as %v ON %v = %v", foreignTableName, dialect.QuoteIdentifier(joinAlias), foreignFieldName, sourceFieldName)
 
+			if _, ok := joinQueryMap[joinAlias]; !ok {
+				joinQueryOrder = append(joinQueryOrder, joinAlias)
+			}
 			joinQueryMap[joinAlias] = joinClause
 
 			currentTableName = foreignKeyInfo.ForeignTable
 			nextJoinAlias = joinAlias
+			target = foreignKeyInfo
 		}
 	}
 
-	return joinQuery, joinQueryMap
-}
-
-func (r *repository) HandleJoinQuery(ctx context.Context, query, fieldName, tableName string, request entity.CatalogQuery, filter entity.FilterItem) (updatedQuery string) {
-	// if fieldName contains double underscore, then we need to join the table
-	foreignFieldSet := strings.Split(fieldName, "__")
-
-	// get foreign table name based on fieldName
-	cleanTableName := strings.Split(tableName, ".")
-	foreignKeyInfo, _ := r.GetForeignKeyInfo(ctx, cleanTableName[1], foreignFieldSet[0], request.TenantCode)
-
-	foreignTableName := fmt.Sprintf("%v.%v", request.TenantCode, foreignKeyInfo.ForeignTable)
-	foreignFieldName := fmt.Sprintf("%v.%v", foreignTableName, foreignKeyInfo.ForeignColumn)
-	sourceFieldName := fmt.Sprintf("%v.%v", tableName, foreignFieldSet[0])
-
-	joinClause := fmt.Sprintf("LEFT JOIN %v ON %v = %v", foreignTableName, foreignFieldName, sourceFieldName)
-
-	if !strings.Contains(query, joinClause) {
-		query = fmt.Sprintf("%s %s", query, joinClause)
-	}
-
-	// add filter condition to query
-	operator := entity.OperatorQueryMap[filter.Operator]
-	value := filter.Value
-
-	// handler value of operator is part of entity.OperatorLIKEList, then we should add %
-	if isOperatorInLIKEList(filter.Operator) {
-		value = fmt.Sprintf("%%%v%%", value)
-	}
-
-	var formattedValue string
-
-	switch v := value.(type) {
-	case string:
-		// Wrap strings in single quotes
-		formattedValue = fmt.Sprintf("'%s'", v)
-	case bool:
-		// Booleans: PostgreSQL uses true/false literals
-		formattedValue = fmt.Sprintf("%t", v)
-	case int, int8, int16, int32, int64:
-		formattedValue = fmt.Sprintf("%d", v)
-	case float32, float64:
-		formattedValue = fmt.Sprintf("%f", v)
-	default:
-		// Fallback to string with single quotes
-		formattedValue = fmt.Sprintf("'%v'", v)
-	}
-
-	// Create filter conditions based on the field, operator, and value
-	query = fmt.Sprintf("%s AND %s %s %s", query, fmt.Sprintf("%v.%v", foreignTableName, foreignFieldSet[1]), operator, formattedValue)
-
-	return query
+	return joinQueryMap, joinQueryOrder, target, nil
 }
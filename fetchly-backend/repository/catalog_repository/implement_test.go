@@ -0,0 +1,477 @@
+package catalogrepository
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+	querybuilder "github.com/fetchlydev/source/fetchly-backend/repository/query_builder"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestBuildFilterCondition(t *testing.T) {
+	column := `"acme"."contact"."name"`
+
+	tests := []struct {
+		name     string
+		filter   entity.FilterItem
+		wantSQL  string
+		wantArgs []any
+	}{
+		{
+			name:     "equal binds the value",
+			filter:   entity.FilterItem{Operator: entity.FilterOperatorEqual, Value: "x' OR '1'='1"},
+			wantSQL:  column + " = ?",
+			wantArgs: []any{"x' OR '1'='1"},
+		},
+		{
+			name:     "not equal",
+			filter:   entity.FilterItem{Operator: entity.FilterOperatorNotEqual, Value: float64(2)},
+			wantSQL:  column + " != ?",
+			wantArgs: []any{float64(2)},
+		},
+		{
+			name:     "contains wraps the value",
+			filter:   entity.FilterItem{Operator: entity.FilterOperatorContains, Value: "ann"},
+			wantSQL:  column + ` ILIKE ? ESCAPE '\'`,
+			wantArgs: []any{"%ann%"},
+		},
+		{
+			name:     "contains matches wildcards literally",
+			filter:   entity.FilterItem{Operator: entity.FilterOperatorContains, Value: `50%_off\`},
+			wantSQL:  column + ` ILIKE ? ESCAPE '\'`,
+			wantArgs: []any{`%50\%\_off\\%`},
+		},
+		{
+			name:     "not contains",
+			filter:   entity.FilterItem{Operator: entity.FilterOperatorNotContains, Value: "ann"},
+			wantSQL:  column + ` NOT ILIKE ? ESCAPE '\'`,
+			wantArgs: []any{"%ann%"},
+		},
+		{
+			name:     "greater than",
+			filter:   entity.FilterItem{Operator: entity.FilterOperatorGreaterThan, Value: 3},
+			wantSQL:  column + " > ?",
+			wantArgs: []any{3},
+		},
+		{
+			name:     "in binds every value",
+			filter:   entity.FilterItem{Operator: entity.FilterOperatorIN, Value: []any{"a", "b", "c"}},
+			wantSQL:  column + " IN (?, ?, ?)",
+			wantArgs: []any{"a", "b", "c"},
+		},
+		{
+			name:     "in of a single value",
+			filter:   entity.FilterItem{Operator: entity.FilterOperatorIN, Value: "a"},
+			wantSQL:  column + " IN (?)",
+			wantArgs: []any{"a"},
+		},
+		{
+			name:    "in of no value matches nothing",
+			filter:  entity.FilterItem{Operator: entity.FilterOperatorIN, Value: []any{}},
+			wantSQL: column + " IN (NULL)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := buildFilterCondition(querybuilder.Postgres, column, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assertExpr(t, expr, tt.wantSQL, tt.wantArgs)
+		})
+	}
+}
+
+func TestBuildFilterConditionOfExtendedOperators(t *testing.T) {
+	column := `"acme"."contact"."age"`
+
+	tests := []struct {
+		name     string
+		filter   entity.FilterItem
+		wantSQL  string
+		wantArgs []any
+	}{
+		{
+			name:     "between",
+			filter:   entity.FilterItem{Operator: entity.FilterOperatorBetween, Value: []any{18, 65}},
+			wantSQL:  column + " BETWEEN ? AND ?",
+			wantArgs: []any{18, 65},
+		},
+		{
+			name:    "is null ignores the value",
+			filter:  entity.FilterItem{Operator: entity.FilterOperatorIsNull, Value: "x"},
+			wantSQL: column + " IS NULL",
+		},
+		{
+			name:    "is not null",
+			filter:  entity.FilterItem{Operator: entity.FilterOperatorIsNotNull},
+			wantSQL: column + " IS NOT NULL",
+		},
+		{
+			name:     "starts with",
+			filter:   entity.FilterItem{Operator: entity.FilterOperatorStartsWith, Value: "a_n"},
+			wantSQL:  column + ` ILIKE ? ESCAPE '\'`,
+			wantArgs: []any{`a\_n%`},
+		},
+		{
+			name:     "ends with",
+			filter:   entity.FilterItem{Operator: entity.FilterOperatorEndsWith, Value: "100%"},
+			wantSQL:  column + ` ILIKE ? ESCAPE '\'`,
+			wantArgs: []any{`%100\%`},
+		},
+		{
+			name:     "contains case sensitive",
+			filter:   entity.FilterItem{Operator: entity.FilterOperatorContainsCaseSensitive, Value: "A_n"},
+			wantSQL:  column + ` LIKE ? ESCAPE '\'`,
+			wantArgs: []any{`%A\_n%`},
+		},
+		{
+			name:     "not in",
+			filter:   entity.FilterItem{Operator: entity.FilterOperatorNotIN, Value: []any{1, 2}},
+			wantSQL:  column + " NOT IN (?, ?)",
+			wantArgs: []any{1, 2},
+		},
+		{
+			name:    "not in of no value matches everything",
+			filter:  entity.FilterItem{Operator: entity.FilterOperatorNotIN, Value: []any{}},
+			wantSQL: "TRUE",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := buildFilterCondition(querybuilder.Postgres, column, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assertExpr(t, expr, tt.wantSQL, tt.wantArgs)
+		})
+	}
+}
+
+func TestBuildFilterConditionOfInvalidBetween(t *testing.T) {
+	for _, value := range []any{nil, 1, []any{1}, []any{1, nil}, []any{1, 2, 3}} {
+		_, err := buildFilterCondition(querybuilder.Postgres, `"age"`, entity.FilterItem{Operator: entity.FilterOperatorBetween, Value: value})
+		if !errors.Is(err, entity.ErrorBadRequest) {
+			t.Errorf("between of %v returned %v, want a bad request", value, err)
+		}
+	}
+}
+
+func TestBuildDateRangeCondition(t *testing.T) {
+	column := `"created_at"`
+	// a wednesday afternoon
+	now := time.Date(2024, time.May, 15, 13, 45, 0, 0, time.UTC)
+	date := func(month time.Month, day int) time.Time {
+		return time.Date(2024, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	between := column + " >= ? AND " + column + " < ?"
+
+	tests := []struct {
+		name     string
+		filter   entity.FilterItem
+		wantSQL  string
+		wantArgs []any
+		wantErr  bool
+	}{
+		{
+			name:     "today",
+			filter:   entity.FilterItem{Operator: entity.FilterOperatorToday},
+			wantSQL:  "(" + between + ")",
+			wantArgs: []any{date(time.May, 15), date(time.May, 16)},
+		},
+		{
+			name:     "before today",
+			filter:   entity.FilterItem{Operator: entity.FilterOperatorBeforeToday},
+			wantSQL:  column + " < ?",
+			wantArgs: []any{date(time.May, 15)},
+		},
+		{
+			name:     "after today",
+			filter:   entity.FilterItem{Operator: entity.FilterOperatorAfterToday},
+			wantSQL:  column + " >= ?",
+			wantArgs: []any{date(time.May, 16)},
+		},
+		{
+			name:     "last days include today",
+			filter:   entity.FilterItem{Operator: entity.FilterOperatorLastNDays, Value: float64(7)},
+			wantSQL:  "(" + between + ")",
+			wantArgs: []any{date(time.May, 8), date(time.May, 16)},
+		},
+		{
+			name:     "next days start tomorrow",
+			filter:   entity.FilterItem{Operator: entity.FilterOperatorNextNDays, Value: " 3 "},
+			wantSQL:  "(" + between + ")",
+			wantArgs: []any{date(time.May, 16), date(time.May, 19)},
+		},
+		{
+			name:     "this week starts on monday",
+			filter:   entity.FilterItem{Operator: entity.FilterOperatorThisWeek},
+			wantSQL:  "(" + between + ")",
+			wantArgs: []any{date(time.May, 13), date(time.May, 20)},
+		},
+		{
+			name:     "this month",
+			filter:   entity.FilterItem{Operator: entity.FilterOperatorThisMonth},
+			wantSQL:  "(" + between + ")",
+			wantArgs: []any{date(time.May, 1), date(time.June, 1)},
+		},
+		{
+			name:     "this year",
+			filter:   entity.FilterItem{Operator: entity.FilterOperatorThisYear},
+			wantSQL:  "(" + between + ")",
+			wantArgs: []any{date(time.January, 1), time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			name:    "days not a number",
+			filter:  entity.FilterItem{Operator: entity.FilterOperatorLastNDays, Value: "week"},
+			wantErr: true,
+		},
+		{
+			name:    "negative days",
+			filter:  entity.FilterItem{Operator: entity.FilterOperatorNextNDays, Value: -1},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := buildDateRangeCondition(column, tt.filter, now)
+			if tt.wantErr {
+				if !errors.Is(err, entity.ErrorBadRequest) {
+					t.Errorf("buildDateRangeCondition() error = %v, want a bad request", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assertExpr(t, expr, tt.wantSQL, tt.wantArgs)
+		})
+	}
+}
+
+func TestBuildFilterConditionInDialects(t *testing.T) {
+	filter := entity.FilterItem{Operator: entity.FilterOperatorContains, Value: "ann"}
+
+	tests := []struct {
+		dialect querybuilder.Dialect
+		column  string
+		wantSQL string
+	}{
+		{dialect: querybuilder.Postgres, column: `"acme"."contact"."name"`, wantSQL: `"acme"."contact"."name" ILIKE ? ESCAPE '\'`},
+		{dialect: querybuilder.MySQL, column: "`contact`.`name`", wantSQL: "`contact`.`name` LIKE ? ESCAPE '\\\\'"},
+		{dialect: querybuilder.SQLite, column: `"contact"."name"`, wantSQL: `"contact"."name" LIKE ? ESCAPE '\'`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dialect.Name(), func(t *testing.T) {
+			expr, err := buildFilterCondition(tt.dialect, tt.column, filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assertExpr(t, expr, tt.wantSQL, []any{"%ann%"})
+		})
+	}
+}
+
+func TestBuildFilterConditionMatchesWildcardsLiterally(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "like.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.Exec("CREATE TABLE contact (name TEXT)").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"50% off", "500 off", "a_b", "axb", `c:\d`} {
+		if err := db.Exec("INSERT INTO contact (name) VALUES (?)", name).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter entity.FilterItem
+		want   []string
+	}{
+		{name: "contains percent", filter: entity.FilterItem{Operator: entity.FilterOperatorContains, Value: "0%"}, want: []string{"50% off"}},
+		{name: "contains underscore", filter: entity.FilterItem{Operator: entity.FilterOperatorContains, Value: "_"}, want: []string{"a_b"}},
+		{name: "not contains underscore", filter: entity.FilterItem{Operator: entity.FilterOperatorNotContains, Value: "_"}, want: []string{"50% off", "500 off", "axb", `c:\d`}},
+		{name: "starts with", filter: entity.FilterItem{Operator: entity.FilterOperatorStartsWith, Value: "a_"}, want: []string{"a_b"}},
+		{name: "ends with", filter: entity.FilterItem{Operator: entity.FilterOperatorEndsWith, Value: "% off"}, want: []string{"50% off"}},
+		{name: "backslash", filter: entity.FilterItem{Operator: entity.FilterOperatorContains, Value: `:\`}, want: []string{`c:\d`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			condition, err := buildFilterCondition(querybuilder.SQLite, `"name"`, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var names []string
+			if err := db.Raw("SELECT name FROM contact WHERE "+condition.SQL+" ORDER BY name", condition.Args...).Scan(&names).Error; err != nil {
+				t.Fatal(err)
+			}
+
+			if fmt.Sprint(names) != fmt.Sprint(tt.want) {
+				t.Errorf("matched %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildFilterConditionOfUnknownOperator(t *testing.T) {
+	if _, err := buildFilterCondition(querybuilder.Postgres, `"name"`, entity.FilterItem{Operator: "drop table"}); err == nil {
+		t.Error("buildFilterCondition() of an unknown operator returned no error")
+	}
+}
+
+func TestBuildFilterGroup(t *testing.T) {
+	tableColumns := querybuilder.NewColumns([]map[string]any{
+		{entity.FieldColumnCode: "name"},
+		{entity.FieldColumnCode: "age"},
+		{entity.FieldColumnCode: "status"},
+	})
+	chainedColumns := map[string]string{"owner_id__name": `"owner_id"."name"`}
+
+	name, age, status := `"acme"."contact"."name"`, `"acme"."contact"."age"`, `"acme"."contact"."status"`
+	condition := func(fieldName string, operator entity.FilterOperator, value any) entity.FilterItem {
+		return entity.FilterItem{FieldName: fieldName, Operator: operator, Value: value}
+	}
+	or := entity.NewFilterGroupOperator(entity.FilterOperatorOr)
+	not := entity.NewFilterGroupOperator(entity.FilterOperatorNot)
+
+	tests := []struct {
+		name     string
+		group    entity.FilterGroup
+		wantSQL  string
+		wantArgs []any
+		wantErr  bool
+	}{
+		{
+			name: "repeated field",
+			group: entity.FilterGroup{Conditions: []entity.FilterItem{
+				condition("age", entity.FilterOperatorGreaterThan, 18),
+				condition("age", entity.FilterOperatorLessThan, 65),
+			}},
+			wantSQL:  "(" + age + " > ? AND " + age + " < ?)",
+			wantArgs: []any{18, 65},
+		},
+		{
+			name: "or",
+			group: entity.FilterGroup{Operator: or, Conditions: []entity.FilterItem{
+				condition("name", entity.FilterOperatorEqual, "a"),
+				condition("name", entity.FilterOperatorEqual, "b"),
+			}},
+			wantSQL:  "(" + name + " = ? OR " + name + " = ?)",
+			wantArgs: []any{"a", "b"},
+		},
+		{
+			name: "or given as a lower case filter operator",
+			group: entity.FilterGroup{Operator: entity.NewFilterOperator("or"), Conditions: []entity.FilterItem{
+				condition("name", entity.FilterOperatorEqual, "a"),
+				condition("status", entity.FilterOperatorIsNull, nil),
+			}},
+			wantSQL:  "(" + name + " = ? OR " + status + " IS NULL)",
+			wantArgs: []any{"a"},
+		},
+		{
+			name: "filters before conditions before groups",
+			group: entity.FilterGroup{
+				Filters:    map[string]entity.FilterItem{"status": {Operator: entity.FilterOperatorEqual, Value: "open"}},
+				Conditions: []entity.FilterItem{condition("name", entity.FilterOperatorEqual, "a")},
+				Groups: []entity.FilterGroup{{Operator: or, Conditions: []entity.FilterItem{
+					condition("age", entity.FilterOperatorLessThan, 18),
+					condition("age", entity.FilterOperatorGreaterThan, 65),
+				}}},
+			},
+			wantSQL:  "(" + status + " = ? AND " + name + " = ? AND (" + age + " < ? OR " + age + " > ?))",
+			wantArgs: []any{"open", "a", 18, 65},
+		},
+		{
+			name: "not",
+			group: entity.FilterGroup{Operator: not, Conditions: []entity.FilterItem{
+				condition("status", entity.FilterOperatorEqual, "closed"),
+				condition("age", entity.FilterOperatorIsNull, nil),
+			}},
+			wantSQL:  "NOT (" + status + " = ? AND " + age + " IS NULL)",
+			wantArgs: []any{"closed"},
+		},
+		{
+			name: "not nested in or",
+			group: entity.FilterGroup{
+				Operator:   or,
+				Conditions: []entity.FilterItem{condition("name", entity.FilterOperatorEqual, "a")},
+				Groups: []entity.FilterGroup{{Operator: not, Conditions: []entity.FilterItem{
+					condition("status", entity.FilterOperatorEqual, "closed"),
+				}}},
+			},
+			wantSQL:  "(" + name + " = ? OR NOT (" + status + " = ?))",
+			wantArgs: []any{"a", "closed"},
+		},
+		{
+			name: "empty groups match everything",
+			group: entity.FilterGroup{
+				Conditions: []entity.FilterItem{condition("name", entity.FilterOperatorEqual, "a")},
+				Groups:     []entity.FilterGroup{{}, {Operator: not}, {Operator: or, Groups: []entity.FilterGroup{{}}}},
+			},
+			wantSQL:  "(" + name + " = ?)",
+			wantArgs: []any{"a"},
+		},
+		{
+			name:  "empty not",
+			group: entity.FilterGroup{Operator: not},
+		},
+		{
+			name: "relationship field",
+			group: entity.FilterGroup{Groups: []entity.FilterGroup{{Conditions: []entity.FilterItem{
+				condition("owner_id__name", entity.FilterOperatorEqual, "ann"),
+			}}}},
+			wantSQL:  `(("owner_id"."name" = ?))`,
+			wantArgs: []any{"ann"},
+		},
+		{
+			name: "unknown field in a nested group",
+			group: entity.FilterGroup{Groups: []entity.FilterGroup{{Conditions: []entity.FilterItem{
+				condition("name; DROP TABLE contact", entity.FilterOperatorEqual, "a"),
+			}}}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := buildFilterGroup(querybuilder.Postgres, querybuilder.Postgres.TableName("acme", "contact"), tt.group, tableColumns, chainedColumns)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("buildFilterGroup() error = %v, want error %v", err, tt.wantErr)
+			}
+
+			assertExpr(t, expr, tt.wantSQL, tt.wantArgs)
+		})
+	}
+}
+
+func assertExpr(t *testing.T, expr querybuilder.Expr, wantSQL string, wantArgs []any) {
+	t.Helper()
+
+	if expr.SQL != wantSQL {
+		t.Errorf("SQL = %q, want %q", expr.SQL, wantSQL)
+	}
+
+	if len(expr.Args) != len(wantArgs) || fmt.Sprint(expr.Args) != fmt.Sprint(wantArgs) {
+		t.Errorf("args = %v, want %v", expr.Args, wantArgs)
+	}
+}
@@ -0,0 +1,541 @@
+package catalogrepository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+	repository_intf "github.com/fetchlydev/source/fetchly-backend/core/repository"
+	"github.com/fetchlydev/source/fetchly-backend/pkg/conn"
+	"github.com/fetchlydev/source/fetchly-backend/pkg/helper"
+	"gorm.io/gorm"
+)
+
+// schemaStatements collects the DDL of the schema changes of a metadata transaction
+type schemaStatements struct {
+	statements []entity.SchemaStatement
+	objects    map[string][2]string
+}
+
+// viewContentRow is a view content with the codes of what it references
+type viewContentRow struct {
+	ViewContent    `gorm:"embedded"`
+	ProductCode    sql.NullString `gorm:"column:product_code"`
+	ObjectCode     sql.NullString `gorm:"column:object_code"`
+	ViewLayoutCode sql.NullString `gorm:"column:view_layout_code"`
+	ViewSchemaCode sql.NullString `gorm:"column:view_schema_code"`
+}
+
+// viewSchemaRow is a view schema with the code of its object
+type viewSchemaRow struct {
+	ViewSchema `gorm:"embedded"`
+	ObjectCode string `gorm:"column:object_code"`
+}
+
+// WithMetadataTransaction runs fn with a repository bound to a transaction on the metadata database, the schema
+// changes made through it are part of the transaction. The DDL of the objects with a data source can not join the
+// transaction, the changes of their tables are refused.
+func (r *repository) WithMetadataTransaction(ctx context.Context, fn func(txRepo repository_intf.CatalogRepository) error) error {
+	statements := &schemaStatements{objects: map[string][2]string{}}
+
+	// the caches may hold what the transaction saw, rolled back or not
+	defer func() {
+		for _, object := range statements.objects {
+			r.forgetObject(object[0], object[1])
+		}
+	}()
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		txRepo := &repository{
+			cfg:              r.cfg,
+			db:               tx,
+			dataSources:      r.dataSources,
+			searchIndexes:    r.searchIndexes,
+			displayTemplates: r.displayTemplates,
+			statements:       statements,
+		}
+
+		return fn(txRepo)
+	})
+}
+
+// SchemaStatements returns the DDL of the schema changes made through a repository of WithMetadataTransaction
+func (r *repository) SchemaStatements() []entity.SchemaStatement {
+	if r.statements == nil {
+		return nil
+	}
+
+	return r.statements.statements
+}
+
+// execSchema runs a DDL statement of an object. Within WithMetadataTransaction the statement is recorded, the
+// statement of an object with a data source is refused since a failed metadata commit could not undo it.
+func (r *repository) execSchema(tx, db *gorm.DB, dataSource *conn.DataSourceConfig, objectCode, statement string) error {
+	if r.statements == nil {
+		return schemaDB(tx, db, dataSource).Exec(statement).Error
+	}
+
+	if dataSource != nil {
+		return fmt.Errorf("%w: the table of object %v is in data source %v, its changes can not be rolled back with the metadata and are not made by a migration", entity.ErrorBadRequest, objectCode, dataSource.Serial)
+	}
+
+	r.statements.statements = append(r.statements.statements, entity.SchemaStatement{ObjectCode: objectCode, SQL: statement})
+	return tx.Exec(statement).Error
+}
+
+// GetObjectCodes lists the codes of the objects of a tenant, of a module when moduleCode is not empty
+func (r *repository) GetObjectCodes(ctx context.Context, tenantCode, moduleCode string) (resp []string, err error) {
+	db := r.db.WithContext(ctx).Model(&Objects{}).
+		Joins("JOIN tenants ON tenants.serial = objects.tenant_serial").
+		Where("tenants.code = ?", tenantCode)
+
+	if moduleCode != "" {
+		db = db.Joins("JOIN modules ON modules.serial = objects.module_serial").Where("modules.code = ?", moduleCode)
+	}
+
+	if err := db.Order("objects.id").Pluck("objects.code", &resp).Error; err != nil {
+		return resp, fmt.Errorf("failed to get objects of tenant %v: %w", tenantCode, err)
+	}
+
+	return resp, nil
+}
+
+// GetModuleByCode returns a module, ErrorNotFound when there is none
+func (r *repository) GetModuleByCode(ctx context.Context, moduleCode string) (resp entity.Modules, err error) {
+	module := Modules{}
+	if err := r.db.WithContext(ctx).Where("code = ?", moduleCode).Limit(1).Find(&module).Error; err != nil {
+		return resp, fmt.Errorf("failed to get module %v: %w", moduleCode, err)
+	}
+
+	if module.Serial == "" {
+		return resp, fmt.Errorf("%w: module %v is not found", entity.ErrorNotFound, moduleCode)
+	}
+
+	return entity.Modules{
+		ID:                 module.ID,
+		Serial:             module.Serial,
+		Code:               module.Code,
+		Name:               module.Name,
+		ParentModuleSerial: module.ParentModuleSerial,
+		Version:            module.Version,
+	}, nil
+}
+
+// SetModuleVersion records the version of the bundle a module is migrated to
+func (r *repository) SetModuleVersion(ctx context.Context, moduleCode, version, userSerial string) (err error) {
+	err = r.db.WithContext(ctx).Model(&Modules{}).Where("code = ?", moduleCode).Updates(map[string]any{
+		"version":    version,
+		"updated_at": time.Now(),
+		"updated_by": userSerial,
+	}).Error
+	if err != nil {
+		return fmt.Errorf("failed to set the version of module %v: %w", moduleCode, err)
+	}
+
+	return nil
+}
+
+// GetViewMetadata returns the view contents of a tenant with their navigation, along with the view schemas of its
+// objects and the view layouts the view contents use. objectCodes narrows the view contents and view schemas down
+// to the ones of the objects, nil keeps them all.
+func (r *repository) GetViewMetadata(ctx context.Context, tenantCode string, objectCodes []string) (resp entity.ViewMetadata, err error) {
+	db := r.db.WithContext(ctx)
+
+	tenant, err := viewTenant(db, tenantCode)
+	if err != nil {
+		return resp, err
+	}
+
+	contentRows := []viewContentRow{}
+	contentQuery := db.Model(&ViewContent{}).
+		Select("view_content.*, products.code AS product_code, objects.code AS object_code, view_layout.code AS view_layout_code, view_schema.code AS view_schema_code").
+		Joins("LEFT JOIN products ON products.serial = view_content.product_serial").
+		Joins("LEFT JOIN objects ON objects.serial = view_content.object_serial").
+		Joins("LEFT JOIN view_layout ON view_layout.serial = view_content.view_layout_serial").
+		Joins("LEFT JOIN view_schema ON view_schema.serial = view_content.view_schema_serial").
+		Where("view_content.tenant_serial = ?", tenant.Serial)
+
+	if objectCodes != nil {
+		contentQuery = contentQuery.Where("objects.code IN ?", objectCodes)
+	}
+
+	if err := contentQuery.Order("view_content.id").Scan(&contentRows).Error; err != nil {
+		return resp, fmt.Errorf("failed to get view contents of tenant %v: %w", tenantCode, err)
+	}
+
+	contentSerials := make([]string, 0, len(contentRows))
+	layoutSerials := []string{}
+	for _, row := range contentRows {
+		contentSerials = append(contentSerials, row.Serial)
+		if row.ViewLayoutSerial.Valid {
+			layoutSerials = append(layoutSerials, row.ViewLayoutSerial.String)
+		}
+	}
+
+	navigation := []Navigation{}
+	if len(contentSerials) > 0 {
+		err := db.Where("view_content_serial IN ?", contentSerials).Order("navigation_order, id").Find(&navigation).Error
+		if err != nil {
+			return resp, fmt.Errorf("failed to get navigation of tenant %v: %w", tenantCode, err)
+		}
+	}
+
+	navigationByContent := map[string][]entity.BundleNavigation{}
+	for _, item := range navigation {
+		navigationByContent[item.ViewContentSerial] = append(navigationByContent[item.ViewContentSerial], entity.BundleNavigation{
+			Code:             item.Code,
+			Title:            item.Title,
+			Description:      item.Description,
+			URL:              item.URL,
+			NavigationLevel:  item.NavigationLevel,
+			Path:             item.Path,
+			NavigationOrder:  item.NavigationOrder,
+			NavigationConfig: item.NavigationConfig,
+		})
+	}
+
+	for _, row := range contentRows {
+		resp.ViewContents = append(resp.ViewContents, entity.BundleViewContent{
+			ProductCode:       row.ProductCode.String,
+			ObjectCode:        row.ObjectCode.String,
+			Code:              row.Code,
+			LayoutType:        row.LayoutType,
+			Name:              row.Name,
+			ViewLayoutCode:    row.ViewLayoutCode.String,
+			ViewSchemaCode:    row.ViewSchemaCode.String,
+			IsDefault:         row.IsDefault,
+			IsShownInList:     row.IsShownInList,
+			ViewContentConfig: row.ViewContentConfig,
+			Navigation:        navigationByContent[row.Serial],
+		})
+	}
+
+	schemaRows := []viewSchemaRow{}
+	schemaQuery := db.Model(&ViewSchema{}).
+		Select("view_schema.*, objects.code AS object_code").
+		Joins("JOIN objects ON objects.serial = view_schema.object_serial AND objects.deleted_at IS NULL").
+		Where("objects.tenant_serial = ?", tenant.Serial)
+
+	if objectCodes != nil {
+		schemaQuery = schemaQuery.Where("objects.code IN ?", objectCodes)
+	}
+
+	if err := schemaQuery.Order("view_schema.id").Scan(&schemaRows).Error; err != nil {
+		return resp, fmt.Errorf("failed to get view schemas of tenant %v: %w", tenantCode, err)
+	}
+
+	for _, row := range schemaRows {
+		resp.ViewSchemas = append(resp.ViewSchemas, entity.BundleViewSchema{
+			ObjectCode:    row.ObjectCode,
+			Code:          row.Code,
+			Name:          row.Name,
+			Query:         row.Query,
+			DisplayField:  row.DisplayField,
+			StructureType: row.StructureType,
+			IsFavorite:    row.IsFavorite,
+			FieldSections: row.FieldSections,
+			Orders:        row.Orders,
+		})
+	}
+
+	layouts := []ViewLayout{}
+	if len(layoutSerials) > 0 {
+		if err := db.Where("serial IN ?", layoutSerials).Order("id").Find(&layouts).Error; err != nil {
+			return resp, fmt.Errorf("failed to get view layouts of tenant %v: %w", tenantCode, err)
+		}
+	}
+
+	for _, layout := range layouts {
+		resp.ViewLayouts = append(resp.ViewLayouts, entity.BundleViewLayout{
+			Code:         layout.Code,
+			LayoutConfig: layout.LayoutConfig,
+		})
+	}
+
+	return resp, nil
+}
+
+// ApplyViewMetadataStep creates, alters or drops a view layout, view schema or view content of a tenant. A view
+// content takes its navigation along, the navigation items it no longer holds are dropped.
+func (r *repository) ApplyViewMetadataStep(ctx context.Context, tenantCode string, step entity.MigrationStep, userSerial string) (err error) {
+	db := r.db.WithContext(ctx)
+	now := time.Now()
+	deleted := map[string]any{"deleted_at": now, "deleted_by": userSerial}
+
+	tenant, err := viewTenant(db, tenantCode)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case step.Target == entity.MigrationTargetViewLayout && step.ViewLayout != nil:
+		if step.Action == entity.MigrationActionDrop {
+			return fmt.Errorf("%w: view layout %v is shared by the tenants and is not dropped", entity.ErrorBadRequest, step.Code)
+		}
+
+		layout := ViewLayout{}
+		if err := db.Where("code = ?", step.ViewLayout.Code).Limit(1).Find(&layout).Error; err != nil {
+			return fmt.Errorf("failed to get view layout %v: %w", step.Code, err)
+		}
+
+		values := ViewLayout{
+			Serial:       layout.Serial,
+			CreatedBy:    userSerial,
+			UpdatedBy:    userSerial,
+			UpdatedAt:    now,
+			Code:         step.ViewLayout.Code,
+			LayoutConfig: step.ViewLayout.LayoutConfig,
+		}
+
+		if err := saveViewMetadata(db, &values, &values.Serial, "layout_config"); err != nil {
+			return fmt.Errorf("failed to save view layout %v: %w", step.Code, err)
+		}
+
+		return nil
+
+	case step.Target == entity.MigrationTargetViewSchema && step.ViewSchema != nil:
+		objectSerial, err := viewObjectSerial(db, tenant, step.ViewSchema.ObjectCode)
+		if err != nil {
+			return err
+		}
+
+		schema := ViewSchema{}
+		if err := db.Where("object_serial = ? AND code = ?", objectSerial, step.ViewSchema.Code).Limit(1).Find(&schema).Error; err != nil {
+			return fmt.Errorf("failed to get view schema %v: %w", step.Code, err)
+		}
+
+		if step.Action == entity.MigrationActionDrop {
+			if schema.Serial == "" {
+				return nil
+			}
+
+			if err := db.Model(&ViewSchema{}).Where("serial = ?", schema.Serial).Updates(deleted).Error; err != nil {
+				return fmt.Errorf("failed to drop view schema %v: %w", step.Code, err)
+			}
+
+			return nil
+		}
+
+		values := ViewSchema{
+			Serial:        schema.Serial,
+			CreatedBy:     userSerial,
+			UpdatedBy:     userSerial,
+			UpdatedAt:     now,
+			Code:          step.ViewSchema.Code,
+			Name:          step.ViewSchema.Name,
+			Query:         step.ViewSchema.Query,
+			DisplayField:  step.ViewSchema.DisplayField,
+			StructureType: step.ViewSchema.StructureType,
+			ActionSerial:  schema.ActionSerial,
+			IsFavorite:    step.ViewSchema.IsFavorite,
+			ObjectSerial:  objectSerial,
+			FieldSections: step.ViewSchema.FieldSections,
+			Orders:        step.ViewSchema.Orders,
+		}
+
+		err = saveViewMetadata(db, &values, &values.Serial, "name", "query", "display_field", "structure_type", "is_favorite", "field_sections", "orders")
+		if err != nil {
+			return fmt.Errorf("failed to save view schema %v: %w", step.Code, err)
+		}
+
+		return nil
+
+	case step.Target == entity.MigrationTargetViewContent && step.ViewContent != nil:
+		return applyViewContent(db, tenant, step, userSerial, now)
+	}
+
+	return fmt.Errorf("%w: step %v of %v %v is not a view metadata step", entity.ErrorBadRequest, step.Action, step.Target, step.Code)
+}
+
+// applyViewContent creates, alters or drops a view content with its navigation
+func applyViewContent(db *gorm.DB, tenant Tenants, step entity.MigrationStep, userSerial string, now time.Time) error {
+	request := step.ViewContent
+	deleted := map[string]any{"deleted_at": now, "deleted_by": userSerial}
+
+	var productSerial, objectSerial, layoutSerial, schemaSerial string
+	if request.ProductCode != "" {
+		product := Products{}
+		if err := db.Where("code = ?", request.ProductCode).Limit(1).Find(&product).Error; err != nil {
+			return fmt.Errorf("failed to get product %v: %w", request.ProductCode, err)
+		}
+
+		if product.Serial == "" {
+			return fmt.Errorf("%w: product %v of view content %v is not found", entity.ErrorBadRequest, request.ProductCode, step.Code)
+		}
+
+		productSerial = product.Serial
+	}
+
+	if request.ObjectCode != "" {
+		serial, err := viewObjectSerial(db, tenant, request.ObjectCode)
+		if err != nil {
+			return err
+		}
+
+		objectSerial = serial
+	}
+
+	content := ViewContent{}
+	err := db.Where("tenant_serial = ? AND code = ? AND layout_type = ?", tenant.Serial, request.Code, request.LayoutType).
+		Where("COALESCE(product_serial, '') = ? AND COALESCE(object_serial, '') = ?", productSerial, objectSerial).
+		Limit(1).Find(&content).Error
+	if err != nil {
+		return fmt.Errorf("failed to get view content %v: %w", step.Code, err)
+	}
+
+	if step.Action == entity.MigrationActionDrop {
+		if content.Serial == "" {
+			return nil
+		}
+
+		if err := db.Model(&Navigation{}).Where("view_content_serial = ?", content.Serial).Updates(deleted).Error; err != nil {
+			return fmt.Errorf("failed to drop navigation of view content %v: %w", step.Code, err)
+		}
+
+		if err := db.Model(&ViewContent{}).Where("serial = ?", content.Serial).Updates(deleted).Error; err != nil {
+			return fmt.Errorf("failed to drop view content %v: %w", step.Code, err)
+		}
+
+		return nil
+	}
+
+	if request.ViewLayoutCode != "" {
+		layout := ViewLayout{}
+		if err := db.Where("code = ?", request.ViewLayoutCode).Limit(1).Find(&layout).Error; err != nil {
+			return fmt.Errorf("failed to get view layout %v: %w", request.ViewLayoutCode, err)
+		}
+
+		if layout.Serial == "" {
+			return fmt.Errorf("%w: view layout %v of view content %v is not found", entity.ErrorBadRequest, request.ViewLayoutCode, step.Code)
+		}
+
+		layoutSerial = layout.Serial
+	}
+
+	if request.ViewSchemaCode != "" {
+		schema := ViewSchema{}
+		if err := db.Where("object_serial = ? AND code = ?", objectSerial, request.ViewSchemaCode).Limit(1).Find(&schema).Error; err != nil {
+			return fmt.Errorf("failed to get view schema %v: %w", request.ViewSchemaCode, err)
+		}
+
+		if schema.Serial == "" {
+			return fmt.Errorf("%w: view schema %v of view content %v is not found", entity.ErrorBadRequest, request.ViewSchemaCode, step.Code)
+		}
+
+		schemaSerial = schema.Serial
+	}
+
+	values := ViewContent{
+		Serial:            content.Serial,
+		CreatedBy:         userSerial,
+		UpdatedBy:         userSerial,
+		UpdatedAt:         now,
+		Code:              request.Code,
+		Name:              request.Name,
+		TenantSerial:      tenant.Serial,
+		ProductSerial:     nullSerial(productSerial),
+		ObjectSerial:      nullSerial(objectSerial),
+		OwnerSerial:       content.OwnerSerial,
+		ViewLayoutSerial:  nullSerial(layoutSerial),
+		ViewSchemaSerial:  nullSerial(schemaSerial),
+		LayoutType:        request.LayoutType,
+		IsDefault:         request.IsDefault,
+		IsShownInList:     request.IsShownInList,
+		ViewContentConfig: request.ViewContentConfig,
+	}
+
+	err = saveViewMetadata(db, &values, &values.Serial, "name", "view_layout_serial", "view_schema_serial", "is_default", "is_shown_in_list", "view_content_config")
+	if err != nil {
+		return fmt.Errorf("failed to save view content %v: %w", step.Code, err)
+	}
+
+	existing := []Navigation{}
+	if err := db.Where("view_content_serial = ?", values.Serial).Find(&existing).Error; err != nil {
+		return fmt.Errorf("failed to get navigation of view content %v: %w", step.Code, err)
+	}
+
+	navigationSerials := make(map[string]string, len(existing))
+	for _, item := range existing {
+		navigationSerials[item.Code] = item.Serial
+	}
+
+	for _, item := range request.Navigation {
+		serial := navigationSerials[item.Code]
+		delete(navigationSerials, item.Code)
+
+		navigation := Navigation{
+			Serial:            serial,
+			CreatedBy:         userSerial,
+			UpdatedBy:         userSerial,
+			UpdatedAt:         now,
+			ViewContentSerial: values.Serial,
+			Code:              item.Code,
+			Title:             item.Title,
+			Description:       item.Description,
+			URL:               item.URL,
+			NavigationLevel:   item.NavigationLevel,
+			Path:              item.Path,
+			NavigationOrder:   item.NavigationOrder,
+			NavigationConfig:  item.NavigationConfig,
+		}
+
+		err := saveViewMetadata(db, &navigation, &navigation.Serial, "title", "description", "url", "navigation_level", "path", "navigation_order", "navigation_config")
+		if err != nil {
+			return fmt.Errorf("failed to save navigation %v: %w", item.Code, err)
+		}
+	}
+
+	for code, serial := range navigationSerials {
+		if err := db.Model(&Navigation{}).Where("serial = ?", serial).Updates(deleted).Error; err != nil {
+			return fmt.Errorf("failed to drop navigation %v: %w", code, err)
+		}
+	}
+
+	return nil
+}
+
+// viewTenant returns the tenant the view metadata belongs to
+func viewTenant(db *gorm.DB, tenantCode string) (Tenants, error) {
+	tenant := Tenants{}
+	if err := db.Where("code = ?", tenantCode).Limit(1).Find(&tenant).Error; err != nil {
+		return tenant, fmt.Errorf("failed to get tenant %v: %w", tenantCode, err)
+	}
+
+	if tenant.Serial == "" {
+		return tenant, fmt.Errorf("%w: tenant %v is not found", entity.ErrorNotFound, tenantCode)
+	}
+
+	return tenant, nil
+}
+
+// viewObjectSerial returns the serial of an object of the tenant a view metadata belongs to
+func viewObjectSerial(db *gorm.DB, tenant Tenants, objectCode string) (string, error) {
+	object := Objects{}
+	if err := db.Where("tenant_serial = ? AND code = ?", tenant.Serial, objectCode).Limit(1).Find(&object).Error; err != nil {
+		return "", fmt.Errorf("failed to get object %v: %w", objectCode, err)
+	}
+
+	if object.Serial == "" {
+		return "", fmt.Errorf("%w: object %v is not found in tenant %v", entity.ErrorBadRequest, objectCode, tenant.Code)
+	}
+
+	return object.Serial, nil
+}
+
+// saveViewMetadata updates the columns of a view metadata row found by its serial, the audit columns along, or
+// creates the row under a new serial when the serial is empty
+func saveViewMetadata(db *gorm.DB, values any, serial *string, columns ...string) error {
+	if *serial != "" {
+		return db.Model(values).Where("serial = ?", *serial).Select(append(columns, "updated_at", "updated_by")).Updates(values).Error
+	}
+
+	generated, err := helper.GenerateUUUID()
+	if err != nil {
+		return err
+	}
+
+	*serial = generated
+	return db.Omit("id").Create(values).Error
+}
@@ -0,0 +1,44 @@
+package catalogrepository
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+	"github.com/fetchlydev/source/fetchly-backend/pkg/conn"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestExecSchemaInMetadataTransaction(t *testing.T) {
+	tests := []struct {
+		name       string
+		dataSource *conn.DataSourceConfig
+		wantErr    error
+		want       int
+	}{
+		{name: "tenant schema", want: 1},
+		{name: "data source", dataSource: &conn.DataSourceConfig{Serial: "warehouse"}, wantErr: entity.ErrorBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "metadata.db")), &gorm.Config{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r := &repository{db: db, statements: &schemaStatements{objects: map[string][2]string{}}}
+
+			err = r.execSchema(db, db, tt.dataSource, "contact", "CREATE TABLE contact (id INTEGER)")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("execSchema() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if got := len(r.SchemaStatements()); got != tt.want {
+				t.Errorf("got %d statements, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,265 @@
+package catalogrepository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+	"github.com/fetchlydev/source/fetchly-backend/pkg/helper"
+	querybuilder "github.com/fetchlydev/source/fetchly-backend/repository/query_builder"
+	"github.com/fetchlydev/source/fetchly-backend/repository/util"
+)
+
+const (
+	relationJunctionAlias = "__relation_junction"
+	relationPageAlias     = "__relation_page"
+	relationParentColumn  = "__relation_parent"
+	relationRowColumn     = "__relation_row"
+	relationTotalColumn   = "__relation_total"
+)
+
+// objectRelationRow is a to many relation field with the codes of the object and field it targets
+type objectRelationRow struct {
+	FieldCode        string `gorm:"column:field_code"`
+	DisplayName      string `gorm:"column:display_name"`
+	Relation         string `gorm:"column:relation"`
+	FieldReference   string `gorm:"column:field_reference"`
+	TargetObjectCode string `gorm:"column:target_object_code"`
+	TargetFieldCode  string `gorm:"column:target_field_code"`
+}
+
+// GetObjectRelations returns the one to many and many to many relation fields of the object by field code, the
+// keys they join on are read from the foreign keys of the child and junction tables
+func (r *repository) GetObjectRelations(ctx context.Context, tenantCode, objectCode string) (resp map[string]entity.ObjectRelation, err error) {
+	rows := []objectRelationRow{}
+	err = r.db.WithContext(ctx).Table("object_fields").
+		Select("object_fields.field_code, object_fields.display_name, object_fields.relation, object_fields.field_reference, target_objects.code AS target_object_code, target_fields.field_code AS target_field_code").
+		Joins("JOIN objects ON objects.serial = object_fields.object_serial AND objects.deleted_at IS NULL").
+		Joins("JOIN tenants ON tenants.serial = objects.tenant_serial").
+		Joins("JOIN objects AS target_objects ON target_objects.serial = object_fields.target_object_serial AND target_objects.deleted_at IS NULL").
+		Joins("JOIN object_fields AS target_fields ON target_fields.serial = object_fields.target_object_field_serial AND target_fields.deleted_at IS NULL").
+		Where("tenants.code = ?", tenantCode).
+		Where("objects.code = ?", objectCode).
+		Where("object_fields.relation IN ?", []string{entity.RelationOneToMany, entity.RelationManyToMany}).
+		Where("object_fields.deleted_at IS NULL").
+		Scan(&rows).Error
+	if err != nil {
+		return resp, fmt.Errorf("failed to get relations of object %v: %w", objectCode, err)
+	}
+
+	resp = make(map[string]entity.ObjectRelation, len(rows))
+	for _, row := range rows {
+		relation := entity.ObjectRelation{
+			FieldCode: row.FieldCode,
+			FieldName: row.DisplayName,
+			Relation:  row.Relation,
+		}
+
+		// the foreign key of the child or junction table references the object
+		parentKey, err := r.GetForeignKeyInfo(ctx, row.TargetObjectCode, row.TargetFieldCode, tenantCode)
+		if err != nil {
+			return resp, err
+		}
+
+		relation.ParentKey = foreignColumnOrIdentifier(parentKey)
+
+		if row.Relation == entity.RelationOneToMany {
+			relation.TargetObject = row.TargetObjectCode
+			relation.TargetField = row.TargetFieldCode
+
+			resp[row.FieldCode] = relation
+			continue
+		}
+
+		if row.FieldReference == "" {
+			return resp, fmt.Errorf("many to many relation %v of object %v has no field reference", row.FieldCode, objectCode)
+		}
+
+		targetKey, err := r.GetForeignKeyInfo(ctx, row.TargetObjectCode, row.FieldReference, tenantCode)
+		if err != nil {
+			return resp, err
+		}
+
+		if targetKey.ForeignTable == "" {
+			return resp, fmt.Errorf("field %v is not a relation of table %v", row.FieldReference, row.TargetObjectCode)
+		}
+
+		relation.TargetObject = targetKey.ForeignTable
+		relation.TargetKey = foreignColumnOrIdentifier(targetKey)
+		relation.JunctionObject = row.TargetObjectCode
+		relation.JunctionParentField = row.TargetFieldCode
+		relation.JunctionTargetField = row.FieldReference
+
+		resp[row.FieldCode] = relation
+	}
+
+	return resp, nil
+}
+
+// foreignColumnOrIdentifier is the column a foreign key references, the serial when the table declares no constraint
+func foreignColumnOrIdentifier(foreignKey entity.ForeignKeyInfo) string {
+	if foreignKey.ForeignColumn == "" {
+		return entity.DEFAULT_IDENTIFIER
+	}
+
+	return foreignKey.ForeignColumn
+}
+
+// GetRelatedObjectData reads a page of the records related to every parent key in a single query. The records are
+// numbered per parent with a window function, so a page never multiplies the rows of the parents. The pages are
+// keyed by the parent key as text.
+func (r *repository) GetRelatedObjectData(ctx context.Context, request entity.RelatedQuery) (resp map[string]entity.CatalogResponse, err error) {
+	resp = make(map[string]entity.CatalogResponse)
+	if len(request.ParentKeys) == 0 {
+		return resp, nil
+	}
+
+	query := request.Query
+	relation := request.Relation
+
+	db, dialect, err := r.dataDB(ctx, query.TenantCode, query.ObjectCode)
+	if err != nil {
+		return resp, err
+	}
+
+	tableName := dialect.TableName(query.TenantCode, query.ObjectCode)
+
+	columnsList, _, joinQueryMap, joinQueryOrder, err := r.GetColumnList(ctx, query)
+	if err != nil {
+		return resp, err
+	}
+
+	tableColumns, err := r.getTableColumns(ctx, query.TenantCode, query.ObjectCode)
+	if err != nil {
+		return resp, err
+	}
+
+	// the parent column is the foreign key of the child, or the one of the junction joined to the target
+	var joins []string
+	var parentColumn, parentDataType string
+	if relation.IsManyToMany() {
+		junctionColumns, err := r.getTableColumns(ctx, query.TenantCode, relation.JunctionObject)
+		if err != nil {
+			return resp, err
+		}
+
+		junctionAlias := dialect.QuoteIdentifier(relationJunctionAlias)
+		junctionTargetColumn, err := junctionColumns.ReferenceIn(dialect, relationJunctionAlias, relation.JunctionTargetField)
+		if err != nil {
+			return resp, err
+		}
+
+		parentColumn, err = junctionColumns.ReferenceIn(dialect, relationJunctionAlias, relation.JunctionParentField)
+		if err != nil {
+			return resp, err
+		}
+
+		targetKeyColumn, err := tableColumns.ReferenceIn(dialect, tableName, relation.TargetKey)
+		if err != nil {
+			return resp, err
+		}
+
+		joinClause := fmt.Sprintf("JOIN %v AS %v ON %v = %v", querybuilder.TableIn(dialect, query.TenantCode, relation.JunctionObject), junctionAlias, junctionTargetColumn, targetKeyColumn)
+		if junctionColumns.Has("deleted_at") {
+			joinClause += fmt.Sprintf(" AND %v.deleted_at IS NULL", junctionAlias)
+		}
+
+		joins = append(joins, joinClause)
+		parentDataType = junctionColumns.DataType(relation.JunctionParentField)
+	} else {
+		parentColumn, err = tableColumns.ReferenceIn(dialect, tableName, relation.TargetField)
+		if err != nil {
+			return resp, err
+		}
+
+		parentDataType = tableColumns.DataType(relation.TargetField)
+	}
+
+	orderString := ""
+	if len(query.Orders) > 0 {
+		orderBy, orderJoinMap, orderJoinOrder, err := buildOrderBy(dialect, query, columnsList, tableColumns)
+		if err != nil {
+			return resp, err
+		}
+
+		orderString = " ORDER BY " + orderBy
+		for _, joinKey := range orderJoinOrder {
+			joins = append(joins, orderJoinMap[joinKey])
+		}
+	}
+
+	// every column is aliased by position, the page is selected from a derived table that can not repeat a name
+	selectColumns := make([]string, 0, len(columnsList)+3)
+	for i, column := range columnsList {
+		selectColumns = append(selectColumns, fmt.Sprintf("%v AS %v", columnExpression(dialect, column), dialect.QuoteIdentifier(fmt.Sprintf("__column_%d", i))))
+	}
+
+	selectColumns = append(selectColumns,
+		fmt.Sprintf("%v AS %v", parentColumn, dialect.QuoteIdentifier(relationParentColumn)),
+		fmt.Sprintf("ROW_NUMBER() OVER (PARTITION BY %v%v) AS %v", parentColumn, orderString, dialect.QuoteIdentifier(relationRowColumn)),
+		fmt.Sprintf("COUNT(*) OVER (PARTITION BY %v) AS %v", parentColumn, dialect.QuoteIdentifier(relationTotalColumn)),
+	)
+
+	relatedQuery, err := r.buildSelectQuery(ctx, dialect, strings.Join(selectColumns, ", "), tableName, query, joinQueryMap, joinQueryOrder, tableColumns, joins...)
+	if err != nil {
+		return resp, err
+	}
+
+	parentKeys := make([]any, 0, len(request.ParentKeys))
+	for _, parentKey := range request.ParentKeys {
+		parentKeys = append(parentKeys, querybuilder.BindValue(parentDataType, parentKey))
+	}
+
+	relatedQuery.Write(fmt.Sprintf(" AND %v IN (%v)", parentColumn, querybuilder.Placeholders(len(parentKeys))), parentKeys...)
+
+	offset := (query.Page - 1) * query.PageSize
+	rowColumn := dialect.QuoteIdentifier(relationRowColumn)
+	pageQuery := querybuilder.New(fmt.Sprintf("SELECT * FROM (%v) AS %v WHERE %v > ? AND %v <= ? ORDER BY %v, %v",
+		relatedQuery.SQL(), dialect.QuoteIdentifier(relationPageAlias), rowColumn, rowColumn, dialect.QuoteIdentifier(relationParentColumn), rowColumn),
+		append(relatedQuery.Args(), offset, offset+query.PageSize)...)
+
+	pageColumns := make([]map[string]any, 0, len(columnsList)+3)
+	pageColumns = append(pageColumns, columnsList...)
+	for _, column := range []string{relationParentColumn, relationRowColumn, relationTotalColumn} {
+		pageColumns = append(pageColumns, map[string]any{
+			entity.FieldColumnCode: column,
+			entity.FieldDataType:   "text",
+		})
+	}
+
+	rows, err := db.Raw(pageQuery.SQL(), pageQuery.Args()...).Rows()
+	if err != nil {
+		return resp, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item, err := util.HandleSingleRow(pageColumns, rows, query)
+		if err != nil {
+			return resp, err
+		}
+
+		parentKey := fmt.Sprintf("%v", item[relationParentColumn].Value)
+		total, _ := strconv.Atoi(fmt.Sprintf("%v", item[relationTotalColumn].Value))
+		for _, column := range []string{relationParentColumn, relationRowColumn, relationTotalColumn} {
+			delete(item, column)
+		}
+
+		page, ok := resp[parentKey]
+		if !ok {
+			page = entity.CatalogResponse{
+				Page:      query.Page,
+				PageSize:  query.PageSize,
+				TotalData: total,
+				TotalPage: int(helper.GenerateTotalPage(int64(total), int64(query.PageSize))),
+			}
+		}
+
+		page.Items = append(page.Items, item)
+		resp[parentKey] = page
+	}
+
+	return resp, rows.Err()
+}
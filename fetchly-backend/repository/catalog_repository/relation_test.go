@@ -0,0 +1,108 @@
+package catalogrepository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+)
+
+func TestGetRelatedObjectData(t *testing.T) {
+	r := newTestRepository(t)
+
+	// every object of the test lives in the data source of object remote
+	for _, objectCode := range []string{"deal", "tag", "deal_tag"} {
+		r.dataSources.cache["acme."+objectCode] = r.dataSources.cache["acme.remote"]
+	}
+
+	db, _, err := r.dataDB(context.Background(), "acme", "remote")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, statement := range []string{
+		`CREATE TABLE deal (serial TEXT, title TEXT, contact_serial TEXT, deleted_at DATETIME)`,
+		`INSERT INTO deal VALUES ('d1', 'a', 'c1', NULL), ('d2', 'b', 'c1', NULL), ('d3', 'c', 'c1', NULL), ('d4', 'd', 'c2', NULL), ('d5', 'e', 'c2', '2024-01-01')`,
+		`CREATE TABLE tag (serial TEXT, label TEXT)`,
+		`INSERT INTO tag VALUES ('t1', 'hot'), ('t2', 'cold'), ('t3', 'new')`,
+		`CREATE TABLE deal_tag (deal_serial TEXT, tag_serial TEXT, deleted_at DATETIME)`,
+		`INSERT INTO deal_tag VALUES ('d1', 't1', NULL), ('d1', 't3', NULL), ('d2', 't2', NULL), ('d2', 't3', '2024-01-01')`,
+	} {
+		if err := db.Exec(statement).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	oneToMany := entity.ObjectRelation{FieldCode: "deals", Relation: entity.RelationOneToMany, ParentKey: "serial", TargetObject: "deal", TargetField: "contact_serial"}
+	manyToMany := entity.ObjectRelation{
+		FieldCode: "tags", Relation: entity.RelationManyToMany, ParentKey: "serial", TargetObject: "tag", TargetKey: "serial",
+		JunctionObject: "deal_tag", JunctionParentField: "deal_serial", JunctionTargetField: "tag_serial",
+	}
+
+	tests := []struct {
+		name       string
+		relation   entity.ObjectRelation
+		query      entity.CatalogQuery
+		parentKeys []any
+		field      string
+		want       map[string]string
+	}{
+		{
+			name:       "one to many page of every parent",
+			relation:   oneToMany,
+			query:      entity.CatalogQuery{ObjectCode: "deal", Page: 1, PageSize: 2, Orders: []entity.Order{{FieldName: "title", Direction: "desc"}}},
+			parentKeys: []any{"c1", "c2", "c3"},
+			field:      "title",
+			want:       map[string]string{"c1": "[c b] of 3", "c2": "[d] of 1"},
+		},
+		{
+			name:       "one to many second page",
+			relation:   oneToMany,
+			query:      entity.CatalogQuery{ObjectCode: "deal", Page: 2, PageSize: 2, Orders: []entity.Order{{FieldName: "title"}}},
+			parentKeys: []any{"c1", "c2"},
+			field:      "title",
+			want:       map[string]string{"c1": "[c] of 3"},
+		},
+		{
+			name:       "many to many through the junction",
+			relation:   manyToMany,
+			query:      entity.CatalogQuery{ObjectCode: "tag", Page: 1, PageSize: 10, Orders: []entity.Order{{FieldName: "label"}}},
+			parentKeys: []any{"d1", "d2"},
+			field:      "label",
+			want:       map[string]string{"d1": "[hot new] of 2", "d2": "[cold] of 1"},
+		},
+		{
+			name:     "no parent",
+			relation: oneToMany,
+			query:    entity.CatalogQuery{ObjectCode: "deal", Page: 1, PageSize: 2},
+			want:     map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.query.TenantCode = "acme"
+
+			pages, err := r.GetRelatedObjectData(context.Background(), entity.RelatedQuery{Query: tt.query, Relation: tt.relation, ParentKeys: tt.parentKeys})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := make(map[string]string, len(pages))
+			for parentKey, page := range pages {
+				values := []any{}
+				for _, item := range page.Items {
+					values = append(values, item[tt.field].Value)
+				}
+
+				got[parentKey] = fmt.Sprintf("%v of %v", values, page.TotalData)
+			}
+
+			// maps are printed sorted by key
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("GetRelatedObjectData() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package catalogrepository
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fetchlydev/source/fetchly-backend/config"
+	"github.com/fetchlydev/source/fetchly-backend/pkg/conn"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// newTestRepository returns a repository on a sqlite metadata database, with object "remote" of tenant "acme"
+// in a sqlite data source
+func newTestRepository(t *testing.T) *repository {
+	t.Helper()
+
+	dir := t.TempDir()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(dir, "metadata.db")), &gorm.Config{NamingStrategy: schema.NamingStrategy{SingularTable: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.Config{}
+	r := &repository{
+		cfg:         cfg,
+		db:          db,
+		dataSources: newDataSourceResolver(conn.NewDBRegistry(&cfg), time.Hour),
+	}
+
+	remote := conn.DataSourceConfig{
+		Serial:  "remote",
+		DBName:  filepath.Join(dir, "remote.db"),
+		Configs: map[string]any{"dialect": conn.DialectSQLite},
+	}
+
+	r.dataSources.cache["acme.remote"] = resolvedDataSource{dataSource: &remote, expiresAt: time.Now().Add(time.Hour)}
+
+	return r
+}
@@ -0,0 +1,868 @@
+package catalogrepository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+	"github.com/fetchlydev/source/fetchly-backend/pkg/conn"
+	"github.com/fetchlydev/source/fetchly-backend/pkg/helper"
+	querybuilder "github.com/fetchlydev/source/fetchly-backend/repository/query_builder"
+	"gorm.io/gorm"
+)
+
+// objectSchemaRow is an object with the codes of its module and data source
+type objectSchemaRow struct {
+	Objects        `gorm:"embedded"`
+	ModuleCode     sql.NullString `gorm:"column:module_code"`
+	DataSourceCode sql.NullString `gorm:"column:data_source_code"`
+}
+
+// fieldSchemaRow is a field with the codes of the object and field it targets
+type fieldSchemaRow struct {
+	ObjectFields     `gorm:"embedded"`
+	TargetObjectCode sql.NullString `gorm:"column:target_object_code"`
+	TargetFieldCode  sql.NullString `gorm:"column:target_field_code"`
+}
+
+// objectSchema is the metadata of an object read for a change of its schema
+type objectSchema struct {
+	object     Objects
+	dataSource *conn.DataSourceConfig
+	fields     map[string]fieldSchemaRow
+	schema     entity.ObjectSchema
+}
+
+// GetObjectSchema returns the object with its fields as the schema management API creates them
+func (r *repository) GetObjectSchema(ctx context.Context, tenantCode, objectCode string) (resp entity.ObjectSchema, err error) {
+	current, err := r.readObjectSchema(ctx, tenantCode, objectCode)
+	if err != nil {
+		return resp, err
+	}
+
+	return current.schema, nil
+}
+
+// CreateObjectSchema creates the table of an object with the standard columns and its fields, along with the metadata
+// of the object, its fields and the data types they introduce. The metadata is written in a transaction the DDL
+// runs last in, a table of the metadata database is created in the same transaction.
+func (r *repository) CreateObjectSchema(ctx context.Context, request entity.ObjectSchema) (err error) {
+	metadataDB := r.db.WithContext(ctx)
+
+	tenant := Tenants{}
+	if err := metadataDB.Where("code = ?", request.TenantCode).Limit(1).Find(&tenant).Error; err != nil {
+		return fmt.Errorf("failed to get tenant %v: %w", request.TenantCode, err)
+	}
+
+	if tenant.Serial == "" {
+		return fmt.Errorf("%w: tenant %v is not found", entity.ErrorBadRequest, request.TenantCode)
+	}
+
+	var existingObjects int64
+	if err := metadataDB.Model(&Objects{}).Where("tenant_serial = ? AND code = ?", tenant.Serial, request.ObjectCode).Count(&existingObjects).Error; err != nil {
+		return fmt.Errorf("failed to check object %v: %w", request.ObjectCode, err)
+	}
+
+	if existingObjects > 0 {
+		return fmt.Errorf("%w: object %v already exists in tenant %v", entity.ErrorBadRequest, request.ObjectCode, request.TenantCode)
+	}
+
+	object := Objects{
+		CreatedBy:       request.UserSerial,
+		UpdatedBy:       request.UserSerial,
+		Code:            request.ObjectCode,
+		DisplayName:     request.DisplayName,
+		DisplayTemplate: sql.NullString{String: request.DisplayTemplate, Valid: request.DisplayTemplate != ""},
+		Description:     request.Description,
+		TenantSerial:    tenant.Serial,
+		ObjectType:      request.ObjectType,
+	}
+
+	object.Serial, err = helper.GenerateUUUID()
+	if err != nil {
+		return err
+	}
+
+	if request.ModuleCode != "" {
+		module := Modules{}
+		if err := metadataDB.Where("code = ?", request.ModuleCode).Limit(1).Find(&module).Error; err != nil {
+			return fmt.Errorf("failed to get module %v: %w", request.ModuleCode, err)
+		}
+
+		if module.Serial == "" {
+			return fmt.Errorf("%w: module %v is not found", entity.ErrorBadRequest, request.ModuleCode)
+		}
+
+		object.ModuleSerial = module.Serial
+	}
+
+	var dataSource *conn.DataSourceConfig
+	if request.DataSourceCode != "" {
+		result := DataSource{}
+		if err := metadataDB.Where("tenant_serial = ? AND code = ?", tenant.Serial, request.DataSourceCode).Limit(1).Find(&result).Error; err != nil {
+			return fmt.Errorf("failed to get data source %v: %w", request.DataSourceCode, err)
+		}
+
+		if result.Serial == "" || r.dataSources.registry == nil {
+			return fmt.Errorf("%w: data source %v is not found", entity.ErrorBadRequest, request.DataSourceCode)
+		}
+
+		config := result.ToConfig()
+		dataSource = &config
+		object.DataSourceSerial = result.Serial
+	}
+
+	db, dialect, err := r.dataSourceDB(dataSource)
+	if err != nil {
+		return err
+	}
+
+	defer r.forgetObject(request.TenantCode, request.ObjectCode)
+
+	return metadataDB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(emptySerialColumns(map[string]string{"module_serial": object.ModuleSerial, "data_source_serial": object.DataSourceSerial})...).Create(&object).Error; err != nil {
+			return fmt.Errorf("failed to create object %v: %w", request.ObjectCode, err)
+		}
+
+		columns := []querybuilder.ColumnDefinition{}
+		fieldCodes := []string{}
+		for _, field := range request.Fields {
+			column, err := r.createSchemaField(tx, dialect, request.TenantCode, object, field, request.UserSerial)
+			if err != nil {
+				return err
+			}
+
+			if column != nil {
+				columns = append(columns, *column)
+				fieldCodes = append(fieldCodes, field.FieldCode)
+			}
+		}
+
+		if err := checkDisplayTemplate(request.ObjectCode, request.DisplayTemplate, fieldCodes); err != nil {
+			return err
+		}
+
+		createTable, err := querybuilder.CreateTableQuery(dialect, request.TenantCode, request.ObjectCode, columns)
+		if err != nil {
+			return err
+		}
+
+		return r.execSchema(tx, db, dataSource, request.ObjectCode, createTable)
+	})
+}
+
+// AlterObjectSchema drops, renames, alters and adds the fields of an object, in that order, and updates the
+// object. A renamed field takes its display template placeholders and the many to many relations referencing it
+// along. A change of data type losing values is refused unless forced.
+func (r *repository) AlterObjectSchema(ctx context.Context, request entity.ObjectSchemaChange) (err error) {
+	current, err := r.readObjectSchema(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return err
+	}
+
+	db, dialect, err := r.dataSourceDB(current.dataSource)
+	if err != nil {
+		return err
+	}
+
+	tableColumns, err := r.getTableColumns(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return err
+	}
+
+	object := current.object
+	displayTemplate := object.DisplayTemplate.String
+	if request.DisplayTemplate != nil {
+		displayTemplate = *request.DisplayTemplate
+	}
+
+	// the columns the display template may show once the change is applied
+	fieldCodes := map[string]bool{}
+	for fieldCode, field := range current.fields {
+		fieldCodes[fieldCode] = field.Relation == "" || field.Relation == entity.RelationManyToOne
+	}
+
+	defer r.forgetObject(request.TenantCode, request.ObjectCode)
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		var statements []string
+
+		for _, fieldCode := range request.DropFields {
+			field, ok := current.fields[fieldCode]
+			if !ok {
+				return fmt.Errorf("%w: field %v is not found in object %v", entity.ErrorBadRequest, fieldCode, request.ObjectCode)
+			}
+
+			if err := checkFieldReferences(tx, object, field); err != nil {
+				return err
+			}
+
+			err := tx.Model(&ObjectFields{}).Where("serial = ?", field.Serial).
+				Updates(map[string]any{"deleted_at": now, "deleted_by": request.UserSerial}).Error
+			if err != nil {
+				return fmt.Errorf("failed to drop field %v: %w", fieldCode, err)
+			}
+
+			if fieldCodes[fieldCode] {
+				statements = append(statements, querybuilder.DropColumnQuery(dialect, request.TenantCode, request.ObjectCode, fieldCode))
+			}
+
+			delete(fieldCodes, fieldCode)
+		}
+
+		for _, change := range request.AlterFields {
+			field, ok := current.fields[change.Code()]
+			if !ok {
+				return fmt.Errorf("%w: field %v is not found in object %v", entity.ErrorBadRequest, change.Code(), request.ObjectCode)
+			}
+
+			from := current.schemaField(change.Code())
+			to := change.Field
+			if from.IsColumn() != to.IsColumn() {
+				return fmt.Errorf("%w: field %v can not change between a column and a to many relation, drop it and add it again", entity.ErrorBadRequest, change.Code())
+			}
+
+			if from.IsColumn() && (from.Relation != to.Relation || from.TargetObjectCode != to.TargetObjectCode || from.TargetFieldCode != to.TargetFieldCode) {
+				return fmt.Errorf("%w: the relation of column %v can not be changed, drop it and add it again", entity.ErrorBadRequest, change.Code())
+			}
+
+			row, toColumn, err := r.schemaFieldRow(tx, dialect, request.TenantCode, object, to, request.UserSerial)
+			if err != nil {
+				return err
+			}
+
+			if from.IsColumn() && !change.IsForced && !entity.IsLosslessTypeChange(from.DataType.PrimitiveDataType, row.primitiveDataType) {
+				return fmt.Errorf("%w: changing field %v from %v to %v may lose values, force the change to cast them", entity.ErrorBadRequest, change.Code(), from.DataType.PrimitiveDataType, row.primitiveDataType)
+			}
+
+			fromColumn := schemaColumn(dialect, from, from.DataType.PrimitiveDataType)
+			if to.FieldCode != change.Code() {
+				if _, ok := fieldCodes[to.FieldCode]; ok || tableColumns.Has(to.FieldCode) {
+					return fmt.Errorf("%w: field %v already exists in object %v", entity.ErrorBadRequest, to.FieldCode, request.ObjectCode)
+				}
+
+				if from.IsColumn() {
+					statements = append(statements, dialect.RenameColumn(request.TenantCode, request.ObjectCode, fromColumn, to.FieldCode)...)
+				}
+
+				// the many to many relations of the object the field is the far key of
+				err := tx.Model(&ObjectFields{}).
+					Where("target_object_serial = ? AND field_reference = ? AND relation = ?", object.Serial, change.Code(), entity.RelationManyToMany).
+					Update("field_reference", to.FieldCode).Error
+				if err != nil {
+					return fmt.Errorf("failed to rename field %v: %w", change.Code(), err)
+				}
+
+				if request.DisplayTemplate == nil {
+					displayTemplate = strings.ReplaceAll(displayTemplate, "{"+change.Code()+"}", "{"+to.FieldCode+"}")
+				}
+
+				delete(fieldCodes, change.Code())
+				fieldCodes[to.FieldCode] = from.IsColumn()
+				fromColumn.Name = to.FieldCode
+			}
+
+			// the foreign key of a column is kept as it is
+			if toColumn != nil {
+				toColumn.References = ""
+			}
+
+			if toColumn != nil && *toColumn != fromColumn {
+				alterColumn, ok := dialect.AlterColumn(request.TenantCode, request.ObjectCode, fromColumn, *toColumn)
+				if !ok {
+					return fmt.Errorf("%w: column %v can not be altered in %v", entity.ErrorBadRequest, change.Code(), dialect.Name())
+				}
+
+				statements = append(statements, alterColumn...)
+			}
+
+			err = tx.Model(&ObjectFields{}).Where("serial = ?", field.Serial).Updates(map[string]any{
+				"updated_at":                 now,
+				"updated_by":                 request.UserSerial,
+				"field_code":                 row.FieldCode,
+				"is_display_name":            row.IsDisplayName,
+				"display_name":               row.DisplayName,
+				"field_reference":            row.FieldReference,
+				"description":                row.Description,
+				"data_type_serial":           row.DataTypeSerial,
+				"validation_rules":           row.ValidationRules,
+				"target_object_serial":       nullSerial(row.TargetObjectSerial),
+				"target_object_field_serial": nullSerial(row.TargetObjectFieldSerial),
+				"relation":                   row.Relation,
+				"is_searchable":              row.IsSearchable,
+				"default_value":              row.DefaultValue,
+			}).Error
+			if err != nil {
+				return fmt.Errorf("failed to alter field %v: %w", change.Code(), err)
+			}
+		}
+
+		for _, field := range request.AddFields {
+			if _, ok := fieldCodes[field.FieldCode]; ok || tableColumns.Has(field.FieldCode) {
+				return fmt.Errorf("%w: field %v already exists in object %v", entity.ErrorBadRequest, field.FieldCode, request.ObjectCode)
+			}
+
+			column, err := r.createSchemaField(tx, dialect, request.TenantCode, object, field, request.UserSerial)
+			if err != nil {
+				return err
+			}
+
+			fieldCodes[field.FieldCode] = column != nil
+			if column == nil {
+				continue
+			}
+
+			addColumn, err := dialect.AddColumn(request.TenantCode, request.ObjectCode, *column)
+			if err != nil {
+				return err
+			}
+
+			statements = append(statements, addColumn...)
+		}
+
+		columnCodes := []string{}
+		for fieldCode, isColumn := range fieldCodes {
+			if isColumn {
+				columnCodes = append(columnCodes, fieldCode)
+			}
+		}
+
+		if err := checkDisplayTemplate(request.ObjectCode, displayTemplate, columnCodes); err != nil {
+			return err
+		}
+
+		objectChanges := map[string]any{
+			"updated_at":       now,
+			"updated_by":       request.UserSerial,
+			"display_template": sql.NullString{String: displayTemplate, Valid: displayTemplate != ""},
+		}
+
+		if request.DisplayName != nil {
+			objectChanges["display_name"] = *request.DisplayName
+		}
+
+		if request.Description != nil {
+			objectChanges["description"] = *request.Description
+		}
+
+		if err := tx.Model(&Objects{}).Where("serial = ?", object.Serial).Updates(objectChanges).Error; err != nil {
+			return fmt.Errorf("failed to alter object %v: %w", request.ObjectCode, err)
+		}
+
+		for _, statement := range statements {
+			if err := r.execSchema(tx, db, current.dataSource, request.ObjectCode, statement); err != nil {
+				return fmt.Errorf("failed to alter table %v: %w", request.ObjectCode, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// DropObjectSchema drops the table of an object and deletes the metadata of the object and its fields. An object
+// the fields of other objects relate to is kept.
+func (r *repository) DropObjectSchema(ctx context.Context, request entity.ObjectSchemaChange) (err error) {
+	current, err := r.readObjectSchema(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return err
+	}
+
+	db, dialect, err := r.dataSourceDB(current.dataSource)
+	if err != nil {
+		return err
+	}
+
+	var relatedFields []string
+	err = r.db.WithContext(ctx).Model(&ObjectFields{}).
+		Joins("JOIN objects ON objects.serial = object_fields.object_serial AND objects.deleted_at IS NULL").
+		Where("object_fields.target_object_serial = ?", current.object.Serial).
+		Where("object_fields.object_serial != ?", current.object.Serial).
+		Pluck("objects.code || '.' || object_fields.field_code", &relatedFields).Error
+	if err != nil {
+		return fmt.Errorf("failed to check the relations of object %v: %w", request.ObjectCode, err)
+	}
+
+	if len(relatedFields) > 0 {
+		return fmt.Errorf("%w: object %v is related to by %v", entity.ErrorBadRequest, request.ObjectCode, strings.Join(relatedFields, ", "))
+	}
+
+	defer r.forgetObject(request.TenantCode, request.ObjectCode)
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		deleted := map[string]any{"deleted_at": time.Now(), "deleted_by": request.UserSerial}
+		if err := tx.Model(&ObjectFields{}).Where("object_serial = ?", current.object.Serial).Updates(deleted).Error; err != nil {
+			return fmt.Errorf("failed to drop the fields of object %v: %w", request.ObjectCode, err)
+		}
+
+		if err := tx.Model(&Objects{}).Where("serial = ?", current.object.Serial).Updates(deleted).Error; err != nil {
+			return fmt.Errorf("failed to drop object %v: %w", request.ObjectCode, err)
+		}
+
+		return r.execSchema(tx, db, current.dataSource, request.ObjectCode, querybuilder.DropTableQuery(dialect, request.TenantCode, request.ObjectCode))
+	})
+}
+
+// readObjectSchema reads the object with its fields and their data types
+func (r *repository) readObjectSchema(ctx context.Context, tenantCode, objectCode string) (*objectSchema, error) {
+	db := r.db.WithContext(ctx)
+
+	row := objectSchemaRow{}
+	err := db.Model(&Objects{}).
+		Select("objects.*, modules.code AS module_code, data_sources.code AS data_source_code").
+		Joins("JOIN tenants ON tenants.serial = objects.tenant_serial").
+		Joins("LEFT JOIN modules ON modules.serial = objects.module_serial").
+		Joins("LEFT JOIN data_sources ON data_sources.serial = objects.data_source_serial").
+		Where("tenants.code = ?", tenantCode).
+		Where("objects.code = ?", objectCode).
+		Limit(1).
+		Scan(&row).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get object %v: %w", objectCode, err)
+	}
+
+	if row.Serial == "" {
+		return nil, fmt.Errorf("%w: object %v is not found in tenant %v", entity.ErrorNotFound, objectCode, tenantCode)
+	}
+
+	fieldRows := []fieldSchemaRow{}
+	err = db.Model(&ObjectFields{}).
+		Select("object_fields.*, target_objects.code AS target_object_code, target_fields.field_code AS target_field_code").
+		Joins("LEFT JOIN objects AS target_objects ON target_objects.serial = object_fields.target_object_serial").
+		Joins("LEFT JOIN object_fields AS target_fields ON target_fields.serial = object_fields.target_object_field_serial").
+		Where("object_fields.object_serial = ?", row.Serial).
+		Order("object_fields.id").
+		Scan(&fieldRows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fields of object %v: %w", objectCode, err)
+	}
+
+	dataTypeSerials := make([]string, 0, len(fieldRows))
+	for _, fieldRow := range fieldRows {
+		dataTypeSerials = append(dataTypeSerials, fieldRow.DataTypeSerial)
+	}
+
+	dataTypes, err := r.GetDataTypeBySerials(ctx, dataTypeSerials)
+	if err != nil {
+		return nil, err
+	}
+
+	dataTypeMap := make(map[string]entity.DataType, len(dataTypes))
+	for _, dataType := range dataTypes {
+		dataTypeMap[dataType.Serial] = dataType
+	}
+
+	dataSource, err := r.dataSources.resolve(ctx, r.db, tenantCode, objectCode)
+	if err != nil {
+		return nil, err
+	}
+
+	current := &objectSchema{
+		object:     row.Objects,
+		dataSource: dataSource,
+		fields:     make(map[string]fieldSchemaRow, len(fieldRows)),
+		schema: entity.ObjectSchema{
+			TenantCode:      tenantCode,
+			ObjectCode:      row.Code,
+			DisplayName:     row.DisplayName,
+			DisplayTemplate: row.DisplayTemplate.String,
+			Description:     row.Description,
+			ObjectType:      row.ObjectType,
+			ModuleCode:      row.ModuleCode.String,
+			DataSourceCode:  row.DataSourceCode.String,
+			Fields:          make([]entity.FieldSchema, 0, len(fieldRows)),
+		},
+	}
+
+	for _, fieldRow := range fieldRows {
+		field := fieldRow.ToEntity()
+		rules := field.ValidationRules
+		if rules == nil {
+			rules = map[string]any{}
+		}
+
+		isRequired := rules[string(entity.ValidationRuleRequired)] == true
+		isUnique := rules[string(entity.ValidationRuleUnique)] == true
+		delete(rules, string(entity.ValidationRuleRequired))
+		delete(rules, string(entity.ValidationRuleUnique))
+
+		targetFieldCode := fieldRow.TargetFieldCode.String
+		if fieldRow.Relation == entity.RelationManyToOne && targetFieldCode == "" {
+			targetFieldCode = entity.DEFAULT_IDENTIFIER
+		}
+
+		current.fields[field.FieldCode] = fieldRow
+		current.schema.Fields = append(current.schema.Fields, entity.FieldSchema{
+			FieldCode:        field.FieldCode,
+			DisplayName:      field.DisplayName,
+			Description:      field.Description,
+			DataType:         dataTypeMap[field.DataType.Serial],
+			IsRequired:       isRequired,
+			IsUnique:         isUnique,
+			IsDisplayName:    field.IsDisplayName,
+			IsSearchable:     field.IsSearchable,
+			DefaultValue:     field.DefaultValue,
+			ValidationRules:  rules,
+			Relation:         field.Relation,
+			TargetObjectCode: fieldRow.TargetObjectCode.String,
+			TargetFieldCode:  targetFieldCode,
+			FieldReference:   field.FieldReference,
+		})
+	}
+
+	return current, nil
+}
+
+// schemaField returns the current definition of a field of the object
+func (s *objectSchema) schemaField(fieldCode string) entity.FieldSchema {
+	for _, field := range s.schema.Fields {
+		if field.FieldCode == fieldCode {
+			return field
+		}
+	}
+
+	return entity.FieldSchema{}
+}
+
+// schemaFieldRow is the metadata of a field about to be written, with the primitive data type of its column
+type schemaFieldRow struct {
+	ObjectFields
+	primitiveDataType string
+}
+
+// createSchemaField creates the metadata of a field of the object and returns the definition of its column, nil
+// for a to many relation
+func (r *repository) createSchemaField(tx *gorm.DB, dialect querybuilder.Dialect, tenantCode string, object Objects, field entity.FieldSchema, userSerial string) (*querybuilder.ColumnDefinition, error) {
+	row, column, err := r.schemaFieldRow(tx, dialect, tenantCode, object, field, userSerial)
+	if err != nil {
+		return nil, err
+	}
+
+	row.CreatedBy = userSerial
+	row.Serial, err = helper.GenerateUUUID()
+	if err != nil {
+		return nil, err
+	}
+
+	omitted := emptySerialColumns(map[string]string{
+		"data_type_serial":           row.DataTypeSerial,
+		"target_object_serial":       row.TargetObjectSerial,
+		"target_object_field_serial": row.TargetObjectFieldSerial,
+	})
+
+	if err := tx.Omit(omitted...).Create(&row.ObjectFields).Error; err != nil {
+		return nil, fmt.Errorf("failed to create field %v: %w", field.FieldCode, err)
+	}
+
+	return column, nil
+}
+
+// schemaFieldRow resolves the data type and the target of a field into its metadata and, for a column, the
+// definition of the column. A many to one relation references the key of its target object, which has to live in
+// the same database.
+func (r *repository) schemaFieldRow(tx *gorm.DB, dialect querybuilder.Dialect, tenantCode string, object Objects, field entity.FieldSchema, userSerial string) (schemaFieldRow, *querybuilder.ColumnDefinition, error) {
+	row := schemaFieldRow{ObjectFields: ObjectFields{
+		UpdatedBy:      userSerial,
+		ObjectSerial:   object.Serial,
+		FieldCode:      field.FieldCode,
+		IsDisplayName:  field.IsDisplayName,
+		DisplayName:    field.DisplayName,
+		FieldReference: field.FieldReference,
+		Description:    field.Description,
+		Relation:       field.Relation,
+		IsSearchable:   field.IsSearchable,
+		DefaultValue:   sql.NullString{String: field.DefaultValue, Valid: field.DefaultValue != ""},
+	}}
+
+	if row.DisplayName == "" {
+		row.DisplayName = field.FieldCode
+	}
+
+	rules := make(map[string]any, len(field.ValidationRules)+2)
+	for rule, value := range field.ValidationRules {
+		rules[rule] = value
+	}
+
+	if field.IsRequired {
+		rules[string(entity.ValidationRuleRequired)] = true
+	}
+
+	if field.IsUnique {
+		rules[string(entity.ValidationRuleUnique)] = true
+	}
+
+	validationRules, err := json.Marshal(rules)
+	if err != nil {
+		return row, nil, err
+	}
+
+	row.ValidationRules = string(validationRules)
+
+	if field.IsColumn() || field.DataType.Code != "" || field.DataType.Serial != "" {
+		dataType, err := schemaDataType(tx, field)
+		if err != nil {
+			return row, nil, err
+		}
+
+		row.DataTypeSerial = dataType.Serial
+		row.primitiveDataType = dataType.PrimitiveDataType
+	}
+
+	if field.IsSearchable && row.primitiveDataType != entity.PrimitiveDataTypeString && row.primitiveDataType != entity.PrimitiveDataTypeText {
+		return row, nil, fmt.Errorf("%w: field %v can not be searchable, only string and text fields are", entity.ErrorBadRequest, field.FieldCode)
+	}
+
+	var references string
+	if field.Relation != "" {
+		target := Objects{}
+		err := tx.Where("tenant_serial = ? AND code = ?", object.TenantSerial, field.TargetObjectCode).Limit(1).Find(&target).Error
+		if err != nil {
+			return row, nil, fmt.Errorf("failed to get target object of field %v: %w", field.FieldCode, err)
+		}
+
+		if target.Serial == "" {
+			return row, nil, fmt.Errorf("%w: target object %v of field %v is not found", entity.ErrorBadRequest, field.TargetObjectCode, field.FieldCode)
+		}
+
+		row.TargetObjectSerial = target.Serial
+
+		targetFieldCode := field.TargetFieldCode
+		if targetFieldCode == "" && field.Relation == entity.RelationManyToOne {
+			targetFieldCode = entity.DEFAULT_IDENTIFIER
+		}
+
+		var targetField ObjectFields
+		if targetFieldCode != entity.DEFAULT_IDENTIFIER {
+			err := tx.Where("object_serial = ? AND field_code = ?", target.Serial, targetFieldCode).Limit(1).Find(&targetField).Error
+			if err != nil {
+				return row, nil, fmt.Errorf("failed to get target field of field %v: %w", field.FieldCode, err)
+			}
+
+			if targetField.Serial == "" {
+				return row, nil, fmt.Errorf("%w: target field %v.%v of field %v is not found", entity.ErrorBadRequest, field.TargetObjectCode, targetFieldCode, field.FieldCode)
+			}
+
+			row.TargetObjectFieldSerial = targetField.Serial
+		}
+
+		switch field.Relation {
+		case entity.RelationManyToOne:
+			if target.DataSourceSerial != object.DataSourceSerial {
+				return row, nil, fmt.Errorf("%w: field %v can only reference an object of the same data source", entity.ErrorBadRequest, field.FieldCode)
+			}
+
+			if targetField.Relation != "" && targetField.Relation != entity.RelationManyToOne {
+				return row, nil, fmt.Errorf("%w: target field %v.%v of field %v is not a column", entity.ErrorBadRequest, field.TargetObjectCode, targetFieldCode, field.FieldCode)
+			}
+
+			references = fmt.Sprintf("%v (%v)", querybuilder.TableIn(dialect, tenantCode, target.Code), dialect.QuoteIdentifier(targetFieldCode))
+
+		case entity.RelationOneToMany, entity.RelationManyToMany:
+			if targetField.Relation != entity.RelationManyToOne || targetField.TargetObjectSerial != object.Serial {
+				return row, nil, fmt.Errorf("%w: target field %v.%v of field %v has to be a many to one relation to object %v", entity.ErrorBadRequest, field.TargetObjectCode, targetFieldCode, field.FieldCode, object.Code)
+			}
+
+			if field.Relation == entity.RelationManyToMany {
+				farField := ObjectFields{}
+				err := tx.Where("object_serial = ? AND field_code = ?", target.Serial, field.FieldReference).Limit(1).Find(&farField).Error
+				if err != nil {
+					return row, nil, fmt.Errorf("failed to get field reference of field %v: %w", field.FieldCode, err)
+				}
+
+				if farField.Relation != entity.RelationManyToOne {
+					return row, nil, fmt.Errorf("%w: field reference %v.%v of field %v has to be a many to one relation", entity.ErrorBadRequest, field.TargetObjectCode, field.FieldReference, field.FieldCode)
+				}
+			}
+		}
+	}
+
+	if !field.IsColumn() {
+		return row, nil, nil
+	}
+
+	return row, &querybuilder.ColumnDefinition{
+		Name:              field.FieldCode,
+		PrimitiveDataType: row.primitiveDataType,
+		IsRequired:        field.IsRequired,
+		IsUnique:          field.IsUnique,
+		Default:           querybuilder.DefaultLiteral(dialect, row.primitiveDataType, field.DefaultValue),
+		References:        references,
+	}, nil
+}
+
+// schemaDataType returns the data type of a field by serial or code. A data type with a primitive data type that
+// is not found is created, a data type found with another primitive data type is refused.
+func schemaDataType(tx *gorm.DB, field entity.FieldSchema) (entity.DataType, error) {
+	requested := field.DataType
+	if requested.Serial == "" && requested.Code == "" {
+		return requested, fmt.Errorf("%w: field %v has no data type", entity.ErrorBadRequest, field.FieldCode)
+	}
+
+	db := tx.Model(&DataType{})
+	if requested.Serial != "" {
+		db = db.Where("serial = ?", requested.Serial)
+	} else {
+		db = db.Where("code = ?", requested.Code)
+	}
+
+	result := DataType{}
+	if err := db.Limit(1).Find(&result).Error; err != nil {
+		return requested, fmt.Errorf("failed to get data type of field %v: %w", field.FieldCode, err)
+	}
+
+	if result.Serial != "" {
+		if requested.PrimitiveDataType != "" && requested.PrimitiveDataType != result.PrimitiveDataType {
+			return requested, fmt.Errorf("%w: data type %v is a %v, not a %v", entity.ErrorBadRequest, result.Code, result.PrimitiveDataType, requested.PrimitiveDataType)
+		}
+
+		return result.ToEntity(), nil
+	}
+
+	if requested.Code == "" || !entity.IsPrimitiveDataType(requested.PrimitiveDataType) {
+		return requested, fmt.Errorf("%w: data type %v of field %v is not found, a new data type needs a code and a primitive data type", entity.ErrorBadRequest, requested.Code, field.FieldCode)
+	}
+
+	if requested.ValidationRules == nil {
+		requested.ValidationRules = map[string]any{}
+	}
+
+	if requested.FieldOptions == nil {
+		requested.FieldOptions = map[string]any{}
+	}
+
+	if requested.Name == "" {
+		requested.Name = requested.Code
+	}
+
+	validationRules, err := json.Marshal(requested.ValidationRules)
+	if err != nil {
+		return requested, err
+	}
+
+	fieldOptions, err := json.Marshal(requested.FieldOptions)
+	if err != nil {
+		return requested, err
+	}
+
+	result = DataType{
+		Code:              requested.Code,
+		Name:              requested.Name,
+		Description:       requested.Description,
+		PrimitiveDataType: requested.PrimitiveDataType,
+		ValidationRules:   string(validationRules),
+		IsActive:          true,
+		DisplayType:       requested.DisplayType,
+		FieldOptions:      string(fieldOptions),
+		Icon:              requested.Icon,
+	}
+
+	result.Serial, err = helper.GenerateUUUID()
+	if err != nil {
+		return requested, err
+	}
+
+	if err := tx.Create(&result).Error; err != nil {
+		return requested, fmt.Errorf("failed to create data type %v: %w", requested.Code, err)
+	}
+
+	return result.ToEntity(), nil
+}
+
+// schemaColumn is the definition of the column of a field of the primitive data type
+func schemaColumn(dialect querybuilder.Dialect, field entity.FieldSchema, primitiveDataType string) querybuilder.ColumnDefinition {
+	return querybuilder.ColumnDefinition{
+		Name:              field.FieldCode,
+		PrimitiveDataType: primitiveDataType,
+		IsRequired:        field.IsRequired,
+		IsUnique:          field.IsUnique,
+		Default:           querybuilder.DefaultLiteral(dialect, primitiveDataType, field.DefaultValue),
+	}
+}
+
+// checkFieldReferences refuses to drop a field other fields relate through
+func checkFieldReferences(tx *gorm.DB, object Objects, field fieldSchemaRow) error {
+	var relatedFields []string
+	err := tx.Model(&ObjectFields{}).
+		Where("target_object_field_serial = ? OR (target_object_serial = ? AND field_reference = ? AND relation = ?)", field.Serial, object.Serial, field.FieldCode, entity.RelationManyToMany).
+		Pluck("field_code", &relatedFields).Error
+	if err != nil {
+		return fmt.Errorf("failed to check the relations of field %v: %w", field.FieldCode, err)
+	}
+
+	if len(relatedFields) > 0 {
+		return fmt.Errorf("%w: field %v is related to by %v", entity.ErrorBadRequest, field.FieldCode, strings.Join(relatedFields, ", "))
+	}
+
+	return nil
+}
+
+// checkDisplayTemplate checks that a display template only shows columns of the object
+func checkDisplayTemplate(objectCode, displayTemplate string, columnCodes []string) error {
+	if strings.TrimSpace(displayTemplate) == "" {
+		return nil
+	}
+
+	template, err := querybuilder.ParseDisplayTemplate(displayTemplate)
+	if err != nil {
+		return fmt.Errorf("%w: object %v: %v", entity.ErrorBadRequest, objectCode, err)
+	}
+
+	for _, fieldCode := range template.Fields() {
+		if !helper.Contains(columnCodes, fieldCode) && !entity.IsStandardColumn(fieldCode) {
+			return fmt.Errorf("%w: display field %v is not a column of object %v", entity.ErrorBadRequest, fieldCode, objectCode)
+		}
+	}
+
+	return nil
+}
+
+// schemaDB is the database the DDL of an object runs on, the metadata transaction for the objects without a data
+// source. The DDL of a data source is outside of the transaction, it runs last so a failure rolls the metadata back.
+func schemaDB(tx, db *gorm.DB, dataSource *conn.DataSourceConfig) *gorm.DB {
+	if dataSource == nil {
+		return tx
+	}
+
+	return db
+}
+
+// emptySerialColumns lists the serial columns left empty, they are omitted from an insert so they stay NULL
+func emptySerialColumns(columns map[string]string) []string {
+	var omitted []string
+	for column, serial := range columns {
+		if serial == "" {
+			omitted = append(omitted, column)
+		}
+	}
+
+	return omitted
+}
+
+// nullSerial is a serial column value, NULL when empty
+func nullSerial(serial string) sql.NullString {
+	return sql.NullString{String: serial, Valid: serial != ""}
+}
+
+// forgetObject drops the cached metadata of an object after a change of its schema
+func (r *repository) forgetObject(tenantCode, objectCode string) {
+	cacheKey := tenantCode + "." + objectCode
+
+	// forgotten again once the metadata transaction ends, the caches fill up with what the transaction sees
+	if r.statements != nil {
+		r.statements.objects[cacheKey] = [2]string{tenantCode, objectCode}
+	}
+
+	r.dataSources.forget(tenantCode, objectCode)
+
+	r.searchIndexes.mu.Lock()
+	delete(r.searchIndexes.fields, cacheKey)
+	r.searchIndexes.mu.Unlock()
+
+	r.displayTemplates.mu.Lock()
+	delete(r.displayTemplates.templates, cacheKey)
+	r.displayTemplates.mu.Unlock()
+}
@@ -0,0 +1,319 @@
+package catalogrepository
+
+import (
+	"context"
+	"crypto/sha1"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+	"github.com/fetchlydev/source/fetchly-backend/pkg/helper"
+	querybuilder "github.com/fetchlydev/source/fetchly-backend/repository/query_builder"
+	"github.com/fetchlydev/source/fetchly-backend/repository/util"
+	"gorm.io/gorm"
+)
+
+const (
+	searchRankColumn      = "__search_rank"
+	searchHighlightPrefix = "__search_highlight_"
+
+	// searchIndexRetryInterval is how long a search index that failed to build is left before it is tried again
+	searchIndexRetryInterval = 10 * time.Minute
+)
+
+// searchIndexes keeps the searchable fields of the objects and the search indexes built for them. An index is
+// named after its fields, a change of the searchable fields builds a new index and drops the old one. ensured
+// holds the time an index may be built again, zero once it is built or being built.
+type searchIndexes struct {
+	ttl     time.Duration
+	mu      sync.RWMutex
+	fields  map[string]searchableFields
+	ensured sync.Map
+}
+
+type searchableFields struct {
+	fieldCodes []string
+	expiresAt  time.Time
+}
+
+func newSearchIndexes(ttl time.Duration) *searchIndexes {
+	return &searchIndexes{
+		ttl:    ttl,
+		fields: make(map[string]searchableFields),
+	}
+}
+
+// objectSearch is the search of a request over the searchable columns of its object
+type objectSearch struct {
+	search     string
+	fieldCodes []string
+	references []string
+	textSearch querybuilder.TextSearch
+	isFullText bool
+}
+
+// searchableFieldCodes returns the field codes of the object marked searchable, sorted
+func (r *repository) searchableFieldCodes(ctx context.Context, tenantCode, objectCode string) ([]string, error) {
+	cacheKey := tenantCode + "." + objectCode
+
+	r.searchIndexes.mu.RLock()
+	cached, ok := r.searchIndexes.fields[cacheKey]
+	r.searchIndexes.mu.RUnlock()
+
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.fieldCodes, nil
+	}
+
+	var fieldCodes []string
+	err := r.db.WithContext(ctx).Model(&ObjectFields{}).
+		Joins("JOIN objects ON objects.serial = object_fields.object_serial AND objects.deleted_at IS NULL").
+		Joins("JOIN tenants ON tenants.serial = objects.tenant_serial").
+		Where("tenants.code = ?", tenantCode).
+		Where("objects.code = ?", objectCode).
+		Where("object_fields.is_searchable").
+		Order("object_fields.field_code").
+		Pluck("object_fields.field_code", &fieldCodes).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get searchable fields of object %v: %w", objectCode, err)
+	}
+
+	r.searchIndexes.mu.Lock()
+	r.searchIndexes.fields[cacheKey] = searchableFields{fieldCodes: fieldCodes, expiresAt: time.Now().Add(r.searchIndexes.ttl)}
+	r.searchIndexes.mu.Unlock()
+
+	return fieldCodes, nil
+}
+
+// resolveSearch returns the search of the request, nil without a search. The fields excluded from the search
+// of the request are left out of the document, they never match.
+func (r *repository) resolveSearch(ctx context.Context, dialect querybuilder.Dialect, tableName string, request entity.CatalogQuery, tableColumns querybuilder.Columns) (*objectSearch, error) {
+	search := strings.TrimSpace(request.Search)
+	if search == "" {
+		return nil, nil
+	}
+
+	fieldCodes, err := r.searchableFieldCodes(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &objectSearch{search: search}
+	for _, fieldCode := range fieldCodes {
+		// fields of other types are not searched, their text can not be indexed
+		if helper.Contains(request.ExcludedSearchFields, fieldCode) || !querybuilder.IsTextType(tableColumns.DataType(fieldCode)) {
+			continue
+		}
+
+		reference, err := tableColumns.ReferenceIn(dialect, tableName, fieldCode)
+		if err != nil {
+			return nil, err
+		}
+
+		result.fieldCodes = append(result.fieldCodes, fieldCode)
+		result.references = append(result.references, reference)
+	}
+
+	if len(result.fieldCodes) == 0 {
+		return nil, fmt.Errorf("%w: object %v has no searchable fields", entity.ErrorBadRequest, request.ObjectCode)
+	}
+
+	result.textSearch, result.isFullText = dialect.TextSearch(result.references)
+
+	return result, nil
+}
+
+// condition matches the records containing the search, databases without full text search match every word of
+// the search in any of the columns with LIKE
+func (s *objectSearch) condition(dialect querybuilder.Dialect) querybuilder.Expr {
+	if s.isFullText {
+		return s.textSearch.Match(s.search)
+	}
+
+	var words []querybuilder.Expr
+	for _, word := range strings.Fields(strings.ToLower(s.search)) {
+		columns := make([]querybuilder.Expr, 0, len(s.references))
+		for _, reference := range s.references {
+			columns = append(columns, querybuilder.NewExpr(fmt.Sprintf("LOWER(%s) LIKE ? %s", reference, dialect.LikeEscape()), "%"+querybuilder.EscapeLike(word)+"%"))
+		}
+
+		words = append(words, querybuilder.Join(columns, " OR "))
+	}
+
+	return querybuilder.Join(words, " AND ")
+}
+
+// getObjectDataBySearch reads a page of the records matching the full text search of the request. Without orders
+// the records are ranked, the best match first. Every searchable field gets its rank and the fragments matching
+// the search as search_rank and search_highlight in its additional data.
+func (r *repository) getObjectDataBySearch(
+	ctx context.Context,
+	db *gorm.DB,
+	dialect querybuilder.Dialect,
+	resp entity.CatalogResponse,
+	columnsString, tableName string,
+	request entity.CatalogQuery,
+	joinQueryMap map[string]string,
+	joinQueryOrder []string,
+	columnsList []map[string]any,
+	tableColumns querybuilder.Columns,
+	search *objectSearch,
+) (entity.CatalogResponse, error) {
+	orderString := ""
+	orderJoins := []string{}
+	if len(request.Orders) > 0 {
+		orderBy, orderJoinMap, orderJoinOrder, err := buildOrderBy(dialect, request, columnsList, tableColumns)
+		if err != nil {
+			return resp, err
+		}
+
+		orderString = orderBy
+		for _, joinKey := range orderJoinOrder {
+			orderJoins = append(orderJoins, orderJoinMap[joinKey])
+		}
+	}
+
+	// the rank and the highlights are selected along the fields and moved into the items afterwards
+	rank := search.textSearch.Rank(search.search)
+	selectClause := querybuilder.NewExpr(fmt.Sprintf("%v, %v AS %v", columnsString, rank.SQL, dialect.QuoteIdentifier(searchRankColumn)), rank.Args...)
+
+	pageColumns := make([]map[string]any, 0, len(columnsList)+len(search.fieldCodes)+1)
+	pageColumns = append(pageColumns, columnsList...)
+	pageColumns = append(pageColumns, map[string]any{
+		entity.FieldColumnCode: searchRankColumn,
+		entity.FieldDataType:   "float4",
+	})
+
+	for i, reference := range search.references {
+		alias := fmt.Sprintf("%v%d", searchHighlightPrefix, i)
+		headline := search.textSearch.Headline(reference, search.search)
+
+		selectClause.SQL = fmt.Sprintf("%v, %v AS %v", selectClause.SQL, headline.SQL, dialect.QuoteIdentifier(alias))
+		selectClause.Args = append(selectClause.Args, headline.Args...)
+		pageColumns = append(pageColumns, map[string]any{
+			entity.FieldColumnCode: alias,
+			entity.FieldDataType:   "text",
+		})
+	}
+
+	query, err := r.buildSelectExprQuery(ctx, dialect, selectClause, tableName, request, joinQueryMap, joinQueryOrder, tableColumns, orderJoins...)
+	if err != nil {
+		return resp, err
+	}
+
+	if orderString != "" {
+		query.Write(" ORDER BY " + orderString)
+	} else {
+		query.Write(fmt.Sprintf(" ORDER BY %v DESC", dialect.QuoteIdentifier(searchRankColumn)))
+	}
+
+	dialect.Paginate(query, request.PageSize, (request.Page-1)*request.PageSize)
+
+	rows, err := db.Raw(query.SQL(), query.Args()...).Rows()
+	if err != nil {
+		return resp, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item, err := util.HandleSingleRow(pageColumns, rows, request)
+		if err != nil {
+			return resp, err
+		}
+
+		rankValue := item[searchRankColumn].Value
+		delete(item, searchRankColumn)
+
+		for i, fieldCode := range search.fieldCodes {
+			alias := fmt.Sprintf("%v%d", searchHighlightPrefix, i)
+			highlight := item[alias].Value
+			delete(item, alias)
+
+			field, ok := item[fieldCode]
+			if !ok {
+				continue
+			}
+
+			field.AdditionalData["search_rank"] = rankValue
+			if headline, ok := highlight.(string); ok {
+				field.AdditionalData["search_highlight"] = querybuilder.HighlightHTML(headline)
+			}
+
+			item[fieldCode] = field
+		}
+
+		resp.Items = append(resp.Items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return resp, err
+	}
+
+	resp.Page = request.Page
+	resp.PageSize = request.PageSize
+	resp.TotalPage = int(helper.GenerateTotalPage(int64(resp.TotalData), int64(request.PageSize)))
+
+	return resp, nil
+}
+
+// ensureSearchIndex builds the GIN index the search of the object runs on, in the background and without locking
+// the table. Searches are answered without the index until it is built, indexes of former searchable fields are
+// dropped once the new one exists.
+func (r *repository) ensureSearchIndex(db *gorm.DB, dialect querybuilder.Dialect, tenantCode, objectCode string, search *objectSearch) {
+	// a transaction can not build an index concurrently, the next search outside of it does
+	if !search.isFullText || r.tx != nil {
+		return
+	}
+
+	hash := sha1.Sum([]byte(strings.Join(search.fieldCodes, ",")))
+	indexPrefix := fmt.Sprintf("%.40s_search_", objectCode)
+	indexName := fmt.Sprintf("%v%x", indexPrefix, hash[:4])
+
+	ensuredKey := tenantCode + "." + indexName
+	if retryAt, isEnsured := r.searchIndexes.ensured.LoadOrStore(ensuredKey, time.Time{}); isEnsured {
+		if retryAt.(time.Time).IsZero() || time.Now().Before(retryAt.(time.Time)) {
+			return
+		}
+
+		if !r.searchIndexes.ensured.CompareAndSwap(ensuredKey, retryAt, time.Time{}) {
+			return
+		}
+	}
+
+	columns := make([]string, 0, len(search.fieldCodes))
+	for _, fieldCode := range search.fieldCodes {
+		columns = append(columns, dialect.QuoteIdentifier(fieldCode))
+	}
+
+	textSearch, _ := dialect.TextSearch(columns)
+	tableName := querybuilder.TableIn(dialect, tenantCode, objectCode)
+
+	go func() {
+		createIndex := fmt.Sprintf("CREATE INDEX CONCURRENTLY IF NOT EXISTS %v ON %v USING GIN (%v)", dialect.QuoteIdentifier(indexName), tableName, textSearch.Document())
+		if err := db.Exec(createIndex).Error; err != nil {
+			log.Printf("failed to build search index %v of %v.%v: %v", indexName, tenantCode, objectCode, err)
+
+			// a failed concurrent build leaves an invalid index behind, the next try would take it as built
+			db.Exec(fmt.Sprintf("DROP INDEX CONCURRENTLY IF EXISTS %v", querybuilder.TableIn(dialect, tenantCode, indexName)))
+			r.searchIndexes.ensured.Store(ensuredKey, time.Now().Add(searchIndexRetryInterval))
+			return
+		}
+
+		var staleIndexes []string
+		err := db.Raw("SELECT indexname FROM pg_indexes WHERE schemaname = ? AND tablename = ? AND indexname LIKE ? AND indexname != ?",
+			tenantCode, objectCode, strings.ReplaceAll(indexPrefix, "_", `\_`)+"%", indexName).Scan(&staleIndexes).Error
+		if err != nil {
+			log.Printf("failed to list search indexes of %v.%v: %v", tenantCode, objectCode, err)
+			return
+		}
+
+		for _, staleIndex := range staleIndexes {
+			dropIndex := fmt.Sprintf("DROP INDEX CONCURRENTLY IF EXISTS %v", querybuilder.TableIn(dialect, tenantCode, staleIndex))
+			if err := db.Exec(dropIndex).Error; err != nil {
+				log.Printf("failed to drop search index %v of %v.%v: %v", staleIndex, tenantCode, objectCode, err)
+			}
+		}
+	}()
+}
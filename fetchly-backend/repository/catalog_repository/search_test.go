@@ -0,0 +1,127 @@
+package catalogrepository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+	querybuilder "github.com/fetchlydev/source/fetchly-backend/repository/query_builder"
+)
+
+func TestResolveSearch(t *testing.T) {
+	tableColumns := querybuilder.NewColumns([]map[string]any{
+		{entity.FieldColumnCode: "name", entity.FieldDataType: "text"},
+		{entity.FieldColumnCode: "email", entity.FieldDataType: "varchar(255)"},
+		{entity.FieldColumnCode: "created_at", entity.FieldDataType: "timestamptz"},
+	})
+
+	tests := []struct {
+		name           string
+		dialect        querybuilder.Dialect
+		searchable     []string
+		request        entity.CatalogQuery
+		wantNil        bool
+		wantErr        error
+		wantCondition  string
+		wantArgs       []any
+		wantIsFullText bool
+	}{
+		{
+			name:    "no search",
+			dialect: querybuilder.Postgres,
+			request: entity.CatalogQuery{Search: "  "},
+			wantNil: true,
+		},
+		{
+			name:           "full text on postgres",
+			dialect:        querybuilder.Postgres,
+			searchable:     []string{"email", "name"},
+			request:        entity.CatalogQuery{Search: " ann "},
+			wantCondition:  `to_tsvector('simple', COALESCE("acme"."contact"."email"::text, '') || ' ' || COALESCE("acme"."contact"."name"::text, '')) @@ websearch_to_tsquery('simple', ?)`,
+			wantArgs:       []any{"ann"},
+			wantIsFullText: true,
+		},
+		{
+			name:          "every word in any column elsewhere",
+			dialect:       querybuilder.SQLite,
+			searchable:    []string{"email", "name"},
+			request:       entity.CatalogQuery{Search: "Ann  Lee"},
+			wantCondition: `((LOWER("contact"."email") LIKE ? ESCAPE '\' OR LOWER("contact"."name") LIKE ? ESCAPE '\') AND (LOWER("contact"."email") LIKE ? ESCAPE '\' OR LOWER("contact"."name") LIKE ? ESCAPE '\'))`,
+			wantArgs:      []any{"%ann%", "%ann%", "%lee%", "%lee%"},
+		},
+		{
+			name:          "excluded field",
+			dialect:       querybuilder.MySQL,
+			searchable:    []string{"email", "name"},
+			request:       entity.CatalogQuery{Search: "ann", ExcludedSearchFields: []string{"email"}},
+			wantCondition: "((LOWER(`contact`.`name`) LIKE ? ESCAPE '\\\\'))",
+			wantArgs:      []any{"%ann%"},
+		},
+		{
+			name:          "wildcards of the search",
+			dialect:       querybuilder.SQLite,
+			searchable:    []string{"name"},
+			request:       entity.CatalogQuery{Search: `50%_off a\b`},
+			wantCondition: `((LOWER("contact"."name") LIKE ? ESCAPE '\') AND (LOWER("contact"."name") LIKE ? ESCAPE '\'))`,
+			wantArgs:      []any{`%50\%\_off%`, `%a\\b%`},
+		},
+		{
+			name:           "fields of other types are not searched",
+			dialect:        querybuilder.Postgres,
+			searchable:     []string{"created_at", "name"},
+			request:        entity.CatalogQuery{Search: "ann"},
+			wantCondition:  `to_tsvector('simple', COALESCE("acme"."contact"."name"::text, '')) @@ websearch_to_tsquery('simple', ?)`,
+			wantArgs:       []any{"ann"},
+			wantIsFullText: true,
+		},
+		{
+			name:       "only fields of other types",
+			dialect:    querybuilder.Postgres,
+			searchable: []string{"created_at"},
+			request:    entity.CatalogQuery{Search: "ann"},
+			wantErr:    entity.ErrorBadRequest,
+		},
+		{
+			name:       "every field excluded",
+			dialect:    querybuilder.Postgres,
+			searchable: []string{"name"},
+			request:    entity.CatalogQuery{Search: "ann", ExcludedSearchFields: []string{"name"}},
+			wantErr:    entity.ErrorBadRequest,
+		},
+		{
+			name:    "no searchable field",
+			dialect: querybuilder.Postgres,
+			request: entity.CatalogQuery{Search: "ann"},
+			wantErr: entity.ErrorBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &repository{searchIndexes: newSearchIndexes(time.Hour)}
+			r.searchIndexes.fields["acme.contact"] = searchableFields{fieldCodes: tt.searchable, expiresAt: time.Now().Add(time.Hour)}
+
+			tt.request.TenantCode = "acme"
+			tt.request.ObjectCode = "contact"
+			search, err := r.resolveSearch(context.Background(), tt.dialect, tt.dialect.TableName("acme", "contact"), tt.request, tableColumns)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("resolveSearch() error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantNil || tt.wantErr != nil {
+				if search != nil {
+					t.Errorf("resolveSearch() = %+v, want nil", search)
+				}
+				return
+			}
+
+			if search.isFullText != tt.wantIsFullText {
+				t.Errorf("full text %v, want %v", search.isFullText, tt.wantIsFullText)
+			}
+
+			assertExpr(t, search.condition(tt.dialect), tt.wantCondition, tt.wantArgs)
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package catalogrepository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+	"github.com/fetchlydev/source/fetchly-backend/pkg/helper"
+	querybuilder "github.com/fetchlydev/source/fetchly-backend/repository/query_builder"
+	"gorm.io/gorm"
+)
+
+// GetTenantByCode returns a tenant, ErrorNotFound when there is none
+func (r *repository) GetTenantByCode(ctx context.Context, tenantCode string) (resp entity.Tenants, err error) {
+	tenant, err := viewTenant(r.db.WithContext(ctx), tenantCode)
+	if err != nil {
+		return resp, err
+	}
+
+	return entity.Tenants{
+		ID:           int32(tenant.ID),
+		Serial:       tenant.Serial,
+		Code:         tenant.Code,
+		Name:         tenant.Name,
+		TenantConfig: tenant.TenantConfig,
+	}, nil
+}
+
+// CreateTenant creates a tenant along with its schema. A tenant code is never reused, an archived tenant keeps its
+// code.
+func (r *repository) CreateTenant(ctx context.Context, request entity.Tenants, userSerial string) (err error) {
+	metadataDB := r.db.WithContext(ctx)
+
+	var existingTenants int64
+	if err := metadataDB.Unscoped().Model(&Tenants{}).Where("code = ?", request.Code).Count(&existingTenants).Error; err != nil {
+		return fmt.Errorf("failed to check tenant %v: %w", request.Code, err)
+	}
+
+	if existingTenants > 0 {
+		return fmt.Errorf("%w: tenant %v already exists", entity.ErrorBadRequest, request.Code)
+	}
+
+	tenant := Tenants{
+		CreatedBy:    userSerial,
+		UpdatedBy:    userSerial,
+		Code:         request.Code,
+		Name:         request.Name,
+		TenantConfig: request.TenantConfig,
+	}
+
+	if tenant.TenantConfig == nil {
+		tenant.TenantConfig = map[string]any{}
+	}
+
+	tenant.Serial, err = helper.GenerateUUUID()
+	if err != nil {
+		return err
+	}
+
+	return metadataDB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("id").Create(&tenant).Error; err != nil {
+			return fmt.Errorf("failed to create tenant %v: %w", request.Code, err)
+		}
+
+		return r.execSchema(tx, tx, nil, "", fmt.Sprintf("CREATE SCHEMA %v", querybuilder.QuoteIdentifier(request.Code)))
+	})
+}
+
+// CopyObjectData copies the rows of an object of a tenant into the table of the same object of another tenant, the
+// columns the two tables share. Both tables are in the metadata database, the tables of a data source are shared by
+// the tenants.
+func (r *repository) CopyObjectData(ctx context.Context, sourceTenantCode, tenantCode, objectCode string) (copied int64, err error) {
+	for _, code := range []string{sourceTenantCode, tenantCode} {
+		dataSource, err := r.dataSources.resolve(ctx, r.db, code, objectCode)
+		if err != nil {
+			return 0, err
+		}
+
+		if dataSource != nil {
+			return 0, fmt.Errorf("%w: object %v of tenant %v is in a data source, its rows are not copied", entity.ErrorBadRequest, objectCode, code)
+		}
+	}
+
+	sourceColumns, err := r.getTableColumns(ctx, sourceTenantCode, objectCode)
+	if err != nil {
+		return 0, err
+	}
+
+	tableColumns, err := r.getTableColumns(ctx, tenantCode, objectCode)
+	if err != nil {
+		return 0, err
+	}
+
+	var columns []string
+	for column := range tableColumns {
+		if sourceColumns.Has(column) {
+			columns = append(columns, querybuilder.QuoteIdentifier(column))
+		}
+	}
+
+	sort.Strings(columns)
+
+	columnList := strings.Join(columns, ", ")
+	statement := fmt.Sprintf("INSERT INTO %v (%v) SELECT %v FROM %v", querybuilder.Table(tenantCode, objectCode), columnList, columnList, querybuilder.Table(sourceTenantCode, objectCode))
+
+	result := r.db.WithContext(ctx).Exec(statement)
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to copy the rows of object %v: %w", objectCode, result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
+
+// StreamObjectRows reads every row of the table of an object as it is stored, the soft deleted ones included,
+// passing every batchSize rows to fn
+func (r *repository) StreamObjectRows(ctx context.Context, tenantCode, objectCode string, batchSize int, fn func(rows []map[string]any) error) (err error) {
+	db, dialect, err := r.dataDB(ctx, tenantCode, objectCode)
+	if err != nil {
+		return err
+	}
+
+	if batchSize < 1 {
+		batchSize = 1000
+	}
+
+	db = db.WithContext(ctx)
+	rows, err := db.Raw(fmt.Sprintf("SELECT * FROM %v", querybuilder.TableIn(dialect, tenantCode, objectCode))).Rows()
+	if err != nil {
+		return fmt.Errorf("failed to read the rows of object %v: %w", objectCode, err)
+	}
+	defer rows.Close()
+
+	batch := make([]map[string]any, 0, batchSize)
+	for rows.Next() {
+		row, err := scanTableRow(db, rows)
+		if err != nil {
+			return err
+		}
+
+		batch = append(batch, row)
+		if len(batch) < batchSize {
+			continue
+		}
+
+		if err := fn(batch); err != nil {
+			return err
+		}
+
+		batch = make([]map[string]any, 0, batchSize)
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if len(batch) == 0 {
+		return nil
+	}
+
+	return fn(batch)
+}
+
+// ArchiveTenant deletes a tenant, it is not found anymore and none of its users can sign in. Its schema and the
+// metadata of its objects are kept until DropTenantSchema.
+func (r *repository) ArchiveTenant(ctx context.Context, tenantCode, userSerial string) (err error) {
+	if err := entity.ValidateTenantCode(tenantCode); err != nil {
+		return err
+	}
+
+	tenant, err := viewTenant(r.db.WithContext(ctx), tenantCode)
+	if err != nil {
+		return err
+	}
+
+	objectCodes, err := r.GetObjectCodes(ctx, tenantCode, "")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		for _, objectCode := range objectCodes {
+			r.forgetObject(tenantCode, objectCode)
+		}
+	}()
+
+	deleted := map[string]any{"deleted_at": time.Now(), "deleted_by": userSerial}
+	if err := r.db.WithContext(ctx).Model(&Tenants{}).Where("serial = ?", tenant.Serial).Updates(deleted).Error; err != nil {
+		return fmt.Errorf("failed to archive tenant %v: %w", tenantCode, err)
+	}
+
+	return nil
+}
+
+// RestoreTenant undoes the archiving of a tenant
+func (r *repository) RestoreTenant(ctx context.Context, tenantCode string) (err error) {
+	restored := map[string]any{"deleted_at": nil, "deleted_by": nil}
+	err = r.db.WithContext(ctx).Unscoped().Model(&Tenants{}).Where("code = ? AND deleted_at IS NOT NULL", tenantCode).Updates(restored).Error
+	if err != nil {
+		return fmt.Errorf("failed to restore tenant %v: %w", tenantCode, err)
+	}
+
+	return nil
+}
+
+// DropTenantSchema drops the schema of an archived tenant along with the rows of its objects
+func (r *repository) DropTenantSchema(ctx context.Context, tenantCode string) (err error) {
+	if err := entity.ValidateTenantCode(tenantCode); err != nil {
+		return err
+	}
+
+	var archivedTenants int64
+	err = r.db.WithContext(ctx).Unscoped().Model(&Tenants{}).Where("code = ? AND deleted_at IS NOT NULL", tenantCode).Count(&archivedTenants).Error
+	if err != nil {
+		return fmt.Errorf("failed to get tenant %v: %w", tenantCode, err)
+	}
+
+	if archivedTenants == 0 {
+		return fmt.Errorf("%w: tenant %v is not archived", entity.ErrorBadRequest, tenantCode)
+	}
+
+	db := r.db.WithContext(ctx)
+	return r.execSchema(db, db, nil, "", fmt.Sprintf("DROP SCHEMA %v CASCADE", querybuilder.QuoteIdentifier(tenantCode)))
+}
@@ -0,0 +1,210 @@
+package catalogrepository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+	querybuilder "github.com/fetchlydev/source/fetchly-backend/repository/query_builder"
+	"gorm.io/gorm"
+)
+
+// SetObjectDataDeleted soft deletes the live records of the request, or restores its soft deleted ones, and
+// returns them as they were before. The audit columns the table has are set along.
+func (r *repository) SetObjectDataDeleted(ctx context.Context, request entity.DeletionRequest) (resp []map[string]any, err error) {
+	if len(request.Serials) == 0 && len(request.ParentKeys) == 0 {
+		return resp, nil
+	}
+
+	db, dialect, err := r.dataDB(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return resp, err
+	}
+
+	tableColumns, err := r.getTableColumns(ctx, request.TenantCode, request.ObjectCode)
+	if err != nil {
+		return resp, err
+	}
+
+	if !tableColumns.Has("deleted_at") {
+		return resp, fmt.Errorf("%w: object %v has no deleted_at column, its records are not soft deleted", entity.ErrorBadRequest, request.ObjectCode)
+	}
+
+	tableName := dialect.TableName(request.TenantCode, request.ObjectCode)
+	table := querybuilder.QuoteReferenceIn(dialect, tableName)
+	identifierColumn := fmt.Sprintf("%v.%v", table, dialect.QuoteIdentifier(entity.DEFAULT_IDENTIFIER))
+
+	conditions := []querybuilder.Expr{}
+	if len(request.Serials) > 0 {
+		serials := make([]any, 0, len(request.Serials))
+		for _, serial := range request.Serials {
+			serials = append(serials, serial)
+		}
+
+		conditions = append(conditions, querybuilder.NewExpr(fmt.Sprintf("%v IN (%v)", identifierColumn, querybuilder.Placeholders(len(serials))), serials...))
+	}
+
+	if request.ForeignKey != "" {
+		foreignKeyColumn, err := tableColumns.ReferenceIn(dialect, tableName, request.ForeignKey)
+		if err != nil {
+			return resp, err
+		}
+
+		parentKeys := make([]any, 0, len(request.ParentKeys))
+		for _, parentKey := range request.ParentKeys {
+			parentKeys = append(parentKeys, querybuilder.BindValue(tableColumns.DataType(request.ForeignKey), parentKey))
+		}
+
+		conditions = append(conditions, querybuilder.NewExpr(fmt.Sprintf("%v IN (%v)", foreignKeyColumn, querybuilder.Placeholders(len(parentKeys))), parentKeys...))
+	}
+
+	var stateCondition querybuilder.Expr
+	switch {
+	case !request.IsRestore:
+		stateCondition = querybuilder.NewExpr(fmt.Sprintf("%v.deleted_at IS NULL", table))
+	case request.DeletedAt != nil:
+		stateCondition = querybuilder.NewExpr(fmt.Sprintf("%v.deleted_at = ?", table), request.DeletedAt)
+	default:
+		stateCondition = querybuilder.NewExpr(fmt.Sprintf("%v.deleted_at IS NOT NULL", table))
+	}
+
+	conditions = append(conditions, stateCondition)
+
+	rowFilterExpr, err := r.buildRowFilterCondition(ctx, dialect, entity.DataMutationRequest{
+		TenantCode: request.TenantCode,
+		ObjectCode: request.ObjectCode,
+		Filters:    request.Filters,
+	})
+	if err != nil {
+		return resp, err
+	}
+
+	conditions = append(conditions, rowFilterExpr)
+
+	selectQuery := querybuilder.New(fmt.Sprintf("SELECT * FROM %v", table))
+	selectQuery.WriteExpr(" WHERE ", querybuilder.Join(conditions, " AND "))
+
+	rows, err := db.WithContext(ctx).Raw(selectQuery.SQL(), selectQuery.Args()...).Rows()
+	if err != nil {
+		return resp, err
+	}
+	defer rows.Close()
+
+	serials := []any{}
+	for rows.Next() {
+		row, err := scanTableRow(db, rows)
+		if err != nil {
+			return resp, err
+		}
+
+		resp = append(resp, row)
+		serials = append(serials, row[entity.DEFAULT_IDENTIFIER])
+	}
+
+	if err := rows.Err(); err != nil {
+		return resp, err
+	}
+
+	if len(resp) == 0 {
+		return resp, nil
+	}
+
+	setClauses := []string{"deleted_at = ?"}
+	values := []any{request.DeletedAt}
+	if request.IsRestore {
+		setClauses = []string{"deleted_at = NULL"}
+		values = []any{}
+	}
+
+	if tableColumns.Has("deleted_by") {
+		if request.IsRestore {
+			setClauses = append(setClauses, fmt.Sprintf("%v = NULL", dialect.QuoteIdentifier("deleted_by")))
+		} else if request.UserSerial != "" {
+			setClauses = append(setClauses, fmt.Sprintf("%v = ?", dialect.QuoteIdentifier("deleted_by")))
+			values = append(values, querybuilder.BindValue(tableColumns.DataType("deleted_by"), request.UserSerial))
+		}
+	}
+
+	// a restore is a change of the record like any other
+	if request.IsRestore && tableColumns.Has("updated_at") {
+		setClauses = append(setClauses, fmt.Sprintf("updated_at = %v", dialect.Now()))
+	}
+
+	if request.IsRestore && tableColumns.Has("updated_by") && request.UserSerial != "" {
+		setClauses = append(setClauses, fmt.Sprintf("%v = ?", dialect.QuoteIdentifier("updated_by")))
+		values = append(values, querybuilder.BindValue(tableColumns.DataType("updated_by"), request.UserSerial))
+	}
+
+	// the records are only changed while still in the state they were read in, a record deleted or restored by
+	// another request in between fails the change so the caller rolls back what it did with the records
+	updateQuery := querybuilder.New(fmt.Sprintf("UPDATE %v SET %v", table, strings.Join(setClauses, ", ")), values...)
+	updateQuery.WriteExpr(" WHERE ", querybuilder.Join([]querybuilder.Expr{
+		querybuilder.NewExpr(fmt.Sprintf("%v IN (%v)", identifierColumn, querybuilder.Placeholders(len(serials))), serials...),
+		stateCondition,
+	}, " AND "))
+
+	result := db.WithContext(ctx).Exec(updateQuery.SQL(), updateQuery.Args()...)
+	if result.Error != nil {
+		return resp, result.Error
+	}
+
+	if result.RowsAffected != int64(len(serials)) {
+		return resp, fmt.Errorf("%w: %d of %d records of object %v were changed meanwhile", entity.ErrorNoUpdateDataFound, int64(len(serials))-result.RowsAffected, len(serials), request.ObjectCode)
+	}
+
+	return resp, nil
+}
+
+// PurgeObjectData deletes for good the records of an object soft deleted before deletedBefore
+func (r *repository) PurgeObjectData(ctx context.Context, tenantCode, objectCode string, deletedBefore time.Time) (purged int64, err error) {
+	db, dialect, err := r.dataDB(ctx, tenantCode, objectCode)
+	if err != nil {
+		return 0, err
+	}
+
+	tableColumns, err := r.getTableColumns(ctx, tenantCode, objectCode)
+	if err != nil {
+		return 0, err
+	}
+
+	if !tableColumns.Has("deleted_at") {
+		return 0, nil
+	}
+
+	table := querybuilder.TableIn(dialect, tenantCode, objectCode)
+	result := db.WithContext(ctx).Exec(fmt.Sprintf("DELETE FROM %v WHERE %v.deleted_at < ?", table, table), deletedBefore)
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to purge object %v of tenant %v: %w", objectCode, tenantCode, result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
+
+// GetTenantCodes lists the codes of the tenants, the archived ones left out
+func (r *repository) GetTenantCodes(ctx context.Context) (resp []string, err error) {
+	if err := r.db.WithContext(ctx).Model(&Tenants{}).Order("id").Pluck("code", &resp).Error; err != nil {
+		return resp, fmt.Errorf("failed to get tenants: %w", err)
+	}
+
+	return resp, nil
+}
+
+// scanTableRow scans the current row of a SELECT * into a map by column, the text some drivers return as bytes
+// as a string
+func scanTableRow(db *gorm.DB, rows *sql.Rows) (map[string]any, error) {
+	row := map[string]any{}
+	if err := db.ScanRows(rows, &row); err != nil {
+		return nil, err
+	}
+
+	for column, value := range row {
+		if valueBytes, ok := value.([]byte); ok {
+			row[column] = string(valueBytes)
+		}
+	}
+
+	return row, nil
+}
@@ -0,0 +1,205 @@
+package catalogrepository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+	"gorm.io/gorm"
+)
+
+// newTrashTestRepository returns the test repository with records of object remote, 1 and 2 live, 3 deleted at
+// firstDeletion and 4 at secondDeletion. 2 and 3 are the children of record p.
+func newTrashTestRepository(t *testing.T, firstDeletion, secondDeletion time.Time) (*repository, *gorm.DB) {
+	t.Helper()
+
+	r := newTestRepository(t)
+
+	db, _, err := r.dataDB(context.Background(), "acme", "remote")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.Exec(`CREATE TABLE remote (serial TEXT, parent_serial TEXT, deleted_at DATETIME, deleted_by TEXT)`).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Exec(`INSERT INTO remote VALUES ('1', NULL, NULL, NULL), ('2', 'p', NULL, NULL), ('3', 'p', ?, 'ann'), ('4', NULL, ?, 'ann')`,
+		firstDeletion, secondDeletion).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return r, db
+}
+
+// deletedSerials lists the serials of the soft deleted records of object remote
+func deletedSerials(t *testing.T, db *gorm.DB) []string {
+	t.Helper()
+
+	var serials []string
+	if err := db.Raw("SELECT serial FROM remote WHERE deleted_at IS NOT NULL ORDER BY serial").Scan(&serials).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	return serials
+}
+
+func TestSetObjectDataDeleted(t *testing.T) {
+	firstDeletion := time.Date(2024, time.May, 1, 10, 0, 0, 0, time.UTC)
+	secondDeletion := time.Date(2024, time.May, 2, 10, 0, 0, 0, time.UTC)
+	now := time.Date(2024, time.May, 3, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		request     entity.DeletionRequest
+		wantRows    []string
+		wantDeleted []string
+	}{
+		{
+			name:        "delete skips the deleted records",
+			request:     entity.DeletionRequest{Serials: []string{"1", "3"}, DeletedAt: now, UserSerial: "bob"},
+			wantRows:    []string{"1"},
+			wantDeleted: []string{"1", "3", "4"},
+		},
+		{
+			name:        "delete the children of a record",
+			request:     entity.DeletionRequest{ForeignKey: "parent_serial", ParentKeys: []any{"p"}, DeletedAt: now},
+			wantRows:    []string{"2"},
+			wantDeleted: []string{"2", "3", "4"},
+		},
+		{
+			name:        "restore skips the live records",
+			request:     entity.DeletionRequest{Serials: []string{"1", "3", "4"}, IsRestore: true},
+			wantRows:    []string{"3", "4"},
+			wantDeleted: []string{},
+		},
+		{
+			name:        "restore the children deleted along with a record",
+			request:     entity.DeletionRequest{ForeignKey: "parent_serial", ParentKeys: []any{"p"}, DeletedAt: firstDeletion, IsRestore: true},
+			wantRows:    []string{"3"},
+			wantDeleted: []string{"4"},
+		},
+		{
+			name:        "restore leaves the children deleted at another time",
+			request:     entity.DeletionRequest{ForeignKey: "parent_serial", ParentKeys: []any{"p"}, DeletedAt: secondDeletion, IsRestore: true},
+			wantRows:    []string{},
+			wantDeleted: []string{"3", "4"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, db := newTrashTestRepository(t, firstDeletion, secondDeletion)
+
+			tt.request.TenantCode = "acme"
+			tt.request.ObjectCode = "remote"
+			rows, err := r.SetObjectDataDeleted(context.Background(), tt.request)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			serials := []string{}
+			for _, row := range rows {
+				serials = append(serials, fmt.Sprint(row[entity.DEFAULT_IDENTIFIER]))
+			}
+			sort.Strings(serials)
+
+			if fmt.Sprint(serials) != fmt.Sprint(tt.wantRows) {
+				t.Errorf("SetObjectDataDeleted() = %v, want %v", serials, tt.wantRows)
+			}
+
+			if got := deletedSerials(t, db); fmt.Sprint(got) != fmt.Sprint(tt.wantDeleted) {
+				t.Errorf("deleted records %v, want %v", got, tt.wantDeleted)
+			}
+		})
+	}
+}
+
+func TestSetObjectDataDeletedChangedMeanwhile(t *testing.T) {
+	firstDeletion := time.Date(2024, time.May, 1, 10, 0, 0, 0, time.UTC)
+	secondDeletion := time.Date(2024, time.May, 2, 10, 0, 0, 0, time.UTC)
+
+	r, db := newTrashTestRepository(t, firstDeletion, secondDeletion)
+
+	// another request deletes record 2 after the records are read and before they are updated
+	isDeletedMeanwhile := false
+	err := db.Callback().Raw().Before("gorm:raw").Register("test:delete_meanwhile", func(tx *gorm.DB) {
+		if isDeletedMeanwhile || !strings.HasPrefix(tx.Statement.SQL.String(), "UPDATE") {
+			return
+		}
+
+		isDeletedMeanwhile = true
+		if err := tx.Session(&gorm.Session{NewDB: true}).Exec("UPDATE remote SET deleted_at = ? WHERE serial = '2'", secondDeletion).Error; err != nil {
+			t.Error(err)
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = r.SetObjectDataDeleted(context.Background(), entity.DeletionRequest{
+		TenantCode: "acme",
+		ObjectCode: "remote",
+		Serials:    []string{"1", "2"},
+		DeletedAt:  time.Now(),
+	})
+	if !errors.Is(err, entity.ErrorNoUpdateDataFound) {
+		t.Fatalf("SetObjectDataDeleted() error = %v, want %v", err, entity.ErrorNoUpdateDataFound)
+	}
+
+	// record 2 keeps the deletion of the other request
+	var deletedAt time.Time
+	if err := db.Raw("SELECT deleted_at FROM remote WHERE serial = '2'").Scan(&deletedAt).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if !deletedAt.Equal(secondDeletion) {
+		t.Errorf("record 2 deleted at %v, want %v", deletedAt, secondDeletion)
+	}
+}
+
+func TestPurgeObjectData(t *testing.T) {
+	firstDeletion := time.Date(2024, time.May, 1, 10, 0, 0, 0, time.UTC)
+	secondDeletion := time.Date(2024, time.May, 2, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		deletedBefore time.Time
+		wantPurged    int64
+		wantLeft      []string
+	}{
+		{name: "nothing deleted before", deletedBefore: firstDeletion, wantLeft: []string{"1", "2", "3", "4"}},
+		{name: "records deleted before", deletedBefore: secondDeletion, wantPurged: 1, wantLeft: []string{"1", "2", "4"}},
+		{name: "every deleted record", deletedBefore: secondDeletion.Add(time.Hour), wantPurged: 2, wantLeft: []string{"1", "2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, db := newTrashTestRepository(t, firstDeletion, secondDeletion)
+
+			purged, err := r.PurgeObjectData(context.Background(), "acme", "remote", tt.deletedBefore)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if purged != tt.wantPurged {
+				t.Errorf("PurgeObjectData() = %d, want %d", purged, tt.wantPurged)
+			}
+
+			var left []string
+			if err := db.Raw("SELECT serial FROM remote ORDER BY serial").Scan(&left).Error; err != nil {
+				t.Fatal(err)
+			}
+
+			if fmt.Sprint(left) != fmt.Sprint(tt.wantLeft) {
+				t.Errorf("records left %v, want %v", left, tt.wantLeft)
+			}
+		})
+	}
+}
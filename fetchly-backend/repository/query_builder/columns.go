@@ -0,0 +1,68 @@
+package querybuilder
+
+import (
+	"fmt"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+)
+
+// Columns is the allow-list of a table's columns as reported by information_schema,
+// mapping the column code to its udt data type.
+type Columns map[string]string
+
+// NewColumns builds the allow-list from the column maps produced by GetColumnList
+func NewColumns(columnList []map[string]any) Columns {
+	columns := make(Columns)
+
+	for _, column := range columnList {
+		code, ok := asString(column[entity.FieldColumnCode])
+		if !ok {
+			continue
+		}
+
+		dataType, _ := asString(column[entity.FieldDataType])
+		columns[code] = dataType
+	}
+
+	return columns
+}
+
+// asString reads an introspected value, some drivers scan text columns as bytes
+func asString(value any) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case []byte:
+		return string(v), true
+	}
+
+	return "", false
+}
+
+func (c Columns) Has(column string) bool {
+	_, ok := c[column]
+	return ok
+}
+
+func (c Columns) DataType(column string) string {
+	return c[column]
+}
+
+// Reference returns the quoted "table"."column" reference, or an error when the
+// column does not exist in the table.
+func (c Columns) Reference(table, column string) (string, error) {
+	if !c.Has(column) {
+		return "", fmt.Errorf("field %v is not found in table %v", column, table)
+	}
+
+	return QuoteReference(table) + "." + QuoteIdentifier(column), nil
+}
+
+// ReferenceIn is Reference quoted with the quoting of dialect
+func (c Columns) ReferenceIn(dialect Dialect, table, column string) (string, error) {
+	if !c.Has(column) {
+		return "", fmt.Errorf("field %v is not found in table %v", column, table)
+	}
+
+	return QuoteReferenceIn(dialect, table) + "." + dialect.QuoteIdentifier(column), nil
+}
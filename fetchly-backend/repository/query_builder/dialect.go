@@ -0,0 +1,492 @@
+package querybuilder
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+)
+
+const (
+	DialectPostgres = "postgres"
+	DialectMySQL    = "mysql"
+	DialectSQLite   = "sqlite"
+)
+
+// Dialect covers the SQL that differs between the databases an object's table can live in. The catalog
+// queries are written once with "?" placeholders and ask the dialect for identifiers, operators,
+// pagination, timestamps and the introspection queries.
+type Dialect interface {
+	Name() string
+	QuoteIdentifier(name string) string
+	// QuoteLiteral returns value as a string literal
+	QuoteLiteral(value string) string
+	// TableName returns the unquoted, dotted reference of the table of an object of a tenant
+	TableName(schema, table string) string
+	// Operator returns the SQL operator of a filter operator
+	Operator(operator entity.FilterOperator) (string, bool)
+	// OperatorCondition returns the condition of a filter operator whose SQL differs between the databases beyond
+	// the operator, the case sensitive contains and the json operators. False when the database can not apply it.
+	OperatorCondition(column string, operator entity.FilterOperator, value any) (Expr, bool)
+	// Paginate appends the LIMIT/OFFSET clause to query
+	Paginate(query *Query, limit, offset int)
+	// LikeEscape is the ESCAPE clause of a LIKE pattern escaped with EscapeLike
+	LikeEscape() string
+	// Now is the expression of the current timestamp
+	Now() string
+	// DateBucket truncates a date or timestamp expression to the start of its bucket
+	DateBucket(expression string, bucket entity.DateBucket) (string, bool)
+	// NullsLast reports whether NULL sorts after every value in ascending order
+	NullsLast() bool
+	// EstimatedCountQuery returns the row count of the table from the statistics of the database, false when
+	// the database keeps none
+	EstimatedCountQuery(schema, table string) (Expr, bool)
+	// ColumnListQuery lists field_code, data_type, foreign_table_name and foreign_field_name of every column of the table
+	ColumnListQuery(schema, table string) Expr
+	// TableColumnsQuery lists field_code and data_type of every column of the table
+	TableColumnsQuery(schema, table string) Expr
+	// ForeignKeyQuery returns foreign_schema, foreign_table and foreign_column referenced by a column
+	ForeignKeyQuery(schema, table, column string) Expr
+	// SupportsReturning reports whether INSERT ... RETURNING is available
+	SupportsReturning() bool
+	// SupportsCursor reports whether DECLARE ... CURSOR is available
+	SupportsCursor() bool
+	// TextSearch returns the full text search over the columns, false when the database has none and a search
+	// falls back to LIKE on every column
+	TextSearch(columns []string) (TextSearch, bool)
+	// Concat concatenates the expressions as text, a NULL expression adds nothing
+	Concat(expressions []string) string
+	// ColumnType returns the column type of a primitive data type
+	ColumnType(primitiveDataType string) (string, bool)
+	// UUIDDefault is the default expression generating the serial of a record, false when the platform generates it
+	UUIDDefault() (string, bool)
+	// AddColumn returns the statements adding the column to the table with its constraints
+	AddColumn(schema, table string, column ColumnDefinition) ([]string, error)
+	// RenameColumn returns the statements renaming the column and its unique constraint
+	RenameColumn(schema, table string, column ColumnDefinition, name string) []string
+	// AlterColumn returns the statements changing the type, nullability, default and uniqueness of a column from
+	// its definition from into to, false when the database can not alter a column in place
+	AlterColumn(schema, table string, from, to ColumnDefinition) ([]string, bool)
+}
+
+// NewDialect returns the dialect of the named database, postgres when the name is empty
+func NewDialect(name string) (Dialect, error) {
+	switch strings.ToLower(name) {
+	case "", DialectPostgres, "postgresql":
+		return Postgres, nil
+	case DialectMySQL:
+		return MySQL, nil
+	case DialectSQLite, "sqlite3":
+		return SQLite, nil
+	}
+
+	return nil, fmt.Errorf("unsupported dialect %v", name)
+}
+
+// QuoteReferenceIn quotes a dotted reference such as tenant.object.column part by part with the quoting of dialect
+func QuoteReferenceIn(dialect Dialect, reference string) string {
+	parts := strings.Split(reference, ".")
+	for i, part := range parts {
+		parts[i] = dialect.QuoteIdentifier(part)
+	}
+
+	return strings.Join(parts, ".")
+}
+
+// TableIn returns the quoted reference of the table of an object in dialect
+func TableIn(dialect Dialect, schema, table string) string {
+	return QuoteReferenceIn(dialect, dialect.TableName(schema, table))
+}
+
+var (
+	Postgres Dialect = postgresDialect{}
+	MySQL    Dialect = mysqlDialect{}
+	SQLite   Dialect = sqliteDialect{}
+)
+
+// standardOperators is the operator mapping shared by the dialects, LIKE is case sensitive on postgres only
+var standardOperators = map[entity.FilterOperator]string{
+	entity.FilterOperatorEqual:            "=",
+	entity.FilterOperatorNotEqual:         "!=",
+	entity.FilterOperatorContains:         "LIKE",
+	entity.FilterOperatorNotContains:      "NOT LIKE",
+	entity.FilterOperatorGreaterThan:      ">",
+	entity.FilterOperatorGreaterThanEqual: ">=",
+	entity.FilterOperatorLessThan:         "<",
+	entity.FilterOperatorLessThanEqual:    "<=",
+	entity.FilterOperatorIN:               "IN",
+	entity.FilterOperatorNotIN:            "NOT IN",
+	entity.FilterOperatorStartsWith:       "LIKE",
+	entity.FilterOperatorEndsWith:         "LIKE",
+}
+
+// postgresDialect keeps every tenant in its own schema of the database
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string {
+	return DialectPostgres
+}
+
+func (postgresDialect) QuoteIdentifier(name string) string {
+	return QuoteIdentifier(name)
+}
+
+func (postgresDialect) QuoteLiteral(value string) string {
+	return QuoteLiteral(value)
+}
+
+func (postgresDialect) TableName(schema, table string) string {
+	return schema + "." + table
+}
+
+func (postgresDialect) Operator(operator entity.FilterOperator) (string, bool) {
+	sqlOperator, ok := entity.OperatorQueryMap[operator]
+	return sqlOperator, ok
+}
+
+func (postgresDialect) LikeEscape() string {
+	return `ESCAPE '\'`
+}
+
+func (postgresDialect) Paginate(query *Query, limit, offset int) {
+	query.Write(" LIMIT ? OFFSET ?", limit, offset)
+}
+
+func (postgresDialect) Now() string {
+	return "NOW()"
+}
+
+func (postgresDialect) DateBucket(expression string, bucket entity.DateBucket) (string, bool) {
+	switch bucket {
+	case entity.DateBucketDay, entity.DateBucketWeek, entity.DateBucketMonth:
+		return fmt.Sprintf("DATE_TRUNC('%s', %s)", bucket, expression), true
+	}
+
+	return "", false
+}
+
+func (postgresDialect) NullsLast() bool {
+	return true
+}
+
+// EstimatedCountQuery reads reltuples, which is -1 for a table that has never been analyzed
+func (postgresDialect) EstimatedCountQuery(schema, table string) (Expr, bool) {
+	return NewExpr("SELECT reltuples::bigint FROM pg_class WHERE oid = to_regclass(?)", Table(schema, table)), true
+}
+
+func (postgresDialect) ColumnListQuery(schema, table string) Expr {
+	return NewExpr(`
+	SELECT
+		col.column_name AS field_code,
+		col.udt_name AS data_type,
+		ccu.table_name AS foreign_table_name,
+		ccu.column_name AS foreign_field_name
+	FROM
+		information_schema.columns AS col
+	LEFT JOIN information_schema.key_column_usage AS kcu ON col.table_name = kcu.table_name
+	AND col.column_name = kcu.column_name
+	LEFT JOIN information_schema.constraint_column_usage AS ccu ON kcu.constraint_name = ccu.constraint_name
+	WHERE
+		col.table_schema = ?
+	AND col.table_name = ?
+	`, schema, table)
+}
+
+func (postgresDialect) TableColumnsQuery(schema, table string) Expr {
+	return NewExpr(`
+	SELECT
+		col.column_name AS field_code,
+		col.udt_name AS data_type
+	FROM
+		information_schema.columns AS col
+	WHERE
+		col.table_schema = ?
+		AND col.table_name = ?
+	`, schema, table)
+}
+
+func (postgresDialect) ForeignKeyQuery(schema, table, column string) Expr {
+	return NewExpr(`
+	SELECT
+		ccu.table_schema AS foreign_schema,
+		ccu.table_name   AS foreign_table,
+		ccu.column_name  AS foreign_column
+	FROM
+		information_schema.table_constraints AS tc
+		JOIN information_schema.key_column_usage AS kcu
+		  ON tc.constraint_name = kcu.constraint_name
+		 AND tc.constraint_schema = kcu.constraint_schema
+		JOIN information_schema.constraint_column_usage AS ccu
+		  ON ccu.constraint_name = tc.constraint_name
+		 AND ccu.constraint_schema = tc.constraint_schema
+	WHERE
+		tc.constraint_type = 'FOREIGN KEY'
+		AND kcu.column_name = ?
+		AND tc.table_name = ?
+		AND tc.table_schema = ?
+	LIMIT 1;
+	`, column, table, schema)
+}
+
+func (postgresDialect) SupportsReturning() bool {
+	return true
+}
+
+func (postgresDialect) SupportsCursor() bool {
+	return true
+}
+
+func (postgresDialect) TextSearch(columns []string) (TextSearch, bool) {
+	return TextSearch{columns: columns}, true
+}
+
+func (postgresDialect) Concat(expressions []string) string {
+	return concatText(expressions, "TEXT", " || ")
+}
+
+// mysqlDialect keeps the tables in the database of the data source, the tenant schema is not used
+type mysqlDialect struct{}
+
+func (mysqlDialect) Name() string {
+	return DialectMySQL
+}
+
+func (mysqlDialect) QuoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// QuoteLiteral escapes the backslash too, mysql reads it as an escape character in string literals
+func (mysqlDialect) QuoteLiteral(value string) string {
+	return QuoteLiteral(strings.ReplaceAll(value, `\`, `\\`))
+}
+
+func (mysqlDialect) TableName(_, table string) string {
+	return table
+}
+
+func (mysqlDialect) Operator(operator entity.FilterOperator) (string, bool) {
+	sqlOperator, ok := standardOperators[operator]
+	return sqlOperator, ok
+}
+
+// LikeEscape doubles the backslash, mysql reads it as an escape in string literals
+func (mysqlDialect) LikeEscape() string {
+	return `ESCAPE '\\'`
+}
+
+func (mysqlDialect) Paginate(query *Query, limit, offset int) {
+	query.Write(" LIMIT ? OFFSET ?", limit, offset)
+}
+
+func (mysqlDialect) Now() string {
+	return "NOW()"
+}
+
+func (mysqlDialect) DateBucket(expression string, bucket entity.DateBucket) (string, bool) {
+	switch bucket {
+	case entity.DateBucketDay:
+		return fmt.Sprintf("DATE(%s)", expression), true
+	case entity.DateBucketWeek:
+		return fmt.Sprintf("DATE_SUB(DATE(%s), INTERVAL WEEKDAY(%s) DAY)", expression, expression), true
+	case entity.DateBucketMonth:
+		return fmt.Sprintf("DATE_SUB(DATE(%s), INTERVAL DAYOFMONTH(%s) - 1 DAY)", expression, expression), true
+	}
+
+	return "", false
+}
+
+func (mysqlDialect) NullsLast() bool {
+	return false
+}
+
+func (mysqlDialect) EstimatedCountQuery(_, table string) (Expr, bool) {
+	return NewExpr("SELECT TABLE_ROWS FROM information_schema.TABLES WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?", table), true
+}
+
+func (mysqlDialect) ColumnListQuery(_, table string) Expr {
+	return NewExpr(`
+	SELECT
+		col.COLUMN_NAME AS field_code,
+		col.DATA_TYPE AS data_type,
+		kcu.REFERENCED_TABLE_NAME AS foreign_table_name,
+		kcu.REFERENCED_COLUMN_NAME AS foreign_field_name
+	FROM
+		information_schema.COLUMNS AS col
+	LEFT JOIN information_schema.KEY_COLUMN_USAGE AS kcu ON kcu.TABLE_SCHEMA = col.TABLE_SCHEMA
+	AND kcu.TABLE_NAME = col.TABLE_NAME
+	AND kcu.COLUMN_NAME = col.COLUMN_NAME
+	AND kcu.REFERENCED_TABLE_NAME IS NOT NULL
+	WHERE
+		col.TABLE_SCHEMA = DATABASE()
+	AND col.TABLE_NAME = ?
+	ORDER BY col.ORDINAL_POSITION
+	`, table)
+}
+
+func (mysqlDialect) TableColumnsQuery(_, table string) Expr {
+	return NewExpr(`
+	SELECT
+		col.COLUMN_NAME AS field_code,
+		col.DATA_TYPE AS data_type
+	FROM
+		information_schema.COLUMNS AS col
+	WHERE
+		col.TABLE_SCHEMA = DATABASE()
+		AND col.TABLE_NAME = ?
+	`, table)
+}
+
+func (mysqlDialect) ForeignKeyQuery(_, table, column string) Expr {
+	return NewExpr(`
+	SELECT
+		kcu.REFERENCED_TABLE_SCHEMA AS foreign_schema,
+		kcu.REFERENCED_TABLE_NAME AS foreign_table,
+		kcu.REFERENCED_COLUMN_NAME AS foreign_column
+	FROM
+		information_schema.KEY_COLUMN_USAGE AS kcu
+	WHERE
+		kcu.TABLE_SCHEMA = DATABASE()
+		AND kcu.TABLE_NAME = ?
+		AND kcu.COLUMN_NAME = ?
+		AND kcu.REFERENCED_TABLE_NAME IS NOT NULL
+	LIMIT 1
+	`, table, column)
+}
+
+func (mysqlDialect) SupportsReturning() bool {
+	return false
+}
+
+func (mysqlDialect) SupportsCursor() bool {
+	return false
+}
+
+func (mysqlDialect) TextSearch(_ []string) (TextSearch, bool) {
+	return TextSearch{}, false
+}
+
+// Concat uses CONCAT, || is a logical or on mysql
+func (mysqlDialect) Concat(expressions []string) string {
+	return "CONCAT(" + concatText(expressions, "CHAR", ", ") + ")"
+}
+
+// sqliteDialect keeps the tables in the main database of the file, the tenant schema is not used
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() string {
+	return DialectSQLite
+}
+
+func (sqliteDialect) QuoteIdentifier(name string) string {
+	return QuoteIdentifier(name)
+}
+
+func (sqliteDialect) QuoteLiteral(value string) string {
+	return QuoteLiteral(value)
+}
+
+func (sqliteDialect) TableName(_, table string) string {
+	return table
+}
+
+func (sqliteDialect) Operator(operator entity.FilterOperator) (string, bool) {
+	sqlOperator, ok := standardOperators[operator]
+	return sqlOperator, ok
+}
+
+func (sqliteDialect) LikeEscape() string {
+	return `ESCAPE '\'`
+}
+
+func (sqliteDialect) Paginate(query *Query, limit, offset int) {
+	query.Write(" LIMIT ? OFFSET ?", limit, offset)
+}
+
+func (sqliteDialect) Now() string {
+	return "CURRENT_TIMESTAMP"
+}
+
+func (sqliteDialect) DateBucket(expression string, bucket entity.DateBucket) (string, bool) {
+	switch bucket {
+	case entity.DateBucketDay:
+		return fmt.Sprintf("DATE(%s)", expression), true
+	case entity.DateBucketWeek:
+		return fmt.Sprintf("DATE(%s, 'weekday 0', '-6 days')", expression), true
+	case entity.DateBucketMonth:
+		return fmt.Sprintf("DATE(%s, 'start of month')", expression), true
+	}
+
+	return "", false
+}
+
+func (sqliteDialect) NullsLast() bool {
+	return false
+}
+
+func (sqliteDialect) EstimatedCountQuery(_, _ string) (Expr, bool) {
+	return Expr{}, false
+}
+
+func (sqliteDialect) ColumnListQuery(_, table string) Expr {
+	return NewExpr(`
+	SELECT
+		col.name AS field_code,
+		LOWER(col.type) AS data_type,
+		fk."table" AS foreign_table_name,
+		COALESCE(fk."to", 'serial') AS foreign_field_name
+	FROM
+		pragma_table_info(?) AS col
+	LEFT JOIN pragma_foreign_key_list(?) AS fk ON fk."from" = col.name
+	ORDER BY col.cid
+	`, table, table)
+}
+
+func (sqliteDialect) TableColumnsQuery(_, table string) Expr {
+	return NewExpr(`
+	SELECT
+		col.name AS field_code,
+		LOWER(col.type) AS data_type
+	FROM
+		pragma_table_info(?) AS col
+	`, table)
+}
+
+func (sqliteDialect) ForeignKeyQuery(_, table, column string) Expr {
+	return NewExpr(`
+	SELECT
+		'main' AS foreign_schema,
+		fk."table" AS foreign_table,
+		COALESCE(fk."to", 'serial') AS foreign_column
+	FROM
+		pragma_foreign_key_list(?) AS fk
+	WHERE
+		fk."from" = ?
+	LIMIT 1
+	`, table, column)
+}
+
+func (sqliteDialect) SupportsReturning() bool {
+	return true
+}
+
+func (sqliteDialect) SupportsCursor() bool {
+	return false
+}
+
+func (sqliteDialect) TextSearch(_ []string) (TextSearch, bool) {
+	return TextSearch{}, false
+}
+
+func (sqliteDialect) Concat(expressions []string) string {
+	return concatText(expressions, "TEXT", " || ")
+}
+
+// concatText casts every expression to textType, NULL as the empty string, and joins them with separator
+func concatText(expressions []string, textType, separator string) string {
+	parts := make([]string, 0, len(expressions))
+	for _, expression := range expressions {
+		parts = append(parts, fmt.Sprintf("COALESCE(CAST(%s AS %s), '')", expression, textType))
+	}
+
+	return strings.Join(parts, separator)
+}
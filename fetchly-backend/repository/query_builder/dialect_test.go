@@ -0,0 +1,190 @@
+package querybuilder
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+)
+
+func TestNewDialect(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{name: "", want: DialectPostgres},
+		{name: "PostgreSQL", want: DialectPostgres},
+		{name: "mysql", want: DialectMySQL},
+		{name: "sqlite3", want: DialectSQLite},
+		{name: "oracle", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dialect, err := NewDialect(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewDialect(%q) error = %v, want error %v", tt.name, err, tt.wantErr)
+			}
+
+			if err == nil && dialect.Name() != tt.want {
+				t.Errorf("NewDialect(%q) = %v, want %v", tt.name, dialect.Name(), tt.want)
+			}
+		})
+	}
+}
+
+func TestDialectSQL(t *testing.T) {
+	tests := []struct {
+		dialect       Dialect
+		table         string
+		identifier    string
+		literal       string
+		contains      string
+		monthBucket   string
+		caseSensitive Expr
+		concat        string
+		isNullsLast   bool
+		hasTextSearch bool
+		hasCursor     bool
+		hasEstimate   bool
+		hasReturning  bool
+	}{
+		{
+			dialect:       Postgres,
+			table:         `"acme"."contact"`,
+			identifier:    `"a""b"`,
+			literal:       `'a\''b'`,
+			contains:      "ILIKE",
+			monthBucket:   "DATE_TRUNC('month', created_at)",
+			caseSensitive: NewExpr(`"name" LIKE ? ESCAPE '\'`, "%Ann%"),
+			concat:        `COALESCE(CAST("a" AS TEXT), '') || COALESCE(CAST("b" AS TEXT), '')`,
+			isNullsLast:   true,
+			hasTextSearch: true,
+			hasCursor:     true,
+			hasEstimate:   true,
+			hasReturning:  true,
+		},
+		{
+			dialect:       MySQL,
+			table:         "`contact`",
+			identifier:    "`a\"b`",
+			literal:       `'a\\''b'`,
+			contains:      "LIKE",
+			monthBucket:   "DATE_SUB(DATE(created_at), INTERVAL DAYOFMONTH(created_at) - 1 DAY)",
+			caseSensitive: NewExpr(`"name" LIKE BINARY ? ESCAPE '\\'`, "%Ann%"),
+			concat:        `CONCAT(COALESCE(CAST("a" AS CHAR), ''), COALESCE(CAST("b" AS CHAR), ''))`,
+			hasEstimate:   true,
+		},
+		{
+			dialect:       SQLite,
+			table:         `"contact"`,
+			identifier:    `"a""b"`,
+			literal:       `'a\''b'`,
+			contains:      "LIKE",
+			monthBucket:   "DATE(created_at, 'start of month')",
+			caseSensitive: NewExpr(`INSTR("name", ?) > 0`, "Ann"),
+			concat:        `COALESCE(CAST("a" AS TEXT), '') || COALESCE(CAST("b" AS TEXT), '')`,
+			hasReturning:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dialect.Name(), func(t *testing.T) {
+			if got := TableIn(tt.dialect, "acme", "contact"); got != tt.table {
+				t.Errorf("TableIn() = %v, want %v", got, tt.table)
+			}
+
+			if got := tt.dialect.QuoteIdentifier(`a"b`); got != tt.identifier {
+				t.Errorf("QuoteIdentifier() = %v, want %v", got, tt.identifier)
+			}
+
+			if got := tt.dialect.QuoteLiteral(`a\'b`); got != tt.literal {
+				t.Errorf("QuoteLiteral() = %v, want %v", got, tt.literal)
+			}
+
+			if got, _ := tt.dialect.Operator(entity.FilterOperatorContains); got != tt.contains {
+				t.Errorf("Operator(contains) = %v, want %v", got, tt.contains)
+			}
+
+			if got, _ := tt.dialect.DateBucket("created_at", entity.DateBucketMonth); got != tt.monthBucket {
+				t.Errorf("DateBucket(month) = %v, want %v", got, tt.monthBucket)
+			}
+
+			condition, ok := tt.dialect.OperatorCondition(`"name"`, entity.FilterOperatorContainsCaseSensitive, "Ann")
+			if !ok || condition.SQL != tt.caseSensitive.SQL || fmt.Sprint(condition.Args) != fmt.Sprint(tt.caseSensitive.Args) {
+				t.Errorf("OperatorCondition(contains case sensitive) = %v, want %v", condition, tt.caseSensitive)
+			}
+
+			if got := tt.dialect.Concat([]string{`"a"`, `"b"`}); got != tt.concat {
+				t.Errorf("Concat() = %v, want %v", got, tt.concat)
+			}
+
+			query := New("SELECT 1")
+			tt.dialect.Paginate(query, 10, 20)
+			if query.SQL() != "SELECT 1 LIMIT ? OFFSET ?" || fmt.Sprint(query.Args()) != "[10 20]" {
+				t.Errorf("Paginate() = %v %v", query.SQL(), query.Args())
+			}
+
+			_, hasTextSearch := tt.dialect.TextSearch([]string{"name"})
+			_, hasEstimate := tt.dialect.EstimatedCountQuery("acme", "contact")
+			capabilities := []bool{tt.dialect.NullsLast(), hasTextSearch, tt.dialect.SupportsCursor(), hasEstimate, tt.dialect.SupportsReturning()}
+			want := []bool{tt.isNullsLast, tt.hasTextSearch, tt.hasCursor, tt.hasEstimate, tt.hasReturning}
+			if fmt.Sprint(capabilities) != fmt.Sprint(want) {
+				t.Errorf("nulls last, text search, cursor, estimate and returning = %v, want %v", capabilities, want)
+			}
+		})
+	}
+}
+
+func TestOperatorConditionOfJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		dialect  Dialect
+		operator entity.FilterOperator
+		value    any
+		want     Expr
+		wantOK   bool
+	}{
+		{
+			name:     "postgres json contains",
+			dialect:  Postgres,
+			operator: entity.FilterOperatorJSONContains,
+			value:    map[string]any{"a": 1},
+			want:     NewExpr(`"tags"::jsonb @> ?::jsonb`, `{"a":1}`),
+			wantOK:   true,
+		},
+		{
+			name:     "mysql has key",
+			dialect:  MySQL,
+			operator: entity.FilterOperatorJSONHasKey,
+			value:    `a"b`,
+			want:     NewExpr(`JSON_CONTAINS_PATH("tags", 'one', ?)`, `$."a\"b"`),
+			wantOK:   true,
+		},
+		{
+			name:     "sqlite array contains every element",
+			dialect:  SQLite,
+			operator: entity.FilterOperatorArrayContains,
+			value:    []any{"a", float64(2)},
+			want: NewExpr(`(EXISTS (SELECT 1 FROM json_each("tags") WHERE json_each.value = ?) AND EXISTS (SELECT 1 FROM json_each("tags") WHERE json_each.value = ?))`,
+				"a", float64(2)),
+			wantOK: true,
+		},
+		{
+			name:     "sqlite json contains not supported",
+			dialect:  SQLite,
+			operator: entity.FilterOperatorJSONContains,
+			value:    "{}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.dialect.OperatorCondition(`"tags"`, tt.operator, tt.value)
+			if ok != tt.wantOK || got.SQL != tt.want.SQL || fmt.Sprint(got.Args) != fmt.Sprint(tt.want.Args) {
+				t.Errorf("OperatorCondition() = %v %v, want %v %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
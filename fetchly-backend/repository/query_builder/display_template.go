@@ -0,0 +1,90 @@
+package querybuilder
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DisplayTemplate composes the display value of a record from its fields, the field codes are written in braces
+// as in "{code} - {name}". Everything outside of the braces is kept as it is.
+type DisplayTemplate struct {
+	parts []displayPart
+}
+
+type displayPart struct {
+	text    string
+	isField bool
+}
+
+// ParseDisplayTemplate parses a display template, a brace that is not closed or an empty field is an error
+func ParseDisplayTemplate(template string) (DisplayTemplate, error) {
+	var result DisplayTemplate
+
+	rest := template
+	for rest != "" {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			result.parts = append(result.parts, displayPart{text: rest})
+			break
+		}
+
+		if start > 0 {
+			result.parts = append(result.parts, displayPart{text: rest[:start]})
+		}
+
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			return result, fmt.Errorf("display template %q has an unclosed brace", template)
+		}
+
+		fieldCode := strings.TrimSpace(rest[start+1 : start+end])
+		if fieldCode == "" || strings.ContainsAny(fieldCode, "{") {
+			return result, fmt.Errorf("display template %q has an invalid field", template)
+		}
+
+		result.parts = append(result.parts, displayPart{text: fieldCode, isField: true})
+		rest = rest[start+end+1:]
+	}
+
+	return result, nil
+}
+
+// NewDisplayField is the display template of a single field
+func NewDisplayField(fieldCode string) DisplayTemplate {
+	return DisplayTemplate{parts: []displayPart{{text: fieldCode, isField: true}}}
+}
+
+func (t DisplayTemplate) IsEmpty() bool {
+	return len(t.parts) == 0
+}
+
+// Fields returns the field codes the template reads
+func (t DisplayTemplate) Fields() []string {
+	var fieldCodes []string
+	for _, part := range t.parts {
+		if part.isField {
+			fieldCodes = append(fieldCodes, part.text)
+		}
+	}
+
+	return fieldCodes
+}
+
+// Expression returns the display value over the columns of table, an already quoted reference. A single field is
+// the column itself and keeps its type, a composition is text.
+func (t DisplayTemplate) Expression(dialect Dialect, table string) string {
+	if len(t.parts) == 1 && t.parts[0].isField {
+		return table + "." + dialect.QuoteIdentifier(t.parts[0].text)
+	}
+
+	expressions := make([]string, 0, len(t.parts))
+	for _, part := range t.parts {
+		if part.isField {
+			expressions = append(expressions, table+"."+dialect.QuoteIdentifier(part.text))
+		} else {
+			expressions = append(expressions, dialect.QuoteLiteral(part.text))
+		}
+	}
+
+	return dialect.Concat(expressions)
+}
@@ -0,0 +1,75 @@
+package querybuilder
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParseDisplayTemplate(t *testing.T) {
+	tests := []struct {
+		template     string
+		wantFields   []string
+		wantPostgres string
+		wantMySQL    string
+		wantErr      bool
+		wantEmpty    bool
+	}{
+		{
+			template:     "{name}",
+			wantFields:   []string{"name"},
+			wantPostgres: `"contact"."name"`,
+			wantMySQL:    "`contact`.`name`",
+		},
+		{
+			template:     "{ code } - {name}",
+			wantFields:   []string{"code", "name"},
+			wantPostgres: `COALESCE(CAST("contact"."code" AS TEXT), '') || COALESCE(CAST(' - ' AS TEXT), '') || COALESCE(CAST("contact"."name" AS TEXT), '')`,
+			wantMySQL:    "CONCAT(COALESCE(CAST(`contact`.`code` AS CHAR), ''), COALESCE(CAST(' - ' AS CHAR), ''), COALESCE(CAST(`contact`.`name` AS CHAR), ''))",
+		},
+		{
+			template:     "Mr. {name}'s",
+			wantFields:   []string{"name"},
+			wantPostgres: `COALESCE(CAST('Mr. ' AS TEXT), '') || COALESCE(CAST("contact"."name" AS TEXT), '') || COALESCE(CAST('''s' AS TEXT), '')`,
+			wantMySQL:    "CONCAT(COALESCE(CAST('Mr. ' AS CHAR), ''), COALESCE(CAST(`contact`.`name` AS CHAR), ''), COALESCE(CAST('''s' AS CHAR), ''))",
+		},
+		{
+			template:  "",
+			wantEmpty: true,
+		},
+		{template: "{name", wantErr: true},
+		{template: "{ }", wantErr: true},
+		{template: "{a{b}", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			template, err := ParseDisplayTemplate(tt.template)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDisplayTemplate() error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if template.IsEmpty() != tt.wantEmpty {
+				t.Errorf("IsEmpty() = %v, want %v", template.IsEmpty(), tt.wantEmpty)
+			}
+
+			if fmt.Sprint(template.Fields()) != fmt.Sprint(tt.wantFields) {
+				t.Errorf("Fields() = %v, want %v", template.Fields(), tt.wantFields)
+			}
+
+			if tt.wantEmpty {
+				return
+			}
+
+			if got := template.Expression(Postgres, `"contact"`); got != tt.wantPostgres {
+				t.Errorf("Expression(postgres) = %v, want %v", got, tt.wantPostgres)
+			}
+
+			if got := template.Expression(MySQL, "`contact`"); got != tt.wantMySQL {
+				t.Errorf("Expression(mysql) = %v, want %v", got, tt.wantMySQL)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package querybuilder
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+)
+
+// json columns are cast to jsonb, so the operators apply to json and jsonb columns alike
+func (postgresDialect) OperatorCondition(column string, operator entity.FilterOperator, value any) (Expr, bool) {
+	switch operator {
+	case entity.FilterOperatorContainsCaseSensitive:
+		return NewExpr(fmt.Sprintf("%s LIKE ? %s", column, postgresDialect{}.LikeEscape()), likeContains(value)), true
+	case entity.FilterOperatorJSONContains:
+		return NewExpr(fmt.Sprintf("%s::jsonb @> ?::jsonb", column), jsonArgument(value)), true
+	case entity.FilterOperatorArrayContains:
+		return NewExpr(fmt.Sprintf("%s::jsonb @> ?::jsonb", column), jsonArgument(toArray(value))), true
+	case entity.FilterOperatorJSONHasKey:
+		return NewExpr(fmt.Sprintf("%s::jsonb -> ? IS NOT NULL", column), fmt.Sprintf("%v", value)), true
+	case entity.FilterOperatorJSONPathExists:
+		return NewExpr(fmt.Sprintf("jsonb_path_exists(%s::jsonb, ?::jsonpath)", column), fmt.Sprintf("%v", value)), true
+	}
+
+	return Expr{}, false
+}
+
+func (mysqlDialect) OperatorCondition(column string, operator entity.FilterOperator, value any) (Expr, bool) {
+	switch operator {
+	case entity.FilterOperatorContainsCaseSensitive:
+		return NewExpr(fmt.Sprintf("%s LIKE BINARY ? %s", column, mysqlDialect{}.LikeEscape()), likeContains(value)), true
+	case entity.FilterOperatorJSONContains:
+		return NewExpr(fmt.Sprintf("JSON_CONTAINS(%s, ?)", column), jsonArgument(value)), true
+	case entity.FilterOperatorArrayContains:
+		return NewExpr(fmt.Sprintf("JSON_CONTAINS(%s, ?)", column), jsonArgument(toArray(value))), true
+	case entity.FilterOperatorJSONHasKey:
+		return NewExpr(fmt.Sprintf("JSON_CONTAINS_PATH(%s, 'one', ?)", column), jsonKeyPath(value)), true
+	case entity.FilterOperatorJSONPathExists:
+		return NewExpr(fmt.Sprintf("JSON_CONTAINS_PATH(%s, 'one', ?)", column), fmt.Sprintf("%v", value)), true
+	}
+
+	return Expr{}, false
+}
+
+// sqlite has no json containment, array_contains matches every value against the elements of the array
+func (sqliteDialect) OperatorCondition(column string, operator entity.FilterOperator, value any) (Expr, bool) {
+	switch operator {
+	case entity.FilterOperatorContainsCaseSensitive:
+		return NewExpr(fmt.Sprintf("INSTR(%s, ?) > 0", column), fmt.Sprintf("%v", value)), true
+	case entity.FilterOperatorArrayContains:
+		var conditions []Expr
+		for _, element := range toArray(value) {
+			conditions = append(conditions, NewExpr(fmt.Sprintf("EXISTS (SELECT 1 FROM json_each(%s) WHERE json_each.value = ?)", column), BindValue("", element)))
+		}
+
+		return Join(conditions, " AND "), len(conditions) > 0
+	case entity.FilterOperatorJSONHasKey:
+		return NewExpr(fmt.Sprintf("json_type(%s, ?) IS NOT NULL", column), jsonKeyPath(value)), true
+	case entity.FilterOperatorJSONPathExists:
+		return NewExpr(fmt.Sprintf("json_type(%s, ?) IS NOT NULL", column), fmt.Sprintf("%v", value)), true
+	}
+
+	return Expr{}, false
+}
+
+// likeContains is the LIKE pattern matching the value anywhere in the text
+func likeContains(value any) string {
+	return "%" + EscapeLike(fmt.Sprintf("%v", value)) + "%"
+}
+
+// jsonArgument encodes a filter value as the json document it is compared with
+func jsonArgument(value any) string {
+	if text, ok := value.(string); ok && json.Valid([]byte(text)) {
+		return text
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+
+	return string(data)
+}
+
+func toArray(value any) []any {
+	if values, ok := value.([]any); ok {
+		return values
+	}
+
+	return []any{value}
+}
+
+// jsonKeyPath is the json path of a top level key, $."key"
+func jsonKeyPath(value any) string {
+	key := strings.ReplaceAll(fmt.Sprintf("%v", value), `"`, `\"`)
+	return fmt.Sprintf(`$."%s"`, key)
+}
@@ -0,0 +1,164 @@
+package querybuilder
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Query accumulates a SQL statement written with "?" placeholders together
+// with the arguments bound to those placeholders, in order.
+type Query struct {
+	sql  strings.Builder
+	args []any
+}
+
+func New(sql string, args ...any) *Query {
+	q := &Query{}
+	return q.Write(sql, args...)
+}
+
+// Write appends a SQL fragment and its arguments. The fragment must never
+// contain user input, only placeholders and quoted identifiers.
+func (q *Query) Write(sql string, args ...any) *Query {
+	q.sql.WriteString(sql)
+	q.args = append(q.args, args...)
+	return q
+}
+
+// WriteExpr appends a prepared expression prefixed by the given keyword, e.g. " AND ".
+func (q *Query) WriteExpr(prefix string, expr Expr) *Query {
+	if expr.IsEmpty() {
+		return q
+	}
+
+	return q.Write(prefix+expr.SQL, expr.Args...)
+}
+
+func (q *Query) SQL() string {
+	return q.sql.String()
+}
+
+func (q *Query) Args() []any {
+	return q.args
+}
+
+// Expr is a standalone SQL fragment, typically a condition, with its own arguments.
+type Expr struct {
+	SQL  string
+	Args []any
+}
+
+func NewExpr(sql string, args ...any) Expr {
+	return Expr{SQL: sql, Args: args}
+}
+
+func (e Expr) IsEmpty() bool {
+	return e.SQL == ""
+}
+
+// Join combines non empty expressions with the given separator, wrapping the result in parentheses
+func Join(exprs []Expr, separator string) Expr {
+	var parts []string
+	var args []any
+
+	for _, expr := range exprs {
+		if expr.IsEmpty() {
+			continue
+		}
+
+		parts = append(parts, expr.SQL)
+		args = append(args, expr.Args...)
+	}
+
+	if len(parts) == 0 {
+		return Expr{}
+	}
+
+	return Expr{
+		SQL:  fmt.Sprintf("(%s)", strings.Join(parts, separator)),
+		Args: args,
+	}
+}
+
+// QuoteIdentifier quotes a single identifier (schema, table, alias or column)
+func QuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// QuoteLiteral quotes a string literal, doubling its single quotes
+func QuoteLiteral(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
+
+// QuoteReference quotes a dotted reference such as tenant.object.column part by part
+func QuoteReference(reference string) string {
+	parts := strings.Split(reference, ".")
+	for i, part := range parts {
+		parts[i] = QuoteIdentifier(part)
+	}
+
+	return strings.Join(parts, ".")
+}
+
+// Table returns the quoted schema qualified table name
+func Table(schema, table string) string {
+	return QuoteIdentifier(schema) + "." + QuoteIdentifier(table)
+}
+
+// Placeholders returns n comma separated placeholders, e.g. "?, ?, ?"
+func Placeholders(n int) string {
+	if n < 1 {
+		return ""
+	}
+
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// Direction normalizes an order direction, defaulting to ASC
+func Direction(direction string) string {
+	if strings.EqualFold(strings.TrimSpace(direction), "desc") {
+		return "DESC"
+	}
+
+	return "ASC"
+}
+
+// BindValue converts a decoded JSON value into an argument the database driver accepts
+// for a column of the given udt data type.
+func BindValue(dataType string, value any) any {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return string(v)
+	case map[string]any, []any, []map[string]any:
+		jsonBytes, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(jsonBytes)
+	case float64:
+		if isIntegerType(dataType) && v == math.Trunc(v) {
+			return int64(v)
+		}
+	}
+
+	return value
+}
+
+// EscapeLike escapes the wildcards of a value matched with LIKE, the pattern is followed by the LikeEscape
+// clause of the dialect
+func EscapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
+func isIntegerType(dataType string) bool {
+	switch strings.ToLower(dataType) {
+	case "int", "int2", "int4", "int8", "integer", "tinyint", "smallint", "mediumint", "bigint", "serial", "bigserial":
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,165 @@
+package querybuilder
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+)
+
+func TestQuery(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    *Query
+		wantSQL  string
+		wantArgs []any
+	}{
+		{
+			name:     "placeholders keep their arguments in order",
+			query:    New("SELECT * FROM t WHERE a = ?", 1).Write(" AND b IN ("+Placeholders(2)+")", "x", "y"),
+			wantSQL:  "SELECT * FROM t WHERE a = ? AND b IN (?, ?)",
+			wantArgs: []any{1, "x", "y"},
+		},
+		{
+			name:     "expression with prefix",
+			query:    New("SELECT * FROM t WHERE TRUE").WriteExpr(" AND ", NewExpr("a > ?", 2)),
+			wantSQL:  "SELECT * FROM t WHERE TRUE AND a > ?",
+			wantArgs: []any{2},
+		},
+		{
+			name:    "empty expression left out",
+			query:   New("SELECT * FROM t").WriteExpr(" WHERE ", Expr{}),
+			wantSQL: "SELECT * FROM t",
+		},
+		{
+			name:     "joined expressions",
+			query:    New("SELECT * FROM t WHERE ").WriteExpr("", Join([]Expr{NewExpr("a = ?", 1), {}, NewExpr("b = ?", 2)}, " OR ")),
+			wantSQL:  "SELECT * FROM t WHERE (a = ? OR b = ?)",
+			wantArgs: []any{1, 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.query.SQL(); got != tt.wantSQL {
+				t.Errorf("SQL() = %q, want %q", got, tt.wantSQL)
+			}
+
+			if got := tt.query.Args(); fmt.Sprint(got) != fmt.Sprint(tt.wantArgs) || len(got) != len(tt.wantArgs) {
+				t.Errorf("Args() = %v, want %v", got, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestJoinOfEmptyExpressions(t *testing.T) {
+	if expr := Join([]Expr{{}, {}}, " AND "); !expr.IsEmpty() {
+		t.Errorf("Join() = %q, want an empty expression", expr.SQL)
+	}
+}
+
+func TestQuoting(t *testing.T) {
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{name: "identifier", got: QuoteIdentifier("name"), want: `"name"`},
+		{name: "identifier with quote", got: QuoteIdentifier(`a"; DROP TABLE t; --`), want: `"a""; DROP TABLE t; --"`},
+		{name: "literal", got: QuoteLiteral("it's"), want: `'it''s'`},
+		{name: "reference", got: QuoteReference("acme.contact.name"), want: `"acme"."contact"."name"`},
+		{name: "table", got: Table("acme", "contact"), want: `"acme"."contact"`},
+		{name: "no placeholders", got: Placeholders(0), want: ""},
+		{name: "placeholders", got: Placeholders(3), want: "?, ?, ?"},
+		{name: "descending", got: Direction(" Desc "), want: "DESC"},
+		{name: "ascending by default", got: Direction("sideways"), want: "ASC"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("got %q, want %q", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBindValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		dataType string
+		value    any
+		want     any
+	}{
+		{name: "nil", dataType: "text", value: nil, want: nil},
+		{name: "bytes", dataType: "text", value: []byte("a"), want: "a"},
+		{name: "whole number of an integer column", dataType: "int8", value: float64(3), want: int64(3)},
+		{name: "fraction of an integer column", dataType: "int8", value: 3.5, want: 3.5},
+		{name: "number of a decimal column", dataType: "numeric", value: float64(3), want: float64(3)},
+		{name: "object", dataType: "jsonb", value: map[string]any{"a": 1}, want: `{"a":1}`},
+		{name: "array", dataType: "jsonb", value: []any{"a", 1}, want: `["a",1]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BindValue(tt.dataType, tt.value); got != tt.want {
+				t.Errorf("BindValue(%q, %v) = %#v, want %#v", tt.dataType, tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestColumnsReference(t *testing.T) {
+	columns := NewColumns([]map[string]any{
+		{entity.FieldColumnCode: "name", entity.FieldDataType: "varchar"},
+		{entity.FieldColumnCode: []byte("age"), entity.FieldDataType: []byte("int4")},
+		{entity.FieldDataType: "text"},
+	})
+
+	tests := []struct {
+		column  string
+		want    string
+		wantErr bool
+	}{
+		{column: "name", want: `"acme"."contact"."name"`},
+		{column: "age", want: `"acme"."contact"."age"`},
+		{column: "name; DROP TABLE contact", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.column, func(t *testing.T) {
+			got, err := columns.Reference("acme.contact", tt.column)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Reference(%q) error = %v, want error %v", tt.column, err, tt.wantErr)
+			}
+
+			if got != tt.want {
+				t.Errorf("Reference(%q) = %q, want %q", tt.column, got, tt.want)
+			}
+		})
+	}
+
+	if dataType := columns.DataType("age"); dataType != "int4" {
+		t.Errorf("DataType(age) = %q, want int4", dataType)
+	}
+}
+
+func TestEscapeLike(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "ann", want: "ann"},
+		{value: "50%", want: `50\%`},
+		{value: "a_b", want: `a\_b`},
+		{value: `a\%`, want: `a\\\%`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := EscapeLike(tt.value); got != tt.want {
+				t.Errorf("EscapeLike(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,373 @@
+package querybuilder
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+)
+
+// ColumnDefinition is a column of a table created or altered by the schema management API. Default is an SQL
+// expression, References the quoted table and column the column references.
+type ColumnDefinition struct {
+	Name              string
+	PrimitiveDataType string
+	IsPrimaryKey      bool
+	IsRequired        bool
+	IsUnique          bool
+	Default           string
+	References        string
+}
+
+// StandardColumnDefinitions are the standard columns of a table in dialect, the serial is its primary key
+func StandardColumnDefinitions(dialect Dialect) []ColumnDefinition {
+	serial := ColumnDefinition{Name: entity.DEFAULT_IDENTIFIER, PrimitiveDataType: entity.PrimitiveDataTypeUUID, IsPrimaryKey: true, IsRequired: true}
+	serial.Default, _ = dialect.UUIDDefault()
+
+	return []ColumnDefinition{
+		serial,
+		{Name: "created_at", PrimitiveDataType: entity.PrimitiveDataTypeDateTime, IsRequired: true, Default: "CURRENT_TIMESTAMP"},
+		{Name: "updated_at", PrimitiveDataType: entity.PrimitiveDataTypeDateTime, IsRequired: true, Default: "CURRENT_TIMESTAMP"},
+		{Name: "deleted_at", PrimitiveDataType: entity.PrimitiveDataTypeDateTime},
+		{Name: "created_by", PrimitiveDataType: entity.PrimitiveDataTypeUUID, IsRequired: true, Default: dialect.QuoteLiteral(entity.DefaultUserSerial)},
+		{Name: "updated_by", PrimitiveDataType: entity.PrimitiveDataTypeUUID, IsRequired: true, Default: dialect.QuoteLiteral(entity.DefaultUserSerial)},
+		{Name: "deleted_by", PrimitiveDataType: entity.PrimitiveDataTypeUUID},
+	}
+}
+
+// Definition returns the definition of the column in dialect, without its unique constraint and foreign key
+func (c ColumnDefinition) Definition(dialect Dialect) (string, error) {
+	columnType, ok := dialect.ColumnType(c.PrimitiveDataType)
+	if !ok {
+		return "", fmt.Errorf("%w: primitive data type %v of column %v is not supported by %v", entity.ErrorBadRequest, c.PrimitiveDataType, c.Name, dialect.Name())
+	}
+
+	definition := dialect.QuoteIdentifier(c.Name) + " " + columnType
+	if c.IsPrimaryKey {
+		definition += " PRIMARY KEY"
+	}
+
+	if c.IsRequired {
+		definition += " NOT NULL"
+	}
+
+	if c.Default != "" {
+		definition += " DEFAULT " + c.Default
+	}
+
+	return definition, nil
+}
+
+// DefaultLiteral returns the default value of a column of the primitive data type as an SQL literal. Numbers and
+// booleans are written as they are, everything else is quoted.
+func DefaultLiteral(dialect Dialect, primitiveDataType, value string) string {
+	if value == "" {
+		return ""
+	}
+
+	switch primitiveDataType {
+	case entity.PrimitiveDataTypeInteger, entity.PrimitiveDataTypeBigInt, entity.PrimitiveDataTypeDecimal, entity.PrimitiveDataTypeFloat:
+		if _, err := strconv.ParseFloat(value, 64); err == nil {
+			return value
+		}
+
+	case entity.PrimitiveDataTypeBoolean:
+		if isTrue, err := strconv.ParseBool(value); err == nil {
+			return strings.ToUpper(strconv.FormatBool(isTrue))
+		}
+	}
+
+	return dialect.QuoteLiteral(value)
+}
+
+// UniqueConstraintName is the name of the unique constraint of a column, the one postgres gives it as well
+func UniqueConstraintName(table, column string) string {
+	return fmt.Sprintf("%.63s", table+"_"+column+"_key")
+}
+
+// CreateTableQuery returns the CREATE TABLE of an object with the standard columns followed by columns
+func CreateTableQuery(dialect Dialect, schema, table string, columns []ColumnDefinition) (string, error) {
+	columns = append(StandardColumnDefinitions(dialect), columns...)
+
+	definitions := make([]string, 0, len(columns))
+	var constraints []string
+	for _, column := range columns {
+		definition, err := column.Definition(dialect)
+		if err != nil {
+			return "", err
+		}
+
+		definitions = append(definitions, definition)
+
+		if column.IsUnique && !column.IsPrimaryKey {
+			constraints = append(constraints, fmt.Sprintf("CONSTRAINT %v UNIQUE (%v)", dialect.QuoteIdentifier(UniqueConstraintName(table, column.Name)), dialect.QuoteIdentifier(column.Name)))
+		}
+
+		if column.References != "" {
+			constraints = append(constraints, fmt.Sprintf("FOREIGN KEY (%v) REFERENCES %v", dialect.QuoteIdentifier(column.Name), column.References))
+		}
+	}
+
+	return fmt.Sprintf("CREATE TABLE %v (%v)", TableIn(dialect, schema, table), strings.Join(append(definitions, constraints...), ", ")), nil
+}
+
+// DropTableQuery returns the DROP TABLE of an object
+func DropTableQuery(dialect Dialect, schema, table string) string {
+	return fmt.Sprintf("DROP TABLE %v", TableIn(dialect, schema, table))
+}
+
+// DropColumnQuery returns the statement dropping a column
+func DropColumnQuery(dialect Dialect, schema, table, column string) string {
+	return fmt.Sprintf("ALTER TABLE %v DROP COLUMN %v", TableIn(dialect, schema, table), dialect.QuoteIdentifier(column))
+}
+
+// renameColumnQuery returns the standard statement renaming a column
+func renameColumnQuery(dialect Dialect, schema, table, column, name string) string {
+	return fmt.Sprintf("ALTER TABLE %v RENAME COLUMN %v TO %v", TableIn(dialect, schema, table), dialect.QuoteIdentifier(column), dialect.QuoteIdentifier(name))
+}
+
+// addColumnQuery returns the standard statement adding a column, the column keeps its foreign key inline
+func addColumnQuery(dialect Dialect, schema, table string, column ColumnDefinition) (string, error) {
+	definition, err := column.Definition(dialect)
+	if err != nil {
+		return "", err
+	}
+
+	if column.References != "" {
+		definition += " REFERENCES " + column.References
+	}
+
+	return fmt.Sprintf("ALTER TABLE %v ADD COLUMN %v", TableIn(dialect, schema, table), definition), nil
+}
+
+func (postgresDialect) ColumnType(primitiveDataType string) (string, bool) {
+	columnType, ok := map[string]string{
+		entity.PrimitiveDataTypeString:   "VARCHAR(255)",
+		entity.PrimitiveDataTypeText:     "TEXT",
+		entity.PrimitiveDataTypeInteger:  "INTEGER",
+		entity.PrimitiveDataTypeBigInt:   "BIGINT",
+		entity.PrimitiveDataTypeDecimal:  "NUMERIC",
+		entity.PrimitiveDataTypeFloat:    "DOUBLE PRECISION",
+		entity.PrimitiveDataTypeBoolean:  "BOOLEAN",
+		entity.PrimitiveDataTypeDate:     "DATE",
+		entity.PrimitiveDataTypeDateTime: "TIMESTAMPTZ",
+		entity.PrimitiveDataTypeTime:     "TIME",
+		entity.PrimitiveDataTypeJSON:     "JSONB",
+		entity.PrimitiveDataTypeUUID:     "VARCHAR(36)",
+	}[primitiveDataType]
+
+	return columnType, ok
+}
+
+func (postgresDialect) UUIDDefault() (string, bool) {
+	return "gen_random_uuid()::text", true
+}
+
+func (d postgresDialect) AddColumn(schema, table string, column ColumnDefinition) ([]string, error) {
+	unique := column.IsUnique
+	column.IsUnique = false
+
+	addColumn, err := addColumnQuery(d, schema, table, column)
+	if err != nil {
+		return nil, err
+	}
+
+	statements := []string{addColumn}
+	if unique {
+		statements = append(statements, fmt.Sprintf("ALTER TABLE %v ADD CONSTRAINT %v UNIQUE (%v)", TableIn(d, schema, table), d.QuoteIdentifier(UniqueConstraintName(table, column.Name)), d.QuoteIdentifier(column.Name)))
+	}
+
+	return statements, nil
+}
+
+func (d postgresDialect) RenameColumn(schema, table string, column ColumnDefinition, name string) []string {
+	statements := []string{renameColumnQuery(d, schema, table, column.Name, name)}
+	if column.IsUnique {
+		statements = append(statements, fmt.Sprintf("ALTER TABLE %v RENAME CONSTRAINT %v TO %v", TableIn(d, schema, table),
+			d.QuoteIdentifier(UniqueConstraintName(table, column.Name)), d.QuoteIdentifier(UniqueConstraintName(table, name))))
+	}
+
+	return statements
+}
+
+// AlterColumn casts the values of a changed type with USING, the default is dropped before the cast and set again
+// after it since it may not cast
+func (d postgresDialect) AlterColumn(schema, table string, from, to ColumnDefinition) ([]string, bool) {
+	tableName := TableIn(d, schema, table)
+	column := d.QuoteIdentifier(to.Name)
+	isTypeChanged := from.PrimitiveDataType != to.PrimitiveDataType
+	isDefaultChanged := from.Default != to.Default || isTypeChanged
+
+	var statements []string
+	if isDefaultChanged && from.Default != "" {
+		statements = append(statements, fmt.Sprintf("ALTER TABLE %v ALTER COLUMN %v DROP DEFAULT", tableName, column))
+	}
+
+	if isTypeChanged {
+		columnType, ok := d.ColumnType(to.PrimitiveDataType)
+		if !ok {
+			return nil, false
+		}
+
+		statements = append(statements, fmt.Sprintf("ALTER TABLE %v ALTER COLUMN %v TYPE %v USING %v::%v", tableName, column, columnType, column, columnType))
+	}
+
+	if isDefaultChanged && to.Default != "" {
+		statements = append(statements, fmt.Sprintf("ALTER TABLE %v ALTER COLUMN %v SET DEFAULT %v", tableName, column, to.Default))
+	}
+
+	if from.IsRequired != to.IsRequired {
+		action := "DROP NOT NULL"
+		if to.IsRequired {
+			action = "SET NOT NULL"
+		}
+
+		statements = append(statements, fmt.Sprintf("ALTER TABLE %v ALTER COLUMN %v %v", tableName, column, action))
+	}
+
+	if from.IsUnique != to.IsUnique {
+		constraint := d.QuoteIdentifier(UniqueConstraintName(table, to.Name))
+		if to.IsUnique {
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %v ADD CONSTRAINT %v UNIQUE (%v)", tableName, constraint, column))
+		} else {
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %v DROP CONSTRAINT IF EXISTS %v", tableName, constraint))
+		}
+	}
+
+	return statements, true
+}
+
+func (mysqlDialect) ColumnType(primitiveDataType string) (string, bool) {
+	columnType, ok := map[string]string{
+		entity.PrimitiveDataTypeString:   "VARCHAR(255)",
+		entity.PrimitiveDataTypeText:     "TEXT",
+		entity.PrimitiveDataTypeInteger:  "INT",
+		entity.PrimitiveDataTypeBigInt:   "BIGINT",
+		entity.PrimitiveDataTypeDecimal:  "DECIMAL(20, 6)",
+		entity.PrimitiveDataTypeFloat:    "DOUBLE",
+		entity.PrimitiveDataTypeBoolean:  "BOOLEAN",
+		entity.PrimitiveDataTypeDate:     "DATE",
+		entity.PrimitiveDataTypeDateTime: "DATETIME",
+		entity.PrimitiveDataTypeTime:     "TIME",
+		entity.PrimitiveDataTypeJSON:     "JSON",
+		entity.PrimitiveDataTypeUUID:     "VARCHAR(36)",
+	}[primitiveDataType]
+
+	return columnType, ok
+}
+
+// UUIDDefault is left to the platform, the records are read back by the serial it generates
+func (mysqlDialect) UUIDDefault() (string, bool) {
+	return "", false
+}
+
+// AddColumn declares the foreign key apart, mysql ignores a REFERENCES written along the column
+func (d mysqlDialect) AddColumn(schema, table string, column ColumnDefinition) ([]string, error) {
+	unique, references := column.IsUnique, column.References
+	column.IsUnique, column.References = false, ""
+
+	addColumn, err := addColumnQuery(d, schema, table, column)
+	if err != nil {
+		return nil, err
+	}
+
+	if unique {
+		addColumn += fmt.Sprintf(", ADD CONSTRAINT %v UNIQUE (%v)", d.QuoteIdentifier(UniqueConstraintName(table, column.Name)), d.QuoteIdentifier(column.Name))
+	}
+
+	if references != "" {
+		addColumn += fmt.Sprintf(", ADD FOREIGN KEY (%v) REFERENCES %v", d.QuoteIdentifier(column.Name), references)
+	}
+
+	return []string{addColumn}, nil
+}
+
+func (d mysqlDialect) RenameColumn(schema, table string, column ColumnDefinition, name string) []string {
+	statements := []string{renameColumnQuery(d, schema, table, column.Name, name)}
+	if column.IsUnique {
+		statements = append(statements, fmt.Sprintf("ALTER TABLE %v RENAME INDEX %v TO %v", TableIn(d, schema, table),
+			d.QuoteIdentifier(UniqueConstraintName(table, column.Name)), d.QuoteIdentifier(UniqueConstraintName(table, name))))
+	}
+
+	return statements
+}
+
+// AlterColumn restates the whole column with MODIFY COLUMN, the unique constraint is an index of its own
+func (d mysqlDialect) AlterColumn(schema, table string, from, to ColumnDefinition) ([]string, bool) {
+	tableName := TableIn(d, schema, table)
+
+	var statements []string
+	if from.PrimitiveDataType != to.PrimitiveDataType || from.IsRequired != to.IsRequired || from.Default != to.Default {
+		column := to
+		column.IsUnique = false
+
+		definition, err := column.Definition(d)
+		if err != nil {
+			return nil, false
+		}
+
+		statements = append(statements, fmt.Sprintf("ALTER TABLE %v MODIFY COLUMN %v", tableName, definition))
+	}
+
+	if from.IsUnique != to.IsUnique {
+		index := d.QuoteIdentifier(UniqueConstraintName(table, to.Name))
+		if to.IsUnique {
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %v ADD CONSTRAINT %v UNIQUE (%v)", tableName, index, d.QuoteIdentifier(to.Name)))
+		} else {
+			statements = append(statements, fmt.Sprintf("ALTER TABLE %v DROP INDEX %v", tableName, index))
+		}
+	}
+
+	return statements, true
+}
+
+func (sqliteDialect) ColumnType(primitiveDataType string) (string, bool) {
+	columnType, ok := map[string]string{
+		entity.PrimitiveDataTypeString:   "VARCHAR(255)",
+		entity.PrimitiveDataTypeText:     "TEXT",
+		entity.PrimitiveDataTypeInteger:  "INTEGER",
+		entity.PrimitiveDataTypeBigInt:   "BIGINT",
+		entity.PrimitiveDataTypeDecimal:  "NUMERIC",
+		entity.PrimitiveDataTypeFloat:    "REAL",
+		entity.PrimitiveDataTypeBoolean:  "BOOLEAN",
+		entity.PrimitiveDataTypeDate:     "DATE",
+		entity.PrimitiveDataTypeDateTime: "DATETIME",
+		entity.PrimitiveDataTypeTime:     "TIME",
+		entity.PrimitiveDataTypeJSON:     "JSON",
+		entity.PrimitiveDataTypeUUID:     "VARCHAR(36)",
+	}[primitiveDataType]
+
+	return columnType, ok
+}
+
+func (sqliteDialect) UUIDDefault() (string, bool) {
+	return "", false
+}
+
+// AddColumn adds the unique constraint as a unique index, sqlite can not add a unique column
+func (d sqliteDialect) AddColumn(schema, table string, column ColumnDefinition) ([]string, error) {
+	unique := column.IsUnique
+	column.IsUnique = false
+
+	addColumn, err := addColumnQuery(d, schema, table, column)
+	if err != nil {
+		return nil, err
+	}
+
+	statements := []string{addColumn}
+	if unique {
+		statements = append(statements, fmt.Sprintf("CREATE UNIQUE INDEX %v ON %v (%v)", d.QuoteIdentifier(UniqueConstraintName(table, column.Name)), TableIn(d, schema, table), d.QuoteIdentifier(column.Name)))
+	}
+
+	return statements, nil
+}
+
+func (d sqliteDialect) RenameColumn(schema, table string, column ColumnDefinition, name string) []string {
+	return []string{renameColumnQuery(d, schema, table, column.Name, name)}
+}
+
+// AlterColumn is not available, sqlite only alters a column by rebuilding its table
+func (sqliteDialect) AlterColumn(_, _ string, _, _ ColumnDefinition) ([]string, bool) {
+	return nil, false
+}
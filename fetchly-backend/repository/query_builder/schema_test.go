@@ -0,0 +1,275 @@
+package querybuilder
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/fetchlydev/source/fetchly-backend/core/entity"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestDefaultLiteral(t *testing.T) {
+	tests := []struct {
+		primitiveDataType string
+		value             string
+		want              string
+	}{
+		{primitiveDataType: entity.PrimitiveDataTypeInteger, value: "42", want: "42"},
+		{primitiveDataType: entity.PrimitiveDataTypeDecimal, value: "1.5", want: "1.5"},
+		{primitiveDataType: entity.PrimitiveDataTypeInteger, value: "1; DROP TABLE contact", want: "'1; DROP TABLE contact'"},
+		{primitiveDataType: entity.PrimitiveDataTypeBoolean, value: "1", want: "TRUE"},
+		{primitiveDataType: entity.PrimitiveDataTypeBoolean, value: "false", want: "FALSE"},
+		{primitiveDataType: entity.PrimitiveDataTypeString, value: "it's", want: "'it''s'"},
+		{primitiveDataType: entity.PrimitiveDataTypeString, value: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.primitiveDataType+" "+tt.value, func(t *testing.T) {
+			if got := DefaultLiteral(Postgres, tt.primitiveDataType, tt.value); got != tt.want {
+				t.Errorf("DefaultLiteral() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUniqueConstraintName(t *testing.T) {
+	if got := UniqueConstraintName("contact", "email"); got != "contact_email_key" {
+		t.Errorf("UniqueConstraintName() = %v", got)
+	}
+
+	if got := UniqueConstraintName(strings.Repeat("t", 40), strings.Repeat("c", 40)); len(got) != 63 {
+		t.Errorf("UniqueConstraintName() is %d long, want the 63 postgres keeps", len(got))
+	}
+}
+
+func TestCreateTableQuery(t *testing.T) {
+	columns := []ColumnDefinition{
+		{Name: "name", PrimitiveDataType: entity.PrimitiveDataTypeString, IsRequired: true, IsUnique: true},
+		{Name: "owner_id", PrimitiveDataType: entity.PrimitiveDataTypeUUID, References: "users (serial)"},
+	}
+
+	tests := []struct {
+		dialect    Dialect
+		wantPrefix string
+		wantSuffix string
+	}{
+		{
+			dialect:    Postgres,
+			wantPrefix: `CREATE TABLE "acme"."contact" ("serial" VARCHAR(36) PRIMARY KEY NOT NULL DEFAULT gen_random_uuid()::text, "created_at" TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP, `,
+			wantSuffix: `"name" VARCHAR(255) NOT NULL, "owner_id" VARCHAR(36), CONSTRAINT "contact_name_key" UNIQUE ("name"), FOREIGN KEY ("owner_id") REFERENCES users (serial))`,
+		},
+		{
+			dialect:    MySQL,
+			wantPrefix: "CREATE TABLE `contact` (`serial` VARCHAR(36) PRIMARY KEY NOT NULL, `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP, ",
+			wantSuffix: "`name` VARCHAR(255) NOT NULL, `owner_id` VARCHAR(36), CONSTRAINT `contact_name_key` UNIQUE (`name`), FOREIGN KEY (`owner_id`) REFERENCES users (serial))",
+		},
+		{
+			dialect:    SQLite,
+			wantPrefix: `CREATE TABLE "contact" ("serial" VARCHAR(36) PRIMARY KEY NOT NULL, "created_at" DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP, `,
+			wantSuffix: `"name" VARCHAR(255) NOT NULL, "owner_id" VARCHAR(36), CONSTRAINT "contact_name_key" UNIQUE ("name"), FOREIGN KEY ("owner_id") REFERENCES users (serial))`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dialect.Name(), func(t *testing.T) {
+			query, err := CreateTableQuery(tt.dialect, "acme", "contact", columns)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !strings.HasPrefix(query, tt.wantPrefix) || !strings.HasSuffix(query, tt.wantSuffix) {
+				t.Errorf("CreateTableQuery() = %v, want %v...%v", query, tt.wantPrefix, tt.wantSuffix)
+			}
+		})
+	}
+
+	if _, err := CreateTableQuery(Postgres, "acme", "contact", []ColumnDefinition{{Name: "tags", PrimitiveDataType: "array"}}); err == nil {
+		t.Error("CreateTableQuery() of an unsupported data type returned no error")
+	}
+}
+
+func TestAddColumn(t *testing.T) {
+	column := ColumnDefinition{Name: "email", PrimitiveDataType: entity.PrimitiveDataTypeString, IsUnique: true, Default: "''", References: "users (email)"}
+
+	tests := []struct {
+		dialect Dialect
+		want    []string
+	}{
+		{
+			dialect: Postgres,
+			want: []string{
+				`ALTER TABLE "acme"."contact" ADD COLUMN "email" VARCHAR(255) DEFAULT '' REFERENCES users (email)`,
+				`ALTER TABLE "acme"."contact" ADD CONSTRAINT "contact_email_key" UNIQUE ("email")`,
+			},
+		},
+		{
+			dialect: MySQL,
+			want: []string{
+				"ALTER TABLE `contact` ADD COLUMN `email` VARCHAR(255) DEFAULT '', ADD CONSTRAINT `contact_email_key` UNIQUE (`email`), ADD FOREIGN KEY (`email`) REFERENCES users (email)",
+			},
+		},
+		{
+			dialect: SQLite,
+			want: []string{
+				`ALTER TABLE "contact" ADD COLUMN "email" VARCHAR(255) DEFAULT '' REFERENCES users (email)`,
+				`CREATE UNIQUE INDEX "contact_email_key" ON "contact" ("email")`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dialect.Name(), func(t *testing.T) {
+			statements, err := tt.dialect.AddColumn("acme", "contact", column)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assertStatements(t, statements, tt.want)
+		})
+	}
+}
+
+func TestRenameColumn(t *testing.T) {
+	column := ColumnDefinition{Name: "email", IsUnique: true}
+
+	tests := []struct {
+		dialect Dialect
+		want    []string
+	}{
+		{
+			dialect: Postgres,
+			want: []string{
+				`ALTER TABLE "acme"."contact" RENAME COLUMN "email" TO "mail"`,
+				`ALTER TABLE "acme"."contact" RENAME CONSTRAINT "contact_email_key" TO "contact_mail_key"`,
+			},
+		},
+		{
+			dialect: MySQL,
+			want: []string{
+				"ALTER TABLE `contact` RENAME COLUMN `email` TO `mail`",
+				"ALTER TABLE `contact` RENAME INDEX `contact_email_key` TO `contact_mail_key`",
+			},
+		},
+		{
+			dialect: SQLite,
+			want:    []string{`ALTER TABLE "contact" RENAME COLUMN "email" TO "mail"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dialect.Name(), func(t *testing.T) {
+			assertStatements(t, tt.dialect.RenameColumn("acme", "contact", column, "mail"), tt.want)
+		})
+	}
+}
+
+func TestAlterColumn(t *testing.T) {
+	age := ColumnDefinition{Name: "age", PrimitiveDataType: entity.PrimitiveDataTypeString, Default: "'0'"}
+
+	tests := []struct {
+		name    string
+		dialect Dialect
+		to      ColumnDefinition
+		want    []string
+		wantOk  bool
+	}{
+		{
+			name:    "postgres type cast around the default",
+			dialect: Postgres,
+			to:      ColumnDefinition{Name: "age", PrimitiveDataType: entity.PrimitiveDataTypeInteger, Default: "0"},
+			want: []string{
+				`ALTER TABLE "acme"."contact" ALTER COLUMN "age" DROP DEFAULT`,
+				`ALTER TABLE "acme"."contact" ALTER COLUMN "age" TYPE INTEGER USING "age"::INTEGER`,
+				`ALTER TABLE "acme"."contact" ALTER COLUMN "age" SET DEFAULT 0`,
+			},
+			wantOk: true,
+		},
+		{
+			name:    "postgres required and unique",
+			dialect: Postgres,
+			to:      ColumnDefinition{Name: "age", PrimitiveDataType: entity.PrimitiveDataTypeString, Default: "'0'", IsRequired: true, IsUnique: true},
+			want: []string{
+				`ALTER TABLE "acme"."contact" ALTER COLUMN "age" SET NOT NULL`,
+				`ALTER TABLE "acme"."contact" ADD CONSTRAINT "contact_age_key" UNIQUE ("age")`,
+			},
+			wantOk: true,
+		},
+		{
+			name:    "postgres unchanged",
+			dialect: Postgres,
+			to:      age,
+			wantOk:  true,
+		},
+		{
+			name:    "postgres unsupported type",
+			dialect: Postgres,
+			to:      ColumnDefinition{Name: "age", PrimitiveDataType: "array"},
+		},
+		{
+			name:    "mysql restates the column",
+			dialect: MySQL,
+			to:      ColumnDefinition{Name: "age", PrimitiveDataType: entity.PrimitiveDataTypeInteger, IsRequired: true, IsUnique: true},
+			want: []string{
+				"ALTER TABLE `contact` MODIFY COLUMN `age` INT NOT NULL",
+				"ALTER TABLE `contact` ADD CONSTRAINT `contact_age_key` UNIQUE (`age`)",
+			},
+			wantOk: true,
+		},
+		{
+			name:    "sqlite can not alter a column",
+			dialect: SQLite,
+			to:      ColumnDefinition{Name: "age", PrimitiveDataType: entity.PrimitiveDataTypeInteger},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statements, ok := tt.dialect.AlterColumn("acme", "contact", age, tt.to)
+			if ok != tt.wantOk {
+				t.Fatalf("AlterColumn() ok = %v, want %v", ok, tt.wantOk)
+			}
+
+			assertStatements(t, statements, tt.want)
+		})
+	}
+}
+
+// TestSQLiteSchemaStatements runs the statements of the sqlite dialect on a sqlite database
+func TestSQLiteSchemaStatements(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	createTable, err := CreateTableQuery(SQLite, "acme", "contact", []ColumnDefinition{
+		{Name: "name", PrimitiveDataType: entity.PrimitiveDataTypeString, IsRequired: true, IsUnique: true, Default: DefaultLiteral(SQLite, entity.PrimitiveDataTypeString, "anonymous")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addColumn, err := SQLite.AddColumn("acme", "contact", ColumnDefinition{Name: "email", PrimitiveDataType: entity.PrimitiveDataTypeString, IsUnique: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statements := append([]string{createTable}, addColumn...)
+	statements = append(statements, SQLite.RenameColumn("acme", "contact", ColumnDefinition{Name: "name"}, "full_name")...)
+	statements = append(statements, DropColumnQuery(SQLite, "acme", "contact", "created_by"), DropTableQuery(SQLite, "acme", "contact"))
+
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			t.Fatalf("%v: %v", statement, err)
+		}
+	}
+}
+
+func assertStatements(t *testing.T, got, want []string) {
+	t.Helper()
+
+	if fmt.Sprint(got) != fmt.Sprint(want) || len(got) != len(want) {
+		t.Errorf("statements = %q, want %q", got, want)
+	}
+}
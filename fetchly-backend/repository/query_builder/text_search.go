@@ -0,0 +1,85 @@
+package querybuilder
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// TextSearchConfig is the text search configuration of the search documents. simple only lowercases the words,
+// so it works for every language the records are written in.
+const TextSearchConfig = "simple"
+
+// the matches of a headline are wrapped in control characters, the records are escaped before they become <mark>
+const (
+	headlineStartSel = "\x02"
+	headlineStopSel  = "\x03"
+)
+
+var headlineOptions = fmt.Sprintf("StartSel=%s, StopSel=%s, MaxFragments=2, MaxWords=20, MinWords=5", headlineStartSel, headlineStopSel)
+
+// TextSearch matches, ranks and highlights a web search style query ("quoted phrase", -excluded, or) against a
+// document of columns on postgres
+type TextSearch struct {
+	columns []string
+}
+
+// Document is the tsvector of the columns. The search index of the table is built on the same expression, a
+// search has to repeat it exactly for the planner to use the index.
+func (t TextSearch) Document() string {
+	parts := make([]string, 0, len(t.columns))
+	for _, column := range t.columns {
+		parts = append(parts, textOf(column))
+	}
+
+	return fmt.Sprintf("to_tsvector('%s', %s)", TextSearchConfig, strings.Join(parts, " || ' ' || "))
+}
+
+// Match matches the records whose document contains the search
+func (t TextSearch) Match(search string) Expr {
+	return NewExpr(fmt.Sprintf("%s @@ %s", t.Document(), tsQuery()), search)
+}
+
+// Rank scores how well the document of a record matches the search, higher is better
+func (t TextSearch) Rank(search string) Expr {
+	return NewExpr(fmt.Sprintf("ts_rank(%s, %s)", t.Document(), tsQuery()), search)
+}
+
+// Headline returns the fragments of column matching the search, NULL when the column does not match. The
+// fragments are the raw text of the record, HighlightHTML turns them into HTML.
+func (t TextSearch) Headline(column, search string) Expr {
+	return NewExpr(fmt.Sprintf(
+		"CASE WHEN to_tsvector('%s', %s) @@ %s THEN ts_headline('%s', %s, %s, ?) END",
+		TextSearchConfig, textOf(column), tsQuery(), TextSearchConfig, textOf(column), tsQuery(),
+	), search, search, headlineOptions)
+}
+
+// HighlightHTML escapes a headline and wraps its matches in <mark>
+func HighlightHTML(headline string) string {
+	return strings.NewReplacer(headlineStartSel, "<mark>", headlineStopSel, "</mark>").Replace(html.EscapeString(headline))
+}
+
+// IsTextType reports whether an introspected data type holds text. Only text columns are searchable, the casts
+// of the other types to text are not immutable and can not be indexed.
+func IsTextType(dataType string) bool {
+	dataType = strings.ToLower(strings.TrimSpace(dataType))
+	if i := strings.Index(dataType, "("); i >= 0 {
+		dataType = strings.TrimSpace(dataType[:i])
+	}
+
+	switch dataType {
+	case "text", "varchar", "character varying", "char", "character", "bpchar", "citext", "name",
+		"nvarchar", "nchar", "tinytext", "mediumtext", "longtext", "clob":
+		return true
+	}
+
+	return false
+}
+
+func tsQuery() string {
+	return fmt.Sprintf("websearch_to_tsquery('%s', ?)", TextSearchConfig)
+}
+
+func textOf(column string) string {
+	return fmt.Sprintf("COALESCE(%s::text, '')", column)
+}
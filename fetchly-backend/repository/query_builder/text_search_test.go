@@ -0,0 +1,102 @@
+package querybuilder
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestTextSearch(t *testing.T) {
+	textSearch, ok := Postgres.TextSearch([]string{`"contact"."name"`, `"contact"."age"`})
+	if !ok {
+		t.Fatal("postgres has no text search")
+	}
+
+	document := `to_tsvector('simple', COALESCE("contact"."name"::text, '') || ' ' || COALESCE("contact"."age"::text, ''))`
+	query := "websearch_to_tsquery('simple', ?)"
+	headline := `CASE WHEN to_tsvector('simple', COALESCE("contact"."name"::text, '')) @@ ` + query +
+		` THEN ts_headline('simple', COALESCE("contact"."name"::text, ''), ` + query +
+		`, ?) END`
+
+	tests := []struct {
+		name     string
+		expr     Expr
+		wantSQL  string
+		wantArgs []any
+	}{
+		{
+			name:     "match",
+			expr:     textSearch.Match(`"ann lee" -bob`),
+			wantSQL:  document + " @@ " + query,
+			wantArgs: []any{`"ann lee" -bob`},
+		},
+		{
+			name:     "rank",
+			expr:     textSearch.Rank("ann"),
+			wantSQL:  "ts_rank(" + document + ", " + query + ")",
+			wantArgs: []any{"ann"},
+		},
+		{
+			name:     "headline",
+			expr:     textSearch.Headline(`"contact"."name"`, "ann"),
+			wantSQL:  headline,
+			wantArgs: []any{"ann", "ann", "StartSel=\x02, StopSel=\x03, MaxFragments=2, MaxWords=20, MinWords=5"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.expr.SQL != tt.wantSQL {
+				t.Errorf("SQL = %q, want %q", tt.expr.SQL, tt.wantSQL)
+			}
+
+			if fmt.Sprint(tt.expr.Args) != fmt.Sprint(tt.wantArgs) {
+				t.Errorf("args = %v, want %v", tt.expr.Args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestHighlightHTML(t *testing.T) {
+	tests := []struct {
+		name     string
+		headline string
+		want     string
+	}{
+		{name: "match", headline: "call \x02ann\x03 today", want: "call <mark>ann</mark> today"},
+		{name: "markup of the record", headline: "<img src=x onerror=\"alert(1)\"> \x02ann\x03 & co", want: `&lt;img src=x onerror=&#34;alert(1)&#34;&gt; <mark>ann</mark> &amp; co`},
+		{name: "mark of the record", headline: "<mark>ann</mark>", want: "&lt;mark&gt;ann&lt;/mark&gt;"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HighlightHTML(tt.headline); got != tt.want {
+				t.Errorf("HighlightHTML() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsTextType(t *testing.T) {
+	tests := []struct {
+		dataType string
+		want     bool
+	}{
+		{dataType: "text", want: true},
+		{dataType: "varchar", want: true},
+		{dataType: "varchar(255)", want: true},
+		{dataType: "VARCHAR (64)", want: true},
+		{dataType: "longtext", want: true},
+		{dataType: "timestamptz", want: false},
+		{dataType: "int8", want: false},
+		{dataType: "jsonb", want: false},
+		{dataType: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dataType, func(t *testing.T) {
+			if got := IsTextType(tt.dataType); got != tt.want {
+				t.Errorf("IsTextType(%q) = %v, want %v", tt.dataType, got, tt.want)
+			}
+		})
+	}
+}
@@ -49,6 +49,11 @@ func HandleSingleRow(columnsList []map[string]any, rows *sql.Rows, request entit
 			}
 		}
 
+		// text columns some drivers scan as bytes, binary columns are left as they are
+		if bytesValue, ok := val.([]byte); ok && !isBinaryType(colName[entity.FieldDataType]) {
+			val = string(bytesValue)
+		}
+
 		key := colName[entity.FieldColumnCode].(string)
 		if _, ok := colName[entity.FieldOriginalFieldCode]; ok {
 			key = colName[entity.FieldOriginalFieldCode].(string)
@@ -79,6 +84,15 @@ func HandleSingleRow(columnsList []map[string]any, rows *sql.Rows, request entit
 	return item, nil
 }
 
+func isBinaryType(dataType any) bool {
+	switch dataType {
+	case "bytea", "blob", "binary", "varbinary", "tinyblob", "mediumblob", "longblob":
+		return true
+	}
+
+	return false
+}
+
 func IsJSON(input any) bool {
 	var temp any
 
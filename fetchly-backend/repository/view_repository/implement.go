@@ -26,39 +26,28 @@ func New(db *gorm.DB, cfg config.Config) repository_intf.ViewRepository {
 }
 
 func (r *repository) GetViewContentByKeys(ctx context.Context, request entity.GetViewContentByKeysRequest) (resp map[string]entity.DataItem, err error) {
-	tenantCode := request.TenantCode
-	if tenantCode == "" {
-		tenantCode = "NULL"
-	} else {
-		tenantCode = fmt.Sprintf("'%s'", tenantCode)
+	// empty keys are passed as NULL so the function falls back to its defaults
+	var tenantCode, productCode, objectCode any
+	if request.TenantCode != "" {
+		tenantCode = request.TenantCode
 	}
 
-	productCode := request.ProductCode
-	if productCode == "" {
-		productCode = "NULL"
-	} else {
-		productCode = fmt.Sprintf("'%s'", productCode)
+	if request.ProductCode != "" {
+		productCode = request.ProductCode
 	}
 
-	objectCode := request.ObjectCode
-	if objectCode == "" {
-		objectCode = "NULL"
-	} else {
-		objectCode = fmt.Sprintf("'%s'", objectCode)
+	if request.ObjectCode != "" {
+		objectCode = request.ObjectCode
 	}
 
 	viewContentCode := request.ViewContentCode
 	if viewContentCode == "" {
-		viewContentCode = "'default'"
-	} else {
-		viewContentCode = fmt.Sprintf("'%s'", viewContentCode)
+		viewContentCode = "default"
 	}
 
 	layoutType := request.LayoutType
 	if layoutType == "" {
-		layoutType = "'record'"
-	} else {
-		layoutType = fmt.Sprintf("'%s'", layoutType)
+		layoutType = "record"
 	}
 
 	db := r.db
@@ -67,8 +56,8 @@ func (r *repository) GetViewContentByKeys(ctx context.Context, request entity.Ge
 		db.Debug()
 	}
 
-	query := fmt.Sprintf("SELECT * FROM get_view_content_all_v2(%s, %s, %s, %s, %s)", tenantCode, productCode, objectCode, viewContentCode, layoutType)
-	rows, err := db.Raw(query).Rows()
+	query := "SELECT * FROM get_view_content_all_v2(?, ?, ?, ?, ?)"
+	rows, err := db.Raw(query, tenantCode, productCode, objectCode, viewContentCode, layoutType).Rows()
 	if err != nil {
 		return resp, err
 	}